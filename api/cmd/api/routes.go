@@ -73,7 +73,7 @@ func initRouter() http.Handler {
 		r.Use(cors.Handler(cors.Options{
 			AllowedOrigins:   []string{"*"}, // Permissive CORS, because these APIs can be called from web frontend apps.
 			AllowedMethods:   []string{"GET", "DELETE", "OPTIONS", "PATCH", "POST", "PUT"},
-			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Timezone"},
+			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Timezone", "Last-Event-ID", middleware.RecipientTokenHeader, handler.IdempotencyKeyHeader},
 			AllowCredentials: false,
 			ExposedHeaders:   []string{"*"},
 			MaxAge:           300,
@@ -90,10 +90,38 @@ func initRouter() http.Handler {
 			r.Use(middleware.VerifyAPIKeyHasFullScope)
 
 			r.Post("/send", handler.SendNotification(app.APP.Service.Notification))
+			// Many direct sends in one call, for callers that would otherwise spend
+			// the per-IP rate limit one recipient at a time.
+			r.Post("/send/batch", handler.SendNotificationBatch(app.APP.Service.Notification))
 			// Read-by-id: the send is fully async (returns a notification id after
 			// one INSERT), so callers poll this to learn the resolved in-app status
 			// and the email delivery outcome. Mirrors Resend's GET /emails/{id}.
 			r.Get("/{notification_id}", handler.GetNotification(app.APP.Service.Notification))
+			// Replace a delivered notification's payload, e.g. to keep an aggregated
+			// notification current. Bumps its `version`.
+			r.Patch("/{notification_id}", handler.PatchNotification(app.APP.Service.Notification))
+			// Withdraw a send made with `send_at` before it fires.
+			r.Delete("/{notification_id}/schedule", handler.CancelScheduledNotification(app.APP.Service.Notification))
+			// Pull a send back after it went out: out of the feed, email stopped.
+			r.Post("/{notification_id}/recall", handler.RecallNotification(app.APP.Service.Notification))
+			// Why it did or did not reach its recipient, check by check.
+			r.Get("/{notification_id}/explain", handler.ExplainNotification(app.APP.Service.Notification))
+		})
+
+		// The Developer API has no broadcast read surface (see the console's
+		// /broadcasts routes); editing, cancelling, recalling and recovering one
+		// are the exceptions, because the id they need is the one the send
+		// returned.
+		r.Route("/broadcasts", func(r chi.Router) {
+			r.Use(middleware.VerifyAPIKeyHasFullScope)
+
+			r.Patch("/{broadcast_id}", handler.PatchBroadcast(app.APP.Service.Broadcast))
+			r.Delete("/{broadcast_id}/schedule", handler.CancelScheduledBroadcast(app.APP.Service.Broadcast))
+			r.Post("/{broadcast_id}/cancel", handler.CancelBroadcast(app.APP.Service.Broadcast))
+			r.Post("/{broadcast_id}/recall", handler.RecallBroadcast(app.APP.Service.Broadcast))
+			// Recovering a broadcast whose batches ran out of retries.
+			r.Get("/{broadcast_id}/batches/failed", handler.ListFailedBroadcastBatches(app.APP.Service.Broadcast))
+			r.Post("/{broadcast_id}/batches/retry", handler.RetryBroadcastBatches(app.APP.Service.Broadcast))
 		})
 
 		// Project preference (catalog) CRUD. Full-scope only — the catalog
@@ -117,24 +145,40 @@ func initRouter() http.Handler {
 			})
 		})
 
+		// One WebSocket following several recipients' feeds. Either scope: each
+		// recipient is authorized as it is subscribed to, by the same rules as
+		// VerifyRecipientToken.
+		r.Get("/inbox/ws", handler.InboxGateway(app.APP.Service.Notification))
+
 		r.Route("/recipients", func(r chi.Router) {
 			r.With(middleware.VerifyAPIKeyHasFullScope).Group(func(r chi.Router) {
 				r.Post("/", handler.CreateRecipient(app.APP.Service.Recipient))
 				r.Post("/batch", handler.BatchCreateRecipients(app.APP.Service.Recipient))
+				// Outside the /{recipient_external_id} subroute on purpose: asking
+				// why a recipient gets nothing must not create them.
+				r.Get("/{recipient_external_id}/explain", handler.ExplainRecipient(app.APP.Service.Notification))
 			})
 
 			r.Route("/{recipient_external_id}", func(r chi.Router) {
+				// Token check first, so a request it rejects never provisions the
+				// recipient it named. See VerifyRecipientToken.
+				r.Use(middleware.VerifyRecipientToken)
 				r.Use(middleware.CreateRecipientIfNotExists)
 
 				r.With(middleware.VerifyAPIKeyHasFullScope).Group(func(r chi.Router) {
 					r.Get("/", handler.GetRecipient(app.APP.Service.Recipient))
 					r.Patch("/", handler.UpdateRecipient(app.APP.Service.Recipient))
 					r.Delete("/", handler.DeleteRecipient(app.APP.Service.Recipient))
+					// Mint a recipient-bound token for this recipient's client.
+					r.Post("/token", handler.IssueRecipientToken(app.APP.Service.Recipient))
 				})
 
 				r.Route("/notifications", func(r chi.Router) {
 					r.Get("/", handler.ListForRecipient(app.APP.Service.Notification))
 					r.Get("/unread-count", handler.UnreadCountForRecipient(app.APP.Service.Notification))
+					// The feed as Server-Sent Events, for clients that would otherwise
+					// poll unread-count. Resumes from Last-Event-ID.
+					r.Get("/stream", handler.StreamRecipientNotifications(app.APP.Service.Notification))
 					r.Patch("/", handler.UpdateRecipientNotifications(app.APP.Service.Notification))
 					r.Delete("/", handler.DeleteRecipientNotifications(app.APP.Service.Notification))
 				})
@@ -217,6 +261,11 @@ func initRouter() http.Handler {
 					// contract with no external caller would be premature.
 					r.Get("/{broadcast_id}", handler.GetBroadcast(app.APP.Service.Broadcast))
 					r.Get("/{broadcast_id}/tree", handler.GetBroadcastDeliveryTree(app.APP.Service.Broadcast))
+					r.Delete("/{broadcast_id}/schedule", handler.CancelScheduledBroadcastConsole(app.APP.Service.Broadcast))
+					r.Post("/{broadcast_id}/cancel", handler.CancelBroadcastConsole(app.APP.Service.Broadcast))
+					r.Post("/{broadcast_id}/recall", handler.RecallBroadcastConsole(app.APP.Service.Broadcast))
+					r.Get("/{broadcast_id}/batches/failed", handler.ListFailedBroadcastBatchesConsole(app.APP.Service.Broadcast))
+					r.Post("/{broadcast_id}/batches/retry", handler.RetryBroadcastBatchesConsole(app.APP.Service.Broadcast))
 				})
 
 				r.Route("/email-settings", func(r chi.Router) {
@@ -233,6 +282,9 @@ func initRouter() http.Handler {
 					r.Get("/{notification_id}", handler.GetNotificationConsole(app.APP.Service.Notification))
 					r.Get("/{notification_id}/tree", handler.GetNotificationDeliveryTree(app.APP.Service.Notification))
 					r.Get("/{notification_id}/deliveries", handler.ListNotificationDeliveries(app.APP.Service.Notification))
+					r.Delete("/{notification_id}/schedule", handler.CancelScheduledNotificationConsole(app.APP.Service.Notification))
+					r.Post("/{notification_id}/recall", handler.RecallNotificationConsole(app.APP.Service.Notification))
+					r.Get("/{notification_id}/explain", handler.ExplainNotificationConsole(app.APP.Service.Notification))
 				})
 
 				r.Get("/analytics", handler.ProjectAnalytics(app.APP.Service.Notification))
@@ -263,6 +315,7 @@ func initRouter() http.Handler {
 						r.Delete("/", handler.DeleteRecipientConsole(app.APP.Service.Recipient))
 						r.Get("/preferences", handler.GetRecipientPreferencesConsole(app.APP.Service.Preference))
 						r.Put("/preferences", handler.UpsertRecipientPreferences(app.APP.Service.Preference))
+						r.Get("/explain", handler.ExplainRecipientConsole(app.APP.Service.Notification))
 
 						r.Route("/contacts", func(r chi.Router) {
 							r.Get("/", handler.ListRecipientContactsConsole(app.APP.Service.RecipientContact))
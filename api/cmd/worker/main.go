@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
@@ -12,7 +15,9 @@ import (
 	"github.com/mudgallabs/bodhveda/internal/job"
 	"github.com/mudgallabs/bodhveda/internal/job/processor"
 	"github.com/mudgallabs/bodhveda/internal/job/task"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
 	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/bodhveda/internal/service"
 	"github.com/mudgallabs/tantra/logger"
 )
 
@@ -24,6 +29,34 @@ const (
 	webhookEventRetention = 30 * 24 * time.Hour
 	// webhookEventCleanupInterval is how often the cleanup job runs.
 	webhookEventCleanupInterval = 24 * time.Hour
+	// idempotencyKeyCleanupInterval is how often expired send Idempotency-Keys are
+	// pruned. Hourly rather than daily: the retention is only a day, and an
+	// expired row is already ignored by Claim, so this is purely about table size.
+	idempotencyKeyCleanupInterval = time.Hour
+	// expiredNotificationGrace is how long an expired notification is kept after
+	// its expires_at. It is already out of every recipient's feed; the grace is for
+	// the sender, so GET /notifications/{id} and the delivery tree can still say
+	// what happened to it.
+	expiredNotificationGrace = 24 * time.Hour
+	// expiredNotificationCleanupInterval is how often the expiry sweep runs.
+	expiredNotificationCleanupInterval = time.Hour
+	// expiredNotificationCleanupChunk bounds each DELETE of the sweep.
+	expiredNotificationCleanupChunk = 5000
+	// expiredNotificationAnnounceInterval is how often notifications that just
+	// expired are announced to open inbox streams: how late a stream can be to
+	// drop one.
+	expiredNotificationAnnounceInterval = time.Minute
+	// wokenNotificationAnnounceInterval is how often notifications whose snooze
+	// just ended are announced: how late a stream can be to show one again.
+	wokenNotificationAnnounceInterval = time.Minute
+	// emailDigestFlushInterval is how often due email digests are claimed. It is
+	// the lateness bound on a digest past its window, so it stays small.
+	emailDigestFlushInterval = time.Minute
+	// emailDigestFlushChunk bounds each claim of the flush.
+	emailDigestFlushChunk = 500
+	// emailDigestStaleAfter is how long a claimed digest may sit in `sending`
+	// before the flush assumes its task was lost and enqueues it again.
+	emailDigestStaleAfter = 15 * time.Minute
 )
 
 func main() {
@@ -46,6 +79,10 @@ func main() {
 		app.APP.Repository.NotificationDelivery, app.APP.Repository.ProjectEmail,
 	))
 
+	asynqMux.Handle(task.TaskTypeEmailDigest, processor.NewEmailDigestProcessor(
+		app.APP.Repository.EmailDigest, app.APP.Repository.ProjectEmail,
+	))
+
 	asynqMux.Handle(task.TaskTypePrepareBroadcastBatches, processor.NewPrepareBroadcastBatchesProcessor(
 		app.DB, app.ASYNQCLIENT, app.APP.Repository.Preference, app.APP.Repository.Broadcast,
 		app.APP.Repository.BroadcastBatch, app.APP.Service.Billing, app.APP.Service.Notification,
@@ -53,7 +90,7 @@ func main() {
 
 	asynqMux.Handle(task.TaskTypeBroadcastDelivery, processor.NewBroadcastDeliveryProcessor(
 		app.DB, app.APP.Repository.Notification, app.APP.Repository.Broadcast, app.APP.Repository.BroadcastBatch,
-		app.APP.Repository.Preference, app.APP.Service.Notification, app.ASYNQCLIENT,
+		app.APP.Repository.Preference, app.APP.Repository.Recipient, app.APP.Service.Notification, app.ASYNQCLIENT,
 	))
 
 	asynqMux.Handle(task.TaskTypeDeleteRecipientData, processor.NewDeleteRecipientDataProcessor(
@@ -67,13 +104,20 @@ func main() {
 		app.APP.Repository.Recipient,
 	))
 
-	// Retention cleanup for the webhook idempotency ledger (#8). A lightweight
+	// Retention cleanup for the webhook idempotency ledger (#8), the send
+	// Idempotency-Key ledger, and expired notifications, plus the email digest
+	// flush and the expiry announcements. A lightweight
 	// ticker is enough here — a single worker, and DELETE is idempotent — so we
 	// avoid standing up an Asynq scheduler for one periodic job. Cancelled when run()
 	// returns (graceful shutdown).
 	cleanupCtx, cancelCleanup := context.WithCancel(context.Background())
 	defer cancelCleanup()
 	go runWebhookEventCleanup(cleanupCtx, app.APP.Repository.WebhookEvent)
+	go runIdempotencyKeyCleanup(cleanupCtx, app.APP.Repository.IdempotencyKey)
+	go runExpiredNotificationCleanup(cleanupCtx, app.APP.Repository.Notification)
+	go runExpiredNotificationAnnounce(cleanupCtx, app.APP.Service.Notification)
+	go runWokenNotificationAnnounce(cleanupCtx, app.APP.Service.Notification)
+	go runEmailDigestFlush(cleanupCtx, app.APP.Repository.EmailDigest, app.ASYNQCLIENT)
 
 	err = run(asynqServer, asynqMux)
 	if err != nil {
@@ -97,16 +141,169 @@ func runWebhookEventCleanup(ctx context.Context, repo repository.WebhookEventRep
 		}
 	}
 
-	cleanup()
+	runPeriodically(ctx, webhookEventCleanupInterval, cleanup)
+}
+
+// runIdempotencyKeyCleanup prunes send Idempotency-Keys past their retention
+// window, once on start and then hourly, until ctx is cancelled.
+func runIdempotencyKeyCleanup(ctx context.Context, repo repository.IdempotencyKeyRepository) {
+	l := logger.Get()
+
+	cleanup := func() {
+		deleted, err := repo.DeleteOlderThan(ctx, time.Now().Add(-service.IdempotencyKeyRetention))
+		if err != nil {
+			l.Errorf("idempotency_key cleanup: %v", err)
+			return
+		}
+		if deleted > 0 {
+			l.Infof("idempotency_key cleanup: pruned %d rows older than %s", deleted, service.IdempotencyKeyRetention)
+		}
+	}
+
+	runPeriodically(ctx, idempotencyKeyCleanupInterval, cleanup)
+}
+
+// runExpiredNotificationCleanup purges notifications a grace period past their
+// expires_at, once on start and then hourly, until ctx is cancelled. Each run
+// deletes in chunks until a chunk comes back short, so a backlog clears in one
+// run without any single statement being large.
+func runExpiredNotificationCleanup(ctx context.Context, repo repository.NotificationRepository) {
+	l := logger.Get()
+
+	cleanup := func() {
+		cutoff := time.Now().Add(-expiredNotificationGrace)
+
+		var total int64
+		for ctx.Err() == nil {
+			deleted, err := repo.DeleteExpired(ctx, cutoff, expiredNotificationCleanupChunk)
+			if err != nil {
+				l.Errorf("expired notification cleanup: %v", err)
+				break
+			}
+			total += deleted
+			if deleted < expiredNotificationCleanupChunk {
+				break
+			}
+		}
+
+		if total > 0 {
+			l.Infof("expired notification cleanup: purged %d notifications expired before %s", total, cutoff.Format(time.RFC3339))
+		}
+	}
+
+	runPeriodically(ctx, expiredNotificationCleanupInterval, cleanup)
+}
+
+// runExpiredNotificationAnnounce publishes a deleted inbox event for each
+// notification as it expires, every minute until ctx is cancelled. Each run
+// covers the window since the one before, so the windows meet. The first looks
+// one interval back: what expired while the worker was down is already out of
+// the feed, and leaves a client's view on its next list.
+func runExpiredNotificationAnnounce(ctx context.Context, notificationService *service.NotificationService) {
+	l := logger.Get()
+	since := time.Now().Add(-expiredNotificationAnnounceInterval)
+
+	announce := func() {
+		now := time.Now()
+		announced, err := notificationService.PublishInboxExpired(ctx, since, now)
+		if err != nil {
+			l.Errorf("expired notification announce: %v", err)
+		}
+		if announced > 0 {
+			l.Infof("expired notification announce: %d notifications", announced)
+		}
+		since = now
+	}
+
+	runPeriodically(ctx, expiredNotificationAnnounceInterval, announce)
+}
+
+// runWokenNotificationAnnounce publishes a created inbox event for each
+// notification as its snooze ends, every minute until ctx is cancelled, on the
+// same windows as runExpiredNotificationAnnounce. A snooze that ended while the
+// worker was down is not announced; the stream's replay on reconnect has it.
+func runWokenNotificationAnnounce(ctx context.Context, notificationService *service.NotificationService) {
+	l := logger.Get()
+	since := time.Now().Add(-wokenNotificationAnnounceInterval)
+
+	announce := func() {
+		now := time.Now()
+		announced, err := notificationService.PublishInboxWoken(ctx, since, now)
+		if err != nil {
+			l.Errorf("woken notification announce: %v", err)
+		}
+		if announced > 0 {
+			l.Infof("woken notification announce: %d notifications", announced)
+		}
+		since = now
+	}
+
+	runPeriodically(ctx, wokenNotificationAnnounceInterval, announce)
+}
+
+// runEmailDigestFlush claims digests whose window has closed and enqueues one
+// email:digest task for each, every minute until ctx is cancelled. The claim is
+// what stops a digest taking more items, so it happens here and not in the task.
+//
+// An enqueue that fails leaves the digest `sending`; ClaimDue picks it up again
+// once it is stale. The fixed task id makes that safe if the first enqueue did
+// in fact land.
+func runEmailDigestFlush(ctx context.Context, repo repository.EmailDigestRepository, client *asynq.Client) {
+	l := logger.Get()
+
+	flush := func() {
+		var total int
+		for ctx.Err() == nil {
+			now := time.Now()
+			ids, err := repo.ClaimDue(ctx, now, now.Add(-emailDigestStaleAfter), emailDigestFlushChunk)
+			if err != nil {
+				l.Errorf("email digest flush: %v", err)
+				break
+			}
+
+			for _, id := range ids {
+				body, err := json.Marshal(dto.EmailDigestTaskPayload{DigestID: id})
+				if err != nil {
+					l.Errorf("email digest flush: marshal digest %d: %v", id, err)
+					continue
+				}
+				_, err = client.Enqueue(
+					asynq.NewTask(task.TaskTypeEmailDigest, body),
+					asynq.TaskID(fmt.Sprintf("email-digest-%d", id)),
+					asynq.MaxRetry(5),
+				)
+				if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+					l.Errorf("email digest flush: enqueue digest %d: %v", id, err)
+				}
+			}
+
+			total += len(ids)
+			if len(ids) < emailDigestFlushChunk {
+				break
+			}
+		}
+
+		if total > 0 {
+			l.Infof("email digest flush: enqueued %d digests", total)
+		}
+	}
+
+	runPeriodically(ctx, emailDigestFlushInterval, flush)
+}
+
+// runPeriodically runs fn once immediately and then on every tick of interval,
+// until ctx is cancelled. The worker's housekeeping jobs all share it.
+func runPeriodically(ctx context.Context, interval time.Duration, fn func()) {
+	fn()
 
-	ticker := time.NewTicker(webhookEventCleanupInterval)
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			cleanup()
+			fn()
 		}
 	}
 }
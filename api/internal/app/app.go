@@ -9,11 +9,13 @@ import (
 	jobs "github.com/mudgallabs/bodhveda/internal/job"
 	"github.com/mudgallabs/bodhveda/internal/model/repository"
 	"github.com/mudgallabs/bodhveda/internal/pg"
+	"github.com/mudgallabs/bodhveda/internal/rdb"
 	"github.com/mudgallabs/bodhveda/internal/service"
 	"github.com/mudgallabs/tantra/auth/oauth"
 	"github.com/mudgallabs/tantra/auth/session"
 	"github.com/mudgallabs/tantra/dbx"
 	"github.com/mudgallabs/tantra/logger"
+	"github.com/redis/go-redis/v9"
 )
 
 var APP *App
@@ -50,6 +52,9 @@ type services struct {
 type repositories struct {
 	APIKey               repository.APIKeyRepository
 	Broadcast            repository.BroadcastRepository
+	EmailDigest          repository.EmailDigestRepository
+	FrequencyCounter     repository.FrequencyCounterRepository
+	IdempotencyKey       repository.IdempotencyKeyRepository
 	BroadcastBatch       repository.BroadcastBatchRepository
 	Notification         repository.NotificationRepository
 	NotificationDelivery repository.NotificationDeliveryRepository
@@ -68,6 +73,10 @@ type repositories struct {
 
 var ASYNQCLIENT *asynq.Client
 
+// REDIS is a plain Redis client for the repositories that live in Redis rather
+// than Postgres (see package rdb). Same server as Asynq.
+var REDIS redis.UniversalClient
+
 func Init() {
 	env.Init("../.env")
 
@@ -92,10 +101,20 @@ func Init() {
 		panic(err)
 	}
 
+	REDIS, err = rdb.NewClient(env.RedisURL)
+	if err != nil {
+		logger.Get().Errorf("failed to create Redis client: %v", err)
+		panic(err)
+	}
+
 	oauth.InitGoogle(env.GOOGLE_CLIENT_ID, env.GOOGLE_CLIENT_SECRET, env.GOOGLE_REDIRECT_URL)
 
 	apikeyRepository := pg.NewAPIKeyRepo(db)
+	idempotencyKeyRepository := pg.NewIdempotencyKeyRepo(db)
 	broadcastRepository := pg.NewBroadcastRepo(db)
+	emailDigestRepository := pg.NewEmailDigestRepo(db)
+	frequencyCounterRepository := rdb.NewFrequencyCounterRepo(REDIS)
+	inboxEventRepository := rdb.NewInboxEventRepo(REDIS)
 	broadcastBatchRepository := pg.NewBroadcastBatchRepo(db)
 	notificationRepository := pg.NewNotificationRepo(db)
 	notificationDeliveryRepository := pg.NewNotificationDeliveryRepo(db)
@@ -116,14 +135,15 @@ func Init() {
 	apikeyService := service.NewAPIKeyService(apikeyRepository, projectRepository)
 	billingService := service.NewBillingService(db, projectRepository, userSubscriptionRepository,
 		usageLogRepository, usageAggregateRepository)
-	broadcastService := service.NewBroadcastService(broadcastRepository, notificationRepository)
+	broadcastService := service.NewBroadcastService(db, broadcastRepository, broadcastBatchRepository, notificationRepository,
+		billingService, ASYNQCLIENT, inboxEventRepository)
 	preferenceService := service.NewProjectPreferenceService(preferenceRepository, recipientRepository)
 	recipientService := service.NewRecipientService(recipientRepository, ASYNQCLIENT)
 	recipientContactService := service.NewRecipientContactService(recipientContactRepository, recipientRepository)
 	notificationService := service.NewNotificationService(notificationRepository, recipientRepository,
 		preferenceRepository, broadcastRepository, broadcastBatchRepository, notificationDeliveryRepository,
-		recipientContactRepository, projectEmailSettingsRepository, projectRepository,
-		billingService, recipientService, ASYNQCLIENT)
+		recipientContactRepository, projectEmailSettingsRepository, projectRepository, idempotencyKeyRepository,
+		frequencyCounterRepository, inboxEventRepository, billingService, recipientService, ASYNQCLIENT)
 	projectService := service.NewProjectService(projectRepository, notificationService, recipientService, ASYNQCLIENT)
 	projectEmailSettingsService := service.NewProjectEmailSettingsService(projectEmailSettingsRepository)
 	emailWebhookService := service.NewEmailWebhookService(projectEmailSettingsRepository, notificationDeliveryRepository, webhookEventRepository, preferenceService)
@@ -151,6 +171,9 @@ func Init() {
 	repositories := repositories{
 		APIKey:               apikeyRepository,
 		Broadcast:            broadcastRepository,
+		EmailDigest:          emailDigestRepository,
+		FrequencyCounter:     frequencyCounterRepository,
+		IdempotencyKey:       idempotencyKeyRepository,
 		BroadcastBatch:       broadcastBatchRepository,
 		Notification:         notificationRepository,
 		NotificationDelivery: notificationDeliveryRepository,
@@ -193,4 +216,11 @@ func Close() {
 			logger.Get().Errorf("failed to close Asynq client: %v", err)
 		}
 	}
+
+	if REDIS != nil {
+		err := REDIS.Close()
+		if err != nil {
+			logger.Get().Errorf("failed to close Redis client: %v", err)
+		}
+	}
 }
@@ -321,8 +321,8 @@ func newDeps(b *testing.B) *deps {
 	notificationService := service.NewNotificationService(
 		notificationRepo, pg.NewRecipientRepo(p), preferenceRepo, broadcastRepo, batchRepo,
 		pg.NewNotificationDeliveryRepo(p), pg.NewRecipientContactRepo(p),
-		pg.NewProjectEmailSettingsRepo(p), pg.NewProjectRepo(p),
-		nil, nil, nil,
+		pg.NewProjectEmailSettingsRepo(p), pg.NewProjectRepo(p), pg.NewIdempotencyKeyRepo(p),
+		nil, nil, nil, nil, nil,
 	)
 
 	return &deps{
@@ -331,7 +331,7 @@ func newDeps(b *testing.B) *deps {
 		batch:      batchRepo,
 		preference: preferenceRepo,
 		delivery: processor.NewBroadcastDeliveryProcessor(
-			p, notificationRepo, broadcastRepo, batchRepo, preferenceRepo, notificationService, nil,
+			p, notificationRepo, broadcastRepo, batchRepo, preferenceRepo, pg.NewRecipientRepo(p), notificationService, nil,
 		),
 	}
 }
@@ -376,12 +376,12 @@ func (d *deps) prepare(ctx context.Context, fx *fixture) (*entity.Broadcast, []*
 			return fmt.Errorf("lock broadcast: %w", err)
 		}
 
-		extIDs, err := d.preference.ListEligibleRecipientExtIDsForBroadcast(ctx, fx.projectID, benchTarget, enum.MediumInApp)
+		extIDs, err := d.preference.ListEligibleRecipientExtIDsForBroadcast(ctx, fx.projectID, benchTarget, enum.MediumInApp, nil)
 		if err != nil {
 			return fmt.Errorf("list eligible recipients: %w", err)
 		}
 
-		audience, err := d.preference.CountBroadcastAudience(ctx, fx.projectID, benchTarget, enum.MediumInApp)
+		audience, err := d.preference.CountBroadcastAudience(ctx, fx.projectID, benchTarget, enum.MediumInApp, nil)
 		if err != nil {
 			return fmt.Errorf("count audience: %w", err)
 		}
@@ -586,7 +586,7 @@ func BenchmarkAudienceQuery(b *testing.B) {
 
 		b.Run(fmt.Sprintf("list/recipients=%d", n), func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
-				extIDs, err := d.preference.ListEligibleRecipientExtIDsForBroadcast(ctx, fx.projectID, benchTarget, enum.MediumInApp)
+				extIDs, err := d.preference.ListEligibleRecipientExtIDsForBroadcast(ctx, fx.projectID, benchTarget, enum.MediumInApp, nil)
 				if err != nil {
 					b.Fatalf("list eligible: %v", err)
 				}
@@ -598,7 +598,7 @@ func BenchmarkAudienceQuery(b *testing.B) {
 
 		b.Run(fmt.Sprintf("count/recipients=%d", n), func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
-				audience, err := d.preference.CountBroadcastAudience(ctx, fx.projectID, benchTarget, enum.MediumInApp)
+				audience, err := d.preference.CountBroadcastAudience(ctx, fx.projectID, benchTarget, enum.MediumInApp, nil)
 				if err != nil {
 					b.Fatalf("count audience: %v", err)
 				}
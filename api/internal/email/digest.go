@@ -0,0 +1,69 @@
+package email
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DigestItemsPlaceholder marks where a project's digest wrapper HTML takes
+	// the rendered items. A wrapper without it is refused on save.
+	DigestItemsPlaceholder = "{{digest_items}}"
+	// DigestCountPlaceholder is replaced by the number of items, in the subject
+	// and in the wrapper.
+	DigestCountPlaceholder = "{{digest_count}}"
+
+	defaultDigestSubject = "You have " + DigestCountPlaceholder + " new notifications"
+	defaultDigestHTML    = "<!doctype html><html><body>" + DigestItemsPlaceholder + "</body></html>"
+)
+
+// DigestItem is one email folded into a digest, as its send rendered it.
+type DigestItem struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// RenderDigest builds the subject, HTML and plain-text bodies of a digest
+// email. subjectTemplate and htmlTemplate are the project's (nil or blank falls
+// back to a plain default).
+//
+// Each item's HTML is inserted as-is — it is the sender's own markup, exactly
+// what would have gone out on its own — under its subject, which is escaped.
+func RenderDigest(subjectTemplate, htmlTemplate *string, items []DigestItem) (subject, htmlBody, text string) {
+	count := strconv.Itoa(len(items))
+
+	subject = defaultDigestSubject
+	if subjectTemplate != nil && strings.TrimSpace(*subjectTemplate) != "" {
+		subject = *subjectTemplate
+	}
+	subject = strings.ReplaceAll(subject, DigestCountPlaceholder, count)
+
+	wrapper := defaultDigestHTML
+	if htmlTemplate != nil && strings.TrimSpace(*htmlTemplate) != "" {
+		wrapper = *htmlTemplate
+	}
+
+	var body, plain strings.Builder
+	for i, item := range items {
+		fmt.Fprintf(&body, `<div class="bodhveda-digest-item"><h3>%s</h3>%s</div>`, html.EscapeString(item.Subject), item.HTML)
+
+		if i > 0 {
+			plain.WriteString("\n\n---\n\n")
+		}
+		plain.WriteString(item.Subject)
+		if item.Text != "" {
+			plain.WriteString("\n\n")
+			plain.WriteString(item.Text)
+		}
+	}
+
+	// Count first: an item's own HTML could contain the count placeholder, and
+	// substituting after insertion would rewrite the sender's content.
+	htmlBody = strings.ReplaceAll(wrapper, DigestCountPlaceholder, count)
+	htmlBody = strings.Replace(htmlBody, DigestItemsPlaceholder, body.String(), 1)
+
+	return subject, htmlBody, plain.String()
+}
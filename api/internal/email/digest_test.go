@@ -0,0 +1,49 @@
+package email
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderDigest_Defaults(t *testing.T) {
+	subject, html, text := RenderDigest(nil, nil, []DigestItem{
+		{Subject: "Invoice <paid>", HTML: "<p>Thanks</p>", Text: "Thanks"},
+		{Subject: "New comment", HTML: "<p>Nice post</p>"},
+	})
+
+	if subject != "You have 2 new notifications" {
+		t.Errorf("subject = %q", subject)
+	}
+	if !strings.Contains(html, "<h3>Invoice &lt;paid&gt;</h3><p>Thanks</p>") {
+		t.Errorf("item subject must be escaped and its html kept as-is: %s", html)
+	}
+	if strings.Contains(html, DigestItemsPlaceholder) {
+		t.Errorf("placeholder left in body: %s", html)
+	}
+	if text != "Invoice <paid>\n\nThanks\n\n---\n\nNew comment" {
+		t.Errorf("text = %q", text)
+	}
+}
+
+// TestRenderDigest_ProjectWrapper — the project's wrapper is used verbatim
+// around the items, and a blank subject falls back rather than sending an email
+// with no subject.
+func TestRenderDigest_ProjectWrapper(t *testing.T) {
+	wrapper := `<header>Acme</header><main>` + DigestItemsPlaceholder + `</main><footer>` + DigestCountPlaceholder + ` updates</footer>`
+	blank := "   "
+
+	subject, html, _ := RenderDigest(&blank, &wrapper, []DigestItem{
+		{Subject: "a", HTML: "<p>" + DigestCountPlaceholder + "</p>"},
+	})
+
+	if subject != "You have 1 new notifications" {
+		t.Errorf("subject = %q, want the default", subject)
+	}
+	if !strings.HasPrefix(html, "<header>Acme</header><main><div") || !strings.HasSuffix(html, "</main><footer>1 updates</footer>") {
+		t.Errorf("wrapper not applied: %s", html)
+	}
+	// The sender's own content is not a template.
+	if !strings.Contains(html, "<p>"+DigestCountPlaceholder+"</p>") {
+		t.Errorf("item content was rewritten: %s", html)
+	}
+}
@@ -1,7 +1,9 @@
 package env
 
 import (
+	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -32,6 +34,17 @@ var (
 	// Discord server, and means a missing var degrades to log-only rather than
 	// failing startup.
 	AlertDiscordWebhookURL string
+
+	// WorkerConcurrency is how many tasks the worker runs at once
+	// (BODHVEDA_WORKER_CONCURRENCY, default 10).
+	WorkerConcurrency int
+	// QueueWeightCritical / QueueWeightNormal / QueueWeightBulk are the relative
+	// shares of WorkerConcurrency each priority lane's queue gets
+	// (BODHVEDA_QUEUE_WEIGHT_*, default 6/3/1). Weighted, not strict: a busy
+	// critical queue slows bulk work down but never starves it outright.
+	QueueWeightCritical int
+	QueueWeightNormal   int
+	QueueWeightBulk     int
 )
 
 func IsProd() bool {
@@ -62,6 +75,10 @@ func Init(path string) {
 	CipherKey = os.Getenv("BODHVEDA_API_CIPHER_KEY")
 	HashKey = os.Getenv("BODHVEDA_API_HASH_KEY")
 	AlertDiscordWebhookURL = os.Getenv("BODHVEDA_ALERT_DISCORD_WEBHOOK_URL")
+	WorkerConcurrency = positiveInt("BODHVEDA_WORKER_CONCURRENCY", 10)
+	QueueWeightCritical = positiveInt("BODHVEDA_QUEUE_WEIGHT_CRITICAL", 6)
+	QueueWeightNormal = positiveInt("BODHVEDA_QUEUE_WEIGHT_NORMAL", 3)
+	QueueWeightBulk = positiveInt("BODHVEDA_QUEUE_WEIGHT_BULK", 1)
 
 	// TODO: We should validate the environment variables here to ensure they are set correctly.
 
@@ -74,3 +91,20 @@ func Init(path string) {
 		}
 	}
 }
+
+// positiveInt reads an optional positive integer, falling back when it is unset.
+// A value that is set but unusable panics rather than quietly running with the
+// default: a typo'd weight would otherwise look like a tuning that did nothing.
+func positiveInt(key string, fallback int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(raw)
+	if err != nil || n <= 0 {
+		panic(fmt.Sprintf("%s must be a positive integer, got %q", key, raw))
+	}
+
+	return n
+}
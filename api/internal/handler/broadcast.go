@@ -2,11 +2,14 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 
+	"github.com/mudgallabs/bodhveda/internal/middleware"
 	"github.com/mudgallabs/bodhveda/internal/model/dto"
 	"github.com/mudgallabs/bodhveda/internal/service"
 	"github.com/mudgallabs/tantra/httpx"
+	"github.com/mudgallabs/tantra/jsonx"
 )
 
 func ListBroadcasts(s *service.BroadcastService) http.HandlerFunc {
@@ -90,3 +93,291 @@ func GetBroadcastDeliveryTree(s *service.BroadcastService) http.HandlerFunc {
 		httpx.SuccessResponse(w, r, http.StatusOK, "", result)
 	}
 }
+
+// CancelScheduledBroadcast (developer API) withdraws a scheduled broadcast
+// before its send_at.
+func CancelScheduledBroadcast(s *service.BroadcastService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+
+		broadcastID, err := httpx.ParamInt(r, "broadcast_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid broadcast ID"))
+			return
+		}
+
+		result, errKind, err := s.CancelScheduled(ctx, apiKey.ProjectID, broadcastID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "Scheduled broadcast cancelled.", result)
+	}
+}
+
+// PatchBroadcast (developer API) replaces a broadcast's payload in every inbox
+// it reached, and for the recipients it has not reached yet.
+func PatchBroadcast(s *service.BroadcastService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+
+		broadcastID, err := httpx.ParamInt(r, "broadcast_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid broadcast ID"))
+			return
+		}
+
+		var payload dto.PatchBroadcastPayload
+		if err := jsonx.DecodeJSONRequest(&payload, r); err != nil {
+			httpx.MalformedJSONResponse(w, r, err)
+			return
+		}
+
+		payload.ProjectID = apiKey.ProjectID
+		payload.BroadcastID = broadcastID
+
+		result, errKind, err := s.Patch(ctx, payload)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "Broadcast updated.", result)
+	}
+}
+
+// CancelBroadcast (developer API) stops a broadcast that is scheduled or still
+// fanning out, and refunds the recipients it will now never reach.
+func CancelBroadcast(s *service.BroadcastService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+
+		broadcastID, err := httpx.ParamInt(r, "broadcast_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid broadcast ID"))
+			return
+		}
+
+		result, errKind, err := s.Cancel(ctx, apiKey.ProjectID, broadcastID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "Broadcast cancelled.", result)
+	}
+}
+
+// CancelBroadcastConsole is CancelBroadcast for the console.
+func CancelBroadcastConsole(s *service.BroadcastService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		projectID, err := httpx.ParamInt(r, "project_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid project ID"))
+			return
+		}
+
+		broadcastID, err := httpx.ParamInt(r, "broadcast_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid broadcast ID"))
+			return
+		}
+
+		result, errKind, err := s.Cancel(ctx, projectID, broadcastID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "Broadcast cancelled.", result)
+	}
+}
+
+// RecallBroadcast (developer API) pulls a broadcast back out of every inbox it
+// reached and stops whatever of it has not gone out yet.
+func RecallBroadcast(s *service.BroadcastService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+
+		broadcastID, err := httpx.ParamInt(r, "broadcast_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid broadcast ID"))
+			return
+		}
+
+		result, errKind, err := s.Recall(ctx, apiKey.ProjectID, broadcastID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "Broadcast recalled.", result)
+	}
+}
+
+// RecallBroadcastConsole is RecallBroadcast for the console.
+func RecallBroadcastConsole(s *service.BroadcastService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		projectID, err := httpx.ParamInt(r, "project_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid project ID"))
+			return
+		}
+
+		broadcastID, err := httpx.ParamInt(r, "broadcast_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid broadcast ID"))
+			return
+		}
+
+		result, errKind, err := s.Recall(ctx, projectID, broadcastID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "Broadcast recalled.", result)
+	}
+}
+
+// CancelScheduledBroadcastConsole is CancelScheduledBroadcast for the console.
+func CancelScheduledBroadcastConsole(s *service.BroadcastService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		projectID, err := httpx.ParamInt(r, "project_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid project ID"))
+			return
+		}
+
+		broadcastID, err := httpx.ParamInt(r, "broadcast_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid broadcast ID"))
+			return
+		}
+
+		result, errKind, err := s.CancelScheduled(ctx, projectID, broadcastID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "Scheduled broadcast cancelled.", result)
+	}
+}
+
+// ListFailedBroadcastBatches (developer API) lists the batches keeping a
+// broadcast from completing.
+func ListFailedBroadcastBatches(s *service.BroadcastService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+
+		broadcastID, err := httpx.ParamInt(r, "broadcast_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid broadcast ID"))
+			return
+		}
+
+		result, errKind, err := s.ListFailedBatches(ctx, apiKey.ProjectID, broadcastID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "", result)
+	}
+}
+
+// ListFailedBroadcastBatchesConsole is ListFailedBroadcastBatches for the console.
+func ListFailedBroadcastBatchesConsole(s *service.BroadcastService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		projectID, err := httpx.ParamInt(r, "project_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid project ID"))
+			return
+		}
+
+		broadcastID, err := httpx.ParamInt(r, "broadcast_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid broadcast ID"))
+			return
+		}
+
+		result, errKind, err := s.ListFailedBatches(ctx, projectID, broadcastID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "", result)
+	}
+}
+
+// RetryBroadcastBatches (developer API) re-enqueues a broadcast's failed batches.
+func RetryBroadcastBatches(s *service.BroadcastService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+
+		broadcastID, err := httpx.ParamInt(r, "broadcast_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid broadcast ID"))
+			return
+		}
+
+		result, errKind, err := s.RetryFailedBatches(ctx, apiKey.ProjectID, broadcastID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, retryBatchesMessage(result), result)
+	}
+}
+
+// RetryBroadcastBatchesConsole is RetryBroadcastBatches for the console.
+func RetryBroadcastBatchesConsole(s *service.BroadcastService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		projectID, err := httpx.ParamInt(r, "project_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid project ID"))
+			return
+		}
+
+		broadcastID, err := httpx.ParamInt(r, "broadcast_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid broadcast ID"))
+			return
+		}
+
+		result, errKind, err := s.RetryFailedBatches(ctx, projectID, broadcastID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, retryBatchesMessage(result), result)
+	}
+}
+
+func retryBatchesMessage(result *dto.RetryBroadcastBatchesResult) string {
+	if len(result.Batches) == 1 {
+		return "1 batch re-enqueued."
+	}
+	return fmt.Sprintf("%d batches re-enqueued.", len(result.Batches))
+}
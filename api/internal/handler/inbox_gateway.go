@@ -0,0 +1,491 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mudgallabs/bodhveda/internal/middleware"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/bodhveda/internal/service"
+	"github.com/mudgallabs/tantra/logger"
+)
+
+const (
+	// gatewayMaxSubscriptions is how many recipients one connection may follow:
+	// a handful of signed-in accounts, with room to spare, and not a way to tail
+	// a whole project down one socket.
+	gatewayMaxSubscriptions = 20
+	// gatewaySendBuffer is how many messages may wait for a slow client. A
+	// client that falls further behind is disconnected rather than buffered for,
+	// and resumes with last_event_id.
+	gatewaySendBuffer = 256
+	// gatewayMaxMessageSize bounds a client message. The largest legitimate one
+	// is an ack naming dto.GatewayMaxAckIDs ids.
+	gatewayMaxMessageSize = 4 << 10
+
+	gatewayWriteWait    = 10 * time.Second
+	gatewayPongWait     = 60 * time.Second
+	gatewayPingInterval = gatewayPongWait * 9 / 10
+)
+
+// The handshake is authenticated by the API key in its Authorization header
+// (APIKeyBasedAuthMiddleware runs before the upgrade), never by a cookie or
+// other ambient credential, so a page on another origin gains nothing by
+// opening one and the origin is not checked. Browsers cannot set that header
+// on a WebSocket handshake, so in practice the clients are native apps and
+// servers; a browser follows a feed over the SSE stream instead.
+var gatewayUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// InboxGateway upgrades to the inbox WebSocket: one connection following the
+// feeds of several recipients, for clients like a desktop app signed into more
+// than one account. See dto.GatewayRequest for the protocol.
+//
+// ⚠️ The connection is served on its own goroutine and the handler returns at
+// once. The router's Timeout middleware cancels every request at 60s and then
+// writes a 504 to it; holding a hijacked connection in the handler would hit
+// both. The connection gets the request's values but not its cancellation.
+func InboxGateway(s *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		apiKey := middleware.GetAPIKeyFromContext(r.Context())
+
+		// The session middleware's writer hides Hijack behind Unwrap, which the
+		// upgrader does not look through.
+		conn, err := gatewayUpgrader.Upgrade(hijacker{w}, r, nil)
+		if err != nil {
+			// The upgrader has already replied.
+			return
+		}
+
+		go serveInboxGateway(context.WithoutCancel(r.Context()), conn, s, apiKey)
+	}
+}
+
+type hijacker struct {
+	http.ResponseWriter
+}
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(h.ResponseWriter).Hijack()
+}
+
+// inboxGateway is one connection. The read loop handles requests one at a
+// time and owns the listener; dispatch forwards the listener's events to the
+// feeds in subs; the write loop is the only writer, as the websocket package
+// requires.
+type inboxGateway struct {
+	conn   *websocket.Conn
+	svc    *service.NotificationService
+	apiKey *entity.APIKey
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	out    chan any
+
+	// listener is the connection's one listener on the bus, however many
+	// recipients it follows. Opened by the first subscribe.
+	listener repository.InboxListener
+
+	mu   sync.Mutex
+	subs map[string]*gatewayFeed
+
+	closeOnce   sync.Once
+	closeCode   int
+	closeReason string
+}
+
+func serveInboxGateway(ctx context.Context, conn *websocket.Conn, svc *service.NotificationService, apiKey *entity.APIKey) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	g := &inboxGateway{
+		conn:   conn,
+		svc:    svc,
+		apiKey: apiKey,
+		ctx:    ctx,
+		cancel: cancel,
+		out:    make(chan any, gatewaySendBuffer),
+		subs:   map[string]*gatewayFeed{},
+	}
+
+	go g.writeLoop()
+	g.readLoop()
+}
+
+// close ends the connection with code, once; the write loop sends the close
+// frame. Everything else stops on the cancelled context.
+func (g *inboxGateway) close(code int, reason string) {
+	g.closeOnce.Do(func() {
+		g.closeCode, g.closeReason = code, reason
+		g.cancel()
+	})
+}
+
+// send queues msg without ever blocking. A full queue means the client is not
+// reading, and waiting on it would stall every feed on the connection, so it
+// is dropped instead — with 1013 (try again later), which a client should
+// answer by reconnecting and resubscribing with last_event_id.
+func (g *inboxGateway) send(msg any) {
+	select {
+	case g.out <- msg:
+	case <-g.ctx.Done():
+	default:
+		g.close(websocket.CloseTryAgainLater, "client is not keeping up")
+	}
+}
+
+func (g *inboxGateway) reply(req *dto.GatewayRequest, typ dto.GatewayReplyType) {
+	g.send(&dto.GatewayReply{Type: typ, ID: req.ID, RecipientID: req.RecipientID})
+}
+
+func (g *inboxGateway) replyError(req *dto.GatewayRequest, code, message string) {
+	g.send(&dto.GatewayReply{
+		Type:        dto.GatewayErrorReply,
+		ID:          req.ID,
+		RecipientID: req.RecipientID,
+		Error:       &dto.GatewayError{Code: code, Message: message},
+	})
+}
+
+func (g *inboxGateway) writeLoop() {
+	defer g.conn.Close()
+
+	ping := time.NewTicker(gatewayPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-g.ctx.Done():
+			msg := websocket.FormatCloseMessage(g.closeCode, g.closeReason)
+			_ = g.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(gatewayWriteWait))
+			return
+
+		case msg := <-g.out:
+			_ = g.conn.SetWriteDeadline(time.Now().Add(gatewayWriteWait))
+			if err := g.conn.WriteJSON(msg); err != nil {
+				g.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+
+		case <-ping.C:
+			if err := g.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(gatewayWriteWait)); err != nil {
+				g.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		}
+	}
+}
+
+// readLoop handles requests until the connection goes. A client that stops
+// answering pings is cut off when its read deadline passes.
+func (g *inboxGateway) readLoop() {
+	defer g.close(websocket.CloseNormalClosure, "")
+
+	g.conn.SetReadLimit(gatewayMaxMessageSize)
+	_ = g.conn.SetReadDeadline(time.Now().Add(gatewayPongWait))
+	g.conn.SetPongHandler(func(string) error {
+		return g.conn.SetReadDeadline(time.Now().Add(gatewayPongWait))
+	})
+
+	for {
+		_, data, err := g.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = g.conn.SetReadDeadline(time.Now().Add(gatewayPongWait))
+
+		var req dto.GatewayRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			g.replyError(&req, dto.GatewayErrInvalidMessage, "Messages are JSON objects with a type.")
+			continue
+		}
+		if err := req.Validate(); err != nil {
+			g.replyError(&req, dto.GatewayErrInvalidMessage, err.Error())
+			continue
+		}
+
+		switch req.Type {
+		case dto.GatewaySubscribe:
+			g.subscribe(&req)
+		case dto.GatewayUnsubscribe:
+			g.unsubscribe(&req)
+		case dto.GatewayAck:
+			g.ack(&req)
+		case dto.GatewayPing:
+			g.reply(&req, dto.GatewayPong)
+		}
+	}
+}
+
+// subscribe follows a recipient's feed. The recipient is authorized exactly as
+// the recipient routes authorize the one in their path, with the token in the
+// message instead of a header. Like the SSE stream, the live subscription opens
+// before the replay, and the replay's live copies are dropped.
+func (g *inboxGateway) subscribe(req *dto.GatewayRequest) {
+	g.mu.Lock()
+	_, subscribed := g.subs[req.RecipientID]
+	count := len(g.subs)
+	g.mu.Unlock()
+
+	if subscribed {
+		g.reply(req, dto.GatewaySubscribed)
+		return
+	}
+	if count >= gatewayMaxSubscriptions {
+		g.replyError(req, dto.GatewayErrSubscriptionCap, fmt.Sprintf("A connection can follow at most %d recipients. Unsubscribe from one first.", gatewayMaxSubscriptions))
+		return
+	}
+
+	expiresAt, refused, err := middleware.AuthorizeRecipient(g.ctx, g.apiKey, req.RecipientID, req.Token)
+	if err != nil {
+		logger.FromCtx(g.ctx).Errorw("inbox gateway authorize", "error", err)
+		g.replyError(req, dto.GatewayErrInternal, "Could not subscribe. Try again.")
+		return
+	}
+	if refused != nil {
+		code := dto.GatewayErrUnauthorized
+		if refused.Forbidden {
+			code = dto.GatewayErrForbidden
+		}
+		msg := refused.Message
+		if errors.Is(refused.Err, middleware.ErrRecipientTokenRequired) {
+			msg += " Send it as the subscribe message's token."
+		}
+		g.replyError(req, code, msg)
+		return
+	}
+
+	if g.listener == nil {
+		listener, _, err := g.svc.ListenForRecipients(g.ctx, g.apiKey.ProjectID)
+		if err != nil {
+			logger.FromCtx(g.ctx).Errorw("inbox gateway listen", "error", err)
+			g.replyError(req, dto.GatewayErrInternal, "Could not subscribe. Try again.")
+			return
+		}
+		g.mu.Lock()
+		g.listener = listener
+		g.mu.Unlock()
+		go g.dispatch(listener.Events())
+	}
+
+	// The feed is in subs before it is followed, so dispatch holds on to its
+	// first live events until the replay has gone out.
+	feed := &gatewayFeed{g: g, recipientID: req.RecipientID}
+	g.mu.Lock()
+	g.subs[req.RecipientID] = feed
+	if !expiresAt.IsZero() {
+		feed.expiry = time.AfterFunc(time.Until(expiresAt), func() { g.expire(feed) })
+	}
+	g.mu.Unlock()
+
+	if err := g.listener.Follow(g.ctx, req.RecipientID); err != nil {
+		g.drop(req.RecipientID, feed)
+		logger.FromCtx(g.ctx).Errorw("inbox gateway subscribe", "error", err)
+		g.replyError(req, dto.GatewayErrInternal, "Could not subscribe. Try again.")
+		return
+	}
+
+	var replay []*dto.Notification
+	var truncated bool
+	if req.LastEventID > 0 {
+		replay, truncated, _, err = g.svc.ReplayForRecipient(g.ctx, g.apiKey.ProjectID, req.RecipientID, req.LastEventID)
+		if err != nil {
+			g.drop(req.RecipientID, feed)
+			logger.FromCtx(g.ctx).Errorw("inbox gateway replay", "error", err)
+			g.replyError(req, dto.GatewayErrInternal, "Could not subscribe. Try again.")
+			return
+		}
+	}
+
+	g.reply(req, dto.GatewaySubscribed)
+
+	g.mu.Lock()
+	feed.replayed = newReplayed(replay)
+	if truncated {
+		feed.push(&dto.InboxEvent{Type: dto.InboxEventReset})
+	}
+	for _, n := range replay {
+		feed.push(&dto.InboxEvent{Type: dto.InboxEventCreated, Notification: n})
+	}
+	feed.live = true
+	for _, event := range feed.pending {
+		if !feed.replayed.duplicate(event) {
+			feed.push(event)
+		}
+	}
+	feed.pending = nil
+	g.mu.Unlock()
+
+	feed.unreadCount()
+}
+
+func (g *inboxGateway) unsubscribe(req *dto.GatewayRequest) {
+	g.drop(req.RecipientID, nil)
+	g.reply(req, dto.GatewayUnsubscribed)
+}
+
+// expire ends a subscription whose recipient token has run out, as the
+// recipient routes would refuse the token's next request. The client is told
+// which recipient, so it can subscribe again with a new token and
+// last_event_id.
+func (g *inboxGateway) expire(feed *gatewayFeed) {
+	if g.ctx.Err() != nil || !g.drop(feed.recipientID, feed) {
+		return
+	}
+
+	g.send(&dto.GatewayReply{
+		Type:        dto.GatewayErrorReply,
+		RecipientID: feed.recipientID,
+		Error:       &dto.GatewayError{Code: dto.GatewayErrTokenExpired, Message: "Recipient token has expired. Subscribe again with a new one."},
+	})
+}
+
+// drop stops following a recipient — only through feed, when given, so a
+// late caller cannot end the subscription that replaced it. Reports whether it
+// dropped one.
+func (g *inboxGateway) drop(recipientID string, feed *gatewayFeed) bool {
+	g.mu.Lock()
+	current, ok := g.subs[recipientID]
+	if ok && feed != nil && current != feed {
+		ok = false
+	}
+	if ok {
+		delete(g.subs, recipientID)
+		if current.expiry != nil {
+			current.expiry.Stop()
+		}
+	}
+	listener := g.listener
+	g.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	if err := listener.Unfollow(g.ctx, recipientID); err != nil {
+		logger.FromCtx(g.ctx).Errorw("inbox gateway unsubscribe", "error", err)
+	}
+	return true
+}
+
+// ack marks notifications read or opened. Only for a recipient this connection
+// follows: that is where it was authorized, and an ack is a write.
+func (g *inboxGateway) ack(req *dto.GatewayRequest) {
+	g.mu.Lock()
+	_, ok := g.subs[req.RecipientID]
+	g.mu.Unlock()
+
+	if !ok {
+		g.replyError(req, dto.GatewayErrNotSubscribed, "Subscribe to a recipient before acknowledging their notifications.")
+		return
+	}
+
+	updated, _, err := g.svc.UpdateForRecipient(g.ctx, g.apiKey.ProjectID, req.RecipientID, req.UpdatePayload())
+	if err != nil {
+		logger.FromCtx(g.ctx).Errorw("inbox gateway ack", "error", err)
+		g.replyError(req, dto.GatewayErrInternal, "Could not acknowledge. Try again.")
+		return
+	}
+
+	g.send(&dto.GatewayReply{Type: dto.GatewayAcked, ID: req.ID, RecipientID: req.RecipientID, Updated: &updated})
+}
+
+// dispatch pushes live events to their feeds, then each touched feed's unread
+// count once per burst. The listener closing its channel while the connection
+// still stands means it lost the bus; the whole connection is closed so the
+// client resubscribes everything, rather than left following feeds that have
+// gone quiet.
+func (g *inboxGateway) dispatch(events <-chan *dto.InboxEvent) {
+	for {
+		select {
+		case <-g.ctx.Done():
+			return
+
+		case event, ok := <-events:
+			if !ok {
+				if g.ctx.Err() == nil {
+					g.close(websocket.CloseTryAgainLater, "inbox events unavailable")
+				}
+				return
+			}
+
+			touched := map[*gatewayFeed]bool{}
+			g.deliver(event, touched)
+
+		drain:
+			for {
+				select {
+				case event, ok := <-events:
+					if !ok {
+						break drain
+					}
+					g.deliver(event, touched)
+				default:
+					break drain
+				}
+			}
+
+			for feed := range touched {
+				feed.unreadCount()
+			}
+		}
+	}
+}
+
+// deliver hands one event to its recipient's feed. An event for a recipient
+// unsubscribed since is dropped; one for a feed still replaying waits for it.
+func (g *inboxGateway) deliver(event *dto.InboxEvent, touched map[*gatewayFeed]bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	feed, ok := g.subs[event.RecipientExtID]
+	if !ok {
+		return
+	}
+	if !feed.live {
+		feed.pending = append(feed.pending, event)
+		return
+	}
+	if !feed.replayed.duplicate(event) {
+		feed.push(event)
+	}
+	touched[feed] = true
+}
+
+// gatewayFeed is one subscription's half of the connection. Everything but g
+// and recipientID is guarded by g.mu.
+type gatewayFeed struct {
+	g           *inboxGateway
+	recipientID string
+	replayed    replayed
+
+	// live is set once the replay has gone out. Until then, live events wait in
+	// pending so they cannot overtake it.
+	live    bool
+	pending []*dto.InboxEvent
+	// expiry ends the subscription when the recipient token that authorized it
+	// expires. Nil when no token did.
+	expiry *time.Timer
+}
+
+func (f *gatewayFeed) push(event *dto.InboxEvent) {
+	f.g.send(&dto.GatewayEvent{RecipientID: f.recipientID, InboxEvent: event})
+}
+
+func (f *gatewayFeed) unreadCount() {
+	count, _, err := f.g.svc.UnreadCountForRecipient(f.g.ctx, f.g.apiKey.ProjectID, f.recipientID)
+	if err != nil {
+		logger.FromCtx(f.g.ctx).Errorw("inbox gateway unread count", "error", err)
+		return
+	}
+	f.push(&dto.InboxEvent{Type: dto.InboxEventUnreadCount, UnreadCount: &count})
+}
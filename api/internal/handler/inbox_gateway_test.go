@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mudgallabs/bodhveda/internal/env"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/bodhveda/internal/recipienttoken"
+	"github.com/mudgallabs/bodhveda/internal/service"
+)
+
+// memBus is the Redis bus in memory: per recipient, the channels following it.
+type memBus struct {
+	mu        sync.Mutex
+	following map[string][]chan *dto.InboxEvent
+	listens   int
+}
+
+func newMemBus() *memBus {
+	return &memBus{following: map[string][]chan *dto.InboxEvent{}}
+}
+
+func (b *memBus) Publish(ctx context.Context, projectID int, events ...*dto.InboxEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, e := range events {
+		for _, ch := range b.following[e.RecipientExtID] {
+			ch <- e
+		}
+	}
+	return nil
+}
+
+func (b *memBus) Subscribe(ctx context.Context, projectID int, recipientExtID string) (<-chan *dto.InboxEvent, error) {
+	l := b.Listen(ctx, projectID)
+	return l.Events(), l.Follow(ctx, recipientExtID)
+}
+
+func (b *memBus) Listen(ctx context.Context, projectID int) repository.InboxListener {
+	b.mu.Lock()
+	b.listens++
+	b.mu.Unlock()
+	return &memListener{bus: b, events: make(chan *dto.InboxEvent, 16)}
+}
+
+type memListener struct {
+	bus    *memBus
+	events chan *dto.InboxEvent
+}
+
+func (l *memListener) Follow(ctx context.Context, recipientExtID string) error {
+	l.bus.mu.Lock()
+	defer l.bus.mu.Unlock()
+	l.bus.following[recipientExtID] = append(l.bus.following[recipientExtID], l.events)
+	return nil
+}
+
+func (l *memListener) Unfollow(ctx context.Context, recipientExtID string) error {
+	l.bus.mu.Lock()
+	defer l.bus.mu.Unlock()
+	l.bus.following[recipientExtID] = slices.DeleteFunc(l.bus.following[recipientExtID], func(ch chan *dto.InboxEvent) bool {
+		return ch == l.events
+	})
+	return nil
+}
+
+func (l *memListener) Events() <-chan *dto.InboxEvent {
+	return l.events
+}
+
+// gatewayRepo answers the calls the gateway makes on a subscriber's behalf.
+type gatewayRepo struct {
+	repository.NotificationRepository
+	acked []int
+}
+
+func (r *gatewayRepo) UnreadCountForRecipient(ctx context.Context, projectID int, recipientExtID string, archived bool) (int, error) {
+	return 3 - len(r.acked), nil
+}
+
+func (r *gatewayRepo) UpdateForRecipient(ctx context.Context, projectID int, recipientExtID string, payload dto.UpdateRecipientNotificationsPayload) ([]int, error) {
+	r.acked = append(r.acked, payload.IDs...)
+	return payload.IDs, nil
+}
+
+func dialGateway(t *testing.T, bus *memBus) *websocket.Conn {
+	t.Helper()
+	// Full scope and no tokens: authorization never reaches the database.
+	return dialGatewayAs(t, bus, &entity.APIKey{ProjectID: 1, Scope: enum.APIKeyScopeFull})
+}
+
+func dialGatewayAs(t *testing.T, bus *memBus, apiKey *entity.APIKey) *websocket.Conn {
+	t.Helper()
+
+	svc := service.NewNotificationService(&gatewayRepo{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, bus, nil, nil, nil)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := gatewayUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		go serveInboxGateway(context.Background(), conn, svc, apiKey)
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// gatewayMsg is any server message, read loosely.
+type gatewayMsg struct {
+	Type         string            `json:"type"`
+	ID           string            `json:"id"`
+	RecipientID  string            `json:"recipient_id"`
+	Updated      *int              `json:"updated"`
+	UnreadCount  *int              `json:"unread_count"`
+	Notification *dto.Notification `json:"notification"`
+	Error        *dto.GatewayError `json:"error"`
+}
+
+func request(t *testing.T, conn *websocket.Conn, req dto.GatewayRequest) {
+	t.Helper()
+	if err := conn.WriteJSON(req); err != nil {
+		t.Fatalf("write %s: %v", req.Type, err)
+	}
+}
+
+// await reads until a message of type typ arrives, and returns it.
+func await(t *testing.T, conn *websocket.Conn, typ string) gatewayMsg {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("waiting for %s: %v", typ, err)
+		}
+		var msg gatewayMsg
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("decode %s: %v", data, err)
+		}
+		if msg.Type == typ {
+			return msg
+		}
+	}
+}
+
+// TestGatewayPushesAndAcks — one connection follows a recipient: it is told the
+// unread count on subscribe, gets new notifications as they are published, and
+// an ack goes through UpdateForRecipient and comes back as an updated event.
+func TestGatewayPushesAndAcks(t *testing.T) {
+	bus := newMemBus()
+	conn := dialGateway(t, bus)
+
+	request(t, conn, dto.GatewayRequest{Type: dto.GatewaySubscribe, ID: "s1", RecipientID: "U1"})
+	if msg := await(t, conn, "subscribed"); msg.ID != "s1" || msg.RecipientID != "u1" {
+		t.Fatalf("subscribed = %+v, want s1 for u1 (lowercased)", msg)
+	}
+	if msg := await(t, conn, "unread_count"); msg.UnreadCount == nil || *msg.UnreadCount != 3 {
+		t.Fatalf("unread_count = %+v, want 3", msg)
+	}
+
+	_ = bus.Publish(context.Background(), 1, &dto.InboxEvent{
+		Type: dto.InboxEventCreated, RecipientExtID: "u1", Notification: &dto.Notification{ID: 41},
+	})
+	if msg := await(t, conn, string(dto.InboxEventCreated)); msg.RecipientID != "u1" || msg.Notification == nil || msg.Notification.ID != 41 {
+		t.Fatalf("created = %+v, want notification 41 for u1", msg)
+	}
+
+	request(t, conn, dto.GatewayRequest{Type: dto.GatewayAck, ID: "a1", RecipientID: "u1", IDs: []int{41}, Read: true})
+	if msg := await(t, conn, "acked"); msg.Updated == nil || *msg.Updated != 1 {
+		t.Fatalf("acked = %+v, want one updated", msg)
+	}
+	await(t, conn, string(dto.InboxEventUpdated))
+	if msg := await(t, conn, "unread_count"); msg.UnreadCount == nil || *msg.UnreadCount != 2 {
+		t.Fatalf("unread_count after ack = %+v, want 2", msg)
+	}
+}
+
+// TestGatewayRefusesWhatItShould — acks need a subscription, and a connection
+// cannot follow more than its share of recipients.
+func TestGatewayRefusesWhatItShould(t *testing.T) {
+	bus := newMemBus()
+	conn := dialGateway(t, bus)
+
+	request(t, conn, dto.GatewayRequest{Type: dto.GatewayAck, ID: "a1", RecipientID: "u1", IDs: []int{1}, Read: true})
+	if msg := await(t, conn, "error"); msg.ID != "a1" || msg.Error.Code != dto.GatewayErrNotSubscribed {
+		t.Fatalf("ack without subscribing = %+v, want not_subscribed", msg)
+	}
+
+	request(t, conn, dto.GatewayRequest{Type: dto.GatewayAck, ID: "a2", RecipientID: "u1"})
+	if msg := await(t, conn, "error"); msg.ID != "a2" || msg.Error.Code != dto.GatewayErrInvalidMessage {
+		t.Fatalf("ack without ids = %+v, want invalid_message", msg)
+	}
+
+	for i := 0; i < gatewayMaxSubscriptions; i++ {
+		request(t, conn, dto.GatewayRequest{Type: dto.GatewaySubscribe, RecipientID: "u" + string(rune('a'+i))})
+		await(t, conn, "subscribed")
+	}
+	request(t, conn, dto.GatewayRequest{Type: dto.GatewaySubscribe, ID: "over", RecipientID: "one-too-many"})
+	if msg := await(t, conn, "error"); msg.ID != "over" || msg.Error.Code != dto.GatewayErrSubscriptionCap {
+		t.Fatalf("subscribe past the limit = %+v, want subscription_limit", msg)
+	}
+
+	request(t, conn, dto.GatewayRequest{Type: dto.GatewayPing, ID: "p"})
+	if msg := await(t, conn, "pong"); msg.ID != "p" {
+		t.Fatalf("pong = %+v, want it to echo p", msg)
+	}
+}
+
+// TestGatewaySaysWhereTheTokenGoes — a project requiring recipient tokens
+// refuses a tokenless subscribe, and points at the message field rather than
+// the header the HTTP routes take.
+func TestGatewaySaysWhereTheTokenGoes(t *testing.T) {
+	bus := newMemBus()
+	conn := dialGatewayAs(t, bus, &entity.APIKey{ProjectID: 1, Scope: enum.APIKeyScopeRecipient, ProjectRequiresRecipientToken: true})
+
+	request(t, conn, dto.GatewayRequest{Type: dto.GatewaySubscribe, ID: "s1", RecipientID: "u1"})
+	msg := await(t, conn, "error")
+	if msg.Error.Code != dto.GatewayErrUnauthorized || !strings.Contains(msg.Error.Message, "subscribe message") || strings.Contains(msg.Error.Message, "header") {
+		t.Fatalf("tokenless subscribe = %+v, want unauthorized naming the subscribe message's token", msg.Error)
+	}
+}
+
+// TestGatewayMultiplexesOneListener — however many recipients a connection
+// follows, it holds one listener on the bus, and each event still reaches only
+// its own recipient, and only while subscribed.
+func TestGatewayMultiplexesOneListener(t *testing.T) {
+	bus := newMemBus()
+	conn := dialGateway(t, bus)
+
+	for _, id := range []string{"u1", "u2", "u3"} {
+		request(t, conn, dto.GatewayRequest{Type: dto.GatewaySubscribe, RecipientID: id})
+		await(t, conn, "subscribed")
+		await(t, conn, "unread_count")
+	}
+	if bus.listens != 1 {
+		t.Fatalf("three subscriptions opened %d listeners, want 1", bus.listens)
+	}
+
+	request(t, conn, dto.GatewayRequest{Type: dto.GatewayUnsubscribe, RecipientID: "u2"})
+	await(t, conn, "unsubscribed")
+
+	_ = bus.Publish(context.Background(), 1,
+		&dto.InboxEvent{Type: dto.InboxEventCreated, RecipientExtID: "u2", Notification: &dto.Notification{ID: 20}},
+		&dto.InboxEvent{Type: dto.InboxEventCreated, RecipientExtID: "u3", Notification: &dto.Notification{ID: 30}},
+	)
+	if msg := await(t, conn, string(dto.InboxEventCreated)); msg.RecipientID != "u3" || msg.Notification.ID != 30 {
+		t.Fatalf("created = %+v, want only u3's notification 30", msg)
+	}
+}
+
+// TestGatewayEndsSubscriptionWhenTokenExpires — a subscription authorized by a
+// recipient token lasts as long as the token: when it expires the client is
+// told, and the connection stops following that recipient.
+func TestGatewayEndsSubscriptionWhenTokenExpires(t *testing.T) {
+	prev := env.HashKey
+	env.HashKey = "test-hash-key-material-0123456789"
+	t.Cleanup(func() { env.HashKey = prev })
+
+	// Expiry is in whole seconds, so this one has between half a second and a
+	// second and a half left.
+	token, _, err := recipienttoken.Build(1, "u1", 1500*time.Millisecond, []byte(env.HashKey))
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	bus := newMemBus()
+	conn := dialGatewayAs(t, bus, &entity.APIKey{ProjectID: 1, Scope: enum.APIKeyScopeRecipient})
+
+	request(t, conn, dto.GatewayRequest{Type: dto.GatewaySubscribe, ID: "s1", RecipientID: "u1", Token: token})
+	await(t, conn, "subscribed")
+
+	msg := await(t, conn, "error")
+	if msg.RecipientID != "u1" || msg.Error.Code != dto.GatewayErrTokenExpired {
+		t.Fatalf("error = %+v, want token_expired for u1", msg)
+	}
+
+	request(t, conn, dto.GatewayRequest{Type: dto.GatewayAck, ID: "a1", RecipientID: "u1", IDs: []int{1}, Read: true})
+	if msg := await(t, conn, "error"); msg.ID != "a1" || msg.Error.Code != dto.GatewayErrNotSubscribed {
+		t.Fatalf("ack after expiry = %+v, want not_subscribed", msg)
+	}
+}
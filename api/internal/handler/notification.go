@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/mudgallabs/bodhveda/internal/middleware"
@@ -14,6 +15,14 @@ import (
 	"github.com/mudgallabs/tantra/query"
 )
 
+const (
+	// IdempotencyKeyHeader makes a send safe to retry. See NotificationService.Send.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on a response answered from the
+	// Idempotency-Key ledger instead of a fresh send.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
 func SendNotification(s *service.NotificationService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -26,8 +35,52 @@ func SendNotification(s *service.NotificationService) http.HandlerFunc {
 		}
 
 		payload.ProjectID = apiKey.ProjectID
+		payload.IdempotencyKey = strings.TrimSpace(r.Header.Get(IdempotencyKeyHeader))
+
+		send := s.Send
+		if isDryRun(r) {
+			send = s.Preview
+		}
+
+		result, message, errKind, err := send(ctx, apiKey.UserID, payload)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		if result.Replayed {
+			w.Header().Set(IdempotentReplayedHeader, "true")
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, message, result)
+	}
+}
+
+// isDryRun reports whether a send asked for `?dry_run=true`: preview the
+// broadcast instead of sending it. See NotificationService.Preview.
+func isDryRun(r *http.Request) bool {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	return dryRun
+}
+
+// SendNotificationBatch (developer API) sends many direct notifications in one
+// call. Items are reported by index; a rejected item does not fail the request.
+func SendNotificationBatch(s *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+
+		var payload dto.BatchSendNotificationsPayload
+		if err := jsonx.DecodeJSONRequest(&payload, r); err != nil {
+			httpx.MalformedJSONResponse(w, r, err)
+			return
+		}
+
+		for i := range payload.Notifications {
+			payload.Notifications[i].ProjectID = apiKey.ProjectID
+		}
 
-		result, message, errKind, err := s.Send(ctx, apiKey.UserID, payload)
+		result, message, errKind, err := s.SendBatch(ctx, apiKey.UserID, payload.Notifications)
 		if err != nil {
 			httpx.ServiceErrResponse(w, r, errKind, err)
 			return
@@ -62,6 +115,197 @@ func GetNotification(s *service.NotificationService) http.HandlerFunc {
 	}
 }
 
+// ExplainNotification (developer API) reports why a notification did or did
+// not reach its recipient. See NotificationService.ExplainNotification.
+func ExplainNotification(s *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+
+		notificationID, err := httpx.ParamInt(r, "notification_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid notification ID"))
+			return
+		}
+
+		var payload dto.ExplainNotificationPayload
+		if err := httpx.DecodeQuery(r, &payload); err != nil {
+			httpx.BadRequestResponse(w, r, err)
+			return
+		}
+
+		result, errKind, err := s.ExplainNotification(ctx, apiKey.UserID, apiKey.ProjectID, notificationID, payload)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "", result)
+	}
+}
+
+// ExplainRecipient (developer API) reports what a send to the recipient would
+// do. Mounted outside the recipient subroute, so it never creates the
+// recipient it is asked about.
+func ExplainRecipient(s *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+		recipientExtID := strings.ToLower(httpx.ParamStr(r, "recipient_external_id"))
+		if recipientExtID == "" {
+			httpx.BadRequestResponse(w, r, errors.New("recipient_id required"))
+			return
+		}
+
+		var payload dto.ExplainRecipientPayload
+		if err := httpx.DecodeQuery(r, &payload); err != nil {
+			httpx.BadRequestResponse(w, r, err)
+			return
+		}
+
+		result, errKind, err := s.ExplainRecipient(ctx, apiKey.UserID, apiKey.ProjectID, recipientExtID, payload)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "", result)
+	}
+}
+
+// CancelScheduledNotification (developer API) withdraws a scheduled direct send
+// before its send_at. 409 once it has fired.
+func CancelScheduledNotification(s *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+
+		notificationID, err := httpx.ParamInt(r, "notification_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid notification ID"))
+			return
+		}
+
+		notification, errKind, err := s.CancelScheduled(ctx, apiKey.ProjectID, notificationID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "Scheduled notification cancelled.", notification)
+	}
+}
+
+// CancelScheduledNotificationConsole is CancelScheduledNotification for the
+// console, project-scoped from the URL.
+func CancelScheduledNotificationConsole(s *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		projectID, err := httpx.ParamInt(r, "project_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid project ID"))
+			return
+		}
+
+		notificationID, err := httpx.ParamInt(r, "notification_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid notification ID"))
+			return
+		}
+
+		notification, errKind, err := s.CancelScheduled(ctx, projectID, notificationID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "Scheduled notification cancelled.", notification)
+	}
+}
+
+// PatchNotification (developer API) replaces a delivered notification's payload.
+func PatchNotification(s *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+
+		notificationID, err := httpx.ParamInt(r, "notification_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid notification ID"))
+			return
+		}
+
+		var payload dto.PatchNotificationPayload
+		if err := jsonx.DecodeJSONRequest(&payload, r); err != nil {
+			httpx.MalformedJSONResponse(w, r, err)
+			return
+		}
+
+		payload.ProjectID = apiKey.ProjectID
+		payload.NotificationID = notificationID
+
+		notification, errKind, err := s.Patch(ctx, payload)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "Notification updated.", notification)
+	}
+}
+
+// RecallNotification (developer API) pulls a sent notification back out of the
+// recipient's feed and stops its email if that has not gone out yet.
+func RecallNotification(s *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+
+		notificationID, err := httpx.ParamInt(r, "notification_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid notification ID"))
+			return
+		}
+
+		notification, errKind, err := s.Recall(ctx, apiKey.ProjectID, notificationID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "Notification recalled.", notification)
+	}
+}
+
+// RecallNotificationConsole is RecallNotification for the console, project-scoped
+// from the URL.
+func RecallNotificationConsole(s *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		projectID, err := httpx.ParamInt(r, "project_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid project ID"))
+			return
+		}
+
+		notificationID, err := httpx.ParamInt(r, "notification_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid notification ID"))
+			return
+		}
+
+		notification, errKind, err := s.Recall(ctx, projectID, notificationID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "Notification recalled.", notification)
+	}
+}
+
 func SendNotificationConsole(s *service.NotificationService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -81,7 +325,12 @@ func SendNotificationConsole(s *service.NotificationService) http.HandlerFunc {
 
 		payload.ProjectID = projectID
 
-		result, message, errKind, err := s.Send(ctx, userID, payload)
+		send := s.Send
+		if isDryRun(r) {
+			send = s.Preview
+		}
+
+		result, message, errKind, err := send(ctx, userID, payload)
 		if err != nil {
 			httpx.ServiceErrResponse(w, r, errKind, err)
 			return
@@ -109,8 +358,31 @@ func ListForRecipient(s *service.NotificationService) http.HandlerFunc {
 			return
 		}
 
+		var filters dto.RecipientFeedFilters
+		if err := httpx.DecodeQuery(r, &filters); err != nil {
+			httpx.BadRequestResponse(w, r, err)
+			return
+		}
+
+		// Grouped, the page is of groups and says so in its key, so a client
+		// cannot mistake one for the other.
+		if filters.GroupBy != "" {
+			groups, returnedCursor, errKind, err := s.ListGroupsForRecipient(
+				ctx, apiKey.ProjectID, recipientExtID, &filters, &cursor)
+			if err != nil {
+				httpx.ServiceErrResponse(w, r, errKind, err)
+				return
+			}
+
+			httpx.SuccessResponse(w, r, http.StatusOK, "", map[string]interface{}{
+				"groups": groups,
+				"cursor": returnedCursor,
+			})
+			return
+		}
+
 		notifications, returnedCursor, errKind, err := s.ListForRecipient(
-			ctx, apiKey.ProjectID, recipientExtID, &cursor)
+			ctx, apiKey.ProjectID, recipientExtID, &filters, &cursor)
 		if err != nil {
 			httpx.ServiceErrResponse(w, r, errKind, err)
 			return
@@ -136,13 +408,16 @@ func UnreadCountForRecipient(s *service.NotificationService) http.HandlerFunc {
 			return
 		}
 
-		count, errKind, err := s.UnreadCountForRecipient(ctx, apiKey.ProjectID, recipientExtID)
+		// As on the list, where the schema decoder parses it the same way.
+		archived, _ := strconv.ParseBool(r.URL.Query().Get("archived"))
+
+		counts, errKind, err := s.UnreadCountsForRecipient(ctx, apiKey.ProjectID, recipientExtID, archived)
 		if err != nil {
 			httpx.ServiceErrResponse(w, r, errKind, err)
 			return
 		}
 
-		httpx.SuccessResponse(w, r, http.StatusOK, "", map[string]int{"unread_count": count})
+		httpx.SuccessResponse(w, r, http.StatusOK, "", counts)
 	}
 }
 
@@ -163,6 +438,16 @@ func UpdateRecipientNotifications(s *service.NotificationService) http.HandlerFu
 			return
 		}
 
+		// The list's filters, on the query string, scope the update. No query
+		// string is the whole feed, as before filters existed.
+		if r.URL.RawQuery != "" {
+			payload.Filters = &dto.RecipientFeedFilters{}
+			if err := httpx.DecodeQuery(r, payload.Filters); err != nil {
+				httpx.BadRequestResponse(w, r, err)
+				return
+			}
+		}
+
 		updated, errKind, err := s.UpdateForRecipient(ctx, apiKey.ProjectID, recipientExtID, payload)
 		if err != nil {
 			httpx.ServiceErrResponse(w, r, errKind, err)
@@ -314,6 +599,72 @@ func ListNotificationDeliveries(s *service.NotificationService) http.HandlerFunc
 	}
 }
 
+func ExplainNotificationConsole(s *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := middleware.GetUserIDFromContext(ctx)
+
+		projectID, err := httpx.ParamInt(r, "project_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid project ID"))
+			return
+		}
+
+		notificationID, err := httpx.ParamInt(r, "notification_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid notification ID"))
+			return
+		}
+
+		var payload dto.ExplainNotificationPayload
+		if err := httpx.DecodeQuery(r, &payload); err != nil {
+			httpx.BadRequestResponse(w, r, err)
+			return
+		}
+
+		result, errKind, err := s.ExplainNotification(ctx, userID, projectID, notificationID, payload)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "", result)
+	}
+}
+
+func ExplainRecipientConsole(s *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID := middleware.GetUserIDFromContext(ctx)
+
+		projectID, err := httpx.ParamInt(r, "project_id")
+		if err != nil {
+			httpx.BadRequestResponse(w, r, errors.New("Invalid project ID"))
+			return
+		}
+
+		recipientExtID := strings.ToLower(httpx.ParamStr(r, "recipient_external_id"))
+		if recipientExtID == "" {
+			httpx.BadRequestResponse(w, r, errors.New("recipient_id required"))
+			return
+		}
+
+		var payload dto.ExplainRecipientPayload
+		if err := httpx.DecodeQuery(r, &payload); err != nil {
+			httpx.BadRequestResponse(w, r, err)
+			return
+		}
+
+		result, errKind, err := s.ExplainRecipient(ctx, userID, projectID, recipientExtID, payload)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusOK, "", result)
+	}
+}
+
 // ProjectAnalytics returns the console Home page's time-series + breakdown
 // analytics for a project over a date range (Phase 9.5). The per-day buckets are
 // computed in the viewer's timezone, taken from the X-Timezone header via
@@ -59,8 +59,8 @@ func TestProjectAnalyticsHandler(t *testing.T) {
 
 	svc := service.NewNotificationService(
 		pg.NewNotificationRepo(pool), nil, nil, nil, nil,
-		pg.NewNotificationDeliveryRepo(pool), nil, nil, nil,
-		nil, nil, nil,
+		pg.NewNotificationDeliveryRepo(pool), nil, nil, nil, nil,
+		nil, nil, nil, nil, nil,
 	)
 
 	r := chi.NewRouter()
@@ -75,8 +75,8 @@ func TestListNotificationDeliveriesHandler(t *testing.T) {
 	// deps are irrelevant to it.
 	svc := service.NewNotificationService(
 		pg.NewNotificationRepo(pool), nil, nil, nil, nil,
-		pg.NewNotificationDeliveryRepo(pool), nil, nil, nil,
-		nil, nil, nil,
+		pg.NewNotificationDeliveryRepo(pool), nil, nil, nil, nil,
+		nil, nil, nil, nil, nil,
 	)
 
 	// Mounted with the same nesting + param names as cmd/api/routes.go.
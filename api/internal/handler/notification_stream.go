@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/middleware"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/service"
+	"github.com/mudgallabs/tantra/httpx"
+	"github.com/mudgallabs/tantra/logger"
+)
+
+const (
+	// inboxStreamLifetime is how long one stream stays open before the server
+	// ends it and the client reconnects with Last-Event-ID. It has to end before
+	// the router's 60s request timeout cancels it mid-write; a reconnect a minute
+	// is one request against the per-IP limit where polling was dozens, and the
+	// replay makes the handover lossless.
+	inboxStreamLifetime = 50 * time.Second
+	// inboxStreamHeartbeat keeps proxies from closing an idle stream. Comment
+	// lines are ignored by every SSE client.
+	inboxStreamHeartbeat = 15 * time.Second
+	// inboxStreamRetry is the reconnect delay the stream asks clients to use.
+	inboxStreamRetry = 2 * time.Second
+)
+
+// StreamRecipientNotifications is the recipient's feed as Server-Sent Events:
+// created / updated / deleted as they happen, each followed by the unread count.
+//
+// Order on connect matters. The live subscription is opened FIRST, then the
+// missed notifications are replayed from Postgres, so a notification written in
+// between arrives twice rather than not at all, and the live copy of anything
+// replayed is dropped.
+//
+// ⚠️ Ids are allocated before the rows commit, so a broadcast batch can commit
+// rows with ids below one a client has already seen. Live, that is harmless —
+// the event still arrives. It only bites a client that disconnected in exactly
+// that window, whose resume then starts past those rows.
+func StreamRecipientNotifications(s *service.NotificationService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+
+		recipientExtID := strings.ToLower(httpx.ParamStr(r, "recipient_external_id"))
+		if recipientExtID == "" {
+			httpx.BadRequestResponse(w, r, errors.New("recipient_id required"))
+			return
+		}
+
+		// Query param for clients that cannot set headers on the first connect;
+		// EventSource sends the header itself on every reconnect.
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+		var lastID int
+		if lastEventID != "" {
+			id, err := strconv.Atoi(lastEventID)
+			if err != nil || id < 0 {
+				httpx.BadRequestResponse(w, r, errors.New("Last-Event-ID must be a notification id"))
+				return
+			}
+			lastID = id
+		}
+
+		streamCtx, cancel := context.WithTimeout(ctx, inboxStreamLifetime)
+		defer cancel()
+
+		events, errKind, err := s.SubscribeForRecipient(streamCtx, apiKey.ProjectID, recipientExtID)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		var replay []*dto.Notification
+		var truncated bool
+		if lastID > 0 {
+			replay, truncated, errKind, err = s.ReplayForRecipient(ctx, apiKey.ProjectID, recipientExtID, lastID)
+			if err != nil {
+				httpx.ServiceErrResponse(w, r, errKind, err)
+				return
+			}
+		}
+
+		rc := http.NewResponseController(w)
+		// The server's WriteTimeout would cut the stream off at 30s.
+		_ = rc.SetWriteDeadline(time.Now().Add(inboxStreamLifetime + 10*time.Second))
+
+		h := w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		stream := &inboxStream{w: w, rc: rc, lastID: lastID, replayed: newReplayed(replay)}
+		stream.retry(inboxStreamRetry)
+
+		if truncated {
+			stream.write(&dto.InboxEvent{Type: dto.InboxEventReset}, false)
+		}
+		for _, n := range replay {
+			stream.write(&dto.InboxEvent{Type: dto.InboxEventCreated, RecipientExtID: recipientExtID, Notification: n}, false)
+		}
+		stream.unreadCount(s, r, apiKey.ProjectID, recipientExtID)
+		if !stream.flush() {
+			return
+		}
+
+		heartbeat := time.NewTicker(inboxStreamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-streamCtx.Done():
+				return
+
+			case <-heartbeat.C:
+				stream.comment("ping")
+				if !stream.flush() {
+					return
+				}
+
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				stream.write(event, true)
+
+				// A broadcast batch or a bulk update can land several at once;
+				// write them all and count once.
+			drain:
+				for {
+					select {
+					case event, ok := <-events:
+						if !ok {
+							break drain
+						}
+						stream.write(event, true)
+					default:
+						break drain
+					}
+				}
+
+				stream.unreadCount(s, r, apiKey.ProjectID, recipientExtID)
+				if !stream.flush() {
+					return
+				}
+			}
+		}
+	}
+}
+
+// inboxStream writes SSE frames and remembers the first write error, after
+// which every write is a no-op and flush reports the stream dead.
+type inboxStream struct {
+	w  io.Writer
+	rc *http.ResponseController
+	// lastID is the highest SSE id sent, the client's resume cursor.
+	lastID   int
+	replayed replayed
+	err      error
+}
+
+func (s *inboxStream) printf(format string, args ...any) {
+	if s.err != nil {
+		return
+	}
+	_, s.err = fmt.Fprintf(s.w, format, args...)
+}
+
+func (s *inboxStream) retry(d time.Duration) {
+	s.printf("retry: %d\n\n", d.Milliseconds())
+}
+
+func (s *inboxStream) comment(text string) {
+	s.printf(": %s\n\n", text)
+}
+
+// write sends one event. A created event carries its notification id as the
+// SSE id, which is the resume cursor — unless it is lower than one already
+// sent, since moving the cursor back would replay what the client has. live is
+// false for the replay itself.
+func (s *inboxStream) write(event *dto.InboxEvent, live bool) {
+	if live && s.replayed.duplicate(event) {
+		return
+	}
+	if event.Type == dto.InboxEventCreated && event.Notification != nil {
+		if id := event.Notification.ID; id > s.lastID {
+			s.lastID = id
+			s.printf("id: %d\n", id)
+		}
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		logger.Get().Errorw("marshal inbox event", "error", err, "type", event.Type)
+		return
+	}
+	s.printf("event: %s\ndata: %s\n\n", event.Type, data)
+}
+
+func (s *inboxStream) unreadCount(svc *service.NotificationService, r *http.Request, projectID int, recipientExtID string) {
+	count, _, err := svc.UnreadCountForRecipient(r.Context(), projectID, recipientExtID)
+	if err != nil {
+		logger.FromCtx(r.Context()).Errorw("inbox stream unread count", "error", err)
+		return
+	}
+	s.write(&dto.InboxEvent{Type: dto.InboxEventUnreadCount, UnreadCount: &count}, false)
+}
+
+func (s *inboxStream) flush() bool {
+	if s.err == nil {
+		s.err = s.rc.Flush()
+	}
+	return s.err == nil
+}
+
+// replayed is what a resuming subscriber was replayed from Postgres. The
+// subscription was opened first, so each may also arrive live, once.
+type replayed map[int]struct{}
+
+func newReplayed(notifications []*dto.Notification) replayed {
+	r := make(replayed, len(notifications))
+	for _, n := range notifications {
+		r[n.ID] = struct{}{}
+	}
+	return r
+}
+
+// duplicate reports whether event is the live copy of a replayed notification,
+// forgetting it so a later event for the same id goes through.
+func (r replayed) duplicate(event *dto.InboxEvent) bool {
+	if event.Type != dto.InboxEventCreated || event.Notification == nil {
+		return false
+	}
+	if _, ok := r[event.Notification.ID]; ok {
+		delete(r, event.Notification.ID)
+		return true
+	}
+	return false
+}
@@ -176,6 +176,45 @@ func BatchCreateRecipients(s *service.RecipientService) http.HandlerFunc {
 	}
 }
 
+// IssueRecipientToken mints a recipient-bound token. Full scope only (see
+// routes.go); the project comes from the key, the recipient from the path.
+func IssueRecipientToken(s *service.RecipientService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := middleware.GetAPIKeyFromContext(ctx)
+		if apiKey == nil {
+			httpx.UnauthorizedResponse(w, r, "API key required", errors.New("API key required"))
+			return
+		}
+
+		recipientExtID := strings.ToLower(httpx.ParamStr(r, "recipient_external_id"))
+		if recipientExtID == "" {
+			httpx.BadRequestResponse(w, r, errors.New("recipient_external_id required"))
+			return
+		}
+
+		// The body is optional: an empty POST mints a token with the default TTL.
+		var payload dto.IssueRecipientTokenPayload
+		if r.ContentLength != 0 {
+			if err := jsonx.DecodeJSONRequest(&payload, r); err != nil {
+				httpx.MalformedJSONResponse(w, r, err)
+				return
+			}
+		}
+
+		payload.ProjectID = apiKey.ProjectID
+		payload.RecipientExtID = recipientExtID
+
+		result, errKind, err := s.IssueToken(ctx, payload)
+		if err != nil {
+			httpx.ServiceErrResponse(w, r, errKind, err)
+			return
+		}
+
+		httpx.SuccessResponse(w, r, http.StatusCreated, "Recipient token issued", result)
+	}
+}
+
 func UpdateRecipient(s *service.RecipientService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -5,6 +5,7 @@ import (
 
 	"github.com/hibiken/asynq"
 	"github.com/mudgallabs/bodhveda/internal/env"
+	"github.com/mudgallabs/bodhveda/internal/job/task"
 )
 
 // NewAsynqClient initializes and returns a new Asynq client.
@@ -19,7 +20,8 @@ func NewAsynqClient() (*asynq.Client, error) {
 }
 
 // NewAsynqServer initializes and returns a new Asynq server.
-// Server is used to process tasks from the Asynq queue.
+// Server is used to process tasks from the priority lanes' queues, picking
+// between them by the weights configured in env.
 func NewAsynqServer() (*asynq.Server, error) {
 	redisConnOpt, err := asynq.ParseRedisURI(env.RedisURL)
 	if err != nil {
@@ -30,7 +32,19 @@ func NewAsynqServer() (*asynq.Server, error) {
 		redisConnOpt,
 		asynq.Config{
 			// Specify how many concurrent workers to use
-			Concurrency: 10,
+			Concurrency: env.WorkerConcurrency,
+			Queues:      Queues(),
 		},
 	), nil
 }
+
+// Queues is the weight of each priority lane's queue, as asynq.Config.Queues
+// takes it. Every queue a task can be routed to must be in here: Asynq never
+// reads a queue its server was not configured with.
+func Queues() map[string]int {
+	return map[string]int{
+		task.QueueCritical: env.QueueWeightCritical,
+		task.QueueDefault:  env.QueueWeightNormal,
+		task.QueueBulk:     env.QueueWeightBulk,
+	}
+}
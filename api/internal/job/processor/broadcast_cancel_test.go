@@ -0,0 +1,144 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/mudgallabs/bodhveda/internal/job/task"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/pg"
+	"github.com/mudgallabs/bodhveda/internal/service"
+	tantraService "github.com/mudgallabs/tantra/service"
+)
+
+// TestCancelledBroadcastStopsBatchesAndRefunds — a broadcast cancelled half way
+// through its fan-out. The batch that already ran keeps its notifications; the
+// one still queued writes nothing when its task fires, and the recipients it held
+// come off the project's usage for the period the broadcast was billed in.
+func TestCancelledBroadcastStopsBatchesAndRefunds(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	projectID := testProject(t, pool, "bcast-cancel-test")
+	t.Cleanup(func() {
+		// Before the project goes: usage rows do not cascade.
+		_, _ = pool.Exec(context.Background(), `DELETE FROM usage_log WHERE project_id = $1`, projectID)
+		_, _ = pool.Exec(context.Background(), `DELETE FROM usage_aggregate WHERE project_id = $1`, projectID)
+	})
+
+	broadcastRepo := pg.NewBroadcastRepo(pool)
+	notificationRepo := pg.NewNotificationRepo(pool)
+	batchRepo := pg.NewBroadcastBatchRepo(pool)
+
+	billing := service.NewBillingService(pool, pg.NewProjectRepo(pool), pg.NewUserSubscriptionRepo(pool),
+		pg.NewUsageLogRepo(pool), pg.NewUsageAggregateRepo(pool))
+	svc := service.NewBroadcastService(pool, broadcastRepo, batchRepo, notificationRepo, billing, nil, nil)
+
+	// Billed for all five recipients, as prepare_batches would have.
+	now := time.Now().UTC()
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO usage_aggregate (project_id, metric, period_start, period_end, used)
+		VALUES ($1, $2, $3, $4, 5)
+	`, projectID, entity.MetricNotifications, now.Add(-24*time.Hour), now.Add(24*time.Hour)); err != nil {
+		t.Fatalf("seed usage: %v", err)
+	}
+
+	broadcast, err := broadcastRepo.Create(ctx, entity.NewBroadcast(projectID, []byte(`{"t":"hi"}`), "product", "updates", "released"))
+	if err != nil {
+		t.Fatalf("create broadcast: %v", err)
+	}
+
+	sent, err := batchRepo.Create(ctx, entity.NewBroadcastBatch(broadcast.ID, []string{"r1", "r2"}))
+	if err != nil {
+		t.Fatalf("create sent batch: %v", err)
+	}
+	if err := batchRepo.Update(ctx, sent.ID, entity.NewBroadcastBatchUpdatePayload(enum.BroadcastBatchStatusSuccess, 1, 0)); err != nil {
+		t.Fatalf("mark batch sent: %v", err)
+	}
+
+	queued, err := batchRepo.Create(ctx, entity.NewBroadcastBatch(broadcast.ID, []string{"r3", "r4", "r5"}))
+	if err != nil {
+		t.Fatalf("create queued batch: %v", err)
+	}
+
+	got, errKind, err := svc.Cancel(ctx, projectID, broadcast.ID)
+	if err != nil {
+		t.Fatalf("cancel: %v (%v)", err, errKind)
+	}
+	if got.Status != enum.BroadcastStatusCancelled {
+		t.Errorf("status = %q, want cancelled", got.Status)
+	}
+
+	payload, err := json.Marshal(dto.BroadcastDeliveryTaskPayload{
+		ProjectID:       projectID,
+		BroadcastID:     broadcast.ID,
+		BatchID:         queued.ID,
+		RecipientExtIDs: []string{"r3", "r4", "r5"},
+		Payload:         []byte(`{"t":"hi"}`),
+		Channel:         "product",
+		Topic:           "updates",
+		Event:           "released",
+	})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	p := NewBroadcastDeliveryProcessor(pool, notificationRepo, broadcastRepo, batchRepo, nil, nil, nil, nil)
+	if err := p.ProcessTask(ctx, asynq.NewTask(task.TaskTypeBroadcastDelivery, payload)); err != nil {
+		t.Fatalf("process task: %v", err)
+	}
+
+	rollup, err := notificationRepo.StatusRollupForBroadcast(ctx, broadcast.ID)
+	if err != nil {
+		t.Fatalf("rollup: %v", err)
+	}
+	if len(rollup) != 0 {
+		t.Errorf("a cancelled batch must write nothing, got %v", rollup)
+	}
+
+	var sentStatus, queuedStatus enum.BroadcastBatchStatus
+	if err := pool.QueryRow(ctx, `
+		SELECT
+			(SELECT status FROM broadcast_batch WHERE id = $1),
+			(SELECT status FROM broadcast_batch WHERE id = $2)
+	`, sent.ID, queued.ID).Scan(&sentStatus, &queuedStatus); err != nil {
+		t.Fatalf("read batch statuses: %v", err)
+	}
+	if sentStatus != enum.BroadcastBatchStatusSuccess || queuedStatus != enum.BroadcastBatchStatusCancelled {
+		t.Errorf("batch statuses = %q, %q; want success, cancelled", sentStatus, queuedStatus)
+	}
+
+	var used int64
+	if err := pool.QueryRow(ctx, `
+		SELECT used FROM usage_aggregate WHERE project_id = $1 AND metric = $2
+	`, projectID, entity.MetricNotifications).Scan(&used); err != nil {
+		t.Fatalf("read usage: %v", err)
+	}
+	if used != 2 {
+		t.Errorf("usage after cancel = %d, want the 3 queued recipients refunded off 5", used)
+	}
+
+	// The refund is logged in the period it is netted against: when the first
+	// batch was billed, not when the cancel ran.
+	var refunded int64
+	var refundAt, billedAt time.Time
+	if err := pool.QueryRow(ctx, `
+		SELECT l.amount, l.used_at, (SELECT MIN(created_at) FROM broadcast_batch WHERE broadcast_id = $3)
+		FROM usage_log l
+		WHERE l.project_id = $1 AND l.metric = $2 AND l.amount < 0
+	`, projectID, entity.MetricNotifications, broadcast.ID).Scan(&refunded, &refundAt, &billedAt); err != nil {
+		t.Fatalf("read refund log: %v", err)
+	}
+	if refunded != -3 || !refundAt.Equal(billedAt) {
+		t.Errorf("refund log = %d at %v, want -3 at %v (when it was billed)", refunded, refundAt, billedAt)
+	}
+
+	// A second cancel is a conflict, and must not refund again.
+	if _, errKind, err := svc.Cancel(ctx, projectID, broadcast.ID); err == nil || errKind != tantraService.ErrConflict {
+		t.Errorf("second cancel = %v (%v), want a conflict", err, errKind)
+	}
+}
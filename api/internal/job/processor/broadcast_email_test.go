@@ -23,8 +23,8 @@ func emailTestService(pool *pgxpool.Pool) *service.NotificationService {
 		pg.NewNotificationRepo(pool), pg.NewRecipientRepo(pool), pg.NewPreferenceRepo(pool),
 		pg.NewBroadcastRepo(pool), pg.NewBroadcastBatchRepo(pool),
 		pg.NewNotificationDeliveryRepo(pool), pg.NewRecipientContactRepo(pool),
-		pg.NewProjectEmailSettingsRepo(pool), pg.NewProjectRepo(pool),
-		nil, nil, nil,
+		pg.NewProjectEmailSettingsRepo(pool), pg.NewProjectRepo(pool), pg.NewIdempotencyKeyRepo(pool),
+		nil, nil, nil, nil, nil,
 	)
 }
 
@@ -125,7 +125,7 @@ func TestBroadcastEmailFansOutToEligibleRecipients(t *testing.T) {
 		t.Fatalf("email-eligible = %d, want 3 (all three are cataloged for email)", len(eligible))
 	}
 
-	tasks, err := svc.FanOutBroadcastEmail(ctx, tx, broadcast, notifications)
+	tasks, err := svc.FanOutBroadcastEmail(ctx, tx, broadcast, notifications, nil)
 	if err != nil {
 		t.Fatalf("fan out: %v", err)
 	}
@@ -244,7 +244,7 @@ func TestBroadcastEmailCapBlocksRatherThanTruncates(t *testing.T) {
 	if err != nil {
 		t.Fatalf("reload broadcast: %v", err)
 	}
-	tasks, err := svc.FanOutBroadcastEmail(ctx, tx, blocked, nil)
+	tasks, err := svc.FanOutBroadcastEmail(ctx, tx, blocked, nil, nil)
 	if err != nil {
 		t.Fatalf("fan out on blocked broadcast: %v", err)
 	}
@@ -327,7 +327,7 @@ func TestBroadcastEmailReachesInAppMutedRecipient(t *testing.T) {
 	preferenceRepo := pg.NewPreferenceRepo(pool)
 	target := dto.Target{Channel: "product", Topic: "updates", Event: "released"}
 
-	inApp, err := preferenceRepo.ListEligibleRecipientExtIDsForBroadcast(ctx, projectID, target, enum.MediumInApp)
+	inApp, err := preferenceRepo.ListEligibleRecipientExtIDsForBroadcast(ctx, projectID, target, enum.MediumInApp, nil)
 	if err != nil {
 		t.Fatalf("list in-app eligible: %v", err)
 	}
@@ -335,7 +335,7 @@ func TestBroadcastEmailReachesInAppMutedRecipient(t *testing.T) {
 		t.Fatalf("in-app eligible = %v, want only [r1]", inApp)
 	}
 
-	emailEligible, err := preferenceRepo.ListEligibleRecipientExtIDsForBroadcast(ctx, projectID, target, enum.MediumEmail)
+	emailEligible, err := preferenceRepo.ListEligibleRecipientExtIDsForBroadcast(ctx, projectID, target, enum.MediumEmail, nil)
 	if err != nil {
 		t.Fatalf("list email eligible: %v", err)
 	}
@@ -344,7 +344,7 @@ func TestBroadcastEmailReachesInAppMutedRecipient(t *testing.T) {
 	}
 
 	// The union is what the broadcast fans out to.
-	fanOut := unionExtIDs(inApp, emailEligible)
+	fanOut := service.UnionRecipientIDs(inApp, emailEligible)
 	if len(fanOut) != 2 {
 		t.Fatalf("fan-out = %v, want both recipients", fanOut)
 	}
@@ -387,7 +387,7 @@ func TestBroadcastEmailReachesInAppMutedRecipient(t *testing.T) {
 		t.Fatalf("insert notifications: %v", err)
 	}
 
-	tasks, err := svc.FanOutBroadcastEmail(ctx, tx, broadcast, notifications)
+	tasks, err := svc.FanOutBroadcastEmail(ctx, tx, broadcast, notifications, nil)
 	if err != nil {
 		t.Fatalf("fan out: %v", err)
 	}
@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/mudgallabs/bodhveda/internal/job/task"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/pg"
+)
+
+// TestPrepareBatchesResolvesRecipientList pins that a list-based broadcast's
+// audience is its list, and only its list.
+//
+// The project has a recipient who is eligible but NOT on the list. If prepare
+// fell back to the project-wide audience it would reach them, and with billing
+// nil that is a panic rather than a silent wrong send. The list itself holds one
+// recipient who opted out and one id that does not exist, so the broadcast
+// completes empty — and the frozen breakdown must say why, id by id.
+func TestPrepareBatchesResolvesRecipientList(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	projectID := testProject(t, pool, "bcast-list-test")
+
+	broadcastRepo := pg.NewBroadcastRepo(pool)
+	preferenceRepo := pg.NewPreferenceRepo(pool)
+	batchRepo := pg.NewBroadcastBatchRepo(pool)
+
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO recipient (project_id, external_id, created_at, updated_at)
+		VALUES ($1, 'bystander', now(), now()), ($1, 'opted-out', now(), now())
+	`, projectID); err != nil {
+		t.Fatalf("insert recipients: %v", err)
+	}
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO preference (project_id, recipient_external_id, channel, topic, event, name, medium, enabled, created_at, updated_at)
+		VALUES ($1, NULL, 'product', 'updates', 'released', 'Updates', 'in_app', true, now(), now()),
+			($1, 'opted-out', 'product', 'updates', 'released', NULL, 'in_app', false, now(), now())
+	`, projectID); err != nil {
+		t.Fatalf("insert preferences: %v", err)
+	}
+
+	broadcast, err := broadcastRepo.CreateWithRecipients(ctx,
+		entity.NewBroadcast(projectID, []byte(`{"t":"hi"}`), "product", "updates", "released"),
+		[]string{"opted-out", "ghost"},
+	)
+	if err != nil {
+		t.Fatalf("create broadcast: %v", err)
+	}
+	if broadcast.RecipientListSize == nil || *broadcast.RecipientListSize != 2 {
+		t.Fatalf("recipient_list_size = %v, want 2", broadcast.RecipientListSize)
+	}
+
+	payload, err := json.Marshal(dto.PrepareBroadcastBatchesPayload{UserID: 1, Broadcast: broadcast})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	p := NewPrepareBroadcastBatchesProcessor(pool, nil, preferenceRepo, broadcastRepo, batchRepo, nil, nil)
+	if err := p.ProcessTask(ctx, asynq.NewTask(task.TaskTypePrepareBroadcastBatches, payload)); err != nil {
+		t.Fatalf("prepare: %v", err)
+	}
+
+	reloaded, err := broadcastRepo.GetByID(ctx, broadcast.ID)
+	if err != nil {
+		t.Fatalf("reload broadcast: %v", err)
+	}
+	if reloaded.Status != enum.BroadcastStatusCompleted {
+		t.Errorf("status = %q, want completed", reloaded.Status)
+	}
+
+	a := reloaded.Audience
+	if a == nil {
+		t.Fatal("audience must be recorded")
+	}
+	want := entity.BroadcastAudience{Total: 2, ExcludedDisabled: 1, ExcludedUnknown: 1}
+	if *a != want {
+		t.Errorf("audience = %+v, want %+v", *a, want)
+	}
+}
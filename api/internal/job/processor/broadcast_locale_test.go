@@ -0,0 +1,86 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/mudgallabs/bodhveda/internal/job/task"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/pg"
+)
+
+// TestBroadcastPicksVariantPerRecipient — one batch, three recipients: an exact
+// match, one that falls back a subtag (pt-BR → pt), and one with no locale at
+// all, who gets the default.
+func TestBroadcastPicksVariantPerRecipient(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	projectID := testProject(t, pool, "bcast-locale-test")
+
+	broadcastRepo := pg.NewBroadcastRepo(pool)
+	notificationRepo := pg.NewNotificationRepo(pool)
+	batchRepo := pg.NewBroadcastBatchRepo(pool)
+
+	recipients := map[string]*string{"de1": strPtr("de"), "br1": strPtr("pt-BR"), "none": nil}
+	extIDs := make([]string, 0, len(recipients))
+	for extID, locale := range recipients {
+		if _, err := pool.Exec(ctx, `
+			INSERT INTO recipient (project_id, external_id, locale, created_at, updated_at) VALUES ($1, $2, $3, now(), now())
+		`, projectID, extID, locale); err != nil {
+			t.Fatalf("insert recipient %s: %v", extID, err)
+		}
+		extIDs = append(extIDs, extID)
+	}
+
+	b := entity.NewBroadcast(projectID, []byte(`{"t":"en"}`), "product", "updates", "released")
+	b.Variants = entity.ContentVariants{
+		"de": {Payload: json.RawMessage(`{"t":"de"}`)},
+		"pt": {Payload: json.RawMessage(`{"t":"pt"}`)},
+	}
+	broadcast, err := broadcastRepo.Create(ctx, b)
+	if err != nil {
+		t.Fatalf("create broadcast: %v", err)
+	}
+
+	batch, err := batchRepo.Create(ctx, entity.NewBroadcastBatch(broadcast.ID, extIDs))
+	if err != nil {
+		t.Fatalf("create batch: %v", err)
+	}
+
+	payload, err := json.Marshal(dto.BroadcastDeliveryTaskPayload{
+		ProjectID:       projectID,
+		BroadcastID:     broadcast.ID,
+		BatchID:         batch.ID,
+		RecipientExtIDs: extIDs,
+		Payload:         []byte(`{"t":"en"}`),
+		Channel:         "product",
+		Topic:           "updates",
+		Event:           "released",
+	})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	p := NewBroadcastDeliveryProcessor(pool, notificationRepo, broadcastRepo, batchRepo, nil, pg.NewRecipientRepo(pool), nil, nil)
+	if err := p.ProcessTask(ctx, asynq.NewTask(task.TaskTypeBroadcastDelivery, payload)); err != nil {
+		t.Fatalf("process task: %v", err)
+	}
+
+	want := map[string]string{"de1": "de", "br1": "pt", "none": "en"}
+	for extID, lang := range want {
+		var got string
+		if err := pool.QueryRow(ctx, `
+			SELECT payload->>'t' FROM notification WHERE broadcast_id = $1 AND recipient_external_id = $2
+		`, broadcast.ID, extID).Scan(&got); err != nil {
+			t.Fatalf("read notification for %s: %v", extID, err)
+		}
+		if got != lang {
+			t.Errorf("%s got the %q payload, want %q", extID, got, lang)
+		}
+	}
+}
+
+func strPtr(s string) *string { return &s }
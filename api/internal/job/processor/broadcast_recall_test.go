@@ -0,0 +1,77 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/mudgallabs/bodhveda/internal/job/task"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/pg"
+)
+
+// TestRecalledBatchDoesNotFanOut — a broadcast recalled after prepare_batches
+// wrote its batches, but before a batch task ran. The task still fires; the
+// batch row is what stops it, and the broadcast must not be completed over the
+// recall either.
+func TestRecalledBatchDoesNotFanOut(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	projectID := testProject(t, pool, "bcast-recall-test")
+
+	broadcastRepo := pg.NewBroadcastRepo(pool)
+	notificationRepo := pg.NewNotificationRepo(pool)
+	batchRepo := pg.NewBroadcastBatchRepo(pool)
+
+	broadcast, err := broadcastRepo.Create(ctx, entity.NewBroadcast(projectID, []byte(`{"t":"oops"}`), "product", "updates", "released"))
+	if err != nil {
+		t.Fatalf("create broadcast: %v", err)
+	}
+
+	batch, err := batchRepo.Create(ctx, entity.NewBroadcastBatch(broadcast.ID, []string{"r1", "r2"}))
+	if err != nil {
+		t.Fatalf("create batch: %v", err)
+	}
+
+	if err := broadcastRepo.Recall(ctx, projectID, broadcast.ID); err != nil {
+		t.Fatalf("recall: %v", err)
+	}
+
+	payload, err := json.Marshal(dto.BroadcastDeliveryTaskPayload{
+		ProjectID:       projectID,
+		BroadcastID:     broadcast.ID,
+		BatchID:         batch.ID,
+		RecipientExtIDs: []string{"r1", "r2"},
+		Payload:         []byte(`{"t":"oops"}`),
+		Channel:         "product",
+		Topic:           "updates",
+		Event:           "released",
+	})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	p := NewBroadcastDeliveryProcessor(pool, notificationRepo, broadcastRepo, batchRepo, nil, nil, nil, nil)
+	if err := p.ProcessTask(ctx, asynq.NewTask(task.TaskTypeBroadcastDelivery, payload)); err != nil {
+		t.Fatalf("process task: %v", err)
+	}
+
+	rollup, err := notificationRepo.StatusRollupForBroadcast(ctx, broadcast.ID)
+	if err != nil {
+		t.Fatalf("rollup: %v", err)
+	}
+	if len(rollup) != 0 {
+		t.Errorf("a recalled batch must write nothing, got %v", rollup)
+	}
+
+	got, err := broadcastRepo.GetByID(ctx, broadcast.ID)
+	if err != nil {
+		t.Fatalf("get broadcast: %v", err)
+	}
+	if got.Status != enum.BroadcastStatusRecalled {
+		t.Errorf("broadcast status = %q, want recalled to stick", got.Status)
+	}
+}
@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+	"github.com/mudgallabs/bodhveda/internal/job/task"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/pg"
+	"github.com/mudgallabs/tantra/dbx"
+)
+
+// TestRetriedFailedBatchCompletesBroadcast — the recovery path for a broadcast
+// stuck `enqueued` behind a batch that ran out of retries. Re-arming the batch
+// must reset its attempt and move it to a new task id, and once its delivery
+// succeeds the broadcast completes like any other.
+func TestRetriedFailedBatchCompletesBroadcast(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	projectID := testProject(t, pool, "bcast-retry-test")
+
+	broadcastRepo := pg.NewBroadcastRepo(pool)
+	notificationRepo := pg.NewNotificationRepo(pool)
+	batchRepo := pg.NewBroadcastBatchRepo(pool)
+
+	broadcast, err := broadcastRepo.Create(ctx, entity.NewBroadcast(projectID, []byte(`{"t":"hi"}`), "product", "updates", "released"))
+	if err != nil {
+		t.Fatalf("create broadcast: %v", err)
+	}
+
+	sent, err := batchRepo.Create(ctx, entity.NewBroadcastBatch(broadcast.ID, []string{"r1"}))
+	if err != nil {
+		t.Fatalf("create sent batch: %v", err)
+	}
+	if err := batchRepo.Update(ctx, sent.ID, entity.NewBroadcastBatchUpdatePayload(enum.BroadcastBatchStatusSuccess, 1, 0)); err != nil {
+		t.Fatalf("mark batch sent: %v", err)
+	}
+
+	stuck, err := batchRepo.Create(ctx, entity.NewBroadcastBatch(broadcast.ID, []string{"r2", "r3"}))
+	if err != nil {
+		t.Fatalf("create stuck batch: %v", err)
+	}
+	if err := batchRepo.Update(ctx, stuck.ID, entity.NewBroadcastBatchUpdatePayload(enum.BroadcastBatchStatusFailed, 3, 0)); err != nil {
+		t.Fatalf("mark batch failed: %v", err)
+	}
+
+	failed, err := batchRepo.ListFailed(ctx, broadcast.ID)
+	if err != nil || len(failed) != 1 || failed[0].ID != stuck.ID {
+		t.Fatalf("failed batches = %v (err %v), want just %d", failed, err, stuck.ID)
+	}
+
+	var retried []*entity.BroadcastBatch
+	err = dbx.WithTx(ctx, pool, func(tx pgx.Tx) error {
+		retried, err = batchRepo.RetryFailedTx(ctx, tx, broadcast.ID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if len(retried) != 1 {
+		t.Fatalf("retried %d batches, want 1", len(retried))
+	}
+	b := retried[0]
+	if b.Status != enum.BroadcastBatchStatusEnqueued || b.Attempt != 0 || b.Retries != 1 {
+		t.Errorf("retried batch = status %q, attempt %d, retries %d; want enqueued, 0, 1", b.Status, b.Attempt, b.Retries)
+	}
+	if b.DeliveryTaskID() == stuck.DeliveryTaskID() {
+		t.Errorf("a retry must not reuse the exhausted task's id %q", stuck.DeliveryTaskID())
+	}
+
+	payload, err := json.Marshal(dto.NewBroadcastDeliveryTaskPayload(broadcast, b))
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+
+	p := NewBroadcastDeliveryProcessor(pool, notificationRepo, broadcastRepo, batchRepo, nil, nil, nil, nil)
+	if err := p.ProcessTask(ctx, asynq.NewTask(task.TaskTypeBroadcastDelivery, payload)); err != nil {
+		t.Fatalf("process task: %v", err)
+	}
+
+	got, err := broadcastRepo.GetByID(ctx, broadcast.ID)
+	if err != nil {
+		t.Fatalf("get broadcast: %v", err)
+	}
+	if got.Status != enum.BroadcastStatusCompleted {
+		t.Errorf("broadcast status = %q, want completed once the retried batch succeeds", got.Status)
+	}
+}
@@ -119,7 +119,7 @@ func TestBroadcastDeliveryWritesDeliveredNotifications(t *testing.T) {
 		t.Fatalf("marshal payload: %v", err)
 	}
 
-	p := NewBroadcastDeliveryProcessor(pool, notificationRepo, broadcastRepo, batchRepo, pg.NewPreferenceRepo(pool), nil, nil)
+	p := NewBroadcastDeliveryProcessor(pool, notificationRepo, broadcastRepo, batchRepo, pg.NewPreferenceRepo(pool), nil, nil, nil)
 	if err := p.ProcessTask(ctx, asynq.NewTask(task.TaskTypeBroadcastDelivery, payload)); err != nil {
 		t.Fatalf("process task: %v", err)
 	}
@@ -283,7 +283,7 @@ func TestBroadcastDeliveryIsIdempotentOnRetry(t *testing.T) {
 		t.Fatalf("marshal payload: %v", err)
 	}
 
-	p := NewBroadcastDeliveryProcessor(pool, notificationRepo, broadcastRepo, batchRepo, pg.NewPreferenceRepo(pool), nil, nil)
+	p := NewBroadcastDeliveryProcessor(pool, notificationRepo, broadcastRepo, batchRepo, pg.NewPreferenceRepo(pool), nil, nil, nil)
 	t2 := asynq.NewTask(task.TaskTypeBroadcastDelivery, payload)
 
 	if err := p.ProcessTask(ctx, t2); err != nil {
@@ -377,7 +377,7 @@ func TestBroadcastDeliveryReturnsErrorSoAsynqRetries(t *testing.T) {
 		t.Fatalf("marshal payload: %v", err)
 	}
 
-	p := NewBroadcastDeliveryProcessor(pool, notificationRepo, broadcastRepo, batchRepo, pg.NewPreferenceRepo(pool), nil, nil)
+	p := NewBroadcastDeliveryProcessor(pool, notificationRepo, broadcastRepo, batchRepo, pg.NewPreferenceRepo(pool), nil, nil, nil)
 	if err := p.ProcessTask(ctx, asynq.NewTask(task.TaskTypeBroadcastDelivery, payload)); err == nil {
 		t.Fatal("ProcessTask returned nil for a batch that could not be delivered; Asynq would ack it and never retry")
 	}
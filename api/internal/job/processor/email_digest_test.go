@@ -0,0 +1,212 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/mudgallabs/bodhveda/internal/email"
+	"github.com/mudgallabs/bodhveda/internal/env"
+	"github.com/mudgallabs/bodhveda/internal/job/task"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	tantraRepo "github.com/mudgallabs/tantra/repository"
+)
+
+// memDigests is EmailDigestRepository in memory, with the status transitions
+// the pg repo makes: only pending or failed items are waiting, MarkSent takes
+// them out of the digest, and MarkFailed leaves them in for the retry.
+type memDigests struct {
+	mu      sync.Mutex
+	digests map[int64]*entity.EmailDigest
+	items   map[int64][]*entity.EmailDigestItem
+	sent    map[int64]bool // delivery id → digested
+}
+
+func (m *memDigests) ClaimDue(ctx context.Context, now, staleBefore time.Time, limit int) ([]int64, error) {
+	return nil, errors.New("not used by the processor")
+}
+
+func (m *memDigests) Get(ctx context.Context, id int64) (*entity.EmailDigest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d, ok := m.digests[id]
+	if !ok {
+		return nil, tantraRepo.ErrNotFound
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (m *memDigests) ListItems(ctx context.Context, id int64) ([]*entity.EmailDigestItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	waiting := []*entity.EmailDigestItem{}
+	for _, item := range m.items[id] {
+		if !m.sent[item.DeliveryID] {
+			waiting = append(waiting, item)
+		}
+	}
+	return waiting, nil
+}
+
+func (m *memDigests) ExpireItems(ctx context.Context, id int64, deliveryIDs []int64) error {
+	return nil
+}
+
+func (m *memDigests) MarkSent(ctx context.Context, id int64, provider, providerMessageID string, attempt int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.digests[id]
+	d.Status = enum.EmailDigestSent
+	d.ProviderMessageID = &providerMessageID
+	d.Attempt = attempt
+	for _, item := range m.items[id] {
+		m.sent[item.DeliveryID] = true
+	}
+	return nil
+}
+
+func (m *memDigests) MarkFailed(ctx context.Context, id int64, reason string, attempt int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	d := m.digests[id]
+	d.Status = enum.EmailDigestFailed
+	d.FailureReason = &reason
+	d.Attempt = attempt
+	return nil
+}
+
+func (m *memDigests) MarkExpired(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.digests[id].Status = enum.EmailDigestExpired
+	return nil
+}
+
+var _ repository.EmailDigestRepository = (*memDigests)(nil)
+
+type staticEmailSettings struct{ settings *entity.ProjectEmailSettings }
+
+func (s staticEmailSettings) Get(ctx context.Context, projectID int) (*entity.ProjectEmailSettings, error) {
+	return s.settings, nil
+}
+
+func (s staticEmailSettings) Upsert(ctx context.Context, settings *entity.ProjectEmailSettings) (*entity.ProjectEmailSettings, error) {
+	return settings, nil
+}
+
+// recordingProvider is the email provider: it keeps every message it accepted,
+// and refuses the first failFirst sends.
+type recordingProvider struct {
+	mu        sync.Mutex
+	failFirst int
+	calls     int
+	accepted  []email.Message
+}
+
+func (p *recordingProvider) Provider() enum.EmailProvider { return enum.EmailProviderResend }
+
+func (p *recordingProvider) Send(ctx context.Context, msg email.Message) (email.SendResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.calls <= p.failFirst {
+		return email.SendResult{}, errors.New("provider unavailable")
+	}
+	p.accepted = append(p.accepted, msg)
+	return email.SendResult{Provider: enum.EmailProviderResend, ProviderMessageID: fmt.Sprintf("msg-%d", len(p.accepted))}, nil
+}
+
+func (p *recordingProvider) VerifyWebhookSignature(secret string, headers http.Header, body []byte) error {
+	return nil
+}
+
+func (p *recordingProvider) NormalizeWebhookEvent(headers http.Header, body []byte) (email.NormalizedEvent, error) {
+	return email.NormalizedEvent{}, nil
+}
+
+func digestTask(t *testing.T, id int64) *asynq.Task {
+	t.Helper()
+	payload, err := json.Marshal(dto.EmailDigestTaskPayload{DigestID: id})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	return asynq.NewTask(task.TaskTypeEmailDigest, payload)
+}
+
+// TestEmailDigestFlushesEachDigestOnce — every claimed digest goes out as one
+// email carrying all of its items, and nothing about how the task is re-run
+// sends it again: a failed attempt that Asynq retries sends once, and a task
+// re-enqueued after the digest went out (ClaimDue's stale re-claim) sends
+// nothing.
+func TestEmailDigestFlushesEachDigestOnce(t *testing.T) {
+	prev := env.CipherKey
+	env.CipherKey = "0123456789abcdef0123456789abcdef"
+	t.Cleanup(func() { env.CipherKey = prev })
+
+	settings, err := entity.NewProjectEmailSettings(1, enum.EmailProviderResend, "re_test", "Test", "test@example.com")
+	if err != nil {
+		t.Fatalf("settings: %v", err)
+	}
+
+	repo := &memDigests{digests: map[int64]*entity.EmailDigest{}, items: map[int64][]*entity.EmailDigestItem{}, sent: map[int64]bool{}}
+	for id := int64(1); id <= 3; id++ {
+		address := fmt.Sprintf("r%d@example.com", id)
+		repo.digests[id] = &entity.EmailDigest{ID: id, ProjectID: 1, RecipientExtID: fmt.Sprintf("r%d", id), Status: enum.EmailDigestSending, AddressSnapshot: &address}
+		for n := int64(0); n < id; n++ {
+			repo.items[id] = append(repo.items[id], &entity.EmailDigestItem{DeliveryID: id*10 + n, Subject: fmt.Sprintf("item %d", n), HTML: "<p>hi</p>"})
+		}
+	}
+
+	provider := &recordingProvider{failFirst: 1}
+	p := NewEmailDigestProcessor(repo, staticEmailSettings{settings})
+	p.newAdapter = func(enum.EmailProvider, string) (email.Adapter, error) { return provider, nil }
+
+	ctx := context.Background()
+	if err := p.ProcessTask(ctx, digestTask(t, 1)); err == nil {
+		t.Fatalf("a refused send must fail the task so Asynq retries it")
+	}
+	if got := repo.digests[1].Status; got != enum.EmailDigestFailed {
+		t.Fatalf("digest 1 after a refused send = %q, want failed", got)
+	}
+
+	// The retry, the other two digests, and a stale re-enqueue of each.
+	for _, id := range []int64{1, 2, 3, 1, 2, 3} {
+		if err := p.ProcessTask(ctx, digestTask(t, id)); err != nil {
+			t.Fatalf("process digest %d: %v", id, err)
+		}
+	}
+
+	if len(provider.accepted) != 3 {
+		t.Fatalf("accepted %d emails, want one per digest (3)", len(provider.accepted))
+	}
+	keys := map[string]bool{}
+	for i, msg := range provider.accepted {
+		id := int64(i + 1)
+		if msg.To != fmt.Sprintf("r%d@example.com", id) {
+			t.Errorf("email %d went to %q", i, msg.To)
+		}
+		if keys[msg.IdempotencyKey] {
+			t.Errorf("idempotency key %q reused across digests", msg.IdempotencyKey)
+		}
+		keys[msg.IdempotencyKey] = true
+		for n := int64(0); n < id; n++ {
+			if !strings.Contains(msg.HTML, fmt.Sprintf("item %d", n)) {
+				t.Errorf("digest %d email is missing item %d", id, n)
+			}
+		}
+		if got := repo.digests[id].Status; got != enum.EmailDigestSent {
+			t.Errorf("digest %d status = %q, want sent", id, got)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/mudgallabs/bodhveda/internal/service"
 	"github.com/mudgallabs/tantra/dbx"
 	"github.com/mudgallabs/tantra/logger"
+	tantraRepo "github.com/mudgallabs/tantra/repository"
 )
 
 // currentAttempt returns the 1-based attempt number for the task being processed
@@ -109,6 +110,41 @@ func (processor *EmailDeliveryProcessor) ProcessTask(ctx context.Context, t *asy
 		return cause
 	}
 
+	// Recalled while the task waited. Checked first: a recalled email is not
+	// sent, expired or not. The recall already wrote `recalled` on the row when
+	// it found it unsent; the write here covers a delivery created after the
+	// recall, whose notification is the one that says so.
+	recalled, err := processor.deliveryRepo.Recalled(ctx, payload.DeliveryID)
+	if err != nil {
+		return fmt.Errorf("check delivery recalled: %w", err)
+	}
+	if recalled {
+		err := processor.deliveryRepo.UpdateResult(ctx, payload.DeliveryID, repository.NotificationDeliveryResult{
+			Status:  enum.DeliveryRecalled,
+			Attempt: attempt,
+		})
+		if err != nil {
+			return fmt.Errorf("update delivery recalled status: %w", err)
+		}
+		logger.Get().Infof("EmailDeliveryProcessor: delivery %d was recalled before it was sent", payload.DeliveryID)
+		return nil
+	}
+
+	// A task that runs after the notification expired — parked behind a backlog,
+	// or retried past it — does not send. Checked before the provider is touched,
+	// and on every attempt, since each retry moves the clock on.
+	if payload.ExpiresAt != nil && !payload.ExpiresAt.After(time.Now()) {
+		err := processor.deliveryRepo.UpdateResult(ctx, payload.DeliveryID, repository.NotificationDeliveryResult{
+			Status:  enum.DeliveryExpired,
+			Attempt: attempt,
+		})
+		if err != nil {
+			return fmt.Errorf("update delivery expired status: %w", err)
+		}
+		logger.Get().Infof("EmailDeliveryProcessor: delivery %d expired before it was sent", payload.DeliveryID)
+		return nil
+	}
+
 	settings, err := processor.projectEmailRepo.Get(ctx, payload.ProjectID)
 	if err != nil {
 		return fail("provider_not_configured", fmt.Errorf("get project email settings: %w", err))
@@ -165,6 +201,140 @@ func (processor *EmailDeliveryProcessor) ProcessTask(ctx context.Context, t *asy
 	return nil
 }
 
+// EmailDigestProcessor sends one claimed email digest: every item still waiting
+// in it, wrapped in the project's digest HTML, as a single email. On success the
+// digest records the provider message id and each delivery in it becomes
+// `digested`; on failure both record the attempt and Asynq retries.
+type EmailDigestProcessor struct {
+	digestRepo       repository.EmailDigestRepository
+	projectEmailRepo repository.ProjectEmailSettingsRepository
+	// newAdapter is email.NewAdapter. Tests swap in a provider that records
+	// what it was asked to send.
+	newAdapter func(provider enum.EmailProvider, apiKey string) (email.Adapter, error)
+}
+
+func NewEmailDigestProcessor(
+	digestRepo repository.EmailDigestRepository,
+	projectEmailRepo repository.ProjectEmailSettingsRepository,
+) *EmailDigestProcessor {
+	return &EmailDigestProcessor{
+		digestRepo:       digestRepo,
+		projectEmailRepo: projectEmailRepo,
+		newAdapter:       email.NewAdapter,
+	}
+}
+
+func (processor *EmailDigestProcessor) ProcessTask(ctx context.Context, t *asynq.Task) error {
+	var payload dto.EmailDigestTaskPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		err = fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+		logger.Get().Error(err)
+		return err
+	}
+
+	attempt := currentAttempt(ctx)
+
+	digest, err := processor.digestRepo.Get(ctx, payload.DigestID)
+	if err != nil {
+		if errors.Is(err, tantraRepo.ErrNotFound) {
+			// The project was deleted while the digest waited.
+			logger.Get().Infof("EmailDigestProcessor: digest %d no longer exists; skipping", payload.DigestID)
+			return nil
+		}
+		return fmt.Errorf("get email digest: %w", err)
+	}
+
+	// Re-enqueued after it already went out (see ClaimDue's stale re-claim).
+	if digest.Status == enum.EmailDigestSent || digest.Status == enum.EmailDigestExpired {
+		return nil
+	}
+
+	fail := func(reason string, cause error) error {
+		if err := processor.digestRepo.MarkFailed(ctx, digest.ID, reason, attempt); err != nil {
+			logger.Get().Errorf("EmailDigestProcessor: mark digest %d failed: %v", digest.ID, err)
+		}
+		return cause
+	}
+
+	items, err := processor.digestRepo.ListItems(ctx, digest.ID)
+	if err != nil {
+		return fmt.Errorf("list email digest items: %w", err)
+	}
+
+	// Items that expired while they waited are dropped, exactly as a late
+	// email:delivery task would drop its one email.
+	now := time.Now()
+	var expired []int64
+	var live []email.DigestItem
+	for _, item := range items {
+		if item.ExpiresAt != nil && !item.ExpiresAt.After(now) {
+			expired = append(expired, item.DeliveryID)
+			continue
+		}
+		text := ""
+		if item.Text != nil {
+			text = *item.Text
+		}
+		live = append(live, email.DigestItem{Subject: item.Subject, HTML: item.HTML, Text: text})
+	}
+
+	if err := processor.digestRepo.ExpireItems(ctx, digest.ID, expired); err != nil {
+		return fmt.Errorf("expire email digest items: %w", err)
+	}
+
+	if len(live) == 0 {
+		if err := processor.digestRepo.MarkExpired(ctx, digest.ID); err != nil {
+			return fmt.Errorf("mark email digest expired: %w", err)
+		}
+		logger.Get().Infof("EmailDigestProcessor: digest %d had nothing left to send", digest.ID)
+		return nil
+	}
+
+	if digest.AddressSnapshot == nil {
+		// Defensive: every digest is opened with an address. One with nowhere to go
+		// must not retry forever.
+		return fail("no_address", fmt.Errorf("email digest %d has no address: %w", digest.ID, asynq.SkipRetry))
+	}
+
+	settings, err := processor.projectEmailRepo.Get(ctx, digest.ProjectID)
+	if err != nil {
+		return fail("provider_not_configured", fmt.Errorf("get project email settings: %w", err))
+	}
+
+	apiKey, err := settings.DecryptSecret()
+	if err != nil {
+		return fail("secret_decrypt_error", fmt.Errorf("decrypt provider secret: %w", err))
+	}
+
+	adapter, err := processor.newAdapter(settings.Provider, apiKey)
+	if err != nil {
+		return fail("adapter_init_error", fmt.Errorf("build email adapter: %w", err))
+	}
+
+	subject, html, text := email.RenderDigest(settings.DigestSubject, settings.DigestHTML, live)
+
+	result, err := adapter.Send(ctx, email.Message{
+		FromName:    settings.FromName,
+		FromAddress: settings.FromAddress,
+		To:          *digest.AddressSnapshot,
+		Subject:     subject,
+		HTML:        html,
+		Text:        text,
+		// Per digest, not per attempt, so a retry cannot send the digest twice.
+		IdempotencyKey: fmt.Sprintf("bodhveda-digest-%d", digest.ID),
+	})
+	if err != nil {
+		return fail("provider_send_error", fmt.Errorf("send email digest: %w", err))
+	}
+
+	if err := processor.digestRepo.MarkSent(ctx, digest.ID, string(result.Provider), result.ProviderMessageID, attempt); err != nil {
+		return fmt.Errorf("mark email digest sent: %w", err)
+	}
+
+	logger.Get().Infof("EmailDigestProcessor: sent digest %d with %d items (provider message id %s)", digest.ID, len(live), result.ProviderMessageID)
+	return nil
+}
+
 type PrepareBroadcastBatchesProcessor struct {
 	db                 *pgxpool.Pool
 	asynqClient        *asynq.Client
@@ -233,7 +403,23 @@ func (processor *PrepareBroadcastBatchesProcessor) ProcessTask(ctx context.Conte
 			return fmt.Errorf("lock broadcast: %w", err)
 		}
 
-		// Already finished — completed, or refused for quota. Nothing to prepare
+		// A scheduled broadcast whose send_at has come. It becomes an ordinary
+		// enqueued one under this lock, so a cancel racing it either lands first
+		// (and the status below reads `cancelled`) or finds it no longer
+		// scheduled and is refused.
+		if status == enum.BroadcastStatusScheduled {
+			broadcast.Status = enum.BroadcastStatusEnqueued
+			broadcast.UpdatedAt = time.Now().UTC()
+
+			if err := processor.broadcastRepo.UpdateTx(ctx, tx, broadcast); err != nil {
+				return fmt.Errorf("release scheduled broadcast: %w", err)
+			}
+
+			status = enum.BroadcastStatusEnqueued
+		}
+
+		// Already finished — completed, refused for quota, or cancelled (before
+		// it went out, or while this task sat in the queue). Nothing to prepare
 		// and nothing to resume.
 		if status != enum.BroadcastStatusEnqueued {
 			return nil
@@ -276,7 +462,20 @@ func (processor *PrepareBroadcastBatchesProcessor) prepareTx(
 	ctx context.Context, tx pgx.Tx, broadcast *entity.Broadcast,
 	target dto.Target, userID int, enqueue *[]*entity.BroadcastBatch,
 ) error {
-	recipientExtIDs, err := processor.preferenceRepo.ListEligibleRecipientExtIDsForBroadcast(ctx, broadcast.ProjectID, target, enum.MediumInApp)
+	// A list-based broadcast's audience is its list, read once here and
+	// intersected with eligibility for every medium below.
+	recipients := service.BroadcastRecipients{ProjectID: broadcast.ProjectID, Target: target, Segment: broadcast.Segment}
+	if broadcast.IsListBased() {
+		var err error
+		if recipients.List, err = processor.broadcastRepo.RecipientListTx(ctx, tx, broadcast.ID); err != nil {
+			return fmt.Errorf("read broadcast recipient list: %w", err)
+		}
+	}
+
+	// The segment narrows every audience below — in-app, email, and the frozen
+	// breakdown — in the same SQL as eligibility, so nothing is batched, billed
+	// or counted for a recipient the sender left out.
+	recipientExtIDs, err := recipients.Eligible(ctx, processor.preferenceRepo, enum.MediumInApp)
 	if err != nil {
 		return fmt.Errorf("list eligible recipient external IDs: %w", err)
 	}
@@ -289,7 +488,7 @@ func (processor *PrepareBroadcastBatchesProcessor) prepareTx(
 	//
 	// Best-effort: this is reporting, so a failure here must never fail the
 	// fan-out. The tree renders a missing audience as "not recorded".
-	if audience, err := processor.preferenceRepo.CountBroadcastAudience(ctx, broadcast.ProjectID, target, enum.MediumInApp); err != nil {
+	if audience, err := recipients.CountAudience(ctx, processor.preferenceRepo); err != nil {
 		logger.Get().Errorf("PrepareBroadcastBatchesProcessor: count audience for broadcast %d: %v", broadcast.ID, err)
 	} else {
 		// Eligible comes from the list we actually fan out to, not the aggregate:
@@ -316,9 +515,7 @@ func (processor *PrepareBroadcastBatchesProcessor) prepareTx(
 	fanOut := recipientExtIDs
 
 	if broadcast.Email != nil && processor.notificationService != nil {
-		emailEligible, err := processor.preferenceRepo.ListEligibleRecipientExtIDsForBroadcast(
-			ctx, broadcast.ProjectID, target, enum.MediumEmail,
-		)
+		emailEligible, err := recipients.Eligible(ctx, processor.preferenceRepo, enum.MediumEmail)
 		if err != nil {
 			return fmt.Errorf("list email-eligible recipients: %w", err)
 		}
@@ -331,7 +528,7 @@ func (processor *PrepareBroadcastBatchesProcessor) prepareTx(
 			return fmt.Errorf("resolve broadcast email audience: %w", err)
 		}
 
-		fanOut = unionExtIDs(recipientExtIDs, emailEligible)
+		fanOut = service.UnionRecipientIDs(recipientExtIDs, emailEligible)
 	}
 
 	event := dto.UsageEvent{
@@ -348,7 +545,7 @@ func (processor *PrepareBroadcastBatchesProcessor) prepareTx(
 	//
 	// It has to be handled before the usage call, twice over:
 	//
-	//  1. `usage_log` has CHECK (amount > 0), so consuming 0 units raised
+	//  1. `usage_log` CHECKs that amount is not 0, so consuming 0 units raised
 	//     SQLSTATE 23514, the task errored, and Asynq retried it until it was
 	//     ARCHIVED. Silent work loss with a failing queue — the exact shape of
 	//     the 2026-07-27 incident, reproduced here by an empty audience.
@@ -421,25 +618,6 @@ func (processor *PrepareBroadcastBatchesProcessor) prepareTx(
 	return nil
 }
 
-// unionExtIDs merges two recipient lists, preserving the order of the first and
-// dropping duplicates. Order matters only for determinism of batch slicing.
-func unionExtIDs(a, b []string) []string {
-	seen := make(map[string]struct{}, len(a)+len(b))
-	out := make([]string, 0, len(a)+len(b))
-
-	for _, list := range [][]string{a, b} {
-		for _, id := range list {
-			if _, dup := seen[id]; dup {
-				continue
-			}
-			seen[id] = struct{}{}
-			out = append(out, id)
-		}
-	}
-
-	return out
-}
-
 // enqueueBatches publishes one delivery task per batch, AFTER the preparation
 // transaction has committed.
 //
@@ -450,17 +628,15 @@ func unionExtIDs(a, b []string) []string {
 func (processor *PrepareBroadcastBatchesProcessor) enqueueBatches(
 	ctx context.Context, broadcast *entity.Broadcast, batches []*entity.BroadcastBatch,
 ) error {
+	// A prepare task enqueued before priorities existed carries a broadcast
+	// with none; its batches go where every broadcast's do by default.
+	priority := broadcast.Priority
+	if priority == "" {
+		priority = enum.PriorityBulk
+	}
+
 	for _, batch := range batches {
-		payload, err := json.Marshal(dto.BroadcastDeliveryTaskPayload{
-			ProjectID:       broadcast.ProjectID,
-			BroadcastID:     broadcast.ID,
-			BatchID:         batch.ID,
-			RecipientExtIDs: batch.RecipientExtIDs,
-			Payload:         broadcast.Payload,
-			Channel:         broadcast.Channel,
-			Topic:           broadcast.Topic,
-			Event:           broadcast.Event,
-		})
+		payload, err := json.Marshal(dto.NewBroadcastDeliveryTaskPayload(broadcast, batch))
 		if err != nil {
 			err = fmt.Errorf("marshal broadcast delivery task payload: %w", err)
 			logger.Get().Error(err)
@@ -474,7 +650,8 @@ func (processor *PrepareBroadcastBatchesProcessor) enqueueBatches(
 		_, err = processor.asynqClient.EnqueueContext(ctx,
 			asynq.NewTask(task.TaskTypeBroadcastDelivery, payload),
 			asynq.MaxRetry(3),
-			asynq.TaskID(fmt.Sprintf("broadcast-batch-%d", batch.ID)),
+			asynq.Queue(task.Queue(priority)),
+			asynq.TaskID(batch.DeliveryTaskID()),
 		)
 		if err != nil {
 			if errors.Is(err, asynq.ErrTaskIDConflict) {
@@ -496,6 +673,7 @@ type BroadcastDeliveryProcessor struct {
 	broadcastRepo       repository.BroadcastRepository
 	broadcastBatchRepo  repository.BroadcastBatchRepository
 	preferenceRepo      repository.PreferenceRepository
+	recipientRepo       repository.RecipientRepository
 	notificationService *service.NotificationService
 	asynqClient         *asynq.Client
 }
@@ -503,7 +681,7 @@ type BroadcastDeliveryProcessor struct {
 func NewBroadcastDeliveryProcessor(
 	db *pgxpool.Pool, notificationRepo repository.NotificationRepository,
 	broadcastRepo repository.BroadcastRepository, broadcastBatchRepo repository.BroadcastBatchRepository,
-	preferenceRepo repository.PreferenceRepository,
+	preferenceRepo repository.PreferenceRepository, recipientRepo repository.RecipientRepository,
 	notificationService *service.NotificationService, asynqClient *asynq.Client,
 ) *BroadcastDeliveryProcessor {
 	return &BroadcastDeliveryProcessor{
@@ -512,6 +690,7 @@ func NewBroadcastDeliveryProcessor(
 		broadcastRepo:       broadcastRepo,
 		broadcastBatchRepo:  broadcastBatchRepo,
 		preferenceRepo:      preferenceRepo,
+		recipientRepo:       recipientRepo,
 		notificationService: notificationService,
 		asynqClient:         asynqClient,
 	}
@@ -550,8 +729,9 @@ func (processor *BroadcastDeliveryProcessor) ProcessTask(ctx context.Context, t
 	// with an error instead of being absorbed — it would convert a benign retry
 	// into a failed one. It would also put a second index on `notification`,
 	// which is the send hot path. See agent-docs/delivery-feedback-design.md §3.3.
-	var alreadyDelivered bool
+	var alreadyDelivered, batchStopped bool
 	var emailTasks []dto.EmailDeliveryTaskPayload
+	var written []*entity.Notification
 
 	// Loaded before the transaction, not inside it: this is the durable record of
 	// what to send (content, and whether the email half was blocked at prepare
@@ -575,6 +755,36 @@ func (processor *BroadcastDeliveryProcessor) ProcessTask(ctx context.Context, t
 			return nil
 		}
 
+		// The broadcast was recalled or cancelled before this batch fanned out.
+		// Both mark the unrun batches under the broadcast's row lock, so the batch
+		// carries its parent's status and there is no need to lock the parent here.
+		// Write nothing; the broadcast is already final, so there is no completion
+		// to check either.
+		if status == enum.BroadcastBatchStatusRecalled || status == enum.BroadcastBatchStatusCancelled {
+			batchStopped = true
+			return nil
+		}
+
+		// The broadcast's payload as of now, not as of the task: the broadcast may
+		// have been edited since the batch was enqueued, and rows written from the
+		// task's copy would be the only stale ones in the feed.
+		content, variants, err := processor.broadcastRepo.ContentTx(ctx, tx, payload.BroadcastID)
+		if err != nil {
+			return fmt.Errorf("read broadcast payload: %w", err)
+		}
+		broadcast.Payload, broadcast.Variants = content, variants
+
+		// Localized variants are picked per recipient by locale, read for the
+		// whole batch at once. No recipient repo wired, or no variants to pick
+		// from: everyone gets the default.
+		var locales map[string]string
+		if len(variants) > 0 && processor.recipientRepo != nil {
+			locales, err = processor.recipientRepo.Locales(ctx, payload.ProjectID, payload.RecipientExtIDs)
+			if err != nil {
+				return fmt.Errorf("read recipient locales: %w", err)
+			}
+		}
+
 		notifications := make([]*entity.Notification, 0, len(payload.RecipientExtIDs))
 
 		// ⚠️ Broadcast notifications are DELIVERED at insert, not `enqueued`.
@@ -627,11 +837,19 @@ func (processor *BroadcastDeliveryProcessor) ProcessTask(ctx context.Context, t
 		}
 
 		for _, recipientExtID := range payload.RecipientExtIDs {
+			body := content
+			if localized := variants.PayloadFor(locales[recipientExtID]); localized != nil {
+				body = localized
+			}
+
 			n := entity.NewNotification(
-				payload.ProjectID, recipientExtID, payload.Payload,
+				payload.ProjectID, recipientExtID, body,
 				&payload.BroadcastID, payload.Channel, payload.Topic, payload.Event,
 			)
 
+			n.ExpiresAt = broadcast.ExpiresAt
+			n.Priority = broadcast.Priority
+
 			if _, ok := inAppEligible[recipientExtID]; ok {
 				n.Status = enum.NotificationStatusDelivered
 			} else {
@@ -645,13 +863,14 @@ func (processor *BroadcastDeliveryProcessor) ProcessTask(ctx context.Context, t
 		if err := processor.notificationRepo.BatchCreateTx(ctx, tx, notifications); err != nil {
 			return fmt.Errorf("batch create notifications: %w", err)
 		}
+		written = notifications
 
 		// The email half. Delivery rows are written here, in the same transaction
 		// as the notifications they hang off; the send tasks are returned and
 		// enqueued only after commit, so no worker can pick up a delivery row that
 		// does not exist yet.
 		if processor.notificationService != nil {
-			emailTasks, err = processor.notificationService.FanOutBroadcastEmail(ctx, tx, broadcast, notifications)
+			emailTasks, err = processor.notificationService.FanOutBroadcastEmail(ctx, tx, broadcast, notifications, locales)
 			if err != nil {
 				return fmt.Errorf("fan out broadcast email: %w", err)
 			}
@@ -683,14 +902,28 @@ func (processor *BroadcastDeliveryProcessor) ProcessTask(ctx context.Context, t
 		return err
 	}
 
+	if batchStopped {
+		logger.Get().Infow("broadcast batch recalled or cancelled, skipping fan-out",
+			"batch_id", payload.BatchID, "broadcast_id", payload.BroadcastID)
+		return nil
+	}
+
 	// ⚠️ After commit. A failure here leaves delivery rows `pending` with no task —
 	// visible in the tree as stuck rather than silently lost — and returning the
 	// error lets Asynq retry, where the batch guard makes the insert a no-op and
 	// this loop is reached again.
-	if err := processor.enqueueEmailTasks(ctx, emailTasks); err != nil {
+	if err := processor.enqueueEmailTasks(ctx, emailTasks, broadcast.Priority); err != nil {
 		return err
 	}
 
+	// Open inbox streams hear about the rows only once they are committed, or a
+	// client could fetch a notification that then rolls back. Empty on a retry
+	// that found the batch already delivered; if the attempt that wrote it died
+	// before getting here, streams still pick the rows up on their next replay.
+	if processor.notificationService != nil {
+		processor.notificationService.PublishInboxCreated(ctx, written)
+	}
+
 	if alreadyDelivered {
 		logger.Get().Infow("broadcast batch already delivered, skipping re-insert",
 			"batch_id", payload.BatchID, "broadcast_id", payload.BroadcastID, "attempt", attempt)
@@ -711,25 +944,11 @@ func (processor *BroadcastDeliveryProcessor) ProcessTask(ctx context.Context, t
 		return err
 	}
 
-	// All batches processed, we can mark the broadcast as completed.
+	// All batches processed, we can mark the broadcast as completed. Complete
+	// only moves an `enqueued` broadcast, so a recall that landed after this
+	// batch committed stays recalled.
 	if remaining == 0 {
-		broadcast, err := processor.broadcastRepo.GetByID(ctx, payload.BroadcastID)
-		if err != nil {
-			// ⚠️ Must return: the next line dereferences `broadcast`, so falling
-			// through here panicked the worker on any lookup failure.
-			err = fmt.Errorf("get broadcast by ID: %w", err)
-			logger.Get().Error(err)
-			return err
-		}
-
-		now := time.Now().UTC()
-		broadcast.CompletedAt = &now
-		broadcast.Status = enum.BroadcastStatusCompleted
-		broadcast.UpdatedAt = now
-
-		err = processor.broadcastRepo.Update(ctx, broadcast)
-		if err != nil {
-			err = fmt.Errorf("update broadcast: %w", err)
+		if err := processor.broadcastRepo.Complete(ctx, payload.BroadcastID); err != nil {
 			logger.Get().Error(err)
 			return err
 		}
@@ -912,7 +1131,10 @@ func (processor *DeleteProjectDataProcessor) ProcessTask(ctx context.Context, t
 // cannot enqueue a second send for the same delivery row. The email adapter also
 // sends a per-delivery idempotency key to the provider, so a duplicate would have
 // to get past both to become a duplicate email.
-func (processor *BroadcastDeliveryProcessor) enqueueEmailTasks(ctx context.Context, tasks []dto.EmailDeliveryTaskPayload) error {
+//
+// The emails run in the broadcast's lane, so a bulk broadcast's mail cannot
+// crowd out a direct send's.
+func (processor *BroadcastDeliveryProcessor) enqueueEmailTasks(ctx context.Context, tasks []dto.EmailDeliveryTaskPayload, priority enum.Priority) error {
 	for _, t := range tasks {
 		body, err := json.Marshal(t)
 		if err != nil {
@@ -924,6 +1146,7 @@ func (processor *BroadcastDeliveryProcessor) enqueueEmailTasks(ctx context.Conte
 		_, err = processor.asynqClient.EnqueueContext(ctx,
 			asynq.NewTask(task.TaskTypeEmailDelivery, body),
 			asynq.MaxRetry(3),
+			asynq.Queue(task.Queue(priority)),
 			asynq.TaskID(fmt.Sprintf("broadcast-email-delivery-%d", t.DeliveryID)),
 		)
 		if err != nil {
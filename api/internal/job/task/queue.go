@@ -0,0 +1,27 @@
+package task
+
+import "github.com/mudgallabs/bodhveda/internal/model/enum"
+
+// The Asynq queues tasks are routed to, one per enum.Priority.
+//
+// ⚠️ Normal work stays on `default`, the queue everything ran on before
+// priorities existed. Renaming it would strand tasks already sitting in Redis —
+// scheduled sends can be parked there for weeks — on a queue no worker reads.
+const (
+	QueueCritical = "critical"
+	QueueDefault  = "default"
+	QueueBulk     = "bulk"
+)
+
+// Queue is the queue a task of priority p is enqueued to. An empty priority is
+// normal: task payloads enqueued before priorities existed carry none.
+func Queue(p enum.Priority) string {
+	switch p {
+	case enum.PriorityCritical:
+		return QueueCritical
+	case enum.PriorityBulk:
+		return QueueBulk
+	default:
+		return QueueDefault
+	}
+}
@@ -4,6 +4,7 @@ package task
 const (
 	TaskTypeNotificationDelivery    = "notification:delivery"
 	TaskTypeEmailDelivery           = "email:delivery"
+	TaskTypeEmailDigest             = "email:digest"
 	TaskTypePrepareBroadcastBatches = "broadcast:prepare_batches"
 	TaskTypeBroadcastDelivery       = "broadcast:delivery"
 	TaskTypeDeleteRecipientData     = "recipient:delete_data"
@@ -15,6 +15,7 @@ import (
 	"github.com/mudgallabs/bodhveda/internal/model/dto"
 	"github.com/mudgallabs/bodhveda/internal/model/entity"
 	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/recipienttoken"
 	"github.com/mudgallabs/tantra/auth/session"
 	"github.com/mudgallabs/tantra/cipher"
 	"github.com/mudgallabs/tantra/httpx"
@@ -251,6 +252,118 @@ func VerifyAPIKeyHasFullScope(next http.Handler) http.Handler {
 	})
 }
 
+// RecipientTokenHeader carries a recipient-bound token (see package
+// recipienttoken) alongside the API key on the recipient sub-routes.
+const RecipientTokenHeader = "X-Recipient-Token"
+
+// VerifyRecipientToken binds a request on /recipients/{recipient_external_id}/...
+// to the recipient the caller has proven it is.
+//
+// A recipient-scope key names a project and nothing more, so on its own it let
+// any holder act on any recipient id it typed into the path (todo.md, the IDOR
+// section). The token closes that:
+//
+//   - A token that is PRESENT is always verified, for every scope: bad signature
+//     or expired is 401, a token for another project is 401, and a token for
+//     another recipient is 403. A caller that bothered to send one has said who
+//     it is, and a mismatch is never what it meant.
+//   - A token that is ABSENT is fine for a full-scope key (the customer's own
+//     server, which can already act as anyone) and, unless the project has
+//     require_recipient_token on, for a recipient-scope key too — the pre-token
+//     behaviour, kept so existing integrations don't break on deploy.
+//
+// ⚠️ Must run BEFORE CreateRecipientIfNotExists. Otherwise a rejected request
+// would still have provisioned whatever recipient id it named.
+func VerifyRecipientToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		apiKey := GetAPIKeyFromContext(ctx)
+
+		recipientExtID := strings.ToLower(httpx.ParamStr(r, "recipient_external_id"))
+		if recipientExtID == "" {
+			httpx.BadRequestResponse(w, r, errors.New("recipient_external_id required"))
+			return
+		}
+
+		token := strings.TrimSpace(r.Header.Get(RecipientTokenHeader))
+
+		_, refused, err := AuthorizeRecipient(ctx, apiKey, recipientExtID, token)
+		if err != nil {
+			httpx.InternalServerErrorResponse(w, r, err)
+			return
+		}
+		if refused != nil {
+			if errors.Is(refused.Err, ErrRecipientTokenRequired) {
+				refused.Message += " Send it in the " + RecipientTokenHeader + " header."
+			}
+			if refused.Forbidden {
+				httpx.ForbiddenResponse(w, r, refused.Message, refused.Err)
+			} else {
+				httpx.UnauthorizedResponse(w, r, refused.Message, refused.Err)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ErrRecipientTokenRequired is the Err of a refusal for sending no token to a
+// project that requires one. Where the token goes depends on the transport, so
+// the caller adds that to the message.
+var ErrRecipientTokenRequired = errors.New("recipient token required")
+
+// RecipientAuthError is why AuthorizeRecipient refused a recipient: a 403 when
+// Forbidden, a 401 otherwise.
+type RecipientAuthError struct {
+	Forbidden bool
+	Message   string
+	Err       error
+}
+
+// AuthorizeRecipient is VerifyRecipientToken's decision without the HTTP, for
+// callers that name recipients somewhere other than the path — the inbox
+// gateway names them in messages. token is "" when none was sent. A nil
+// refusal and nil error means the caller may act as recipientExtID, until
+// expiresAt when it was a token that authorized it (zero otherwise). A request
+// is over long before that; a connection that outlives it must stop acting as
+// the recipient.
+func AuthorizeRecipient(ctx context.Context, apiKey *entity.APIKey, recipientExtID, token string) (expiresAt time.Time, refused *RecipientAuthError, err error) {
+	if token == "" {
+		if apiKey.Scope == enum.APIKeyScopeFull {
+			return time.Time{}, nil, nil
+		}
+
+		// Read with the key itself (see APIKeyRepo.GetByTokenHash): this branch
+		// is every tokenless feed poll, and it must not cost a query.
+		if apiKey.ProjectRequiresRecipientToken {
+			return time.Time{}, &RecipientAuthError{Message: "This project requires a recipient token, minted by your server.", Err: ErrRecipientTokenRequired}, nil
+		}
+
+		return time.Time{}, nil, nil
+	}
+
+	claims, err := recipienttoken.Parse(token, []byte(env.HashKey))
+	if err != nil {
+		if errors.Is(err, recipienttoken.ErrExpired) {
+			return time.Time{}, &RecipientAuthError{Message: "Recipient token has expired", Err: err}, nil
+		}
+		return time.Time{}, &RecipientAuthError{Message: "Invalid recipient token", Err: err}, nil
+	}
+
+	// Same message as a bad signature: a token from another project is not a
+	// credential here, and saying so would confirm the project exists.
+	if claims.ProjectID != apiKey.ProjectID {
+		return time.Time{}, &RecipientAuthError{Message: "Invalid recipient token", Err: errors.New("recipient token project mismatch")}, nil
+	}
+
+	if claims.RecipientExtID != recipientExtID {
+		return time.Time{}, &RecipientAuthError{Forbidden: true, Message: "Recipient token does not match this recipient.", Err: errors.New("recipient token recipient mismatch")}, nil
+	}
+
+	return time.Unix(claims.ExpiresAt, 0), nil, nil
+}
+
 func CreateRecipientIfNotExists(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
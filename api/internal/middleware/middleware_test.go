@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	// chi v1 — the version cmd/api/routes.go and tantra's httpx.ParamStr use.
+	"github.com/go-chi/chi"
+	"github.com/mudgallabs/bodhveda/internal/email"
+	"github.com/mudgallabs/bodhveda/internal/env"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/recipienttoken"
+)
+
+const testHashKey = "test-hash-key-material-0123456789"
+
+// signForTest signs claims verbatim in the recipient token format, so a test
+// can craft tokens Build would never produce (expired ones).
+func signForTest(t *testing.T, claims recipienttoken.Claims) string {
+	t.Helper()
+	body, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte(testHashKey))
+	mac.Write(body)
+	return base64.RawURLEncoding.EncodeToString(body) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func mintForTest(t *testing.T, projectID int, recipientExtID string, key string) string {
+	t.Helper()
+	token, _, err := recipienttoken.Build(projectID, recipientExtID, recipienttoken.DefaultTTL, []byte(key))
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	return token
+}
+
+// serveRecipientRoute sends one request through VerifyRecipientToken, mounted
+// on a recipient path the way cmd/api/routes.go mounts it, and returns the
+// status. 200 means the request reached the handler.
+func serveRecipientRoute(t *testing.T, apiKey *entity.APIKey, recipientExtID, token string) int {
+	t.Helper()
+
+	r := chi.NewRouter()
+	r.With(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxAPIKey, apiKey)))
+		})
+	}, VerifyRecipientToken).Get("/recipients/{recipient_external_id}/notifications", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/recipients/"+recipientExtID+"/notifications", nil)
+	if token != "" {
+		req.Header.Set(RecipientTokenHeader, token)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestVerifyRecipientToken(t *testing.T) {
+	prev := env.HashKey
+	env.HashKey = testHashKey
+	t.Cleanup(func() { env.HashKey = prev })
+
+	recipientKey := &entity.APIKey{ProjectID: 7, Scope: enum.APIKeyScopeRecipient}
+	requiringKey := &entity.APIKey{ProjectID: 7, Scope: enum.APIKeyScopeRecipient, ProjectRequiresRecipientToken: true}
+	fullKey := &entity.APIKey{ProjectID: 7, Scope: enum.APIKeyScopeFull, ProjectRequiresRecipientToken: true}
+
+	expired := signForTest(t, recipienttoken.Claims{
+		Kind: "recipient", ProjectID: 7, RecipientExtID: "u1", ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+	})
+	unsubscribe, err := email.BuildUnsubscribeToken(email.UnsubscribeClaims{
+		ProjectID: 7, RecipientExtID: "u1", Channel: "email",
+	}, []byte(testHashKey))
+	if err != nil {
+		t.Fatalf("build unsubscribe token: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		apiKey    *entity.APIKey
+		recipient string
+		token     string
+		want      int
+	}{
+		{"no token, full scope", fullKey, "u1", "", http.StatusOK},
+		{"no token, token not required", recipientKey, "u1", "", http.StatusOK},
+		{"no token, token required", requiringKey, "u1", "", http.StatusUnauthorized},
+		{"valid token", requiringKey, "u1", mintForTest(t, 7, "u1", testHashKey), http.StatusOK},
+		{"path id is lowercased before the match", requiringKey, "U1", mintForTest(t, 7, "u1", testHashKey), http.StatusOK},
+		{"bad signature", recipientKey, "u1", mintForTest(t, 7, "u1", "some-other-key"), http.StatusUnauthorized},
+		{"wrong recipient", recipientKey, "u2", mintForTest(t, 7, "u1", testHashKey), http.StatusForbidden},
+		{"wrong recipient, full scope", fullKey, "u2", mintForTest(t, 7, "u1", testHashKey), http.StatusForbidden},
+		{"another project", recipientKey, "u1", mintForTest(t, 8, "u1", testHashKey), http.StatusUnauthorized},
+		{"expired", recipientKey, "u1", expired, http.StatusUnauthorized},
+		{"unsubscribe token", recipientKey, "u1", unsubscribe, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serveRecipientRoute(t, tt.apiKey, tt.recipient, tt.token); got != tt.want {
+				t.Fatalf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAuthorizeRecipient_Refusals pins what a refusal says, since the gateway
+// relays it to the client as-is.
+func TestAuthorizeRecipient_Refusals(t *testing.T) {
+	prev := env.HashKey
+	env.HashKey = testHashKey
+	t.Cleanup(func() { env.HashKey = prev })
+
+	key := &entity.APIKey{ProjectID: 7, Scope: enum.APIKeyScopeRecipient}
+
+	expired := signForTest(t, recipienttoken.Claims{
+		Kind: "recipient", ProjectID: 7, RecipientExtID: "u1", ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+	})
+	_, refused, err := AuthorizeRecipient(context.Background(), key, "u1", expired)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if refused == nil || refused.Forbidden || refused.Message != "Recipient token has expired" {
+		t.Fatalf("expired token refusal = %+v, want a 401 saying it expired", refused)
+	}
+
+	// No token where one is required: the helper does not say where a token
+	// goes, since that differs between the routes and the gateway.
+	requiring := &entity.APIKey{ProjectID: 7, Scope: enum.APIKeyScopeRecipient, ProjectRequiresRecipientToken: true}
+	_, missing, _ := AuthorizeRecipient(context.Background(), requiring, "u1", "")
+	if missing == nil || !errors.Is(missing.Err, ErrRecipientTokenRequired) || strings.Contains(missing.Message, RecipientTokenHeader) {
+		t.Fatalf("missing token refusal = %+v, want ErrRecipientTokenRequired with no transport in the message", missing)
+	}
+
+	// A token for another project reads exactly like a forged one.
+	_, forged, _ := AuthorizeRecipient(context.Background(), key, "u1", mintForTest(t, 7, "u1", "some-other-key"))
+	_, foreign, _ := AuthorizeRecipient(context.Background(), key, "u1", mintForTest(t, 8, "u1", testHashKey))
+	if forged == nil || foreign == nil || forged.Message != foreign.Message {
+		t.Fatalf("foreign project refusal = %+v, want the same message as a bad signature (%+v)", foreign, forged)
+	}
+}
+
+// TestAuthorizeRecipient_ReportsExpiry — what a token authorizes lasts until
+// the token expires, and what needed no token has no end.
+func TestAuthorizeRecipient_ReportsExpiry(t *testing.T) {
+	prev := env.HashKey
+	env.HashKey = testHashKey
+	t.Cleanup(func() { env.HashKey = prev })
+
+	key := &entity.APIKey{ProjectID: 7, Scope: enum.APIKeyScopeRecipient}
+
+	token, want, err := recipienttoken.Build(7, "u1", recipienttoken.DefaultTTL, []byte(testHashKey))
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	expiresAt, refused, err := AuthorizeRecipient(context.Background(), key, "u1", token)
+	if err != nil || refused != nil {
+		t.Fatalf("authorize = %+v, %v", refused, err)
+	}
+	if !expiresAt.Equal(want) {
+		t.Errorf("expiresAt = %s, want the token's %s", expiresAt, want)
+	}
+
+	expiresAt, _, _ = AuthorizeRecipient(context.Background(), key, "u1", "")
+	if !expiresAt.IsZero() {
+		t.Errorf("tokenless expiresAt = %s, want zero", expiresAt)
+	}
+}
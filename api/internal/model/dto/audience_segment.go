@@ -0,0 +1,106 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/tantra/apires"
+	"github.com/mudgallabs/tantra/service"
+)
+
+const (
+	// MaxAudienceSegmentDepth and MaxAudienceSegmentNodes bound a broadcast's
+	// `audience`. Each node becomes a few SQL conditions in the eligibility scan,
+	// so the bounds are on the query we are willing to build, not on expressiveness.
+	MaxAudienceSegmentDepth = 5
+	MaxAudienceSegmentNodes = 50
+
+	// MaxAudienceReadWithinDays is a year, which is as far back as "engaged
+	// recently" reasonably goes.
+	MaxAudienceReadWithinDays = 365
+
+	maxExternalIDPrefixLen = 255
+)
+
+// validateAudienceSegment checks a broadcast's audience segment, normalizing tags
+// and attribute keys in place the way the recipient list filters do.
+func validateAudienceSegment(errs *service.InputValidationErrors, segment *entity.AudienceSegment) {
+	nodes := 0
+	validateAudienceNode(errs, segment, "audience", 1, &nodes)
+	if nodes > MaxAudienceSegmentNodes {
+		errs.Add(apires.NewApiError("Audience too large", fmt.Sprintf("An audience can have at most %d conditions in total.", MaxAudienceSegmentNodes), "audience", nodes))
+	}
+}
+
+func validateAudienceNode(errs *service.InputValidationErrors, node *entity.AudienceSegment, field string, depth int, nodes *int) {
+	*nodes++
+
+	if depth > MaxAudienceSegmentDepth {
+		errs.Add(apires.NewApiError("Audience too deep", fmt.Sprintf("all/any can be nested at most %d levels deep.", MaxAudienceSegmentDepth), field, nil))
+		return
+	}
+
+	// An empty node would match everyone, which is almost certainly not what
+	// the sender meant. Omit `audience` to reach everyone.
+	if !node.HasConditions() && len(node.All) == 0 && len(node.Any) == 0 {
+		errs.Add(apires.NewApiError("Empty audience condition", "Each audience condition must test something. Omit 'audience' to send to everyone.", field, nil))
+		return
+	}
+	if node.All != nil && len(node.All) == 0 {
+		errs.Add(apires.NewApiError("Empty audience condition", "'all' needs at least one condition.", field+".all", nil))
+	}
+	if node.Any != nil && len(node.Any) == 0 {
+		errs.Add(apires.NewApiError("Empty audience condition", "'any' needs at least one condition.", field+".any", nil))
+	}
+
+	if node.CreatedAfter != nil && node.CreatedBefore != nil && !node.CreatedAfter.Before(*node.CreatedBefore) {
+		errs.Add(apires.NewApiError("Invalid created range", "created_after must be before created_before.", field+".created_after", node.CreatedAfter))
+	}
+
+	if node.HasContact != nil && !node.HasContact.ValidContactMedium() {
+		errs.Add(apires.NewApiError("Invalid contact medium", "has_contact must be one of 'email', 'sms', 'web_push' or 'mobile_push'.", field+".has_contact", node.HasContact))
+	}
+	if node.LacksContact != nil && !node.LacksContact.ValidContactMedium() {
+		errs.Add(apires.NewApiError("Invalid contact medium", "lacks_contact must be one of 'email', 'sms', 'web_push' or 'mobile_push'.", field+".lacks_contact", node.LacksContact))
+	}
+
+	if node.ReadWithinDays != nil && (*node.ReadWithinDays < 1 || *node.ReadWithinDays > MaxAudienceReadWithinDays) {
+		errs.Add(apires.NewApiError("Invalid read_within_days", fmt.Sprintf("read_within_days must be between 1 and %d.", MaxAudienceReadWithinDays), field+".read_within_days", node.ReadWithinDays))
+	}
+
+	if node.ExternalIDPrefix != nil {
+		if *node.ExternalIDPrefix == "" || len(*node.ExternalIDPrefix) > maxExternalIDPrefixLen {
+			errs.Add(apires.NewApiError("Invalid external_id_prefix", fmt.Sprintf("external_id_prefix must be 1-%d characters.", maxExternalIDPrefixLen), field+".external_id_prefix", node.ExternalIDPrefix))
+		}
+		// Recipient ids are stored lowercase, so a prefix has to be too.
+		prefix := strings.ToLower(*node.ExternalIDPrefix)
+		node.ExternalIDPrefix = &prefix
+	}
+
+	if node.Tag != nil {
+		tag := strings.ToLower(strings.TrimSpace(*node.Tag))
+		if tag == "" || len(tag) > maxTagLen {
+			errs.Add(apires.NewApiError("Invalid tag", fmt.Sprintf("Tags are 1-%d characters", maxTagLen), field+".tag", node.Tag))
+		}
+		node.Tag = &tag
+	}
+
+	if node.Attribute != nil {
+		key, value, ok := strings.Cut(*node.Attribute, ":")
+		key = strings.TrimSpace(key)
+		if !ok || !attributeKeyRe.MatchString(key) {
+			errs.Add(apires.NewApiError("Invalid attribute", "attribute must be 'key:value', with a key of 1-64 letters, digits, '_', '.' or '-'.", field+".attribute", node.Attribute))
+		} else {
+			attribute := key + ":" + value
+			node.Attribute = &attribute
+		}
+	}
+
+	for i := range node.All {
+		validateAudienceNode(errs, &node.All[i], fmt.Sprintf("%s.all[%d]", field, i), depth+1, nodes)
+	}
+	for i := range node.Any {
+		validateAudienceNode(errs, &node.Any[i], fmt.Sprintf("%s.any[%d]", field, i), depth+1, nodes)
+	}
+}
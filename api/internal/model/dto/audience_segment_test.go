@@ -0,0 +1,77 @@
+package dto
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func broadcastWithAudience(t *testing.T, audience string) *SendNotificationPayload {
+	t.Helper()
+
+	p := &SendNotificationPayload{
+		ProjectID: 1,
+		Target:    &Target{Channel: "launch", Topic: "none", Event: "announced"},
+		Payload:   json.RawMessage(`{"title":"hi"}`),
+	}
+	if err := json.Unmarshal([]byte(audience), &p.Audience); err != nil {
+		t.Fatalf("unmarshal audience %s: %v", audience, err)
+	}
+	return p
+}
+
+func TestAudienceSegmentValidation(t *testing.T) {
+	valid := `{
+		"has_contact": "email",
+		"created_after": "2026-01-01T00:00:00Z",
+		"created_before": "2026-06-01T00:00:00Z",
+		"any": [{"tag": " Beta "}, {"read_within_days": 30}, {"attribute": "plan:pro"}],
+		"all": [{"external_id_prefix": "Team_"}]
+	}`
+	p := broadcastWithAudience(t, valid)
+	if err := p.Validate(); err != nil {
+		t.Fatalf("valid audience rejected: %v", err)
+	}
+	if got := *p.Audience.Any[0].Tag; got != "beta" {
+		t.Errorf("tag = %q, want it normalized like recipient tags", got)
+	}
+	if got := *p.Audience.All[0].ExternalIDPrefix; got != "team_" {
+		t.Errorf("external_id_prefix = %q, want it lowercased like recipient ids", got)
+	}
+
+	bad := []struct{ audience, field string }{
+		{`{}`, "audience"},
+		{`{"any": []}`, "audience"},
+		{`{"all": [{"tag": "x"}], "any": []}`, "audience.any"},
+		{`{"all": [{}]}`, "audience.all[0]"},
+		{`{"created_after": "2026-06-01T00:00:00Z", "created_before": "2026-01-01T00:00:00Z"}`, "audience.created_after"},
+		{`{"has_contact": "in_app"}`, "audience.has_contact"},
+		{`{"read_within_days": 0}`, "audience.read_within_days"},
+		{`{"read_within_days": 366}`, "audience.read_within_days"},
+		{`{"external_id_prefix": ""}`, "audience.external_id_prefix"},
+		{`{"tag": "  "}`, "audience.tag"},
+		{`{"attribute": "plan"}`, "audience.attribute"},
+		{`{"all":[{"all":[{"all":[{"all":[{"all":[{"tag":"x"}]}]}]}]}]}`, "audience.all[0].all[0].all[0].all[0].all[0]"},
+	}
+	for _, tt := range bad {
+		if err := broadcastWithAudience(t, tt.audience).Validate(); !hasErrorFor(err, tt.field) {
+			t.Errorf("audience %s: want an error on %s, got %v", tt.audience, tt.field, err)
+		}
+	}
+}
+
+func TestAudienceSegmentTooManyNodes(t *testing.T) {
+	children := strings.Repeat(`{"tag":"x"},`, MaxAudienceSegmentNodes)
+	p := broadcastWithAudience(t, `{"any":[`+strings.TrimSuffix(children, ",")+`]}`)
+	if err := p.Validate(); !hasErrorFor(err, "audience") {
+		t.Errorf("want too-large error, got %v", err)
+	}
+}
+
+func TestAudienceRejectedOnDirectSend(t *testing.T) {
+	p := broadcastWithAudience(t, `{"tag": "beta"}`)
+	p.RecipientExtID = strptr("u1")
+	if err := p.Validate(); !hasErrorFor(err, "audience") {
+		t.Errorf("want audience rejected on a direct send, got %v", err)
+	}
+}
@@ -15,8 +15,20 @@ type Broadcast struct {
 	Target      Target               `json:"target"`
 	Status      enum.BroadcastStatus `json:"status"`
 	CompletedAt *time.Time           `json:"completed_at,omitempty"`
-	CreatedAt   time.Time            `json:"created_at"`
-	UpdatedAt   time.Time            `json:"updated_at"`
+	// SendAt is present on a scheduled broadcast, before and after it fires.
+	SendAt    *time.Time    `json:"send_at,omitempty"`
+	ExpiresAt *time.Time    `json:"expires_at,omitempty"`
+	Priority  enum.Priority `json:"priority"`
+	Version   int           `json:"version"`
+	// Variants is the broadcast's localized content, keyed by BCP 47 tag.
+	Variants entity.ContentVariants `json:"variants,omitempty"`
+	// Audience is the segment the broadcast was narrowed to, if any.
+	Audience *entity.AudienceSegment `json:"audience,omitempty"`
+	// RecipientListSize is set when the broadcast was sent to a list of
+	// recipient ids, and is how many.
+	RecipientListSize *int      `json:"recipient_list_size,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 func FromBroadcast(broadcast *entity.Broadcast) *Broadcast {
@@ -32,10 +44,17 @@ func FromBroadcast(broadcast *entity.Broadcast) *Broadcast {
 			Topic:   broadcast.Topic,
 			Event:   broadcast.Event,
 		},
-		Status:      broadcast.Status,
-		CompletedAt: broadcast.CompletedAt,
-		CreatedAt:   broadcast.CreatedAt,
-		UpdatedAt:   broadcast.UpdatedAt,
+		Status:            broadcast.Status,
+		CompletedAt:       broadcast.CompletedAt,
+		SendAt:            broadcast.SendAt,
+		ExpiresAt:         broadcast.ExpiresAt,
+		Priority:          broadcast.Priority,
+		Version:           broadcast.Version,
+		Variants:          broadcast.Variants,
+		Audience:          broadcast.Segment,
+		RecipientListSize: broadcast.RecipientListSize,
+		CreatedAt:         broadcast.CreatedAt,
+		UpdatedAt:         broadcast.UpdatedAt,
 	}
 }
 
@@ -49,6 +68,10 @@ func FromBroadcast(broadcast *entity.Broadcast) *Broadcast {
 type DeliveryTree struct {
 	Kind   enum.NotificationKind `json:"kind"`
 	Target Target                `json:"target"`
+	// ScheduledFor is set while the send is still waiting for its send_at. A
+	// scheduled broadcast has no rows and no audience yet, so without it the
+	// tree would be indistinguishable from one whose fan-out never ran.
+	ScheduledFor *time.Time `json:"scheduled_for,omitempty"`
 	// Audience is present for broadcasts only, and only once fan-out has run.
 	Audience *DeliveryTreeAudience `json:"audience,omitempty"`
 	// Mediums is the per-medium breakdown. Today in_app for broadcasts, and
@@ -57,6 +80,51 @@ type DeliveryTree struct {
 	Mediums []DeliveryTreeMedium `json:"mediums"`
 }
 
+// BroadcastPreviewSampleSize is how many recipient ids a dry run lists.
+const BroadcastPreviewSampleSize = 20
+
+// BroadcastPreview is what a broadcast would do if it were sent right now,
+// worked out by the eligibility rule prepare_batches applies but writing
+// nothing. It counts rather than lists, so its cost does not grow with the
+// audience.
+//
+// ⚠️ It is a forecast, not a reservation. Preferences, recipients and usage can
+// all move between the preview and the send — and a scheduled broadcast is only
+// resolved at its send_at.
+type BroadcastPreview struct {
+	// Audience is the in-app breakdown the broadcast would freeze at fan-out.
+	// Expandable is always false, as on the delivery tree.
+	Audience DeliveryTreeAudience `json:"audience"`
+	// Email is set when the send carries an email block.
+	Email *BroadcastPreviewEmail `json:"email,omitempty"`
+	// Recipients is how many notifications the broadcast would write: its in-app
+	// audience plus anyone it would reach by email alone. That is also what it
+	// would be billed.
+	Recipients int `json:"recipients"`
+	// Usage is Recipients against the plan's quota. A broadcast that would exceed
+	// it is not sent at all.
+	Usage *UsageEstimate `json:"usage"`
+	// SampleRecipientIDs is up to BroadcastPreviewSampleSize of the recipients
+	// it would reach, the first by external id.
+	SampleRecipientIDs []string `json:"sample_recipient_ids"`
+	// UnknownRecipientIDs are the ids on a list-based broadcast's list with no
+	// recipient. With create_missing_recipients they would be created first.
+	UnknownRecipientIDs []string `json:"unknown_recipient_ids,omitempty"`
+}
+
+// BroadcastPreviewEmail is the email half of a dry run.
+type BroadcastPreviewEmail struct {
+	// Eligible is everyone who enabled the target for email, whatever their
+	// in-app preference.
+	Eligible int `json:"eligible"`
+	// Cap is the project's max_broadcast_recipients_for_email. 0 when the
+	// project has no email settings.
+	Cap int `json:"cap"`
+	// BlockedReason is the entity.EmailBlocked* reason the email half would be
+	// blocked for. Empty when it would send.
+	BlockedReason string `json:"blocked_reason,omitempty"`
+}
+
 // DeliveryTreeAudience is the frozen recipient breakdown for a broadcast.
 type DeliveryTreeAudience struct {
 	Total    int `json:"total"`
@@ -67,6 +135,12 @@ type DeliveryTreeAudience struct {
 	// row, or a disabled one). This is the usual reason a broadcast silently
 	// reaches nobody, and it is a config mistake rather than a recipient choice.
 	ExcludedNotCataloged int `json:"excluded_not_cataloged"`
+	// ExcludedSegment — outside the broadcast's `audience`. Counted before the
+	// preference buckets, so those only describe recipients the segment kept.
+	ExcludedSegment int `json:"excluded_segment"`
+	// ExcludedUnknown — on a list-based broadcast, ids on the list with no
+	// recipient when it fanned out. Always 0 otherwise.
+	ExcludedUnknown int `json:"excluded_unknown"`
 	// ⚠️ Expandable is false for broadcasts, and the console MUST respect it.
 	// Excluded recipients are filtered out before any row is written, so there is
 	// nothing to drill into — only a count. On a DIRECT send the same situation
@@ -93,6 +167,13 @@ type DeliveryTreeMedium struct {
 	// Pending is the count still in flight. Non-zero long after the send is the
 	// signature of a stalled worker, which is the whole reason this view exists.
 	Pending int `json:"pending"`
+	// Scheduled is the count waiting for its send_at. Reported apart from Pending
+	// because it is expected to sit still.
+	Scheduled int `json:"scheduled"`
+	// Recalled is the count the sender pulled back. Already inside Outcomes'
+	// `suppressed`; broken out because "we recalled 4,812 of these" is the
+	// question an operator asks right after a recall.
+	Recalled int `json:"recalled"`
 }
 
 // InAppMediumFromRollup builds the in_app branch from a per-status rollup of
@@ -117,6 +198,12 @@ func InAppMediumFromRollup(rollup map[enum.NotificationStatus]int) DeliveryTreeM
 		if !status.Terminal() {
 			m.Pending += count
 		}
+		if status == enum.NotificationStatusScheduled {
+			m.Scheduled += count
+		}
+		if status == enum.NotificationStatusRecalled {
+			m.Recalled += count
+		}
 	}
 
 	return m
@@ -140,6 +227,9 @@ func EmailMediumFromDelivery(status *enum.DeliveryStatus) (DeliveryTreeMedium, b
 	if !status.Terminal() {
 		m.Pending = 1
 	}
+	if *status == enum.DeliveryRecalled {
+		m.Recalled = 1
+	}
 
 	return m, true
 }
@@ -161,3 +251,42 @@ type ListBroadcastssResult struct {
 	Broadcasts []*BroadcastListItem `json:"broadcasts"`
 	Pagination query.PaginationMeta `json:"pagination"`
 }
+
+// BroadcastBatch is one slice of a broadcast's fan-out, as shown to an operator
+// recovering a broadcast that did not complete. The recipient ids are left out:
+// a batch holds up to a thousand of them, and the count is what is asked for.
+type BroadcastBatch struct {
+	ID         int                       `json:"id"`
+	Recipients int                       `json:"recipients"`
+	Status     enum.BroadcastBatchStatus `json:"status"`
+	Attempt    int                       `json:"attempt"`
+	Retries    int                       `json:"retries"`
+	CreatedAt  time.Time                 `json:"created_at"`
+	UpdatedAt  time.Time                 `json:"updated_at"`
+}
+
+func FromBroadcastBatch(batch *entity.BroadcastBatch) *BroadcastBatch {
+	return &BroadcastBatch{
+		ID:         batch.ID,
+		Recipients: batch.Recipients,
+		Status:     batch.Status,
+		Attempt:    batch.Attempt,
+		Retries:    batch.Retries,
+		CreatedAt:  batch.CreatedAt,
+		UpdatedAt:  batch.UpdatedAt,
+	}
+}
+
+func FromBroadcastBatches(batches []*entity.BroadcastBatch) []*BroadcastBatch {
+	out := make([]*BroadcastBatch, 0, len(batches))
+	for _, b := range batches {
+		out = append(out, FromBroadcastBatch(b))
+	}
+	return out
+}
+
+type RetryBroadcastBatchesResult struct {
+	Broadcast *Broadcast `json:"broadcast"`
+	// Batches are the ones re-enqueued, now back to `enqueued`.
+	Batches []*BroadcastBatch `json:"batches"`
+}
@@ -80,6 +80,28 @@ func TestInAppRollupCountsNotRequested(t *testing.T) {
 	}
 }
 
+// A recalled broadcast is suppressed, not failed, and its count is broken out so
+// the tree can say how much of the send was pulled back.
+func TestInAppRollupCountsRecalled(t *testing.T) {
+	m := InAppMediumFromRollup(map[enum.NotificationStatus]int{
+		enum.NotificationStatusDelivered: 3,
+		enum.NotificationStatusRecalled:  7,
+	})
+
+	if m.Total != 10 {
+		t.Errorf("total = %d, want 10", m.Total)
+	}
+	if m.Recalled != 7 {
+		t.Errorf("recalled = %d, want 7", m.Recalled)
+	}
+	if got := m.Outcomes[string(enum.OutcomeSuppressed)]; got != 7 {
+		t.Errorf("recalled should be suppressed, got %d", got)
+	}
+	if got := m.Outcomes[string(enum.OutcomeFailed)]; got != 0 {
+		t.Errorf("recalled must not count as failed, got %d", got)
+	}
+}
+
 func TestInAppRollupOnEmptyBroadcast(t *testing.T) {
 	m := InAppMediumFromRollup(map[enum.NotificationStatus]int{})
 
@@ -134,4 +156,12 @@ func TestEmailMediumFromDelivery(t *testing.T) {
 			t.Errorf("muted email must not count as failed, got %d", got)
 		}
 	})
+
+	t.Run("recalled before it went out", func(t *testing.T) {
+		status := enum.DeliveryRecalled
+		m, _ := EmailMediumFromDelivery(&status)
+		if m.Recalled != 1 || m.Pending != 0 {
+			t.Errorf("recalled is terminal and counted, got recalled=%d pending=%d", m.Recalled, m.Pending)
+		}
+	})
 }
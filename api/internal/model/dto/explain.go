@@ -0,0 +1,209 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/tantra/apires"
+	"github.com/mudgallabs/tantra/service"
+)
+
+// ExplainKind is the kind of send an explanation is for. The two resolve the
+// same cascade with different defaults, and make different checks after it.
+type ExplainKind string
+
+const (
+	ExplainKindDirect    ExplainKind = "direct"
+	ExplainKindBroadcast ExplainKind = "broadcast"
+)
+
+// ExplainRecipientPayload is the query of GET /recipients/{id}/explain.
+type ExplainRecipientPayload struct {
+	CheckRecipientTargetPayload
+	// Kind defaults to direct when omitted (query param `kind`).
+	Kind ExplainKind `json:"kind" schema:"kind"`
+}
+
+func (q *ExplainRecipientPayload) Validate() error {
+	var errs service.InputValidationErrors
+
+	if err := q.CheckRecipientTargetPayload.Validate(); err != nil {
+		if inputErrs, ok := err.(service.InputValidationErrors); ok {
+			errs = inputErrs
+		} else {
+			return err
+		}
+	}
+
+	if q.Kind == "" {
+		q.Kind = ExplainKindDirect
+	}
+	if q.Kind != ExplainKindDirect && q.Kind != ExplainKindBroadcast {
+		errs.Add(apires.NewApiError("Invalid kind", "Kind must be one of: direct, broadcast", "kind", string(q.Kind)))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ExplainNotificationPayload is the query of GET /notifications/{id}/explain.
+type ExplainNotificationPayload struct {
+	// Medium defaults to the notification's own: in_app, or email for an
+	// email-only send.
+	Medium string `json:"medium" schema:"medium"`
+}
+
+func (q *ExplainNotificationPayload) Validate() error {
+	if q.Medium == "" {
+		return nil
+	}
+
+	q.Medium = string(normalizeMedium(q.Medium))
+	if apiErr, ok := validateMedium(enum.Medium(q.Medium)); !ok {
+		var errs service.InputValidationErrors
+		errs.Add(apiErr)
+		return errs
+	}
+	return nil
+}
+
+// ExplainResult is how one step of an explanation came out.
+type ExplainResult string
+
+const (
+	ExplainPass ExplainResult = "pass"
+	// ExplainFail is a step that stops the send on this medium.
+	ExplainFail ExplainResult = "fail"
+	// ExplainInfo is a step that changes how it goes out, not whether — a
+	// deferral, a digest — or one that cannot be evaluated without side effects.
+	ExplainInfo ExplainResult = "info"
+)
+
+// Explanation is the decision log for one recipient, target and medium: every
+// check the send path makes, in the order it makes them, with what each found.
+//
+// ⚠️ It is worked out against the project as it is NOW. For a notification sent
+// earlier, Recorded is what actually happened; the steps are what would happen
+// if it were sent again, which is the useful half when the two differ.
+type Explanation struct {
+	RecipientID string      `json:"recipient_id"`
+	Target      Target      `json:"target"`
+	Medium      string      `json:"medium"`
+	Kind        ExplainKind `json:"kind"`
+	// NotificationID is set when explaining a notification.
+	NotificationID *int `json:"notification_id,omitempty"`
+	// Deliver is the verdict: no step failed.
+	Deliver bool `json:"deliver"`
+	// Reason is the reason code of the first step that failed. Empty when
+	// Deliver.
+	Reason string        `json:"reason,omitempty"`
+	Steps  []ExplainStep `json:"steps"`
+	// Recorded is the outcome stored for the notification on Medium, when
+	// explaining one.
+	Recorded *ExplainRecorded `json:"recorded,omitempty"`
+}
+
+// Explanation step names, in the order a direct send reaches them.
+const (
+	ExplainStepRecipient     = "recipient"
+	ExplainStepStrictTargets = "strict_targets"
+	ExplainStepExpiry        = "expiry"
+	ExplainStepPreference    = "preference"
+	ExplainStepSuppression   = "suppression"
+	ExplainStepEmailBlocked  = "broadcast_email"
+	ExplainStepProvider      = "provider"
+	ExplainStepContact       = "contact"
+	ExplainStepFrequencyCap  = "frequency_cap"
+	ExplainStepDigest        = "digest"
+	ExplainStepQuietHours    = "quiet_hours"
+	ExplainStepQuota         = "quota"
+)
+
+// ExplainStep is one check. Detail says what it found in a sentence; the
+// optional fields carry the data behind it for the step that has some.
+type ExplainStep struct {
+	Step   string        `json:"step"`
+	Result ExplainResult `json:"result"`
+	// Reason is a stable code for a fail — the status or failure_reason the
+	// send would record, where there is one.
+	Reason string `json:"reason,omitempty"`
+	Detail string `json:"detail"`
+
+	Cascade      []ExplainRung        `json:"cascade,omitempty"`
+	Contact      *ExplainContact      `json:"contact,omitempty"`
+	FrequencyCap *ExplainFrequencyCap `json:"frequency_cap,omitempty"`
+	Usage        *UsageEstimate       `json:"usage,omitempty"`
+	// Until is when a deferral ends: the end of the recipient's quiet hours.
+	Until *time.Time `json:"until,omitempty"`
+	// ComplainedAt is when the recipient last reported this target as spam.
+	ComplainedAt *time.Time `json:"complained_at,omitempty"`
+}
+
+// ExplainRung is one rung of the preference cascade. The four stored rungs are
+// listed whether or not they matched, then the default.
+type ExplainRung struct {
+	Source entity.PreferenceSource `json:"source"`
+	// PreferenceID is the row the rung matched; nil when it matched none, and
+	// always nil for the default.
+	PreferenceID *int `json:"preference_id"`
+	// Enabled is what the rung says. Nil when it matched no row.
+	Enabled   *bool `json:"enabled"`
+	Mandatory bool  `json:"mandatory"`
+	// Consulted is false for the topic='any' rungs of a topic='none' target.
+	Consulted bool `json:"consulted"`
+	// Decided marks the rung whose answer the send uses. A mandatory project
+	// row decides ahead of the recipient's own rungs.
+	Decided bool `json:"decided"`
+}
+
+// ExplainRungsFromTrace lists the stored rungs and the default, marking the
+// one resolved decided.
+func ExplainRungsFromTrace(rungs []*entity.PreferenceRung, decided entity.PreferenceSource, defaultEnabled bool) []ExplainRung {
+	out := make([]ExplainRung, 0, len(rungs)+1)
+	for _, r := range rungs {
+		rung := ExplainRung{
+			Source:       r.Source,
+			PreferenceID: r.PreferenceID,
+			Mandatory:    r.Mandatory,
+			Consulted:    r.Consulted,
+			Decided:      r.Source == decided,
+		}
+		if r.PreferenceID != nil {
+			enabled := r.Enabled
+			rung.Enabled = &enabled
+		}
+		out = append(out, rung)
+	}
+
+	return append(out, ExplainRung{
+		Source:    entity.PreferenceSourceDefault,
+		Enabled:   &defaultEnabled,
+		Consulted: true,
+		Decided:   decided == entity.PreferenceSourceDefault,
+	})
+}
+
+// ExplainContact is the primary contact an email would go to.
+type ExplainContact struct {
+	ID       int64  `json:"id"`
+	Address  string `json:"address"`
+	Verified bool   `json:"verified"`
+}
+
+// ExplainFrequencyCap is the catalog entry's cap. The explanation reports it
+// but never counts against it.
+type ExplainFrequencyCap struct {
+	Limit  int            `json:"limit"`
+	Window enum.CapWindow `json:"window"`
+}
+
+// ExplainRecorded is the outcome stored for a notification on one medium.
+type ExplainRecorded struct {
+	// Status is the notification's in-app status, or the email delivery row's.
+	// Empty when no email delivery row was written.
+	Status        string  `json:"status,omitempty"`
+	FailureReason *string `json:"failure_reason,omitempty"`
+}
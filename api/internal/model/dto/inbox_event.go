@@ -0,0 +1,87 @@
+package dto
+
+import "time"
+
+// InboxEventType names a change to a recipient's feed. They are also the SSE
+// `event:` names of GET /recipients/{id}/notifications/stream.
+type InboxEventType string
+
+const (
+	// InboxEventCreated is a notification landing in the feed. Its SSE id is the
+	// notification id, which is what Last-Event-ID resumes from.
+	InboxEventCreated InboxEventType = "notification.created"
+	// InboxEventUpdated is a change to notifications already in the feed: a
+	// patched payload, or read/opened state.
+	InboxEventUpdated InboxEventType = "notification.updated"
+	// InboxEventDeleted is notifications leaving the feed: deleted by the
+	// recipient, recalled, collapsed under a newer send, or expired.
+	InboxEventDeleted InboxEventType = "notification.deleted"
+	// InboxEventUnreadCount is never published. The stream works it out after
+	// every other event, once per listener, so a send nobody is watching costs
+	// no COUNT.
+	InboxEventUnreadCount InboxEventType = "unread_count"
+	// InboxEventReset tells a resuming client it missed more than the stream
+	// replays, and should reload its feed rather than trust the events.
+	InboxEventReset InboxEventType = "reset"
+)
+
+// InboxEvent is one change to one recipient's feed, as fanned out across API
+// replicas and written to the stream.
+type InboxEvent struct {
+	Type InboxEventType `json:"type"`
+	// RecipientExtID addresses the event. It is the channel, not the message, so
+	// it is not serialized.
+	RecipientExtID string `json:"-"`
+
+	// Notification is set for created, and for an updated that replaced the
+	// payload.
+	Notification *Notification `json:"notification,omitempty"`
+	// IDs are the notifications an updated or deleted touched. Empty with All
+	// set means every notification in the feed.
+	IDs []int `json:"ids,omitempty"`
+	All bool  `json:"all,omitempty"`
+	// Read / Opened / Seen are the state an updated set, when it set one.
+	Read   *bool `json:"read,omitempty"`
+	Opened *bool `json:"opened,omitempty"`
+	Seen   *bool `json:"seen,omitempty"`
+	// Archived and SnoozedUntil are an archive or a snooze. Either takes the
+	// notifications out of the inbox as a client shows it; a snooze that is
+	// already over puts them back.
+	Archived     *bool      `json:"archived,omitempty"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+
+	UnreadCount *int `json:"unread_count,omitempty"`
+}
+
+// NewInboxUpdatedEvent is the event for UpdateForRecipient, given the ids it
+// changed. An update of the whole feed says so instead of listing it.
+func NewInboxUpdatedEvent(recipientExtID string, payload UpdateRecipientNotificationsPayload, updated []int) *InboxEvent {
+	all := len(payload.IDs) == 0 && payload.Filters == nil
+	ids := updated
+	if all {
+		ids = nil
+	}
+
+	return &InboxEvent{
+		Type:           InboxEventUpdated,
+		RecipientExtID: recipientExtID,
+		IDs:            ids,
+		All:            all,
+		Read:           payload.State.Read,
+		Opened:         payload.State.Opened,
+		Seen:           payload.State.Seen,
+		Archived:       payload.Archived,
+		SnoozedUntil:   payload.SnoozeUntil,
+	}
+}
+
+// NewInboxDeletedEvent is the event for notifications leaving the feed. No ids
+// means all of them.
+func NewInboxDeletedEvent(recipientExtID string, ids []int) *InboxEvent {
+	return &InboxEvent{
+		Type:           InboxEventDeleted,
+		RecipientExtID: recipientExtID,
+		IDs:            ids,
+		All:            len(ids) == 0,
+	}
+}
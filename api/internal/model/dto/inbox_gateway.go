@@ -0,0 +1,141 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// GatewayMaxAckIDs bounds the ids one ack may name.
+const GatewayMaxAckIDs = 100
+
+// GatewayRequestType is what a client asks the inbox gateway to do.
+type GatewayRequestType string
+
+const (
+	GatewaySubscribe   GatewayRequestType = "subscribe"
+	GatewayUnsubscribe GatewayRequestType = "unsubscribe"
+	// GatewayAck marks notifications read and/or opened, as PATCH
+	// /recipients/{id}/notifications does.
+	GatewayAck  GatewayRequestType = "ack"
+	GatewayPing GatewayRequestType = "ping"
+)
+
+// GatewayRequest is one client message. Every field but Type is optional for
+// some type; Validate says which.
+type GatewayRequest struct {
+	Type GatewayRequestType `json:"type"`
+	// ID is the client's, echoed on the reply so it can match the two.
+	ID          string `json:"id,omitempty"`
+	RecipientID string `json:"recipient_id,omitempty"`
+
+	// Token is the recipient token, for a subscribe. The same rules apply as to
+	// the X-Recipient-Token header on the recipient routes.
+	Token string `json:"token,omitempty"`
+	// LastEventID is the newest notification id the client already has, for a
+	// subscribe that resumes.
+	LastEventID int `json:"last_event_id,omitempty"`
+
+	// IDs, Read, Opened and Seen are an ack. An ack only ever sets state:
+	// unreading is a PATCH.
+	IDs    []int `json:"ids,omitempty"`
+	Read   bool  `json:"read,omitempty"`
+	Opened bool  `json:"opened,omitempty"`
+	Seen   bool  `json:"seen,omitempty"`
+}
+
+func (r *GatewayRequest) Validate() error {
+	r.RecipientID = strings.ToLower(strings.TrimSpace(r.RecipientID))
+
+	switch r.Type {
+	case GatewayPing:
+		return nil
+	case GatewaySubscribe, GatewayUnsubscribe:
+		if r.RecipientID == "" {
+			return errors.New("recipient_id is required")
+		}
+		if r.LastEventID < 0 {
+			return errors.New("last_event_id must be a notification id")
+		}
+		return nil
+	case GatewayAck:
+		if r.RecipientID == "" {
+			return errors.New("recipient_id is required")
+		}
+		if len(r.IDs) == 0 {
+			return errors.New("ids is required: an ack names the notifications it acknowledges")
+		}
+		if len(r.IDs) > GatewayMaxAckIDs {
+			return fmt.Errorf("an ack can name at most %d ids", GatewayMaxAckIDs)
+		}
+		if !r.Read && !r.Opened && !r.Seen {
+			return errors.New("an ack sets read, opened, seen, or several")
+		}
+		return nil
+	default:
+		return errors.New("type must be one of: subscribe, unsubscribe, ack, ping")
+	}
+}
+
+// UpdatePayload is the ack as UpdateForRecipient takes it.
+func (r *GatewayRequest) UpdatePayload() UpdateRecipientNotificationsPayload {
+	var state NotificationStateFilter
+	if r.Read {
+		state.Read = &r.Read
+	}
+	if r.Opened {
+		state.Opened = &r.Opened
+	}
+	if r.Seen {
+		state.Seen = &r.Seen
+	}
+	return UpdateRecipientNotificationsPayload{
+		NotificationIDsPayload: NotificationIDsPayload{IDs: r.IDs},
+		State:                  state,
+	}
+}
+
+// GatewayReplyType answers a GatewayRequest.
+type GatewayReplyType string
+
+const (
+	GatewaySubscribed   GatewayReplyType = "subscribed"
+	GatewayUnsubscribed GatewayReplyType = "unsubscribed"
+	GatewayAcked        GatewayReplyType = "acked"
+	GatewayPong         GatewayReplyType = "pong"
+	GatewayErrorReply   GatewayReplyType = "error"
+)
+
+// Gateway error codes.
+const (
+	GatewayErrInvalidMessage  = "invalid_message"
+	GatewayErrUnauthorized    = "unauthorized"
+	GatewayErrForbidden       = "forbidden"
+	GatewayErrSubscriptionCap = "subscription_limit"
+	GatewayErrNotSubscribed   = "not_subscribed"
+	GatewayErrInternal        = "internal_error"
+	// GatewayErrTokenExpired ends a subscription: the recipient token that
+	// authorized it has expired. It answers no request.
+	GatewayErrTokenExpired = "token_expired"
+)
+
+type GatewayReply struct {
+	Type        GatewayReplyType `json:"type"`
+	ID          string           `json:"id,omitempty"`
+	RecipientID string           `json:"recipient_id,omitempty"`
+	// Updated is how many notifications an ack changed.
+	Updated *int          `json:"updated,omitempty"`
+	Error   *GatewayError `json:"error,omitempty"`
+}
+
+type GatewayError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// GatewayEvent is an inbox event pushed to a connection, flattened so its type
+// is the message type and the recipient it is for sits beside it.
+type GatewayEvent struct {
+	RecipientID string `json:"recipient_id"`
+	*InboxEvent
+}
@@ -1,7 +1,10 @@
 package dto
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"html"
 	"strings"
 	"time"
@@ -22,8 +25,19 @@ type Notification struct {
 	State          NotificationState       `json:"state"`
 	Status         enum.NotificationStatus `json:"status"`
 	CompletedAt    *time.Time              `json:"completed_at,omitempty"`
-	CreatedAt      time.Time               `json:"created_at"`
-	UpdatedAt      time.Time               `json:"updated_at"`
+	// SendAt is present on a scheduled send, before and after it fires.
+	SendAt      *time.Time    `json:"send_at,omitempty"`
+	ExpiresAt   *time.Time    `json:"expires_at,omitempty"`
+	CollapseKey *string       `json:"collapse_key,omitempty"`
+	Priority    enum.Priority `json:"priority"`
+	// SnoozedUntil is present once the recipient has snoozed it, and stays
+	// after the snooze ends.
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+	// Version counts payload edits, starting at 1. A client caching rendered
+	// notifications re-renders one whose version has moved on.
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 	// Email is the email-medium delivery outcome for this notification, present
 	// only when the send included an email block. The console renders it beside
 	// the in-app Status so a diverging outcome (e.g. in-app muted, email
@@ -64,11 +78,16 @@ type NotificationEmailDelivery struct {
 type NotificationState struct {
 	Opened bool `json:"opened"`
 	Read   bool `json:"read"`
+	// Seen is the weakest of the three: the notification was on screen, in a
+	// list or a dropdown. It is what a "new" badge clears.
+	Seen     bool `json:"seen"`
+	Archived bool `json:"archived"`
 }
 
 type NotificationStateFilter struct {
 	Opened *bool `schema:"opened"`
 	Read   *bool `schema:"read"`
+	Seen   *bool `schema:"seen"`
 }
 
 func FromNotification(notification *entity.Notification) *Notification {
@@ -82,14 +101,22 @@ func FromNotification(notification *entity.Notification) *Notification {
 			Event:   notification.Event,
 		},
 		State: NotificationState{
-			Read:   notification.ReadAt != nil,
-			Opened: notification.OpenedAt != nil,
+			Read:     notification.ReadAt != nil,
+			Opened:   notification.OpenedAt != nil,
+			Seen:     notification.SeenAt != nil,
+			Archived: notification.ArchivedAt != nil,
 		},
-		BroadcastID: notification.BroadcastID,
-		Status:      notification.Status,
-		CompletedAt: notification.CompletedAt,
-		CreatedAt:   notification.CreatedAt,
-		UpdatedAt:   notification.UpdatedAt,
+		BroadcastID:  notification.BroadcastID,
+		Status:       notification.Status,
+		CompletedAt:  notification.CompletedAt,
+		SendAt:       notification.SendAt,
+		ExpiresAt:    notification.ExpiresAt,
+		CollapseKey:  notification.CollapseKey,
+		Priority:     notification.Priority,
+		SnoozedUntil: notification.SnoozedUntil,
+		Version:      notification.Version,
+		CreatedAt:    notification.CreatedAt,
+		UpdatedAt:    notification.UpdatedAt,
 	}
 
 	if e := notification.Email; e != nil {
@@ -239,6 +266,12 @@ func indexCloseTag(s, name string) int {
 type SendNotificationPayload struct {
 	ProjectID int
 
+	// IdempotencyKey comes from the `Idempotency-Key` header, not the body.
+	// Optional; when set, a repeat of the same key within the retention window is
+	// answered with the first request's result instead of sending again. See
+	// NotificationService.Send.
+	IdempotencyKey string `json:"-"`
+
 	// RecipientExtID is the ID of the recipient for the notification.
 	// Optional, if nil then it's a broadcast notification, if present then it's a direct notification.
 	RecipientExtID *string `json:"recipient_id"`
@@ -259,6 +292,250 @@ type SendNotificationPayload struct {
 	// Email, when present, makes email eligible for this send (direct-only).
 	// Absence ⇒ no email. See EmailContent.
 	Email *EmailContent `json:"email"`
+
+	// SendAt, when set, schedules the send instead of running it now. The row is
+	// written immediately in status `scheduled` and can be cancelled until then.
+	// omitempty keeps an unscheduled send's Fingerprint what it always was.
+	SendAt *time.Time `json:"send_at,omitempty"`
+
+	// ExpiresAt, when set, is when the notification stops being worth seeing.
+	// Past it the notification leaves the recipient's feed and an email not yet
+	// sent is dropped. omitempty for the same reason as SendAt.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+
+	// CollapseKey, when set on a direct send, makes it replace the recipient's
+	// unread notification with the same key instead of adding another row to
+	// their feed. omitempty for the same reason as SendAt.
+	CollapseKey *string `json:"collapse_key,omitempty"`
+
+	// Priority picks the lane the send's work runs in: `critical` for what a
+	// person is waiting on (a password reset), `bulk` for what can wait behind
+	// it. Omitted, a direct send is `normal` and a broadcast is `bulk` — see
+	// PriorityOr. omitempty for the same reason as SendAt.
+	Priority *enum.Priority `json:"priority,omitempty"`
+
+	// Variants holds localized content keyed by BCP 47 tag ("pt-BR"). Each
+	// recipient gets the variant matching their locale, falling back one subtag
+	// at a time ("pt-BR", then "pt") and finally to `payload`/`email` above. A
+	// "default" key is accepted as another way of writing those. omitempty for
+	// the same reason as SendAt.
+	Variants map[string]ContentVariant `json:"variants,omitempty"`
+
+	// Audience narrows a broadcast to the recipients matching it, on top of
+	// their preferences. Broadcast-only: a direct send already names its one
+	// recipient. omitempty for the same reason as SendAt.
+	Audience *entity.AudienceSegment `json:"audience,omitempty"`
+
+	// RecipientIDs sends a broadcast to exactly these recipients instead of to
+	// everyone, still subject to each one's preferences for the target.
+	// Broadcast-only. omitempty for the same reason as SendAt.
+	RecipientIDs []string `json:"recipient_ids,omitempty"`
+	// CreateMissingRecipients creates a recipient for every id in RecipientIDs
+	// that has none. Off, those ids are reported back and reach nobody.
+	CreateMissingRecipients bool `json:"create_missing_recipients,omitempty"`
+}
+
+// MaxBroadcastRecipientIDs bounds a list-based broadcast. A billing export of a
+// few tens of thousands of users is the case it is sized for.
+const MaxBroadcastRecipientIDs = 100_000
+
+// maxRecipientIDLength is recipient.external_id's column width.
+const maxRecipientIDLength = 255
+
+// IsListBased reports whether this is a broadcast to an explicit list of
+// recipients.
+func (p *SendNotificationPayload) IsListBased() bool {
+	return p.RecipientIDs != nil
+}
+
+// validateRecipientIDs checks a list-based broadcast's ids, lowercasing them the
+// way every recipient id is stored and dropping repeats, first one kept.
+func (p *SendNotificationPayload) validateRecipientIDs(errs *service.InputValidationErrors) {
+	if p.RecipientIDs == nil {
+		if p.CreateMissingRecipients {
+			errs.Add(apires.NewApiError("Invalid create_missing_recipients", "create_missing_recipients only applies to a broadcast with recipient_ids.", "create_missing_recipients", nil))
+		}
+		return
+	}
+
+	switch {
+	case p.RecipientExtID != nil:
+		errs.Add(apires.NewApiError("Invalid recipient_ids", "recipient_ids is supported on broadcasts only. Use recipient_id for a direct send.", "recipient_ids", nil))
+		return
+	case len(p.RecipientIDs) == 0:
+		errs.Add(apires.NewApiError("Invalid recipient_ids", "recipient_ids cannot be empty. Omit it to broadcast to everyone.", "recipient_ids", nil))
+		return
+	case len(p.RecipientIDs) > MaxBroadcastRecipientIDs:
+		errs.Add(apires.NewApiError("Too many recipient_ids", fmt.Sprintf("A broadcast can name at most %d recipients.", MaxBroadcastRecipientIDs), "recipient_ids", len(p.RecipientIDs)))
+		return
+	}
+
+	if p.Audience != nil {
+		errs.Add(apires.NewApiError("Invalid audience", "audience cannot be combined with recipient_ids: the list already names who the broadcast is for.", "audience", nil))
+	}
+
+	seen := make(map[string]struct{}, len(p.RecipientIDs))
+	ids := make([]string, 0, len(p.RecipientIDs))
+	for i, id := range p.RecipientIDs {
+		id = strings.ToLower(strings.TrimSpace(id))
+		if id == "" || len(id) > maxRecipientIDLength {
+			// One error, not one per id: a bad export tends to be bad throughout,
+			// and 100,000 identical errors help nobody.
+			errs.Add(apires.NewApiError("Invalid recipient id", fmt.Sprintf("Recipient ids must be 1-%d characters.", maxRecipientIDLength), fmt.Sprintf("recipient_ids[%d]", i), id))
+			return
+		}
+		if _, dup := seen[id]; dup {
+			continue
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	p.RecipientIDs = ids
+}
+
+// ContentVariant is one locale's content blocks. A block it omits falls back
+// along the locale chain, so a variant only has to carry what differs.
+type ContentVariant struct {
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Email   *EmailContent   `json:"email,omitempty"`
+}
+
+// DefaultVariantKey is the variants key that stands for the send's top-level
+// content.
+const DefaultVariantKey = "default"
+
+// MaxContentVariants bounds the variants on one send. A broadcast stores them
+// on its row and every batch reads them back, so this is a size limit as much
+// as a sanity check.
+const MaxContentVariants = 50
+
+// ContentVariants returns the validated variants in the form the worker
+// resolves them from: canonical keys, and email text already derived.
+func (p *SendNotificationPayload) ContentVariants() entity.ContentVariants {
+	if len(p.Variants) == 0 {
+		return nil
+	}
+
+	variants := make(entity.ContentVariants, len(p.Variants))
+	for tag, v := range p.Variants {
+		variant := entity.ContentVariant{}
+		if IsJSONContent(v.Payload) {
+			variant.Payload = v.Payload
+		}
+		if v.Email != nil {
+			variant.Email = &entity.VariantEmail{Subject: v.Email.Subject, HTML: v.Email.HTML, Text: v.Email.ResolvedText()}
+		}
+		variants[tag] = variant
+	}
+	return variants
+}
+
+// liftDefaultVariant moves a "default" variant into the top-level content
+// blocks, which is where every later step looks for the default. It runs before
+// the rest of Validate, so the content rules below see the lifted blocks.
+func (p *SendNotificationPayload) liftDefaultVariant(errs *service.InputValidationErrors) {
+	def, ok := p.Variants[DefaultVariantKey]
+	if !ok {
+		return
+	}
+	delete(p.Variants, DefaultVariantKey)
+
+	if IsJSONContent(def.Payload) {
+		if p.HasPayload() {
+			errs.Add(apires.NewApiError("Conflicting default", "variants.default.payload and payload both set the default payload. Use one of them.", "variants.default.payload", nil))
+		} else {
+			p.Payload = def.Payload
+		}
+	}
+	if def.Email != nil {
+		if p.Email != nil {
+			errs.Add(apires.NewApiError("Conflicting default", "variants.default.email and email both set the default email. Use one of them.", "variants.default.email", nil))
+		} else {
+			p.Email = def.Email
+		}
+	}
+}
+
+// validateVariants checks each localized variant and rewrites its key to the
+// canonical tag recipient locales are stored as.
+//
+// A variant can only localize a medium the send already asked for: the
+// top-level blocks are what the catalog gate and the worker read as intent, so
+// an `email` that exists only under "pt" would slip past both.
+func (p *SendNotificationPayload) validateVariants(errs *service.InputValidationErrors) {
+	if len(p.Variants) == 0 {
+		return
+	}
+
+	if len(p.Variants) > MaxContentVariants {
+		errs.Add(apires.NewApiError("Too many variants", fmt.Sprintf("A send can carry at most %d variants.", MaxContentVariants), "variants", nil))
+		return
+	}
+
+	canonical := make(map[string]ContentVariant, len(p.Variants))
+	for tag, v := range p.Variants {
+		field := "variants." + tag
+
+		key, ok := entity.CanonicalLocale(tag)
+		if !ok {
+			errs.Add(apires.NewApiError("Invalid variant", fmt.Sprintf("%q is not a BCP 47 language tag, e.g. en or pt-BR.", tag), field, nil))
+			continue
+		}
+		if _, dup := canonical[key]; dup {
+			errs.Add(apires.NewApiError("Duplicate variant", fmt.Sprintf("%q is the same locale as another variant (%s).", tag, key), field, nil))
+			continue
+		}
+
+		hasPayload := IsJSONContent(v.Payload)
+		if !hasPayload && v.Email == nil {
+			errs.Add(apires.NewApiError("Empty variant", "A variant must carry a 'payload', an 'email', or both.", field, nil))
+		}
+		if hasPayload && !p.HasPayload() {
+			errs.Add(apires.NewApiError("Invalid variant", "A variant can only localize 'payload' when the send has a default 'payload'.", field+".payload", nil))
+		}
+		if hasPayload && len(v.Payload) > enum.NotificationMaxPayloadSize {
+			errs.Add(apires.NewApiError("Payload too large", fmt.Sprintf("payload cannot be larger than %d KB.", enum.NotificationMaxPayloadSize/1024), field+".payload", nil))
+		}
+		if v.Email != nil {
+			if p.Email == nil {
+				errs.Add(apires.NewApiError("Invalid variant", "A variant can only localize 'email' when the send has a default 'email'.", field+".email", nil))
+			}
+			if strings.TrimSpace(v.Email.Subject) == "" {
+				errs.Add(apires.NewApiError("Email subject is required", "email.subject cannot be empty in a variant's email block", field+".email.subject", v.Email.Subject))
+			}
+			if strings.TrimSpace(v.Email.HTML) == "" && strings.TrimSpace(v.Email.Text) == "" {
+				errs.Add(apires.NewApiError("Email content is required", "At least one of email.html or email.text must be provided", field+".email", nil))
+			}
+		}
+
+		canonical[key] = v
+	}
+	p.Variants = canonical
+}
+
+// PriorityOr is the send's priority, or fallback when it did not name one.
+func (p *SendNotificationPayload) PriorityOr(fallback enum.Priority) enum.Priority {
+	if p.Priority == nil {
+		return fallback
+	}
+	return *p.Priority
+}
+
+const (
+	// MaxScheduleAhead bounds send_at. A scheduled send lives in Redis as a
+	// parked Asynq task until it fires, so the horizon is a capacity limit as
+	// much as a sanity check.
+	MaxScheduleAhead = 30 * 24 * time.Hour
+	// scheduleClockSkew is how far in the past a send_at may be and still be
+	// accepted, as "now". The caller's clock and ours disagree a little, and a
+	// retry of a send scheduled a moment ahead must not start failing.
+	scheduleClockSkew = 5 * time.Minute
+)
+
+// IsScheduled reports whether the send should wait for SendAt. A send_at that
+// has already arrived (within the accepted skew) is just an immediate send.
+func (p *SendNotificationPayload) IsScheduled() bool {
+	return p.SendAt != nil && p.SendAt.After(time.Now())
 }
 
 // HasEmail reports whether the send carries an email content block (the sender's
@@ -319,6 +596,8 @@ func (p *SendNotificationPayload) Validate() error {
 		errs.Add(apires.NewApiError("Project is required", "Project ID must be a positive integer", "project_id", p.ProjectID))
 	}
 
+	p.liftDefaultVariant(&errs)
+
 	if p.RecipientExtID != nil && *p.RecipientExtID == "" {
 		errs.Add(apires.NewApiError("Recipient ID cannot be empty if provided", "Recipient ID cannot be empty if this field is provided. Omit the field if you want to send a broadcast notification.", "recipient_id", p.RecipientExtID))
 	} else if p.RecipientExtID != nil {
@@ -394,6 +673,72 @@ func (p *SendNotificationPayload) Validate() error {
 		}
 	}
 
+	if p.SendAt != nil {
+		now := time.Now()
+		utc := p.SendAt.UTC()
+		p.SendAt = &utc
+
+		if utc.Before(now.Add(-scheduleClockSkew)) {
+			errs.Add(apires.NewApiError("Invalid send_at", "send_at is in the past. Omit it to send immediately.", "send_at", p.SendAt))
+		} else if utc.After(now.Add(MaxScheduleAhead)) {
+			errs.Add(apires.NewApiError("Invalid send_at", fmt.Sprintf("send_at cannot be more than %d days ahead.", int(MaxScheduleAhead.Hours()/24)), "send_at", p.SendAt))
+		}
+	}
+
+	if p.ExpiresAt != nil {
+		utc := p.ExpiresAt.UTC()
+		p.ExpiresAt = &utc
+
+		// Against send_at when there is one: a send that has expired by the time
+		// it fires would be written, parked, and then never seen by anyone.
+		startsAt := time.Now()
+		if p.SendAt != nil && p.SendAt.After(startsAt) {
+			startsAt = *p.SendAt
+		}
+
+		if !utc.After(startsAt) {
+			detail := "expires_at must be in the future."
+			if p.SendAt != nil {
+				detail = "expires_at must be after send_at."
+			}
+			errs.Add(apires.NewApiError("Invalid expires_at", detail, "expires_at", p.ExpiresAt))
+		}
+	}
+
+	if p.CollapseKey != nil {
+		switch {
+		case p.RecipientExtID == nil:
+			errs.Add(apires.NewApiError("Invalid collapse_key", "collapse_key is supported on direct sends only.", "collapse_key", p.CollapseKey))
+		case !p.HasPayload():
+			// Collapsing happens in the inbox; an email-only send never lands there.
+			errs.Add(apires.NewApiError("Invalid collapse_key", "collapse_key requires a 'payload': it replaces an in-app notification, and this send has none.", "collapse_key", p.CollapseKey))
+		case strings.TrimSpace(*p.CollapseKey) == "":
+			errs.Add(apires.NewApiError("Invalid collapse_key", "collapse_key cannot be empty. Omit it to send without collapsing.", "collapse_key", p.CollapseKey))
+		case len(*p.CollapseKey) > MaxCollapseKeyLength:
+			errs.Add(apires.NewApiError("Invalid collapse_key", fmt.Sprintf("collapse_key cannot be longer than %d characters", MaxCollapseKeyLength), "collapse_key", nil))
+		}
+	}
+
+	if p.Priority != nil && !p.Priority.Valid() {
+		errs.Add(apires.NewApiError("Invalid priority", "priority must be one of 'critical', 'normal' or 'bulk'.", "priority", p.Priority))
+	}
+
+	p.validateVariants(&errs)
+
+	p.validateRecipientIDs(&errs)
+
+	if p.Audience != nil {
+		if p.RecipientExtID != nil {
+			errs.Add(apires.NewApiError("Invalid audience", "audience is supported on broadcasts only.", "audience", nil))
+		} else {
+			validateAudienceSegment(&errs, p.Audience)
+		}
+	}
+
+	if p.IdempotencyKey != "" && len(p.IdempotencyKey) > MaxIdempotencyKeyLength {
+		errs.Add(apires.NewApiError("Invalid Idempotency-Key", fmt.Sprintf("Idempotency-Key cannot be longer than %d characters", MaxIdempotencyKeyLength), "Idempotency-Key", nil))
+	}
+
 	if len(errs) > 0 {
 		return errs
 	}
@@ -401,6 +746,63 @@ func (p *SendNotificationPayload) Validate() error {
 	return nil
 }
 
+// MaxIdempotencyKeyLength bounds the Idempotency-Key header. Long enough for a
+// UUID or a composite "<event>:<entity id>" key, short enough to index.
+const MaxIdempotencyKeyLength = 255
+
+// MaxCollapseKeyLength bounds collapse_key, which is indexed alongside the
+// recipient.
+const MaxCollapseKeyLength = 255
+
+// Fingerprint is a stable hash of what this send asks for, used to tell a
+// genuine retry from a key reused for a different request. Call it after
+// Validate, so that cosmetic differences it normalizes (recipient id casing)
+// do not count as a different body. The in-app payload is compacted by the
+// encoder, so whitespace does not count either; key order inside it does.
+func (p *SendNotificationPayload) Fingerprint() (string, error) {
+	body, err := json.Marshal(sendFingerprint{
+		RecipientExtID:          p.RecipientExtID,
+		Target:                  p.Target,
+		Payload:                 p.Payload,
+		Email:                   p.Email,
+		SendAt:                  p.SendAt,
+		ExpiresAt:               p.ExpiresAt,
+		CollapseKey:             p.CollapseKey,
+		Priority:                p.Priority,
+		Variants:                p.Variants,
+		Audience:                p.Audience,
+		RecipientIDs:            p.RecipientIDs,
+		CreateMissingRecipients: p.CreateMissingRecipients,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// sendFingerprint is the part of a send the client wrote, and all Fingerprint
+// hashes. It is spelled out rather than marshalled from the payload so that
+// what the server sets (ProjectID) stays out, and so that a field added to
+// SendNotificationPayload changes no stored fingerprint until it is listed
+// here. A field added here must be omitempty, or every key claimed before the
+// deploy reads as a different body.
+type sendFingerprint struct {
+	RecipientExtID          *string                   `json:"recipient_id"`
+	Target                  *Target                   `json:"target"`
+	Payload                 json.RawMessage           `json:"payload"`
+	Email                   *EmailContent             `json:"email"`
+	SendAt                  *time.Time                `json:"send_at,omitempty"`
+	ExpiresAt               *time.Time                `json:"expires_at,omitempty"`
+	CollapseKey             *string                   `json:"collapse_key,omitempty"`
+	Priority                *enum.Priority            `json:"priority,omitempty"`
+	Variants                map[string]ContentVariant `json:"variants,omitempty"`
+	Audience                *entity.AudienceSegment   `json:"audience,omitempty"`
+	RecipientIDs            []string                  `json:"recipient_ids,omitempty"`
+	CreateMissingRecipients bool                      `json:"create_missing_recipients,omitempty"`
+}
+
 func (p *SendNotificationPayload) IsDirect() bool {
 	return p.RecipientExtID != nil && *p.RecipientExtID != ""
 }
@@ -422,6 +824,74 @@ type SendNotificationResult struct {
 	// (old doc #19) — the send returns 200 and the outcome is reported here. In-app
 	// is intentionally absent (its outcome lives on the notification row).
 	Deliveries []*NotificationDelivery `json:"deliveries,omitempty"`
+
+	// UnknownRecipientIDs are the ids of a list-based broadcast that had no
+	// recipient when it was sent. They stay on the list, so one created before
+	// the broadcast fans out is still reached.
+	UnknownRecipientIDs []string `json:"unknown_recipient_ids,omitempty"`
+	// CreatedRecipientIDs are the recipients create_missing_recipients created.
+	CreatedRecipientIDs []string `json:"created_recipient_ids,omitempty"`
+
+	// Preview is set instead of Broadcast on a dry run (`?dry_run=true`), which
+	// sends nothing.
+	Preview *BroadcastPreview `json:"preview,omitempty"`
+
+	// Replayed is set when this result was answered from the Idempotency-Key
+	// ledger rather than produced by a send. Surfaced as the
+	// `Idempotent-Replayed` response header, not in the body, so a replay's body
+	// is byte-for-byte the original.
+	Replayed bool `json:"-"`
+}
+
+// MaxBatchSendSize bounds POST /notifications/send/batch. Lower than the
+// recipient batch's 1000 because each item is a full send — payload and email
+// body — and every accepted item becomes an Asynq task in the same request.
+const MaxBatchSendSize = 500
+
+// BatchSendNotificationsPayload is a batch of DIRECT sends. Each item is an
+// ordinary SendNotificationPayload and is validated exactly like one.
+type BatchSendNotificationsPayload struct {
+	Notifications []SendNotificationPayload `json:"notifications"`
+}
+
+// ValidateBatchItem is Validate plus the batch's one extra rule: every item is a
+// direct send. A broadcast fans out on its own and is already one call for any
+// audience size, so there is nothing to gain from batching it.
+func (p *SendNotificationPayload) ValidateBatchItem() error {
+	var errs service.InputValidationErrors
+
+	if err := p.Validate(); err != nil {
+		errs = append(errs, err.(service.InputValidationErrors)...)
+	}
+
+	if p.RecipientExtID == nil {
+		errs.Add(apires.NewApiError("Recipient ID is required", "Every item in a batch send is a direct notification and must name a recipient_id. Send a broadcast with POST /notifications/send.", "recipient_id", nil))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+type BatchSendNotificationSent struct {
+	Notification *Notification `json:"notification"`
+	BatchIndex   int           `json:"batch_index"`
+}
+
+type BatchSendNotificationFailed struct {
+	Errors         service.InputValidationErrors `json:"errors"`
+	RecipientExtID *string                       `json:"recipient_id"`
+	BatchIndex     int                           `json:"batch_index"`
+}
+
+// BatchSendNotificationsResult reports every item of a batch send by its index
+// in the request, as BatchCreateRecipientsResult does. Nothing is dropped: an
+// item is in exactly one of the two lists.
+type BatchSendNotificationsResult struct {
+	Sent   []BatchSendNotificationSent   `json:"sent"`
+	Failed []BatchSendNotificationFailed `json:"failed"`
 }
 
 // NotificationDelivery is the API representation of a per-(notification, medium)
@@ -463,6 +933,9 @@ type EmailDeliveryTaskPayload struct {
 	Subject    string
 	HTML       string
 	Text       string
+	// ExpiresAt is the notification's expiry, carried so a task that runs (or
+	// retries) after it can record the delivery `expired` instead of sending.
+	ExpiresAt *time.Time
 	// UnsubscribeURL is the public one-click unsubscribe link (Phase 6) injected as
 	// the outbound email's List-Unsubscribe header. Built on the send path (which
 	// has project/recipient/target) and carried through so the worker can set the
@@ -470,6 +943,13 @@ type EmailDeliveryTaskPayload struct {
 	UnsubscribeURL string
 }
 
+// EmailDigestTaskPayload is the Asynq payload for the email:digest task. Only
+// the id rides through Redis: the items and the address are read from the digest
+// when it sends, so everything that joined it before the claim goes out.
+type EmailDigestTaskPayload struct {
+	DigestID int64
+}
+
 type NotificationsOverviewResult struct {
 	TotalNotifications int `json:"total_notifications"`
 	TotalDirectSent    int `json:"total_direct_sent"`
@@ -542,6 +1022,17 @@ type AnalyticsInAppByStatus struct {
 	// Total is a count of all notification rows, so this bucket is what keeps the
 	// per-status split summing to it.
 	NotRequested int `json:"not_requested"`
+	// Scheduled sends not yet due, and scheduled sends cancelled before they
+	// fired. Rows like any other, so they need buckets for the same reason.
+	Scheduled int `json:"scheduled"`
+	Cancelled int `json:"cancelled"`
+	// Collapsed counts delivered notifications a newer send under the same
+	// collapse_key replaced.
+	Collapsed int `json:"collapsed"`
+	// Throttled counts sends turned away by a frequency cap.
+	Throttled int `json:"throttled"`
+	// Recalled counts notifications the sender pulled back after sending.
+	Recalled int `json:"recalled"`
 }
 
 // AnalyticsInAppDay is one calendar day's in-app counts, the day computed in the
@@ -556,6 +1047,11 @@ type AnalyticsInAppDay struct {
 	QuotaExceeded int    `json:"quota_exceeded"`
 	Failed        int    `json:"failed"`
 	NotRequested  int    `json:"not_requested"`
+	Scheduled     int    `json:"scheduled"`
+	Cancelled     int    `json:"cancelled"`
+	Collapsed     int    `json:"collapsed"`
+	Throttled     int    `json:"throttled"`
+	Recalled      int    `json:"recalled"`
 }
 
 // AnalyticsEmail is the email (notification_delivery) side. Attempted is the
@@ -574,8 +1070,8 @@ type AnalyticsEmail struct {
 	Series    []AnalyticsEmailDay    `json:"series"`
 }
 
-// AnalyticsEmailByStatus counts the email delivery statuses. Only the eight
-// statuses v1 actually writes appear — the four reserved DeliveryStatus values
+// AnalyticsEmailByStatus counts the email delivery statuses. Only the statuses
+// v1 actually writes appear — the four reserved DeliveryStatus values
 // (sending/suppressed/quota_exceeded/rejected) are omitted so a chart never
 // shows an axis for data that cannot exist.
 type AnalyticsEmailByStatus struct {
@@ -587,6 +1083,11 @@ type AnalyticsEmailByStatus struct {
 	Failed     int `json:"failed"`
 	NoContact  int `json:"no_contact"`
 	Muted      int `json:"muted"`
+	Expired    int `json:"expired"`
+	Digested   int `json:"digested"`
+	Deferred   int `json:"deferred"`
+	Throttled  int `json:"throttled"`
+	Recalled   int `json:"recalled"`
 }
 
 // AnalyticsEmailDay is one calendar day's email counts (Day in the viewer's
@@ -638,11 +1139,16 @@ type NotificationDeliveryDetail struct {
 	ComplainedAt    *time.Time `json:"complained_at,omitempty"`
 	// OpenedAt / ClickedAt are soft, directional signals only — see the note on
 	// NotificationEmailDelivery.
-	OpenedAt  *time.Time      `json:"opened_at,omitempty"`
-	ClickedAt *time.Time      `json:"clicked_at,omitempty"`
-	Events    []DeliveryEvent `json:"events"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
+	OpenedAt  *time.Time `json:"opened_at,omitempty"`
+	ClickedAt *time.Time `json:"clicked_at,omitempty"`
+	// DigestID / DigestMessageID are set when the email went out folded into a
+	// digest: the provider message id is the digest's, shared with every other
+	// delivery in it.
+	DigestID        *int64          `json:"digest_id,omitempty"`
+	DigestMessageID *string         `json:"digest_message_id,omitempty"`
+	Events          []DeliveryEvent `json:"events"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
 }
 
 // DeliveryEvent is one entry of the delivery row's provider_response JSONB array
@@ -683,6 +1189,8 @@ func FromNotificationDeliveryDetail(d *entity.NotificationDelivery, events []Del
 		ComplainedAt:      d.ComplainedAt,
 		OpenedAt:          d.OpenedAt,
 		ClickedAt:         d.ClickedAt,
+		DigestID:          d.DigestID,
+		DigestMessageID:   d.DigestMessageID,
 		Events:            events,
 		CreatedAt:         d.CreatedAt,
 		UpdatedAt:         d.UpdatedAt,
@@ -717,6 +1225,80 @@ type ListRecipientNotificationsRequest struct {
 	Limit          int
 }
 
+// NotificationEdit is the body of PATCH /notifications/{id} and
+// PATCH /broadcasts/{id}: a replacement in-app payload, applied to notifications
+// that were already delivered.
+type NotificationEdit struct {
+	// Payload replaces the old one whole; there is no merge. Required.
+	Payload json.RawMessage `json:"payload"`
+	// ResetRead marks the notification unread (and unopened) again, for an edit
+	// the recipient should notice — "Alice liked your post" turning into "Alice
+	// and 3 others liked your post".
+	ResetRead bool `json:"reset_read"`
+	// Version, when set, is the version the caller last read. The edit is
+	// refused with a 409 if the row has moved on since. Omit it to overwrite
+	// unconditionally.
+	Version *int `json:"version,omitempty"`
+}
+
+func (e *NotificationEdit) validate(errs *service.InputValidationErrors) {
+	if !IsJSONContent(e.Payload) {
+		errs.Add(apires.NewApiError("Payload is required", "An edit replaces the notification's payload, so 'payload' cannot be omitted or null.", "payload", nil))
+	} else if len(e.Payload) > enum.NotificationMaxPayloadSize {
+		errs.Add(apires.NewApiError("Payload too large", fmt.Sprintf("payload cannot be larger than %d KB.", enum.NotificationMaxPayloadSize/1024), "payload", nil))
+	}
+
+	if e.Version != nil && *e.Version <= 0 {
+		errs.Add(apires.NewApiError("Invalid version", "version must be a positive integer. Omit it to edit without a version check.", "version", e.Version))
+	}
+}
+
+// PatchNotificationPayload edits one delivered notification.
+type PatchNotificationPayload struct {
+	ProjectID      int
+	NotificationID int `json:"-"`
+
+	NotificationEdit
+}
+
+func (p *PatchNotificationPayload) Validate() error {
+	var errs service.InputValidationErrors
+
+	if p.ProjectID <= 0 {
+		errs.Add(apires.NewApiError("Project is required", "Project ID must be a positive integer", "project_id", p.ProjectID))
+	}
+	p.NotificationEdit.validate(&errs)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// PatchBroadcastPayload edits every delivered notification a broadcast wrote,
+// and the broadcast itself, so batches that have not fanned out yet carry the
+// new payload too. Version is checked against the broadcast's.
+type PatchBroadcastPayload struct {
+	ProjectID   int
+	BroadcastID int `json:"-"`
+
+	NotificationEdit
+}
+
+func (p *PatchBroadcastPayload) Validate() error {
+	var errs service.InputValidationErrors
+
+	if p.ProjectID <= 0 {
+		errs.Add(apires.NewApiError("Project is required", "Project ID must be a positive integer", "project_id", p.ProjectID))
+	}
+	p.NotificationEdit.validate(&errs)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
 type NotificationIDsPayload struct {
 	IDs []int `json:"ids"`
 }
@@ -734,6 +1316,10 @@ type NotificationDeliveryTaskPayload struct {
 	// the worker now, not on the request path — so the send API returns after a
 	// single notification INSERT. Nil when the send carried no email block.
 	Email *EmailContent
+	// Variants is the send's localized content. The worker picks the recipient's
+	// variant once it has their locale; Notification.Payload and Email above
+	// are the default.
+	Variants entity.ContentVariants
 }
 
 type BroadcastDeliveryTaskPayload struct {
@@ -747,9 +1333,68 @@ type BroadcastDeliveryTaskPayload struct {
 	Event           string
 }
 
+// NewBroadcastDeliveryTaskPayload is the delivery task for one batch of the
+// broadcast. The payload it carries is informational; delivery reads the
+// broadcast's current content under the batch lock.
+func NewBroadcastDeliveryTaskPayload(broadcast *entity.Broadcast, batch *entity.BroadcastBatch) BroadcastDeliveryTaskPayload {
+	return BroadcastDeliveryTaskPayload{
+		ProjectID:       broadcast.ProjectID,
+		BroadcastID:     broadcast.ID,
+		BatchID:         batch.ID,
+		RecipientExtIDs: batch.RecipientExtIDs,
+		Payload:         broadcast.Payload,
+		Channel:         broadcast.Channel,
+		Topic:           broadcast.Topic,
+		Event:           broadcast.Event,
+	}
+}
+
 type UpdateRecipientNotificationsPayload struct {
 	NotificationIDsPayload
 	State NotificationStateFilter `json:"state"`
+	// Archived moves the notifications to the archive, or back to the inbox.
+	Archived *bool `json:"archived,omitempty"`
+	// SnoozeUntil hides the notifications until then, and marks them unread so
+	// they come back as new. A time already past wakes them now.
+	SnoozeUntil *time.Time `json:"snooze_until,omitempty"`
+
+	// Filters, from the query string, narrow the update to the notifications
+	// the same filters would list: `?channel=billing` with `{"state":{"read":
+	// true}}` is "mark billing read", in one UPDATE. Nil is the whole feed,
+	// inbox and archive, as an update without filters always was.
+	Filters *RecipientFeedFilters `json:"-"`
+}
+
+// Validate rejects an update that contradicts itself. An update that changes
+// nothing is allowed, as it always was.
+func (p *UpdateRecipientNotificationsPayload) Validate() error {
+	var errs service.InputValidationErrors
+
+	if p.Filters != nil {
+		if err := p.Filters.Validate(); err != nil {
+			return err
+		}
+		if p.Filters.GroupBy != "" {
+			errs.Add(apires.NewApiError("Invalid group_by", "An update applies to notifications, not groups", "group_by", p.Filters.GroupBy))
+		}
+	}
+
+	if p.SnoozeUntil != nil {
+		if p.SnoozeUntil.IsZero() {
+			errs.Add(apires.NewApiError("Invalid snooze", "`snooze_until` must be a time", "snooze_until", *p.SnoozeUntil))
+		}
+		// A snooze comes back unread; asking for it read as well is asking for
+		// two things at once.
+		if p.State.Read != nil && *p.State.Read {
+			errs.Add(apires.NewApiError("Invalid snooze", "A snoozed notification comes back unread, so it cannot be marked read too", "state.read", true))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
 }
 
 type ListNotificationsFilters struct {
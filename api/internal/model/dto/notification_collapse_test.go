@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func collapsingSend(key string) SendNotificationPayload {
+	return SendNotificationPayload{
+		ProjectID:      1,
+		RecipientExtID: strptr("user_1"),
+		Payload:        json.RawMessage(`{"title":"Vikram commented on your post"}`),
+		CollapseKey:    &key,
+	}
+}
+
+func TestSendNotificationPayload_Validate_CollapseKey(t *testing.T) {
+	ok := collapsingSend("post_42:comments")
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("a direct send with a collapse_key should validate, got %v", err)
+	}
+
+	blank := collapsingSend("  ")
+	if err := blank.Validate(); !hasErrorFor(err, "collapse_key") {
+		t.Errorf("a blank collapse_key must be rejected, got %v", err)
+	}
+
+	long := collapsingSend(strings.Repeat("k", MaxCollapseKeyLength+1))
+	if err := long.Validate(); !hasErrorFor(err, "collapse_key") {
+		t.Errorf("an over-long collapse_key must be rejected, got %v", err)
+	}
+
+	// A broadcast has no single inbox to collapse into.
+	broadcast := collapsingSend("k")
+	broadcast.RecipientExtID = nil
+	broadcast.Target = &Target{Channel: "posts", Topic: "post_42", Event: "comment"}
+	if err := broadcast.Validate(); !hasErrorFor(err, "collapse_key") {
+		t.Errorf("collapse_key on a broadcast must be rejected, got %v", err)
+	}
+
+	// Email-only: there is no inbox row to replace.
+	emailOnly := collapsingSend("k")
+	emailOnly.Payload = nil
+	emailOnly.Email = &EmailContent{Subject: "New comment", Text: "Vikram commented"}
+	if err := emailOnly.Validate(); !hasErrorFor(err, "collapse_key") {
+		t.Errorf("collapse_key on an email-only send must be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func expiringSend(sendAt *time.Time, expiresAt time.Time) SendNotificationPayload {
+	return SendNotificationPayload{
+		ProjectID:      1,
+		RecipientExtID: strptr("user_1"),
+		Payload:        json.RawMessage(`{"title":"Meeting starts in 5 minutes"}`),
+		SendAt:         sendAt,
+		ExpiresAt:      &expiresAt,
+	}
+}
+
+func TestSendNotificationPayload_Validate_ExpiresAt(t *testing.T) {
+	soon := expiringSend(nil, time.Now().Add(5*time.Minute))
+	if err := soon.Validate(); err != nil {
+		t.Fatalf("expires_at in the future should validate, got %v", err)
+	}
+
+	// Unlike send_at there is no skew allowance: a send that is stale on arrival
+	// has nothing to show anyone.
+	past := expiringSend(nil, time.Now().Add(-time.Second))
+	if err := past.Validate(); !hasErrorFor(err, "expires_at") {
+		t.Errorf("expires_at in the past must be rejected, got %v", err)
+	}
+
+	// Scheduled to fire after it would already have expired.
+	sendAt := time.Now().Add(2 * time.Hour)
+	beforeSend := expiringSend(&sendAt, time.Now().Add(time.Hour))
+	if err := beforeSend.Validate(); !hasErrorFor(err, "expires_at") {
+		t.Errorf("expires_at before send_at must be rejected, got %v", err)
+	}
+
+	afterSend := expiringSend(&sendAt, sendAt.Add(time.Hour))
+	if err := afterSend.Validate(); err != nil {
+		t.Fatalf("expires_at after send_at should validate, got %v", err)
+	}
+}
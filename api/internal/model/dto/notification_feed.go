@@ -0,0 +1,116 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/tantra/apires"
+	"github.com/mudgallabs/tantra/service"
+)
+
+// FeedGroupBy collapses a recipient's feed to one row per group.
+type FeedGroupBy string
+
+const (
+	// FeedGroupByTopic groups on channel and topic: everything about one post,
+	// whatever happened to it.
+	FeedGroupByTopic FeedGroupBy = "topic"
+	// FeedGroupByTarget groups on the whole target, event included.
+	FeedGroupByTarget FeedGroupBy = "target"
+)
+
+// RecipientFeedFilters narrows GET /recipients/{id}/notifications — the tabs of
+// a notification center. Every filter is on top of the feed's own visibility
+// rule, never instead of it: a filter can hide more, not show what the feed
+// hides.
+type RecipientFeedFilters struct {
+	// Channel / Topic / Event match exactly, as they do on the console list.
+	Channel *string `schema:"channel"`
+	Topic   *string `schema:"topic"`
+	Event   *string `schema:"event"`
+
+	Read   *bool `schema:"read"`
+	Opened *bool `schema:"opened"`
+	Seen   *bool `schema:"seen"`
+
+	// UntilID keeps the notifications from id down, in feed order: "everything
+	// up to the newest one on screen", for a mark-all-seen that leaves alone
+	// whatever arrived since the client last looked.
+	UntilID *int `schema:"until_id"`
+
+	// CreatedFrom / CreatedTo bound created_at, inclusive, as RFC3339 instants.
+	CreatedFrom *time.Time `schema:"created_from"`
+	CreatedTo   *time.Time `schema:"created_to"`
+
+	// Archived is the archive instead of the inbox. Snoozed notifications are
+	// in neither until they wake.
+	Archived bool `schema:"archived"`
+
+	// GroupBy, when set, returns groups instead of notifications. The filters
+	// above apply first, so `read=false&group_by=topic` is the unread topics.
+	GroupBy FeedGroupBy `schema:"group_by"`
+}
+
+func (f *RecipientFeedFilters) Validate() error {
+	var errs service.InputValidationErrors
+
+	f.Channel = normalizeOptionalStr(f.Channel, false)
+	f.Topic = normalizeOptionalStr(f.Topic, false)
+	f.Event = normalizeOptionalStr(f.Event, false)
+
+	switch f.GroupBy {
+	case "", FeedGroupByTopic, FeedGroupByTarget:
+	default:
+		errs.Add(apires.NewApiError("Invalid group_by", "Expected `topic` or `target`", "group_by", f.GroupBy))
+	}
+
+	if f.UntilID != nil && *f.UntilID <= 0 {
+		errs.Add(apires.NewApiError("Invalid until_id", "Expected a notification id", "until_id", *f.UntilID))
+	}
+
+	if f.CreatedFrom != nil && f.CreatedTo != nil && f.CreatedTo.Before(*f.CreatedFrom) {
+		errs.Add(apires.NewApiError("Invalid date range", "`created_to` is before `created_from`", "created_to", *f.CreatedTo))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// NotificationGroupKey is what a group's notifications share. Event is only
+// set when grouped by target.
+type NotificationGroupKey struct {
+	Channel string `json:"channel"`
+	Topic   string `json:"topic"`
+	Event   string `json:"event,omitempty"`
+}
+
+type NotificationGroup struct {
+	Group NotificationGroupKey `json:"group"`
+	// Count and UnreadCount are of the notifications that passed the filters.
+	Count       int           `json:"count"`
+	UnreadCount int           `json:"unread_count"`
+	Newest      *Notification `json:"newest"`
+}
+
+func FromNotificationGroups(groups []*entity.NotificationGroup) []*NotificationGroup {
+	dtos := make([]*NotificationGroup, len(groups))
+	for i, g := range groups {
+		dtos[i] = &NotificationGroup{
+			Group:       NotificationGroupKey{Channel: g.Channel, Topic: g.Topic, Event: g.Event},
+			Count:       g.Count,
+			UnreadCount: g.UnreadCount,
+			Newest:      FromNotification(g.Newest),
+		}
+	}
+	return dtos
+}
+
+// UnreadCounts is GET /recipients/{id}/notifications/unread-count. ByChannel
+// sums to UnreadCount, and a channel with nothing unread is absent.
+type UnreadCounts struct {
+	UnreadCount int            `json:"unread_count"`
+	ByChannel   map[string]int `json:"by_channel"`
+}
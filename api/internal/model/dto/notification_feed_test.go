@@ -0,0 +1,54 @@
+package dto
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecipientFeedFiltersValidate(t *testing.T) {
+	blank, channel := "  ", " billing "
+	f := RecipientFeedFilters{Channel: &channel, Topic: &blank, GroupBy: FeedGroupByTopic}
+	if err := f.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if f.Channel == nil || *f.Channel != "billing" || f.Topic != nil {
+		t.Errorf("channel = %v, topic = %v: want trimmed, and a blank topic dropped", f.Channel, f.Topic)
+	}
+
+	from := time.Now()
+	to := from.Add(-time.Hour)
+	for name, f := range map[string]RecipientFeedFilters{
+		"group_by":   {GroupBy: "event"},
+		"date range": {CreatedFrom: &from, CreatedTo: &to},
+	} {
+		if err := f.Validate(); err == nil {
+			t.Errorf("%s: Validate accepted %+v", name, f)
+		}
+	}
+}
+
+func TestSnoozeCannotAlsoMarkRead(t *testing.T) {
+	read, unread := true, false
+	later := time.Now().Add(time.Hour)
+
+	p := UpdateRecipientNotificationsPayload{SnoozeUntil: &later, State: NotificationStateFilter{Read: &read}}
+	if err := p.Validate(); err == nil {
+		t.Error("a snooze that also marks read was accepted")
+	}
+
+	p.State.Read = &unread
+	if err := p.Validate(); err != nil {
+		t.Errorf("a snooze that marks unread, which it does anyway, was refused: %v", err)
+	}
+}
+
+func TestUpdateByFilterRefusesGroups(t *testing.T) {
+	read := true
+	p := UpdateRecipientNotificationsPayload{
+		State:   NotificationStateFilter{Read: &read},
+		Filters: &RecipientFeedFilters{GroupBy: FeedGroupByTopic},
+	}
+	if err := p.Validate(); err == nil {
+		t.Error("an update by group_by was accepted")
+	}
+}
@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+)
+
+func TestPatchNotificationPayload_Validate(t *testing.T) {
+	edit := func(payload string, version *int) PatchNotificationPayload {
+		return PatchNotificationPayload{
+			ProjectID:      1,
+			NotificationID: 7,
+			NotificationEdit: NotificationEdit{
+				Payload: json.RawMessage(payload),
+				Version: version,
+			},
+		}
+	}
+
+	ok := edit(`{"title":"Alice and 3 others liked your post"}`, nil)
+	if err := ok.Validate(); err != nil {
+		t.Errorf("a plain edit should validate, got %v", err)
+	}
+
+	two := 2
+	versioned := edit(`{"title":"hi"}`, &two)
+	if err := versioned.Validate(); err != nil {
+		t.Errorf("an edit at version 2 should validate, got %v", err)
+	}
+
+	// An edit is a replacement, so there must be something to replace with.
+	for _, body := range []string{``, `null`} {
+		empty := edit(body, nil)
+		if err := empty.Validate(); !hasErrorFor(err, "payload") {
+			t.Errorf("payload %q must be rejected, got %v", body, err)
+		}
+	}
+
+	big := edit(`{"t":"`+strings.Repeat("x", enum.NotificationMaxPayloadSize)+`"}`, nil)
+	if err := big.Validate(); !hasErrorFor(err, "payload") {
+		t.Errorf("an oversized payload must be rejected, got %v", err)
+	}
+
+	zero := 0
+	stale := edit(`{"title":"hi"}`, &zero)
+	if err := stale.Validate(); !hasErrorFor(err, "version") {
+		t.Errorf("version 0 must be rejected, got %v", err)
+	}
+}
+
+func TestPatchBroadcastPayload_Validate(t *testing.T) {
+	ok := PatchBroadcastPayload{
+		ProjectID:        1,
+		BroadcastID:      3,
+		NotificationEdit: NotificationEdit{Payload: json.RawMessage(`{"title":"fixed typo"}`), ResetRead: true},
+	}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("a broadcast edit should validate, got %v", err)
+	}
+
+	missing := PatchBroadcastPayload{ProjectID: 1, BroadcastID: 3}
+	if err := missing.Validate(); !hasErrorFor(err, "payload") {
+		t.Errorf("a broadcast edit without a payload must be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+)
+
+func TestSendNotificationPayload_Validate_Priority(t *testing.T) {
+	send := func(p enum.Priority) SendNotificationPayload {
+		return SendNotificationPayload{
+			ProjectID:      1,
+			RecipientExtID: strptr("user_1"),
+			Payload:        json.RawMessage(`{"title":"Reset your password"}`),
+			Priority:       &p,
+		}
+	}
+
+	for _, p := range []enum.Priority{enum.PriorityCritical, enum.PriorityNormal, enum.PriorityBulk} {
+		payload := send(p)
+		if err := payload.Validate(); err != nil {
+			t.Errorf("priority %q should validate, got %v", p, err)
+		}
+	}
+
+	urgent := send("urgent")
+	if err := urgent.Validate(); !hasErrorFor(err, "priority") {
+		t.Errorf("an unknown priority must be rejected, got %v", err)
+	}
+}
+
+func TestSendNotificationPayload_PriorityOr(t *testing.T) {
+	var unset SendNotificationPayload
+	if got := unset.PriorityOr(enum.PriorityBulk); got != enum.PriorityBulk {
+		t.Errorf("no priority: got %q, want the fallback", got)
+	}
+
+	critical := enum.PriorityCritical
+	set := SendNotificationPayload{Priority: &critical}
+	if got := set.PriorityOr(enum.PriorityBulk); got != enum.PriorityCritical {
+		t.Errorf("a named priority must win over the fallback, got %q", got)
+	}
+
+	// Omitted is omitted from the fingerprint too, so an Idempotency-Key reused
+	// across the upgrade still matches its original request.
+	body, err := json.Marshal(&unset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields["priority"]; ok {
+		t.Error("an unset priority must not appear in the fingerprinted body")
+	}
+}
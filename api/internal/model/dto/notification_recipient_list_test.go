@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+)
+
+func listBroadcast(ids ...string) *SendNotificationPayload {
+	return &SendNotificationPayload{
+		ProjectID:    1,
+		Target:       &Target{Channel: "billing", Topic: "none", Event: "notice"},
+		Payload:      json.RawMessage(`{"title":"hi"}`),
+		RecipientIDs: ids,
+	}
+}
+
+func TestRecipientIDsNormalized(t *testing.T) {
+	p := listBroadcast("U1", " u2 ", "u1", "U3")
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if want := []string{"u1", "u2", "u3"}; !reflect.DeepEqual(p.RecipientIDs, want) {
+		t.Errorf("recipient_ids = %q, want %q", p.RecipientIDs, want)
+	}
+}
+
+func TestRecipientIDsValidation(t *testing.T) {
+	tests := map[string]struct {
+		payload *SendNotificationPayload
+		field   string
+	}{
+		// An explicit [] is a list naming nobody, not "no list".
+		"empty list": {listBroadcast([]string{}...), "recipient_ids"},
+		"blank id":   {listBroadcast("u1", "  "), "recipient_ids[1]"},
+		"direct send": {func() *SendNotificationPayload {
+			p := listBroadcast("u1")
+			p.RecipientExtID = strptr("u1")
+			return p
+		}(), "recipient_ids"},
+		"with audience": {func() *SendNotificationPayload {
+			p := listBroadcast("u1")
+			tag := "beta"
+			p.Audience = &entity.AudienceSegment{Tag: &tag}
+			return p
+		}(), "audience"},
+		"create missing without a list": {func() *SendNotificationPayload {
+			p := listBroadcast()
+			p.CreateMissingRecipients = true
+			return p
+		}(), "create_missing_recipients"},
+	}
+
+	for name, tt := range tests {
+		if err := tt.payload.Validate(); !hasErrorFor(err, tt.field) {
+			t.Errorf("%s: want an error on %s, got %v", name, tt.field, err)
+		}
+	}
+}
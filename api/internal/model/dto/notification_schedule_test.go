@@ -0,0 +1,119 @@
+package dto
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+)
+
+func scheduledSend(at time.Time) SendNotificationPayload {
+	return SendNotificationPayload{
+		ProjectID:      1,
+		RecipientExtID: strptr("user_1"),
+		Payload:        json.RawMessage(`{"title":"hi"}`),
+		SendAt:         &at,
+	}
+}
+
+func TestSendNotificationPayload_Validate_SendAt(t *testing.T) {
+	future := scheduledSend(time.Now().Add(time.Hour))
+	if err := future.Validate(); err != nil {
+		t.Fatalf("send_at an hour ahead should validate, got %v", err)
+	}
+	if !future.IsScheduled() {
+		t.Error("a future send_at must schedule the send")
+	}
+
+	// Slightly behind our clock is the caller's clock, not a mistake: accepted,
+	// and sent now rather than parked.
+	skewed := scheduledSend(time.Now().Add(-time.Minute))
+	if err := skewed.Validate(); err != nil {
+		t.Fatalf("send_at within clock skew should validate, got %v", err)
+	}
+	if skewed.IsScheduled() {
+		t.Error("a send_at that has already arrived is an immediate send")
+	}
+
+	past := scheduledSend(time.Now().Add(-time.Hour))
+	if err := past.Validate(); !hasErrorFor(err, "send_at") {
+		t.Errorf("send_at an hour ago must be rejected, got %v", err)
+	}
+
+	tooFar := scheduledSend(time.Now().Add(MaxScheduleAhead + time.Hour))
+	if err := tooFar.Validate(); !hasErrorFor(err, "send_at") {
+		t.Errorf("send_at past the horizon must be rejected, got %v", err)
+	}
+}
+
+// An unscheduled send must fingerprint exactly as it did before send_at existed,
+// or every Idempotency-Key in flight across the deploy reads as a different body.
+func TestSendNotificationPayload_FingerprintOmitsUnsetSendAt(t *testing.T) {
+	p := SendNotificationPayload{ProjectID: 1, RecipientExtID: strptr("user_1"), Payload: json.RawMessage(`{"a":1}`)}
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), "send_at") {
+		t.Errorf("unset send_at leaked into the fingerprinted body: %s", body)
+	}
+}
+
+// The fingerprint is what the client sent and nothing else: server-set fields
+// must not turn a retry into a mismatch, while a changed body still must.
+func TestSendNotificationPayload_FingerprintIsClientFieldsOnly(t *testing.T) {
+	base := SendNotificationPayload{ProjectID: 1, RecipientExtID: strptr("user_1"), Payload: json.RawMessage(`{"a":1}`)}
+	want, err := base.Fingerprint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverSide := base
+	serverSide.ProjectID = 2
+	serverSide.IdempotencyKey = "k"
+	if got, _ := serverSide.Fingerprint(); got != want {
+		t.Errorf("ProjectID or IdempotencyKey changed the fingerprint")
+	}
+
+	changed := base
+	changed.Payload = json.RawMessage(`{"a":2}`)
+	if got, _ := changed.Fingerprint(); got == want {
+		t.Errorf("a different payload kept the same fingerprint")
+	}
+
+	scheduled := base
+	sendAt := time.Now().Add(time.Hour)
+	scheduled.SendAt = &sendAt
+	if got, _ := scheduled.Fingerprint(); got == want {
+		t.Errorf("send_at did not change the fingerprint")
+	}
+}
+
+// Scheduled rows are counted, but never as pending: pending that does not drain
+// is how a stalled worker shows up, and a send parked until next week is not one.
+func TestInAppRollupKeepsScheduledOutOfPending(t *testing.T) {
+	m := InAppMediumFromRollup(map[enum.NotificationStatus]int{
+		enum.NotificationStatusEnqueued:  2,
+		enum.NotificationStatusScheduled: 5,
+		enum.NotificationStatusCancelled: 1,
+	})
+
+	if m.Total != 8 {
+		t.Errorf("total = %d, want 8", m.Total)
+	}
+	if m.Pending != 2 {
+		t.Errorf("Pending = %d, want 2 (scheduled is not in flight)", m.Pending)
+	}
+	if m.Scheduled != 5 {
+		t.Errorf("Scheduled = %d, want 5", m.Scheduled)
+	}
+	if got := m.Outcomes[string(enum.OutcomeScheduled)]; got != 5 {
+		t.Errorf("scheduled outcome = %d, want 5", got)
+	}
+	if got := m.Outcomes[string(enum.OutcomeSuppressed)]; got != 1 {
+		t.Errorf("suppressed = %d, want 1 (cancelled is deliberate, not failed)", got)
+	}
+}
@@ -0,0 +1,114 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func variantSend(variants map[string]ContentVariant) SendNotificationPayload {
+	return SendNotificationPayload{
+		ProjectID:      1,
+		RecipientExtID: strptr("user_1"),
+		Payload:        json.RawMessage(`{"title":"Hello"}`),
+		Email:          &EmailContent{Subject: "Hi", Text: "Hi!"},
+		Variants:       variants,
+	}
+}
+
+func TestSendNotificationPayload_Validate_VariantsCanonicalized(t *testing.T) {
+	p := variantSend(map[string]ContentVariant{
+		"pt-br": {Payload: json.RawMessage(`{"title":"Olá"}`)},
+		"DE":    {Email: &EmailContent{Subject: "Hallo", HTML: "<p>Hallo</p>"}},
+	})
+	if err := p.Validate(); err != nil {
+		t.Fatalf("valid variants rejected: %v", err)
+	}
+
+	if _, ok := p.Variants["pt-BR"]; !ok {
+		t.Errorf("pt-br should be stored as pt-BR, got %v", p.Variants)
+	}
+	if _, ok := p.Variants["de"]; !ok {
+		t.Errorf("DE should be stored as de, got %v", p.Variants)
+	}
+
+	// The email text is derived once, at the edge, like a broadcast's.
+	if got := p.ContentVariants()["de"].Email.Text; got != "Hallo" {
+		t.Errorf("variant email text = %q, want it derived from html", got)
+	}
+}
+
+func TestSendNotificationPayload_Validate_DefaultVariant(t *testing.T) {
+	p := SendNotificationPayload{
+		ProjectID:      1,
+		RecipientExtID: strptr("user_1"),
+		Variants: map[string]ContentVariant{
+			"default": {Payload: json.RawMessage(`{"title":"Hello"}`)},
+			"pt":      {Payload: json.RawMessage(`{"title":"Olá"}`)},
+		},
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("a default variant should stand in for payload: %v", err)
+	}
+	if string(p.Payload) != `{"title":"Hello"}` {
+		t.Errorf("payload = %s, want the default variant's", p.Payload)
+	}
+	if _, ok := p.Variants["default"]; ok {
+		t.Error("the default variant must be lifted out of the map")
+	}
+
+	both := variantSend(map[string]ContentVariant{"default": {Payload: json.RawMessage(`{"title":"Hey"}`)}})
+	if err := both.Validate(); !hasErrorFor(err, "variants.default.payload") {
+		t.Errorf("payload set twice must be rejected, got %v", err)
+	}
+}
+
+func TestSendNotificationPayload_Validate_VariantErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		send  SendNotificationPayload
+		field string
+	}{
+		{
+			name:  "not a language tag",
+			send:  variantSend(map[string]ContentVariant{"english please": {Payload: json.RawMessage(`{}`)}}),
+			field: "variants.english please",
+		},
+		{
+			name:  "empty variant",
+			send:  variantSend(map[string]ContentVariant{"pt": {}}),
+			field: "variants.pt",
+		},
+		{
+			name:  "variant email without subject",
+			send:  variantSend(map[string]ContentVariant{"pt": {Email: &EmailContent{Text: "Oi"}}}),
+			field: "variants.pt.email.subject",
+		},
+		{
+			name: "variant adds email to an in-app send",
+			send: SendNotificationPayload{
+				ProjectID:      1,
+				RecipientExtID: strptr("user_1"),
+				Payload:        json.RawMessage(`{"title":"Hello"}`),
+				Variants:       map[string]ContentVariant{"pt": {Email: &EmailContent{Subject: "Oi", Text: "Oi"}}},
+			},
+			field: "variants.pt.email",
+		},
+	}
+
+	for _, tt := range tests {
+		if err := tt.send.Validate(); !hasErrorFor(err, tt.field) {
+			t.Errorf("%s: want an error on %q, got %v", tt.name, tt.field, err)
+		}
+	}
+}
+
+func TestSendNotificationPayload_Validate_DuplicateVariant(t *testing.T) {
+	p := variantSend(map[string]ContentVariant{
+		"pt-BR": {Payload: json.RawMessage(`{"title":"Olá"}`)},
+		"pt-br": {Payload: json.RawMessage(`{"title":"Oi"}`)},
+	})
+	err := p.Validate()
+	if !hasErrorFor(err, "variants.pt-BR") && !hasErrorFor(err, "variants.pt-br") {
+		t.Errorf("two keys for one locale must be rejected, got %v", err)
+	}
+}
@@ -38,8 +38,17 @@ type ProjectPreference struct {
 	Enabled   bool   `json:"default_enabled"`
 	// Mandatory marks an entry the recipient cannot opt out of. It is still gated
 	// by the entry's own default_enabled, so the project keeps a kill switch.
-	Mandatory bool   `json:"mandatory"`
-	Name      string `json:"name"`
+	Mandatory bool `json:"mandatory"`
+	// Digest is the entry's email digest window ("hourly" or "daily"); null when
+	// its emails go out immediately.
+	Digest *enum.DigestWindow `json:"digest"`
+	// BypassQuietHours sends the entry's emails even inside a recipient's quiet
+	// hours. Mandatory entries always do.
+	BypassQuietHours bool `json:"bypass_quiet_hours"`
+	// FrequencyCap limits how many sends of this entry one recipient gets per
+	// window; null when uncapped.
+	FrequencyCap *FrequencyCap `json:"frequency_cap"`
+	Name         string        `json:"name"`
 	// Description is optional; null when the catalog entry has no blurb.
 	Description *string   `json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
@@ -64,6 +73,72 @@ type CreateProjectPreferencePayload struct {
 	// transactional sends (password resets, security alerts, one-shot welcomes)
 	// that must still pass the strict-target gate. Defaults to false.
 	Mandatory bool `json:"mandatory"`
+	// Digest batches this entry's emails per recipient into one email per window
+	// ("hourly" or "daily"). Email entries only; omitted or blank sends each
+	// email immediately.
+	Digest string `json:"digest"`
+	// BypassQuietHours sends this entry's emails even inside the recipient's
+	// quiet hours. Email entries only; defaults to false. Mandatory entries bypass
+	// quiet hours without it.
+	BypassQuietHours bool `json:"bypass_quiet_hours"`
+	// FrequencyCap caps how many sends of this (target, medium) one recipient
+	// gets per window. Omitted means uncapped.
+	FrequencyCap *FrequencyCap `json:"frequency_cap"`
+}
+
+// FrequencyCap is at most Limit sends per recipient per Window ("minute",
+// "hour" or "day") for one catalog entry.
+type FrequencyCap struct {
+	Limit  int            `json:"limit"`
+	Window enum.CapWindow `json:"window"`
+}
+
+// validateFrequencyCap normalizes and checks a request-supplied cap.
+// `allowOff` admits window enum.CapOff, which only an update can mean; the
+// limit is ignored then.
+func validateFrequencyCap(errs *service.InputValidationErrors, c *FrequencyCap, allowOff bool) {
+	c.Window = enum.CapWindow(strings.ToLower(strings.TrimSpace(string(c.Window))))
+	if allowOff && c.Window == enum.CapOff {
+		return
+	}
+
+	if !c.Window.Valid() {
+		detail := "Frequency cap window must be one of: minute, hour, day"
+		if allowOff {
+			detail = "Frequency cap window must be one of: minute, hour, day, off"
+		}
+		errs.Add(apires.NewApiError("Invalid frequency cap", detail, "frequency_cap.window", string(c.Window)))
+	}
+	if c.Limit <= 0 {
+		errs.Add(apires.NewApiError("Invalid frequency cap", "Frequency cap limit must be a positive integer", "frequency_cap.limit", c.Limit))
+	}
+}
+
+// Entity is the cap to store, or nil when none was given.
+func (c *FrequencyCap) Entity() *entity.FrequencyCap {
+	if c == nil {
+		return nil
+	}
+	return &entity.FrequencyCap{Limit: c.Limit, Window: c.Window}
+}
+
+// validateDigest checks a request-supplied digest window against the entry's
+// medium. `allowOff` admits enum.DigestOff, which only an update can mean.
+func validateDigest(digest enum.DigestWindow, medium enum.Medium, allowOff bool) (apires.ApiError, bool) {
+	if allowOff && digest == enum.DigestOff {
+		return apires.ApiError{}, true
+	}
+	if !digest.Valid() {
+		detail := "Digest must be one of: hourly, daily"
+		if allowOff {
+			detail = "Digest must be one of: hourly, daily, off"
+		}
+		return apires.NewApiError("Invalid digest", detail, "digest", string(digest)), false
+	}
+	if medium != "" && medium != enum.MediumEmail {
+		return apires.NewApiError("Invalid digest", "Only email catalog entries can be digested", "digest", string(digest)), false
+	}
+	return apires.ApiError{}, true
 }
 
 // normalizeDescription trims a request-supplied description and maps blank to
@@ -105,6 +180,21 @@ func (p *CreateProjectPreferencePayload) Validate() error {
 		errs.Add(apires.NewApiError("Name is required", "Name cannot be empty", "name", p.Name))
 	}
 
+	p.Digest = strings.ToLower(strings.TrimSpace(p.Digest))
+	if p.Digest != "" {
+		if apiErr, ok := validateDigest(enum.DigestWindow(p.Digest), enum.Medium(p.Medium), false); !ok {
+			errs.Add(apiErr)
+		}
+	}
+
+	if p.BypassQuietHours && p.Medium != string(enum.MediumEmail) {
+		errs.Add(apires.NewApiError("Invalid quiet hours bypass", "Only email catalog entries can bypass quiet hours", "bypass_quiet_hours", p.BypassQuietHours))
+	}
+
+	if p.FrequencyCap != nil {
+		validateFrequencyCap(&errs, p.FrequencyCap, false)
+	}
+
 	if len(errs) > 0 {
 		return errs
 	}
@@ -112,6 +202,15 @@ func (p *CreateProjectPreferencePayload) Validate() error {
 	return nil
 }
 
+// DigestPtr is the digest window to store: nil when the entry is not digested.
+func (p *CreateProjectPreferencePayload) DigestPtr() *enum.DigestWindow {
+	if p.Digest == "" {
+		return nil
+	}
+	d := enum.DigestWindow(p.Digest)
+	return &d
+}
+
 // DescriptionPtr normalizes the request-supplied description into the nullable
 // value stored on the entity (blank → nil).
 func (p *CreateProjectPreferencePayload) DescriptionPtr() *string {
@@ -135,6 +234,15 @@ type UpdateProjectPreferencePayload struct {
 	// this key, and with a plain bool that would silently un-mark a password
 	// reset as optional every time someone fixed a typo in its name.
 	Mandatory *bool `json:"mandatory"`
+	// Digest is a pointer for the same reason: omitted keeps the current window.
+	// "off" turns digesting off. Whether the entry is an email entry is checked
+	// by the service, which has the row.
+	Digest *enum.DigestWindow `json:"digest"`
+	// BypassQuietHours is a pointer for the same reason again: omitted keeps it.
+	BypassQuietHours *bool `json:"bypass_quiet_hours"`
+	// FrequencyCap is omitted to keep the current cap; `{"window": "off"}`
+	// removes it. Any medium may be capped.
+	FrequencyCap *FrequencyCap `json:"frequency_cap"`
 }
 
 func (p *UpdateProjectPreferencePayload) Validate() error {
@@ -144,6 +252,18 @@ func (p *UpdateProjectPreferencePayload) Validate() error {
 		errs.Add(apires.NewApiError("Name is required", "Name cannot be empty", "name", p.Name))
 	}
 
+	if p.Digest != nil {
+		d := enum.DigestWindow(strings.ToLower(strings.TrimSpace(string(*p.Digest))))
+		p.Digest = &d
+		if apiErr, ok := validateDigest(d, "", true); !ok {
+			errs.Add(apiErr)
+		}
+	}
+
+	if p.FrequencyCap != nil {
+		validateFrequencyCap(&errs, p.FrequencyCap, true)
+	}
+
 	if len(errs) > 0 {
 		return errs
 	}
@@ -170,14 +290,24 @@ func FromPreferenceForProject(e *entity.Preference) *ProjectPreference {
 			Topic:   e.Topic,
 			Event:   e.Event,
 		},
-		Medium:      e.Medium,
-		Enabled:     e.Enabled,
-		Mandatory:   e.Mandatory,
-		Name:        *e.Name,
-		Description: e.Description,
-		CreatedAt:   e.CreatedAt,
-		UpdatedAt:   e.UpdatedAt,
+		Medium:           e.Medium,
+		Enabled:          e.Enabled,
+		Mandatory:        e.Mandatory,
+		Digest:           e.Digest,
+		BypassQuietHours: e.BypassQuietHours,
+		FrequencyCap:     fromFrequencyCap(e.FrequencyCap()),
+		Name:             *e.Name,
+		Description:      e.Description,
+		CreatedAt:        e.CreatedAt,
+		UpdatedAt:        e.UpdatedAt,
+	}
+}
+
+func fromFrequencyCap(c *entity.FrequencyCap) *FrequencyCap {
+	if c == nil {
+		return nil
 	}
+	return &FrequencyCap{Limit: c.Limit, Window: c.Window}
 }
 
 type RecipientPreference struct {
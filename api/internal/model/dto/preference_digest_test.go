@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/mudgallabs/bodhveda/internal/email"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+)
+
+func digestEntry(medium, digest string) CreateProjectPreferencePayload {
+	return CreateProjectPreferencePayload{
+		ProjectID: 1, Channel: "billing", Topic: "none", Event: "invoice",
+		Medium: medium, Name: "Invoices", Digest: digest,
+	}
+}
+
+func TestCreateProjectPreferencePayload_Validate_Digest(t *testing.T) {
+	daily := digestEntry("email", "Daily")
+	if err := daily.Validate(); err != nil {
+		t.Fatalf("daily digest on an email entry should validate, got %v", err)
+	}
+	if got := daily.DigestPtr(); got == nil || *got != enum.DigestDaily {
+		t.Errorf("digest = %v, want daily", got)
+	}
+
+	// In-app has nothing to batch.
+	inApp := digestEntry("in_app", "hourly")
+	if err := inApp.Validate(); !hasErrorFor(err, "digest") {
+		t.Errorf("digest on an in_app entry must be rejected, got %v", err)
+	}
+
+	// "off" means something only on an update.
+	off := digestEntry("email", "off")
+	if err := off.Validate(); !hasErrorFor(err, "digest") {
+		t.Errorf("digest off on create must be rejected, got %v", err)
+	}
+
+	none := digestEntry("email", "")
+	if err := none.Validate(); err != nil || none.DigestPtr() != nil {
+		t.Errorf("omitted digest should validate and store nothing, got %v / %v", err, none.DigestPtr())
+	}
+}
+
+func TestUpdateProjectPreferencePayload_Validate_Digest(t *testing.T) {
+	off := enum.DigestWindow(" OFF ")
+	p := UpdateProjectPreferencePayload{Name: "Invoices", Digest: &off}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("digest off should validate on update, got %v", err)
+	}
+	if *p.Digest != enum.DigestOff {
+		t.Errorf("digest = %q, want normalized off", *p.Digest)
+	}
+
+	weekly := enum.DigestWindow("weekly")
+	p = UpdateProjectPreferencePayload{Name: "Invoices", Digest: &weekly}
+	if err := p.Validate(); !hasErrorFor(err, "digest") {
+		t.Errorf("unknown window must be rejected, got %v", err)
+	}
+}
+
+func validEmailSettings() UpsertProjectEmailSettingsPayload {
+	return UpsertProjectEmailSettingsPayload{ProjectID: 1, Secret: "re_key", FromName: "Acme", FromAddress: "hey@acme.dev"}
+}
+
+func TestUpsertProjectEmailSettingsPayload_Validate_DigestWrapper(t *testing.T) {
+	missing := "<html><body>Your updates</body></html>"
+	p := validEmailSettings()
+	p.DigestHTML = &missing
+	if err := p.Validate(); !hasErrorFor(err, "digest_html") {
+		t.Errorf("wrapper without %s must be rejected, got %v", email.DigestItemsPlaceholder, err)
+	}
+
+	ok := "<main>" + email.DigestItemsPlaceholder + "</main>"
+	p = validEmailSettings()
+	p.DigestHTML = &ok
+	if err := p.Validate(); err != nil {
+		t.Errorf("wrapper with the placeholder should validate, got %v", err)
+	}
+
+	// Blank clears the wrapper back to the default.
+	blank := ""
+	p = validEmailSettings()
+	p.DigestHTML = &blank
+	if err := p.Validate(); err != nil {
+		t.Errorf("blank wrapper should validate, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+)
+
+func TestCreateProjectPreferencePayload_Validate_FrequencyCap(t *testing.T) {
+	ok := digestEntry("in_app", "")
+	ok.FrequencyCap = &FrequencyCap{Limit: 5, Window: " Hour "}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("a cap on an in_app entry should validate, got %v", err)
+	}
+	if ok.FrequencyCap.Window != enum.CapPerHour {
+		t.Errorf("window = %q, want it normalized to hour", ok.FrequencyCap.Window)
+	}
+
+	badWindow := digestEntry("email", "")
+	badWindow.FrequencyCap = &FrequencyCap{Limit: 5, Window: "week"}
+	if err := badWindow.Validate(); !hasErrorFor(err, "frequency_cap.window") {
+		t.Errorf("an unknown window must be rejected, got %v", err)
+	}
+
+	zero := digestEntry("email", "")
+	zero.FrequencyCap = &FrequencyCap{Limit: 0, Window: enum.CapPerDay}
+	if err := zero.Validate(); !hasErrorFor(err, "frequency_cap.limit") {
+		t.Errorf("a zero limit must be rejected, got %v", err)
+	}
+
+	// "off" removes a cap, which only an update can do.
+	off := digestEntry("email", "")
+	off.FrequencyCap = &FrequencyCap{Window: enum.CapOff}
+	if err := off.Validate(); !hasErrorFor(err, "frequency_cap.window") {
+		t.Errorf("window off on create must be rejected, got %v", err)
+	}
+}
+
+func TestUpdateProjectPreferencePayload_Validate_FrequencyCapOff(t *testing.T) {
+	off := UpdateProjectPreferencePayload{Name: "Invoices", FrequencyCap: &FrequencyCap{Window: "OFF"}}
+	if err := off.Validate(); err != nil {
+		t.Fatalf("window off on update should validate without a limit, got %v", err)
+	}
+	if got := off.FrequencyCap.Entity(); got == nil || got.Window != enum.CapOff {
+		t.Errorf("entity = %+v, want the off window passed through", got)
+	}
+
+	var omitted *FrequencyCap
+	if omitted.Entity() != nil {
+		t.Error("an omitted cap must reach the repo as nil, which keeps the current one")
+	}
+}
@@ -13,6 +13,10 @@ type Project struct {
 	// StrictTargets reports whether this project rejects sends to targets it has
 	// not cataloged. False by default — see entity.Project.
 	StrictTargets bool `json:"strict_targets"`
+
+	// RequireRecipientToken reports whether non-full-scope keys must present a
+	// recipient token on the recipient sub-routes. False by default.
+	RequireRecipientToken bool `json:"require_recipient_token"`
 }
 
 type CreateProjectPaylaod struct {
@@ -48,6 +52,9 @@ type UpdateProjectPayload struct {
 	// with a plain bool that request would silently disable the gate on a project
 	// that had deliberately enabled it.
 	StrictTargets *bool `json:"strict_targets"`
+
+	// RequireRecipientToken is a pointer for the same reason StrictTargets is.
+	RequireRecipientToken *bool `json:"require_recipient_token"`
 }
 
 func (p *UpdateProjectPayload) Validate() error {
@@ -78,9 +85,10 @@ func FromProject(p *entity.Project) *Project {
 	}
 
 	return &Project{
-		ID:            p.ID,
-		Name:          p.Name,
-		StrictTargets: p.StrictTargets,
+		ID:                    p.ID,
+		Name:                  p.Name,
+		StrictTargets:         p.StrictTargets,
+		RequireRecipientToken: p.RequireRecipientToken,
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/mudgallabs/bodhveda/internal/email"
 	"github.com/mudgallabs/bodhveda/internal/model/enum"
 	"github.com/mudgallabs/tantra/apires"
 	"github.com/mudgallabs/tantra/service"
@@ -21,10 +22,14 @@ type ProjectEmailSettings struct {
 	// WebhookSecretMasked is the masked webhook signing secret (Phase 5), empty
 	// when no webhook secret is configured. WebhookSecretSet lets the console tell
 	// "not configured" from "configured" without exposing the value.
-	WebhookSecretMasked string    `json:"webhook_secret_masked"`
-	WebhookSecretSet    bool      `json:"webhook_secret_set"`
-	CreatedAt           time.Time `json:"created_at"`
-	UpdatedAt           time.Time `json:"updated_at"`
+	WebhookSecretMasked string `json:"webhook_secret_masked"`
+	WebhookSecretSet    bool   `json:"webhook_secret_set"`
+	// DigestSubject / DigestHTML are the wrapper digest emails are sent in; null
+	// when the project uses the default.
+	DigestSubject *string   `json:"digest_subject"`
+	DigestHTML    *string   `json:"digest_html"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 // MaskSecret turns a plaintext provider secret into a display-safe hint that
@@ -55,6 +60,11 @@ type UpsertProjectEmailSettingsPayload struct {
 	// on the way IN only. Always optional: omit (or leave blank) to keep the
 	// existing webhook secret; supply a new one to set or rotate it.
 	WebhookSecret string `json:"webhook_secret"`
+	// DigestSubject / DigestHTML set the wrapper for digest emails. Omit to keep
+	// the current value; send "" to go back to the default. The HTML must contain
+	// the {{digest_items}} placeholder, and either may use {{digest_count}}.
+	DigestSubject *string `json:"digest_subject"`
+	DigestHTML    *string `json:"digest_html"`
 
 	// hasExisting is set by the service before Validate so a rotation can omit
 	// the secret only when there is an existing one to keep.
@@ -107,6 +117,14 @@ func (p *UpsertProjectEmailSettingsPayload) Validate() error {
 		errs.Add(apires.NewApiError("Invalid from address", "From address must be a valid email", "from_address", p.FromAddress))
 	}
 
+	if p.DigestSubject != nil {
+		trimmed := strings.TrimSpace(*p.DigestSubject)
+		p.DigestSubject = &trimmed
+	}
+	if p.DigestHTML != nil && strings.TrimSpace(*p.DigestHTML) != "" && !strings.Contains(*p.DigestHTML, email.DigestItemsPlaceholder) {
+		errs.Add(apires.NewApiError("Invalid digest HTML", "Digest HTML must contain the "+email.DigestItemsPlaceholder+" placeholder", "digest_html", ""))
+	}
+
 	if len(errs) > 0 {
 		return errs
 	}
@@ -1,20 +1,30 @@
 package dto
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/recipienttoken"
 	"github.com/mudgallabs/tantra/apires"
 	"github.com/mudgallabs/tantra/query"
 	"github.com/mudgallabs/tantra/service"
 )
 
 type Recipient struct {
-	ExternalID string    `json:"id"` // Unique recipient ID from the client's system.
-	Name       string    `json:"name"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ExternalID      string  `json:"id"` // Unique recipient ID from the client's system.
+	Name            string  `json:"name"`
+	Locale          *string `json:"locale"`
+	Timezone        *string `json:"timezone"`
+	QuietHoursStart *string `json:"quiet_hours_start"`
+	QuietHoursEnd   *string `json:"quiet_hours_end"`
+	// Attributes and Tags are always present, as {} and [] when unset.
+	Attributes map[string]json.RawMessage `json:"attributes"`
+	Tags       []string                   `json:"tags"`
+	CreatedAt  time.Time                  `json:"created_at"`
+	UpdatedAt  time.Time                  `json:"updated_at"`
 }
 
 type CreateRecipientPayload struct {
@@ -22,6 +32,86 @@ type CreateRecipientPayload struct {
 
 	ExternalID string  `json:"id"`
 	Name       *string `json:"name"`
+	// Locale is a BCP 47 tag ("pt-BR"), stored canonicalized. It picks which of
+	// a send's `variants` this recipient gets. In a batch, omitting it keeps
+	// whatever an existing recipient has.
+	Locale *string `json:"locale"`
+	// Timezone is an IANA zone name ("Asia/Kolkata"); omitted reads as UTC.
+	Timezone *string `json:"timezone"`
+	// QuietHoursStart / QuietHoursEnd ("HH:MM", local to Timezone) hold email
+	// back during that window each day; it may cross midnight. Both or neither.
+	// In a batch, omitting them keeps whatever an existing recipient has.
+	QuietHoursStart *string `json:"quiet_hours_start"`
+	QuietHoursEnd   *string `json:"quiet_hours_end"`
+	// Attributes are the caller's own fields, merged into an existing
+	// recipient's by key; a null value removes that key. Tags replace the
+	// stored set when sent. In a batch, omitting either keeps what is stored.
+	Attributes map[string]json.RawMessage `json:"attributes"`
+	Tags       []string                   `json:"tags"`
+}
+
+// validateRecipientLocale checks and canonicalizes a locale in place. `allowClear`
+// admits "", which on an update clears the stored value.
+func validateRecipientLocale(errs *service.InputValidationErrors, locale *string, allowClear bool) {
+	if locale == nil {
+		return
+	}
+
+	if strings.TrimSpace(*locale) == "" {
+		*locale = ""
+		if !allowClear {
+			errs.Add(apires.NewApiError("Invalid locale", "Locale cannot be empty", "locale", *locale))
+		}
+		return
+	}
+
+	canonical, ok := entity.CanonicalLocale(*locale)
+	if !ok {
+		errs.Add(apires.NewApiError("Invalid locale", "Locale must be a BCP 47 language tag, e.g. en or pt-BR", "locale", *locale))
+		return
+	}
+	*locale = canonical
+}
+
+// validateRecipientSchedule checks a timezone and quiet-hours pair. `allowClear`
+// admits "" for each, which on an update clears the stored value.
+func validateRecipientSchedule(errs *service.InputValidationErrors, timezone, start, end *string, allowClear bool) {
+	if timezone != nil {
+		tz := strings.TrimSpace(*timezone)
+		*timezone = tz
+		if tz == "" {
+			if !allowClear {
+				errs.Add(apires.NewApiError("Invalid timezone", "Timezone cannot be empty", "timezone", tz))
+			}
+		} else if _, err := time.LoadLocation(tz); err != nil || tz == "Local" {
+			errs.Add(apires.NewApiError("Invalid timezone", "Timezone must be an IANA zone name, e.g. Europe/Berlin", "timezone", tz))
+		}
+	}
+
+	if (start == nil) != (end == nil) {
+		errs.Add(apires.NewApiError("Incomplete quiet hours", "quiet_hours_start and quiet_hours_end must be set together", "quiet_hours_start", start))
+		return
+	}
+	if start == nil {
+		return
+	}
+
+	*start, *end = strings.TrimSpace(*start), strings.TrimSpace(*end)
+	if *start == "" && *end == "" && allowClear {
+		return
+	}
+
+	startMin, startErr := entity.ParseClock(*start)
+	if startErr != nil {
+		errs.Add(apires.NewApiError("Invalid quiet hours", "quiet_hours_start must be HH:MM (24-hour)", "quiet_hours_start", *start))
+	}
+	endMin, endErr := entity.ParseClock(*end)
+	if endErr != nil {
+		errs.Add(apires.NewApiError("Invalid quiet hours", "quiet_hours_end must be HH:MM (24-hour)", "quiet_hours_end", *end))
+	}
+	if startErr == nil && endErr == nil && startMin == endMin {
+		errs.Add(apires.NewApiError("Invalid quiet hours", "Quiet hours cannot start and end at the same time", "quiet_hours_end", *end))
+	}
 }
 
 func (p *CreateRecipientPayload) Validate() error {
@@ -42,6 +132,11 @@ func (p *CreateRecipientPayload) Validate() error {
 		errs.Add(apires.NewApiError("Name is required", "Name cannot be empty", "name", p.Name))
 	}
 
+	validateRecipientLocale(&errs, p.Locale, false)
+	validateRecipientSchedule(&errs, p.Timezone, p.QuietHoursStart, p.QuietHoursEnd, false)
+	validateRecipientAttributes(&errs, p.Attributes)
+	p.Tags = normalizeRecipientTags(&errs, p.Tags)
+
 	if len(errs) > 0 {
 		return errs
 	}
@@ -49,8 +144,29 @@ func (p *CreateRecipientPayload) Validate() error {
 	return nil
 }
 
+// ApplySettings copies the locale, timezone, quiet hours, attributes and tags
+// onto a recipient being created.
+func (p *CreateRecipientPayload) ApplySettings(r *entity.Recipient) {
+	r.Locale = p.Locale
+	r.Timezone = p.Timezone
+	r.QuietHoursStart = p.QuietHoursStart
+	r.QuietHoursEnd = p.QuietHoursEnd
+	r.Attributes = p.Attributes
+	r.Tags = p.Tags
+}
+
+// UpdateRecipientPayload is a partial update: an omitted field keeps its value.
+// "" clears the locale or the timezone, and clears quiet hours when sent for
+// both ends. Attributes merge by key (null removes one); tags, when sent,
+// replace the set, so [] clears it.
 type UpdateRecipientPayload struct {
-	Name *string `json:"name"`
+	Name            *string                    `json:"name"`
+	Locale          *string                    `json:"locale"`
+	Timezone        *string                    `json:"timezone"`
+	QuietHoursStart *string                    `json:"quiet_hours_start"`
+	QuietHoursEnd   *string                    `json:"quiet_hours_end"`
+	Attributes      map[string]json.RawMessage `json:"attributes"`
+	Tags            []string                   `json:"tags"`
 }
 
 func (p *UpdateRecipientPayload) Validate() error {
@@ -60,6 +176,11 @@ func (p *UpdateRecipientPayload) Validate() error {
 		errs.Add(apires.NewApiError("Name is required", "Name cannot be empty", "name", p.Name))
 	}
 
+	validateRecipientLocale(&errs, p.Locale, true)
+	validateRecipientSchedule(&errs, p.Timezone, p.QuietHoursStart, p.QuietHoursEnd, true)
+	validateRecipientAttributes(&errs, p.Attributes)
+	p.Tags = normalizeRecipientTags(&errs, p.Tags)
+
 	if len(errs) > 0 {
 		return errs
 	}
@@ -97,10 +218,16 @@ func FromRecipient(r *entity.Recipient) *Recipient {
 	}
 
 	return &Recipient{
-		ExternalID: r.ExternalID,
-		Name:       r.Name,
-		CreatedAt:  r.CreatedAt,
-		UpdatedAt:  r.UpdatedAt,
+		ExternalID:      r.ExternalID,
+		Name:            r.Name,
+		Locale:          r.Locale,
+		Timezone:        r.Timezone,
+		QuietHoursStart: r.QuietHoursStart,
+		QuietHoursEnd:   r.QuietHoursEnd,
+		Attributes:      r.Attributes,
+		Tags:            r.Tags,
+		CreatedAt:       r.CreatedAt,
+		UpdatedAt:       r.UpdatedAt,
 	}
 }
 
@@ -157,9 +284,102 @@ type DeleteRecipientDataPayload struct {
 type ListRecipientsPayload struct {
 	query.Pagination
 	ProjectID int `json:"project_id"`
+
+	// Tags keeps recipients carrying EVERY given tag (`?tag=beta&tag=vip`).
+	Tags []string `schema:"tag"`
+	// Attribute keeps recipients whose attribute equals the value, one
+	// `key:value` per param, all of which must match. An array attribute matches
+	// when any element does.
+	Attribute []string `schema:"attribute"`
+
+	// Attributes is Attribute parsed by Validate.
+	Attributes []RecipientAttributeFilter `schema:"-"`
+}
+
+// Validate normalizes the filters the way the stored values are normalized, and
+// rejects an attribute filter that is not key:value.
+func (p *ListRecipientsPayload) Validate() error {
+	var errs service.InputValidationErrors
+
+	tags := make([]string, 0, len(p.Tags))
+	for _, tag := range p.Tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > MaxRecipientFilters {
+		errs.Add(apires.NewApiError("Too many tag filters", fmt.Sprintf("At most %d tag filters", MaxRecipientFilters), "tag", len(tags)))
+	}
+	p.Tags = tags
+
+	p.Attributes = parseRecipientAttributeFilters(&errs, p.Attribute)
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
 }
 
 type ListRecipientsResult struct {
 	Recipients []*RecipientListItem `json:"recipients"`
 	Pagination query.PaginationMeta `json:"pagination"`
 }
+
+// IssueRecipientTokenPayload is the body of POST /recipients/{id}/token. The
+// recipient comes from the path, the project from the (full-scope) API key.
+type IssueRecipientTokenPayload struct {
+	ProjectID      int
+	RecipientExtID string
+
+	// ExpiresIn is the token lifetime in seconds. Optional; omitted means
+	// recipienttoken.DefaultTTL.
+	ExpiresIn *int `json:"expires_in"`
+}
+
+func (p *IssueRecipientTokenPayload) Validate() error {
+	var errs service.InputValidationErrors
+
+	if p.ProjectID <= 0 {
+		errs.Add(apires.NewApiError("Project is required", "Project ID must be a positive integer", "project_id", p.ProjectID))
+	}
+
+	if p.RecipientExtID == "" {
+		errs.Add(apires.NewApiError("ID is required", "ID cannot be empty", "id", p.RecipientExtID))
+	} else {
+		p.RecipientExtID = strings.ToLower(p.RecipientExtID)
+	}
+
+	if p.ExpiresIn != nil {
+		ttl := time.Duration(*p.ExpiresIn) * time.Second
+		if ttl < recipienttoken.MinTTL || ttl > recipienttoken.MaxTTL {
+			errs.Add(apires.NewApiError(
+				"Invalid expiry",
+				fmt.Sprintf("expires_in must be between %d and %d seconds", int(recipienttoken.MinTTL.Seconds()), int(recipienttoken.MaxTTL.Seconds())),
+				"expires_in", *p.ExpiresIn,
+			))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// TTL is the validated lifetime, falling back to the default.
+func (p *IssueRecipientTokenPayload) TTL() time.Duration {
+	if p.ExpiresIn == nil {
+		return recipienttoken.DefaultTTL
+	}
+	return time.Duration(*p.ExpiresIn) * time.Second
+}
+
+// RecipientToken is a freshly minted recipient-bound token. The client sends
+// Token in the X-Recipient-Token header on the recipient sub-routes.
+type RecipientToken struct {
+	Token          string    `json:"token"`
+	RecipientExtID string    `json:"recipient_id"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
@@ -0,0 +1,172 @@
+package dto
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/mudgallabs/tantra/apires"
+	"github.com/mudgallabs/tantra/service"
+)
+
+const (
+	// MaxRecipientAttributes caps the keys one request may write. It is a
+	// per-request cap; merging writes can grow a recipient past it.
+	MaxRecipientAttributes = 50
+	MaxRecipientTags       = 50
+	// MaxRecipientFilters caps each of the tag and attribute filters on a list.
+	MaxRecipientFilters = 20
+
+	maxAttributeValueBytes = 1024
+	maxTagLen              = 64
+)
+
+// Attribute keys are plain identifiers. Keeping ':' out of them is what lets the
+// list filter take "key:value" without an escaping scheme.
+var attributeKeyRe = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// validateRecipientAttributes checks attribute keys and values, compacting each
+// value in place. A null value is kept: it removes the key when merged into an
+// existing recipient, and is dropped when creating one.
+func validateRecipientAttributes(errs *service.InputValidationErrors, attrs map[string]json.RawMessage) {
+	if len(attrs) > MaxRecipientAttributes {
+		errs.Add(apires.NewApiError("Too many attributes", fmt.Sprintf("At most %d attributes per request", MaxRecipientAttributes), "attributes", len(attrs)))
+		return
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		field := "attributes." + key
+		if !attributeKeyRe.MatchString(key) {
+			errs.Add(apires.NewApiError("Invalid attribute key", "Keys are 1-64 letters, digits, '_', '.' or '-'", field, key))
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, attrs[key]); err != nil || !isAttributeValue(buf.Bytes()) {
+			errs.Add(apires.NewApiError("Invalid attribute value", "Values must be a string, number, boolean or an array of those (or null to remove)", field, string(attrs[key])))
+			continue
+		}
+		if buf.Len() > maxAttributeValueBytes {
+			errs.Add(apires.NewApiError("Attribute value too large", fmt.Sprintf("Values are at most %d bytes of JSON", maxAttributeValueBytes), field, buf.Len()))
+			continue
+		}
+		attrs[key] = buf.Bytes()
+	}
+}
+
+// isAttributeValue admits null, scalars and flat arrays of scalars. Nesting is
+// kept out so every attribute stays answerable by one containment check.
+func isAttributeValue(raw json.RawMessage) bool {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return false
+	}
+
+	switch v := v.(type) {
+	case nil, string, float64, bool:
+		return true
+	case []any:
+		for _, elem := range v {
+			switch elem.(type) {
+			case string, float64, bool:
+			default:
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// normalizeRecipientTags trims, lowercases, dedupes and sorts tags. It keeps nil
+// apart from empty: nil is "not sent", empty clears.
+func normalizeRecipientTags(errs *service.InputValidationErrors, tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > maxTagLen {
+			errs.Add(apires.NewApiError("Invalid tag", fmt.Sprintf("Tags are 1-%d characters", maxTagLen), "tags", tag))
+			continue
+		}
+		normalized = append(normalized, tag)
+	}
+
+	slices.Sort(normalized)
+	normalized = slices.Compact(normalized)
+
+	if len(normalized) > MaxRecipientTags {
+		errs.Add(apires.NewApiError("Too many tags", fmt.Sprintf("At most %d tags", MaxRecipientTags), "tags", len(normalized)))
+	}
+
+	return normalized
+}
+
+// RecipientAttributeFilter is one `attribute=key:value` list filter.
+type RecipientAttributeFilter struct {
+	Key   string
+	Value string
+}
+
+// Matches lists the JSON objects an attribute matching the filter contains. The
+// value arrives as text, so "12" is the number 12 or the string "12", and each
+// of those may be the whole value or one element of an array value.
+func (f RecipientAttributeFilter) Matches() []json.RawMessage {
+	str, _ := json.Marshal(f.Value)
+	values := []json.RawMessage{str}
+
+	var literal any
+	if err := json.Unmarshal([]byte(f.Value), &literal); err == nil {
+		switch literal.(type) {
+		case float64, bool:
+			var buf bytes.Buffer
+			_ = json.Compact(&buf, []byte(f.Value))
+			values = append(values, buf.Bytes())
+		}
+	}
+
+	matches := make([]json.RawMessage, 0, 2*len(values))
+	for _, v := range values {
+		scalar, _ := json.Marshal(map[string]json.RawMessage{f.Key: v})
+		array, _ := json.Marshal(map[string][]json.RawMessage{f.Key: {v}})
+		matches = append(matches, scalar, array)
+	}
+	return matches
+}
+
+// parseRecipientAttributeFilters splits "key:value" filters on the first ':'.
+// Blank entries are ignored, like any other cleared list filter.
+func parseRecipientAttributeFilters(errs *service.InputValidationErrors, raw []string) []RecipientAttributeFilter {
+	var filters []RecipientAttributeFilter
+	for _, r := range raw {
+		if strings.TrimSpace(r) == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(r, ":")
+		key = strings.TrimSpace(key)
+		if !ok || !attributeKeyRe.MatchString(key) {
+			errs.Add(apires.NewApiError("Invalid attribute filter", "Expected key:value, e.g. plan:pro", "attribute", r))
+			continue
+		}
+		filters = append(filters, RecipientAttributeFilter{Key: key, Value: value})
+	}
+
+	if len(filters) > MaxRecipientFilters {
+		errs.Add(apires.NewApiError("Too many attribute filters", fmt.Sprintf("At most %d attribute filters", MaxRecipientFilters), "attribute", len(filters)))
+	}
+
+	return filters
+}
@@ -0,0 +1,88 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRecipientAttributesValidation(t *testing.T) {
+	p := CreateRecipientPayload{
+		ProjectID:  1,
+		ExternalID: "u1",
+		Attributes: map[string]json.RawMessage{
+			"plan":    json.RawMessage(`"pro"`),
+			"seats":   json.RawMessage(` 12 `),
+			"roles":   json.RawMessage(`["admin", "billing"]`),
+			"removed": json.RawMessage(`null`),
+		},
+	}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("valid attributes rejected: %v", err)
+	}
+	if got := string(p.Attributes["seats"]); got != "12" {
+		t.Errorf("seats = %q, want the compacted value", got)
+	}
+
+	bad := map[string]string{
+		"nested":    `{"a": 1}`,
+		"deep":      `[[1]]`,
+		"bad key":   `"x"`,
+		"has:colon": `"x"`,
+	}
+	for key, value := range bad {
+		p := CreateRecipientPayload{ProjectID: 1, ExternalID: "u1", Attributes: map[string]json.RawMessage{key: json.RawMessage(value)}}
+		if err := p.Validate(); !hasErrorFor(err, "attributes."+key) {
+			t.Errorf("attribute %s=%s: want an error, got %v", key, value, err)
+		}
+	}
+
+	many := map[string]json.RawMessage{}
+	for i := range MaxRecipientAttributes + 1 {
+		many[fmt.Sprintf("k%d", i)] = json.RawMessage(`1`)
+	}
+	up := UpdateRecipientPayload{Attributes: many}
+	if err := up.Validate(); !hasErrorFor(err, "attributes") {
+		t.Errorf("want too-many-attributes error, got %v", err)
+	}
+}
+
+func TestRecipientTagsNormalized(t *testing.T) {
+	p := UpdateRecipientPayload{Tags: []string{" VIP", "beta", "vip"}}
+	if err := p.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if want := []string{"beta", "vip"}; !reflect.DeepEqual(p.Tags, want) {
+		t.Errorf("tags = %q, want %q", p.Tags, want)
+	}
+
+	// [] clears the tags and must survive as empty, not collapse to "not sent".
+	p = UpdateRecipientPayload{Tags: []string{}}
+	if err := p.Validate(); err != nil || p.Tags == nil {
+		t.Errorf("empty tags = %v (err %v), want a non-nil empty set", p.Tags, err)
+	}
+
+	p = UpdateRecipientPayload{Tags: []string{"  "}}
+	if err := p.Validate(); !hasErrorFor(err, "tags") {
+		t.Errorf("blank tag: want an error, got %v", err)
+	}
+}
+
+func TestRecipientAttributeFilterMatches(t *testing.T) {
+	tests := map[RecipientAttributeFilter][]string{
+		{Key: "plan", Value: "pro"}:  {`{"plan":"pro"}`, `{"plan":["pro"]}`},
+		{Key: "seats", Value: "12"}:  {`{"seats":"12"}`, `{"seats":["12"]}`, `{"seats":12}`, `{"seats":[12]}`},
+		{Key: "beta", Value: "true"}: {`{"beta":"true"}`, `{"beta":["true"]}`, `{"beta":true}`, `{"beta":[true]}`},
+	}
+
+	for filter, want := range tests {
+		var got []string
+		for _, m := range filter.Matches() {
+			got = append(got, string(m))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%+v.Matches() = %v, want %v", filter, got, want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package dto
+
+import "testing"
+
+func TestCreateRecipientPayload_Validate_Schedule(t *testing.T) {
+	ok := CreateRecipientPayload{
+		ProjectID: 1, ExternalID: "user_1",
+		Timezone: strptr(" Asia/Kolkata "), QuietHoursStart: strptr("22:00"), QuietHoursEnd: strptr("07:00"),
+	}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("overnight quiet hours should validate, got %v", err)
+	}
+	if *ok.Timezone != "Asia/Kolkata" {
+		t.Errorf("timezone = %q, want it trimmed", *ok.Timezone)
+	}
+
+	badZone := CreateRecipientPayload{ProjectID: 1, ExternalID: "user_1", Timezone: strptr("IST")}
+	if err := badZone.Validate(); !hasErrorFor(err, "timezone") {
+		t.Errorf("a zone abbreviation must be rejected, got %v", err)
+	}
+
+	// Half a window has no meaning.
+	half := CreateRecipientPayload{ProjectID: 1, ExternalID: "user_1", QuietHoursStart: strptr("22:00")}
+	if err := half.Validate(); !hasErrorFor(err, "quiet_hours_start") {
+		t.Errorf("start without end must be rejected, got %v", err)
+	}
+
+	empty := CreateRecipientPayload{ProjectID: 1, ExternalID: "user_1", QuietHoursStart: strptr("9:00"), QuietHoursEnd: strptr("09:00")}
+	if err := empty.Validate(); !hasErrorFor(err, "quiet_hours_start") {
+		t.Errorf("a malformed time must be rejected, got %v", err)
+	}
+
+	zero := CreateRecipientPayload{ProjectID: 1, ExternalID: "user_1", QuietHoursStart: strptr("09:00"), QuietHoursEnd: strptr("09:00")}
+	if err := zero.Validate(); !hasErrorFor(err, "quiet_hours_end") {
+		t.Errorf("a zero-length window must be rejected, got %v", err)
+	}
+}
+
+// On update "" is how a value is cleared, so it is accepted there and nowhere
+// else.
+func TestUpdateRecipientPayload_Validate_ClearSchedule(t *testing.T) {
+	clear := UpdateRecipientPayload{Timezone: strptr(""), QuietHoursStart: strptr(""), QuietHoursEnd: strptr("")}
+	if err := clear.Validate(); err != nil {
+		t.Fatalf("clearing timezone and quiet hours should validate, got %v", err)
+	}
+
+	halfClear := UpdateRecipientPayload{QuietHoursStart: strptr(""), QuietHoursEnd: strptr("07:00")}
+	if err := halfClear.Validate(); !hasErrorFor(err, "quiet_hours_start") {
+		t.Errorf("clearing one end only must be rejected, got %v", err)
+	}
+
+	create := CreateRecipientPayload{ProjectID: 1, ExternalID: "user_1", Timezone: strptr("")}
+	if err := create.Validate(); !hasErrorFor(err, "timezone") {
+		t.Errorf("empty timezone on create must be rejected, got %v", err)
+	}
+}
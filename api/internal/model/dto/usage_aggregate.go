@@ -17,3 +17,16 @@ func NewUsageAggregate(userID int, metric entity.Metric, used, limit int64) Usag
 		Limit:  limit,
 	}
 }
+
+// UsageEstimate is what an event would do to its plan's quota if it ran now.
+// Nothing is consumed producing one.
+type UsageEstimate struct {
+	Metric entity.Metric `json:"metric"`
+	// Amount is what the event would consume.
+	Amount int64 `json:"amount"`
+	// Used is what the user's projects have consumed so far this period.
+	Used int64 `json:"used"`
+	// Limit is nil on a plan with no limit on the metric.
+	Limit       *int64 `json:"limit"`
+	WouldExceed bool   `json:"would_exceed"`
+}
@@ -22,6 +22,12 @@ type APIKey struct {
 	UserID    int
 	CreatedAt time.Time
 	UpdatedAt time.Time
+
+	// ProjectRequiresRecipientToken is the project's require_recipient_token,
+	// read alongside the key by GetByTokenHash so the recipient routes can
+	// decide on a missing token without a project read per request. Only set
+	// on keys loaded for authentication.
+	ProjectRequiresRecipientToken bool
 }
 
 func NewAPIKey(userID, projectID int, name string, scope enum.APIKeyScope) (*APIKey, error) {
@@ -0,0 +1,55 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+)
+
+// AudienceSegment narrows a broadcast to the recipients matching it, using data
+// Bodhveda already stores about them. It is applied on top of preferences, never
+// instead of them: a recipient outside the segment is excluded, and one inside
+// it still has to resolve eligible.
+//
+// A segment is a tree. The conditions set on one node must ALL hold, `all`
+// requires every child to match and `any` requires at least one, so
+//
+//	{"has_contact": "email", "any": [{"tag": "beta"}, {"read_within_days": 30}]}
+//
+// is "has a primary email contact, and is tagged beta or read something in the
+// last 30 days". Stored on the broadcast row as JSON, so the field names are the
+// API's.
+type AudienceSegment struct {
+	All []AudienceSegment `json:"all,omitempty"`
+	Any []AudienceSegment `json:"any,omitempty"`
+
+	// CreatedAfter / CreatedBefore bound when the recipient was created:
+	// [after, before).
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+
+	// HasContact / LacksContact test for a PRIMARY recipient_contact on a medium.
+	HasContact   *enum.Medium `json:"has_contact,omitempty"`
+	LacksContact *enum.Medium `json:"lacks_contact,omitempty"`
+
+	// ReadWithinDays keeps recipients who read any notification of the project
+	// in the last N days, counted back from when the broadcast fans out.
+	ReadWithinDays *int `json:"read_within_days,omitempty"`
+
+	// ExternalIDPrefix matches the start of the recipient's id.
+	ExternalIDPrefix *string `json:"external_id_prefix,omitempty"`
+
+	// Tag and Attribute match like the console's recipient list filters: a tag
+	// the recipient carries, and a "key:value" attribute.
+	Tag       *string `json:"tag,omitempty"`
+	Attribute *string `json:"attribute,omitempty"`
+}
+
+// HasConditions reports whether the node itself tests anything, combinators
+// aside.
+func (s *AudienceSegment) HasConditions() bool {
+	return s.CreatedAfter != nil || s.CreatedBefore != nil ||
+		s.HasContact != nil || s.LacksContact != nil ||
+		s.ReadWithinDays != nil || s.ExternalIDPrefix != nil ||
+		s.Tag != nil || s.Attribute != nil
+}
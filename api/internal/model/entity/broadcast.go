@@ -16,8 +16,27 @@ type Broadcast struct {
 	Event       string
 	Status      enum.BroadcastStatus
 	CompletedAt *time.Time
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// SendAt is when a scheduled broadcast is due; nil for an immediate one.
+	SendAt *time.Time
+	// ExpiresAt is copied onto every notification the broadcast fans out into.
+	ExpiresAt *time.Time
+	// Priority is the lane the broadcast's tasks run in, and is copied onto
+	// every notification it fans out into. Bulk unless the send said otherwise.
+	Priority enum.Priority
+	// Version counts payload edits made through the broadcast, starting at 1.
+	Version int
+	// Variants is the localized content each recipient is matched against by
+	// locale at fan-out. Nil for a broadcast sent without any.
+	Variants ContentVariants
+	// Segment narrows the audience at fan-out. Nil reaches every recipient the
+	// preferences allow, which is what a broadcast always did.
+	Segment *AudienceSegment
+	// RecipientListSize is set on a broadcast sent to an explicit list of
+	// recipient ids (stored in broadcast_recipient), and is the list's length.
+	// Nil for a broadcast to the target's whole audience. See IsListBased.
+	RecipientListSize *int
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
 	// Audience is the recipient breakdown FROZEN when prepare_batches resolved
 	// this broadcast's audience. Nil for broadcasts sent before the counts
 	// existed, and for ones whose fan-out has not run yet — the console must
@@ -33,6 +52,17 @@ type Broadcast struct {
 	Email *BroadcastEmail
 }
 
+// IsListBased reports whether the broadcast's audience is its recipient list
+// rather than every recipient of the project.
+func (b *Broadcast) IsListBased() bool {
+	return b.RecipientListSize != nil
+}
+
+// Expired reports whether the broadcast's expires_at has passed at now.
+func (b *Broadcast) Expired(now time.Time) bool {
+	return b.ExpiresAt != nil && !b.ExpiresAt.After(now)
+}
+
 // BroadcastEmail is the email half of a broadcast: the content, the frozen count
 // of who was eligible for it, and why it did not go out if it did not.
 type BroadcastEmail struct {
@@ -46,7 +76,8 @@ type BroadcastEmail struct {
 	EligibleRecipients *int
 
 	// BlockedReason is set when email was requested but deliberately did not go
-	// out — currently only `recipient_cap_exceeded`. Empty when email ran.
+	// out: `recipient_cap_exceeded` or `provider_not_configured`. Empty when email
+	// ran.
 	BlockedReason string
 }
 
@@ -58,6 +89,10 @@ type BroadcastEmail struct {
 // looks like success and is harder to notice than nothing being sent.
 const EmailBlockedRecipientCapExceeded = "recipient_cap_exceeded"
 
+// EmailBlockedProviderNotConfigured is recorded when the project has no email
+// settings, so nothing could be mailed whatever the audience.
+const EmailBlockedProviderNotConfigured = "provider_not_configured"
+
 // BroadcastAudience is the frozen recipient breakdown for one broadcast.
 //
 // ⚠️ It is stored rather than computed on read because these numbers are only
@@ -71,11 +106,20 @@ const EmailBlockedRecipientCapExceeded = "recipient_cap_exceeded"
 // usual reason a broadcast silently reaches nobody. They deliberately reuse the
 // vocabulary the direct-send path already writes to
 // notification_delivery.failure_reason ('preference_disabled' / 'not_cataloged').
+//
+// ExcludedSegment is the SENDER narrowing the broadcast with a segment. It is
+// counted first: a recipient outside the segment lands there whatever their
+// preferences, so the four buckets still add up to Total.
+//
+// ExcludedUnknown is only ever set on a list-based broadcast, where Total is the
+// length of the list: ids on it that had no recipient at fan-out.
 type BroadcastAudience struct {
 	Total                int
 	Eligible             int
 	ExcludedDisabled     int
 	ExcludedNotCataloged int
+	ExcludedSegment      int
+	ExcludedUnknown      int
 }
 
 func NewBroadcast(projectID int, payload json.RawMessage, channel string, topic string, event string) *Broadcast {
@@ -88,6 +132,8 @@ func NewBroadcast(projectID int, payload json.RawMessage, channel string, topic
 		Event:       event,
 		Status:      enum.BroadcastStatusEnqueued,
 		CompletedAt: nil,
+		Priority:    enum.PriorityBulk,
+		Version:     1,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/mudgallabs/bodhveda/internal/model/enum"
@@ -20,9 +21,23 @@ type BroadcastBatch struct {
 	RecipientExtIDs []string
 	Status          enum.BroadcastBatchStatus
 	Attempt         int
-	Duration        int // Duration in milliseconds
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	// Retries counts the times an operator re-enqueued the batch after it
+	// failed. It is part of the delivery task id; see DeliveryTaskID.
+	Retries   int
+	Duration  int // Duration in milliseconds
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// DeliveryTaskID is the Asynq task id the batch's delivery is enqueued under.
+// Stable within a round, so re-enqueueing a batch that is already queued is
+// refused as a duplicate. A new round per retry, because the id of a task that
+// exhausted its retries stays taken in Asynq's archive.
+func (b *BroadcastBatch) DeliveryTaskID() string {
+	if b.Retries == 0 {
+		return fmt.Sprintf("broadcast-batch-%d", b.ID)
+	}
+	return fmt.Sprintf("broadcast-batch-%d-retry-%d", b.ID, b.Retries)
 }
 
 func NewBroadcastBatch(broadcastID int, recipientExtIDs []string) *BroadcastBatch {
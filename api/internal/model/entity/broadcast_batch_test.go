@@ -0,0 +1,14 @@
+package entity
+
+import "testing"
+
+func TestBroadcastBatchDeliveryTaskID(t *testing.T) {
+	// The first round keeps the id batches have always been enqueued under, so
+	// a prepare retry still collides with the task a previous attempt queued.
+	if got := (&BroadcastBatch{ID: 42}).DeliveryTaskID(); got != "broadcast-batch-42" {
+		t.Errorf("first round = %q", got)
+	}
+	if got := (&BroadcastBatch{ID: 42, Retries: 2}).DeliveryTaskID(); got != "broadcast-batch-42-retry-2" {
+		t.Errorf("second retry = %q", got)
+	}
+}
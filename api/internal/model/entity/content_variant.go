@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"encoding/json"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// ContentVariants is a send's localized content, keyed by canonical BCP 47 tag
+// ("pt-BR"). The send's own payload and email block are the default, so a
+// variant only carries what differs for its locale.
+type ContentVariants map[string]ContentVariant
+
+// ContentVariant is one locale's content. Either block may be omitted, in which
+// case that block falls back along the locale chain like a missing variant.
+type ContentVariant struct {
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Email   *VariantEmail   `json:"email,omitempty"`
+}
+
+// VariantEmail is a localized email block. Text is already resolved (derived
+// from HTML when the caller omitted it), like BroadcastEmail's.
+type VariantEmail struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// CanonicalLocale parses a BCP 47 tag and returns its canonical form ("pt-br"
+// becomes "pt-BR"). Recipient locales and variant keys both go through it, so
+// matching them is a plain string comparison.
+func CanonicalLocale(tag string) (string, bool) {
+	t, err := language.Parse(strings.TrimSpace(tag))
+	if err != nil || t == language.Und {
+		return "", false
+	}
+	return t.String(), true
+}
+
+// LocaleChain lists the tags to try for a locale, most specific first, by
+// dropping one subtag at a time: "zh-Hant-TW" tries "zh-Hant-TW", "zh-Hant",
+// then "zh". The default content is the implicit last step.
+func LocaleChain(locale string) []string {
+	if locale == "" {
+		return nil
+	}
+
+	chain := []string{locale}
+	for i := strings.LastIndexByte(locale, '-'); i > 0; i = strings.LastIndexByte(locale, '-') {
+		locale = locale[:i]
+		chain = append(chain, locale)
+	}
+	return chain
+}
+
+// PayloadFor returns the in-app payload for a recipient's locale, or nil when no
+// variant along its chain carries one and the default applies.
+func (v ContentVariants) PayloadFor(locale string) json.RawMessage {
+	for _, tag := range LocaleChain(locale) {
+		if variant, ok := v[tag]; ok && len(variant.Payload) > 0 {
+			return variant.Payload
+		}
+	}
+	return nil
+}
+
+// EmailFor is PayloadFor for the email block.
+func (v ContentVariants) EmailFor(locale string) *VariantEmail {
+	for _, tag := range LocaleChain(locale) {
+		if variant, ok := v[tag]; ok && variant.Email != nil {
+			return variant.Email
+		}
+	}
+	return nil
+}
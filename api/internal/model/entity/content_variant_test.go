@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCanonicalLocale(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"pt-BR", "pt-BR", true},
+		{"pt-br", "pt-BR", true},
+		{" en ", "en", true},
+		{"zh-hant-tw", "zh-Hant-TW", true},
+		{"", "", false},
+		{"und", "", false},
+		{"not a locale", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := CanonicalLocale(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("CanonicalLocale(%q) = (%q, %v), want (%q, %v)", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestLocaleChain(t *testing.T) {
+	tests := map[string][]string{
+		"pt-BR":      {"pt-BR", "pt"},
+		"zh-Hant-TW": {"zh-Hant-TW", "zh-Hant", "zh"},
+		"en":         {"en"},
+		"":           nil,
+	}
+
+	for locale, want := range tests {
+		if got := LocaleChain(locale); !reflect.DeepEqual(got, want) {
+			t.Errorf("LocaleChain(%q) = %v, want %v", locale, got, want)
+		}
+	}
+}
+
+func TestContentVariantsFallback(t *testing.T) {
+	variants := ContentVariants{
+		"pt":    {Payload: json.RawMessage(`{"t":"pt"}`), Email: &VariantEmail{Subject: "Olá"}},
+		"pt-BR": {Email: &VariantEmail{Subject: "Oi"}},
+		"de":    {Payload: json.RawMessage(`{"t":"de"}`)},
+	}
+
+	// pt-BR has its own email, but no payload of its own, so that block comes
+	// from pt one step up the chain.
+	if got := string(variants.PayloadFor("pt-BR")); got != `{"t":"pt"}` {
+		t.Errorf("pt-BR payload = %s, want pt's", got)
+	}
+	if got := variants.EmailFor("pt-BR"); got == nil || got.Subject != "Oi" {
+		t.Errorf("pt-BR email = %+v, want its own", got)
+	}
+
+	if got := variants.PayloadFor("de-AT"); string(got) != `{"t":"de"}` {
+		t.Errorf("de-AT payload = %s, want de's", got)
+	}
+	if got := variants.EmailFor("de-AT"); got != nil {
+		t.Errorf("de-AT email = %+v, want the default (nil)", got)
+	}
+
+	if got := variants.PayloadFor("fr"); got != nil {
+		t.Errorf("fr payload = %s, want the default (nil)", got)
+	}
+	if got := variants.PayloadFor(""); got != nil {
+		t.Errorf("no locale should get the default, got %s", got)
+	}
+}
@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+)
+
+// EmailDigest is one recipient's batch of digested emails for one window. While
+// `open` it accepts items; the flush job claims it once due_at passes and sends
+// it as a single email.
+type EmailDigest struct {
+	ID              int64
+	ProjectID       int
+	RecipientExtID  string
+	Window          enum.DigestWindow
+	Status          enum.EmailDigestStatus
+	ContactID       *int64
+	AddressSnapshot *string
+	Provider        *string
+	// ProviderMessageID is the digest email's id at the provider. Every delivery
+	// in the digest links back to it through notification_delivery.digest_id.
+	ProviderMessageID *string
+	FailureReason     *string
+	Attempt           int
+	DueAt             time.Time
+	SentAt            *time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// EmailDigestItem is one email waiting in a digest, rendered as it would have
+// been sent on its own.
+type EmailDigestItem struct {
+	DeliveryID int64
+	Subject    string
+	HTML       string
+	Text       *string
+	// ExpiresAt is the notification's expiry, copied so the digest can drop an
+	// item that stopped being true while it waited.
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// IdempotencyKey is one claimed `Idempotency-Key` on POST /notifications/send.
+//
+// Response is nil while the claiming request is still in flight, and holds the
+// JSON-encoded SendNotificationResult once it has finished — which is what a
+// repeat of the same key is answered with.
+type IdempotencyKey struct {
+	ID          int
+	ProjectID   int
+	Key         string
+	RequestHash string
+	Response    json.RawMessage
+	CreatedAt   time.Time
+}
+
+// Completed reports whether the claiming request has finished and recorded its
+// response.
+func (k *IdempotencyKey) Completed() bool {
+	return len(k.Response) > 0
+}
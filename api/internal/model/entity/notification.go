@@ -18,10 +18,30 @@ type Notification struct {
 	Event          string
 	ReadAt         *time.Time
 	OpenedAt       *time.Time
+	SeenAt         *time.Time
 	Status         enum.NotificationStatus
 	CompletedAt    *time.Time
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	// SendAt is when a scheduled send is due. Nil for an immediate send; kept
+	// after the send fires, as the record of when it was asked to go out.
+	SendAt *time.Time
+	// ExpiresAt is when the notification stops being worth showing or sending.
+	// Nil means it never does.
+	ExpiresAt *time.Time
+	// CollapseKey, on a direct send, groups it with the recipient's earlier
+	// unread notifications under the same key: delivering it collapses them.
+	CollapseKey *string
+	// Priority is the lane the send's tasks run in. Broadcast notifications
+	// inherit their broadcast's.
+	Priority enum.Priority
+	// Version counts payload edits, starting at 1. See NotificationRepository.Patch.
+	Version int
+	// ArchivedAt moves the notification from the recipient's inbox to their
+	// archive. SnoozedUntil hides it from both until then; it is kept after,
+	// because the feed sorts a woken notification by it.
+	ArchivedAt   *time.Time
+	SnoozedUntil *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 
 	// Email delivery summary for this notification's email medium. Populated
 	// ONLY by ListNotifications (batch-joined from notification_delivery);
@@ -30,6 +50,11 @@ type Notification struct {
 	Email *NotificationEmailDelivery
 }
 
+// Expired reports whether the notification's expires_at has passed at now.
+func (n *Notification) Expired(now time.Time) bool {
+	return n.ExpiresAt != nil && !n.ExpiresAt.After(now)
+}
+
 // NotificationEmailDelivery is the email-medium delivery summary attached to a
 // listed notification. It carries every BOUNDED column of the delivery row, so
 // the list can explain an outcome (failure_reason) and the detail dialog can
@@ -73,7 +98,22 @@ func NewNotification(projectID int, recipientExtID string, payload json.RawMessa
 		OpenedAt:       nil,
 		Status:         enum.NotificationStatusEnqueued,
 		CompletedAt:    nil,
+		Priority:       enum.PriorityNormal,
+		Version:        1,
 		CreatedAt:      now,
 		UpdatedAt:      now,
 	}
 }
+
+// NotificationGroup is one row of a grouped recipient feed: the notifications
+// sharing a channel and topic (and event, when grouped by target), counted,
+// with the newest of them — the first in feed order, so a woken snooze counts
+// as new. Event is empty when the feed is grouped by topic.
+type NotificationGroup struct {
+	Channel     string
+	Topic       string
+	Event       string
+	Count       int
+	UnreadCount int
+	Newest      *Notification
+}
@@ -40,8 +40,14 @@ type NotificationDelivery struct {
 	// one per event by ApplyWebhookStatus (Phase 5). Unbounded — never project it
 	// into a list response. Nil when no webhook has ever landed for this row.
 	ProviderResponse json.RawMessage
-	CreatedAt        time.Time
-	UpdatedAt        time.Time
+	// DigestID is set when the email went (or will go) out inside a digest rather
+	// than on its own. DigestMessageID is that digest's provider message id — the
+	// row's own ProviderMessageID stays nil, since one provider message covers
+	// every delivery in the digest.
+	DigestID        *int64
+	DigestMessageID *string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
 // NewNotificationDelivery builds a delivery record with a resolved status. For a
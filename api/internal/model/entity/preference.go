@@ -2,6 +2,8 @@ package entity
 
 import (
 	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
 )
 
 type Preference struct {
@@ -32,6 +34,20 @@ type Preference struct {
 	// overridden, so a mandatory one is a contradiction. Enforced by a CHECK
 	// constraint (migration 20260801120000), not by convention.
 	Mandatory bool
+	// Digest, when set, batches this entry's emails per recipient into one email
+	// per window instead of sending each on its own. Nil means send immediately.
+	// Only a project-level email row may carry one (CHECK in migration
+	// 20260808120000).
+	Digest *enum.DigestWindow
+	// BypassQuietHours sends this entry's emails even inside the recipient's
+	// quiet hours. Only a project-level email row may set it (CHECK in migration
+	// 20260809120000); mandatory entries bypass regardless.
+	BypassQuietHours bool
+	// FrequencyCapLimit / FrequencyCapWindow cap how many sends of this entry's
+	// (target, medium) one recipient gets per window. Both set or both nil (CHECK
+	// in migration 20260810120000), and only on a project-level row.
+	FrequencyCapLimit  *int
+	FrequencyCapWindow *enum.CapWindow
 	// Name is the catalog entry's human name (e.g. "Marketing emails"). Nullable:
 	// null on a recipient-level row, required on a project-level (catalog) row.
 	Name *string
@@ -67,6 +83,29 @@ type ProjectPreferenceListItem struct {
 	Subscribers int
 }
 
+// CatalogEmailOptions is how an email catalog entry wants its emails sent. The
+// zero value — no digest, quiet hours apply — is also what a target with no
+// email entry gets.
+type CatalogEmailOptions struct {
+	Digest           enum.DigestWindow
+	BypassQuietHours bool
+}
+
+// FrequencyCap is at most Limit sends per recipient per Window, for the one
+// (target, medium) a catalog entry covers.
+type FrequencyCap struct {
+	Limit  int
+	Window enum.CapWindow
+}
+
+// FrequencyCap returns the entry's cap, or nil when it has none.
+func (p *Preference) FrequencyCap() *FrequencyCap {
+	if p.FrequencyCapLimit == nil || p.FrequencyCapWindow == nil {
+		return nil
+	}
+	return &FrequencyCap{Limit: *p.FrequencyCapLimit, Window: *p.FrequencyCapWindow}
+}
+
 // UncatalogedTarget is one (target, medium) a project has sent but never
 // cataloged — a send that strict targets would have rejected.
 //
@@ -148,3 +187,18 @@ type ResolvedPreference struct {
 func (r ResolvedPreference) Inherited() bool {
 	return r.Source != PreferenceSourceRecipientExact
 }
+
+// PreferenceRung is what one rung of the cascade holds for a single (recipient,
+// target, medium): the row it matched, if any. ResolvedPreference says which rung
+// won; the rungs say what each one would have said, which is what a "why did
+// this not send" question actually needs.
+type PreferenceRung struct {
+	Source PreferenceSource
+	// PreferenceID is the row this rung matched. Nil when it matched none.
+	PreferenceID *int
+	Enabled      bool
+	Mandatory    bool
+	// Consulted is false for the two topic='any' rungs of a topic='none' target:
+	// the cascade skips them, so a row there decides nothing.
+	Consulted bool
+}
@@ -19,6 +19,13 @@ type Project struct {
 	// NotificationService.gateTarget.
 	StrictTargets bool
 
+	// RequireRecipientToken makes a recipient token mandatory on the recipient
+	// sub-routes for any key that is not full scope. Off by default so existing
+	// notification centers keep working until their server starts minting; a
+	// token that is presented is verified regardless. See
+	// middleware.VerifyRecipientToken.
+	RequireRecipientToken bool
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
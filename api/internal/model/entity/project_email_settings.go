@@ -41,6 +41,13 @@ type ProjectEmailSettings struct {
 	// notice than nothing being sent.
 	MaxBroadcastRecipientsForEmail int
 
+	// DigestSubject / DigestHTML are the wrapper a digest email is sent in. The
+	// HTML must contain DigestItemsPlaceholder, which is replaced by the rendered
+	// items; the subject may use DigestCountPlaceholder. Both are optional — a
+	// project that never configures them gets a plain default.
+	DigestSubject *string
+	DigestHTML    *string
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -9,8 +11,23 @@ type Recipient struct {
 	ExternalID string // Unique recipient ID from the client's system. Stored lowercase; callers normalize via DTO Validate.
 	ProjectID  int
 	Name       string
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	// Locale is a canonical BCP 47 tag ("pt-BR") picking the send's content
+	// variant; nil gets the default content.
+	Locale *string
+	// Timezone is an IANA zone name; nil reads as UTC.
+	Timezone *string
+	// QuietHoursStart / QuietHoursEnd bound the daily window, in the recipient's
+	// local time ("HH:MM"), during which email is deferred. Both or neither; the
+	// window may cross midnight.
+	QuietHoursStart *string
+	QuietHoursEnd   *string
+	// Attributes is the customer's own data about the recipient ("plan": "pro").
+	// Values are JSON scalars or arrays of scalars; never nil once read back.
+	Attributes map[string]json.RawMessage
+	// Tags is a set of lowercase labels, kept sorted.
+	Tags      []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 func NewRecipient(projectID int, externalID, name string) *Recipient {
@@ -24,6 +41,61 @@ func NewRecipient(projectID int, externalID, name string) *Recipient {
 	}
 }
 
+// QuietUntil reports whether now falls inside the recipient's quiet hours and,
+// if so, when they end. The end is computed on the local calendar, so a window
+// spanning a DST change still ends at its wall-clock time.
+func (r *Recipient) QuietUntil(now time.Time) (time.Time, bool) {
+	if r.QuietHoursStart == nil || r.QuietHoursEnd == nil {
+		return time.Time{}, false
+	}
+
+	start, err := ParseClock(*r.QuietHoursStart)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := ParseClock(*r.QuietHoursEnd)
+	if err != nil || start == end {
+		return time.Time{}, false
+	}
+
+	loc := time.UTC
+	if r.Timezone != nil {
+		if l, err := time.LoadLocation(*r.Timezone); err == nil {
+			loc = l
+		}
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	endToday := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, loc)
+
+	if start < end {
+		if minute >= start && minute < end {
+			return endToday, true
+		}
+		return time.Time{}, false
+	}
+
+	// Crosses midnight: 22:00-07:00 is quiet from 22:00 to the end of the day and
+	// from the start of the day to 07:00.
+	if minute >= start {
+		return endToday.AddDate(0, 0, 1), true
+	}
+	if minute < end {
+		return endToday, true
+	}
+	return time.Time{}, false
+}
+
+// ParseClock parses an "HH:MM" wall-clock time into minutes past midnight.
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil || len(s) != 5 {
+		return 0, fmt.Errorf("invalid time %q: want HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
 type RecipientListItem struct {
 	Recipient
 
@@ -0,0 +1,92 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func quietRecipient(tz, start, end string) *Recipient {
+	r := &Recipient{QuietHoursStart: &start, QuietHoursEnd: &end}
+	if tz != "" {
+		r.Timezone = &tz
+	}
+	return r
+}
+
+func TestRecipientQuietUntil(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		recipient *Recipient
+		now       time.Time
+		wantQuiet bool
+		wantUntil time.Time
+	}{
+		{
+			name:      "overnight window, before midnight, ends tomorrow",
+			recipient: quietRecipient("Asia/Kolkata", "22:00", "07:00"),
+			now:       time.Date(2026, 8, 10, 23, 30, 0, 0, kolkata),
+			wantQuiet: true,
+			wantUntil: time.Date(2026, 8, 11, 7, 0, 0, 0, kolkata),
+		},
+		{
+			name:      "overnight window, after midnight, ends today",
+			recipient: quietRecipient("Asia/Kolkata", "22:00", "07:00"),
+			now:       time.Date(2026, 8, 11, 3, 0, 0, 0, kolkata),
+			wantQuiet: true,
+			wantUntil: time.Date(2026, 8, 11, 7, 0, 0, 0, kolkata),
+		},
+		{
+			name:      "overnight window, end is exclusive",
+			recipient: quietRecipient("Asia/Kolkata", "22:00", "07:00"),
+			now:       time.Date(2026, 8, 11, 7, 0, 0, 0, kolkata),
+		},
+		{
+			name:      "daytime window",
+			recipient: quietRecipient("", "12:00", "13:30"),
+			now:       time.Date(2026, 8, 10, 12, 15, 0, 0, time.UTC),
+			wantQuiet: true,
+			wantUntil: time.Date(2026, 8, 10, 13, 30, 0, 0, time.UTC),
+		},
+		{
+			// 23:30 UTC is 05:00 the next morning in Kolkata.
+			name:      "window is read in the recipient's zone, not the server's",
+			recipient: quietRecipient("Asia/Kolkata", "22:00", "07:00"),
+			now:       time.Date(2026, 8, 10, 23, 30, 0, 0, time.UTC),
+			wantQuiet: true,
+			wantUntil: time.Date(2026, 8, 11, 7, 0, 0, 0, kolkata),
+		},
+		{
+			name:      "no window",
+			recipient: &Recipient{},
+			now:       time.Date(2026, 8, 10, 3, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			until, quiet := tc.recipient.QuietUntil(tc.now)
+			if quiet != tc.wantQuiet {
+				t.Fatalf("quiet = %v, want %v", quiet, tc.wantQuiet)
+			}
+			if quiet && !until.Equal(tc.wantUntil) {
+				t.Errorf("until = %v, want %v", until, tc.wantUntil)
+			}
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	if m, err := ParseClock("07:30"); err != nil || m != 450 {
+		t.Errorf("ParseClock(07:30) = %d, %v", m, err)
+	}
+	for _, bad := range []string{"7:30", "24:00", "07:60", "0730", ""} {
+		if _, err := ParseClock(bad); err == nil {
+			t.Errorf("ParseClock(%q) should fail", bad)
+		}
+	}
+}
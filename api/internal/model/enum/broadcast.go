@@ -7,4 +7,16 @@ const (
 	BroadcastStatusCompleted     BroadcastStatus = "completed"
 	BroadcastStatusQuotaExceeded BroadcastStatus = "quota_exceeded"
 	BroadcastStatusFailed        BroadcastStatus = "failed"
+	// BroadcastStatusScheduled is a broadcast waiting for its `send_at`. Its
+	// prepare_batches task is parked in Asynq and flips it to `enqueued` when it
+	// fires; the audience is resolved then, not at request time.
+	BroadcastStatusScheduled BroadcastStatus = "scheduled"
+	// BroadcastStatusCancelled is a broadcast stopped before it finished going
+	// out: withdrawn while scheduled, or cancelled mid fan-out. Notifications it
+	// already wrote stay where they are; the batches it had not run never will.
+	BroadcastStatusCancelled BroadcastStatus = "cancelled"
+	// BroadcastStatusRecalled is a broadcast pulled back after it started going
+	// out. Its notifications are recalled with it, and any batch that had not
+	// fanned out yet never will.
+	BroadcastStatusRecalled BroadcastStatus = "recalled"
 )
@@ -6,4 +6,11 @@ const (
 	BroadcastBatchStatusEnqueued BroadcastBatchStatus = "enqueued"
 	BroadcastBatchStatusSuccess  BroadcastBatchStatus = "success"
 	BroadcastBatchStatusFailed   BroadcastBatchStatus = "failed"
+	// BroadcastBatchStatusRecalled is a batch whose broadcast was recalled before
+	// it fanned out. The delivery task finds it and writes nothing.
+	BroadcastBatchStatusRecalled BroadcastBatchStatus = "recalled"
+	// BroadcastBatchStatusCancelled is a batch whose broadcast was cancelled
+	// before it fanned out. Treated like a recalled one, and its recipients are
+	// refunded.
+	BroadcastBatchStatusCancelled BroadcastBatchStatus = "cancelled"
 )
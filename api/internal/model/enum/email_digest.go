@@ -0,0 +1,53 @@
+package enum
+
+import "time"
+
+// DigestWindow is how long a catalog entry's emails accumulate per recipient
+// before going out as one digest. Matches the `preference.digest` and
+// `email_digest.digest_window` CHECKs.
+type DigestWindow string
+
+const (
+	DigestHourly DigestWindow = "hourly"
+	DigestDaily  DigestWindow = "daily"
+
+	// DigestOff is accepted by the catalog update payload to turn digesting off.
+	// It is never stored: an entry that is not digested has a NULL digest.
+	DigestOff DigestWindow = "off"
+)
+
+// Valid reports whether w is a window a digest can be opened for.
+func (w DigestWindow) Valid() bool {
+	switch w {
+	case DigestHourly, DigestDaily:
+		return true
+	default:
+		return false
+	}
+}
+
+// Duration is how long a digest stays open after its first item.
+func (w DigestWindow) Duration() time.Duration {
+	switch w {
+	case DigestDaily:
+		return 24 * time.Hour
+	default:
+		return time.Hour
+	}
+}
+
+// EmailDigestStatus is the lifecycle of an `email_digest` row.
+type EmailDigestStatus string
+
+const (
+	// EmailDigestOpen — accepting items until due_at.
+	EmailDigestOpen EmailDigestStatus = "open"
+	// EmailDigestSending — claimed by the flush job; no longer accepting items.
+	EmailDigestSending EmailDigestStatus = "sending"
+	EmailDigestSent    EmailDigestStatus = "sent"
+	// EmailDigestFailed — the last attempt failed; the task may still retry it.
+	EmailDigestFailed EmailDigestStatus = "failed"
+	// EmailDigestExpired — every item expired before the digest went out, so
+	// nothing was sent.
+	EmailDigestExpired EmailDigestStatus = "expired"
+)
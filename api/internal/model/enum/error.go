@@ -5,4 +5,7 @@ import "errors"
 var (
 	ErrSubscriptionExpired error = errors.New("subscription expired")
 	ErrQuotaExceeded       error = errors.New("quota exceeded")
+	// ErrVersionMismatch is an edit that named a version the row has already
+	// moved past.
+	ErrVersionMismatch error = errors.New("version mismatch")
 )
@@ -0,0 +1,39 @@
+package enum
+
+import "time"
+
+// CapWindow is the window a catalog entry's frequency cap counts over. Matches
+// the `preference.frequency_cap_window` CHECK.
+type CapWindow string
+
+const (
+	CapPerMinute CapWindow = "minute"
+	CapPerHour   CapWindow = "hour"
+	CapPerDay    CapWindow = "day"
+
+	// CapOff is accepted by the catalog update payload to remove a cap. It is
+	// never stored: an uncapped entry has a NULL window.
+	CapOff CapWindow = "off"
+)
+
+// Valid reports whether w is a window a cap can be stored with.
+func (w CapWindow) Valid() bool {
+	switch w {
+	case CapPerMinute, CapPerHour, CapPerDay:
+		return true
+	default:
+		return false
+	}
+}
+
+// Duration is the length of one counting window.
+func (w CapWindow) Duration() time.Duration {
+	switch w {
+	case CapPerMinute:
+		return time.Minute
+	case CapPerDay:
+		return 24 * time.Hour
+	default:
+		return time.Hour
+	}
+}
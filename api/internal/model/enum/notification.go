@@ -45,6 +45,31 @@ const (
 	// GET /notifications/{id} have something to hang off; it is excluded from
 	// every recipient-facing read path.
 	NotificationStatusNotRequested NotificationStatus = "not_requested"
+	// NotificationStatusScheduled is a send carrying a future `send_at`. The row
+	// is written at request time so the caller has an id to read and cancel, but
+	// nothing has been resolved yet: the notification:delivery task is parked in
+	// Asynq until send_at, and moves the row on to `enqueued` (or
+	// `not_requested`, for an email-only send) when it fires.
+	//
+	// Hidden from the recipient's feed — it has not been sent.
+	NotificationStatusScheduled NotificationStatus = "scheduled"
+	// NotificationStatusCancelled is a scheduled send withdrawn before it fired.
+	// Terminal; the parked task finds it and does nothing.
+	NotificationStatusCancelled NotificationStatus = "cancelled"
+	// NotificationStatusCollapsed is a delivered, still-unread notification that a
+	// newer send with the same `collapse_key` has replaced. Terminal and hidden
+	// from the recipient's feed — the replacement row carries the content now.
+	NotificationStatusCollapsed NotificationStatus = "collapsed"
+	// NotificationStatusThrottled is a send the recipient's frequency cap for its
+	// target turned away: they had already had the catalog entry's limit of
+	// in-app notifications in the current window. Terminal, hidden from the feed
+	// and not metered — it was never delivered.
+	NotificationStatusThrottled NotificationStatus = "throttled"
+	// NotificationStatusRecalled is a notification the sender pulled back after
+	// it went out — POST /notifications/{id}/recall, or a recalled broadcast.
+	// Terminal and hidden from the feed, read or not; unlike a delete it is
+	// project-wide and the row stays for the audit trail.
+	NotificationStatusRecalled NotificationStatus = "recalled"
 )
 
 // Valid reports whether s is a status a notification row can actually hold.
@@ -53,7 +78,9 @@ const (
 func (s NotificationStatus) Valid() bool {
 	switch s {
 	case NotificationStatusEnqueued, NotificationStatusMuted, NotificationStatusDelivered,
-		NotificationStatusQuotaExceeded, NotificationStatusFailed, NotificationStatusNotRequested:
+		NotificationStatusQuotaExceeded, NotificationStatusFailed, NotificationStatusNotRequested,
+		NotificationStatusScheduled, NotificationStatusCancelled, NotificationStatusCollapsed,
+		NotificationStatusThrottled, NotificationStatusRecalled:
 		return true
 	default:
 		return false
@@ -23,6 +23,23 @@ package enum
 //     different medium — so the quota rejection is recorded where every other
 //     email outcome lives: on the delivery row. See
 //     NotificationService.DeliverDirectNotification.
+//   - DeliveryExpired is written instead of sending when the notification's
+//     expires_at passed before the email went out — at fan-out, or by the
+//     email:delivery task itself when it runs (or retries) late.
+//   - DeliveryDigested is written for an email that went out as part of a
+//     per-recipient digest rather than on its own. The row waits as `pending`
+//     with a digest_id until the digest email is sent; the provider message id
+//     it links to is the digest's (email_digest.provider_message_id).
+//   - DeliveryDeferred is set instead of DeliveryPending when the email landed
+//     inside the recipient's quiet hours: its task is enqueued to run when the
+//     window ends, and the worker moves it on from there like a pending one.
+//   - DeliveryThrottled is written instead of sending when the recipient has
+//     already had the catalog entry's frequency cap of emails for this target
+//     in the current window. Terminal: the email is dropped, not delayed.
+//   - DeliveryRecalled is written over a pending, deferred or failed (and so
+//     still retrying) delivery when its notification is recalled. The
+//     email:delivery task checks for it before calling the provider, so a
+//     recall that races the worker loses only emails already handed off.
 //
 // The remaining values (sending, suppressed, rejected) exist to match the table
 // CHECK but are not set yet (suppressed is reserved for address-level
@@ -42,6 +59,11 @@ const (
 	DeliverySuppressed       DeliveryStatus = "suppressed"
 	DeliveryQuotaExceeded    DeliveryStatus = "quota_exceeded"
 	DeliveryRejected         DeliveryStatus = "rejected"
+	DeliveryExpired          DeliveryStatus = "expired"
+	DeliveryDigested         DeliveryStatus = "digested"
+	DeliveryDeferred         DeliveryStatus = "deferred"
+	DeliveryThrottled        DeliveryStatus = "throttled"
+	DeliveryRecalled         DeliveryStatus = "recalled"
 )
 
 // Valid reports whether s is a status the `notification_delivery.status` CHECK
@@ -52,7 +74,8 @@ func (s DeliveryStatus) Valid() bool {
 	case DeliveryPending, DeliverySending, DeliverySent, DeliveryDelivered,
 		DeliveryBounced, DeliveryComplained, DeliveryFailed, DeliverySkippedMuted,
 		DeliverySkippedNoContact, DeliverySuppressed, DeliveryQuotaExceeded,
-		DeliveryRejected:
+		DeliveryRejected, DeliveryExpired, DeliveryDigested, DeliveryDeferred,
+		DeliveryThrottled, DeliveryRecalled:
 		return true
 	default:
 		return false
@@ -3,7 +3,7 @@ package enum
 // Outcome is the coarse, medium-independent answer to "how did this end up?".
 //
 // It exists because the raw status enums are too many and too specific to reason
-// about safely. NotificationStatus has 6 values and DeliveryStatus has 12, and
+// about safely. NotificationStatus has 11 values and DeliveryStatus has 17, and
 // anyone summarising them — the console tree, an alert, a chart — has to decide
 // which ones are bad. They will get it wrong in one specific way, and it matters:
 //
@@ -32,6 +32,10 @@ const (
 	// an outcome at all: it is the request restated. Counting it as succeeded
 	// would inflate delivery; counting it as failed would invent a problem.
 	OutcomeNotRequested Outcome = "not_requested"
+	// OutcomeScheduled — not due yet. Kept apart from pending on purpose: pending
+	// that does not drain is the signature of a stalled worker, and a send parked
+	// until next Tuesday is not that.
+	OutcomeScheduled Outcome = "scheduled"
 )
 
 // Outcome classifies a per-(notification, medium) delivery status.
@@ -39,9 +43,16 @@ func (s DeliveryStatus) Outcome() Outcome {
 	switch s {
 	case DeliveryPending, DeliverySending:
 		return OutcomePending
-	case DeliverySent, DeliveryDelivered:
+	case DeliveryDeferred:
+		// Held for quiet hours, not stuck: see OutcomeScheduled.
+		return OutcomeScheduled
+	case DeliverySent, DeliveryDelivered, DeliveryDigested:
 		return OutcomeSucceeded
-	case DeliverySkippedMuted, DeliverySkippedNoContact, DeliverySuppressed:
+	case DeliverySkippedMuted, DeliverySkippedNoContact, DeliverySuppressed, DeliveryExpired:
+		return OutcomeSuppressed
+	case DeliveryThrottled, DeliveryRecalled:
+		// The project's own frequency cap, or the sender pulling the send back —
+		// both deliberate.
 		return OutcomeSuppressed
 	case DeliveryFailed, DeliveryBounced, DeliveryComplained, DeliveryQuotaExceeded, DeliveryRejected:
 		return OutcomeFailed
@@ -64,7 +75,7 @@ func (s DeliveryStatus) Outcome() Outcome {
 // different question from "is this the final word from the provider?".
 func (s DeliveryStatus) Terminal() bool {
 	switch s {
-	case DeliveryPending, DeliverySending:
+	case DeliveryPending, DeliverySending, DeliveryDeferred:
 		return false
 	default:
 		return true
@@ -80,9 +91,17 @@ func (s NotificationStatus) Outcome() Outcome {
 	switch s {
 	case NotificationStatusEnqueued:
 		return OutcomePending
-	case NotificationStatusDelivered:
+	case NotificationStatusDelivered, NotificationStatusCollapsed:
+		// Collapsed was delivered; a newer send under the same key took its place
+		// in the inbox, which is what the sender asked for.
 		return OutcomeSucceeded
-	case NotificationStatusMuted:
+	case NotificationStatusScheduled:
+		return OutcomeScheduled
+	case NotificationStatusMuted, NotificationStatusCancelled, NotificationStatusThrottled,
+		NotificationStatusRecalled:
+		// Cancelled and recalled are the sender withdrawing the send (before and
+		// after it went out), throttled the project's own frequency cap —
+		// deliberate, like a mute, and nothing an operator has to fix.
 		return OutcomeSuppressed
 	case NotificationStatusFailed, NotificationStatusQuotaExceeded:
 		return OutcomeFailed
@@ -100,6 +119,11 @@ func (s NotificationStatus) Outcome() Outcome {
 // it sub-second, so a row still `enqueued` minutes later means the send path
 // stopped after the initial INSERT. internal/monitor's stuck_sends check is
 // built on exactly this.
+//
+// `scheduled` counts as terminal here even though it will move again: nothing is
+// working on it until its send_at, and a row that is SUPPOSED to sit still must
+// never look like a stall. Callers wanting "not due yet" ask for
+// OutcomeScheduled instead.
 func (s NotificationStatus) Terminal() bool {
 	return s != NotificationStatusEnqueued
 }
@@ -12,13 +12,22 @@ func TestDeliveryStatusOutcome(t *testing.T) {
 	}{
 		{DeliveryPending, OutcomePending},
 		{DeliverySending, OutcomePending},
+		// Quiet hours: waiting on the clock, not on a worker.
+		{DeliveryDeferred, OutcomeScheduled},
 		{DeliverySent, OutcomeSucceeded},
 		{DeliveryDelivered, OutcomeSucceeded},
+		{DeliveryDigested, OutcomeSucceeded},
 		// ⚠️ The three that must NOT be failures: the recipient opted out, has no
 		// address, or is suppressed. All are the system working as designed.
 		{DeliverySkippedMuted, OutcomeSuppressed},
 		{DeliverySkippedNoContact, OutcomeSuppressed},
 		{DeliverySuppressed, OutcomeSuppressed},
+		// Not sent because the sender said it would be stale by then.
+		{DeliveryExpired, OutcomeSuppressed},
+		// Over the catalog entry's frequency cap: the cap working, not a fault.
+		{DeliveryThrottled, OutcomeSuppressed},
+		// The sender recalled the notification before the email went out.
+		{DeliveryRecalled, OutcomeSuppressed},
 		{DeliveryFailed, OutcomeFailed},
 		{DeliveryBounced, OutcomeFailed},
 		{DeliveryComplained, OutcomeFailed},
@@ -41,7 +50,8 @@ func TestDeliveryStatusOutcome(t *testing.T) {
 	for _, s := range []DeliveryStatus{
 		DeliveryPending, DeliverySending, DeliverySent, DeliveryDelivered, DeliveryBounced,
 		DeliveryComplained, DeliveryFailed, DeliverySkippedMuted, DeliverySkippedNoContact,
-		DeliverySuppressed, DeliveryQuotaExceeded, DeliveryRejected,
+		DeliverySuppressed, DeliveryQuotaExceeded, DeliveryRejected, DeliveryExpired,
+		DeliveryDigested, DeliveryDeferred, DeliveryThrottled, DeliveryRecalled,
 	} {
 		if !covered[s] {
 			t.Errorf("DeliveryStatus %q is not covered by the outcome table", s)
@@ -62,7 +72,7 @@ func TestUnknownStatusIsNotTreatedAsSuccess(t *testing.T) {
 }
 
 func TestDeliveryStatusTerminal(t *testing.T) {
-	nonTerminal := []DeliveryStatus{DeliveryPending, DeliverySending}
+	nonTerminal := []DeliveryStatus{DeliveryPending, DeliverySending, DeliveryDeferred}
 	for _, s := range nonTerminal {
 		if s.Terminal() {
 			t.Errorf("%q should not be terminal", s)
@@ -71,7 +81,8 @@ func TestDeliveryStatusTerminal(t *testing.T) {
 
 	for _, s := range []DeliveryStatus{
 		DeliverySent, DeliveryDelivered, DeliveryBounced, DeliveryComplained, DeliveryFailed,
-		DeliverySkippedMuted, DeliverySkippedNoContact, DeliveryQuotaExceeded,
+		DeliverySkippedMuted, DeliverySkippedNoContact, DeliveryQuotaExceeded, DeliveryExpired,
+		DeliveryDigested, DeliveryThrottled, DeliveryRecalled,
 	} {
 		if !s.Terminal() {
 			t.Errorf("%q should be terminal", s)
@@ -92,6 +103,17 @@ func TestNotificationStatusOutcome(t *testing.T) {
 		// Its own bucket: the SENDER never asked for in-app. Not a success (it was
 		// never delivered) and not a failure (nothing went wrong).
 		{NotificationStatusNotRequested, OutcomeNotRequested},
+		// Not due yet is not in flight: folding it into pending would make every
+		// send scheduled for next week read as a stalled worker.
+		{NotificationStatusScheduled, OutcomeScheduled},
+		// The sender withdrew it. Deliberate, like a mute — never a failure.
+		{NotificationStatusCancelled, OutcomeSuppressed},
+		// It was delivered; a newer send under the same collapse_key replaced it.
+		{NotificationStatusCollapsed, OutcomeSucceeded},
+		// Turned away by the frequency cap — as deliberate as a mute.
+		{NotificationStatusThrottled, OutcomeSuppressed},
+		// Pulled back by the sender after it went out.
+		{NotificationStatusRecalled, OutcomeSuppressed},
 	}
 
 	for _, tc := range tests {
@@ -112,6 +134,8 @@ func TestOnlyEnqueuedIsNonTerminal(t *testing.T) {
 	for _, s := range []NotificationStatus{
 		NotificationStatusDelivered, NotificationStatusMuted, NotificationStatusFailed,
 		NotificationStatusQuotaExceeded, NotificationStatusNotRequested,
+		NotificationStatusScheduled, NotificationStatusCancelled, NotificationStatusCollapsed,
+		NotificationStatusThrottled, NotificationStatusRecalled,
 	} {
 		if !s.Terminal() {
 			t.Errorf("%q should be terminal", s)
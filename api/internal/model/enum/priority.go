@@ -0,0 +1,23 @@
+package enum
+
+// Priority is the lane a send's background work runs in. Each one is its own
+// Asynq queue, consumed by weight (see task.Queue and job.NewAsynqServer), so a
+// large broadcast cannot sit in front of a password reset. Matches the
+// `notification.priority` and `broadcast.priority` CHECKs.
+type Priority string
+
+const (
+	PriorityCritical Priority = "critical"
+	PriorityNormal   Priority = "normal"
+	PriorityBulk     Priority = "bulk"
+)
+
+// Valid reports whether p is a priority a send can ask for.
+func (p Priority) Valid() bool {
+	switch p {
+	case PriorityCritical, PriorityNormal, PriorityBulk:
+		return true
+	default:
+		return false
+	}
+}
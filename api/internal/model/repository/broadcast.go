@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/mudgallabs/bodhveda/internal/model/dto"
@@ -23,10 +25,26 @@ type BroadcastReader interface {
 	// the remainder of the transaction. The lock, not the read, is the point —
 	// see the implementation.
 	StatusForUpdateTx(ctx context.Context, tx pgx.Tx, broadcastID int) (enum.BroadcastStatus, error)
+
+	// LockTx is StatusForUpdateTx for an operator action, scoped to the project
+	// and taking a lock that does not block the broadcast's own batches.
+	LockTx(ctx context.Context, tx pgx.Tx, projectID, broadcastID int) (enum.BroadcastStatus, error)
+
+	// ContentTx reads a broadcast's current payload and localized variants in
+	// the caller's transaction. It is what a batch fans out, so an edit made
+	// while the broadcast is still sending reaches the batches that have not run
+	// yet.
+	ContentTx(ctx context.Context, tx pgx.Tx, broadcastID int) (json.RawMessage, entity.ContentVariants, error)
+
+	// RecipientListTx reads the ids a list-based broadcast was sent to.
+	RecipientListTx(ctx context.Context, tx pgx.Tx, broadcastID int) ([]string, error)
 }
 
 type BroadcastWriter interface {
 	Create(ctx context.Context, notification *entity.Broadcast) (*entity.Broadcast, error)
+	// CreateWithRecipients creates a list-based broadcast together with its
+	// recipient list, atomically.
+	CreateWithRecipients(ctx context.Context, broadcast *entity.Broadcast, recipientExtIDs []string) (*entity.Broadcast, error)
 	Update(ctx context.Context, notification *entity.Broadcast) error
 
 	// UpdateTx is Update, enrolled in a caller's transaction.
@@ -45,4 +63,32 @@ type BroadcastWriter interface {
 	// not touch the email CONTENT columns — see the implementation.
 	SetEmailOutcomeTx(ctx context.Context, tx pgx.Tx, broadcastID int, eligible int, blockedReason string) error
 	DeleteForProject(ctx context.Context, projectID int) (int, error)
+
+	// CancelScheduled flips a `scheduled` broadcast to `cancelled`. Returns
+	// tantra repository.ErrNotFound when the project has no such broadcast and
+	// ErrConflict when it is no longer scheduled.
+	CancelScheduled(ctx context.Context, projectID, broadcastID int) error
+
+	// CancelTx flips a `scheduled` or `enqueued` broadcast and its unfinished
+	// batches to `cancelled`, returning the number of recipients those batches
+	// held and when the broadcast was billed for them. Returns tantra
+	// repository.ErrNotFound when the project has no such broadcast and
+	// ErrConflict when it has already finished.
+	CancelTx(ctx context.Context, tx pgx.Tx, projectID, broadcastID int) (int64, time.Time, error)
+
+	// Complete marks an `enqueued` broadcast `completed`. A no-op for any other
+	// status, so the last batch cannot overwrite a recall.
+	Complete(ctx context.Context, broadcastID int) error
+
+	// Recall flips a broadcast, its unfinished batches, its notifications and
+	// their still-unsent email deliveries to `recalled`. Returns tantra
+	// repository.ErrNotFound when the project has no such broadcast and
+	// ErrConflict when it is already recalled or was cancelled.
+	Recall(ctx context.Context, projectID, broadcastID int) error
+
+	// Patch replaces the payload of a broadcast and of every delivered
+	// notification it wrote, bumping their versions. Returns tantra
+	// repository.ErrNotFound, ErrConflict when the broadcast is not sending or
+	// sent, and enum.ErrVersionMismatch when patch.Version is stale.
+	Patch(ctx context.Context, patch *dto.PatchBroadcastPayload) error
 }
@@ -33,6 +33,9 @@ type BroadcastBatchReader interface {
 	// ResumableForBroadcastTx returns the batches still awaiting delivery, so a
 	// retry can re-enqueue exactly those.
 	ResumableForBroadcastTx(ctx context.Context, tx pgx.Tx, broadcastID int) ([]*entity.BroadcastBatch, error)
+
+	// ListFailed returns a broadcast's `failed` batches.
+	ListFailed(ctx context.Context, broadcastID int) ([]*entity.BroadcastBatch, error)
 }
 
 type BroadcastBatchWriter interface {
@@ -49,5 +52,9 @@ type BroadcastBatchWriter interface {
 	// can be committed atomically with the work it describes.
 	UpdateTx(ctx context.Context, tx pgx.Tx, batchID int, payload *entity.BroadcastBatchUpdatePayload) error
 
+	// RetryFailedTx re-arms a broadcast's `failed` batches for another round of
+	// delivery and returns them, for the caller to enqueue once it commits.
+	RetryFailedTx(ctx context.Context, tx pgx.Tx, broadcastID int) ([]*entity.BroadcastBatch, error)
+
 	DeleteForProject(ctx context.Context, projectID int) (int, error)
 }
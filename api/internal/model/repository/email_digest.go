@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+)
+
+// EmailDigestRepository is the flush side of email digests. Items go IN through
+// NotificationDeliveryRepository.CreateDigested, on the send path; everything
+// here runs in the worker.
+type EmailDigestRepository interface {
+	// ClaimDue moves up to limit open digests whose due_at has passed to
+	// `sending` and returns their ids. Concurrent callers never claim the same
+	// digest (FOR UPDATE SKIP LOCKED), and a claimed digest stops accepting
+	// items — the next send opens a fresh one.
+	//
+	// A digest left `sending` since before staleBefore is claimed again: its task
+	// was never enqueued, or the worker died first. Re-enqueueing one that is in
+	// fact still queued is harmless, since its task id is fixed.
+	ClaimDue(ctx context.Context, now, staleBefore time.Time, limit int) ([]int64, error)
+	// Get returns a digest by id, or ErrNotFound.
+	Get(ctx context.Context, id int64) (*entity.EmailDigest, error)
+	// ListItems returns the items still waiting to go out in a digest — those
+	// whose delivery is pending or failed — oldest first.
+	ListItems(ctx context.Context, id int64) ([]*entity.EmailDigestItem, error)
+	// ExpireItems marks the given deliveries of a digest `expired`.
+	ExpireItems(ctx context.Context, id int64, deliveryIDs []int64) error
+	// MarkSent records the digest email as accepted by the provider and marks
+	// every waiting delivery in it `digested`, in one transaction.
+	MarkSent(ctx context.Context, id int64, provider, providerMessageID string, attempt int) error
+	// MarkFailed records a failed send attempt on the digest and its waiting
+	// deliveries. A later MarkSent (the task retrying) still completes them.
+	MarkFailed(ctx context.Context, id int64, reason string, attempt int) error
+	// MarkExpired closes a digest that has nothing left to send.
+	MarkExpired(ctx context.Context, id int64) error
+}
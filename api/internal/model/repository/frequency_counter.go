@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// FrequencyCounterRepository counts sends against catalog frequency caps. It is
+// the one store on the send path that is not Postgres: every capped send is a
+// write, the counts are worthless once their window closes, and Redis expires
+// them on its own.
+type FrequencyCounterRepository interface {
+	// Take counts one send against key in the fixed window of length `window`
+	// containing now, and reports whether it fits within limit. A send that does
+	// not fit is still counted — it is rejected either way, and not counting it
+	// would only make the next check cheaper to lose.
+	Take(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (bool, error)
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+)
+
+// IdempotencyKeyRepository is the ledger behind the `Idempotency-Key` header on
+// POST /notifications/send. It has the same claim / release / retention shape as
+// WebhookEventRepository, plus a slot for the response a repeat is answered with.
+type IdempotencyKeyRepository interface {
+	// Claim tries to take (projectID, key) for a new request. It returns the row
+	// and true when this caller now owns the key and should perform the send; it
+	// returns the EXISTING row and false when another request already owns it.
+	//
+	// A row older than expiredBefore does not count as owned — it is re-claimed
+	// in place. A row without a response is owned until then too: there is no
+	// telling whether its send happened. Atomic under concurrent requests
+	// (INSERT ... ON CONFLICT DO UPDATE ... WHERE).
+	Claim(ctx context.Context, projectID int, key, requestHash string, expiredBefore time.Time) (*entity.IdempotencyKey, bool, error)
+	// Complete records the response for a claimed key.
+	Complete(ctx context.Context, id int, response json.RawMessage) error
+	// Release removes a claim whose request failed, so a retry is processed
+	// instead of being mistaken for a duplicate.
+	Release(ctx context.Context, id int) error
+	// DeleteOlderThan removes keys claimed before cutoff (retention cleanup) and
+	// returns how many were deleted.
+	DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error)
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+)
+
+// InboxEventRepository fans feed changes out to every API replica holding a
+// stream for the recipient. It is a bus, not a store: an event published while
+// nobody listens is gone, and a listener that reconnects catches up from
+// Postgres (see NotificationService.ReplayForRecipient), not from here.
+type InboxEventRepository interface {
+	// Publish sends events to their recipients' listeners in one round trip.
+	Publish(ctx context.Context, projectID int, events ...*dto.InboxEvent) error
+	// Subscribe listens for one recipient's events until ctx ends, when the
+	// channel is closed. It returns once the subscription is live, so nothing
+	// published after it returns is missed. The channel is also closed early
+	// when events may have been lost — the listener fell behind, or the bus
+	// reconnected — and the caller should then resume from Postgres.
+	Subscribe(ctx context.Context, projectID int, recipientExtID string) (<-chan *dto.InboxEvent, error)
+	// Listen opens a listener for a project's recipients, following none yet.
+	// It stops when ctx ends, or early as a Subscribe does.
+	Listen(ctx context.Context, projectID int) InboxListener
+}
+
+// InboxListener follows any number of one project's recipients. Listeners
+// share the process's one connection to the bus, so a listener costs the bus
+// nothing but its subscriptions, and two following the same recipient share
+// those too.
+type InboxListener interface {
+	// Follow adds a recipient. Like Subscribe, it returns once nothing
+	// published after it can be missed.
+	Follow(ctx context.Context, recipientExtID string) error
+	// Unfollow drops a recipient. Events already on their way may still
+	// arrive.
+	Unfollow(ctx context.Context, recipientExtID string) error
+	// Events carries every followed recipient's events, RecipientExtID set. It
+	// is closed when the listener stops.
+	Events() <-chan *dto.InboxEvent
+}
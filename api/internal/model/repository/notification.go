@@ -22,8 +22,19 @@ type NotificationReader interface {
 	// Returns tantra repository.ErrNotFound when no such row exists in the project.
 	Get(ctx context.Context, projectID, id int) (*entity.Notification, error)
 	Overview(ctx context.Context, projectID int) (*dto.NotificationsOverviewResult, error)
-	ListForRecipient(ctx context.Context, projectID int, recipientExtID string, cursor *query.Cursor) ([]*entity.Notification, *query.Cursor, error)
-	UnreadCountForRecipient(ctx context.Context, projectID int, recipientExtID string) (int, error)
+	// ListForRecipient is the recipient's feed, newest first. Nil filters are
+	// the whole feed; filters.GroupBy is ignored here.
+	ListForRecipient(ctx context.Context, projectID int, recipientExtID string, filters *dto.RecipientFeedFilters, cursor *query.Cursor) ([]*entity.Notification, *query.Cursor, error)
+	// ListGroupsForRecipient is the feed grouped by filters.GroupBy, in the
+	// feed's order of each group's newest notification. The cursor pages on
+	// that newest id.
+	ListGroupsForRecipient(ctx context.Context, projectID int, recipientExtID string, filters *dto.RecipientFeedFilters, cursor *query.Cursor) ([]*entity.NotificationGroup, *query.Cursor, error)
+	// UnreadCountForRecipient counts the inbox, or the archive when archived is
+	// set, exactly as ListForRecipient shows them.
+	UnreadCountForRecipient(ctx context.Context, projectID int, recipientExtID string, archived bool) (int, error)
+	// UnreadCountsByChannelForRecipient is UnreadCountForRecipient broken down
+	// by channel. Channels with nothing unread are absent.
+	UnreadCountsByChannelForRecipient(ctx context.Context, projectID int, recipientExtID string, archived bool) (map[string]int, error)
 	ListNotifications(ctx context.Context, filters *dto.ListNotificationsFilters) ([]*entity.Notification, int, error)
 	// InAppAnalyticsSeries returns per-day in-app notification counts over a date
 	// range, bucketed by day in the viewer's timezone `tz` (Phase 9.5).
@@ -36,6 +47,15 @@ type NotificationReader interface {
 	// project-scoped: the caller must verify ownership first (see the
 	// implementation for why that is the right place for it).
 	StatusRollupForBroadcast(ctx context.Context, broadcastID int) (map[enum.NotificationStatus]int, error)
+	// ListForBroadcast pages a broadcast's notifications in one status, in id
+	// order after afterID. Not project-scoped, like StatusRollupForBroadcast.
+	ListForBroadcast(ctx context.Context, broadcastID int, status enum.NotificationStatus, afterID, limit int) ([]*entity.Notification, error)
+	// ListExpiredBetween pages the delivered notifications ACROSS ALL PROJECTS
+	// whose expires_at is in (from, to], in id order after afterID.
+	ListExpiredBetween(ctx context.Context, from, to time.Time, afterID, limit int) ([]*entity.Notification, error)
+	// ListWokenBetween pages the inbox notifications ACROSS ALL PROJECTS whose
+	// snooze ended in (from, to], in id order after afterID.
+	ListWokenBetween(ctx context.Context, from, to time.Time, afterID, limit int) ([]*entity.Notification, error)
 	// CountStuck counts notifications ACROSS ALL PROJECTS that were created before
 	// `olderThan` but after `newerThan`, and are still in the only non-terminal
 	// status (`enqueued`).
@@ -52,8 +72,35 @@ type NotificationReader interface {
 type NotificationWriter interface {
 	Create(ctx context.Context, notification *entity.Notification) (*entity.Notification, error)
 	BatchCreateTx(ctx context.Context, tx pgx.Tx, notifications []*entity.Notification) error
+	BatchCreate(ctx context.Context, notifications []*entity.Notification) error
 	Update(ctx context.Context, notification *entity.Notification) error
-	UpdateForRecipient(ctx context.Context, projectID int, recipientExtID string, payload dto.UpdateRecipientNotificationsPayload) (int, error)
+	// UpdateCollapsing is Update for a delivered notification carrying a
+	// collapse_key: in the same transaction it collapses the recipient's older
+	// unread notifications under that key, or collapses this one when a newer
+	// one has already been delivered. Sets notification.Status to what was written,
+	// and returns the ids of the older notifications it took out of the feed.
+	UpdateCollapsing(ctx context.Context, notification *entity.Notification) ([]int, error)
+	// UpdateForRecipient applies the payload's state in one UPDATE and returns
+	// the ids it changed.
+	UpdateForRecipient(ctx context.Context, projectID int, recipientExtID string, payload dto.UpdateRecipientNotificationsPayload) ([]int, error)
 	DeleteForRecipient(ctx context.Context, projectID int, recipientExtID string, notificationIDs []int) (int, error)
 	DeleteForProject(ctx context.Context, projectID int) (int, error)
+	// DeleteExpired purges up to limit notifications whose expires_at is before
+	// cutoff. Cross-project: it is the worker's housekeeping, not a tenant action.
+	DeleteExpired(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+
+	// ReleaseScheduled moves a due `scheduled` notification on to `next` and
+	// returns the row's status afterwards — `cancelled` if the cancel won.
+	ReleaseScheduled(ctx context.Context, projectID, id int, next enum.NotificationStatus, completedAt *time.Time) (enum.NotificationStatus, error)
+	// CancelScheduled flips a `scheduled` notification to `cancelled`. Returns
+	// tantra repository.ErrNotFound / ErrConflict (no longer scheduled).
+	CancelScheduled(ctx context.Context, projectID, id int) (*entity.Notification, error)
+	// Recall flips a sent notification to `recalled` and its still-unsent email
+	// deliveries with it. Returns tantra repository.ErrNotFound / ErrConflict
+	// (already recalled, or cancelled).
+	Recall(ctx context.Context, projectID, id int) (*entity.Notification, error)
+	// Patch replaces a delivered notification's payload and bumps its version.
+	// Returns tantra repository.ErrNotFound, ErrConflict when the notification is
+	// not `delivered`, and enum.ErrVersionMismatch when patch.Version is stale.
+	Patch(ctx context.Context, patch *dto.PatchNotificationPayload) (*entity.Notification, error)
 }
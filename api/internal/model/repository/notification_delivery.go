@@ -38,6 +38,13 @@ type NotificationDeliveryReader interface {
 	// (Phase 6). Scoping by projectID keeps one project's webhook from resolving
 	// another project's delivery row. Returns ErrNotFound when no row matches.
 	GetTargetByProviderMessageID(ctx context.Context, projectID int, providerMessageID string) (*DeliveryTarget, error)
+	// LatestComplaint returns the recipient's most recent `complained` email
+	// delivery for target — the spam report that flipped their email preference
+	// for it off. Returns ErrNotFound when there has been none.
+	LatestComplaint(ctx context.Context, projectID int, recipientExtID string, target dto.Target) (*entity.NotificationDelivery, error)
+	// Recalled reports whether a delivery, or the notification it belongs to, has
+	// been recalled. The email:delivery task asks right before it sends.
+	Recalled(ctx context.Context, id int64) (bool, error)
 }
 
 // DeliveryTarget is the recipient + target a delivery row belongs to, resolved
@@ -53,6 +60,11 @@ type DeliveryTarget struct {
 type NotificationDeliveryWriter interface {
 	// Create inserts a delivery row (status already resolved by the caller).
 	Create(ctx context.Context, delivery *entity.NotificationDelivery) (*entity.NotificationDelivery, error)
+	// CreateDigested inserts a pending delivery attached to the recipient's open
+	// email digest for window (opening one if needed), along with the rendered
+	// item the digest will carry for it. Returns ErrConflict when the
+	// notification already has an email delivery.
+	CreateDigested(ctx context.Context, delivery *entity.NotificationDelivery, window enum.DigestWindow, item *entity.EmailDigestItem) (*entity.NotificationDelivery, error)
 
 	// BatchCreateTx inserts many delivery rows in the caller's transaction and
 	// back-fills their IDs, matched by recipient external id.
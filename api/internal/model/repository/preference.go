@@ -22,6 +22,14 @@ type PreferenceReader interface {
 	// whether the matched entry is mandatory. It is the strict-target gate's
 	// primitive — see the implementation for why exact match would break Grahak.
 	LookupCatalogEntry(ctx context.Context, projectID int, target dto.Target, medium enum.Medium) (exists bool, mandatory bool, err error)
+	// LookupCatalogEmail reports the email sending options (digest window, quiet
+	// hours bypass) of the email catalog entry the target resolves to, with the
+	// same matching as LookupCatalogEntry. No entry yields the zero value.
+	LookupCatalogEmail(ctx context.Context, projectID int, target dto.Target) (entity.CatalogEmailOptions, error)
+	// LookupCatalogFrequencyCap returns the frequency cap of the catalog entry a
+	// (target, medium) resolves to, matched like LookupCatalogEntry. Nil when the
+	// entry is uncapped or the target is not cataloged.
+	LookupCatalogFrequencyCap(ctx context.Context, projectID int, target dto.Target, medium enum.Medium) (*entity.FrequencyCap, error)
 	// ListUncatalogedSentTargets reports the (target, medium) pairs the project
 	// has sent since `since` but never cataloged — i.e. exactly what the strict-
 	// target gate would reject. It resolves the catalog with the same predicate
@@ -35,21 +43,33 @@ type PreferenceReader interface {
 	// catalog surface.
 	GetProjectPreferenceByID(ctx context.Context, projectID int, preferenceID int) (*entity.Preference, error)
 	ShouldDirectNotificationBeDelivered(ctx context.Context, projectID int, recipientExtID string, target dto.Target, medium enum.Medium) (bool, error)
-	ListEligibleRecipientExtIDsForBroadcast(ctx context.Context, projectID int, target dto.Target, medium enum.Medium) ([]string, error)
+	ListEligibleRecipientExtIDsForBroadcast(ctx context.Context, projectID int, target dto.Target, medium enum.Medium, segment *entity.AudienceSegment) ([]string, error)
 
 	// FilterEligibleRecipientsForBroadcast narrows a KNOWN candidate set to those
 	// eligible on `medium`. Broadcast email resolves eligibility per batch, and
 	// the project-wide list can dwarf the batch — see the implementation.
 	FilterEligibleRecipientsForBroadcast(ctx context.Context, projectID int, target dto.Target, medium enum.Medium, recipientExtIDs []string) ([]string, error)
 	// CountBroadcastAudience returns the recipient breakdown for a target: total,
-	// eligible, and the two DIFFERENT reasons a recipient is excluded (their own
-	// opt-out vs the project never cataloging the target).
+	// eligible, and the DIFFERENT reasons a recipient is excluded (their own
+	// opt-out, the project never cataloging the target, or the broadcast's
+	// segment leaving them out).
 	//
 	// ⚠️ Its eligibility predicate MUST stay identical to
 	// ListEligibleRecipientExtIDsForBroadcast's — they are two views of one rule,
 	// and a drift between them shows up as a tree whose numbers do not add up.
 	// pg keeps them adjacent and cross-checks them in a test for that reason.
-	CountBroadcastAudience(ctx context.Context, projectID int, target dto.Target, medium enum.Medium) (*entity.BroadcastAudience, error)
+	CountBroadcastAudience(ctx context.Context, projectID int, target dto.Target, medium enum.Medium, segment *entity.AudienceSegment) (*entity.BroadcastAudience, error)
+	// CountBroadcastAudienceAmong is CountBroadcastAudience restricted to the
+	// given recipients, for a list-based broadcast. Same predicate, same caveat.
+	CountBroadcastAudienceAmong(ctx context.Context, projectID int, target dto.Target, medium enum.Medium, recipientExtIDs []string) (*entity.BroadcastAudience, error)
+	// CountBroadcastFanOut counts the recipients eligible on ANY of mediums —
+	// what a broadcast sent on those mediums would write — and returns the first
+	// sampleSize of them by external id. Same predicate as
+	// ListEligibleRecipientExtIDsForBroadcast, without materializing the list.
+	CountBroadcastFanOut(ctx context.Context, projectID int, target dto.Target, mediums []enum.Medium, segment *entity.AudienceSegment, sampleSize int) (int, []string, error)
+	// CountBroadcastFanOutAmong is CountBroadcastFanOut restricted to the given
+	// recipients, for a list-based broadcast.
+	CountBroadcastFanOutAmong(ctx context.Context, projectID int, target dto.Target, mediums []enum.Medium, recipientExtIDs []string, sampleSize int) (int, []string, error)
 	// ResolveRecipientPreferences answers every known (target, medium) for one
 	// recipient with the SAME cascade ShouldDirectNotificationBeDelivered uses,
 	// in one query. Callers pass the mediums to resolve (see enum.ActiveMediums).
@@ -58,6 +78,11 @@ type PreferenceReader interface {
 	// the targets given, including ones nothing is stored about — which is why a
 	// single-target check cannot just filter ResolveRecipientPreferences.
 	ResolveRecipientPreferenceForTargets(ctx context.Context, projectID int, recipientExtID string, mediums []enum.Medium, targets []dto.Target) ([]*entity.ResolvedPreference, error)
+	// TracePreference returns the four stored rungs of that cascade for one
+	// (recipient, target, medium), in cascade order, whether or not each matched.
+	// It does not resolve them; the default and the mandatory override are the
+	// caller's to apply, from ResolveRecipientPreferenceForTargets.
+	TracePreference(ctx context.Context, projectID int, recipientExtID string, target dto.Target, medium enum.Medium) ([]*entity.PreferenceRung, error)
 }
 
 type PreferenceWriter interface {
@@ -65,8 +90,11 @@ type PreferenceWriter interface {
 	// UpdateProjectPreference updates a catalog entry's mutable fields (name,
 	// description and the project-level default). Scoped to project-level rows
 	// (recipient NULL) and to the project; returns tantra's ErrNotFound when no
-	// such row exists. A nil description clears the entry's description.
-	UpdateProjectPreference(ctx context.Context, projectID int, preferenceID int, name string, description *string, enabled bool, mandatory *bool) (*entity.Preference, error)
+	// such row exists. A nil description clears the entry's description; a nil
+	// mandatory, digest, bypassQuietHours or frequencyCap is left as it is,
+	// enum.DigestOff clears the digest and a cap with window enum.CapOff removes
+	// the cap.
+	UpdateProjectPreference(ctx context.Context, projectID int, preferenceID int, name string, description *string, enabled bool, mandatory *bool, digest *enum.DigestWindow, bypassQuietHours *bool, frequencyCap *entity.FrequencyCap) (*entity.Preference, error)
 	// UpsertProjectPreferences declaratively merges a set of catalog entries in a
 	// single transaction: each is upserted by its natural key (channel, topic,
 	// event, medium) — inserted if new, its name + description + default updated if
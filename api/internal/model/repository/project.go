@@ -21,8 +21,9 @@ type ProjectReader interface {
 
 type ProjectWriter interface {
 	Create(ctx context.Context, project *entity.Project) (*entity.Project, error)
-	// Update is partial: a nil strictTargets keeps the stored value.
-	Update(ctx context.Context, userID, projectID int, name string, strictTargets *bool) (*entity.Project, error)
+	// Update is partial: a nil strictTargets or requireRecipientToken keeps the
+	// stored value.
+	Update(ctx context.Context, userID, projectID int, name string, strictTargets, requireRecipientToken *bool) (*entity.Project, error)
 	SoftDelete(ctx context.Context, userID, projectID int) error
 	Delete(ctx context.Context, projectID int) error
 }
@@ -14,16 +14,24 @@ type RecipientRepository interface {
 }
 
 type RecipientReader interface {
-	List(ctx context.Context, projectID int, pagination query.Pagination) ([]*entity.RecipientListItem, int, error)
+	List(ctx context.Context, payload *dto.ListRecipientsPayload) ([]*entity.RecipientListItem, int, error)
 	Get(ctx context.Context, projectID int, externalID string) (*entity.Recipient, error)
 	GetListItem(ctx context.Context, projectID int, externalID string) (*entity.RecipientListItem, error)
 	Exists(ctx context.Context, projectID int, externalID string) (bool, error)
 	TotalCount(ctx context.Context, projectID int) (int, error)
+	// Locales maps each of the given recipients that has a locale to it.
+	// Recipients without one are left out.
+	Locales(ctx context.Context, projectID int, externalIDs []string) (map[string]string, error)
+	// Missing returns the given ids that have no recipient in the project.
+	Missing(ctx context.Context, projectID int, externalIDs []string) ([]string, error)
 }
 
 type RecipientWriter interface {
 	Create(ctx context.Context, recipient *entity.Recipient) (*entity.Recipient, error)
 	BatchCreate(ctx context.Context, recipients []*entity.Recipient) (created []string, updated []string, err error)
+	// CreateMissing creates a bare recipient for each given id that has none and
+	// returns the ids it created. Existing recipients are not touched.
+	CreateMissing(ctx context.Context, projectID int, externalIDs []string) ([]string, error)
 	Update(ctx context.Context, projectID int, externalID string, payload *dto.UpdateRecipientPayload) (*entity.Recipient, error)
 	SoftDelete(ctx context.Context, projectID int, externalID string) error
 	Delete(ctx context.Context, projectID int, externalID string) error
@@ -33,6 +41,10 @@ type RecipientWriter interface {
 type RecipientSearchFilter struct {
 	ProjectID  *int    `json:"project_id"`
 	ExternalID *string `json:"external_id"`
+	// Tags and Attributes are ANDed: a recipient must carry every tag and match
+	// every attribute filter.
+	Tags       []string                       `json:"tags"`
+	Attributes []dto.RecipientAttributeFilter `json:"attributes"`
 }
 
 type SearchRecipientPayload = query.SearchPayload[RecipientSearchFilter]
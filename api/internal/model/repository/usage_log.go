@@ -17,4 +17,6 @@ type UsageLogRepositoryReader interface{}
 
 type UsageLogRepositoryWriter interface {
 	Add(ctx context.Context, tx pgx.Tx, projectID int, metric entity.Metric, amount int64, periodStart, periodEnd time.Time) error
+	// Refund gives back usage consumed at usedAt, in the period that covered it.
+	Refund(ctx context.Context, tx pgx.Tx, projectID int, metric entity.Metric, amount int64, usedAt time.Time) error
 }
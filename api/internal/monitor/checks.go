@@ -3,16 +3,12 @@ package monitor
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hibiken/asynq"
 )
 
-// The queue the whole app enqueues to. Asynq is configured with no Queues map
-// (internal/job/asynq.go), so every task type — notification delivery, email,
-// broadcast fan-out, cascading deletes — shares this one queue.
-const defaultQueue = "default"
-
 // Thresholds. Deliberately loose: this monitor exists to catch an outage that
 // went unnoticed for a day, not to page on jitter. Every one of these is
 // tuned to fire on the two failure modes described in
@@ -57,9 +53,34 @@ type Check struct {
 // to an interface so the checks are testable without a Redis.
 type queueInspector interface {
 	Servers() ([]*asynq.ServerInfo, error)
+	Queues() ([]string, error)
 	GetQueueInfo(queue string) (*asynq.QueueInfo, error)
 }
 
+// queueInfos reads every queue in Redis — one per priority lane (see
+// internal/job/task/queue.go), plus any a future change adds. Listing them
+// rather than naming them here means a new lane is watched from its first task.
+//
+// Asynq creates a queue on its first enqueue, so a lane nothing has been sent
+// on yet is simply absent. That is correct, not a gap: it has nothing to report.
+func queueInfos(inspector queueInspector) ([]*asynq.QueueInfo, error) {
+	queues, err := inspector.Queues()
+	if err != nil {
+		return nil, fmt.Errorf("list asynq queues: %w", err)
+	}
+
+	infos := make([]*asynq.QueueInfo, 0, len(queues))
+	for _, queue := range queues {
+		info, err := inspector.GetQueueInfo(queue)
+		if err != nil {
+			return nil, fmt.Errorf("inspect queue %q: %w", queue, err)
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, nil
+}
+
 // stuckCounter is the slice of the notification repository this package needs.
 type stuckCounter interface {
 	CountStuck(ctx context.Context, olderThan, newerThan time.Time) (int, error)
@@ -132,36 +153,51 @@ func checkWorkerAbsent(inspector queueInspector) (Finding, error) {
 
 	return Finding{
 		Firing:  true,
-		Summary: "No Asynq worker is registered — nothing is consuming the queue.",
+		Summary: "No Asynq worker is registered — nothing is consuming the queues.",
 		Fields: map[string]string{
-			"queue": defaultQueue,
-			"hint":  "check the worker container/process",
+			"hint": "check the worker container/process",
 		},
 	}, nil
 }
 
-// checkQueueLatency fires when the oldest pending task is older than
-// queueLatencyThreshold. QueueInfo.Latency is exactly that age, and it is 0 on
-// an empty queue — so a healthy idle system never trips this.
+// checkQueueLatency fires when the oldest pending task in any queue is older
+// than queueLatencyThreshold. QueueInfo.Latency is exactly that age, and it is 0
+// on an empty queue — so a healthy idle system never trips this.
+//
+// Per queue, not in total: the bulk lane backing up behind a big broadcast is
+// worth knowing about even while the critical lane drains fine, and the reverse
+// is an outage.
 func checkQueueLatency(inspector queueInspector) (Finding, error) {
-	info, err := inspector.GetQueueInfo(defaultQueue)
+	infos, err := queueInfos(inspector)
 	if err != nil {
-		return Finding{}, fmt.Errorf("inspect queue %q: %w", defaultQueue, err)
+		return Finding{}, err
 	}
 
-	if info.Latency <= queueLatencyThreshold {
+	var worst *asynq.QueueInfo
+	var backedUp []string
+	for _, info := range infos {
+		if info.Latency <= queueLatencyThreshold {
+			continue
+		}
+		backedUp = append(backedUp, info.Queue)
+		if worst == nil || info.Latency > worst.Latency {
+			worst = info
+		}
+	}
+
+	if worst == nil {
 		return Finding{}, nil
 	}
 
 	return Finding{
 		Firing: true,
-		Summary: fmt.Sprintf("Queue is backed up — oldest pending task is %s old (threshold %s).",
-			info.Latency.Round(time.Second), queueLatencyThreshold),
+		Summary: fmt.Sprintf("Queue %q is backed up — oldest pending task is %s old (threshold %s).",
+			worst.Queue, worst.Latency.Round(time.Second), queueLatencyThreshold),
 		Fields: map[string]string{
-			"queue":   defaultQueue,
-			"pending": fmt.Sprintf("%d", info.Pending),
-			"active":  fmt.Sprintf("%d", info.Active),
-			"retry":   fmt.Sprintf("%d", info.Retry),
+			"queue":   strings.Join(backedUp, ", "),
+			"pending": fmt.Sprintf("%d", worst.Pending),
+			"active":  fmt.Sprintf("%d", worst.Active),
+			"retry":   fmt.Sprintf("%d", worst.Retry),
 		},
 	}, nil
 }
@@ -174,29 +210,47 @@ func checkQueueLatency(inspector queueInspector) (Finding, error) {
 // Processed/Failed are asynq's DAILY counters and reset at midnight, so this
 // reports "today", not a rolling window. Good enough: an incident this severe is
 // visible within one tick of starting.
+//
+// Judged per queue, because each lane carries different work: a provider outage
+// fails the email tasks in every lane, but a bad broadcast fails only bulk, and
+// pooling the counters would let a busy healthy lane hide it.
 func checkFailureRatio(inspector queueInspector) (Finding, error) {
-	info, err := inspector.GetQueueInfo(defaultQueue)
+	infos, err := queueInfos(inspector)
 	if err != nil {
-		return Finding{}, fmt.Errorf("inspect queue %q: %w", defaultQueue, err)
+		return Finding{}, err
 	}
 
-	if info.Processed < failureRatioMinProcessed {
-		return Finding{}, nil
+	var worst *asynq.QueueInfo
+	var worstRatio float64
+	var failing []string
+	for _, info := range infos {
+		if info.Processed < failureRatioMinProcessed {
+			continue
+		}
+
+		ratio := float64(info.Failed) / float64(info.Processed)
+		if ratio <= failureRatioThreshold {
+			continue
+		}
+
+		failing = append(failing, info.Queue)
+		if worst == nil || ratio > worstRatio {
+			worst, worstRatio = info, ratio
+		}
 	}
 
-	ratio := float64(info.Failed) / float64(info.Processed)
-	if ratio <= failureRatioThreshold {
+	if worst == nil {
 		return Finding{}, nil
 	}
 
 	return Finding{
 		Firing: true,
-		Summary: fmt.Sprintf("%d of %d tasks failed today (%.0f%%, threshold %.0f%%).",
-			info.Failed, info.Processed, ratio*100, failureRatioThreshold*100),
+		Summary: fmt.Sprintf("%d of %d tasks in queue %q failed today (%.0f%%, threshold %.0f%%).",
+			worst.Failed, worst.Processed, worst.Queue, worstRatio*100, failureRatioThreshold*100),
 		Fields: map[string]string{
-			"queue":    defaultQueue,
-			"archived": fmt.Sprintf("%d", info.Archived),
-			"retry":    fmt.Sprintf("%d", info.Retry),
+			"queue":    strings.Join(failing, ", "),
+			"archived": fmt.Sprintf("%d", worst.Archived),
+			"retry":    fmt.Sprintf("%d", worst.Retry),
 		},
 	}, nil
 }
@@ -216,31 +270,45 @@ func checkFailureRatio(inspector queueInspector) (Finding, error) {
 // further growth — so a burst produces "Firing" and then, once archiving stops,
 // "Resolved". That reads correctly: the resolve genuinely means "nothing more is
 // being dropped". A sustained incident keeps growing and stays open.
+//
+// The baseline is kept per queue. A queue seen for the first time — a lane's
+// first task — is primed like a restart, not compared against zero.
 type archivedGrowthCheck struct {
-	last   int
-	primed bool
+	last map[string]int
 }
 
 func (c *archivedGrowthCheck) run(inspector queueInspector) (Finding, error) {
-	info, err := inspector.GetQueueInfo(defaultQueue)
+	infos, err := queueInfos(inspector)
 	if err != nil {
-		return Finding{}, fmt.Errorf("inspect queue %q: %w", defaultQueue, err)
+		return Finding{}, err
 	}
 
-	prev := c.last
-	c.last = info.Archived
-
-	// First observation establishes the baseline. Without this, a restart would
-	// alert on the entire pre-existing archived backlog as if it just happened.
-	if !c.primed {
-		c.primed = true
-		return Finding{}, nil
+	if c.last == nil {
+		c.last = make(map[string]int, len(infos))
 	}
 
-	growth := info.Archived - prev
-	if growth <= 0 {
+	var growth, total int
+	var grew []string
+	for _, info := range infos {
+		prev, primed := c.last[info.Queue]
+		c.last[info.Queue] = info.Archived
+		total += info.Archived
+
+		// First observation establishes the baseline. Without this, a restart would
+		// alert on the entire pre-existing archived backlog as if it just happened.
+		if !primed {
+			continue
+		}
+
 		// Not an error when negative: asynq prunes archived tasks, and they can be
 		// deleted from asynqmon. Re-baselining above already handled it.
+		if delta := info.Archived - prev; delta > 0 {
+			growth += delta
+			grew = append(grew, info.Queue)
+		}
+	}
+
+	if growth == 0 {
 		return Finding{}, nil
 	}
 
@@ -249,8 +317,8 @@ func (c *archivedGrowthCheck) run(inspector queueInspector) (Finding, error) {
 		Summary: fmt.Sprintf("%d task(s) were archived since the last check — they exhausted retries and will never run.",
 			growth),
 		Fields: map[string]string{
-			"queue":          defaultQueue,
-			"archived_total": fmt.Sprintf("%d", info.Archived),
+			"queue":          strings.Join(grew, ", "),
+			"archived_total": fmt.Sprintf("%d", total),
 			"hint":           "inspect with asynqmon; these tasks are lost unless re-run",
 		},
 	}, nil
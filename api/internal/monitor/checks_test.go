@@ -10,11 +10,15 @@ import (
 )
 
 // fakeInspector stands in for *asynq.Inspector so the checks are testable
-// without a Redis.
+// without a Redis. With no queues set it reports just `default`, and info
+// answers for any queue not in infos.
 type fakeInspector struct {
 	servers    []*asynq.ServerInfo
+	queues     []string
 	info       *asynq.QueueInfo
+	infos      map[string]*asynq.QueueInfo
 	serversErr error
+	queuesErr  error
 	infoErr    error
 }
 
@@ -22,14 +26,32 @@ func (f *fakeInspector) Servers() ([]*asynq.ServerInfo, error) {
 	return f.servers, f.serversErr
 }
 
-func (f *fakeInspector) GetQueueInfo(string) (*asynq.QueueInfo, error) {
+func (f *fakeInspector) Queues() ([]string, error) {
+	if f.queuesErr != nil {
+		return nil, f.queuesErr
+	}
+	if f.queues == nil {
+		return []string{"default"}, nil
+	}
+	return f.queues, nil
+}
+
+func (f *fakeInspector) GetQueueInfo(queue string) (*asynq.QueueInfo, error) {
 	if f.infoErr != nil {
 		return nil, f.infoErr
 	}
-	if f.info == nil {
-		return &asynq.QueueInfo{}, nil
+
+	info, ok := f.infos[queue]
+	if !ok {
+		info = f.info
+	}
+	if info == nil {
+		info = &asynq.QueueInfo{}
 	}
-	return f.info, nil
+
+	named := *info
+	named.Queue = queue
+	return &named, nil
 }
 
 type fakeStuckCounter struct {
@@ -224,6 +246,99 @@ func TestArchivedGrowthCheck(t *testing.T) {
 	})
 }
 
+// Every queue is watched, not just `default`: a backed-up or failing lane must
+// fire even while the others are healthy, and the finding must name it.
+func TestQueueChecksCoverEveryQueue(t *testing.T) {
+	lanes := []string{"critical", "default", "bulk"}
+
+	t.Run("latency in one lane", func(t *testing.T) {
+		f, err := checkQueueLatency(&fakeInspector{queues: lanes, infos: map[string]*asynq.QueueInfo{
+			"bulk": {Latency: queueLatencyThreshold + time.Minute},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !f.Firing {
+			t.Fatal("a backed-up bulk queue should fire")
+		}
+		if got := f.Fields["queue"]; got != "bulk" {
+			t.Errorf("queue field = %q, want %q", got, "bulk")
+		}
+	})
+
+	t.Run("failure ratio in one lane", func(t *testing.T) {
+		// The busy healthy lane must not dilute the failing one.
+		f, err := checkFailureRatio(&fakeInspector{queues: lanes, infos: map[string]*asynq.QueueInfo{
+			"default":  {Processed: 10000, Failed: 0},
+			"critical": {Processed: 40, Failed: 30},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !f.Firing {
+			t.Fatal("a failing critical queue should fire")
+		}
+		if got := f.Fields["queue"]; got != "critical" {
+			t.Errorf("queue field = %q, want %q", got, "critical")
+		}
+	})
+
+	t.Run("archived growth per lane", func(t *testing.T) {
+		c := &archivedGrowthCheck{}
+		insp := &fakeInspector{queues: lanes, infos: map[string]*asynq.QueueInfo{
+			"default": {Archived: 50},
+			"bulk":    {Archived: 3},
+		}}
+		if _, err := c.run(insp); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// default shrinks by more than bulk grows: a pooled count would miss it.
+		insp.infos = map[string]*asynq.QueueInfo{
+			"default": {Archived: 40},
+			"bulk":    {Archived: 5},
+		}
+		f, err := c.run(insp)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !f.Firing {
+			t.Fatal("growth in the bulk queue should fire")
+		}
+		if got := f.Fields["queue"]; got != "bulk" {
+			t.Errorf("queue field = %q, want %q", got, "bulk")
+		}
+	})
+
+	t.Run("a queue seen for the first time is only a baseline", func(t *testing.T) {
+		c := &archivedGrowthCheck{}
+		insp := &fakeInspector{queues: []string{"default"}}
+		if _, err := c.run(insp); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		insp.queues = lanes
+		insp.infos = map[string]*asynq.QueueInfo{"critical": {Archived: 9}}
+		f, err := c.run(insp)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if f.Firing {
+			t.Fatal("a new queue's existing archive must not fire as growth")
+		}
+	})
+
+	t.Run("propagates a queue listing error", func(t *testing.T) {
+		f, err := checkQueueLatency(&fakeInspector{queuesErr: errors.New("redis down")})
+		if err == nil {
+			t.Fatal("expected the inspector error to propagate")
+		}
+		if f.Firing {
+			t.Fatal("an errored check must not report as firing")
+		}
+	})
+}
+
 func TestCheckStuckSends(t *testing.T) {
 	now := func() time.Time { return time.Date(2026, 7, 29, 12, 0, 0, 0, time.UTC) }
 
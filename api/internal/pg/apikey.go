@@ -87,10 +87,15 @@ func (r *APIKeyRepo) List(ctx context.Context, userID, projectID int) ([]*entity
 }
 
 func (r *APIKeyRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*entity.APIKey, error) {
+	// The project join rides along with the lookup every authenticated request
+	// already makes. It also means a key whose project is gone no longer
+	// authenticates.
 	sql := `
-		SELECT id, name, token, nonce, token_hash, scope, project_id, user_id, created_at, updated_at
-		FROM api_key
-		WHERE token_hash = $1
+		SELECT k.id, k.name, k.token, k.nonce, k.token_hash, k.scope, k.project_id, k.user_id, k.created_at, k.updated_at,
+		       p.require_recipient_token
+		FROM api_key k
+		JOIN project p ON p.id = k.project_id
+		WHERE k.token_hash = $1
 	`
 	row := r.db.QueryRow(ctx, sql, tokenHash)
 
@@ -106,6 +111,7 @@ func (r *APIKeyRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*ent
 		&apiKey.UserID,
 		&apiKey.CreatedAt,
 		&apiKey.UpdatedAt,
+		&apiKey.ProjectRequiresRecipientToken,
 	)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,127 @@
+package pg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+)
+
+// segmentSQL compiles a broadcast's audience segment into a predicate over the
+// recipient alias `r`. Values are bound as parameters appended to args, numbered
+// after whatever args already holds, so the predicate can be spliced into a query
+// whose own parameters come first.
+//
+// A nil segment is TRUE. Every condition it can produce is over NOT NULL columns
+// or an EXISTS, so the predicate is never NULL and `NOT (...)` is a safe way to
+// count who it leaves out.
+func segmentSQL(segment *entity.AudienceSegment, args *[]any) string {
+	if segment == nil {
+		return "TRUE"
+	}
+
+	bind := func(v any) string {
+		*args = append(*args, v)
+		return fmt.Sprintf("$%d", len(*args))
+	}
+
+	var conds []string
+
+	if segment.CreatedAfter != nil {
+		conds = append(conds, "r.created_at >= "+bind(*segment.CreatedAfter))
+	}
+	if segment.CreatedBefore != nil {
+		conds = append(conds, "r.created_at < "+bind(*segment.CreatedBefore))
+	}
+
+	// Primary contacts only: the primary is the address a send actually uses,
+	// so "has an email" means "a send could email them".
+	const primaryContact = `EXISTS (
+			SELECT 1 FROM recipient_contact rc
+			WHERE rc.project_id = r.project_id AND rc.recipient_external_id = r.external_id
+				AND rc.medium = %s AND rc.is_primary
+		)`
+	if segment.HasContact != nil {
+		conds = append(conds, fmt.Sprintf(primaryContact, bind(string(*segment.HasContact))))
+	}
+	if segment.LacksContact != nil {
+		conds = append(conds, "NOT "+fmt.Sprintf(primaryContact, bind(string(*segment.LacksContact))))
+	}
+
+	if segment.ReadWithinDays != nil {
+		conds = append(conds, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM notification rn
+			WHERE rn.project_id = r.project_id AND rn.recipient_external_id = r.external_id
+				AND rn.read_at IS NOT NULL AND rn.read_at >= now() - make_interval(days => %s::int)
+		)`, bind(*segment.ReadWithinDays)))
+	}
+
+	if segment.ExternalIDPrefix != nil {
+		conds = append(conds, "r.external_id LIKE "+bind(likePrefix(*segment.ExternalIDPrefix)))
+	}
+
+	if segment.Tag != nil {
+		conds = append(conds, "r.tags @> ARRAY["+bind(*segment.Tag)+"::text]")
+	}
+
+	if segment.Attribute != nil {
+		key, value, _ := strings.Cut(*segment.Attribute, ":")
+		matches := dto.RecipientAttributeFilter{Key: key, Value: value}.Matches()
+		ors := make([]string, len(matches))
+		for i, m := range matches {
+			ors[i] = "r.attributes @> " + bind([]byte(m)) + "::jsonb"
+		}
+		conds = append(conds, "("+strings.Join(ors, " OR ")+")")
+	}
+
+	for i := range segment.All {
+		conds = append(conds, segmentSQL(&segment.All[i], args))
+	}
+
+	if len(segment.Any) > 0 {
+		ors := make([]string, len(segment.Any))
+		for i := range segment.Any {
+			ors[i] = segmentSQL(&segment.Any[i], args)
+		}
+		conds = append(conds, "("+strings.Join(ors, " OR ")+")")
+	}
+
+	if len(conds) == 0 {
+		return "TRUE"
+	}
+	return "(" + strings.Join(conds, " AND ") + ")"
+}
+
+// likePrefix escapes LIKE's wildcards in a prefix so "user_" matches those five
+// characters rather than "user" plus any one.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+// segmentColumn encodes a broadcast's segment for the JSONB column; none is NULL.
+func segmentColumn(segment *entity.AudienceSegment) ([]byte, error) {
+	if segment == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(segment)
+	if err != nil {
+		return nil, fmt.Errorf("marshal broadcast segment: %w", err)
+	}
+	return raw, nil
+}
+
+// segmentFrom decodes the segment column; NULL is no segment.
+func segmentFrom(raw []byte) (*entity.AudienceSegment, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var segment entity.AudienceSegment
+	if err := json.Unmarshal(raw, &segment); err != nil {
+		return nil, fmt.Errorf("unmarshal broadcast segment: %w", err)
+	}
+	return &segment, nil
+}
@@ -2,7 +2,10 @@ package pg
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -12,6 +15,7 @@ import (
 	"github.com/mudgallabs/bodhveda/internal/model/repository"
 	"github.com/mudgallabs/tantra/dbx"
 	"github.com/mudgallabs/tantra/query"
+	tantraRepo "github.com/mudgallabs/tantra/repository"
 )
 
 type BroadcastRepo struct {
@@ -27,41 +31,148 @@ func NewBroadcastRepo(db *pgxpool.Pool) repository.BroadcastRepository {
 }
 
 func (r *BroadcastRepo) Create(ctx context.Context, broadcast *entity.Broadcast) (*entity.Broadcast, error) {
+	return createBroadcast(ctx, r.db, broadcast)
+}
+
+// CreateWithRecipients creates a list-based broadcast and its recipient list in
+// one transaction, so prepare_batches can never find a list broadcast whose list
+// is missing or half written. It sets RecipientListSize from the ids.
+func (r *BroadcastRepo) CreateWithRecipients(ctx context.Context, broadcast *entity.Broadcast, recipientExtIDs []string) (*entity.Broadcast, error) {
+	size := len(recipientExtIDs)
+	broadcast.RecipientListSize = &size
+
+	var created *entity.Broadcast
+	err := dbx.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		created, err = createBroadcast(ctx, tx, broadcast)
+		if err != nil {
+			return err
+		}
+
+		rows := make([][]any, len(recipientExtIDs))
+		for i, id := range recipientExtIDs {
+			rows[i] = []any{created.ID, id}
+		}
+
+		_, err = tx.CopyFrom(ctx, pgx.Identifier{"broadcast_recipient"},
+			[]string{"broadcast_id", "recipient_external_id"}, pgx.CopyFromRows(rows))
+		if err != nil {
+			return fmt.Errorf("copy broadcast recipients: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// RecipientListTx reads a list-based broadcast's recipient ids in the caller's
+// transaction, in a stable order so batches slice the same way on every read.
+func (r *BroadcastRepo) RecipientListTx(ctx context.Context, tx pgx.Tx, broadcastID int) ([]string, error) {
+	rows, err := tx.Query(ctx, `
+		SELECT recipient_external_id
+		FROM broadcast_recipient
+		WHERE broadcast_id = $1
+		ORDER BY recipient_external_id
+	`, broadcastID)
+	if err != nil {
+		return nil, fmt.Errorf("query broadcast recipients: %w", err)
+	}
+
+	extIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("scan broadcast recipients: %w", err)
+	}
+	return extIDs, nil
+}
+
+func createBroadcast(ctx context.Context, db dbx.DBExecutor, broadcast *entity.Broadcast) (*entity.Broadcast, error) {
 	var subject, html, text *string
 	if broadcast.Email != nil {
 		subject, html, text = &broadcast.Email.Subject, &broadcast.Email.HTML, &broadcast.Email.Text
 	}
 
+	variants, err := variantsColumn(broadcast.Variants)
+	if err != nil {
+		return nil, err
+	}
+
+	segment, err := segmentColumn(broadcast.Segment)
+	if err != nil {
+		return nil, err
+	}
+
 	sql := `
 		INSERT INTO broadcast (
 			project_id, payload, channel, topic, event, completed_at, created_at, updated_at, status,
-			email_subject, email_html, email_text
+			email_subject, email_html, email_text, send_at, expires_at, priority, variants, segment,
+			recipient_list_size
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		RETURNING id, project_id, payload, channel, topic, event, completed_at, created_at, updated_at, status,
-			email_subject, email_html, email_text
+			email_subject, email_html, email_text, send_at, expires_at, priority, version, variants, segment,
+			recipient_list_size
 	`
-	row := r.db.QueryRow(ctx, sql, broadcast.ProjectID, broadcast.Payload, broadcast.Channel, broadcast.Topic,
+	row := db.QueryRow(ctx, sql, broadcast.ProjectID, broadcast.Payload, broadcast.Channel, broadcast.Topic,
 		broadcast.Event, broadcast.CompletedAt, broadcast.CreatedAt, broadcast.UpdatedAt, broadcast.Status,
-		subject, html, text,
+		subject, html, text, broadcast.SendAt, broadcast.ExpiresAt, broadcast.Priority, variants, segment,
+		broadcast.RecipientListSize,
 	)
 
 	var newBroadcast entity.Broadcast
 	var gotSubject, gotHTML, gotText *string
+	var gotVariants, gotSegment []byte
 
-	err := row.Scan(&newBroadcast.ID, &newBroadcast.ProjectID, &newBroadcast.Payload, &newBroadcast.Channel,
+	err = row.Scan(&newBroadcast.ID, &newBroadcast.ProjectID, &newBroadcast.Payload, &newBroadcast.Channel,
 		&newBroadcast.Topic, &newBroadcast.Event, &newBroadcast.CompletedAt, &newBroadcast.CreatedAt,
-		&newBroadcast.UpdatedAt, &newBroadcast.Status, &gotSubject, &gotHTML, &gotText,
+		&newBroadcast.UpdatedAt, &newBroadcast.Status, &gotSubject, &gotHTML, &gotText, &newBroadcast.SendAt,
+		&newBroadcast.ExpiresAt, &newBroadcast.Priority, &newBroadcast.Version, &gotVariants, &gotSegment,
+		&newBroadcast.RecipientListSize,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scan broadcast: %w", err)
 	}
 
 	newBroadcast.Email = broadcastEmailFrom(gotSubject, gotHTML, gotText, nil, nil)
+	if newBroadcast.Variants, err = variantsFrom(gotVariants); err != nil {
+		return nil, err
+	}
+	if newBroadcast.Segment, err = segmentFrom(gotSegment); err != nil {
+		return nil, err
+	}
 
 	return &newBroadcast, nil
 }
 
+// variantsColumn encodes a broadcast's variants for the JSONB column; none is
+// SQL NULL rather than an empty object.
+func variantsColumn(variants entity.ContentVariants) ([]byte, error) {
+	if len(variants) == 0 {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(variants)
+	if err != nil {
+		return nil, fmt.Errorf("marshal broadcast variants: %w", err)
+	}
+	return raw, nil
+}
+
+// variantsFrom decodes the variants column; NULL is no variants.
+func variantsFrom(raw []byte) (entity.ContentVariants, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var variants entity.ContentVariants
+	if err := json.Unmarshal(raw, &variants); err != nil {
+		return nil, fmt.Errorf("unmarshal broadcast variants: %w", err)
+	}
+	return variants, nil
+}
+
 // broadcastEmailFrom rebuilds the email half from its nullable columns.
 //
 // ⚠️ Keyed on the SUBJECT being non-null, not on any of the counts. Subject is
@@ -94,8 +205,9 @@ func (r *BroadcastRepo) GetByID(ctx context.Context, id int) (*entity.Broadcast,
 	sql := `
 		SELECT id, project_id, payload, channel, topic, event, completed_at, created_at,
 		updated_at, status, total_recipients, eligible_recipients, excluded_disabled,
-		excluded_not_cataloged, email_subject, email_html, email_text,
-		email_eligible_recipients, email_blocked_reason
+		excluded_not_cataloged, excluded_segment, excluded_unknown, email_subject, email_html, email_text,
+		email_eligible_recipients, email_blocked_reason, send_at, expires_at, priority, version, variants, segment,
+		recipient_list_size
 		FROM broadcast
 		WHERE id = $1
 	`
@@ -107,26 +219,43 @@ func (r *BroadcastRepo) GetByID(ctx context.Context, id int) (*entity.Broadcast,
 	// stays distinguishable from a real zero — a broadcast that legitimately
 	// reached nobody and one whose audience was never measured are different
 	// facts, and the console renders them differently.
-	var total, eligible, excludedDisabled, excludedNotCataloged *int
+	var total, eligible, excludedDisabled, excludedNotCataloged, excludedSegment, excludedUnknown *int
 	var emailSubject, emailHTML, emailText, emailBlockedReason *string
 	var emailEligible *int
+	var variants, segment []byte
 
 	err := row.Scan(&broadcast.ID, &broadcast.ProjectID, &broadcast.Payload, &broadcast.Channel, &broadcast.Topic,
 		&broadcast.Event, &broadcast.CompletedAt, &broadcast.CreatedAt, &broadcast.UpdatedAt, &broadcast.Status,
-		&total, &eligible, &excludedDisabled, &excludedNotCataloged,
-		&emailSubject, &emailHTML, &emailText, &emailEligible, &emailBlockedReason)
+		&total, &eligible, &excludedDisabled, &excludedNotCataloged, &excludedSegment, &excludedUnknown,
+		&emailSubject, &emailHTML, &emailText, &emailEligible, &emailBlockedReason, &broadcast.SendAt,
+		&broadcast.ExpiresAt, &broadcast.Priority, &broadcast.Version, &variants, &segment,
+		&broadcast.RecipientListSize)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, tantraRepo.ErrNotFound
+		}
 		return nil, fmt.Errorf("scan broadcast by id: %w", err)
 	}
 
 	broadcast.Email = broadcastEmailFrom(emailSubject, emailHTML, emailText, emailEligible, emailBlockedReason)
+	if broadcast.Variants, err = variantsFrom(variants); err != nil {
+		return nil, err
+	}
+	if broadcast.Segment, err = segmentFrom(segment); err != nil {
+		return nil, err
+	}
 
 	if total != nil {
+		// excluded_segment and excluded_unknown are NULL on audiences frozen
+		// before segments and lists existed; those broadcasts had neither, so
+		// zero is the truth for them.
 		broadcast.Audience = &entity.BroadcastAudience{
 			Total:                *total,
 			Eligible:             derefOrZero(eligible),
 			ExcludedDisabled:     derefOrZero(excludedDisabled),
 			ExcludedNotCataloged: derefOrZero(excludedNotCataloged),
+			ExcludedSegment:      derefOrZero(excludedSegment),
+			ExcludedUnknown:      derefOrZero(excludedUnknown),
 		}
 	}
 
@@ -197,10 +326,11 @@ func setBroadcastAudience(ctx context.Context, db dbx.DBExecutor, broadcastID in
 	sql := `
 		UPDATE broadcast
 		SET total_recipients = $2, eligible_recipients = $3, excluded_disabled = $4,
-		excluded_not_cataloged = $5
+		excluded_not_cataloged = $5, excluded_segment = $6, excluded_unknown = $7
 		WHERE id = $1
 	`
-	_, err := db.Exec(ctx, sql, broadcastID, a.Total, a.Eligible, a.ExcludedDisabled, a.ExcludedNotCataloged)
+	_, err := db.Exec(ctx, sql, broadcastID, a.Total, a.Eligible, a.ExcludedDisabled, a.ExcludedNotCataloged,
+		a.ExcludedSegment, a.ExcludedUnknown)
 	if err != nil {
 		return fmt.Errorf("set broadcast audience: %w", err)
 	}
@@ -245,6 +375,296 @@ func (r *BroadcastRepo) StatusForUpdateTx(ctx context.Context, tx pgx.Tx, broadc
 	return status, nil
 }
 
+// CancelScheduled withdraws a broadcast that has not gone out yet.
+//
+// The `status = 'scheduled'` predicate is what makes this safe against the
+// parked prepare_batches task firing at the same moment: that task takes the
+// row with FOR UPDATE and moves it to `enqueued`, so whichever commits first
+// wins and the other finds nothing to do.
+func (r *BroadcastRepo) CancelScheduled(ctx context.Context, projectID, broadcastID int) error {
+	now := time.Now().UTC()
+
+	sql := `
+		UPDATE broadcast
+		SET status = 'cancelled', completed_at = $3, updated_at = $3
+		WHERE id = $1 AND project_id = $2 AND status = 'scheduled'
+	`
+	tag, err := r.db.Exec(ctx, sql, broadcastID, projectID, now)
+	if err != nil {
+		return fmt.Errorf("cancel scheduled broadcast: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return nil
+	}
+
+	var exists bool
+	err = r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM broadcast WHERE id = $1 AND project_id = $2)`, broadcastID, projectID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("query broadcast: %w", err)
+	}
+	if !exists {
+		return tantraRepo.ErrNotFound
+	}
+
+	return tantraRepo.ErrConflict
+}
+
+// LockTx locks one of the project's broadcasts for an operator action on its
+// batches, and returns its status. Returns tantra repository.ErrNotFound for a
+// broadcast the project does not have.
+//
+// ⚠️ FOR NO KEY UPDATE, not the FOR UPDATE StatusForUpdateTx takes. It still
+// conflicts with that lock, which is all that serialising against preparation
+// needs. But a batch mid fan-out holds its batch lock while its notification
+// inserts take FOR KEY SHARE on this row through the broadcast_id foreign key;
+// FOR UPDATE would block those inserts while the caller waits on the batch, and
+// the two would deadlock.
+func (r *BroadcastRepo) LockTx(ctx context.Context, tx pgx.Tx, projectID, broadcastID int) (enum.BroadcastStatus, error) {
+	var status enum.BroadcastStatus
+
+	err := tx.QueryRow(ctx, `
+		SELECT status FROM broadcast WHERE id = $1 AND project_id = $2 FOR NO KEY UPDATE
+	`, broadcastID, projectID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", tantraRepo.ErrNotFound
+		}
+		return "", fmt.Errorf("lock broadcast: %w", err)
+	}
+
+	return status, nil
+}
+
+// CancelTx stops a broadcast that is scheduled or still fanning out, and returns
+// how many recipients it was billed for but will now never reach, with the time
+// it was billed. Both are zero when nothing had been billed yet.
+//
+// Same lock order as Recall, for the same reason: the broadcast row first, which
+// prepare_batches also takes, so a cancel racing preparation lands before it (and
+// preparation finds `cancelled`) or after its batches exist. The batch update
+// then waits out any batch mid fan-out, which keeps its `success` and its
+// notifications. What is left is exactly what will never run, so it is exactly
+// what is refunded.
+func (r *BroadcastRepo) CancelTx(ctx context.Context, tx pgx.Tx, projectID, broadcastID int) (int64, time.Time, error) {
+	now := time.Now().UTC()
+
+	status, err := r.LockTx(ctx, tx, projectID, broadcastID)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if status != enum.BroadcastStatusScheduled && status != enum.BroadcastStatusEnqueued {
+		return 0, time.Time{}, tantraRepo.ErrConflict
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE broadcast
+		SET status = 'cancelled', completed_at = $2, updated_at = $2
+		WHERE id = $1
+	`, broadcastID, now)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("cancel broadcast: %w", err)
+	}
+
+	// Batches are written in the transaction that bills the broadcast, so the
+	// earliest one's created_at is when it was billed.
+	var undelivered int64
+	var billedAt *time.Time
+	err = tx.QueryRow(ctx, `
+		WITH cancelled AS (
+			UPDATE broadcast_batch
+			SET status = 'cancelled', updated_at = $2
+			WHERE broadcast_id = $1 AND status <> 'success'
+			RETURNING recipients
+		)
+		SELECT
+			COALESCE((SELECT SUM(recipients) FROM cancelled), 0),
+			(SELECT MIN(created_at) FROM broadcast_batch WHERE broadcast_id = $1)
+	`, broadcastID, now).Scan(&undelivered, &billedAt)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("cancel broadcast batches: %w", err)
+	}
+	if undelivered == 0 || billedAt == nil {
+		return 0, time.Time{}, nil
+	}
+
+	return undelivered, *billedAt, nil
+}
+
+// Complete marks the broadcast `completed` once its last batch has fanned out.
+//
+// Conditional on `enqueued` rather than a read-modify-write through Update: the
+// last batch finishing and a recall landing are independent, and an unconditional
+// write would quietly turn a recalled broadcast back into a completed one.
+func (r *BroadcastRepo) Complete(ctx context.Context, broadcastID int) error {
+	now := time.Now().UTC()
+
+	sql := `
+		UPDATE broadcast
+		SET status = 'completed', completed_at = $2, updated_at = $2
+		WHERE id = $1 AND status = 'enqueued'
+	`
+	if _, err := r.db.Exec(ctx, sql, broadcastID, now); err != nil {
+		return fmt.Errorf("complete broadcast: %w", err)
+	}
+
+	return nil
+}
+
+// Recall pulls a broadcast back after it started going out.
+//
+// ⚠️ The order of the statements is the point. The broadcast row is locked
+// first, which serialises this against prepare_batches (it takes the same lock
+// and stops on anything but `enqueued`). The batches come next: a batch mid
+// fan-out holds its row lock, so this statement waits for it to commit and the
+// notifications it wrote are visible to the statement after. Recalling the
+// notifications first would miss exactly those rows.
+//
+// A batch that already succeeded keeps `success` — it did fan out, and its
+// notifications carry the recall. Every other batch becomes `recalled`, which
+// BroadcastDeliveryProcessor treats as "write nothing".
+func (r *BroadcastRepo) Recall(ctx context.Context, projectID, broadcastID int) error {
+	return dbx.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
+		now := time.Now().UTC()
+
+		var status enum.BroadcastStatus
+		err := tx.QueryRow(ctx, `
+			SELECT status FROM broadcast WHERE id = $1 AND project_id = $2 FOR UPDATE
+		`, broadcastID, projectID).Scan(&status)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return tantraRepo.ErrNotFound
+			}
+			return fmt.Errorf("lock broadcast: %w", err)
+		}
+		if status == enum.BroadcastStatusRecalled || status == enum.BroadcastStatusCancelled {
+			return tantraRepo.ErrConflict
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE broadcast
+			SET status = 'recalled', completed_at = COALESCE(completed_at, $2), updated_at = $2
+			WHERE id = $1
+		`, broadcastID, now)
+		if err != nil {
+			return fmt.Errorf("recall broadcast: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE broadcast_batch
+			SET status = 'recalled', updated_at = $2
+			WHERE broadcast_id = $1 AND status <> 'success'
+		`, broadcastID, now)
+		if err != nil {
+			return fmt.Errorf("recall broadcast batches: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE notification
+			SET status = 'recalled', updated_at = $2
+			WHERE broadcast_id = $1 AND status <> 'recalled'
+		`, broadcastID, now)
+		if err != nil {
+			return fmt.Errorf("recall broadcast notifications: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE notification_delivery nd
+			SET status = 'recalled', updated_at = $2
+			FROM notification n
+			WHERE n.id = nd.notification_id AND n.broadcast_id = $1 AND nd.`+recallableDelivery, broadcastID, now)
+		if err != nil {
+			return fmt.Errorf("recall broadcast deliveries: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// ContentTx reads the broadcast's payload and variants in the caller's
+// transaction. Called under the batch lock, which is what makes it safe against
+// Patch — see there.
+func (r *BroadcastRepo) ContentTx(ctx context.Context, tx pgx.Tx, broadcastID int) (json.RawMessage, entity.ContentVariants, error) {
+	var payload json.RawMessage
+	var raw []byte
+
+	if err := tx.QueryRow(ctx, `SELECT payload, variants FROM broadcast WHERE id = $1`, broadcastID).Scan(&payload, &raw); err != nil {
+		return nil, nil, err
+	}
+
+	variants, err := variantsFrom(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return payload, variants, nil
+}
+
+// Patch edits a broadcast's payload: the broadcast row, and every notification
+// of it already in an inbox.
+//
+// ⚠️ Same lock order as Recall, for the same reason. The batches are locked
+// before the notifications are updated, so a batch mid fan-out commits first and
+// its rows are caught by the update; a batch that has not started reads the new
+// payload through ContentTx once it gets its lock.
+//
+// An edit is not localized. It replaces the payload for every locale, so the
+// variants lose their payloads (their emails stay, since email is not edited)
+// and the recipients not reached yet get the new payload whatever their locale.
+//
+// Only an `enqueued` or `completed` broadcast can be edited. A scheduled one
+// still fans out from the snapshot in its parked task, and every other status
+// has nothing left in anyone's feed.
+func (r *BroadcastRepo) Patch(ctx context.Context, patch *dto.PatchBroadcastPayload) error {
+	return dbx.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
+		now := time.Now().UTC()
+
+		var status enum.BroadcastStatus
+		var version int
+		err := tx.QueryRow(ctx, `
+			SELECT status, version FROM broadcast WHERE id = $1 AND project_id = $2 FOR UPDATE
+		`, patch.BroadcastID, patch.ProjectID).Scan(&status, &version)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return tantraRepo.ErrNotFound
+			}
+			return fmt.Errorf("lock broadcast: %w", err)
+		}
+		if status != enum.BroadcastStatusEnqueued && status != enum.BroadcastStatusCompleted {
+			return tantraRepo.ErrConflict
+		}
+		if patch.Version != nil && *patch.Version != version {
+			return enum.ErrVersionMismatch
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE broadcast
+			SET payload = $2, version = version + 1, updated_at = $3,
+				variants = (SELECT jsonb_object_agg(key, value - 'payload') FROM jsonb_each(variants))
+			WHERE id = $1
+		`, patch.BroadcastID, patch.Payload, now)
+		if err != nil {
+			return fmt.Errorf("patch broadcast: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `SELECT id FROM broadcast_batch WHERE broadcast_id = $1 FOR UPDATE`, patch.BroadcastID); err != nil {
+			return fmt.Errorf("lock broadcast batches: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE notification
+			SET payload = $2, version = version + 1, updated_at = $3,
+				read_at = CASE WHEN $4 THEN NULL ELSE read_at END,
+				opened_at = CASE WHEN $4 THEN NULL ELSE opened_at END
+			WHERE broadcast_id = $1 AND status = 'delivered'
+		`, patch.BroadcastID, patch.Payload, now, patch.ResetRead)
+		if err != nil {
+			return fmt.Errorf("patch broadcast notifications: %w", err)
+		}
+
+		return nil
+	})
+}
+
 func (r *BroadcastRepo) DeleteForProject(ctx context.Context, projectID int) (int, error) {
 	sql := `
 		DELETE FROM broadcast
@@ -261,7 +681,8 @@ func (r *BroadcastRepo) DeleteForProject(ctx context.Context, projectID int) (in
 func (r *BroadcastRepo) List(ctx context.Context, projectID int, pagination query.Pagination) ([]*dto.BroadcastListItem, int, error) {
 	sql := `
 		SELECT 
-			id, payload, channel, topic, event, completed_at, created_at, updated_at, status
+			id, payload, channel, topic, event, completed_at, created_at, updated_at, status, send_at, expires_at,
+			priority, version
 		FROM broadcast
 	`
 	b := dbx.NewSQLBuilder(sql)
@@ -284,7 +705,7 @@ func (r *BroadcastRepo) List(ctx context.Context, projectID int, pagination quer
 		err := rows.Scan(
 			&broadcast.ID, &broadcast.Payload, &broadcast.Target.Channel, &broadcast.Target.Topic,
 			&broadcast.Target.Event, &broadcast.CompletedAt, &broadcast.CreatedAt, &broadcast.UpdatedAt,
-			&broadcast.Status,
+			&broadcast.Status, &broadcast.SendAt, &broadcast.ExpiresAt, &broadcast.Priority, &broadcast.Version,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("scan: %w", err)
@@ -3,6 +3,7 @@ package pg
 import (
 	"context"
 	"os"
+	"slices"
 	"testing"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -105,12 +106,12 @@ func TestBroadcastAudienceMatchesEligibleList(t *testing.T) {
 	assertConsistent := func(label string, wantEligible, wantDisabled, wantNotCataloged int) {
 		t.Helper()
 
-		audience, err := prefRepo.CountBroadcastAudience(ctx, projectID, target, medium)
+		audience, err := prefRepo.CountBroadcastAudience(ctx, projectID, target, medium, nil)
 		if err != nil {
 			t.Fatalf("%s: count audience: %v", label, err)
 		}
 
-		list, err := prefRepo.ListEligibleRecipientExtIDsForBroadcast(ctx, projectID, target, medium)
+		list, err := prefRepo.ListEligibleRecipientExtIDsForBroadcast(ctx, projectID, target, medium, nil)
 		if err != nil {
 			t.Fatalf("%s: list eligible: %v", label, err)
 		}
@@ -131,6 +132,19 @@ func TestBroadcastAudienceMatchesEligibleList(t *testing.T) {
 			t.Errorf("%s: excluded_not_cataloged = %d, want %d", label, audience.ExcludedNotCataloged, wantNotCataloged)
 		}
 
+		// The dry run counts what the fan-out would list, and samples from it.
+		fanOut, sample, err := prefRepo.CountBroadcastFanOut(ctx, projectID, target, []enum.Medium{medium}, nil, 2)
+		if err != nil {
+			t.Fatalf("%s: count fan-out: %v", label, err)
+		}
+		if fanOut != len(list) {
+			t.Errorf("%s: fan-out count = %d, list returned %d", label, fanOut, len(list))
+		}
+		sorted := slices.Sorted(slices.Values(list))
+		if !slices.Equal(sample, sorted[:min(len(sorted), 2)]) {
+			t.Errorf("%s: sample = %v, want the first two of %v", label, sample, sorted)
+		}
+
 		// The four buckets must partition the recipients exactly, or the tree
 		// renders numbers that do not add up to the total it displays.
 		if sum := audience.Eligible + audience.ExcludedDisabled + audience.ExcludedNotCataloged; sum != audience.Total {
@@ -148,6 +162,21 @@ func TestBroadcastAudienceMatchesEligibleList(t *testing.T) {
 
 	setCatalogPref(true)
 	assertConsistent("catalog enabled", 3, 1, 0)
+
+	// Opted out of in-app but into email: reached by a broadcast on both, once.
+	optedOut := "opted-out"
+	emailPref := entity.NewPreference(&projectID, &optedOut, target.Channel, target.Topic, target.Event,
+		string(enum.MediumEmail), nil, nil, true)
+	if _, err := prefRepo.Create(ctx, emailPref); err != nil {
+		t.Fatalf("create email pref: %v", err)
+	}
+	both, _, err := prefRepo.CountBroadcastFanOut(ctx, projectID, target, []enum.Medium{enum.MediumInApp, enum.MediumEmail}, nil, 0)
+	if err != nil {
+		t.Fatalf("count fan-out on both mediums: %v", err)
+	}
+	if both != 4 {
+		t.Errorf("fan-out on both mediums = %d, want the 3 in-app plus the email-only one", both)
+	}
 }
 
 // A catalog row that exists but is DISABLED must land in not_cataloged, not in
@@ -202,7 +231,7 @@ func TestBroadcastAudienceDisabledCatalogIsNotRecipientOptOut(t *testing.T) {
 		t.Fatalf("create disabled catalog pref: %v", err)
 	}
 
-	audience, err := prefRepo.CountBroadcastAudience(ctx, projectID, target, medium)
+	audience, err := prefRepo.CountBroadcastAudience(ctx, projectID, target, medium, nil)
 	if err != nil {
 		t.Fatalf("count audience: %v", err)
 	}
@@ -3,6 +3,7 @@ package pg
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -39,7 +40,7 @@ func createBroadcastBatch(ctx context.Context, db dbx.DBExecutor, broadcastBatch
 			broadcast_id, recipients, recipient_external_ids, status, attempt, duration, created_at, updated_at
 		)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, broadcast_id, recipients, recipient_external_ids, status, attempt, duration, created_at, updated_at
+		RETURNING ` + broadcastBatchColumns + `
 	`
 	row := db.QueryRow(ctx, sql, broadcastBatch.BroadcastID, broadcastBatch.Recipients, broadcastBatch.RecipientExtIDs,
 		broadcastBatch.Status, broadcastBatch.Attempt, broadcastBatch.Duration, broadcastBatch.CreatedAt, broadcastBatch.UpdatedAt,
@@ -48,7 +49,7 @@ func createBroadcastBatch(ctx context.Context, db dbx.DBExecutor, broadcastBatch
 	var newBatch entity.BroadcastBatch
 
 	err := row.Scan(&newBatch.ID, &newBatch.BroadcastID, &newBatch.Recipients, &newBatch.RecipientExtIDs,
-		&newBatch.Status, &newBatch.Attempt, &newBatch.Duration, &newBatch.CreatedAt, &newBatch.UpdatedAt,
+		&newBatch.Status, &newBatch.Attempt, &newBatch.Retries, &newBatch.Duration, &newBatch.CreatedAt, &newBatch.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -65,7 +66,7 @@ func createBroadcastBatch(ctx context.Context, db dbx.DBExecutor, broadcastBatch
 // empty set would silently mark such a batch successful having reached nobody.
 func (r *BroadcastBatchRepo) ResumableForBroadcastTx(ctx context.Context, tx pgx.Tx, broadcastID int) ([]*entity.BroadcastBatch, error) {
 	sql := `
-		SELECT id, broadcast_id, recipients, recipient_external_ids, status, attempt, duration, created_at, updated_at
+		SELECT ` + broadcastBatchColumns + `
 		FROM broadcast_batch
 		WHERE broadcast_id = $1 AND status = $2 AND recipient_external_ids IS NOT NULL
 		ORDER BY id
@@ -75,6 +76,61 @@ func (r *BroadcastBatchRepo) ResumableForBroadcastTx(ctx context.Context, tx pgx
 	if err != nil {
 		return nil, err
 	}
+
+	return collectBroadcastBatches(rows)
+}
+
+// ListFailed returns the broadcast's `failed` batches, oldest first. A batch
+// still inside its Asynq retries is `failed` between attempts too, so this is
+// "has failed", not "has given up".
+func (r *BroadcastBatchRepo) ListFailed(ctx context.Context, broadcastID int) ([]*entity.BroadcastBatch, error) {
+	sql := `
+		SELECT ` + broadcastBatchColumns + `
+		FROM broadcast_batch
+		WHERE broadcast_id = $1 AND status = $2
+		ORDER BY id
+	`
+
+	rows, err := r.db.Query(ctx, sql, broadcastID, enum.BroadcastBatchStatusFailed)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectBroadcastBatches(rows)
+}
+
+// RetryFailedTx moves the broadcast's `failed` batches back to `enqueued` for
+// another round: attempt reset to 0, retries bumped so the round gets its own
+// task id. The caller enqueues the returned batches after commit.
+//
+// Batches without recipient_external_ids are left `failed`, for the same reason
+// ResumableForBroadcastTx skips them.
+func (r *BroadcastBatchRepo) RetryFailedTx(ctx context.Context, tx pgx.Tx, broadcastID int) ([]*entity.BroadcastBatch, error) {
+	sql := `
+		UPDATE broadcast_batch
+		SET status = $2, attempt = 0, retries = retries + 1, updated_at = $4
+		WHERE broadcast_id = $1 AND status = $3 AND recipient_external_ids IS NOT NULL
+		RETURNING ` + broadcastBatchColumns
+
+	rows, err := tx.Query(ctx, sql, broadcastID,
+		enum.BroadcastBatchStatusEnqueued, enum.BroadcastBatchStatusFailed, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	batches, err := collectBroadcastBatches(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// RETURNING has no ORDER BY; enqueue in id order like preparation does.
+	slices.SortFunc(batches, func(a, b *entity.BroadcastBatch) int { return a.ID - b.ID })
+	return batches, nil
+}
+
+const broadcastBatchColumns = `id, broadcast_id, recipients, recipient_external_ids, status, attempt, retries, duration, created_at, updated_at`
+
+func collectBroadcastBatches(rows pgx.Rows) ([]*entity.BroadcastBatch, error) {
 	defer rows.Close()
 
 	var batches []*entity.BroadcastBatch
@@ -82,7 +138,7 @@ func (r *BroadcastBatchRepo) ResumableForBroadcastTx(ctx context.Context, tx pgx
 	for rows.Next() {
 		var b entity.BroadcastBatch
 		if err := rows.Scan(&b.ID, &b.BroadcastID, &b.Recipients, &b.RecipientExtIDs,
-			&b.Status, &b.Attempt, &b.Duration, &b.CreatedAt, &b.UpdatedAt,
+			&b.Status, &b.Attempt, &b.Retries, &b.Duration, &b.CreatedAt, &b.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -109,11 +165,15 @@ func (r *BroadcastBatchRepo) UpdateTx(ctx context.Context, tx pgx.Tx, batchID in
 	return updateBroadcastBatch(ctx, tx, batchID, payload)
 }
 
+// updateBroadcastBatch never moves a batch out of `recalled` or `cancelled`. The
+// failure path in BroadcastDeliveryProcessor writes `failed` outside its
+// rolled-back transaction, and a recall or cancel landing in between would
+// otherwise be undone — and a `failed` batch is retried.
 func updateBroadcastBatch(ctx context.Context, db dbx.DBExecutor, batchID int, payload *entity.BroadcastBatchUpdatePayload) error {
 	sql := `
 		UPDATE broadcast_batch
 		SET updated_at = $2, status = $3, attempt = $4, duration = $5
-		WHERE id = $1
+		WHERE id = $1 AND status NOT IN ('recalled', 'cancelled')
 	`
 	_, err := db.Exec(ctx, sql, batchID, time.Now().UTC(), payload.Status, payload.Attempt, payload.Duration)
 	return err
@@ -0,0 +1,192 @@
+package pg
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+)
+
+func TestLikePrefixEscapesWildcards(t *testing.T) {
+	tests := map[string]string{
+		"user":    "user%",
+		"user_":   `user\_%`,
+		"50%":     `50\%%`,
+		`back\sl`: `back\\sl%`,
+	}
+	for prefix, want := range tests {
+		if got := likePrefix(prefix); got != want {
+			t.Errorf("likePrefix(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+}
+
+func TestSegmentSQLNumbersAfterExistingArgs(t *testing.T) {
+	tag := "beta"
+	days := 30
+	args := []any{1, "c", "t", "e", "in_app"}
+
+	got := segmentSQL(&entity.AudienceSegment{
+		Any: []entity.AudienceSegment{{Tag: &tag}, {ReadWithinDays: &days}},
+	}, &args)
+
+	if len(args) != 7 || args[5] != "beta" || args[6] != 30 {
+		t.Fatalf("args = %v, want the segment's values appended as $6 and $7", args)
+	}
+	for _, want := range []string{"$6::text", "$7::int", " OR "} {
+		if !strings.Contains(got, want) {
+			t.Errorf("segmentSQL = %s, want it to contain %q", got, want)
+		}
+	}
+
+	if got := segmentSQL(nil, &args); got != "TRUE" {
+		t.Errorf("nil segment = %s, want TRUE", got)
+	}
+}
+
+// TestBroadcastSegmentNarrowsAudience checks a segment against a live Postgres:
+// the eligible list and the audience count must agree once a segment is in play,
+// and excluded_segment must complete the partition of the total.
+//
+// Skipped unless TEST_DB_URL is set. Self-cleaning.
+func TestBroadcastSegmentNarrowsAudience(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set; skipping DB integration test")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	var userID int
+	if err := pool.QueryRow(ctx, `SELECT user_id FROM project ORDER BY id LIMIT 1`).Scan(&userID); err != nil {
+		t.Fatalf("need at least one existing project to borrow a user_id: %v", err)
+	}
+
+	var projectID int
+	err = pool.QueryRow(ctx, `
+		INSERT INTO project (user_id, name, created_at, updated_at)
+		VALUES ($1, 'segment-test', now(), now()) RETURNING id
+	`, userID).Scan(&projectID)
+	if err != nil {
+		t.Fatalf("insert project: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `DELETE FROM project WHERE id = $1`, projectID)
+	})
+
+	target := dto.Target{Channel: "launch", Topic: "none", Event: "announced"}
+	medium := enum.MediumInApp
+
+	prefRepo := NewPreferenceRepo(pool)
+	name := "Launch"
+	catalog := entity.NewPreference(&projectID, nil, target.Channel, target.Topic, target.Event,
+		string(medium), &name, nil, true)
+	if _, err := prefRepo.Create(ctx, catalog); err != nil {
+		t.Fatalf("create catalog pref: %v", err)
+	}
+
+	// ext id, created days ago, tags, has a primary email, read in the last week
+	seeds := []struct {
+		extID    string
+		ageDays  int
+		tags     []string
+		email    bool
+		readDays int
+	}{
+		{"team_ana", 2, []string{"beta"}, true, 1},
+		{"team_raj", 400, nil, false, 0},
+		{"teamx_li", 2, []string{"beta"}, false, 0},
+		{"cust_ola", 10, nil, true, 90},
+		{"cust_kai", 10, []string{"vip"}, true, 3},
+	}
+	for _, s := range seeds {
+		_, err := pool.Exec(ctx, `
+			INSERT INTO recipient (project_id, external_id, tags, created_at, updated_at)
+			VALUES ($1, $2, COALESCE($3::text[], '{}'), now() - make_interval(days => $4), now())
+		`, projectID, s.extID, s.tags, s.ageDays)
+		if err != nil {
+			t.Fatalf("insert recipient %s: %v", s.extID, err)
+		}
+		if s.email {
+			_, err := pool.Exec(ctx, `
+				INSERT INTO recipient_contact (project_id, recipient_external_id, medium, address, is_primary)
+				VALUES ($1, $2, 'email', $2 || '@example.com', true)
+			`, projectID, s.extID)
+			if err != nil {
+				t.Fatalf("insert contact %s: %v", s.extID, err)
+			}
+		}
+		if s.readDays > 0 {
+			_, err := pool.Exec(ctx, `
+				INSERT INTO notification (project_id, recipient_external_id, payload, channel, topic, event, status,
+					created_at, updated_at, read_at)
+				VALUES ($1, $2, '{}', 'launch', 'none', 'announced', 'delivered', now(), now(),
+					now() - make_interval(days => $3))
+			`, projectID, s.extID, s.readDays)
+			if err != nil {
+				t.Fatalf("insert notification %s: %v", s.extID, err)
+			}
+		}
+	}
+
+	email := enum.MediumEmail
+	prefix := "team_"
+	beta := "beta"
+	week := 7
+	after := time.Now().AddDate(0, 0, -30)
+
+	tests := []struct {
+		name    string
+		segment *entity.AudienceSegment
+		want    []string
+	}{
+		{"none", nil, []string{"cust_kai", "cust_ola", "team_ana", "team_raj", "teamx_li"}},
+		// "_" is literal, so teamx_li is not a team_ member.
+		{"prefix", &entity.AudienceSegment{ExternalIDPrefix: &prefix}, []string{"team_ana", "team_raj"}},
+		{"lacks email", &entity.AudienceSegment{LacksContact: &email}, []string{"team_raj", "teamx_li"}},
+		{"created and read", &entity.AudienceSegment{CreatedAfter: &after, ReadWithinDays: &week}, []string{"cust_kai", "team_ana"}},
+		{"has email and any", &entity.AudienceSegment{
+			HasContact: &email,
+			Any:        []entity.AudienceSegment{{Tag: &beta}, {ReadWithinDays: &week}},
+		}, []string{"cust_kai", "team_ana"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := prefRepo.ListEligibleRecipientExtIDsForBroadcast(ctx, projectID, target, medium, tt.segment)
+			if err != nil {
+				t.Fatalf("list eligible: %v", err)
+			}
+			slices.Sort(list)
+			if !slices.Equal(list, tt.want) {
+				t.Errorf("eligible = %v, want %v", list, tt.want)
+			}
+
+			audience, err := prefRepo.CountBroadcastAudience(ctx, projectID, target, medium, tt.segment)
+			if err != nil {
+				t.Fatalf("count audience: %v", err)
+			}
+			if audience.Eligible != len(list) {
+				t.Errorf("aggregate says %d eligible, list returned %d", audience.Eligible, len(list))
+			}
+			if want := len(seeds) - len(tt.want); audience.ExcludedSegment != want {
+				t.Errorf("excluded_segment = %d, want %d", audience.ExcludedSegment, want)
+			}
+			if sum := audience.Eligible + audience.ExcludedDisabled + audience.ExcludedNotCataloged + audience.ExcludedSegment; sum != audience.Total {
+				t.Errorf("buckets sum to %d, but total = %d", sum, audience.Total)
+			}
+		})
+	}
+}
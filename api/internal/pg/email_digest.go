@@ -0,0 +1,202 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/tantra/dbx"
+	tantraRepo "github.com/mudgallabs/tantra/repository"
+)
+
+type EmailDigestRepo struct {
+	db   dbx.DBExecutor
+	pool *pgxpool.Pool
+}
+
+func NewEmailDigestRepo(db *pgxpool.Pool) repository.EmailDigestRepository {
+	return &EmailDigestRepo{db: db, pool: db}
+}
+
+const emailDigestColumns = `
+	id, project_id, recipient_external_id, digest_window, status, contact_id, address_snapshot,
+	provider, provider_message_id, failure_reason, attempt, due_at, sent_at, created_at, updated_at
+`
+
+// digestWaiting selects the deliveries of digest $1 that have not gone out yet.
+// `failed` is included so a retry after a failed attempt still covers them.
+const digestWaiting = `digest_id = $1 AND status IN ('pending', 'failed')`
+
+func scanEmailDigest(row scannable) (*entity.EmailDigest, error) {
+	var d entity.EmailDigest
+	err := row.Scan(&d.ID, &d.ProjectID, &d.RecipientExtID, &d.Window, &d.Status, &d.ContactID, &d.AddressSnapshot,
+		&d.Provider, &d.ProviderMessageID, &d.FailureReason, &d.Attempt, &d.DueAt, &d.SentAt, &d.CreatedAt, &d.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ClaimDue bumps updated_at on every claim, which is what staleBefore measures
+// against: a digest re-claimed from `sending` gets a fresh grace period.
+//
+// ix_email_digest_due only covers open rows; stale `sending` rows are few (each
+// one is a lost enqueue or a crashed worker), so that half is a small scan.
+func (r *EmailDigestRepo) ClaimDue(ctx context.Context, now, staleBefore time.Time, limit int) ([]int64, error) {
+	sql := `
+		UPDATE email_digest SET status = 'sending', updated_at = now()
+		WHERE id IN (
+			SELECT id FROM email_digest
+			WHERE (status = 'open' AND due_at <= $1)
+			   OR (status = 'sending' AND updated_at < $2)
+			ORDER BY due_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id
+	`
+
+	rows, err := r.db.Query(ctx, sql, now, staleBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim due digests: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return ids, nil
+}
+
+func (r *EmailDigestRepo) Get(ctx context.Context, id int64) (*entity.EmailDigest, error) {
+	sql := `SELECT ` + emailDigestColumns + ` FROM email_digest WHERE id = $1`
+
+	digest, err := scanEmailDigest(r.db.QueryRow(ctx, sql, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, tantraRepo.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return digest, nil
+}
+
+func (r *EmailDigestRepo) ListItems(ctx context.Context, id int64) ([]*entity.EmailDigestItem, error) {
+	sql := `
+		SELECT i.delivery_id, i.subject, i.html, i.text, i.expires_at, i.created_at
+		FROM email_digest_item i
+		JOIN notification_delivery nd ON nd.id = i.delivery_id
+		WHERE nd.` + digestWaiting + `
+		ORDER BY i.delivery_id
+	`
+
+	rows, err := r.db.Query(ctx, sql, id)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*entity.EmailDigestItem{}
+	for rows.Next() {
+		var item entity.EmailDigestItem
+		if err := rows.Scan(&item.DeliveryID, &item.Subject, &item.HTML, &item.Text, &item.ExpiresAt, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		items = append(items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return items, nil
+}
+
+func (r *EmailDigestRepo) ExpireItems(ctx context.Context, id int64, deliveryIDs []int64) error {
+	if len(deliveryIDs) == 0 {
+		return nil
+	}
+
+	sql := `
+		UPDATE notification_delivery SET status = 'expired', updated_at = now()
+		WHERE ` + digestWaiting + ` AND id = ANY($2)
+	`
+
+	if _, err := r.db.Exec(ctx, sql, id, deliveryIDs); err != nil {
+		return fmt.Errorf("expire digest items: %w", err)
+	}
+
+	return nil
+}
+
+func (r *EmailDigestRepo) MarkSent(ctx context.Context, id int64, provider, providerMessageID string, attempt int) error {
+	return dbx.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
+		digestSQL := `
+			UPDATE email_digest
+			SET status = 'sent', provider = $2, provider_message_id = $3, failure_reason = NULL,
+				attempt = $4, sent_at = now(), updated_at = now()
+			WHERE id = $1
+		`
+		if _, err := tx.Exec(ctx, digestSQL, id, provider, providerMessageID, attempt); err != nil {
+			return fmt.Errorf("mark digest sent: %w", err)
+		}
+
+		deliverySQL := `
+			UPDATE notification_delivery
+			SET status = 'digested', provider = $2, failure_reason = NULL, attempt = $3,
+				sent_at = now(), updated_at = now()
+			WHERE ` + digestWaiting
+		if _, err := tx.Exec(ctx, deliverySQL, id, provider, attempt); err != nil {
+			return fmt.Errorf("mark deliveries digested: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *EmailDigestRepo) MarkFailed(ctx context.Context, id int64, reason string, attempt int) error {
+	return dbx.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
+		digestSQL := `
+			UPDATE email_digest
+			SET status = 'failed', failure_reason = $2, attempt = $3, updated_at = now()
+			WHERE id = $1
+		`
+		if _, err := tx.Exec(ctx, digestSQL, id, reason, attempt); err != nil {
+			return fmt.Errorf("mark digest failed: %w", err)
+		}
+
+		deliverySQL := `
+			UPDATE notification_delivery
+			SET status = 'failed', failure_reason = $2, attempt = $3, updated_at = now()
+			WHERE ` + digestWaiting
+		if _, err := tx.Exec(ctx, deliverySQL, id, reason, attempt); err != nil {
+			return fmt.Errorf("mark deliveries failed: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *EmailDigestRepo) MarkExpired(ctx context.Context, id int64) error {
+	sql := `UPDATE email_digest SET status = 'expired', updated_at = now() WHERE id = $1`
+
+	if _, err := r.db.Exec(ctx, sql, id); err != nil {
+		return fmt.Errorf("mark digest expired: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestClaimDueClaimsEachDigestOnce runs several flush loops against the same
+// due digests at once, the way overlapping scheduler ticks (or two workers)
+// would, and checks every due digest is claimed by exactly one of them. A
+// digest claimed twice is a digest emailed twice; one claimed by nobody is a
+// digest that never goes out.
+//
+// Skipped unless TEST_DB_URL is set. Self-cleaning.
+func TestClaimDueClaimsEachDigestOnce(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set; skipping DB integration test")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	var userID int
+	if err := pool.QueryRow(ctx, `SELECT user_id FROM project ORDER BY id LIMIT 1`).Scan(&userID); err != nil {
+		t.Fatalf("need at least one existing project to borrow a user_id: %v", err)
+	}
+
+	var projectID int
+	err = pool.QueryRow(ctx, `
+		INSERT INTO project (user_id, name, created_at, updated_at)
+		VALUES ($1, 'email-digest-claim-test', now(), now()) RETURNING id
+	`, userID).Scan(&projectID)
+	if err != nil {
+		t.Fatalf("insert project: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(ctx, "DELETE FROM project WHERE id = $1", projectID) })
+
+	insert := func(extID, status string, dueAt, updatedAt time.Time) int64 {
+		t.Helper()
+		var id int64
+		err := pool.QueryRow(ctx, `
+			INSERT INTO email_digest (project_id, recipient_external_id, digest_window, status, due_at, created_at, updated_at)
+			VALUES ($1, $2, 'hourly', $3, $4, $5, $5) RETURNING id
+		`, projectID, extID, status, dueAt, updatedAt).Scan(&id)
+		if err != nil {
+			t.Fatalf("insert digest %s: %v", extID, err)
+		}
+		return id
+	}
+
+	now := time.Now().UTC()
+	const staleAfter = 10 * time.Minute
+
+	want := map[int64]bool{}
+	for i := 0; i < 40; i++ {
+		want[insert(fmt.Sprintf("due-%d", i), "open", now.Add(-time.Minute), now)] = true
+	}
+	// Left `sending` by a worker that died: claimed again.
+	want[insert("stale", "sending", now.Add(-time.Hour), now.Add(-2*staleAfter))] = true
+	// Not due yet, and in flight right now: neither is claimed.
+	notDue := insert("not-due", "open", now.Add(time.Hour), now)
+	inFlight := insert("in-flight", "sending", now.Add(-time.Minute), now)
+
+	repo := NewEmailDigestRepo(pool)
+
+	var mu sync.Mutex
+	claimedBy := map[int64]int{}
+	var wg sync.WaitGroup
+	for worker := 0; worker < 6; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				ids, err := repo.ClaimDue(ctx, now, now.Add(-staleAfter), 4)
+				if err != nil {
+					t.Errorf("claim: %v", err)
+					return
+				}
+				if len(ids) == 0 {
+					return
+				}
+				mu.Lock()
+				for _, id := range ids {
+					claimedBy[id]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// A loop can stop early when every row it saw was locked by another; one
+	// more pass picks up anything left, and must not re-claim the rest.
+	ids, err := repo.ClaimDue(ctx, now, now.Add(-staleAfter), 100)
+	if err != nil {
+		t.Fatalf("final claim: %v", err)
+	}
+	for _, id := range ids {
+		claimedBy[id]++
+	}
+
+	for id, n := range claimedBy {
+		if n != 1 {
+			t.Errorf("digest %d claimed %d times", id, n)
+		}
+		if !want[id] {
+			t.Errorf("digest %d was claimed but is not due", id)
+		}
+	}
+	for id := range want {
+		if claimedBy[id] == 0 {
+			t.Errorf("due digest %d was never claimed", id)
+		}
+	}
+	if claimedBy[notDue] != 0 || claimedBy[inFlight] != 0 {
+		t.Errorf("claimed a digest that is not due (%d) or still in flight (%d)", claimedBy[notDue], claimedBy[inFlight])
+	}
+
+	var sending int
+	if err := pool.QueryRow(ctx, `
+		SELECT count(*) FROM email_digest WHERE project_id = $1 AND status = 'sending'
+	`, projectID).Scan(&sending); err != nil {
+		t.Fatalf("count sending: %v", err)
+	}
+	if sending != len(want)+1 {
+		t.Errorf("%d digests are sending, want the %d claimed plus the one in flight", sending, len(want))
+	}
+}
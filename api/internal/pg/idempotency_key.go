@@ -0,0 +1,96 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/tantra/dbx"
+)
+
+type IdempotencyKeyRepo struct {
+	db dbx.DBExecutor
+}
+
+func NewIdempotencyKeyRepo(db *pgxpool.Pool) repository.IdempotencyKeyRepository {
+	return &IdempotencyKeyRepo{db: db}
+}
+
+const idempotencyKeyColumns = `id, project_id, key, request_hash, response, created_at`
+
+func scanIdempotencyKey(row scannable) (*entity.IdempotencyKey, error) {
+	var k entity.IdempotencyKey
+	if err := row.Scan(&k.ID, &k.ProjectID, &k.Key, &k.RequestHash, &k.Response, &k.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// Claim is one statement on the happy path. The conflict branch only fires for a
+// row that no longer protects anything — past retention — and overwrites it as
+// if it were new; for a live row the WHERE fails, nothing
+// is returned, and the caller is handed the existing row instead.
+//
+// The follow-up SELECT can lose a race with the retention sweep deleting the row
+// it just conflicted with. That is retried once: by then the key is free and the
+// INSERT wins outright.
+func (r *IdempotencyKeyRepo) Claim(ctx context.Context, projectID int, key, requestHash string, expiredBefore time.Time) (*entity.IdempotencyKey, bool, error) {
+	claimSQL := `
+		INSERT INTO idempotency_key (project_id, key, request_hash, created_at)
+		VALUES ($1, $2, $3, now())
+		ON CONFLICT (project_id, key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    response = NULL,
+		    created_at = EXCLUDED.created_at
+		WHERE idempotency_key.created_at < $4
+		RETURNING ` + idempotencyKeyColumns
+
+	existingSQL := `
+		SELECT ` + idempotencyKeyColumns + `
+		FROM idempotency_key
+		WHERE project_id = $1 AND key = $2
+	`
+
+	for attempt := 0; attempt < 2; attempt++ {
+		claimed, err := scanIdempotencyKey(r.db.QueryRow(ctx, claimSQL, projectID, key, requestHash, expiredBefore))
+		if err == nil {
+			return claimed, true, nil
+		}
+		if err != pgx.ErrNoRows {
+			return nil, false, fmt.Errorf("claim: %w", err)
+		}
+
+		existing, err := scanIdempotencyKey(r.db.QueryRow(ctx, existingSQL, projectID, key))
+		if err == nil {
+			return existing, false, nil
+		}
+		if err != pgx.ErrNoRows {
+			return nil, false, fmt.Errorf("get existing: %w", err)
+		}
+	}
+
+	return nil, false, fmt.Errorf("claim: key %q neither claimable nor present", key)
+}
+
+func (r *IdempotencyKeyRepo) Complete(ctx context.Context, id int, response json.RawMessage) error {
+	_, err := r.db.Exec(ctx, `UPDATE idempotency_key SET response = $2 WHERE id = $1`, id, response)
+	return err
+}
+
+func (r *IdempotencyKeyRepo) Release(ctx context.Context, id int) error {
+	_, err := r.db.Exec(ctx, `DELETE FROM idempotency_key WHERE id = $1`, id)
+	return err
+}
+
+func (r *IdempotencyKeyRepo) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM idempotency_key WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -2,6 +2,7 @@ package pg
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -28,11 +29,67 @@ import (
 //   - `quota_exceeded` — the project was over its plan limit; never delivered.
 //   - `not_requested` — the SENDER never asked for in-app. The row exists only to
 //     carry the email delivery, the analytics join, and GET /notifications/{id}.
+//   - `scheduled` — not sent yet. It surfaces once its send_at fires and the
+//     worker moves it on; until then it is the sender's, not the recipient's.
+//   - `cancelled` — a scheduled send withdrawn before it fired. Never sent.
+//   - `collapsed` — replaced by a newer send under the same collapse_key, which
+//     carries the content now. See UpdateCollapsing.
+//   - `throttled` — over its catalog entry's frequency cap; never delivered.
+//   - `recalled` — pulled back by the sender after it went out. See Recall.
+//   - anything past its `expires_at`, whatever its status. Stale by the
+//     sender's own account; the worker purges it after a grace period.
 //
 // The operator's views deliberately do NOT use this — the console notifications
-// list and the recipient detail panel show all three, because "why didn't they
+// list and the recipient detail panel show all of them, because "why didn't they
 // get it?" is answered by exactly the rows this hides. See ListNotifications.
-const recipientFeedVisible = `status NOT IN ('muted', 'quota_exceeded', 'not_requested')`
+const recipientFeedVisible = `(status NOT IN ('muted', 'quota_exceeded', 'not_requested', 'scheduled', 'cancelled', 'collapsed', 'throttled', 'recalled')
+	AND (expires_at IS NULL OR expires_at > now()))`
+
+// recipientNotSnoozed hides a snoozed row until its snooze ends, from every view
+// and count. Like expiry, it is read against now(), so a snooze ends on time
+// without anything having to wake it.
+const recipientNotSnoozed = `(snoozed_until IS NULL OR snoozed_until <= now())`
+
+// recipientFeedView narrows recipientFeedVisible to one of the recipient's two
+// views: the inbox, or the archive.
+func recipientFeedView(archived bool) string {
+	if archived {
+		return `(archived_at IS NOT NULL AND ` + recipientNotSnoozed + `)`
+	}
+	return `(archived_at IS NULL AND ` + recipientNotSnoozed + `)`
+}
+
+// recipientUnread is what both unread counts count: a visible row in the view
+// that has not been read. Snoozing clears read_at when the snooze is set, not
+// when it ends, so it is recipientFeedView's snooze check that keeps a snoozed
+// row out of the counts until it wakes, rather than the read_at test.
+func recipientUnread(archived bool) string {
+	return `(read_at IS NULL AND ` + recipientFeedVisible + ` AND ` + recipientFeedView(archived) + `)`
+}
+
+// feedPosition is where a row sits in the recipient's feed: when it was sent,
+// or when its snooze ended, which is how a woken notification comes back on
+// top. Ties, and there are many in a broadcast, are broken by id.
+const feedPosition = `COALESCE(snoozed_until, created_at)`
+
+// notificationColumns is the projection every full-row read uses, in the order
+// scanNotification reads it. The email delivery summary is attached separately
+// where a method needs it.
+const notificationColumns = `id, project_id, recipient_external_id, payload, broadcast_id, channel, topic, event,
+	read_at, opened_at, created_at, updated_at, completed_at, status, send_at, expires_at, collapse_key,
+	priority, version, archived_at, snoozed_until, seen_at`
+
+func scanNotification(row scannable) (*entity.Notification, error) {
+	var n entity.Notification
+	err := row.Scan(&n.ID, &n.ProjectID, &n.RecipientExtID, &n.Payload, &n.BroadcastID, &n.Channel,
+		&n.Topic, &n.Event, &n.ReadAt, &n.OpenedAt, &n.CreatedAt, &n.UpdatedAt, &n.CompletedAt,
+		&n.Status, &n.SendAt, &n.ExpiresAt, &n.CollapseKey, &n.Priority, &n.Version,
+		&n.ArchivedAt, &n.SnoozedUntil, &n.SeenAt)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
 
 type NotificationRepo struct {
 	db   dbx.DBExecutor
@@ -50,44 +107,35 @@ func (r *NotificationRepo) Create(ctx context.Context, notification *entity.Noti
 	sql := `
 		INSERT INTO notification (
 			project_id, recipient_external_id, payload, broadcast_id, channel,
-			topic, event, read_at, opened_at, created_at, updated_at, completed_at, status
+			topic, event, read_at, opened_at, created_at, updated_at, completed_at, status, send_at, expires_at,
+			collapse_key, priority
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-		RETURNING id, project_id, recipient_external_id, payload, broadcast_id, channel, topic, event,
-		read_at, opened_at, created_at, updated_at, completed_at, status
-	`
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING ` + notificationColumns
 
 	row := r.db.QueryRow(ctx, sql, notification.ProjectID, notification.RecipientExtID, notification.Payload,
 		notification.BroadcastID, notification.Channel, notification.Topic, notification.Event,
 		notification.ReadAt, notification.OpenedAt, notification.CreatedAt, notification.UpdatedAt,
-		notification.CompletedAt, notification.Status,
+		notification.CompletedAt, notification.Status, notification.SendAt, notification.ExpiresAt,
+		notification.CollapseKey, notification.Priority,
 	)
 
-	var newNotification entity.Notification
-	err := row.Scan(&newNotification.ID, &newNotification.ProjectID, &newNotification.RecipientExtID,
-		&newNotification.Payload, &newNotification.BroadcastID, &newNotification.Channel, &newNotification.Topic,
-		&newNotification.Event, &newNotification.ReadAt, &newNotification.OpenedAt, &newNotification.CreatedAt,
-		&newNotification.UpdatedAt, &newNotification.CompletedAt, &newNotification.Status,
-	)
+	newNotification, err := scanNotification(row)
 	if err != nil {
 		return nil, fmt.Errorf("insert notification: %w", err)
 	}
 
-	return &newNotification, nil
+	return newNotification, nil
 }
 
 func (r *NotificationRepo) Get(ctx context.Context, projectID, id int) (*entity.Notification, error) {
 	sql := `
-		SELECT id, project_id, recipient_external_id, payload, broadcast_id, channel, topic, event,
-		       read_at, opened_at, created_at, updated_at, completed_at, status
+		SELECT ` + notificationColumns + `
 		FROM notification
 		WHERE id = $1 AND project_id = $2
 	`
 
-	var n entity.Notification
-	err := r.db.QueryRow(ctx, sql, id, projectID).Scan(&n.ID, &n.ProjectID, &n.RecipientExtID,
-		&n.Payload, &n.BroadcastID, &n.Channel, &n.Topic, &n.Event, &n.ReadAt, &n.OpenedAt,
-		&n.CreatedAt, &n.UpdatedAt, &n.CompletedAt, &n.Status)
+	n, err := scanNotification(r.db.QueryRow(ctx, sql, id, projectID))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, tantraRepo.ErrNotFound
@@ -115,7 +163,7 @@ func (r *NotificationRepo) Get(ctx context.Context, projectID, id int) (*entity.
 		return nil, fmt.Errorf("query email delivery: %w", derr)
 	}
 
-	return &n, nil
+	return n, nil
 }
 
 // BatchCreateTx inserts notifications and back-fills their IDs.
@@ -125,20 +173,38 @@ func (r *NotificationRepo) Get(ctx context.Context, projectID, id int) (*entity.
 // there is no way to record an email outcome per recipient. That — not
 // idempotency — is why the insert had to stop being a COPY (see
 // agent-docs/overview.md §Open/next).
-//
-// ⚠️ IDs are matched back by recipient_external_id, NOT by the order of RETURNING.
-// Postgres does not guarantee RETURNING order matches input order, and relying on
-// it would misattribute every delivery row the first time the planner chose
-// differently — silently mailing the right content to the wrong person's row. The
-// mapping is safe because a broadcast batch holds each recipient at most once
-// (its ids come from ListEligibleRecipientExtIDsForBroadcast, which is distinct
-// by recipient).
 func (r *NotificationRepo) BatchCreateTx(ctx context.Context, tx pgx.Tx, notifications []*entity.Notification) error {
+	return batchCreateNotifications(ctx, tx, notifications)
+}
+
+// BatchCreate is BatchCreateTx for a caller with no transaction of its own (the
+// batch send endpoint). It is still all-or-nothing: the rows go in as a single
+// INSERT.
+func (r *NotificationRepo) BatchCreate(ctx context.Context, notifications []*entity.Notification) error {
+	return batchCreateNotifications(ctx, r.db, notifications)
+}
+
+// batchCreateNotifications allocates the ids up front and inserts them
+// explicitly, so each entity has its id before the INSERT runs.
+//
+// ⚠️ NOT matched back from RETURNING. Postgres does not guarantee RETURNING order
+// matches input order, and relying on it would misattribute every delivery row the
+// first time the planner chose differently — silently mailing the right content
+// to the wrong person's row. The ids used to be matched back by
+// recipient_external_id instead, which only held while every batch named each
+// recipient once; a batch send may name the same recipient many times.
+func batchCreateNotifications(ctx context.Context, db dbx.DBExecutor, notifications []*entity.Notification) error {
 	if len(notifications) == 0 {
 		return nil
 	}
 
 	n := len(notifications)
+
+	ids, err := allocateNotificationIDs(ctx, db, n)
+	if err != nil {
+		return err
+	}
+
 	projectIDs := make([]int, n)
 	extIDs := make([]string, n)
 	payloads := make([][]byte, n)
@@ -152,10 +218,14 @@ func (r *NotificationRepo) BatchCreateTx(ctx context.Context, tx pgx.Tx, notific
 	updatedAt := make([]time.Time, n)
 	completedAt := make([]*time.Time, n)
 	statuses := make([]string, n)
-
-	byExtID := make(map[string]*entity.Notification, n)
+	sendAt := make([]*time.Time, n)
+	expiresAt := make([]*time.Time, n)
+	collapseKeys := make([]*string, n)
+	priorities := make([]string, n)
 
 	for i, notification := range notifications {
+		notification.ID = ids[i]
+
 		projectIDs[i] = notification.ProjectID
 		extIDs[i] = notification.RecipientExtID
 		payloads[i] = notification.Payload
@@ -169,57 +239,64 @@ func (r *NotificationRepo) BatchCreateTx(ctx context.Context, tx pgx.Tx, notific
 		updatedAt[i] = notification.UpdatedAt
 		completedAt[i] = notification.CompletedAt
 		statuses[i] = string(notification.Status)
-
-		byExtID[notification.RecipientExtID] = notification
+		sendAt[i] = notification.SendAt
+		expiresAt[i] = notification.ExpiresAt
+		collapseKeys[i] = notification.CollapseKey
+		priorities[i] = string(notification.Priority)
 	}
 
 	sql := `
 		INSERT INTO notification (
-			project_id, recipient_external_id, payload, broadcast_id,
-			channel, topic, event, read_at, opened_at, created_at, updated_at, completed_at, status
+			id, project_id, recipient_external_id, payload, broadcast_id,
+			channel, topic, event, read_at, opened_at, created_at, updated_at, completed_at, status, send_at,
+			expires_at, collapse_key, priority
 		)
 		SELECT * FROM unnest(
-			$1::int[], $2::text[], $3::jsonb[], $4::int[],
-			$5::text[], $6::text[], $7::text[], $8::timestamptz[], $9::timestamptz[],
-			$10::timestamptz[], $11::timestamptz[], $12::timestamptz[], $13::text[]
+			$1::int[], $2::int[], $3::text[], $4::jsonb[], $5::int[],
+			$6::text[], $7::text[], $8::text[], $9::timestamptz[], $10::timestamptz[],
+			$11::timestamptz[], $12::timestamptz[], $13::timestamptz[], $14::text[], $15::timestamptz[],
+			$16::timestamptz[], $17::text[], $18::text[]
 		)
-		RETURNING id, recipient_external_id
 	`
 
-	rows, err := tx.Query(ctx, sql,
-		projectIDs, extIDs, payloads, broadcastIDs,
-		channels, topics, events, readAt, openedAt, createdAt, updatedAt, completedAt, statuses,
+	tag, err := db.Exec(ctx, sql,
+		ids, projectIDs, extIDs, payloads, broadcastIDs,
+		channels, topics, events, readAt, openedAt, createdAt, updatedAt, completedAt, statuses, sendAt,
+		expiresAt, collapseKeys, priorities,
 	)
 	if err != nil {
 		return fmt.Errorf("insert notifications: %w", err)
 	}
-	defer rows.Close()
-
-	var inserted int
 
-	for rows.Next() {
-		var id int
-		var extID string
-
-		if err := rows.Scan(&id, &extID); err != nil {
-			return fmt.Errorf("scan inserted notification: %w", err)
-		}
+	if inserted := tag.RowsAffected(); inserted != int64(n) {
+		return fmt.Errorf("inserted %d notifications, expected %d", inserted, n)
+	}
 
-		if notification, ok := byExtID[extID]; ok {
-			notification.ID = id
-		}
+	return nil
+}
 
-		inserted++
+// allocateNotificationIDs draws n ids from notification's own sequence, in one
+// round trip. Gaps from a rolled-back batch are fine: the ids are unique, not
+// dense, exactly as they are for a rolled-back single INSERT.
+func allocateNotificationIDs(ctx context.Context, db dbx.DBExecutor, n int) ([]int, error) {
+	rows, err := db.Query(ctx, `
+		SELECT nextval(pg_get_serial_sequence('notification', 'id'))::int
+		FROM generate_series(1, $1)
+	`, n)
+	if err != nil {
+		return nil, fmt.Errorf("allocate notification ids: %w", err)
 	}
-	if err := rows.Err(); err != nil {
-		return fmt.Errorf("read inserted notifications: %w", err)
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("read allocated notification ids: %w", err)
 	}
 
-	if inserted != n {
-		return fmt.Errorf("inserted %d notifications, expected %d", inserted, n)
+	if len(ids) != n {
+		return nil, fmt.Errorf("allocated %d notification ids, expected %d", len(ids), n)
 	}
 
-	return nil
+	return ids, nil
 }
 
 func (r *NotificationRepo) Overview(ctx context.Context, projectID int) (*dto.NotificationsOverviewResult, error) {
@@ -258,14 +335,9 @@ func (r *NotificationRepo) Overview(ctx context.Context, projectID int) (*dto.No
 // sat here for a long time and was never applied — a comment is not a migration,
 // and no runner is wired in. Its leading `id DESC` could not seek to a project
 // anyway. Migrations live in migrations/, applied with goose.)
-func (r *NotificationRepo) ListForRecipient(ctx context.Context, projectID int, recipientExtID string, cursor *query.Cursor) ([]*entity.Notification, *query.Cursor, error) {
-	returnedCursor := &query.Cursor{
-		After:  nil,
-		Before: nil,
-	}
-
+func (r *NotificationRepo) ListForRecipient(ctx context.Context, projectID int, recipientExtID string, filters *dto.RecipientFeedFilters, cursor *query.Cursor) ([]*entity.Notification, *query.Cursor, error) {
 	b := dbx.NewSQLBuilder(`
-		SELECT id, project_id, recipient_external_id, payload, broadcast_id, channel, topic, event, read_at, opened_at, created_at, updated_at, completed_at, status
+		SELECT ` + notificationColumns + `
 		FROM notification
 	`)
 	b.AddCompareFilter("project_id", dbx.OperatorEQ, projectID)
@@ -273,16 +345,22 @@ func (r *NotificationRepo) ListForRecipient(ctx context.Context, projectID int,
 	// Only surface notifications that were actually delivered (or are still in
 	// flight) — see recipientFeedVisible.
 	b.AppendWhere(recipientFeedVisible)
+	applyRecipientFeedFilters(b, projectID, recipientExtID, filters)
 
+	// Cursors are still notification ids, so a client's cursor and an SSE
+	// Last-Event-ID keep working. A cursor whose row is gone pages from the
+	// top, or replays from the start: a page seen twice rather than one never
+	// seen.
 	if cursor.BeforeIsValid() && !cursor.AfterIsValid() {
-		b.AddCompareFilter("id", dbx.OperatorLT, cursor.Before)
+		addFeedPositionFilter(b, projectID, recipientExtID, "<", *cursor.Before, "infinity")
 	}
 
 	if cursor.AfterIsValid() && !cursor.BeforeIsValid() {
-		b.AddCompareFilter("id", dbx.OperatorGT, cursor.After)
+		addFeedPositionFilter(b, projectID, recipientExtID, ">", *cursor.After, "-infinity")
 	}
 
-	b.AddSorting("id", "DESC")
+	// The builder's sorting takes one column.
+	b.AddSorting(feedPosition+" DESC, id", "DESC")
 	b.AddPagination(*cursor.Limit+1, 0) // Overfetch by 1 to determine if there are more notifications.
 
 	sql, args := b.Build()
@@ -296,17 +374,12 @@ func (r *NotificationRepo) ListForRecipient(ctx context.Context, projectID int,
 
 	notifications := []*entity.Notification{}
 	for rows.Next() {
-		var notification entity.Notification
-
-		err := rows.Scan(&notification.ID, &notification.ProjectID, &notification.RecipientExtID,
-			&notification.Payload, &notification.BroadcastID, &notification.Channel, &notification.Topic,
-			&notification.Event, &notification.ReadAt, &notification.OpenedAt, &notification.CreatedAt,
-			&notification.UpdatedAt, &notification.CompletedAt, &notification.Status)
+		notification, err := scanNotification(rows)
 		if err != nil {
 			return nil, nil, fmt.Errorf("scan: %w", err)
 		}
 
-		notifications = append(notifications, &notification)
+		notifications = append(notifications, notification)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -320,35 +393,221 @@ func (r *NotificationRepo) ListForRecipient(ctx context.Context, projectID int,
 		notifications = notifications[:*cursor.Limit]
 	}
 
+	returnedCursor := &query.Cursor{}
 	if len(notifications) > 0 {
-		firstNotification := notifications[0]
-		lastNotification := notifications[len(notifications)-1]
+		returnedCursor = feedCursor(cursor, notifications[0].ID, notifications[len(notifications)-1].ID, hasMore)
+	}
+
+	return notifications, returnedCursor, nil
+}
+
+// ListGroupsForRecipient groups the filtered feed in SQL and joins each group
+// to its newest row, so a page costs one query however big the groups are.
+//
+// A group's newest row is its first in feed order, and the groups are sorted
+// and paged by that row's feedPosition exactly as the plain feed is, with its
+// id as the cursor. So a woken snooze brings its group back to the top, as it
+// does the notification. A notification landing in a group moves it to the
+// top too, so a client paging while the feed changes can see a group again on
+// a later page — the same trade the plain feed makes for new rows, and a
+// client keying groups by their key already copes.
+func (r *NotificationRepo) ListGroupsForRecipient(ctx context.Context, projectID int, recipientExtID string, filters *dto.RecipientFeedFilters, cursor *query.Cursor) ([]*entity.NotificationGroup, *query.Cursor, error) {
+	keys := []string{"channel", "topic"}
+	if filters.GroupBy == dto.FeedGroupByTarget {
+		keys = append(keys, "event")
+	}
+
+	// The group key is not selected: the newest row carries it.
+	b := dbx.NewSQLBuilder(`
+		SELECT COUNT(*) AS group_count,
+			COUNT(*) FILTER (WHERE read_at IS NULL) AS group_unread_count,
+			(array_agg(id ORDER BY ` + feedPosition + ` DESC, id DESC))[1] AS newest_id
+		FROM notification
+	`)
+	b.AddCompareFilter("project_id", dbx.OperatorEQ, projectID)
+	b.AddCompareFilter("recipient_external_id", dbx.OperatorEQ, recipientExtID)
+	b.AppendWhere(recipientFeedVisible)
+	applyRecipientFeedFilters(b, projectID, recipientExtID, filters)
+	b.AddGroupBy(keys...)
+
+	grouped, args := b.Build()
+
+	// The builder has no HAVING, so the cursor goes on the outer query, where
+	// the unqualified columns are the newest row's.
+	page := ""
+	if cursor.BeforeIsValid() && !cursor.AfterIsValid() {
+		args = append(args, *cursor.Before, projectID, recipientExtID)
+		page = "WHERE " + feedPositionCompare("<", len(args)-2, "infinity")
+	}
+	if cursor.AfterIsValid() && !cursor.BeforeIsValid() {
+		args = append(args, *cursor.After, projectID, recipientExtID)
+		page = "WHERE " + feedPositionCompare(">", len(args)-2, "-infinity")
+	}
 
-		before := fmt.Sprintf("%d", lastNotification.ID)
-		after := fmt.Sprintf("%d", firstNotification.ID)
+	sql := fmt.Sprintf(`
+		SELECT g.group_count, g.group_unread_count, %s
+		FROM (%s) g
+		JOIN notification ON notification.id = g.newest_id
+		%s
+		ORDER BY %s DESC, id DESC
+		LIMIT %d
+	`, notificationColumns, grouped, page, feedPosition, *cursor.Limit+1)
+
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
 
-		if hasMore {
-			returnedCursor.Before = &before
+	groups := []*entity.NotificationGroup{}
+	for rows.Next() {
+		var g entity.NotificationGroup
+		newest, err := scanNotification(leadingColumns{rows, []any{&g.Count, &g.UnreadCount}})
+		if err != nil {
+			return nil, nil, fmt.Errorf("scan: %w", err)
 		}
 
-		// We are not at the start of  list if we have a 'before' cursor
-		// that means there are newer items than the current first item.
-		if cursor.BeforeIsValid() && !cursor.AfterIsValid() {
-			returnedCursor.After = &after
+		g.Channel, g.Topic, g.Newest = newest.Channel, newest.Topic, newest
+		if filters.GroupBy == dto.FeedGroupByTarget {
+			g.Event = newest.Event
 		}
+		groups = append(groups, &g)
 	}
 
-	return notifications, returnedCursor, nil
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	hasMore := false
+	if len(groups) > *cursor.Limit {
+		hasMore = true
+		groups = groups[:*cursor.Limit]
+	}
+
+	returnedCursor := &query.Cursor{}
+	if len(groups) > 0 {
+		returnedCursor = feedCursor(cursor, groups[0].Newest.ID, groups[len(groups)-1].Newest.ID, hasMore)
+	}
+
+	return groups, returnedCursor, nil
+}
+
+// applyRecipientFeedFilters narrows a query already scoped to a recipient's
+// visible feed to one of its views, and to what the filters ask for. Nil
+// filters are the whole inbox.
+func applyRecipientFeedFilters(b *dbx.SQLBuilder, projectID int, recipientExtID string, filters *dto.RecipientFeedFilters) {
+	if filters == nil {
+		b.AppendWhere(recipientFeedView(false))
+		return
+	}
+
+	b.AppendWhere(recipientFeedView(filters.Archived))
+
+	if filters.Channel != nil {
+		b.AddCompareFilter("channel", dbx.OperatorEQ, *filters.Channel)
+	}
+	if filters.Topic != nil {
+		b.AddCompareFilter("topic", dbx.OperatorEQ, *filters.Topic)
+	}
+	if filters.Event != nil {
+		b.AddCompareFilter("event", dbx.OperatorEQ, *filters.Event)
+	}
+
+	if filters.Read != nil {
+		if *filters.Read {
+			b.AppendWhere("read_at IS NOT NULL")
+		} else {
+			b.AppendWhere("read_at IS NULL")
+		}
+	}
+	if filters.Opened != nil {
+		if *filters.Opened {
+			b.AppendWhere("opened_at IS NOT NULL")
+		} else {
+			b.AppendWhere("opened_at IS NULL")
+		}
+	}
+	if filters.Seen != nil {
+		if *filters.Seen {
+			b.AppendWhere("seen_at IS NOT NULL")
+		} else {
+			b.AppendWhere("seen_at IS NULL")
+		}
+	}
+
+	// A gone id keeps nothing: an update scoped to it must not widen to all.
+	if filters.UntilID != nil {
+		addFeedPositionFilter(b, projectID, recipientExtID, "<=", *filters.UntilID, "-infinity")
+	}
+
+	// Dereferenced for the reason given in ListNotifications.
+	if filters.CreatedFrom != nil {
+		b.AddCompareFilter("created_at", dbx.OperatorGTE, *filters.CreatedFrom)
+	}
+	if filters.CreatedTo != nil {
+		b.AddCompareFilter("created_at", dbx.OperatorLTE, *filters.CreatedTo)
+	}
+}
+
+// addFeedPositionFilter compares rows' feedPosition with that of notification
+// id, looked up in the same query. missing stands in for the position of an id
+// that is no longer there — or was never the recipient's: the lookup is scoped
+// like the feed, so a cursor naming someone else's notification positions
+// nothing by it, and reveals nothing about when it was sent.
+func addFeedPositionFilter(b *dbx.SQLBuilder, projectID int, recipientExtID string, operator string, id any, missing string) {
+	b.AppendWhere(feedPositionCompare(operator, b.ArgNum(), missing), id, projectID, recipientExtID)
+}
+
+// feedPositionCompare is addFeedPositionFilter's condition, for a query built
+// by hand: the id, project and recipient are arguments n, n+1 and n+2.
+func feedPositionCompare(operator string, n int, missing string) string {
+	return fmt.Sprintf(`(%[1]s, id) %[2]s (COALESCE((
+			SELECT %[1]s FROM notification
+			WHERE id = $%[3]d AND project_id = $%[5]d AND recipient_external_id = $%[6]d
+		), '%[4]s'), $%[3]d)`,
+		feedPosition, operator, n, missing, n+1, n+2)
+}
+
+// feedCursor is the cursor returned with a page of the feed, given the ids of
+// its first and last rows.
+func feedCursor(cursor *query.Cursor, firstID, lastID int, hasMore bool) *query.Cursor {
+	returnedCursor := &query.Cursor{}
+
+	before := fmt.Sprintf("%d", lastID)
+	after := fmt.Sprintf("%d", firstID)
+
+	if hasMore {
+		returnedCursor.Before = &before
+	}
+
+	// We are not at the start of  list if we have a 'before' cursor
+	// that means there are newer items than the current first item.
+	if cursor.BeforeIsValid() && !cursor.AfterIsValid() {
+		returnedCursor.After = &after
+	}
+
+	return returnedCursor
+}
+
+// leadingColumns scans columns selected ahead of a row's usual projection into
+// dest, and the rest through the wrapped row.
+type leadingColumns struct {
+	row  scannable
+	dest []any
+}
+
+func (l leadingColumns) Scan(dest ...any) error {
+	return l.row.Scan(append(l.dest, dest...)...)
 }
 
-func (r *NotificationRepo) UnreadCountForRecipient(ctx context.Context, projectID int, recipientExtID string) (int, error) {
+func (r *NotificationRepo) UnreadCountForRecipient(ctx context.Context, projectID int, recipientExtID string, archived bool) (int, error) {
 	// Must stay in lockstep with ListForRecipient's predicate: a badge counting
 	// rows the feed will not show is a bug the user experiences as an unread
 	// count they cannot clear.
 	sql := `
 		SELECT COUNT(*) FROM notification
-		WHERE project_id = $1 AND recipient_external_id = $2 AND read_at IS NULL
-		  AND ` + recipientFeedVisible + `
+		WHERE project_id = $1 AND recipient_external_id = $2
+		  AND ` + recipientUnread(archived) + `
 	`
 	var count int
 
@@ -360,6 +619,39 @@ func (r *NotificationRepo) UnreadCountForRecipient(ctx context.Context, projectI
 	return count, nil
 }
 
+// UnreadCountsByChannelForRecipient is under the same lockstep rule as
+// UnreadCountForRecipient.
+func (r *NotificationRepo) UnreadCountsByChannelForRecipient(ctx context.Context, projectID int, recipientExtID string, archived bool) (map[string]int, error) {
+	sql := `
+		SELECT channel, COUNT(*) FROM notification
+		WHERE project_id = $1 AND recipient_external_id = $2
+		  AND ` + recipientUnread(archived) + `
+		GROUP BY channel
+	`
+
+	rows, err := r.db.Query(ctx, sql, projectID, recipientExtID)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var channel string
+		var count int
+		if err := rows.Scan(&channel, &count); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		counts[channel] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+
+	return counts, nil
+}
+
 // StatusRollupForBroadcast returns the per-status notification counts for one
 // broadcast — the in_app branch of the console's delivery tree.
 //
@@ -400,6 +692,20 @@ func (r *NotificationRepo) StatusRollupForBroadcast(ctx context.Context, broadca
 	return rollup, nil
 }
 
+// ListForBroadcast pages one broadcast's notifications in a status, by id.
+// Like StatusRollupForBroadcast it is keyed by broadcast_id alone, so the
+// caller owns the project check.
+func (r *NotificationRepo) ListForBroadcast(ctx context.Context, broadcastID int, status enum.NotificationStatus, afterID, limit int) ([]*entity.Notification, error) {
+	sql := `
+		SELECT ` + notificationColumns + `
+		FROM notification
+		WHERE broadcast_id = $1 AND status = $2 AND id > $3
+		ORDER BY id
+		LIMIT $4
+	`
+	return r.listNotifications(ctx, sql, broadcastID, status, afterID, limit)
+}
+
 // CountStuck counts notifications stalled in `enqueued` across all projects.
 // See the interface doc in model/repository/notification.go for why it is not
 // project-scoped.
@@ -436,7 +742,7 @@ func (r *NotificationRepo) CountStuck(ctx context.Context, olderThan, newerThan
 	return count, nil
 }
 
-func (r *NotificationRepo) UpdateForRecipient(ctx context.Context, projectID int, recipientExtID string, payload dto.UpdateRecipientNotificationsPayload) (int, error) {
+func (r *NotificationRepo) UpdateForRecipient(ctx context.Context, projectID int, recipientExtID string, payload dto.UpdateRecipientNotificationsPayload) ([]int, error) {
 	sql := `
 		UPDATE notification
 	`
@@ -476,9 +782,46 @@ func (r *NotificationRepo) UpdateForRecipient(ctx context.Context, projectID int
 		}
 	}
 
-	if len(payload.IDs) == 0 {
-		// If no notification IDs are provided, update all notifications for the recipient.
-	} else {
+	if payload.State.Seen != nil {
+		if *payload.State.Seen {
+			b.SetColumn("seen_at", now)
+		} else {
+			b.SetColumn("seen_at", nil)
+		}
+	}
+
+	if payload.Archived != nil {
+		if *payload.Archived {
+			b.SetColumn("archived_at", now)
+		} else {
+			b.SetColumn("archived_at", nil)
+		}
+	}
+
+	if payload.SnoozeUntil != nil {
+		until := payload.SnoozeUntil.UTC()
+		if until.Before(now) {
+			until = now
+		}
+		b.SetColumn("snoozed_until", until)
+		// Validate has already refused a snooze that also marks read.
+		if payload.State.Read == nil {
+			b.SetColumn("read_at", nil)
+		}
+	}
+
+	if payload.Filters != nil {
+		// The filters bring their view with them, which already leaves out the
+		// snoozed.
+		applyRecipientFeedFilters(b, projectID, recipientExtID, payload.Filters)
+	} else if len(payload.IDs) == 0 {
+		// If no notification IDs are provided, update all notifications for the
+		// recipient — bar the snoozed ones, which the recipient cannot see right
+		// now and which must still come back unread.
+		b.AppendWhere(recipientNotSnoozed)
+	}
+
+	if len(payload.IDs) > 0 {
 		// Update only specific notifications
 		ids := make([]any, len(payload.IDs))
 		for i, id := range payload.IDs {
@@ -488,13 +831,22 @@ func (r *NotificationRepo) UpdateForRecipient(ctx context.Context, projectID int
 	}
 
 	sql, args := b.Build()
+	// The ids are what the streams announce: an update by filter names no ids
+	// up front, and a client cannot work out from the filter which of its rows
+	// changed.
+	sql += " RETURNING id"
 
-	res, err := r.db.Exec(ctx, sql, args...)
+	rows, err := r.db.Query(ctx, sql, args...)
 	if err != nil {
-		return 0, fmt.Errorf("update notifications for recipient: %w", err)
+		return nil, fmt.Errorf("update notifications for recipient: %w", err)
 	}
 
-	return int(res.RowsAffected()), nil
+	updated, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, fmt.Errorf("update notifications for recipient: %w", err)
+	}
+
+	return updated, nil
 }
 
 // DeleteForRecipient deliberately does NOT apply recipientFeedVisible, unlike
@@ -546,6 +898,85 @@ func (r *NotificationRepo) DeleteForProject(ctx context.Context, projectID int)
 	return int(res.RowsAffected()), nil
 }
 
+// DeleteExpired purges up to limit notifications whose expires_at is before
+// cutoff, across every project, and returns how many went. Their delivery rows
+// go with them (ON DELETE CASCADE).
+//
+// Bounded per call so a backlog of expired rows is worked off in short
+// statements rather than one long DELETE holding locks on a hot table; the
+// caller repeats until a call comes back short. Served by ix_notification_expires_at.
+func (r *NotificationRepo) DeleteExpired(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	sql := `
+		DELETE FROM notification
+		WHERE id IN (
+			SELECT id FROM notification
+			WHERE expires_at IS NOT NULL AND expires_at < $1
+			ORDER BY expires_at
+			LIMIT $2
+		)
+	`
+	res, err := r.db.Exec(ctx, sql, cutoff, limit)
+	if err != nil {
+		return 0, fmt.Errorf("delete expired notifications: %w", err)
+	}
+	return res.RowsAffected(), nil
+}
+
+// ListExpiredBetween pages, across projects, the delivered notifications whose
+// expires_at fell in (from, to] — the ones that left a feed in that window
+// without anything writing to them. Paged by id, which is stable because the
+// window is fixed. Served by ix_notification_expires_at.
+func (r *NotificationRepo) ListExpiredBetween(ctx context.Context, from, to time.Time, afterID, limit int) ([]*entity.Notification, error) {
+	sql := `
+		SELECT ` + notificationColumns + `
+		FROM notification
+		WHERE expires_at > $1 AND expires_at <= $2 AND status = 'delivered' AND id > $3
+		ORDER BY id
+		LIMIT $4
+	`
+	return r.listNotifications(ctx, sql, from, to, afterID, limit)
+}
+
+// ListWokenBetween pages, across projects, the notifications whose snooze
+// ended in (from, to] and that are back in an inbox now — the ones that came
+// back to a feed in that window without anything writing to them. Paged by id,
+// like ListExpiredBetween. Served by ix_notification_snoozed_until.
+func (r *NotificationRepo) ListWokenBetween(ctx context.Context, from, to time.Time, afterID, limit int) ([]*entity.Notification, error) {
+	sql := `
+		SELECT ` + notificationColumns + `
+		FROM notification
+		WHERE snoozed_until > $1 AND snoozed_until <= $2 AND status = 'delivered' AND id > $3
+		  AND ` + recipientFeedVisible + `
+		  AND ` + recipientFeedView(false) + `
+		ORDER BY id
+		LIMIT $4
+	`
+	return r.listNotifications(ctx, sql, from, to, afterID, limit)
+}
+
+// listNotifications runs a full-row read and scans every row.
+func (r *NotificationRepo) listNotifications(ctx context.Context, sql string, args ...any) ([]*entity.Notification, error) {
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*entity.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows: %w", err)
+	}
+
+	return notifications, nil
+}
+
 // escapeLikeNeedle makes a user-typed search term match literally, by escaping
 // the wildcards LIKE/ILIKE would otherwise honour inside it.
 //
@@ -564,7 +995,7 @@ func escapeLikeNeedle(s string) string {
 
 func (r *NotificationRepo) ListNotifications(ctx context.Context, filters *dto.ListNotificationsFilters) ([]*entity.Notification, int, error) {
 	sql := `
-		SELECT id, project_id, recipient_external_id, payload, broadcast_id, channel, topic, event, read_at, opened_at, created_at, updated_at, completed_at, status
+		SELECT ` + notificationColumns + `
 		FROM notification
 	`
 
@@ -660,17 +1091,12 @@ func (r *NotificationRepo) ListNotifications(ctx context.Context, filters *dto.L
 
 	notifications := []*entity.Notification{}
 	for rows.Next() {
-		var notification entity.Notification
-
-		err := rows.Scan(&notification.ID, &notification.ProjectID, &notification.RecipientExtID,
-			&notification.Payload, &notification.BroadcastID, &notification.Channel, &notification.Topic,
-			&notification.Event, &notification.ReadAt, &notification.OpenedAt, &notification.CreatedAt,
-			&notification.UpdatedAt, &notification.CompletedAt, &notification.Status)
+		notification, err := scanNotification(rows)
 		if err != nil {
 			return nil, 0, fmt.Errorf("scan: %w", err)
 		}
 
-		notifications = append(notifications, &notification)
+		notifications = append(notifications, notification)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -736,16 +1162,282 @@ func (r *NotificationRepo) ListNotifications(ctx context.Context, filters *dto.L
 }
 
 func (r *NotificationRepo) Update(ctx context.Context, notification *entity.Notification) error {
+	return updateNotification(ctx, r.db, notification)
+}
+
+// updateNotification leaves a recalled row alone: the worker writes the in-app
+// outcome from a snapshot taken before a recall could have landed, and must not
+// put the notification back in the feed.
+//
+// It writes the payload only while the row is unedited (version 1), which is
+// how the worker stores the recipient's localized variant. After a Patch the
+// worker's copy is stale, and a retried task must not put it back. An absent
+// payload is never written, so an email-only row keeps its SQL NULL.
+func updateNotification(ctx context.Context, db dbx.DBExecutor, notification *entity.Notification) error {
+	var payload json.RawMessage
+	if dto.IsJSONContent(notification.Payload) {
+		payload = notification.Payload
+	}
+
 	sql := `
 		UPDATE notification
-		SET payload = $3, channel = $4, topic = $5, event = $6, read_at = $7, opened_at = $8,
-		updated_at = $9, completed_at = $10, status = $11
-		WHERE id = $1 AND project_id = $2
+		SET channel = $3, topic = $4, event = $5, read_at = $6, opened_at = $7,
+		updated_at = $8, completed_at = $9, status = $10,
+		payload = CASE WHEN version = 1 AND $11::jsonb IS NOT NULL THEN $11::jsonb ELSE payload END
+		WHERE id = $1 AND project_id = $2 AND status <> 'recalled'
 	`
-	_, err := r.db.Exec(ctx, sql,
-		notification.ID, notification.ProjectID, notification.Payload, notification.Channel,
+	_, err := db.Exec(ctx, sql,
+		notification.ID, notification.ProjectID, notification.Channel,
 		notification.Topic, notification.Event, notification.ReadAt, notification.OpenedAt,
-		notification.UpdatedAt, notification.CompletedAt, notification.Status,
+		notification.UpdatedAt, notification.CompletedAt, notification.Status, payload,
 	)
 	return err
 }
+
+// UpdateCollapsing writes a delivered notification that carries a collapse_key,
+// replacing the recipient's older unread notifications under the same key.
+//
+// The newest notification is the one that survives, because it is the one the
+// feed puts first: the feed is ordered and paginated by feedPosition, and a send
+// being delivered now sits at or above every older row under its key — one whose
+// snooze has ended woke no later than now, and one still snoozed is replaced
+// before it wakes. So the latest payload lands at the top. The ones it replaces
+// move to `collapsed`, which the feed hides.
+//
+// ⚠️ Serialized per (project, recipient, key) with a transaction-scoped advisory
+// lock. Without it, two deliveries under the same key running side by side each
+// find nothing to collapse (neither is `delivered` yet from the other's point of
+// view) and both stay in the feed. Under the lock the second one always sees the
+// first. Task order is not send order — a retry can deliver an older send after
+// a newer one — so a notification that finds a NEWER delivered one under its key
+// collapses itself instead of the other way round.
+//
+// Returns the ids of the older notifications it collapsed: they were in the
+// feed, and open streams have to be told they left it. A notification that
+// collapses itself was never in the feed, so it is not among them.
+func (r *NotificationRepo) UpdateCollapsing(ctx context.Context, notification *entity.Notification) ([]int, error) {
+	if notification.CollapseKey == nil || notification.Status != enum.NotificationStatusDelivered {
+		return nil, r.Update(ctx, notification)
+	}
+
+	var collapsed []int
+	err := dbx.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
+		lockKey := fmt.Sprintf("notification-collapse:%d:%s:%s", notification.ProjectID, notification.RecipientExtID, *notification.CollapseKey)
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended($1, 0))`, lockKey); err != nil {
+			return fmt.Errorf("lock collapse key: %w", err)
+		}
+
+		var superseded bool
+		err := tx.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM notification
+				WHERE project_id = $1 AND recipient_external_id = $2 AND collapse_key = $3
+					AND id > $4 AND status = 'delivered'
+			)
+		`, notification.ProjectID, notification.RecipientExtID, *notification.CollapseKey, notification.ID).Scan(&superseded)
+		if err != nil {
+			return fmt.Errorf("query newer collapsed notification: %w", err)
+		}
+
+		if superseded {
+			notification.Status = enum.NotificationStatusCollapsed
+		} else {
+			rows, err := tx.Query(ctx, `
+				UPDATE notification
+				SET status = 'collapsed', updated_at = $5
+				WHERE project_id = $1 AND recipient_external_id = $2 AND collapse_key = $3
+					AND id < $4 AND status = 'delivered' AND read_at IS NULL
+				RETURNING id
+			`, notification.ProjectID, notification.RecipientExtID, *notification.CollapseKey, notification.ID, notification.UpdatedAt)
+			if err != nil {
+				return fmt.Errorf("collapse older notifications: %w", err)
+			}
+			collapsed, err = pgx.CollectRows(rows, pgx.RowTo[int])
+			if err != nil {
+				return fmt.Errorf("collapse older notifications: %w", err)
+			}
+		}
+
+		if err := updateNotification(ctx, tx, notification); err != nil {
+			return fmt.Errorf("update notification: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return collapsed, nil
+}
+
+// ReleaseScheduled moves a due scheduled notification on to `next`, and returns
+// the status the row is in afterwards.
+//
+// ⚠️ Conditional on the row still being `scheduled`, and that condition is the
+// whole cancellation story: CancelScheduled flips the same row the other way
+// under the same predicate, so exactly one of the two wins and the loser sees
+// the winner's status. When the update matches nothing — cancelled, or released
+// already by an earlier attempt of the same task — the current status is
+// returned instead so the caller can tell which.
+func (r *NotificationRepo) ReleaseScheduled(ctx context.Context, projectID, id int, next enum.NotificationStatus, completedAt *time.Time) (enum.NotificationStatus, error) {
+	sql := `
+		UPDATE notification
+		SET status = $3, completed_at = $4, updated_at = $5
+		WHERE id = $1 AND project_id = $2 AND status = 'scheduled'
+		RETURNING status
+	`
+
+	var status enum.NotificationStatus
+
+	err := r.db.QueryRow(ctx, sql, id, projectID, next, completedAt, time.Now().UTC()).Scan(&status)
+	if err == nil {
+		return status, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return "", fmt.Errorf("release scheduled notification: %w", err)
+	}
+
+	err = r.db.QueryRow(ctx, `SELECT status FROM notification WHERE id = $1 AND project_id = $2`, id, projectID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", tantraRepo.ErrNotFound
+		}
+		return "", fmt.Errorf("query notification status: %w", err)
+	}
+
+	return status, nil
+}
+
+// CancelScheduled withdraws a scheduled notification before it fires. Returns
+// tantra repository.ErrNotFound when the project has no such notification, and
+// ErrConflict when it exists but is no longer scheduled — it has already gone
+// out, or was cancelled before.
+func (r *NotificationRepo) CancelScheduled(ctx context.Context, projectID, id int) (*entity.Notification, error) {
+	now := time.Now().UTC()
+
+	sql := `
+		UPDATE notification
+		SET status = 'cancelled', completed_at = $3, updated_at = $3
+		WHERE id = $1 AND project_id = $2 AND status = 'scheduled'
+		RETURNING ` + notificationColumns
+
+	n, err := scanNotification(r.db.QueryRow(ctx, sql, id, projectID, now))
+	if err == nil {
+		return n, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("cancel scheduled notification: %w", err)
+	}
+
+	var exists bool
+	err = r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM notification WHERE id = $1 AND project_id = $2)`, id, projectID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("query notification: %w", err)
+	}
+	if !exists {
+		return nil, tantraRepo.ErrNotFound
+	}
+
+	return nil, tantraRepo.ErrConflict
+}
+
+// recallableDelivery is the set of email delivery statuses a recall still
+// stops: queued, held for quiet hours, or failed and waiting on an Asynq retry.
+// Anything past that is with the provider already, and keeps its status.
+const recallableDelivery = `status IN ('pending', 'deferred', 'failed')`
+
+// Recall pulls a notification back out of the recipient's feed after it was
+// sent, and stops its email if that has not gone out yet. Returns tantra
+// repository.ErrNotFound when the project has no such notification, and
+// ErrConflict when it is already recalled or was cancelled before it went out.
+//
+// The notification and its deliveries move in one transaction, so a recall is
+// never half-applied. The email:delivery task re-reads its row before sending;
+// see EmailDeliveryProcessor.
+func (r *NotificationRepo) Recall(ctx context.Context, projectID, id int) (*entity.Notification, error) {
+	var recalled *entity.Notification
+
+	err := dbx.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
+		now := time.Now().UTC()
+
+		n, err := scanNotification(tx.QueryRow(ctx, `
+			UPDATE notification
+			SET status = 'recalled', completed_at = COALESCE(completed_at, $3), updated_at = $3
+			WHERE id = $1 AND project_id = $2 AND status NOT IN ('recalled', 'cancelled')
+			RETURNING `+notificationColumns, id, projectID, now))
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("recall notification: %w", err)
+			}
+
+			var exists bool
+			err = tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM notification WHERE id = $1 AND project_id = $2)`, id, projectID).Scan(&exists)
+			if err != nil {
+				return fmt.Errorf("query notification: %w", err)
+			}
+			if !exists {
+				return tantraRepo.ErrNotFound
+			}
+			return tantraRepo.ErrConflict
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE notification_delivery
+			SET status = 'recalled', updated_at = $2
+			WHERE notification_id = $1 AND `+recallableDelivery, n.ID, now)
+		if err != nil {
+			return fmt.Errorf("recall notification deliveries: %w", err)
+		}
+
+		recalled = n
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recalled, nil
+}
+
+// Patch replaces a delivered notification's payload.
+//
+// Only `delivered`: anything earlier is still the worker's, and anything else
+// is not in the feed, so there is nothing on screen to update. The version check
+// rides in the same UPDATE as the write, which is what makes it a real
+// compare-and-swap rather than a read followed by a hopeful write.
+func (r *NotificationRepo) Patch(ctx context.Context, patch *dto.PatchNotificationPayload) (*entity.Notification, error) {
+	sql := `
+		UPDATE notification
+		SET payload = $3, version = version + 1, updated_at = $4,
+			read_at = CASE WHEN $5 THEN NULL ELSE read_at END,
+			opened_at = CASE WHEN $5 THEN NULL ELSE opened_at END
+		WHERE id = $1 AND project_id = $2 AND status = 'delivered'
+			AND ($6::int IS NULL OR version = $6)
+		RETURNING ` + notificationColumns
+
+	n, err := scanNotification(r.db.QueryRow(ctx, sql, patch.NotificationID, patch.ProjectID, patch.Payload,
+		time.Now().UTC(), patch.ResetRead, patch.Version))
+	if err == nil {
+		return n, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("patch notification: %w", err)
+	}
+
+	var status enum.NotificationStatus
+	var version int
+	err = r.db.QueryRow(ctx, `SELECT status, version FROM notification WHERE id = $1 AND project_id = $2`,
+		patch.NotificationID, patch.ProjectID).Scan(&status, &version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, tantraRepo.ErrNotFound
+		}
+		return nil, fmt.Errorf("query notification: %w", err)
+	}
+	if status != enum.NotificationStatusDelivered {
+		return nil, tantraRepo.ErrConflict
+	}
+
+	return nil, enum.ErrVersionMismatch
+}
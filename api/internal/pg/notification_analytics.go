@@ -40,7 +40,14 @@ func (r *NotificationRepo) InAppAnalyticsSeries(ctx context.Context, projectID i
 			-- in no band of the stacked chart, and the series would silently stop
 			-- adding up. They are not muted (the recipient did not opt out) and
 			-- not failed (nothing failed) — in-app was simply never requested.
-			count(*) FILTER (WHERE status = 'not_requested') AS not_requested
+			count(*) FILTER (WHERE status = 'not_requested') AS not_requested,
+			-- Same reasoning: scheduled and cancelled sends are rows too, so they
+			-- get bands rather than silently widening the gap to total.
+			count(*) FILTER (WHERE status = 'scheduled') AS scheduled,
+			count(*) FILTER (WHERE status = 'cancelled') AS cancelled,
+			count(*) FILTER (WHERE status = 'collapsed') AS collapsed,
+			count(*) FILTER (WHERE status = 'throttled') AS throttled,
+			count(*) FILTER (WHERE status = 'recalled') AS recalled
 		FROM notification
 		WHERE project_id = $1
 			AND ($2::timestamptz IS NULL OR created_at >= $2)
@@ -59,7 +66,8 @@ func (r *NotificationRepo) InAppAnalyticsSeries(ctx context.Context, projectID i
 	for rows.Next() {
 		var d dto.AnalyticsInAppDay
 		if err := rows.Scan(&d.Day, &d.Total, &d.Enqueued, &d.Muted, &d.Delivered,
-			&d.QuotaExceeded, &d.Failed, &d.NotRequested); err != nil {
+			&d.QuotaExceeded, &d.Failed, &d.NotRequested, &d.Scheduled, &d.Cancelled, &d.Collapsed,
+			&d.Throttled, &d.Recalled); err != nil {
 			return nil, fmt.Errorf("scan in-app analytics day: %w", err)
 		}
 		series = append(series, d)
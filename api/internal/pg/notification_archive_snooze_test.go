@@ -0,0 +1,201 @@
+package pg
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/tantra/query"
+)
+
+// TestArchiveAndSnooze walks one inbox through an archive and a snooze, against
+// a live Postgres: an archived notification moves between the two views with
+// its unread state, a snoozed one is in neither and survives mark-all-read, and
+// once its snooze is over it is back unread and on top.
+//
+// Skipped unless TEST_DB_URL is set. Self-cleaning.
+func TestArchiveAndSnooze(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set; skipping DB integration test")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	var userID int
+	if err := pool.QueryRow(ctx, `SELECT user_id FROM project ORDER BY id LIMIT 1`).Scan(&userID); err != nil {
+		t.Fatalf("need at least one existing project to borrow a user_id: %v", err)
+	}
+
+	var projectID int
+	err = pool.QueryRow(ctx, `
+		INSERT INTO project (user_id, name, created_at, updated_at)
+		VALUES ($1, 'archive-snooze-test', now(), now()) RETURNING id
+	`, userID).Scan(&projectID)
+	if err != nil {
+		t.Fatalf("insert project: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(ctx, "DELETE FROM project WHERE id = $1", projectID) })
+
+	const extID = "snooze-user"
+	_, err = pool.Exec(ctx, `
+		INSERT INTO recipient (external_id, name, project_id, created_at, updated_at)
+		VALUES ($1, 'Snooze', $2, now(), now())
+	`, extID, projectID)
+	if err != nil {
+		t.Fatalf("insert recipient: %v", err)
+	}
+
+	// Oldest first, a minute apart, all unread.
+	ids := make([]int, 3)
+	for i := range ids {
+		err := pool.QueryRow(ctx, `
+			INSERT INTO notification
+				(project_id, recipient_external_id, payload, channel, topic, event, status, created_at, updated_at)
+			VALUES ($1, $2, '{}', 'posts', 'none', 'reply', 'delivered',
+				now() - make_interval(mins => $3), now())
+			RETURNING id
+		`, projectID, extID, len(ids)-i).Scan(&ids[i])
+		if err != nil {
+			t.Fatalf("insert notification %d: %v", i, err)
+		}
+	}
+
+	repo := NewNotificationRepo(pool)
+	limit := 50
+	feed := func(archived bool) []int {
+		t.Helper()
+		notifs, _, err := repo.ListForRecipient(ctx, projectID, extID, &dto.RecipientFeedFilters{Archived: archived}, &query.Cursor{Limit: &limit})
+		if err != nil {
+			t.Fatalf("list (archived=%v): %v", archived, err)
+		}
+		return notificationIDs(notifs)
+	}
+	unread := func(archived bool) int {
+		t.Helper()
+		count, err := repo.UnreadCountForRecipient(ctx, projectID, extID, archived)
+		if err != nil {
+			t.Fatalf("unread count (archived=%v): %v", archived, err)
+		}
+		return count
+	}
+	unreadByChannel := func() map[string]int {
+		t.Helper()
+		counts, err := repo.UnreadCountsByChannelForRecipient(ctx, projectID, extID, false)
+		if err != nil {
+			t.Fatalf("unread counts by channel: %v", err)
+		}
+		return counts
+	}
+	update := func(payload dto.UpdateRecipientNotificationsPayload) int {
+		t.Helper()
+		updated, err := repo.UpdateForRecipient(ctx, projectID, extID, payload)
+		if err != nil {
+			t.Fatalf("update %+v: %v", payload, err)
+		}
+		return len(updated)
+	}
+	yes := true
+
+	// 1. Archiving moves a notification from one view to the other, unread.
+	update(dto.UpdateRecipientNotificationsPayload{NotificationIDsPayload: dto.NotificationIDsPayload{IDs: ids[:1]}, Archived: &yes})
+	if got := feed(false); !reflect.DeepEqual(got, []int{ids[2], ids[1]}) {
+		t.Errorf("inbox after archiving = %v, want %v", got, []int{ids[2], ids[1]})
+	}
+	if got := feed(true); !reflect.DeepEqual(got, ids[:1]) {
+		t.Errorf("archive = %v, want %v", got, ids[:1])
+	}
+	if in, arch := unread(false), unread(true); in != 2 || arch != 1 {
+		t.Errorf("unread = %d in the inbox and %d archived, want 2 and 1", in, arch)
+	}
+
+	// 2. A snoozed notification is in neither view, and mark-all-read passes it by.
+	later := time.Now().Add(time.Hour)
+	update(dto.UpdateRecipientNotificationsPayload{
+		NotificationIDsPayload: dto.NotificationIDsPayload{IDs: ids[1:2]},
+		SnoozeUntil:            &later,
+	})
+	if got := feed(false); !reflect.DeepEqual(got, ids[2:]) {
+		t.Errorf("inbox while snoozed = %v, want %v", got, ids[2:])
+	}
+	// It is still unread, but neither count includes it until it wakes.
+	if n, by := unread(false), unreadByChannel(); n != 1 || !reflect.DeepEqual(by, map[string]int{"posts": 1}) {
+		t.Errorf("unread while snoozed = %d, by channel %v; want 1 and posts:1, without the snoozed one", n, by)
+	}
+	if n := update(dto.UpdateRecipientNotificationsPayload{State: dto.NotificationStateFilter{Read: &yes}}); n != 2 {
+		t.Errorf("mark-all-read updated %d, want 2: the inbox and the archive, not the snoozed one", n)
+	}
+
+	// 3. Its snooze over, it is back: unread, and above the newer notification.
+	if _, err := pool.Exec(ctx, `UPDATE notification SET snoozed_until = now() - interval '1 second' WHERE id = $1`, ids[1]); err != nil {
+		t.Fatalf("end the snooze: %v", err)
+	}
+	if got := feed(false); !reflect.DeepEqual(got, []int{ids[1], ids[2]}) {
+		t.Errorf("inbox after the snooze = %v, want the woken %d on top", got, ids[1])
+	}
+	// The worker finds it in the window its snooze ended in, to announce it.
+	woken, err := repo.ListWokenBetween(ctx, time.Now().Add(-time.Minute), time.Now(), 0, 100)
+	if err != nil {
+		t.Fatalf("list woken: %v", err)
+	}
+	var wokenHere []int
+	for _, n := range woken {
+		if n.ProjectID == projectID {
+			wokenHere = append(wokenHere, n.ID)
+		}
+	}
+	if !reflect.DeepEqual(wokenHere, ids[1:2]) {
+		t.Errorf("woken in the last minute = %v, want %v", wokenHere, ids[1:2])
+	}
+	if n, by := unread(false), unreadByChannel(); n != 1 || !reflect.DeepEqual(by, map[string]int{"posts": 1}) {
+		t.Errorf("unread after the snooze = %d, by channel %v; want 1 and posts:1, the woken notification", n, by)
+	}
+
+	// 4. And the id cursor pages past it in feed order.
+	one := 1
+	page, cursor, err := repo.ListForRecipient(ctx, projectID, extID, nil, &query.Cursor{Limit: &one})
+	if err != nil || len(page) != 1 || page[0].ID != ids[1] || cursor.Before == nil {
+		t.Fatalf("first page = %v (cursor %+v, err %v), want %d", notificationIDs(page), cursor, err, ids[1])
+	}
+	page, _, err = repo.ListForRecipient(ctx, projectID, extID, nil, &query.Cursor{Before: cursor.Before, Limit: &one})
+	if err != nil || len(page) != 1 || page[0].ID != ids[2] {
+		t.Errorf("second page = %v (err %v), want %d", notificationIDs(page), err, ids[2])
+	}
+
+	// 5. A cursor naming someone else's notification is no position in this
+	// feed: it pages like a gone id, from the top.
+	var foreign int
+	err = pool.QueryRow(ctx, `
+		INSERT INTO notification
+			(project_id, recipient_external_id, payload, channel, topic, event, status, created_at, updated_at)
+		VALUES ($1, 'someone-else', '{}', 'posts', 'none', 'reply', 'delivered', now() - interval '30 seconds', now())
+		RETURNING id
+	`, projectID).Scan(&foreign)
+	if err != nil {
+		t.Fatalf("insert another recipient's notification: %v", err)
+	}
+	before := strconv.Itoa(foreign)
+	page, _, err = repo.ListForRecipient(ctx, projectID, extID, nil, &query.Cursor{Before: &before, Limit: &limit})
+	if err != nil || !reflect.DeepEqual(notificationIDs(page), []int{ids[1], ids[2]}) {
+		t.Errorf("page before another recipient's id = %v (err %v), want the whole feed", notificationIDs(page), err)
+	}
+}
+
+func notificationIDs(notifs []*entity.Notification) []int {
+	ids := make([]int, len(notifs))
+	for i, n := range notifs {
+		ids[i] = n.ID
+	}
+	return ids
+}
@@ -0,0 +1,122 @@
+package pg
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/tantra/query"
+)
+
+// TestUpdateForRecipientByFilter is "mark billing read" and "mark all seen up
+// to here" against a live Postgres: one UPDATE, scoped by the list's own
+// filters, returning exactly the ids it changed.
+//
+// Skipped unless TEST_DB_URL is set. Self-cleaning.
+func TestUpdateForRecipientByFilter(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set; skipping DB integration test")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	var userID int
+	if err := pool.QueryRow(ctx, `SELECT user_id FROM project ORDER BY id LIMIT 1`).Scan(&userID); err != nil {
+		t.Fatalf("need at least one existing project to borrow a user_id: %v", err)
+	}
+
+	var projectID int
+	err = pool.QueryRow(ctx, `
+		INSERT INTO project (user_id, name, created_at, updated_at)
+		VALUES ($1, 'bulk-update-test', now(), now()) RETURNING id
+	`, userID).Scan(&projectID)
+	if err != nil {
+		t.Fatalf("insert project: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(ctx, "DELETE FROM project WHERE id = $1", projectID) })
+
+	const extID = "bulk-user"
+	_, err = pool.Exec(ctx, `
+		INSERT INTO recipient (external_id, name, project_id, created_at, updated_at)
+		VALUES ($1, 'Bulk', $2, now(), now())
+	`, extID, projectID)
+	if err != nil {
+		t.Fatalf("insert recipient: %v", err)
+	}
+
+	// Oldest first. The muted billing row is outside the feed and must not be
+	// caught by a filter that matches its channel.
+	seeds := []struct{ channel, status string }{
+		{"billing", "delivered"},
+		{"social", "delivered"},
+		{"billing", "muted"},
+		{"billing", "delivered"},
+		{"social", "delivered"},
+	}
+	ids := make([]int, len(seeds))
+	for i, s := range seeds {
+		err := pool.QueryRow(ctx, `
+			INSERT INTO notification
+				(project_id, recipient_external_id, payload, channel, topic, event, status, created_at, updated_at)
+			VALUES ($1, $2, '{}', $3, 'none', 'notice', $4, now() - make_interval(mins => $5), now())
+			RETURNING id
+		`, projectID, extID, s.channel, s.status, len(seeds)-i).Scan(&ids[i])
+		if err != nil {
+			t.Fatalf("insert notification %d: %v", i, err)
+		}
+	}
+
+	repo := NewNotificationRepo(pool)
+	yes := true
+	billing := "billing"
+
+	// 1. Mark billing read.
+	updated, err := repo.UpdateForRecipient(ctx, projectID, extID, dto.UpdateRecipientNotificationsPayload{
+		State:   dto.NotificationStateFilter{Read: &yes},
+		Filters: &dto.RecipientFeedFilters{Channel: &billing},
+	})
+	if err != nil {
+		t.Fatalf("mark billing read: %v", err)
+	}
+	slices.Sort(updated)
+	if want := []int{ids[0], ids[3]}; !reflect.DeepEqual(updated, want) {
+		t.Errorf("mark billing read changed %v, want %v", updated, want)
+	}
+	if count, _ := repo.UnreadCountForRecipient(ctx, projectID, extID, false); count != 2 {
+		t.Errorf("unread after marking billing read = %d, want the 2 social", count)
+	}
+
+	// 2. Mark seen up to the second-newest: the newest arrived after the
+	//    client looked, and stays unseen.
+	updated, err = repo.UpdateForRecipient(ctx, projectID, extID, dto.UpdateRecipientNotificationsPayload{
+		State:   dto.NotificationStateFilter{Seen: &yes},
+		Filters: &dto.RecipientFeedFilters{UntilID: &ids[3]},
+	})
+	if err != nil {
+		t.Fatalf("mark seen until: %v", err)
+	}
+	slices.Sort(updated)
+	if want := []int{ids[0], ids[1], ids[3]}; !reflect.DeepEqual(updated, want) {
+		t.Errorf("mark seen until %d changed %v, want %v", ids[3], updated, want)
+	}
+
+	no := false
+	limit := 50
+	unseen, _, err := repo.ListForRecipient(ctx, projectID, extID, &dto.RecipientFeedFilters{Seen: &no}, &query.Cursor{Limit: &limit})
+	if err != nil {
+		t.Fatalf("list unseen: %v", err)
+	}
+	if got := notificationIDs(unseen); !reflect.DeepEqual(got, []int{ids[4]}) {
+		t.Errorf("unseen = %v, want only the newest, %d", got, ids[4])
+	}
+}
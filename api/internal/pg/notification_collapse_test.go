@@ -0,0 +1,135 @@
+package pg
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/tantra/query"
+)
+
+// TestUpdateCollapsingLeavesOneInTheFeed delivers several notifications under
+// one collapse_key at once, the way concurrent notification:delivery tasks
+// would, and checks the recipient ends up with exactly one of them — the newest.
+// A read notification under the same key is history, not something to replace,
+// and must be left alone.
+//
+// Skipped unless TEST_DB_URL is set. Self-cleaning.
+func TestUpdateCollapsingLeavesOneInTheFeed(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set; skipping DB integration test")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	var userID int
+	if err := pool.QueryRow(ctx, `SELECT user_id FROM project ORDER BY id LIMIT 1`).Scan(&userID); err != nil {
+		t.Fatalf("need at least one existing project to borrow a user_id: %v", err)
+	}
+
+	var projectID int
+	err = pool.QueryRow(ctx, `
+		INSERT INTO project (user_id, name, created_at, updated_at)
+		VALUES ($1, 'collapse-key-test', now(), now()) RETURNING id
+	`, userID).Scan(&projectID)
+	if err != nil {
+		t.Fatalf("insert project: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(ctx, "DELETE FROM project WHERE id = $1", projectID) })
+
+	const extID = "collapse-user"
+	key := "post_42:comments"
+	repo := NewNotificationRepo(pool)
+
+	// Already read: stays in the feed below whatever arrives next.
+	read := entity.NewNotification(projectID, extID, []byte(`{"n":"read"}`), nil, "posts", "post_42", "comment")
+	read.CollapseKey = &key
+	read.Status = enum.NotificationStatusDelivered
+	now := time.Now().UTC()
+	read.ReadAt = &now
+	if read, err = repo.Create(ctx, read); err != nil {
+		t.Fatalf("insert read notification: %v", err)
+	}
+
+	const sends = 8
+	pending := make([]*entity.Notification, sends)
+	for i := range pending {
+		n := entity.NewNotification(projectID, extID, []byte(`{"n":"comment"}`), nil, "posts", "post_42", "comment")
+		n.CollapseKey = &key
+		if pending[i], err = repo.Create(ctx, n); err != nil {
+			t.Fatalf("insert notification %d: %v", i, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	collapsedBy := map[int]int{}
+	errs := make(chan error, sends)
+	for _, n := range pending {
+		wg.Add(1)
+		go func(n *entity.Notification) {
+			defer wg.Done()
+			n.Status = enum.NotificationStatusDelivered
+			n.UpdatedAt = time.Now().UTC()
+			collapsed, err := repo.UpdateCollapsing(ctx, n)
+			mu.Lock()
+			for _, id := range collapsed {
+				collapsedBy[id]++
+			}
+			mu.Unlock()
+			errs <- err
+		}(n)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("update collapsing: %v", err)
+		}
+	}
+
+	// What is reported collapsed is what streams are told left the feed: each
+	// once, never the survivor, never the read one.
+	for id, n := range collapsedBy {
+		if n != 1 {
+			t.Errorf("notification %d reported collapsed %d times", id, n)
+		}
+		if id == pending[sends-1].ID || id == read.ID {
+			t.Errorf("notification %d reported collapsed, but it stays in the feed", id)
+		}
+	}
+
+	limit := 50
+	feed, _, err := repo.ListForRecipient(ctx, projectID, extID, nil, &query.Cursor{Limit: &limit})
+	if err != nil {
+		t.Fatalf("list for recipient: %v", err)
+	}
+
+	newest := pending[sends-1].ID
+	if len(feed) != 2 || feed[0].ID != newest || feed[1].ID != read.ID {
+		got := make([]int, 0, len(feed))
+		for _, n := range feed {
+			got = append(got, n.ID)
+		}
+		t.Fatalf("feed = %v, want [%d %d]: the newest send on top, the read one kept", got, newest, read.ID)
+	}
+
+	count, err := repo.UnreadCountForRecipient(ctx, projectID, extID, false)
+	if err != nil {
+		t.Fatalf("unread count: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("unread count = %d, want 1", count)
+	}
+}
@@ -16,19 +16,27 @@ import (
 )
 
 type NotificationDeliveryRepo struct {
-	db dbx.DBExecutor
+	db   dbx.DBExecutor
+	pool *pgxpool.Pool
 }
 
 func NewNotificationDeliveryRepo(db *pgxpool.Pool) repository.NotificationDeliveryRepository {
 	return &NotificationDeliveryRepo{
-		db: db,
+		db:   db,
+		pool: db,
 	}
 }
 
+// notificationDeliveryFields reads a digested row's provider message id off its
+// digest: the row's own provider_message_id stays NULL, because one provider
+// message covers the whole digest and ux_nd_provider_message allows one row per
+// message.
 const notificationDeliveryFields = `
 	id, notification_id, project_id, recipient_external_id, medium, contact_id, address_snapshot,
 	status, provider, provider_message_id, failure_reason, attempt, sent_at, delivered_at, bounced_at,
-	complained_at, opened_at, clicked_at, provider_response, created_at, updated_at
+	complained_at, opened_at, clicked_at, provider_response, digest_id,
+	(SELECT ed.provider_message_id FROM email_digest ed WHERE ed.id = notification_delivery.digest_id),
+	created_at, updated_at
 `
 
 func scanNotificationDelivery(row interface {
@@ -42,7 +50,8 @@ func scanNotificationDelivery(row interface {
 	err := row.Scan(
 		&d.ID, &d.NotificationID, &d.ProjectID, &d.RecipientExtID, &medium, &d.ContactID, &d.AddressSnapshot,
 		&status, &d.Provider, &d.ProviderMessageID, &d.FailureReason, &d.Attempt, &d.SentAt, &d.DeliveredAt,
-		&d.BouncedAt, &d.ComplainedAt, &d.OpenedAt, &d.ClickedAt, &providerResponse, &d.CreatedAt, &d.UpdatedAt,
+		&d.BouncedAt, &d.ComplainedAt, &d.OpenedAt, &d.ClickedAt, &providerResponse, &d.DigestID, &d.DigestMessageID,
+		&d.CreatedAt, &d.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
@@ -80,6 +89,77 @@ func (r *NotificationDeliveryRepo) Create(ctx context.Context, delivery *entity.
 	return created, nil
 }
 
+// CreateDigested inserts a pending delivery into the recipient's open digest for
+// window, opening one if there is none, together with the rendered item it will
+// contribute. One transaction: a delivery attached to a digest with no item would
+// be marked digested without ever being in the email.
+//
+// Concurrent sends for one recipient converge on the same digest through the
+// ux_email_digest_open conflict target. The address is refreshed on each item so
+// the digest goes to wherever the recipient's primary contact points last.
+func (r *NotificationDeliveryRepo) CreateDigested(ctx context.Context, delivery *entity.NotificationDelivery, window enum.DigestWindow, item *entity.EmailDigestItem) (*entity.NotificationDelivery, error) {
+	var created *entity.NotificationDelivery
+
+	err := dbx.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
+		digestSQL := `
+			INSERT INTO email_digest
+				(project_id, recipient_external_id, digest_window, status, contact_id, address_snapshot, provider, due_at, created_at, updated_at)
+			VALUES ($1, $2, $3, 'open', $4, $5, $6, now() + $7::bigint * interval '1 second', now(), now())
+			ON CONFLICT (project_id, recipient_external_id, digest_window) WHERE status = 'open'
+			DO UPDATE SET
+				contact_id = EXCLUDED.contact_id,
+				address_snapshot = EXCLUDED.address_snapshot,
+				provider = EXCLUDED.provider,
+				updated_at = now()
+			RETURNING id
+		`
+
+		var digestID int64
+		err := tx.QueryRow(ctx, digestSQL,
+			delivery.ProjectID, delivery.RecipientExtID, string(window), delivery.ContactID,
+			delivery.AddressSnapshot, delivery.Provider, int64(window.Duration().Seconds()),
+		).Scan(&digestID)
+		if err != nil {
+			return fmt.Errorf("open digest: %w", err)
+		}
+
+		deliverySQL := fmt.Sprintf(`
+			INSERT INTO notification_delivery
+				(notification_id, project_id, recipient_external_id, medium, contact_id, address_snapshot,
+				 status, provider, attempt, digest_id, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 0, $9, $10, $11)
+			RETURNING %s
+		`, notificationDeliveryFields)
+
+		created, err = scanNotificationDelivery(tx.QueryRow(ctx, deliverySQL,
+			delivery.NotificationID, delivery.ProjectID, delivery.RecipientExtID, string(delivery.Medium),
+			delivery.ContactID, delivery.AddressSnapshot, string(delivery.Status), delivery.Provider,
+			digestID, delivery.CreatedAt, delivery.UpdatedAt,
+		))
+		if err != nil {
+			if dbx.IsUniqueViolation(err) {
+				return tantraRepo.ErrConflict
+			}
+			return fmt.Errorf("insert delivery: %w", err)
+		}
+
+		itemSQL := `
+			INSERT INTO email_digest_item (delivery_id, subject, html, text, expires_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, now())
+		`
+		if _, err := tx.Exec(ctx, itemSQL, created.ID, item.Subject, item.HTML, item.Text, item.ExpiresAt); err != nil {
+			return fmt.Errorf("insert digest item: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
 func (r *NotificationDeliveryRepo) Get(ctx context.Context, id int64) (*entity.NotificationDelivery, error) {
 	sql := fmt.Sprintf(`
 		SELECT %s
@@ -171,8 +251,13 @@ func (r *NotificationDeliveryRepo) UpdateResult(ctx context.Context, id int64, r
 // terminal among {bounced, complained, failed} wins (equal rank ⇒ not overwritten).
 // `%s` is substituted with either `status` (the current column) or the incoming
 // status literal so the same ladder ranks both.
+//
+// `digested` falls to ELSE on purpose: it is what says "this went out inside a
+// digest", so a digest's webhooks stamp delivered_at/bounced_at/... on the row
+// but never replace its status.
 const deliveryStatusRank = `(CASE %s
 	WHEN 'pending' THEN 0
+	WHEN 'deferred' THEN 0
 	WHEN 'sending' THEN 1
 	WHEN 'sent' THEN 2
 	WHEN 'delivered' THEN 3
@@ -193,6 +278,8 @@ func (r *NotificationDeliveryRepo) ApplyWebhookStatus(ctx context.Context, u rep
 	// one project's webhook can't touch another's row. The status only advances when
 	// $2 outranks the current status; each *_at column is first-write-wins
 	// (COALESCE); the raw event is appended to the provider_response JSONB array.
+	// A digest email's events land on every delivery the digest carried: those
+	// rows have no provider_message_id of their own and are found through theirs.
 	sql := fmt.Sprintf(`
 		UPDATE notification_delivery SET
 			status = CASE
@@ -206,7 +293,10 @@ func (r *NotificationDeliveryRepo) ApplyWebhookStatus(ctx context.Context, u rep
 			clicked_at    = CASE WHEN $3 = 'clicked'    THEN COALESCE(clicked_at,    $4) ELSE clicked_at    END,
 			provider_response = COALESCE(provider_response, '[]'::jsonb) || $5::jsonb,
 			updated_at = now()
-		WHERE provider_message_id = $1 AND project_id = $6
+		WHERE project_id = $6 AND (
+			provider_message_id = $1
+			OR digest_id IN (SELECT id FROM email_digest WHERE provider_message_id = $1 AND project_id = $6)
+		)
 	`, fmt.Sprintf(deliveryStatusRank, "$2::text"), fmt.Sprintf(deliveryStatusRank, "status"))
 
 	res, err := r.db.Exec(ctx, sql, u.ProviderMessageID, newStatus, u.Kind, u.At, string(u.RawEvent), u.ProjectID)
@@ -240,6 +330,59 @@ func (r *NotificationDeliveryRepo) GetTargetByProviderMessageID(ctx context.Cont
 	return &t, nil
 }
 
+func (r *NotificationDeliveryRepo) LatestComplaint(ctx context.Context, projectID int, recipientExtID string, target dto.Target) (*entity.NotificationDelivery, error) {
+	// The target lives on the notification. It is checked in a subquery rather
+	// than joined, because notificationDeliveryFields names its columns bare.
+	sql := fmt.Sprintf(`
+		SELECT %s
+		FROM notification_delivery
+		WHERE project_id = $1
+		  AND recipient_external_id = $2
+		  AND medium = 'email'
+		  AND status = 'complained'
+		  AND EXISTS (
+		      SELECT 1 FROM notification n
+		      WHERE n.id = notification_delivery.notification_id
+		        AND n.channel = $3 AND n.topic = $4 AND n.event = $5
+		  )
+		ORDER BY complained_at DESC NULLS LAST, id DESC
+		LIMIT 1
+	`, notificationDeliveryFields)
+
+	row := r.db.QueryRow(ctx, sql, projectID, recipientExtID, target.Channel, target.Topic, target.Event)
+	delivery, err := scanNotificationDelivery(row)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, tantraRepo.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// Recalled reports whether the delivery or its notification reads `recalled`.
+//
+// Both, because the notification is the one a recall is guaranteed to reach: a
+// direct send recalled before the worker resolved it only gets its delivery row
+// afterwards, as `pending`, and that row must not send either.
+func (r *NotificationDeliveryRepo) Recalled(ctx context.Context, id int64) (bool, error) {
+	sql := `
+		SELECT nd.status = 'recalled' OR n.status = 'recalled'
+		FROM notification_delivery nd
+		JOIN notification n ON n.id = nd.notification_id
+		WHERE nd.id = $1
+	`
+	var recalled bool
+	if err := r.db.QueryRow(ctx, sql, id).Scan(&recalled); err != nil {
+		if err == pgx.ErrNoRows {
+			return false, tantraRepo.ErrNotFound
+		}
+		return false, err
+	}
+	return recalled, nil
+}
+
 // EmailAnalyticsSeries returns per-day email delivery counts for a project over
 // the range, bucketed by DAY in the viewer's timezone (`tz`). It aggregates
 // `notification_delivery WHERE medium='email'` — the SEPARATE table where the
@@ -266,6 +409,11 @@ func (r *NotificationDeliveryRepo) EmailAnalyticsSeries(ctx context.Context, pro
 			count(*) FILTER (WHERE status = 'failed') AS failed,
 			count(*) FILTER (WHERE status = 'no_contact') AS no_contact,
 			count(*) FILTER (WHERE status = 'muted') AS muted,
+			count(*) FILTER (WHERE status = 'expired') AS expired,
+			count(*) FILTER (WHERE status = 'digested') AS digested,
+			count(*) FILTER (WHERE status = 'deferred') AS deferred,
+			count(*) FILTER (WHERE status = 'throttled') AS throttled,
+			count(*) FILTER (WHERE status = 'recalled') AS recalled,
 			count(*) FILTER (WHERE opened_at IS NOT NULL) AS opened,
 			count(*) FILTER (WHERE clicked_at IS NOT NULL) AS clicked
 		FROM notification_delivery
@@ -288,10 +436,12 @@ func (r *NotificationDeliveryRepo) EmailAnalyticsSeries(ctx context.Context, pro
 		var (
 			day                                                      string
 			attempted, pending, sent, delivered, bounced, complained int
-			failed, noContact, muted, opened, clicked                int
+			failed, noContact, muted, expired, digested, deferred    int
+			throttled, recalled                                      int
+			opened, clicked                                          int
 		)
 		if err := rows.Scan(&day, &attempted, &pending, &sent, &delivered, &bounced,
-			&complained, &failed, &noContact, &muted, &opened, &clicked); err != nil {
+			&complained, &failed, &noContact, &muted, &expired, &digested, &deferred, &throttled, &recalled, &opened, &clicked); err != nil {
 			return nil, nil, fmt.Errorf("scan email analytics day: %w", err)
 		}
 
@@ -312,6 +462,11 @@ func (r *NotificationDeliveryRepo) EmailAnalyticsSeries(ctx context.Context, pro
 		totals.ByStatus.Failed += failed
 		totals.ByStatus.NoContact += noContact
 		totals.ByStatus.Muted += muted
+		totals.ByStatus.Expired += expired
+		totals.ByStatus.Digested += digested
+		totals.ByStatus.Deferred += deferred
+		totals.ByStatus.Throttled += throttled
+		totals.ByStatus.Recalled += recalled
 		totals.Opened += opened
 		totals.Clicked += clicked
 	}
@@ -0,0 +1,181 @@
+package pg
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/tantra/query"
+)
+
+// TestRecipientFeedFiltersAndGroups runs the notification-center tabs against a
+// live Postgres: filters narrow the visible feed and never widen it, groups
+// count what the filters kept, and the per-channel unread counts add up to the
+// badge.
+//
+// Skipped unless TEST_DB_URL is set. Self-cleaning.
+func TestRecipientFeedFiltersAndGroups(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set; skipping DB integration test")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	var userID int
+	if err := pool.QueryRow(ctx, `SELECT user_id FROM project ORDER BY id LIMIT 1`).Scan(&userID); err != nil {
+		t.Fatalf("need at least one existing project to borrow a user_id: %v", err)
+	}
+
+	var projectID int
+	err = pool.QueryRow(ctx, `
+		INSERT INTO project (user_id, name, created_at, updated_at)
+		VALUES ($1, 'feed-filters-test', now(), now()) RETURNING id
+	`, userID).Scan(&projectID)
+	if err != nil {
+		t.Fatalf("insert project: %v", err)
+	}
+	t.Cleanup(func() { _, _ = pool.Exec(ctx, "DELETE FROM project WHERE id = $1", projectID) })
+
+	const extID = "tabs-user"
+	_, err = pool.Exec(ctx, `
+		INSERT INTO recipient (external_id, name, project_id, created_at, updated_at)
+		VALUES ($1, 'Tabs', $2, now(), now())
+	`, extID, projectID)
+	if err != nil {
+		t.Fatalf("insert recipient: %v", err)
+	}
+
+	// In id order. The muted mention is outside the feed, and has to stay out of
+	// every filter, group and count below.
+	seeds := []struct {
+		channel, topic, event, status string
+		read                          bool
+	}{
+		{"billing", "invoice_1", "paid", "delivered", true},
+		{"billing", "invoice_1", "overdue", "delivered", false},
+		{"social", "post_1", "mention", "delivered", false},
+		{"social", "post_1", "mention", "delivered", false},
+		{"social", "post_2", "mention", "muted", false},
+	}
+	ids := make([]int, len(seeds))
+	for i, s := range seeds {
+		err := pool.QueryRow(ctx, `
+			INSERT INTO notification
+				(project_id, recipient_external_id, payload, channel, topic, event, status, read_at, created_at, updated_at)
+			VALUES ($1, $2, '{}', $3, $4, $5, $6, CASE WHEN $7 THEN now() END, now(), now())
+			RETURNING id
+		`, projectID, extID, s.channel, s.topic, s.event, s.status, s.read).Scan(&ids[i])
+		if err != nil {
+			t.Fatalf("insert notification %d: %v", i, err)
+		}
+	}
+
+	repo := NewNotificationRepo(pool)
+	limit := 50
+	str := func(s string) *string { return &s }
+	unread := false
+
+	// 1. Filters.
+	for name, tc := range map[string]struct {
+		filters dto.RecipientFeedFilters
+		want    []int
+	}{
+		"channel":  {dto.RecipientFeedFilters{Channel: str("billing")}, []int{ids[1], ids[0]}},
+		"event":    {dto.RecipientFeedFilters{Event: str("mention")}, []int{ids[3], ids[2]}},
+		"unread":   {dto.RecipientFeedFilters{Read: &unread}, []int{ids[3], ids[2], ids[1]}},
+		"combined": {dto.RecipientFeedFilters{Channel: str("billing"), Read: &unread}, []int{ids[1]}},
+	} {
+		notifs, _, err := repo.ListForRecipient(ctx, projectID, extID, &tc.filters, &query.Cursor{Limit: &limit})
+		if err != nil {
+			t.Fatalf("%s: list: %v", name, err)
+		}
+		got := make([]int, len(notifs))
+		for i, n := range notifs {
+			got[i] = n.ID
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: feed = %v, want %v", name, got, tc.want)
+		}
+	}
+
+	// 2. Grouped by topic: the newest group first, counts of the visible rows only.
+	byTopic := &dto.RecipientFeedFilters{GroupBy: dto.FeedGroupByTopic}
+	groups, _, err := repo.ListGroupsForRecipient(ctx, projectID, extID, byTopic, &query.Cursor{Limit: &limit})
+	if err != nil {
+		t.Fatalf("group by topic: %v", err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("group by topic returned %d groups, want 2 (the muted post_2 has none)", len(groups))
+	}
+	if g := groups[0]; g.Topic != "post_1" || g.Event != "" || g.Count != 2 || g.UnreadCount != 2 || g.Newest.ID != ids[3] {
+		t.Errorf("first group = %+v, want post_1 with 2 unread, newest %d", g, ids[3])
+	}
+	if g := groups[1]; g.Topic != "invoice_1" || g.Count != 2 || g.UnreadCount != 1 || g.Newest.ID != ids[1] {
+		t.Errorf("second group = %+v, want invoice_1 with 1 of 2 unread, newest %d", g, ids[1])
+	}
+
+	// 3. Grouped by target, a page at a time.
+	byTarget := &dto.RecipientFeedFilters{GroupBy: dto.FeedGroupByTarget}
+	two := 2
+	page, cursor, err := repo.ListGroupsForRecipient(ctx, projectID, extID, byTarget, &query.Cursor{Limit: &two})
+	if err != nil {
+		t.Fatalf("group by target: %v", err)
+	}
+	if len(page) != 2 || page[0].Event != "mention" || page[1].Event != "overdue" || cursor.Before == nil {
+		t.Fatalf("first page = %+v (cursor %+v), want mention then overdue, with more", page, cursor)
+	}
+	page, cursor, err = repo.ListGroupsForRecipient(ctx, projectID, extID, byTarget, &query.Cursor{Before: cursor.Before, Limit: &two})
+	if err != nil {
+		t.Fatalf("group by target, page 2: %v", err)
+	}
+	if len(page) != 1 || page[0].Event != "paid" || page[0].UnreadCount != 0 || cursor.Before != nil {
+		t.Errorf("second page = %+v (cursor %+v), want only the read paid group, and no more", page, cursor)
+	}
+
+	// 4. The breakdown adds up to the badge.
+	byChannel, err := repo.UnreadCountsByChannelForRecipient(ctx, projectID, extID, false)
+	if err != nil {
+		t.Fatalf("unread by channel: %v", err)
+	}
+	if want := map[string]int{"billing": 1, "social": 2}; !reflect.DeepEqual(byChannel, want) {
+		t.Errorf("unread by channel = %v, want %v", byChannel, want)
+	}
+	total, err := repo.UnreadCountForRecipient(ctx, projectID, extID, false)
+	if err != nil {
+		t.Fatalf("unread count: %v", err)
+	}
+	if total != 3 {
+		t.Errorf("unread count = %d, want 3, the sum of the breakdown", total)
+	}
+
+	// 5. Groups sit where the feed puts their newest row: the overdue invoice,
+	// woken from a snooze, brings its group back to the top, and the cursor
+	// pages on by feed position.
+	if _, err := pool.Exec(ctx, `UPDATE notification SET snoozed_until = now() - interval '1 second' WHERE id = $1`, ids[1]); err != nil {
+		t.Fatalf("wake a snooze: %v", err)
+	}
+	one := 1
+	page, cursor, err = repo.ListGroupsForRecipient(ctx, projectID, extID, byTopic, &query.Cursor{Limit: &one})
+	if err != nil {
+		t.Fatalf("group by topic after the snooze: %v", err)
+	}
+	if len(page) != 1 || page[0].Topic != "invoice_1" || page[0].Newest.ID != ids[1] || cursor.Before == nil {
+		t.Fatalf("first group after the snooze = %+v (cursor %+v), want invoice_1, newest %d", page, cursor, ids[1])
+	}
+	page, _, err = repo.ListGroupsForRecipient(ctx, projectID, extID, byTopic, &query.Cursor{Before: cursor.Before, Limit: &one})
+	if err != nil {
+		t.Fatalf("group by topic after the snooze, page 2: %v", err)
+	}
+	if len(page) != 1 || page[0].Topic != "post_1" {
+		t.Errorf("second group after the snooze = %+v, want post_1", page)
+	}
+}
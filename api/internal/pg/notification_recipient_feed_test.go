@@ -21,6 +21,9 @@ import (
 // clear, and mark-all-read silently stamping hidden rows makes an email-only
 // notification look like something the recipient read.
 //
+// The expired row is `delivered` in every other respect: expiry hides a row
+// whatever its status, so it has to be shown not to lean on one.
+//
 // The `not_requested` row also doubles as proof the payload-nullable migration
 // landed: it is inserted with a NULL payload, which the pre-migration schema
 // rejects outright.
@@ -68,32 +71,38 @@ func TestRecipientFeedExcludesUndeliveredStatuses(t *testing.T) {
 	type seed struct {
 		status  enum.NotificationStatus
 		payload any
+		expired bool
 		visible bool
 	}
 	seeds := []seed{
-		{enum.NotificationStatusDelivered, `{"n":"delivered"}`, true},
-		{enum.NotificationStatusEnqueued, `{"n":"enqueued"}`, true},
-		{enum.NotificationStatusMuted, `{"n":"muted"}`, false},
-		{enum.NotificationStatusQuotaExceeded, `{"n":"quota"}`, false},
-		{enum.NotificationStatusNotRequested, nil, false},
+		{enum.NotificationStatusDelivered, `{"n":"delivered"}`, false, true},
+		{enum.NotificationStatusEnqueued, `{"n":"enqueued"}`, false, true},
+		{enum.NotificationStatusMuted, `{"n":"muted"}`, false, false},
+		{enum.NotificationStatusQuotaExceeded, `{"n":"quota"}`, false, false},
+		{enum.NotificationStatusNotRequested, nil, false, false},
+		{enum.NotificationStatusCollapsed, `{"n":"collapsed"}`, false, false},
+		{enum.NotificationStatusThrottled, `{"n":"throttled"}`, false, false},
+		{enum.NotificationStatusDelivered, `{"n":"expired"}`, true, false},
 	}
 
-	idByStatus := make(map[enum.NotificationStatus]int, len(seeds))
+	ids := make([]int, len(seeds))
 	wantVisible := 0
-	for _, s := range seeds {
+	for i, s := range seeds {
 		var id int
 		err := pool.QueryRow(ctx, `
 			INSERT INTO notification
-				(project_id, recipient_external_id, payload, channel, topic, event, status, created_at, updated_at)
-			VALUES ($1, $2, $3, 'conversation', 'thread_7', 'reply', $4, now(), now())
+				(project_id, recipient_external_id, payload, channel, topic, event, status, created_at, updated_at,
+				 expires_at)
+			VALUES ($1, $2, $3, 'conversation', 'thread_7', 'reply', $4, now(), now(),
+				CASE WHEN $5 THEN now() - interval '1 minute' END)
 			RETURNING id
-		`, projectID, extID, s.payload, string(s.status)).Scan(&id)
+		`, projectID, extID, s.payload, string(s.status), s.expired).Scan(&id)
 		if err != nil {
 			// A NULL payload failing here means the payload-nullable migration has
 			// not been applied to this database.
 			t.Fatalf("insert %s notification (payload=%v): %v", s.status, s.payload, err)
 		}
-		idByStatus[s.status] = id
+		ids[i] = id
 		if s.visible {
 			wantVisible++
 		}
@@ -103,7 +112,7 @@ func TestRecipientFeedExcludesUndeliveredStatuses(t *testing.T) {
 
 	// 1. The feed shows only the delivered/in-flight rows.
 	limit := 50
-	notifs, _, err := repo.ListForRecipient(ctx, projectID, extID, &query.Cursor{Limit: &limit})
+	notifs, _, err := repo.ListForRecipient(ctx, projectID, extID, nil, &query.Cursor{Limit: &limit})
 	if err != nil {
 		t.Fatalf("list for recipient: %v", err)
 	}
@@ -116,14 +125,18 @@ func TestRecipientFeedExcludesUndeliveredStatuses(t *testing.T) {
 	}
 	for _, n := range notifs {
 		switch n.Status {
-		case enum.NotificationStatusMuted, enum.NotificationStatusQuotaExceeded, enum.NotificationStatusNotRequested:
+		case enum.NotificationStatusMuted, enum.NotificationStatusQuotaExceeded, enum.NotificationStatusNotRequested,
+			enum.NotificationStatusCollapsed, enum.NotificationStatusThrottled:
 			t.Errorf("notification %d with status %s leaked into the recipient feed", n.ID, n.Status)
 		}
+		if n.ExpiresAt != nil {
+			t.Errorf("expired notification %d leaked into the recipient feed", n.ID)
+		}
 	}
 
 	// 2. The unread count agrees with the feed. None of the seeds are read yet, so
 	//    "unread" and "visible" are the same set here — which is the point.
-	count, err := repo.UnreadCountForRecipient(ctx, projectID, extID)
+	count, err := repo.UnreadCountForRecipient(ctx, projectID, extID, false)
 	if err != nil {
 		t.Fatalf("unread count: %v", err)
 	}
@@ -139,16 +152,16 @@ func TestRecipientFeedExcludesUndeliveredStatuses(t *testing.T) {
 	if err != nil {
 		t.Fatalf("mark all read: %v", err)
 	}
-	if updated != wantVisible {
-		t.Errorf("mark-all-read updated %d rows, want %d", updated, wantVisible)
+	if len(updated) != wantVisible {
+		t.Errorf("mark-all-read updated %d rows, want %d", len(updated), wantVisible)
 	}
 
-	for _, s := range seeds {
+	for i, s := range seeds {
 		if s.visible {
 			continue
 		}
 		var readAt *string
-		err := pool.QueryRow(ctx, `SELECT read_at::text FROM notification WHERE id = $1`, idByStatus[s.status]).Scan(&readAt)
+		err := pool.QueryRow(ctx, `SELECT read_at::text FROM notification WHERE id = $1`, ids[i]).Scan(&readAt)
 		if err != nil {
 			t.Fatalf("read back %s notification: %v", s.status, err)
 		}
@@ -158,7 +171,7 @@ func TestRecipientFeedExcludesUndeliveredStatuses(t *testing.T) {
 	}
 
 	// 4. And the count is now zero, not merely smaller.
-	count, err = repo.UnreadCountForRecipient(ctx, projectID, extID)
+	count, err = repo.UnreadCountForRecipient(ctx, projectID, extID, false)
 	if err != nil {
 		t.Fatalf("unread count after mark-all-read: %v", err)
 	}
@@ -44,21 +44,21 @@ func (r *PreferenceRepo) Create(ctx context.Context, pref *entity.Preference) (*
 	// recipient-level rows (see the WHERE on the conflict target), and a mandatory
 	// recipient row is a contradiction the CHECK constraint rejects.
 	sql := `
-		INSERT INTO preference (project_id, recipient_external_id, channel, topic, event, medium, name, description, enabled, mandatory, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO preference (project_id, recipient_external_id, channel, topic, event, medium, name, description, enabled, mandatory, digest, bypass_quiet_hours, frequency_cap_limit, frequency_cap_window, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $14, $15, $16, $12, $13)
 		ON CONFLICT (project_id, recipient_external_id, channel, topic, event, medium)
 		WHERE recipient_external_id IS NOT NULL
 		DO UPDATE SET
 			enabled = EXCLUDED.enabled,
 			updated_at = EXCLUDED.updated_at
-		RETURNING id, project_id, recipient_external_id, channel, topic, event, medium, name, description, enabled, mandatory, created_at, updated_at
+		RETURNING id, project_id, recipient_external_id, channel, topic, event, medium, name, description, enabled, mandatory, digest, bypass_quiet_hours, frequency_cap_limit, frequency_cap_window, created_at, updated_at
 	`
 
-	row := r.db.QueryRow(ctx, sql, pref.ProjectID, pref.RecipientExtID, pref.Channel, pref.Topic, pref.Event, pref.Medium, pref.Name, pref.Description, pref.Enabled, pref.Mandatory, pref.CreatedAt, pref.UpdatedAt)
+	row := r.db.QueryRow(ctx, sql, pref.ProjectID, pref.RecipientExtID, pref.Channel, pref.Topic, pref.Event, pref.Medium, pref.Name, pref.Description, pref.Enabled, pref.Mandatory, pref.Digest, pref.CreatedAt, pref.UpdatedAt, pref.BypassQuietHours, pref.FrequencyCapLimit, pref.FrequencyCapWindow)
 
 	var newPref entity.Preference
 
-	err := row.Scan(&newPref.ID, &newPref.ProjectID, &newPref.RecipientExtID, &newPref.Channel, &newPref.Topic, &newPref.Event, &newPref.Medium, &newPref.Name, &newPref.Description, &newPref.Enabled, &newPref.Mandatory, &newPref.CreatedAt, &newPref.UpdatedAt)
+	err := row.Scan(&newPref.ID, &newPref.ProjectID, &newPref.RecipientExtID, &newPref.Channel, &newPref.Topic, &newPref.Event, &newPref.Medium, &newPref.Name, &newPref.Description, &newPref.Enabled, &newPref.Mandatory, &newPref.Digest, &newPref.BypassQuietHours, &newPref.FrequencyCapLimit, &newPref.FrequencyCapWindow, &newPref.CreatedAt, &newPref.UpdatedAt)
 	if err != nil {
 		if dbx.IsUniqueViolation(err) {
 			return nil, tantraRepo.ErrConflict
@@ -75,7 +75,7 @@ func (r *PreferenceRepo) Create(ctx context.Context, pref *entity.Preference) (*
 // a recipient-level row with the same id resolves to ErrNotFound here.
 func (r *PreferenceRepo) GetProjectPreferenceByID(ctx context.Context, projectID int, preferenceID int) (*entity.Preference, error) {
 	sql := `
-		SELECT id, project_id, recipient_external_id, channel, topic, event, medium, name, description, enabled, mandatory, created_at, updated_at
+		SELECT id, project_id, recipient_external_id, channel, topic, event, medium, name, description, enabled, mandatory, digest, bypass_quiet_hours, frequency_cap_limit, frequency_cap_window, created_at, updated_at
 		FROM preference
 		WHERE project_id = $1 AND id = $2 AND recipient_external_id IS NULL
 	`
@@ -83,7 +83,7 @@ func (r *PreferenceRepo) GetProjectPreferenceByID(ctx context.Context, projectID
 	row := r.db.QueryRow(ctx, sql, projectID, preferenceID)
 
 	var pref entity.Preference
-	err := row.Scan(&pref.ID, &pref.ProjectID, &pref.RecipientExtID, &pref.Channel, &pref.Topic, &pref.Event, &pref.Medium, &pref.Name, &pref.Description, &pref.Enabled, &pref.Mandatory, &pref.CreatedAt, &pref.UpdatedAt)
+	err := row.Scan(&pref.ID, &pref.ProjectID, &pref.RecipientExtID, &pref.Channel, &pref.Topic, &pref.Event, &pref.Medium, &pref.Name, &pref.Description, &pref.Enabled, &pref.Mandatory, &pref.Digest, &pref.BypassQuietHours, &pref.FrequencyCapLimit, &pref.FrequencyCapWindow, &pref.CreatedAt, &pref.UpdatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, tantraRepo.ErrNotFound
@@ -98,21 +98,36 @@ func (r *PreferenceRepo) GetProjectPreferenceByID(ctx context.Context, projectID
 // description and the project-level default). Scoped to project-level rows
 // (recipient NULL) for the same reason GetProjectPreferenceByID is; RETURNING
 // gives back the fresh row so the caller need not re-read. A nil description
-// clears it. ErrNotFound when nothing matched.
-func (r *PreferenceRepo) UpdateProjectPreference(ctx context.Context, projectID int, preferenceID int, name string, description *string, enabled bool, mandatory *bool) (*entity.Preference, error) {
+// clears it. A nil digest leaves it alone and enum.DigestOff clears it; a nil
+// bypassQuietHours leaves it alone. A nil frequencyCap leaves the cap alone and
+// one with window enum.CapOff removes it. ErrNotFound when nothing matched.
+func (r *PreferenceRepo) UpdateProjectPreference(ctx context.Context, projectID int, preferenceID int, name string, description *string, enabled bool, mandatory *bool, digest *enum.DigestWindow, bypassQuietHours *bool, frequencyCap *entity.FrequencyCap) (*entity.Preference, error) {
+	var capLimit *int
+	var capWindow *enum.CapWindow
+	if frequencyCap != nil {
+		capLimit, capWindow = &frequencyCap.Limit, &frequencyCap.Window
+	}
+
 	sql := `
 		UPDATE preference
 		-- COALESCE, not assignment: a NULL $6 means the caller did not mention
 		-- mandatory, so it keeps whatever it already was. See the payload doc.
-		SET name = $3, description = $4, enabled = $5, mandatory = COALESCE($6, mandatory), updated_at = now()
+		-- digest ($7) follows the same rule, with 'off' as the way to clear it.
+		SET name = $3, description = $4, enabled = $5, mandatory = COALESCE($6, mandatory),
+			digest = CASE WHEN $7::text IS NULL THEN digest WHEN $7::text = 'off' THEN NULL ELSE $7::text END,
+			bypass_quiet_hours = COALESCE($8, bypass_quiet_hours),
+			-- The cap moves as a pair, keyed on the window ($10) like digest.
+			frequency_cap_limit = CASE WHEN $10::text IS NULL THEN frequency_cap_limit WHEN $10::text = 'off' THEN NULL ELSE $9::int END,
+			frequency_cap_window = CASE WHEN $10::text IS NULL THEN frequency_cap_window WHEN $10::text = 'off' THEN NULL ELSE $10::text END,
+			updated_at = now()
 		WHERE project_id = $1 AND id = $2 AND recipient_external_id IS NULL
-		RETURNING id, project_id, recipient_external_id, channel, topic, event, medium, name, description, enabled, mandatory, created_at, updated_at
+		RETURNING id, project_id, recipient_external_id, channel, topic, event, medium, name, description, enabled, mandatory, digest, bypass_quiet_hours, frequency_cap_limit, frequency_cap_window, created_at, updated_at
 	`
 
-	row := r.db.QueryRow(ctx, sql, projectID, preferenceID, name, description, enabled, mandatory)
+	row := r.db.QueryRow(ctx, sql, projectID, preferenceID, name, description, enabled, mandatory, digest, bypassQuietHours, capLimit, capWindow)
 
 	var pref entity.Preference
-	err := row.Scan(&pref.ID, &pref.ProjectID, &pref.RecipientExtID, &pref.Channel, &pref.Topic, &pref.Event, &pref.Medium, &pref.Name, &pref.Description, &pref.Enabled, &pref.Mandatory, &pref.CreatedAt, &pref.UpdatedAt)
+	err := row.Scan(&pref.ID, &pref.ProjectID, &pref.RecipientExtID, &pref.Channel, &pref.Topic, &pref.Event, &pref.Medium, &pref.Name, &pref.Description, &pref.Enabled, &pref.Mandatory, &pref.Digest, &pref.BypassQuietHours, &pref.FrequencyCapLimit, &pref.FrequencyCapWindow, &pref.CreatedAt, &pref.UpdatedAt)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, tantraRepo.ErrNotFound
@@ -163,8 +178,8 @@ func (r *PreferenceRepo) UpsertProjectPreferences(ctx context.Context, projectID
 
 	err := dbx.WithTx(ctx, r.pool, func(tx pgx.Tx) error {
 		upsertSQL := `
-			INSERT INTO preference (project_id, recipient_external_id, channel, topic, event, medium, name, description, enabled, mandatory, created_at, updated_at)
-			VALUES ($1, NULL, $2, $3, $4, $5, $6, $7, $8, $9, now(), now())
+			INSERT INTO preference (project_id, recipient_external_id, channel, topic, event, medium, name, description, enabled, mandatory, digest, bypass_quiet_hours, frequency_cap_limit, frequency_cap_window, created_at, updated_at)
+			VALUES ($1, NULL, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, now(), now())
 			ON CONFLICT (project_id, channel, topic, event, medium)
 			WHERE recipient_external_id IS NULL
 			DO UPDATE SET
@@ -172,6 +187,10 @@ func (r *PreferenceRepo) UpsertProjectPreferences(ctx context.Context, projectID
 				description = EXCLUDED.description,
 				enabled = EXCLUDED.enabled,
 				mandatory = EXCLUDED.mandatory,
+				digest = EXCLUDED.digest,
+				bypass_quiet_hours = EXCLUDED.bypass_quiet_hours,
+				frequency_cap_limit = EXCLUDED.frequency_cap_limit,
+				frequency_cap_window = EXCLUDED.frequency_cap_window,
 				updated_at = now()
 		`
 
@@ -181,7 +200,7 @@ func (r *PreferenceRepo) UpsertProjectPreferences(ctx context.Context, projectID
 		mediums := make([]string, len(prefs))
 		for i, p := range prefs {
 			channels[i], topics[i], events[i], mediums[i] = p.Channel, p.Topic, p.Event, p.Medium
-			if _, err := tx.Exec(ctx, upsertSQL, projectID, p.Channel, p.Topic, p.Event, p.Medium, p.Name, p.Description, p.Enabled, p.Mandatory); err != nil {
+			if _, err := tx.Exec(ctx, upsertSQL, projectID, p.Channel, p.Topic, p.Event, p.Medium, p.Name, p.Description, p.Enabled, p.Mandatory, p.Digest, p.BypassQuietHours, p.FrequencyCapLimit, p.FrequencyCapWindow); err != nil {
 				return fmt.Errorf("upsert preference: %w", err)
 			}
 		}
@@ -204,7 +223,7 @@ func (r *PreferenceRepo) UpsertProjectPreferences(ctx context.Context, projectID
 		}
 
 		readSQL := `
-			SELECT id, project_id, recipient_external_id, channel, topic, event, medium, name, description, enabled, mandatory, created_at, updated_at
+			SELECT id, project_id, recipient_external_id, channel, topic, event, medium, name, description, enabled, mandatory, digest, bypass_quiet_hours, frequency_cap_limit, frequency_cap_window, created_at, updated_at
 			FROM preference
 			WHERE project_id = $1 AND recipient_external_id IS NULL
 			ORDER BY channel, topic, event, medium
@@ -218,7 +237,7 @@ func (r *PreferenceRepo) UpsertProjectPreferences(ctx context.Context, projectID
 		catalog := []*entity.Preference{}
 		for rows.Next() {
 			var p entity.Preference
-			if err := rows.Scan(&p.ID, &p.ProjectID, &p.RecipientExtID, &p.Channel, &p.Topic, &p.Event, &p.Medium, &p.Name, &p.Description, &p.Enabled, &p.Mandatory, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			if err := rows.Scan(&p.ID, &p.ProjectID, &p.RecipientExtID, &p.Channel, &p.Topic, &p.Event, &p.Medium, &p.Name, &p.Description, &p.Enabled, &p.Mandatory, &p.Digest, &p.BypassQuietHours, &p.FrequencyCapLimit, &p.FrequencyCapWindow, &p.CreatedAt, &p.UpdatedAt); err != nil {
 				return fmt.Errorf("scan: %w", err)
 			}
 			catalog = append(catalog, &p)
@@ -250,7 +269,7 @@ func (r *PreferenceRepo) ListPreferences(ctx context.Context, projectID int, kin
 func (r *PreferenceRepo) findPreferences(ctx context.Context, payload repository.SearchPreferencePayload) ([]*entity.Preference, int, error) {
 	baseSQL := `
 		SELECT
-			p.id, p.project_id, p.recipient_external_id, p.channel, p.topic, p.event, p.medium, p.name, p.description, p.enabled, p.mandatory, p.created_at, p.updated_at
+			p.id, p.project_id, p.recipient_external_id, p.channel, p.topic, p.event, p.medium, p.name, p.description, p.enabled, p.mandatory, p.digest, p.bypass_quiet_hours, p.frequency_cap_limit, p.frequency_cap_window, p.created_at, p.updated_at
 		FROM preference p
 	`
 
@@ -298,7 +317,7 @@ func (r *PreferenceRepo) findPreferences(ctx context.Context, payload repository
 	prefs := []*entity.Preference{}
 	for rows.Next() {
 		var newPref entity.Preference
-		err := rows.Scan(&newPref.ID, &newPref.ProjectID, &newPref.RecipientExtID, &newPref.Channel, &newPref.Topic, &newPref.Event, &newPref.Medium, &newPref.Name, &newPref.Description, &newPref.Enabled, &newPref.Mandatory, &newPref.CreatedAt, &newPref.UpdatedAt)
+		err := rows.Scan(&newPref.ID, &newPref.ProjectID, &newPref.RecipientExtID, &newPref.Channel, &newPref.Topic, &newPref.Event, &newPref.Medium, &newPref.Name, &newPref.Description, &newPref.Enabled, &newPref.Mandatory, &newPref.Digest, &newPref.BypassQuietHours, &newPref.FrequencyCapLimit, &newPref.FrequencyCapWindow, &newPref.CreatedAt, &newPref.UpdatedAt)
 
 		if err != nil {
 			return nil, 0, err
@@ -512,6 +531,67 @@ func (r *PreferenceRepo) ResolveRecipientPreferenceForTargets(ctx context.Contex
 	return r.resolvePreferences(ctx, projectID, recipientExtID, mediums, targets)
 }
 
+// TracePreference reads each rung of the cascade on its own, for the explain
+// endpoint. The joins are resolvePreferences' four, one per row of a fixed
+// rung list instead of side by side, so every rung comes back — matched or not —
+// in the order the cascade consults them.
+//
+// It answers "what is stored", never "what wins". The winner stays with
+// resolvePreferences, so a trace can never disagree with a send.
+func (r *PreferenceRepo) TracePreference(ctx context.Context, projectID int, recipientExtID string, target dto.Target, medium enum.Medium) ([]*entity.PreferenceRung, error) {
+	sql := `
+		-- INPUTS:
+		-- $1 = project_id
+		-- $2 = recipient_external_id
+		-- $3 = channel
+		-- $4 = topic
+		-- $5 = event
+		-- $6 = medium
+
+		SELECT
+		    rung.source,
+		    p.id,
+		    COALESCE(p.enabled, false),
+		    COALESCE(p.mandatory, false),
+		    (NOT rung.fallback OR $4 != 'none') AS consulted
+		FROM (VALUES
+		    (1, 'recipient_exact', true, false),
+		    (2, 'recipient_any', true, true),
+		    (3, 'project_exact', false, false),
+		    (4, 'project_any', false, true)
+		) AS rung(ord, source, own, fallback)
+		LEFT JOIN preference p
+		    ON p.project_id = $1
+		   AND (CASE WHEN rung.own THEN p.recipient_external_id = $2 ELSE p.recipient_external_id IS NULL END)
+		   AND p.channel = $3
+		   AND p.topic = (CASE WHEN rung.fallback THEN 'any' ELSE $4 END)
+		   AND p.event = $5
+		   AND p.medium = $6
+		ORDER BY rung.ord;
+	`
+
+	rows, err := r.db.Query(ctx, sql, projectID, recipientExtID, target.Channel, target.Topic, target.Event, string(medium))
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	rungs := []*entity.PreferenceRung{}
+	for rows.Next() {
+		var rung entity.PreferenceRung
+		if err := rows.Scan(&rung.Source, &rung.PreferenceID, &rung.Enabled, &rung.Mandatory, &rung.Consulted); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		rungs = append(rungs, &rung)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return rungs, nil
+}
+
 // resolvePreferences is the one cascade, run over a universe of cells.
 //
 // When targets is nil the universe is everything known about this recipient (the
@@ -684,32 +764,9 @@ func (r *PreferenceRepo) resolvePreferences(ctx context.Context, projectID int,
 // TestBroadcastAudienceMatchesEligibleList a tautology instead of a race.
 //
 // All three bind the same parameters: $1 project_id, $2 channel, $3 topic,
-// $4 event, $5 medium.
+// $4 event, $5 medium. The dry run's fan-out count binds $5 as an array of
+// mediums instead, and joins on each in turn through broadcastEligibilityJoinsOn.
 const (
-	// broadcastEligibilityJoins resolves the four cascade rungs for each recipient:
-	// their own exact row, their own topic='any' rule, the project's exact catalog
-	// entry, and the project's topic='any' entry. The `$3 != 'none'` guard mirrors
-	// the direct cascade — 'none' means "no topic", so a wildcard must not widen it.
-	broadcastEligibilityJoins = `
-		LEFT JOIN preference rp
-			ON rp.project_id = r.project_id
-			AND rp.recipient_external_id = r.external_id
-			AND rp.channel = $2 AND rp.topic = $3 AND rp.event = $4 AND rp.medium = $5
-		LEFT JOIN preference rf
-			ON rf.project_id = r.project_id
-			AND rf.recipient_external_id = r.external_id
-			AND rf.channel = $2 AND rf.topic = 'any' AND rf.event = $4 AND rf.medium = $5
-			AND $3 != 'none'
-		LEFT JOIN preference pp
-			ON pp.project_id = r.project_id
-			AND pp.recipient_external_id IS NULL
-			AND pp.channel = $2 AND pp.topic = $3 AND pp.event = $4 AND pp.medium = $5
-		LEFT JOIN preference pf
-			ON pf.project_id = r.project_id
-			AND pf.recipient_external_id IS NULL
-			AND pf.channel = $2 AND pf.topic = 'any' AND pf.event = $4 AND pf.medium = $5
-			AND $3 != 'none'`
-
 	// broadcastEligibleExpr is the cascade itself. Identical in shape and order to
 	// ResolveRecipientPreferences' COALESCE, with one deliberate difference: the
 	// final default is FALSE, not medium='in_app'. A direct in-app send delivers
@@ -727,36 +784,91 @@ const (
 		)`
 )
 
+// broadcastEligibilityJoins resolves the four cascade rungs for each recipient:
+// their own exact row, their own topic='any' rule, the project's exact catalog
+// entry, and the project's topic='any' entry. The `$3 != 'none'` guard mirrors
+// the direct cascade — 'none' means "no topic", so a wildcard must not widen it.
+var broadcastEligibilityJoins = broadcastEligibilityJoinsOn("$5")
+
+// broadcastEligibilityJoinsOn is broadcastEligibilityJoins with the medium taken
+// from an SQL expression instead of $5, so that one query can resolve each
+// recipient on several mediums (CountBroadcastFanOut). Same rungs, same rule.
+func broadcastEligibilityJoinsOn(medium string) string {
+	return fmt.Sprintf(`
+		LEFT JOIN preference rp
+			ON rp.project_id = r.project_id
+			AND rp.recipient_external_id = r.external_id
+			AND rp.channel = $2 AND rp.topic = $3 AND rp.event = $4 AND rp.medium = %[1]s
+		LEFT JOIN preference rf
+			ON rf.project_id = r.project_id
+			AND rf.recipient_external_id = r.external_id
+			AND rf.channel = $2 AND rf.topic = 'any' AND rf.event = $4 AND rf.medium = %[1]s
+			AND $3 != 'none'
+		LEFT JOIN preference pp
+			ON pp.project_id = r.project_id
+			AND pp.recipient_external_id IS NULL
+			AND pp.channel = $2 AND pp.topic = $3 AND pp.event = $4 AND pp.medium = %[1]s
+		LEFT JOIN preference pf
+			ON pf.project_id = r.project_id
+			AND pf.recipient_external_id IS NULL
+			AND pf.channel = $2 AND pf.topic = 'any' AND pf.event = $4 AND pf.medium = %[1]s
+			AND $3 != 'none'`, medium)
+}
+
 // CountBroadcastAudience is the aggregate twin of
 // ListEligibleRecipientExtIDsForBroadcast below. Both are built from the shared
 // fragments above so the console tree cannot stop adding up;
 // TestBroadcastAudienceMatchesEligibleList pins them together.
 //
-// The three buckets partition the project's recipients exactly:
+// The four buckets partition the project's recipients exactly:
 //
-//	cascade resolves true                         -> eligible
+//	outside the segment                             -> excluded_segment
+//	cascade resolves true                           -> eligible
 //	cascade false, recipient had a row of their own -> excluded_disabled
 //	cascade false, recipient said nothing           -> excluded_not_cataloged
-func (r *PreferenceRepo) CountBroadcastAudience(ctx context.Context, projectID int, target dto.Target, medium enum.Medium) (*entity.BroadcastAudience, error) {
+//
+// The segment is checked first, so the other three only count recipients the
+// sender aimed at. A nil segment excludes nobody.
+func (r *PreferenceRepo) CountBroadcastAudience(ctx context.Context, projectID int, target dto.Target, medium enum.Medium, segment *entity.AudienceSegment) (*entity.BroadcastAudience, error) {
+	args := []any{projectID, target.Channel, target.Topic, target.Event, string(medium)}
+	inSegment := segmentSQL(segment, &args)
+
+	return r.countBroadcastAudience(ctx, inSegment, "", args)
+}
+
+// CountBroadcastAudienceAmong is CountBroadcastAudience over a KNOWN set of
+// recipients — a list-based broadcast's list — instead of the whole project. Ids
+// with no recipient are simply not counted; Total is the recipients that exist.
+func (r *PreferenceRepo) CountBroadcastAudienceAmong(ctx context.Context, projectID int, target dto.Target, medium enum.Medium, recipientExtIDs []string) (*entity.BroadcastAudience, error) {
+	if len(recipientExtIDs) == 0 {
+		return &entity.BroadcastAudience{}, nil
+	}
+
+	args := []any{projectID, target.Channel, target.Topic, target.Event, string(medium), recipientExtIDs}
+	return r.countBroadcastAudience(ctx, "TRUE", "AND r.external_id = ANY($6)", args)
+}
+
+func (r *PreferenceRepo) countBroadcastAudience(ctx context.Context, inSegment, scope string, args []any) (*entity.BroadcastAudience, error) {
 	sql := `
 		SELECT
 			COUNT(*) AS total,
-			COUNT(*) FILTER (WHERE ` + broadcastEligibleExpr + `) AS eligible,
+			COUNT(*) FILTER (WHERE ` + inSegment + ` AND ` + broadcastEligibleExpr + `) AS eligible,
 			COUNT(*) FILTER (
-				WHERE NOT ` + broadcastEligibleExpr + `
+				WHERE ` + inSegment + ` AND NOT ` + broadcastEligibleExpr + `
 				AND (rp.id IS NOT NULL OR rf.id IS NOT NULL)
 			) AS excluded_disabled,
 			COUNT(*) FILTER (
-				WHERE NOT ` + broadcastEligibleExpr + `
+				WHERE ` + inSegment + ` AND NOT ` + broadcastEligibleExpr + `
 				AND rp.id IS NULL AND rf.id IS NULL
-			) AS excluded_not_cataloged
+			) AS excluded_not_cataloged,
+			COUNT(*) FILTER (WHERE NOT ` + inSegment + `) AS excluded_segment
 		FROM recipient r` + broadcastEligibilityJoins + `
-		WHERE r.project_id = $1
+		WHERE r.project_id = $1 ` + scope + `
 	`
 
 	var a entity.BroadcastAudience
-	err := r.db.QueryRow(ctx, sql, projectID, target.Channel, target.Topic, target.Event, string(medium)).
-		Scan(&a.Total, &a.Eligible, &a.ExcludedDisabled, &a.ExcludedNotCataloged)
+	err := r.db.QueryRow(ctx, sql, args...).
+		Scan(&a.Total, &a.Eligible, &a.ExcludedDisabled, &a.ExcludedNotCataloged, &a.ExcludedSegment)
 	if err != nil {
 		return nil, fmt.Errorf("query and scan: %w", err)
 	}
@@ -765,15 +877,19 @@ func (r *PreferenceRepo) CountBroadcastAudience(ctx context.Context, projectID i
 }
 
 // ListEligibleRecipientExtIDsForBroadcast returns the recipients opted in to a
-// (target, medium) for broadcast fan-out.
-func (r *PreferenceRepo) ListEligibleRecipientExtIDsForBroadcast(ctx context.Context, projectID int, target dto.Target, medium enum.Medium) ([]string, error) {
+// (target, medium) for broadcast fan-out, narrowed to the segment when there is
+// one.
+func (r *PreferenceRepo) ListEligibleRecipientExtIDsForBroadcast(ctx context.Context, projectID int, target dto.Target, medium enum.Medium, segment *entity.AudienceSegment) ([]string, error) {
+	args := []any{projectID, target.Channel, target.Topic, target.Event, string(medium)}
+	inSegment := segmentSQL(segment, &args)
+
 	sql := `
 		SELECT r.external_id
 		FROM recipient r` + broadcastEligibilityJoins + `
-		WHERE r.project_id = $1 AND ` + broadcastEligibleExpr + `;
+		WHERE r.project_id = $1 AND ` + inSegment + ` AND ` + broadcastEligibleExpr + `;
 	`
 
-	rows, err := r.db.Query(ctx, sql, projectID, target.Channel, target.Topic, target.Event, string(medium))
+	rows, err := r.db.Query(ctx, sql, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query: %w", err)
 	}
@@ -796,6 +912,80 @@ func (r *PreferenceRepo) ListEligibleRecipientExtIDsForBroadcast(ctx context.Con
 	return extIDs, nil
 }
 
+// CountBroadcastFanOut counts the recipients a broadcast would reach on any of
+// mediums — the union prepare_batches writes a notification for — and returns
+// the first sampleSize of them by external id. It is the eligibility rule of
+// ListEligibleRecipientExtIDsForBroadcast, resolved per medium in one pass, for
+// a caller that needs the size of the audience but not the audience.
+func (r *PreferenceRepo) CountBroadcastFanOut(ctx context.Context, projectID int, target dto.Target, mediums []enum.Medium, segment *entity.AudienceSegment, sampleSize int) (int, []string, error) {
+	args := []any{projectID, target.Channel, target.Topic, target.Event, mediumStrings(mediums)}
+	inSegment := segmentSQL(segment, &args)
+
+	return r.countBroadcastFanOut(ctx, inSegment, "", args, sampleSize)
+}
+
+// CountBroadcastFanOutAmong is CountBroadcastFanOut restricted to the given
+// recipients, for a list-based broadcast.
+func (r *PreferenceRepo) CountBroadcastFanOutAmong(ctx context.Context, projectID int, target dto.Target, mediums []enum.Medium, recipientExtIDs []string, sampleSize int) (int, []string, error) {
+	if len(recipientExtIDs) == 0 {
+		return 0, []string{}, nil
+	}
+
+	args := []any{projectID, target.Channel, target.Topic, target.Event, mediumStrings(mediums), recipientExtIDs}
+	return r.countBroadcastFanOut(ctx, "TRUE", "AND r.external_id = ANY($6)", args, sampleSize)
+}
+
+// countBroadcastFanOut reads the count off the sample rows (COUNT(*) OVER runs
+// before the LIMIT), so it asks for at least one row even when no sample is
+// wanted.
+func (r *PreferenceRepo) countBroadcastFanOut(ctx context.Context, inSegment, scope string, args []any, sampleSize int) (int, []string, error) {
+	args = append(args, max(sampleSize, 1))
+	limit := fmt.Sprintf("$%d", len(args))
+
+	sql := `
+		SELECT e.external_id, COUNT(*) OVER ()
+		FROM (
+			SELECT r.external_id
+			FROM recipient r
+			CROSS JOIN unnest($5::text[]) AS m(medium)` + broadcastEligibilityJoinsOn("m.medium") + `
+			WHERE r.project_id = $1 AND ` + inSegment + ` ` + scope + `
+			GROUP BY r.external_id
+			HAVING bool_or(` + broadcastEligibleExpr + `)
+		) e
+		ORDER BY e.external_id
+		LIMIT ` + limit + `
+	`
+
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return 0, nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	count := 0
+	sample := []string{}
+	for rows.Next() {
+		var extID string
+		if err := rows.Scan(&extID, &count); err != nil {
+			return 0, nil, fmt.Errorf("scan: %w", err)
+		}
+		sample = append(sample, extID)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return count, sample[:min(len(sample), sampleSize)], nil
+}
+
+func mediumStrings(mediums []enum.Medium) []string {
+	out := make([]string, len(mediums))
+	for i, m := range mediums {
+		out[i] = string(m)
+	}
+	return out
+}
+
 func (r *PreferenceRepo) DeleteForRecipient(ctx context.Context, projectID int, recipientExtID string) (int, error) {
 	sql := `
 		DELETE FROM preference
@@ -906,6 +1096,69 @@ func (r *PreferenceRepo) LookupCatalogEntry(ctx context.Context, projectID int,
 	return true, mandatory, nil
 }
 
+// LookupCatalogEmail reports how the email catalog entry a target resolves to
+// wants its emails sent: digested or not, and whether quiet hours apply. No
+// entry reads as the zero value — send immediately, quiet hours apply. It
+// matches exactly like LookupCatalogEntry — exact before wildcard — so a
+// per-conversation topic under a digested wildcard row is digested too.
+func (r *PreferenceRepo) LookupCatalogEmail(ctx context.Context, projectID int, target dto.Target) (entity.CatalogEmailOptions, error) {
+	sql := `
+		SELECT digest, bypass_quiet_hours
+		FROM preference
+		WHERE project_id = $1
+		  AND recipient_external_id IS NULL
+		  AND ` + catalogMatch("$2", "$3", "$4", "$5") + `
+		ORDER BY (topic = $3) DESC
+		LIMIT 1;
+	`
+
+	var opts entity.CatalogEmailOptions
+	var digest *enum.DigestWindow
+	err := r.db.QueryRow(ctx, sql, projectID, target.Channel, target.Topic, target.Event, string(enum.MediumEmail)).Scan(&digest, &opts.BypassQuietHours)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return entity.CatalogEmailOptions{}, nil
+		}
+
+		return entity.CatalogEmailOptions{}, fmt.Errorf("query: %w", err)
+	}
+
+	if digest != nil {
+		opts.Digest = *digest
+	}
+
+	return opts, nil
+}
+
+// LookupCatalogFrequencyCap returns the frequency cap of the catalog entry a
+// (target, medium) resolves to, or nil when the entry has none or there is no
+// entry. Matching is LookupCatalogEntry's, exact before wildcard, so one capped
+// wildcard row caps every concrete topic under it — each topic counted apart,
+// since the counter is keyed by the target that was sent.
+func (r *PreferenceRepo) LookupCatalogFrequencyCap(ctx context.Context, projectID int, target dto.Target, medium enum.Medium) (*entity.FrequencyCap, error) {
+	sql := `
+		SELECT frequency_cap_limit, frequency_cap_window
+		FROM preference
+		WHERE project_id = $1
+		  AND recipient_external_id IS NULL
+		  AND ` + catalogMatch("$2", "$3", "$4", "$5") + `
+		ORDER BY (topic = $3) DESC
+		LIMIT 1;
+	`
+
+	var pref entity.Preference
+	err := r.db.QueryRow(ctx, sql, projectID, target.Channel, target.Topic, target.Event, string(medium)).Scan(&pref.FrequencyCapLimit, &pref.FrequencyCapWindow)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return pref.FrequencyCap(), nil
+}
+
 // ListUncatalogedSentTargets reports every (target, medium) this project has
 // actually sent since `since` that its catalog does not cover — the sends that
 // would have been REJECTED if strict targets were on.
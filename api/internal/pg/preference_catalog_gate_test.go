@@ -305,7 +305,7 @@ func TestBroadcastEligibilityHonoursWildcardTopic(t *testing.T) {
 
 	target := dto.Target{Channel: "conversation", Topic: "thread-7", Event: "reply"}
 
-	eligible, err := repo.ListEligibleRecipientExtIDsForBroadcast(ctx, projectID, target, enum.MediumInApp)
+	eligible, err := repo.ListEligibleRecipientExtIDsForBroadcast(ctx, projectID, target, enum.MediumInApp, nil)
 	if err != nil {
 		t.Fatalf("list eligible: %v", err)
 	}
@@ -314,7 +314,7 @@ func TestBroadcastEligibilityHonoursWildcardTopic(t *testing.T) {
 	}
 
 	// The aggregate the console tree renders must agree with the list, cell for cell.
-	audience, err := repo.CountBroadcastAudience(ctx, projectID, target, enum.MediumInApp)
+	audience, err := repo.CountBroadcastAudience(ctx, projectID, target, enum.MediumInApp, nil)
 	if err != nil {
 		t.Fatalf("count audience: %v", err)
 	}
@@ -354,7 +354,7 @@ func TestUpdatingACatalogEntryDoesNotClearMandatory(t *testing.T) {
 	}
 
 	// A caller changing only the name — no mention of mandatory.
-	updated, err := repo.UpdateProjectPreference(ctx, projectID, id, "Password reset email", nil, true, nil)
+	updated, err := repo.UpdateProjectPreference(ctx, projectID, id, "Password reset email", nil, true, nil, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("update: %v", err)
 	}
@@ -367,7 +367,7 @@ func TestUpdatingACatalogEntryDoesNotClearMandatory(t *testing.T) {
 
 	// And it must still be settable when the caller DOES mean it.
 	off := false
-	updated, err = repo.UpdateProjectPreference(ctx, projectID, id, "Password reset email", nil, true, &off)
+	updated, err = repo.UpdateProjectPreference(ctx, projectID, id, "Password reset email", nil, true, &off, nil, nil, nil)
 	if err != nil {
 		t.Fatalf("update clearing mandatory: %v", err)
 	}
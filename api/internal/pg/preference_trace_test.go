@@ -0,0 +1,72 @@
+package pg
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+)
+
+// TestTracePreferenceListsEveryRung — the trace returns all four rungs in
+// cascade order, matched or not, and the one the resolver picks is among the
+// matched ones. A recipient's own off row loses to a mandatory catalog entry,
+// which is exactly the case a "why did this still send" question is about.
+func TestTracePreferenceListsEveryRung(t *testing.T) {
+	ctx, pool, projectID, repo := gateFixture(t)
+
+	catalogEntry(t, pool, projectID, "billing", "any", "invoice", enum.MediumEmail, true, true)
+	_, err := pool.Exec(context.Background(), `
+		INSERT INTO preference (project_id, recipient_external_id, channel, topic, event, medium, enabled, created_at, updated_at)
+		VALUES ($1, 'trace_user', 'billing', 'acct_1', 'invoice', 'email', false, now(), now())
+	`, projectID)
+	if err != nil {
+		t.Fatalf("insert recipient row: %v", err)
+	}
+
+	target := dto.Target{Channel: "billing", Topic: "acct_1", Event: "invoice"}
+
+	rungs, err := repo.TracePreference(ctx, projectID, "trace_user", target, enum.MediumEmail)
+	if err != nil {
+		t.Fatalf("trace: %v", err)
+	}
+
+	want := []struct {
+		source  entity.PreferenceSource
+		matched bool
+	}{
+		{entity.PreferenceSourceRecipientExact, true},
+		{entity.PreferenceSourceRecipientAny, false},
+		{entity.PreferenceSourceProjectExact, false},
+		{entity.PreferenceSourceProjectAny, true},
+	}
+	if len(rungs) != len(want) {
+		t.Fatalf("got %d rungs, want %d", len(rungs), len(want))
+	}
+	for i, w := range want {
+		if rungs[i].Source != w.source || (rungs[i].PreferenceID != nil) != w.matched || !rungs[i].Consulted {
+			t.Errorf("rung %d = %+v, want %s matched=%v", i, rungs[i], w.source, w.matched)
+		}
+	}
+	if !rungs[3].Mandatory || !rungs[3].Enabled || rungs[0].Enabled {
+		t.Errorf("rungs = %+v %+v, want the recipient off and the mandatory wildcard on", rungs[0], rungs[3])
+	}
+
+	resolved, err := repo.ResolveRecipientPreferenceForTargets(ctx, projectID, "trace_user", []enum.Medium{enum.MediumEmail}, []dto.Target{target})
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if resolved[0].Source != entity.PreferenceSourceProjectAny || !resolved[0].Enabled {
+		t.Errorf("resolved = %+v, want the mandatory wildcard to decide", resolved[0])
+	}
+
+	// A 'none' topic never consults the wildcards.
+	rungs, err = repo.TracePreference(ctx, projectID, "trace_user", dto.Target{Channel: "billing", Topic: "none", Event: "invoice"}, enum.MediumEmail)
+	if err != nil {
+		t.Fatalf("trace none: %v", err)
+	}
+	if rungs[1].Consulted || rungs[3].Consulted || !rungs[0].Consulted || !rungs[2].Consulted {
+		t.Errorf("none-topic rungs = %+v %+v %+v %+v, want the wildcards unconsulted", rungs[0], rungs[1], rungs[2], rungs[3])
+	}
+}
@@ -26,7 +26,7 @@ func NewProjectRepo(db *pgxpool.Pool) repository.ProjectRepository {
 
 // projectColumns is the one place the projection is written. Every read below
 // scans it in this order via scanProject.
-const projectColumns = `id, user_id, name, strict_targets, created_at, updated_at`
+const projectColumns = `id, user_id, name, strict_targets, require_recipient_token, created_at, updated_at`
 
 type scannable interface {
 	Scan(dest ...any) error
@@ -34,7 +34,7 @@ type scannable interface {
 
 func scanProject(row scannable) (*entity.Project, error) {
 	var p entity.Project
-	err := row.Scan(&p.ID, &p.UserID, &p.Name, &p.StrictTargets, &p.CreatedAt, &p.UpdatedAt)
+	err := row.Scan(&p.ID, &p.UserID, &p.Name, &p.StrictTargets, &p.RequireRecipientToken, &p.CreatedAt, &p.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -43,10 +43,10 @@ func scanProject(row scannable) (*entity.Project, error) {
 
 func (r *ProjectRepo) Create(ctx context.Context, project *entity.Project) (*entity.Project, error) {
 	sql := `
-		INSERT INTO project (user_id, name, strict_targets, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO project (user_id, name, strict_targets, require_recipient_token, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING ` + projectColumns
-	row := r.db.QueryRow(ctx, sql, project.UserID, project.Name, project.StrictTargets, project.CreatedAt, project.UpdatedAt)
+	row := r.db.QueryRow(ctx, sql, project.UserID, project.Name, project.StrictTargets, project.RequireRecipientToken, project.CreatedAt, project.UpdatedAt)
 
 	return scanProject(row)
 }
@@ -102,16 +102,18 @@ func (r *ProjectRepo) List(ctx context.Context, userID int) ([]*entity.Project,
 // Update is a PARTIAL update: a nil field means "not supplied", and the stored
 // value is kept. Writing the zero value instead would let a rename silently turn
 // strict targets off — the same bug that had to be fixed for preference.mandatory
-// (COALESCE below is the same remedy).
-func (r *ProjectRepo) Update(ctx context.Context, userID, projectID int, name string, strictTargets *bool) (*entity.Project, error) {
+// (COALESCE below is the same remedy). require_recipient_token gets the same
+// treatment for the same reason — a rename must not quietly re-open the feed.
+func (r *ProjectRepo) Update(ctx context.Context, userID, projectID int, name string, strictTargets, requireRecipientToken *bool) (*entity.Project, error) {
 	sql := `
 		UPDATE project
 		SET name = $1,
 		    strict_targets = COALESCE($2, strict_targets),
-		    updated_at = $3
-		WHERE user_id = $4 AND id = $5 AND deleted_at IS NULL
+		    require_recipient_token = COALESCE($3, require_recipient_token),
+		    updated_at = $4
+		WHERE user_id = $5 AND id = $6 AND deleted_at IS NULL
 		RETURNING ` + projectColumns
-	row := r.db.QueryRow(ctx, sql, name, strictTargets, time.Now().UTC(), userID, projectID)
+	row := r.db.QueryRow(ctx, sql, name, strictTargets, requireRecipientToken, time.Now().UTC(), userID, projectID)
 
 	p, err := scanProject(row)
 	if err != nil {
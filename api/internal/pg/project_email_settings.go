@@ -23,7 +23,7 @@ func NewProjectEmailSettingsRepo(db *pgxpool.Pool) repository.ProjectEmailSettin
 
 const projectEmailSettingsFields = `
 	project_id, provider, secret, nonce, from_name, from_address, webhook_secret, webhook_nonce,
-	max_broadcast_recipients_for_email, created_at, updated_at
+	max_broadcast_recipients_for_email, digest_subject, digest_html, created_at, updated_at
 `
 
 func scanProjectEmailSettings(row interface {
@@ -32,7 +32,7 @@ func scanProjectEmailSettings(row interface {
 	var s entity.ProjectEmailSettings
 	var provider string
 	err := row.Scan(&s.ProjectID, &provider, &s.Secret, &s.Nonce, &s.FromName, &s.FromAddress,
-		&s.WebhookSecret, &s.WebhookNonce, &s.MaxBroadcastRecipientsForEmail, &s.CreatedAt, &s.UpdatedAt)
+		&s.WebhookSecret, &s.WebhookNonce, &s.MaxBroadcastRecipientsForEmail, &s.DigestSubject, &s.DigestHTML, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -62,8 +62,8 @@ func (r *ProjectEmailSettingsRepo) Get(ctx context.Context, projectID int) (*ent
 func (r *ProjectEmailSettingsRepo) Upsert(ctx context.Context, s *entity.ProjectEmailSettings) (*entity.ProjectEmailSettings, error) {
 	sql := `
 		INSERT INTO project_email_settings
-			(project_id, provider, secret, nonce, from_name, from_address, webhook_secret, webhook_nonce, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			(project_id, provider, secret, nonce, from_name, from_address, webhook_secret, webhook_nonce, digest_subject, digest_html, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		ON CONFLICT (project_id) DO UPDATE SET
 			provider = EXCLUDED.provider,
 			secret = EXCLUDED.secret,
@@ -72,13 +72,15 @@ func (r *ProjectEmailSettingsRepo) Upsert(ctx context.Context, s *entity.Project
 			from_address = EXCLUDED.from_address,
 			webhook_secret = EXCLUDED.webhook_secret,
 			webhook_nonce = EXCLUDED.webhook_nonce,
+			digest_subject = EXCLUDED.digest_subject,
+			digest_html = EXCLUDED.digest_html,
 			updated_at = EXCLUDED.updated_at
 		RETURNING ` + projectEmailSettingsFields + `
 	`
 
 	row := r.db.QueryRow(ctx, sql,
 		s.ProjectID, string(s.Provider), s.Secret, s.Nonce, s.FromName, s.FromAddress,
-		s.WebhookSecret, s.WebhookNonce, s.CreatedAt, s.UpdatedAt,
+		s.WebhookSecret, s.WebhookNonce, s.DigestSubject, s.DigestHTML, s.CreatedAt, s.UpdatedAt,
 	)
 
 	return scanProjectEmailSettings(row)
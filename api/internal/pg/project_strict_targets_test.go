@@ -71,12 +71,12 @@ func TestUpdateWithoutStrictTargetsKeepsIt(t *testing.T) {
 	ctx, _, userID, projectID, repo := projectFixture(t)
 
 	on := true
-	if _, err := repo.Update(ctx, userID, projectID, "strict-targets-test", &on); err != nil {
+	if _, err := repo.Update(ctx, userID, projectID, "strict-targets-test", &on, nil); err != nil {
 		t.Fatalf("enable strict targets: %v", err)
 	}
 
 	// A rename, carrying no strict_targets at all.
-	renamed, err := repo.Update(ctx, userID, projectID, "renamed", nil)
+	renamed, err := repo.Update(ctx, userID, projectID, "renamed", nil, nil)
 	if err != nil {
 		t.Fatalf("rename: %v", err)
 	}
@@ -97,11 +97,11 @@ func TestUpdateCanTurnStrictTargetsOff(t *testing.T) {
 
 	on, off := true, false
 
-	if _, err := repo.Update(ctx, userID, projectID, "p", &on); err != nil {
+	if _, err := repo.Update(ctx, userID, projectID, "p", &on, nil); err != nil {
 		t.Fatalf("enable: %v", err)
 	}
 
-	updated, err := repo.Update(ctx, userID, projectID, "p", &off)
+	updated, err := repo.Update(ctx, userID, projectID, "p", &off, nil)
 	if err != nil {
 		t.Fatalf("disable: %v", err)
 	}
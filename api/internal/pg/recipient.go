@@ -2,7 +2,9 @@ package pg
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -27,17 +29,46 @@ func NewRecipientRepo(db *pgxpool.Pool) repository.RecipientRepository {
 	}
 }
 
+const recipientColumns = `id, external_id, name, project_id, locale, timezone, quiet_hours_start, quiet_hours_end, attributes, tags, created_at, updated_at`
+
+func recipientScanTargets(r *entity.Recipient) []any {
+	return []any{&r.ID, &r.ExternalID, &r.Name, &r.ProjectID, &r.Locale, &r.Timezone, &r.QuietHoursStart, &r.QuietHoursEnd, &r.Attributes, &r.Tags, &r.CreatedAt, &r.UpdatedAt}
+}
+
+// attributesParam encodes an attributes map for a ::jsonb parameter, nil when
+// the caller sent none.
+func attributesParam(attrs map[string]json.RawMessage) ([]byte, error) {
+	if attrs == nil {
+		return nil, nil
+	}
+	return json.Marshal(attrs)
+}
+
+// mergeAttributesSQL merges the jsonb parameter into `attributes`: sent keys
+// overwrite, and a key sent as null is removed.
+func mergeAttributesSQL(current, param string) string {
+	return fmt.Sprintf(`CASE WHEN %[2]s::jsonb IS NULL THEN %[1]s
+		ELSE (%[1]s || %[2]s::jsonb) - ARRAY(SELECT key FROM jsonb_each(%[2]s::jsonb) WHERE value = 'null'::jsonb) END`, current, param)
+}
+
 func (r *RecipientRepo) Create(ctx context.Context, recipient *entity.Recipient) (*entity.Recipient, error) {
+	attributes, err := attributesParam(recipient.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("marshal attributes: %w", err)
+	}
+
+	// Nulls in attributes mean "remove the key", which on a new row is nothing.
 	sql := `
-		INSERT INTO recipient (external_id, name, project_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, external_id, name, project_id, created_at, updated_at
-	`
-	row := r.db.QueryRow(ctx, sql, recipient.ExternalID, recipient.Name, recipient.ProjectID, recipient.CreatedAt, recipient.UpdatedAt)
+		INSERT INTO recipient (external_id, name, project_id, timezone, quiet_hours_start, quiet_hours_end, created_at, updated_at, locale, attributes, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE(jsonb_strip_nulls($10::jsonb), '{}'), COALESCE($11::text[], '{}'))
+		RETURNING ` + recipientColumns
+	row := r.db.QueryRow(ctx, sql, recipient.ExternalID, recipient.Name, recipient.ProjectID,
+		recipient.Timezone, recipient.QuietHoursStart, recipient.QuietHoursEnd, recipient.CreatedAt, recipient.UpdatedAt, recipient.Locale,
+		attributes, recipient.Tags)
 
 	var newRecipient entity.Recipient
 
-	err := row.Scan(&newRecipient.ID, &newRecipient.ExternalID, &newRecipient.Name, &newRecipient.ProjectID, &newRecipient.CreatedAt, &newRecipient.UpdatedAt)
+	err = row.Scan(recipientScanTargets(&newRecipient)...)
 	if err != nil {
 		if dbx.IsUniqueViolation(err) {
 			return nil, tantraRepo.ErrConflict
@@ -54,16 +85,31 @@ func (r *RecipientRepo) BatchCreate(ctx context.Context, recipients []*entity.Re
 	}
 
 	sql := `
-		INSERT INTO recipient (external_id, name, project_id, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO recipient (external_id, name, project_id, timezone, quiet_hours_start, quiet_hours_end, created_at, updated_at, locale, attributes, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE(jsonb_strip_nulls($10::jsonb), '{}'), COALESCE($11::text[], '{}'))
 		ON CONFLICT (project_id, external_id) DO UPDATE
-		SET name = EXCLUDED.name, updated_at = EXCLUDED.updated_at
+		SET name = EXCLUDED.name,
+			-- Omitted in the batch means "leave it": a re-sync that only knows names
+			-- must not wipe a timezone set elsewhere. The window moves as a pair.
+			timezone = COALESCE(EXCLUDED.timezone, recipient.timezone),
+			locale = COALESCE(EXCLUDED.locale, recipient.locale),
+			quiet_hours_start = CASE WHEN EXCLUDED.quiet_hours_start IS NULL THEN recipient.quiet_hours_start ELSE EXCLUDED.quiet_hours_start END,
+			quiet_hours_end = CASE WHEN EXCLUDED.quiet_hours_start IS NULL THEN recipient.quiet_hours_end ELSE EXCLUDED.quiet_hours_end END,
+			attributes = ` + mergeAttributesSQL("recipient.attributes", "$10") + `,
+			tags = COALESCE($11::text[], recipient.tags),
+			updated_at = EXCLUDED.updated_at
 		RETURNING (xmax = 0) AS inserted, external_id
 	`
 
 	batch := &pgx.Batch{}
 	for _, recipient := range recipients {
-		batch.Queue(sql, recipient.ExternalID, recipient.Name, recipient.ProjectID, recipient.CreatedAt, recipient.UpdatedAt)
+		attributes, err := attributesParam(recipient.Attributes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("marshal attributes of %s: %w", recipient.ExternalID, err)
+		}
+		batch.Queue(sql, recipient.ExternalID, recipient.Name, recipient.ProjectID,
+			recipient.Timezone, recipient.QuietHoursStart, recipient.QuietHoursEnd, recipient.CreatedAt, recipient.UpdatedAt, recipient.Locale,
+			attributes, recipient.Tags)
 	}
 
 	batchResult := r.pool.SendBatch(ctx, batch)
@@ -88,12 +134,14 @@ func (r *RecipientRepo) BatchCreate(ctx context.Context, recipients []*entity.Re
 	return created, updated, nil
 }
 
-func (r *RecipientRepo) List(ctx context.Context, projectID int, pagination query.Pagination) ([]*entity.RecipientListItem, int, error) {
+func (r *RecipientRepo) List(ctx context.Context, filters *dto.ListRecipientsPayload) ([]*entity.RecipientListItem, int, error) {
 	payload := repository.SearchRecipientPayload{
 		Filters: repository.RecipientSearchFilter{
-			ProjectID: &projectID,
+			ProjectID:  &filters.ProjectID,
+			Tags:       filters.Tags,
+			Attributes: filters.Attributes,
 		},
-		Pagination: pagination,
+		Pagination: filters.Pagination,
 	}
 	recipients, total, err := r.findRecipients(ctx, payload, true)
 	return recipients, total, err
@@ -145,15 +193,29 @@ func (r *RecipientRepo) GetListItem(ctx context.Context, projectID int, external
 }
 
 func (r *RecipientRepo) Update(ctx context.Context, projectID int, externalID string, payload *dto.UpdateRecipientPayload) (*entity.Recipient, error) {
+	attributes, err := attributesParam(payload.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("marshal attributes: %w", err)
+	}
+
+	// Every field is optional: nil keeps the stored value, and "" clears the
+	// nullable ones. The payload guarantees the quiet-hours pair arrives together.
 	sql := `
 		UPDATE recipient
-		SET name = $1, updated_at = $2
+		SET name = COALESCE($1, name),
+			locale = CASE WHEN $8::text IS NULL THEN locale ELSE NULLIF($8::text, '') END,
+			attributes = ` + mergeAttributesSQL("attributes", "$9") + `,
+			tags = COALESCE($10::text[], tags),
+			timezone = CASE WHEN $5::text IS NULL THEN timezone ELSE NULLIF($5::text, '') END,
+			quiet_hours_start = CASE WHEN $6::text IS NULL THEN quiet_hours_start ELSE NULLIF($6::text, '') END,
+			quiet_hours_end = CASE WHEN $7::text IS NULL THEN quiet_hours_end ELSE NULLIF($7::text, '') END,
+			updated_at = $2
 		WHERE project_id = $3 AND external_id = $4
-		RETURNING id, external_id, name, project_id, created_at, updated_at
-	`
-	row := r.db.QueryRow(ctx, sql, payload.Name, time.Now().UTC(), projectID, externalID)
+		RETURNING ` + recipientColumns
+	row := r.db.QueryRow(ctx, sql, payload.Name, time.Now().UTC(), projectID, externalID,
+		payload.Timezone, payload.QuietHoursStart, payload.QuietHoursEnd, payload.Locale, attributes, payload.Tags)
 	var updated entity.Recipient
-	err := row.Scan(&updated.ID, &updated.ExternalID, &updated.Name, &updated.ProjectID, &updated.CreatedAt, &updated.UpdatedAt)
+	err = row.Scan(recipientScanTargets(&updated)...)
 	if err != nil {
 		if err.Error() == "no rows in result set" {
 			return nil, tantraRepo.ErrNotFound
@@ -191,7 +253,7 @@ func (r *RecipientRepo) Delete(ctx context.Context, projectID int, externalID st
 
 func (r *RecipientRepo) findRecipients(ctx context.Context, payload repository.SearchRecipientPayload, includeNotificationsCount bool) ([]*entity.RecipientListItem, int, error) {
 	const baseFields = `
-	r.id, r.external_id, r.name, r.project_id, r.created_at, r.updated_at
+	r.id, r.external_id, r.name, r.project_id, r.locale, r.timezone, r.quiet_hours_start, r.quiet_hours_end, r.attributes, r.tags, r.created_at, r.updated_at
 `
 
 	var baseSQL string
@@ -227,6 +289,23 @@ func (r *RecipientRepo) findRecipients(ctx context.Context, payload repository.S
 		builder.AddCompareFilter("r.external_id", "=", *payload.Filters.ExternalID)
 	}
 
+	// Both are containment checks so the GIN indexes on tags and attributes
+	// serve them (see 20260815120000_add_recipient_attributes.sql).
+	if len(payload.Filters.Tags) > 0 {
+		builder.AppendWhere(fmt.Sprintf("r.tags @> $%d::text[]", builder.ArgNum()), payload.Filters.Tags)
+	}
+
+	for _, attr := range payload.Filters.Attributes {
+		matches := attr.Matches()
+		conds := make([]string, len(matches))
+		args := make([]any, len(matches))
+		for i, m := range matches {
+			conds[i] = fmt.Sprintf("r.attributes @> $%d::jsonb", builder.ArgNum()+i)
+			args[i] = []byte(m)
+		}
+		builder.AppendWhere("("+strings.Join(conds, " OR ")+")", args...)
+	}
+
 	// Apply default sorting if not provided.
 	if payload.Sort.Field == "" {
 		payload.Sort.Field = "r.id"
@@ -245,7 +324,7 @@ func (r *RecipientRepo) findRecipients(ctx context.Context, payload repository.S
 	}
 
 	if includeNotificationsCount {
-		builder.AddGroupBy("r.id, r.external_id, r.name, r.project_id, r.created_at, r.updated_at")
+		builder.AddGroupBy("r.id, r.external_id, r.name, r.project_id, r.locale, r.timezone, r.quiet_hours_start, r.quiet_hours_end, r.attributes, r.tags, r.created_at, r.updated_at")
 	}
 
 	builder.AddPagination(payload.Pagination.Limit, payload.Pagination.Offset())
@@ -265,11 +344,11 @@ func (r *RecipientRepo) findRecipients(ctx context.Context, payload repository.S
 		var newRecipient entity.RecipientListItem
 		var err error
 
+		targets := recipientScanTargets(&newRecipient.Recipient)
 		if includeNotificationsCount {
-			err = rows.Scan(&newRecipient.ID, &newRecipient.ExternalID, &newRecipient.Name, &newRecipient.ProjectID, &newRecipient.CreatedAt, &newRecipient.UpdatedAt, &newRecipient.DirectNotificationsCount, &newRecipient.BroadcastNotificationsCount)
-		} else {
-			err = rows.Scan(&newRecipient.ID, &newRecipient.ExternalID, &newRecipient.Name, &newRecipient.ProjectID, &newRecipient.CreatedAt, &newRecipient.UpdatedAt)
+			targets = append(targets, &newRecipient.DirectNotificationsCount, &newRecipient.BroadcastNotificationsCount)
 		}
+		err = rows.Scan(targets...)
 
 		if err != nil {
 			return nil, 0, fmt.Errorf("scan: %w", err)
@@ -292,6 +371,84 @@ func (r *RecipientRepo) findRecipients(ctx context.Context, payload repository.S
 	return recipients, total, nil
 }
 
+// Locales reads the locale of every recipient in a broadcast batch in one query,
+// so picking content variants costs a batch one round trip, not one per row.
+func (r *RecipientRepo) Locales(ctx context.Context, projectID int, externalIDs []string) (map[string]string, error) {
+	locales := make(map[string]string)
+	if len(externalIDs) == 0 {
+		return locales, nil
+	}
+
+	sql := `
+		SELECT external_id, locale
+		FROM recipient
+		WHERE project_id = $1 AND external_id = ANY($2) AND locale IS NOT NULL
+	`
+	rows, err := r.db.Query(ctx, sql, projectID, externalIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var externalID, locale string
+		if err := rows.Scan(&externalID, &locale); err != nil {
+			return nil, fmt.Errorf("scan: %w", err)
+		}
+		locales[externalID] = locale
+	}
+
+	return locales, rows.Err()
+}
+
+// Missing returns the ids among externalIDs that have no recipient in the
+// project, in the order given.
+func (r *RecipientRepo) Missing(ctx context.Context, projectID int, externalIDs []string) ([]string, error) {
+	if len(externalIDs) == 0 {
+		return nil, nil
+	}
+
+	sql := `
+		SELECT id
+		FROM unnest($2::text[]) WITH ORDINALITY AS given(id, ord)
+		WHERE NOT EXISTS (
+			SELECT 1 FROM recipient
+			WHERE project_id = $1 AND external_id = given.id
+		)
+		ORDER BY ord
+	`
+	rows, err := r.db.Query(ctx, sql, projectID, externalIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// CreateMissing creates a bare recipient for each id that has none, leaving
+// existing recipients untouched, and returns the ids it created. One statement,
+// so a list of any size costs one round trip and races a concurrent create
+// harmlessly.
+func (r *RecipientRepo) CreateMissing(ctx context.Context, projectID int, externalIDs []string) ([]string, error) {
+	if len(externalIDs) == 0 {
+		return nil, nil
+	}
+
+	sql := `
+		INSERT INTO recipient (project_id, external_id, created_at, updated_at)
+		SELECT $1, id, now(), now()
+		FROM unnest($2::text[]) AS given(id)
+		ON CONFLICT (project_id, external_id) DO NOTHING
+		RETURNING external_id
+	`
+	rows, err := r.db.Query(ctx, sql, projectID, externalIDs)
+	if err != nil {
+		return nil, fmt.Errorf("query: %w", err)
+	}
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
 func (r *RecipientRepo) Exists(ctx context.Context, projectID int, externalID string) (bool, error) {
 	sql := `
 		SELECT EXISTS (
@@ -0,0 +1,85 @@
+package pg
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TestRecipientMissingAndCreateMissing covers the two halves of a list-based
+// broadcast's unknown-id handling: reporting the ids with no recipient, and
+// creating them without touching the recipients that already exist.
+//
+// Skipped unless TEST_DB_URL is set. Self-cleaning.
+func TestRecipientMissingAndCreateMissing(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set; skipping DB integration test")
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	var userID int
+	if err := pool.QueryRow(ctx, `SELECT user_id FROM project ORDER BY id LIMIT 1`).Scan(&userID); err != nil {
+		t.Fatalf("need at least one existing project to borrow a user_id: %v", err)
+	}
+
+	var projectID int
+	err = pool.QueryRow(ctx, `
+		INSERT INTO project (user_id, name, created_at, updated_at)
+		VALUES ($1, 'missing-recipients-test', now(), now()) RETURNING id
+	`, userID).Scan(&projectID)
+	if err != nil {
+		t.Fatalf("insert project: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = pool.Exec(context.Background(), `DELETE FROM project WHERE id = $1`, projectID)
+	})
+
+	if _, err := pool.Exec(ctx, `
+		INSERT INTO recipient (project_id, external_id, name, created_at, updated_at)
+		VALUES ($1, 'known', 'Known Person', now(), now())
+	`, projectID); err != nil {
+		t.Fatalf("insert recipient: %v", err)
+	}
+
+	repo := NewRecipientRepo(pool)
+	ids := []string{"new-b", "known", "new-a"}
+
+	missing, err := repo.Missing(ctx, projectID, ids)
+	if err != nil {
+		t.Fatalf("missing: %v", err)
+	}
+	if want := []string{"new-b", "new-a"}; !slices.Equal(missing, want) {
+		t.Errorf("missing = %v, want %v in the order given", missing, want)
+	}
+
+	created, err := repo.CreateMissing(ctx, projectID, ids)
+	if err != nil {
+		t.Fatalf("create missing: %v", err)
+	}
+	slices.Sort(created)
+	if want := []string{"new-a", "new-b"}; !slices.Equal(created, want) {
+		t.Errorf("created = %v, want %v", created, want)
+	}
+
+	known, err := repo.Get(ctx, projectID, "known")
+	if err != nil {
+		t.Fatalf("get known: %v", err)
+	}
+	if known.Name != "Known Person" {
+		t.Errorf("existing recipient's name = %q, want it untouched", known.Name)
+	}
+
+	if missing, err := repo.Missing(ctx, projectID, ids); err != nil || len(missing) != 0 {
+		t.Errorf("after create, missing = %v (err %v), want none", missing, err)
+	}
+}
@@ -47,3 +47,33 @@ func (r *UsageLogRepo) Add(ctx context.Context, tx pgx.Tx, projectID int, metric
 
 	return nil
 }
+
+// Refund records a negative usage_log row and takes the amount off the aggregate
+// of the period usedAt fell in — the period that was charged, which need not be
+// the current one. The aggregate never goes below zero.
+//
+// The log row is dated usedAt too, not now: summed by period, usage_log must
+// agree with usage_aggregate, and a refund dated now would land in the next
+// period whenever a cancel crosses a boundary.
+func (r *UsageLogRepo) Refund(ctx context.Context, tx pgx.Tx, projectID int, metric entity.Metric, amount int64, usedAt time.Time) error {
+	now := time.Now().UTC()
+
+	_, err := tx.Exec(ctx, `
+        INSERT INTO usage_log (project_id, metric, amount, used_at)
+        VALUES ($1, $2, $3, $4)
+    `, projectID, metric, -amount, usedAt)
+	if err != nil {
+		return fmt.Errorf("inserting usage refund log: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+        UPDATE usage_aggregate
+        SET used = GREATEST(used - $3, 0), updated_at = $5
+        WHERE project_id = $1 AND metric = $2 AND period_start <= $4 AND period_end > $4
+    `, projectID, metric, amount, usedAt, now)
+	if err != nil {
+		return fmt.Errorf("refunding usage aggregate: %w", err)
+	}
+
+	return nil
+}
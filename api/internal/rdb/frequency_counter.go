@@ -0,0 +1,39 @@
+package rdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type FrequencyCounterRepo struct {
+	client redis.UniversalClient
+}
+
+func NewFrequencyCounterRepo(client redis.UniversalClient) *FrequencyCounterRepo {
+	return &FrequencyCounterRepo{client: client}
+}
+
+// Take counts one send in a fixed window: the key carries the window's start,
+// so a new window is a new key and nothing has to be reset. Windows are aligned
+// to the epoch, which makes a "day" a UTC day.
+//
+// INCR and the expiry go in one MULTI so a key can never be left without a TTL.
+// The expiry is an absolute time derived from the window, so setting it on every
+// send is idempotent; the minute past the window's end only keeps a key alive
+// for a send whose clock is slightly behind ours.
+func (r *FrequencyCounterRepo) Take(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (bool, error) {
+	start := now.Truncate(window)
+	redisKey := fmt.Sprintf("bodhveda:frequency_cap:%s:%d", key, start.Unix())
+
+	pipe := r.client.TxPipeline()
+	count := pipe.Incr(ctx, redisKey)
+	pipe.ExpireAt(ctx, redisKey, start.Add(window).Add(time.Minute))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("count frequency cap: %w", err)
+	}
+
+	return count.Val() <= int64(limit), nil
+}
@@ -0,0 +1,61 @@
+package rdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+func testCounter(t *testing.T) *FrequencyCounterRepo {
+	t.Helper()
+
+	uri := os.Getenv("TEST_REDIS_URL")
+	if uri == "" {
+		t.Skip("TEST_REDIS_URL not set; skipping Redis integration test")
+	}
+
+	client, err := NewClient(uri)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return NewFrequencyCounterRepo(client)
+}
+
+func TestFrequencyCounterTake(t *testing.T) {
+	repo := testCounter(t)
+	ctx := context.Background()
+	key := fmt.Sprintf("test:%d", time.Now().UnixNano())
+	now := time.Date(2026, 8, 10, 12, 30, 0, 0, time.UTC)
+
+	for i := 1; i <= 3; i++ {
+		ok, err := repo.Take(ctx, key, 2, time.Hour, now)
+		if err != nil {
+			t.Fatalf("take %d: %v", i, err)
+		}
+		if want := i <= 2; ok != want {
+			t.Fatalf("take %d = %v, want %v", i, ok, want)
+		}
+	}
+
+	// The next window starts from zero.
+	ok, err := repo.Take(ctx, key, 2, time.Hour, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("take in next window: %v", err)
+	}
+	if !ok {
+		t.Error("a new window must not inherit the previous window's count")
+	}
+
+	// Keys must never be left without a TTL, or the counters leak forever.
+	ttl, err := repo.client.TTL(ctx, fmt.Sprintf("bodhveda:frequency_cap:%s:%d", key, now.Truncate(time.Hour).Unix())).Result()
+	if err != nil {
+		t.Fatalf("ttl: %v", err)
+	}
+	if ttl <= 0 {
+		t.Errorf("ttl = %v, want a positive expiry", ttl)
+	}
+}
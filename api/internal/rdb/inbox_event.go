@@ -0,0 +1,323 @@
+package rdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/tantra/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// InboxEventRepo holds ONE Redis connection for every listener on the process,
+// however many streams and gateway subscriptions are open: a PubSub is a
+// dedicated connection, and one per stream would run Redis out of clients long
+// before the API ran out of anything. Listeners share a channel by refcount; the
+// first on it subscribes the connection and the last off it unsubscribes.
+type InboxEventRepo struct {
+	client redis.UniversalClient
+
+	start  sync.Once
+	pubsub *redis.PubSub
+
+	mu       sync.Mutex
+	channels map[string]*inboxFollowers
+}
+
+func NewInboxEventRepo(client redis.UniversalClient) *InboxEventRepo {
+	return &InboxEventRepo{client: client, channels: map[string]*inboxFollowers{}}
+}
+
+// inboxChannel is one recipient's channel. Per recipient rather than per
+// project so a replica only receives what its own listeners asked for; Redis
+// keeps no state for a channel with no subscribers, so there is nothing to
+// clean up either.
+func inboxChannel(projectID int, recipientExtID string) string {
+	return fmt.Sprintf("bodhveda:inbox:%d:%s", projectID, recipientExtID)
+}
+
+// Publish pipelines one PUBLISH per event, so a broadcast batch is a single
+// round trip however many recipients it wrote to.
+func (r *InboxEventRepo) Publish(ctx context.Context, projectID int, events ...*dto.InboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+	for _, event := range events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("marshal inbox event: %w", err)
+		}
+		pipe.Publish(ctx, inboxChannel(projectID, event.RecipientExtID), body)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("publish inbox events: %w", err)
+	}
+	return nil
+}
+
+// Subscribe is a listener following one recipient.
+func (r *InboxEventRepo) Subscribe(ctx context.Context, projectID int, recipientExtID string) (<-chan *dto.InboxEvent, error) {
+	l := r.listen(ctx, projectID)
+	if err := l.Follow(ctx, recipientExtID); err != nil {
+		l.stop()
+		return nil, err
+	}
+	return l.Events(), nil
+}
+
+// Listen opens a listener on the process's shared connection, which is made on
+// the first Follow of any listener and kept for the life of the process. The
+// listener stops when ctx ends.
+func (r *InboxEventRepo) Listen(ctx context.Context, projectID int) repository.InboxListener {
+	return r.listen(ctx, projectID)
+}
+
+func (r *InboxEventRepo) listen(ctx context.Context, projectID int) *inboxListener {
+	ctx, stop := context.WithCancel(ctx)
+	l := &inboxListener{
+		repo:      r,
+		stop:      stop,
+		projectID: projectID,
+		done:      ctx.Done(),
+		events:    make(chan *dto.InboxEvent, inboxListenerBuffer),
+		following: map[string]bool{},
+	}
+	go func() {
+		<-l.done
+		r.mu.Lock()
+		r.closeLocked(l)
+		r.mu.Unlock()
+	}()
+	return l
+}
+
+// inboxListenerBuffer is how far a listener may fall behind the bus before it
+// is closed. Delivery never waits on a listener, since one slow stream would
+// hold up every other on the process; a closed one reconnects and replays.
+const inboxListenerBuffer = 256
+
+// inboxFollowers is one channel's side of the shared connection.
+type inboxFollowers struct {
+	recipientExtID string
+	listeners      map[*inboxListener]bool
+	// inFlight counts SUBSCRIBEs sent for the channel and not yet confirmed.
+	// waiting are the Follow calls released when the last of them is, which
+	// is the one that matters after an unsubscribe and a resubscribe.
+	inFlight int
+	waiting  []chan struct{}
+}
+
+// connect starts the shared connection and its reader, once.
+func (r *InboxEventRepo) connect() {
+	r.start.Do(func() {
+		r.pubsub = r.client.Subscribe(context.Background())
+		go r.run(r.pubsub.ChannelWithSubscriptions())
+	})
+}
+
+// follow adds l to channel, subscribing the connection if l is the channel's
+// first listener. It returns the channel to wait on for the subscription, or
+// nil when it is live already.
+//
+// The lock is held across the SUBSCRIBE and UNSUBSCRIBE writes so the commands
+// reach Redis in the order the map changed. Neither waits for a reply.
+func (r *InboxEventRepo) follow(l *inboxListener, channel, recipientExtID string) (<-chan struct{}, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l.closed {
+		return nil, errors.New("subscribe inbox events: listener closed")
+	}
+
+	f := r.channels[channel]
+	if f == nil {
+		f = &inboxFollowers{recipientExtID: recipientExtID, listeners: map[*inboxListener]bool{}}
+		r.channels[channel] = f
+	}
+	if f.listeners[l] {
+		return nil, nil
+	}
+	f.listeners[l] = true
+	l.following[channel] = true
+
+	if len(f.listeners) > 1 {
+		if f.inFlight == 0 {
+			return nil, nil
+		}
+		confirmed := make(chan struct{})
+		f.waiting = append(f.waiting, confirmed)
+		return confirmed, nil
+	}
+
+	if err := r.pubsub.Subscribe(context.Background(), channel); err != nil {
+		r.unfollowLocked(l, channel)
+		return nil, fmt.Errorf("subscribe inbox events: %w", err)
+	}
+	f.inFlight++
+	confirmed := make(chan struct{})
+	f.waiting = append(f.waiting, confirmed)
+	return confirmed, nil
+}
+
+// unfollowLocked takes l off channel, unsubscribing the connection once nobody
+// is left on it.
+func (r *InboxEventRepo) unfollowLocked(l *inboxListener, channel string) {
+	delete(l.following, channel)
+
+	f := r.channels[channel]
+	if f == nil || !f.listeners[l] {
+		return
+	}
+	delete(f.listeners, l)
+	if len(f.listeners) > 0 {
+		return
+	}
+
+	if f.inFlight == 0 {
+		delete(r.channels, channel)
+	}
+	if err := r.pubsub.Unsubscribe(context.Background(), channel); err != nil {
+		logger.Get().Errorw("unsubscribe inbox events", "error", err, "channel", channel)
+	}
+}
+
+// closeLocked stops l: off every channel, and its events closed.
+func (r *InboxEventRepo) closeLocked(l *inboxListener) {
+	if l.closed {
+		return
+	}
+	l.closed = true
+	for channel := range l.following {
+		r.unfollowLocked(l, channel)
+	}
+	close(l.events)
+	l.stop()
+}
+
+// run is the shared connection's only reader. It hands each event to the
+// channel's listeners without waiting on any of them, under the lock, which is
+// also what makes closing a listener's events safe.
+func (r *InboxEventRepo) run(messages <-chan any) {
+	for msg := range messages {
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if msg.Kind == "subscribe" {
+				r.confirm(msg.Channel)
+			}
+
+		case *redis.Message:
+			var event dto.InboxEvent
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				logger.Get().Errorw("decode inbox event", "error", err, "channel", msg.Channel)
+				continue
+			}
+
+			r.mu.Lock()
+			if f := r.channels[msg.Channel]; f != nil {
+				for l := range f.listeners {
+					e := event
+					e.RecipientExtID = f.recipientExtID
+					select {
+					case l.events <- &e:
+					default:
+						logger.Get().Warnw("inbox listener fell behind; closing it", "channel", msg.Channel)
+						r.closeLocked(l)
+					}
+				}
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// confirm releases the Follow calls waiting on channel once its last SUBSCRIBE
+// is confirmed. A confirmation nobody sent for is go-redis resubscribing after
+// a reconnect: whatever was published while the connection was down is lost,
+// so the channel's listeners are closed to make their clients replay it.
+func (r *InboxEventRepo) confirm(channel string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f := r.channels[channel]
+	if f == nil {
+		return
+	}
+
+	if f.inFlight == 0 {
+		for l := range f.listeners {
+			r.closeLocked(l)
+		}
+		return
+	}
+
+	f.inFlight--
+	if f.inFlight > 0 {
+		return
+	}
+	for _, confirmed := range f.waiting {
+		close(confirmed)
+	}
+	f.waiting = nil
+	if len(f.listeners) == 0 {
+		delete(r.channels, channel)
+	}
+}
+
+// inboxListener is one caller's view of the shared connection: the recipients
+// it follows, and its own events. Its fields past done are guarded by the
+// repo's lock.
+type inboxListener struct {
+	repo      *InboxEventRepo
+	stop      context.CancelFunc
+	projectID int
+	done      <-chan struct{}
+
+	events    chan *dto.InboxEvent
+	following map[string]bool
+	closed    bool
+}
+
+func (l *inboxListener) Events() <-chan *dto.InboxEvent {
+	return l.events
+}
+
+// Follow subscribes to a recipient's channel. Subscribe itself does not wait
+// for Redis; the confirmation does, and only once it has arrived is a PUBLISH
+// guaranteed to reach us — which is what lets the caller replay from Postgres
+// after this returns without a gap between the two. A channel another listener
+// already has live returns at once.
+func (l *inboxListener) Follow(ctx context.Context, recipientExtID string) error {
+	l.repo.connect()
+
+	channel := inboxChannel(l.projectID, recipientExtID)
+	confirmed, err := l.repo.follow(l, channel, recipientExtID)
+	if err != nil || confirmed == nil {
+		return err
+	}
+
+	select {
+	case <-confirmed:
+		return nil
+	case <-ctx.Done():
+		_ = l.Unfollow(context.Background(), recipientExtID)
+		return fmt.Errorf("subscribe inbox events: %w", ctx.Err())
+	case <-l.done:
+		return errors.New("subscribe inbox events: listener closed")
+	}
+}
+
+// Unfollow takes effect at once for this listener: an event for the recipient
+// still on its way is dropped here, not delivered.
+func (l *inboxListener) Unfollow(ctx context.Context, recipientExtID string) error {
+	l.repo.mu.Lock()
+	l.repo.unfollowLocked(l, inboxChannel(l.projectID, recipientExtID))
+	l.repo.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,173 @@
+package rdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+)
+
+func testInboxEvents(t *testing.T) *InboxEventRepo {
+	t.Helper()
+
+	uri := os.Getenv("TEST_REDIS_URL")
+	if uri == "" {
+		t.Skip("TEST_REDIS_URL not set; skipping Redis integration test")
+	}
+
+	client, err := NewClient(uri)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return NewInboxEventRepo(client)
+}
+
+// TestInboxEventsReachOnlyTheirRecipient — a subscriber hears its own
+// recipient's events, in order, and nobody else's.
+func TestInboxEventsReachOnlyTheirRecipient(t *testing.T) {
+	repo := testInboxEvents(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	recipient := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	events, err := repo.Subscribe(ctx, 1, recipient)
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	err = repo.Publish(ctx, 1,
+		&dto.InboxEvent{Type: dto.InboxEventCreated, RecipientExtID: "someone-else", Notification: &dto.Notification{ID: 1}},
+		&dto.InboxEvent{Type: dto.InboxEventCreated, RecipientExtID: recipient, Notification: &dto.Notification{ID: 2}},
+		dto.NewInboxDeletedEvent(recipient, []int{2}),
+	)
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	created := <-events
+	if created == nil || created.Type != dto.InboxEventCreated || created.Notification.ID != 2 {
+		t.Fatalf("first event = %+v, want created for notification 2", created)
+	}
+	if created.RecipientExtID != recipient {
+		t.Errorf("recipient = %q, want %q", created.RecipientExtID, recipient)
+	}
+
+	deleted := <-events
+	if deleted == nil || deleted.Type != dto.InboxEventDeleted || len(deleted.IDs) != 1 || deleted.IDs[0] != 2 {
+		t.Fatalf("second event = %+v, want deleted for notification 2", deleted)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+// TestInboxListenerFollowsSeveral — one listener carries several recipients'
+// events, each tagged with its recipient, and stops carrying one it unfollows.
+func TestInboxListenerFollowsSeveral(t *testing.T) {
+	repo := testInboxEvents(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	a := fmt.Sprintf("test-a-%d", time.Now().UnixNano())
+	b := fmt.Sprintf("test-b-%d", time.Now().UnixNano())
+
+	l := repo.Listen(ctx, 1)
+	for _, recipient := range []string{a, b} {
+		if err := l.Follow(ctx, recipient); err != nil {
+			t.Fatalf("follow %s: %v", recipient, err)
+		}
+	}
+
+	err := repo.Publish(ctx, 1,
+		&dto.InboxEvent{Type: dto.InboxEventCreated, RecipientExtID: a, Notification: &dto.Notification{ID: 1}},
+		&dto.InboxEvent{Type: dto.InboxEventCreated, RecipientExtID: b, Notification: &dto.Notification{ID: 2}},
+	)
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	for _, want := range []string{a, b} {
+		if got := <-l.Events(); got == nil || got.RecipientExtID != want {
+			t.Fatalf("event = %+v, want one for %s", got, want)
+		}
+	}
+
+	if err := l.Unfollow(ctx, a); err != nil {
+		t.Fatalf("unfollow: %v", err)
+	}
+
+	err = repo.Publish(ctx, 1,
+		&dto.InboxEvent{Type: dto.InboxEventCreated, RecipientExtID: a, Notification: &dto.Notification{ID: 3}},
+		&dto.InboxEvent{Type: dto.InboxEventCreated, RecipientExtID: b, Notification: &dto.Notification{ID: 4}},
+	)
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if got := <-l.Events(); got == nil || got.Notification.ID != 4 {
+		t.Fatalf("event after unfollow = %+v, want only b's notification 4", got)
+	}
+
+	cancel()
+	for range l.Events() {
+	}
+}
+
+// TestInboxListenersShareAChannel — two listeners on one recipient both hear
+// it, and the first one leaving does not unsubscribe the other.
+func TestInboxListenersShareAChannel(t *testing.T) {
+	repo := testInboxEvents(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	recipient := fmt.Sprintf("test-shared-%d", time.Now().UnixNano())
+
+	firstCtx, leave := context.WithCancel(ctx)
+	first, err := repo.Subscribe(firstCtx, 1, recipient)
+	if err != nil {
+		t.Fatalf("subscribe first: %v", err)
+	}
+	second, err := repo.Subscribe(ctx, 1, recipient)
+	if err != nil {
+		t.Fatalf("subscribe second: %v", err)
+	}
+
+	publish := func(id int) {
+		t.Helper()
+		err := repo.Publish(ctx, 1, &dto.InboxEvent{Type: dto.InboxEventCreated, RecipientExtID: recipient, Notification: &dto.Notification{ID: id}})
+		if err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+
+	publish(1)
+	for name, events := range map[string]<-chan *dto.InboxEvent{"first": first, "second": second} {
+		if got := <-events; got == nil || got.Notification.ID != 1 {
+			t.Fatalf("%s listener got %+v, want notification 1", name, got)
+		}
+	}
+
+	leave()
+	for range first {
+	}
+
+	publish(2)
+	if got := <-second; got == nil || got.Notification.ID != 2 {
+		t.Fatalf("after the first left, the second got %+v, want notification 2", got)
+	}
+
+	repo.mu.Lock()
+	followers := len(repo.channels[inboxChannel(1, recipient)].listeners)
+	repo.mu.Unlock()
+	if followers != 1 {
+		t.Errorf("%d listeners on the channel, want the one left", followers)
+	}
+
+	cancel()
+	for range second {
+	}
+}
@@ -0,0 +1,27 @@
+// Package rdb holds the repositories backed by Redis, as package pg holds the
+// ones backed by Postgres. Redis is already a hard dependency through Asynq, so
+// anything here shares its connection settings.
+package rdb
+
+import (
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+// NewClient connects to the Redis at uri. The URI is parsed the way Asynq
+// parses BODHVEDA_REDIS_URL, so every scheme the queue accepts works here too.
+func NewClient(uri string) (redis.UniversalClient, error) {
+	opt, err := asynq.ParseRedisURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf("parse redis uri: %w", err)
+	}
+
+	client, ok := opt.MakeRedisClient().(redis.UniversalClient)
+	if !ok {
+		return nil, fmt.Errorf("unexpected redis client type %T", opt.MakeRedisClient())
+	}
+
+	return client, nil
+}
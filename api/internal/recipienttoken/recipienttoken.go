@@ -0,0 +1,126 @@
+// Package recipienttoken mints and verifies recipient-bound tokens.
+//
+// A recipient-scope API key identifies a PROJECT, never a person, and it is the
+// key a customer ships to the browser. On its own it cannot say which recipient
+// the caller is, so the recipient sub-routes had no way to refuse
+// /recipients/someone-else/notifications. A recipient token is the missing
+// proof: the customer's server mints it with its full-scope key for exactly one
+// recipient, hands it to that recipient's client, and the recipient routes only
+// accept a path id that matches it.
+//
+// The format is the one the unsubscribe link already uses (see
+// email.BuildUnsubscribeToken):
+//
+//	base64url(claimsJSON) + "." + base64url(HMAC-SHA256(claimsJSON, HashKey))
+//
+// Stateless on purpose — verifying one is an HMAC, not a DB read, and it sits in
+// front of every feed poll.
+//
+// ⚠️ Both token kinds are signed with the same key and both carry a project and
+// a recipient, so an unsubscribe token (which sits in a recipient's inbox for
+// six months) would otherwise verify here too. The `k` claim is what keeps the
+// two apart: Parse rejects anything not minted by Build.
+package recipienttoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalid means the token is malformed, tampered with, or not a recipient
+	// token at all.
+	ErrInvalid = errors.New("recipient token is invalid")
+	// ErrExpired means the token verifies but is past its expiry.
+	ErrExpired = errors.New("recipient token has expired")
+)
+
+const (
+	// DefaultTTL is what a mint without an explicit lifetime gets. Short, because
+	// the token lives in a browser; the customer's server re-mints on page load.
+	DefaultTTL = time.Hour
+	// MinTTL and MaxTTL bound a caller-chosen lifetime. A day is the ceiling
+	// because there is no revocation: a leaked token is good until it expires.
+	MinTTL = time.Minute
+	MaxTTL = 24 * time.Hour
+)
+
+// kind is the value of the `k` claim. See the package doc for why it exists.
+const kind = "recipient"
+
+// Claims are what a recipient token carries. Short JSON keys keep it compact
+// enough for a header.
+type Claims struct {
+	Kind           string `json:"k"`
+	ProjectID      int    `json:"p"`
+	RecipientExtID string `json:"r"`
+	ExpiresAt      int64  `json:"exp"` // unix seconds
+}
+
+// Build signs a token for one recipient of one project, valid for ttl. The
+// recipient id is expected already normalized (lowercase), the same form the
+// routes compare against.
+func Build(projectID int, recipientExtID string, ttl time.Duration, key []byte) (string, time.Time, error) {
+	expiresAt := time.Now().Add(ttl).UTC().Truncate(time.Second)
+
+	claims := Claims{
+		Kind:           kind,
+		ProjectID:      projectID,
+		RecipientExtID: recipientExtID,
+		ExpiresAt:      expiresAt.Unix(),
+	}
+
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("marshal recipient token claims: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(body) + "." + sign(body, key), expiresAt, nil
+}
+
+// Parse verifies a token's signature, kind and expiry and returns its claims.
+//
+// Unlike the unsubscribe token, a missing expiry is not "never expires": every
+// token Build mints has one, so a token without it did not come from Build.
+func Parse(token string, key []byte) (Claims, error) {
+	payload, sig, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok || payload == "" || sig == "" {
+		return Claims{}, ErrInvalid
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Claims{}, ErrInvalid
+	}
+
+	if !hmac.Equal([]byte(sig), []byte(sign(body, key))) {
+		return Claims{}, ErrInvalid
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(body, &claims); err != nil {
+		return Claims{}, ErrInvalid
+	}
+
+	if claims.Kind != kind || claims.ProjectID <= 0 || claims.RecipientExtID == "" || claims.ExpiresAt <= 0 {
+		return Claims{}, ErrInvalid
+	}
+
+	if time.Now().Unix() > claims.ExpiresAt {
+		return Claims{}, ErrExpired
+	}
+
+	return claims, nil
+}
+
+func sign(body, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,102 @@
+package recipienttoken
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/email"
+)
+
+var testKey = []byte("test-hash-key-material-0123456789")
+
+// signClaimsForTest signs claims verbatim, so a test can craft tokens Build
+// would never produce (expired, wrong kind, no expiry).
+func signClaimsForTest(t *testing.T, claims Claims) string {
+	t.Helper()
+	body, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(body) + "." + sign(body, testKey)
+}
+
+func TestRecipientToken_RoundTrip(t *testing.T) {
+	token, expiresAt, err := Build(7, "user-1", DefaultTTL, testKey)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	claims, err := Parse(token, testKey)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if claims.ProjectID != 7 || claims.RecipientExtID != "user-1" {
+		t.Fatalf("claims round-trip mismatch: %+v", claims)
+	}
+	if claims.ExpiresAt != expiresAt.Unix() {
+		t.Errorf("exp = %d, Build reported %d", claims.ExpiresAt, expiresAt.Unix())
+	}
+}
+
+func TestRecipientToken_TamperedAndWrongKey(t *testing.T) {
+	token, _, err := Build(7, "user-1", DefaultTTL, testKey)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	// Re-point the claims at another recipient but keep the original signature —
+	// the exact forgery the token exists to stop.
+	forged := signClaimsForTest(t, Claims{Kind: kind, ProjectID: 7, RecipientExtID: "user-2", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	_, sig, _ := strings.Cut(token, ".")
+	payload, _, _ := strings.Cut(forged, ".")
+	if _, err := Parse(payload+"."+sig, testKey); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("swapped claims: got %v, want ErrInvalid", err)
+	}
+
+	if _, err := Parse(token, []byte("a-different-hash-key-000000000000")); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("wrong key: got %v, want ErrInvalid", err)
+	}
+}
+
+func TestRecipientToken_Expired(t *testing.T) {
+	token := signClaimsForTest(t, Claims{Kind: kind, ProjectID: 1, RecipientExtID: "r", ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+	if _, err := Parse(token, testKey); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expired: got %v, want ErrExpired", err)
+	}
+}
+
+// TestRecipientToken_RejectsUnsubscribeToken pins the domain separation. Both
+// tokens are signed with the same key and an unsubscribe token names a project
+// and a recipient; without the kind check, every unsubscribe link in every inbox
+// would double as a six-month feed credential.
+func TestRecipientToken_RejectsUnsubscribeToken(t *testing.T) {
+	unsub, err := email.BuildUnsubscribeToken(email.UnsubscribeClaims{
+		ProjectID: 1, RecipientExtID: "user-1", Channel: "c", Topic: "t", Event: "e",
+	}, testKey)
+	if err != nil {
+		t.Fatalf("build unsubscribe token: %v", err)
+	}
+
+	if _, err := Parse(unsub, testKey); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("unsubscribe token accepted as a recipient token: %v", err)
+	}
+}
+
+func TestRecipientToken_MissingExpiryIsInvalid(t *testing.T) {
+	token := signClaimsForTest(t, Claims{Kind: kind, ProjectID: 1, RecipientExtID: "r"})
+	if _, err := Parse(token, testKey); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("no exp: got %v, want ErrInvalid", err)
+	}
+}
+
+func TestRecipientToken_Malformed(t *testing.T) {
+	for _, tok := range []string{"", "no-dot", "onlyone.", ".onlysig", "!!!.@@@"} {
+		if _, err := Parse(tok, testKey); !errors.Is(err, ErrInvalid) {
+			t.Errorf("malformed %q: got %v, want ErrInvalid", tok, err)
+		}
+	}
+}
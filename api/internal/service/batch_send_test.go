@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hibiken/asynq"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/tantra/service"
+)
+
+// batchRepo records what a batch send inserts and any status it writes back.
+type batchRepo struct {
+	repository.NotificationRepository
+	inserts int
+	created []*entity.Notification
+	updated []*entity.Notification
+}
+
+func (r *batchRepo) BatchCreate(ctx context.Context, notifications []*entity.Notification) error {
+	r.inserts++
+	for i, n := range notifications {
+		n.ID = 100 + i
+	}
+	r.created = append(r.created, notifications...)
+	return nil
+}
+
+func (r *batchRepo) Update(ctx context.Context, notification *entity.Notification) error {
+	r.updated = append(r.updated, notification)
+	return nil
+}
+
+func batchItem(recipient string) dto.SendNotificationPayload {
+	return dto.SendNotificationPayload{
+		ProjectID:      1,
+		RecipientExtID: &recipient,
+		Payload:        json.RawMessage(`{"title": "Invoice paid"}`),
+	}
+}
+
+// TestSendBatchReportsRejectedItemsByIndex — a bad item is reported where it sat
+// in the request and does not take the others down with it. Nothing valid is
+// left here, so nothing is inserted.
+func TestSendBatchReportsRejectedItemsByIndex(t *testing.T) {
+	repo := &batchRepo{}
+	svc, _, catalog := gateService(true, false)
+	svc.repo = repo
+
+	broadcast := batchItem("x")
+	broadcast.RecipientExtID = nil
+	broadcast.Target = someTarget()
+
+	empty := batchItem("user-2")
+	empty.Payload = nil
+
+	targeted := func(recipient string) dto.SendNotificationPayload {
+		p := batchItem(recipient)
+		p.Target = someTarget()
+		return p
+	}
+
+	result, _, errKind, err := svc.SendBatch(context.Background(), 1, []dto.SendNotificationPayload{
+		broadcast, empty, targeted("user-3"), targeted("user-4"),
+	})
+	if err != nil {
+		t.Fatalf("send batch: %v (kind %v)", err, errKind)
+	}
+
+	if len(result.Sent) != 0 || len(result.Failed) != 4 {
+		t.Fatalf("sent %d, failed %d; want 0 and 4", len(result.Sent), len(result.Failed))
+	}
+	for i, failed := range result.Failed {
+		if failed.BatchIndex != i {
+			t.Errorf("failed[%d].BatchIndex = %d, want %d", i, failed.BatchIndex, i)
+		}
+	}
+	if !hasField(result.Failed[0].Errors, "recipient_id") {
+		t.Errorf("broadcast item: %v, want a recipient_id error", result.Failed[0].Errors)
+	}
+	if !hasField(result.Failed[2].Errors, "target") {
+		t.Errorf("uncataloged item: %v, want a target error", result.Failed[2].Errors)
+	}
+
+	// Two items, one target: the catalog is asked once, not once per item.
+	if catalog.lookups != 1 {
+		t.Errorf("catalog lookups = %d, want 1", catalog.lookups)
+	}
+	if repo.inserts != 0 {
+		t.Errorf("inserted %d times with nothing valid, want 0", repo.inserts)
+	}
+}
+
+// TestSendBatchUnqueuedItemIsFailedNotDropped — the rows are in before anything
+// is enqueued, so an enqueue failure must close the row out and report the item.
+// The client points at nothing, so every enqueue fails.
+func TestSendBatchUnqueuedItemIsFailedNotDropped(t *testing.T) {
+	repo := &batchRepo{}
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: "127.0.0.1:1"})
+	defer client.Close()
+
+	svc, _, _ := gateService(false, false)
+	svc.repo = repo
+	svc.asynqClient = client
+
+	// The same recipient twice is a legitimate batch: two notifications.
+	result, _, errKind, err := svc.SendBatch(context.Background(), 1, []dto.SendNotificationPayload{
+		batchItem("User-1"), batchItem("user-1"),
+	})
+	if err != nil {
+		t.Fatalf("send batch: %v (kind %v)", err, errKind)
+	}
+
+	if repo.inserts != 1 || len(repo.created) != 2 {
+		t.Fatalf("inserts = %d with %d rows, want one INSERT of 2", repo.inserts, len(repo.created))
+	}
+	if len(result.Failed) != 2 || result.Failed[0].BatchIndex != 0 || result.Failed[1].BatchIndex != 1 {
+		t.Fatalf("failed = %+v, want both items at their indexes", result.Failed)
+	}
+	if len(repo.updated) != 2 {
+		t.Fatalf("updated %d rows, want both closed out", len(repo.updated))
+	}
+	for _, n := range repo.updated {
+		if n.Status != enum.NotificationStatusFailed || n.CompletedAt == nil {
+			t.Errorf("notification %d: status %q, completed %v; want failed and completed", n.ID, n.Status, n.CompletedAt)
+		}
+	}
+}
+
+func TestSendBatchSizeLimits(t *testing.T) {
+	svc := NewNotificationService(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	if _, _, errKind, err := svc.SendBatch(context.Background(), 1, nil); err == nil || errKind != service.ErrInvalidInput {
+		t.Errorf("empty batch: got (%v, %v), want invalid input", errKind, err)
+	}
+
+	tooMany := make([]dto.SendNotificationPayload, dto.MaxBatchSendSize+1)
+	if _, _, errKind, err := svc.SendBatch(context.Background(), 1, tooMany); err == nil || errKind != service.ErrInvalidInput {
+		t.Errorf("oversized batch: got (%v, %v), want invalid input", errKind, err)
+	}
+}
+
+func hasField(errs service.InputValidationErrors, field string) bool {
+	for _, e := range errs {
+		if e.PropertyPath == field {
+			return true
+		}
+	}
+	return false
+}
@@ -134,6 +134,83 @@ func (s *BillingService) CheckAndConsumeUsage(ctx context.Context, event dto.Usa
 	})
 }
 
+// RefundUsageTx gives back usage that was consumed at usedAt but never delivered,
+// in the caller's transaction. A cancelled broadcast's unrun batches are the one
+// caller today.
+//
+// No subscription or plan lookup: a refund is never refused, and it lands on the
+// period the usage was charged to, which the aggregate row already records.
+// Nor is the refund attributed to anyone: usage is kept per project, so the
+// event's UserID is not read.
+func (s *BillingService) RefundUsageTx(ctx context.Context, tx pgx.Tx, event dto.UsageEvent, usedAt time.Time) error {
+	if event.Amount <= 0 {
+		return fmt.Errorf("refund amount must be positive, got %d", event.Amount)
+	}
+
+	return s.usageLogRepo.Refund(ctx, tx, event.ProjectID, event.Metric, event.Amount, usedAt)
+}
+
+// EstimateUsage answers what CheckAndConsumeUsage would, for an event that is
+// only being considered: how much of the period's quota is used, and whether the
+// event would push it over. It consumes nothing.
+//
+// A subscription whose period has lapsed is estimated against that period, not
+// the one the next real send would renew it into. Close enough for a preview,
+// and renewing is a write this must not make.
+func (s *BillingService) EstimateUsage(ctx context.Context, event dto.UsageEvent) (*dto.UsageEstimate, error) {
+	sub, _, err := s.GetSubscription(ctx, event.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	used, limit, err := s.usedAndLimit(ctx, sub, event.Metric)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.UsageEstimate{
+		Metric:      event.Metric,
+		Amount:      event.Amount,
+		Used:        used,
+		Limit:       limit,
+		WouldExceed: limit != nil && used+event.Amount > *limit,
+	}, nil
+}
+
+// usedAndLimit is the quota arithmetic's inputs for one metric: what the user's
+// projects have used this subscription period, and the plan's limit (nil when
+// unlimited).
+func (s *BillingService) usedAndLimit(ctx context.Context, sub *dto.UserSubscription, metric entity.Metric) (int64, *int64, error) {
+	// Get plan from hardcoded definitions
+	plan, ok := entity.GetPlan(sub.PlanID)
+	if !ok {
+		return 0, nil, fmt.Errorf("unknown plan ID: %s", sub.PlanID)
+	}
+
+	entitlement, ok := plan.Entitlements[metric]
+	if !ok {
+		return 0, nil, errors.New("metric not available in plan")
+	}
+
+	projects, err := s.projectRepo.List(ctx, sub.UserID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	projectIDs := make([]int, len(projects))
+	for i, p := range projects {
+		projectIDs[i] = p.ID
+	}
+
+	// Check aggregate usage
+	used, err := s.usageAggregateRepo.Get(ctx, projectIDs, metric, sub.CurrentPeriodStart, sub.CurrentPeriodEnd)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return used, entitlement.Limit, nil
+}
+
 func (s *BillingService) checkAndConsumeUsage(
 	ctx context.Context, event dto.UsageEvent,
 	runWrite func(ctx context.Context, write func(pgx.Tx) error) error,
@@ -165,35 +242,13 @@ func (s *BillingService) checkAndConsumeUsage(
 		sub = dto.FromUserSubscription(newSub)
 	}
 
-	// Get plan from hardcoded definitions
-	plan, ok := entity.GetPlan(sub.PlanID)
-	if !ok {
-		return fmt.Errorf("unknown plan ID: %s", sub.PlanID)
-	}
-
-	entitlement, ok := plan.Entitlements[event.Metric]
-	if !ok {
-		return errors.New("metric not available in plan")
-	}
-
-	projects, err := s.projectRepo.List(ctx, event.UserID)
-	if err != nil {
-		return fmt.Errorf("failed to list projects: %w", err)
-	}
-
-	projectIDs := make([]int, len(projects))
-	for i, p := range projects {
-		projectIDs[i] = p.ID
-	}
-
-	// Check aggregate usage
-	used, err := s.usageAggregateRepo.Get(ctx, projectIDs, event.Metric, sub.CurrentPeriodStart, sub.CurrentPeriodEnd)
+	used, limit, err := s.usedAndLimit(ctx, sub, event.Metric)
 	if err != nil {
 		return err
 	}
 
 	// If there's a limit, check if the new usage would exceed it
-	if entitlement.Limit != nil && used+event.Amount > *entitlement.Limit {
+	if limit != nil && used+event.Amount > *limit {
 		return enum.ErrQuotaExceeded
 	}
 
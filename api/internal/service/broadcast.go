@@ -2,25 +2,47 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mudgallabs/bodhveda/internal/job/task"
 	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
 	"github.com/mudgallabs/bodhveda/internal/model/enum"
 	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/tantra/dbx"
+	"github.com/mudgallabs/tantra/logger"
 	tantraRepo "github.com/mudgallabs/tantra/repository"
 	"github.com/mudgallabs/tantra/service"
 )
 
 type BroadcastService struct {
+	db               *pgxpool.Pool
 	repo             repository.BroadcastRepository
+	batchRepo        repository.BroadcastBatchRepository
 	notificationRepo repository.NotificationRepository
+	billingService   *BillingService
+	asynqClient      *asynq.Client
+	inboxEvents      repository.InboxEventRepository
 }
 
-func NewBroadcastService(repo repository.BroadcastRepository, notificationRepo repository.NotificationRepository) *BroadcastService {
+func NewBroadcastService(
+	db *pgxpool.Pool, repo repository.BroadcastRepository, batchRepo repository.BroadcastBatchRepository,
+	notificationRepo repository.NotificationRepository, billingService *BillingService, asynqClient *asynq.Client,
+	inboxEvents repository.InboxEventRepository,
+) *BroadcastService {
 	return &BroadcastService{
+		db:               db,
 		repo:             repo,
+		batchRepo:        batchRepo,
 		notificationRepo: notificationRepo,
+		billingService:   billingService,
+		asynqClient:      asynqClient,
+		inboxEvents:      inboxEvents,
 	}
 }
 
@@ -84,12 +106,18 @@ func (s *BroadcastService) GetDeliveryTree(ctx context.Context, projectID, broad
 		Mediums: []dto.DeliveryTreeMedium{dto.InAppMediumFromRollup(rollup)},
 	}
 
+	if broadcast.Status == enum.BroadcastStatusScheduled {
+		tree.ScheduledFor = broadcast.SendAt
+	}
+
 	if a := broadcast.Audience; a != nil {
 		tree.Audience = &dto.DeliveryTreeAudience{
 			Total:                a.Total,
 			Eligible:             a.Eligible,
 			ExcludedDisabled:     a.ExcludedDisabled,
 			ExcludedNotCataloged: a.ExcludedNotCataloged,
+			ExcludedSegment:      a.ExcludedSegment,
+			ExcludedUnknown:      a.ExcludedUnknown,
 			// Always false for a broadcast: excluded recipients are filtered out
 			// before any row is written, so there is nothing to drill into.
 			Expandable: false,
@@ -99,6 +127,258 @@ func (s *BroadcastService) GetDeliveryTree(ctx context.Context, projectID, broad
 	return tree, service.ErrNone, nil
 }
 
+// CancelScheduled withdraws a scheduled broadcast before its send_at. Once
+// prepare_batches has picked it up the audience is being written, and this is a
+// 409 — the broadcast is on its way.
+func (s *BroadcastService) CancelScheduled(ctx context.Context, projectID, broadcastID int) (*dto.Broadcast, service.Error, error) {
+	if err := s.repo.CancelScheduled(ctx, projectID, broadcastID); err != nil {
+		if errors.Is(err, tantraRepo.ErrNotFound) {
+			return nil, service.ErrNotFound, fmt.Errorf("broadcast not found")
+		}
+		if errors.Is(err, tantraRepo.ErrConflict) {
+			return nil, service.ErrConflict, fmt.Errorf("Only a scheduled broadcast can be cancelled. This one has already started sending or was cancelled.")
+		}
+		return nil, service.ErrInternalServerError, fmt.Errorf("cancel scheduled broadcast: %w", err)
+	}
+
+	return s.GetBroadcast(ctx, projectID, broadcastID)
+}
+
+// Cancel stops a broadcast that has not finished going out. Unlike Recall it
+// leaves alone whatever already reached an inbox: the batches that fanned out
+// keep their notifications, the rest never run, and the recipients they held are
+// refunded. A scheduled broadcast cancels whole. One that has completed, or was
+// recalled or cancelled, is a 409.
+//
+// The refund commits with the cancel or not at all, so a broadcast is never
+// cancelled without its refund, nor refunded twice.
+func (s *BroadcastService) Cancel(ctx context.Context, projectID, broadcastID int) (*dto.Broadcast, service.Error, error) {
+	err := dbx.WithTx(ctx, s.db, func(tx pgx.Tx) error {
+		undelivered, billedAt, err := s.repo.CancelTx(ctx, tx, projectID, broadcastID)
+		if err != nil {
+			return err
+		}
+		if undelivered == 0 {
+			return nil
+		}
+
+		return s.billingService.RefundUsageTx(ctx, tx, dto.UsageEvent{
+			ProjectID: projectID,
+			Metric:    entity.MetricNotifications,
+			Amount:    undelivered,
+		}, billedAt)
+	})
+	if err != nil {
+		if errors.Is(err, tantraRepo.ErrNotFound) {
+			return nil, service.ErrNotFound, fmt.Errorf("broadcast not found")
+		}
+		if errors.Is(err, tantraRepo.ErrConflict) {
+			return nil, service.ErrConflict, fmt.Errorf("Only a broadcast that is scheduled or still sending can be cancelled. To pull back one that has gone out, recall it.")
+		}
+		return nil, service.ErrInternalServerError, fmt.Errorf("cancel broadcast: %w", err)
+	}
+
+	return s.GetBroadcast(ctx, projectID, broadcastID)
+}
+
+// Recall pulls a broadcast back from every inbox it reached, and stops the rest
+// of it: batches still waiting never fan out and emails still waiting are never
+// sent. Works at any point after the send, scheduled included; only a broadcast
+// already recalled or cancelled is a 409.
+func (s *BroadcastService) Recall(ctx context.Context, projectID, broadcastID int) (*dto.Broadcast, service.Error, error) {
+	if err := s.repo.Recall(ctx, projectID, broadcastID); err != nil {
+		if errors.Is(err, tantraRepo.ErrNotFound) {
+			return nil, service.ErrNotFound, fmt.Errorf("broadcast not found")
+		}
+		if errors.Is(err, tantraRepo.ErrConflict) {
+			return nil, service.ErrConflict, fmt.Errorf("This broadcast has already been recalled or was cancelled before it went out.")
+		}
+		return nil, service.ErrInternalServerError, fmt.Errorf("recall broadcast: %w", err)
+	}
+
+	go s.publishBroadcastInbox(context.WithoutCancel(ctx), broadcastID, enum.NotificationStatusRecalled, inboxDeletedEvents)
+
+	return s.GetBroadcast(ctx, projectID, broadcastID)
+}
+
+// ListFailedBatches returns the broadcast's `failed` batches: the ones keeping it
+// from completing, once Asynq has given up on them.
+func (s *BroadcastService) ListFailedBatches(ctx context.Context, projectID, broadcastID int) ([]*dto.BroadcastBatch, service.Error, error) {
+	if _, errKind, err := s.GetBroadcast(ctx, projectID, broadcastID); err != nil {
+		return nil, errKind, err
+	}
+
+	batches, err := s.batchRepo.ListFailed(ctx, broadcastID)
+	if err != nil {
+		return nil, service.ErrInternalServerError, fmt.Errorf("list failed broadcast batches: %w", err)
+	}
+
+	return dto.FromBroadcastBatches(batches), service.ErrNone, nil
+}
+
+// RetryFailedBatches re-enqueues a broadcast's `failed` batches for another round
+// of delivery. Nothing here guards against a batch delivering twice, because
+// delivery already does: it locks the batch and writes nothing for one that is
+// `success`, so a retry racing a straggling Asynq attempt of the same batch is
+// harmless. When the last batch succeeds, delivery completes the broadcast the
+// usual way.
+//
+// Only an `enqueued` broadcast has batches to retry. A recalled or cancelled
+// one, or one with no failed batches, is a 409.
+func (s *BroadcastService) RetryFailedBatches(ctx context.Context, projectID, broadcastID int) (*dto.RetryBroadcastBatchesResult, service.Error, error) {
+	var batches []*entity.BroadcastBatch
+
+	err := dbx.WithTx(ctx, s.db, func(tx pgx.Tx) error {
+		status, err := s.repo.LockTx(ctx, tx, projectID, broadcastID)
+		if err != nil {
+			return err
+		}
+		if status != enum.BroadcastStatusEnqueued {
+			return tantraRepo.ErrConflict
+		}
+
+		batches, err = s.batchRepo.RetryFailedTx(ctx, tx, broadcastID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, tantraRepo.ErrNotFound) {
+			return nil, service.ErrNotFound, fmt.Errorf("broadcast not found")
+		}
+		if errors.Is(err, tantraRepo.ErrConflict) {
+			return nil, service.ErrConflict, fmt.Errorf("Only a broadcast that is still sending has batches to retry.")
+		}
+		return nil, service.ErrInternalServerError, fmt.Errorf("retry failed broadcast batches: %w", err)
+	}
+	if len(batches) == 0 {
+		return nil, service.ErrConflict, fmt.Errorf("This broadcast has no failed batches to retry.")
+	}
+
+	broadcast, err := s.repo.GetByID(ctx, broadcastID)
+	if err != nil {
+		return nil, service.ErrInternalServerError, fmt.Errorf("get broadcast: %w", err)
+	}
+
+	if err := s.enqueueBatches(ctx, broadcast, batches); err != nil {
+		return nil, service.ErrInternalServerError, err
+	}
+
+	return &dto.RetryBroadcastBatchesResult{
+		Broadcast: dto.FromBroadcast(broadcast),
+		Batches:   dto.FromBroadcastBatches(batches),
+	}, service.ErrNone, nil
+}
+
+// enqueueBatches sends retried batches to Asynq after their commit. A batch that
+// cannot be enqueued goes back to `failed`, so it shows up to be retried again
+// rather than sitting `enqueued` with no task behind it.
+func (s *BroadcastService) enqueueBatches(ctx context.Context, broadcast *entity.Broadcast, batches []*entity.BroadcastBatch) error {
+	priority := broadcast.Priority
+	if priority == "" {
+		priority = enum.PriorityBulk
+	}
+
+	for i, batch := range batches {
+		payload, err := json.Marshal(dto.NewBroadcastDeliveryTaskPayload(broadcast, batch))
+		if err == nil {
+			_, err = s.asynqClient.EnqueueContext(ctx,
+				asynq.NewTask(task.TaskTypeBroadcastDelivery, payload),
+				asynq.MaxRetry(3),
+				asynq.Queue(task.Queue(priority)),
+				asynq.TaskID(batch.DeliveryTaskID()),
+			)
+		}
+		if err == nil || errors.Is(err, asynq.ErrTaskIDConflict) {
+			continue
+		}
+
+		for _, unsent := range batches[i:] {
+			if updateErr := s.batchRepo.Update(ctx, unsent.ID, entity.NewBroadcastBatchUpdatePayload(
+				enum.BroadcastBatchStatusFailed, 0, unsent.Duration,
+			)); updateErr != nil {
+				logger.Get().Errorw("return unsent broadcast batch to failed",
+					"error", updateErr, "batch_id", unsent.ID)
+			}
+		}
+		return fmt.Errorf("enqueue broadcast batch %d: %w", batch.ID, err)
+	}
+
+	return nil
+}
+
+// Patch replaces a broadcast's payload for everyone it reached, and for everyone
+// it has not reached yet. A scheduled broadcast is a 409: its content is fixed in
+// the task that will send it, so cancel and resend instead.
+func (s *BroadcastService) Patch(ctx context.Context, payload dto.PatchBroadcastPayload) (*dto.Broadcast, service.Error, error) {
+	if err := payload.Validate(); err != nil {
+		return nil, service.ErrInvalidInput, err
+	}
+
+	if err := s.repo.Patch(ctx, &payload); err != nil {
+		if errors.Is(err, tantraRepo.ErrNotFound) {
+			return nil, service.ErrNotFound, fmt.Errorf("broadcast not found")
+		}
+		if errors.Is(err, tantraRepo.ErrConflict) {
+			return nil, service.ErrConflict, fmt.Errorf("Only a broadcast that is sending or sent can be edited.")
+		}
+		if errors.Is(err, enum.ErrVersionMismatch) {
+			return nil, service.ErrConflict, fmt.Errorf("The broadcast has been edited since version %d. Read it again and retry.", *payload.Version)
+		}
+		return nil, service.ErrInternalServerError, fmt.Errorf("patch broadcast: %w", err)
+	}
+
+	go s.publishBroadcastInbox(context.WithoutCancel(ctx), payload.BroadcastID, enum.NotificationStatusDelivered, inboxPatchedEvents)
+
+	return s.GetBroadcast(ctx, payload.ProjectID, payload.BroadcastID)
+}
+
+// broadcastInboxPage is how many of a broadcast's notifications
+// publishBroadcastInbox reads per round trip.
+const broadcastInboxPage = 1000
+
+// publishBroadcastInbox announces a recall or an edit to the streams of
+// everyone the broadcast reached, a page of its notifications in status at a
+// time. It runs after the commit and off the request, since a large broadcast
+// is many pages; like every inbox event it is best-effort, and a page that
+// cannot be read ends it with a log line.
+func (s *BroadcastService) publishBroadcastInbox(ctx context.Context, broadcastID int, status enum.NotificationStatus, events func([]*entity.Notification) map[int][]*dto.InboxEvent) {
+	if s.inboxEvents == nil {
+		return
+	}
+
+	afterID := 0
+	for {
+		notifications, err := s.notificationRepo.ListForBroadcast(ctx, broadcastID, status, afterID, broadcastInboxPage)
+		if err != nil {
+			logger.Get().Errorw("list broadcast notifications for inbox events", "error", err, "broadcast_id", broadcastID)
+			return
+		}
+
+		for projectID, page := range events(notifications) {
+			publishInboxEvents(ctx, s.inboxEvents, projectID, page...)
+		}
+
+		if len(notifications) < broadcastInboxPage {
+			return
+		}
+		afterID = notifications[len(notifications)-1].ID
+	}
+}
+
+// inboxPatchedEvents is the updated event for each edited notification,
+// carrying its new payload the way NotificationService.Patch's does.
+func inboxPatchedEvents(notifications []*entity.Notification) map[int][]*dto.InboxEvent {
+	byProject := map[int][]*dto.InboxEvent{}
+	for _, n := range notifications {
+		byProject[n.ProjectID] = append(byProject[n.ProjectID], &dto.InboxEvent{
+			Type:           dto.InboxEventUpdated,
+			RecipientExtID: n.RecipientExtID,
+			Notification:   dto.FromNotification(n),
+			IDs:            []int{n.ID},
+		})
+	}
+	return byProject
+}
+
 func (s *BroadcastService) List(ctx context.Context, payload *dto.ListBroadcastsFilters) (*dto.ListBroadcastssResult, service.Error, error) {
 	payload.Pagination.ApplyDefaults()
 
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+)
+
+// BroadcastRecipients is who a broadcast is addressed to, before anyone's
+// preferences are consulted: every recipient of the project narrowed by Segment,
+// or, when List is non-nil, exactly the ids on it.
+//
+// It is the one definition of a broadcast's audience. prepare_batches resolves
+// it to fan out, and a dry run resolves it to report what that fan-out would do,
+// so the two cannot drift apart.
+type BroadcastRecipients struct {
+	ProjectID int
+	Target    dto.Target
+	Segment   *entity.AudienceSegment
+	// List is a list-based broadcast's recipient ids. Nil for everyone else; a
+	// list is never empty, validation rejects that.
+	List []string
+}
+
+// recipientListChunk is how many ids of a list-based broadcast go into one
+// eligibility query. Keeps each statement's array parameter bounded however long
+// the list is.
+const recipientListChunk = 5000
+
+// Eligible lists the recipients who would get the broadcast on medium. For a
+// list-based broadcast the list is filtered a chunk at a time, and ids with no
+// recipient fall out in the filter.
+func (r BroadcastRecipients) Eligible(ctx context.Context, preferenceRepo repository.PreferenceRepository, medium enum.Medium) ([]string, error) {
+	if r.List == nil {
+		return preferenceRepo.ListEligibleRecipientExtIDsForBroadcast(ctx, r.ProjectID, r.Target, medium, r.Segment)
+	}
+
+	var eligible []string
+	for chunk := range slices.Chunk(r.List, recipientListChunk) {
+		ids, err := preferenceRepo.FilterEligibleRecipientsForBroadcast(ctx, r.ProjectID, r.Target, medium, chunk)
+		if err != nil {
+			return nil, err
+		}
+		eligible = append(eligible, ids...)
+	}
+	return eligible, nil
+}
+
+// CountAudience is Eligible's in-app breakdown. For a list-based broadcast Total
+// is the length of the list, and the ids that matched no recipient are
+// ExcludedUnknown, so the buckets still add up to it.
+func (r BroadcastRecipients) CountAudience(ctx context.Context, preferenceRepo repository.PreferenceRepository) (*entity.BroadcastAudience, error) {
+	if r.List == nil {
+		return preferenceRepo.CountBroadcastAudience(ctx, r.ProjectID, r.Target, enum.MediumInApp, r.Segment)
+	}
+
+	audience := &entity.BroadcastAudience{}
+	for chunk := range slices.Chunk(r.List, recipientListChunk) {
+		a, err := preferenceRepo.CountBroadcastAudienceAmong(ctx, r.ProjectID, r.Target, enum.MediumInApp, chunk)
+		if err != nil {
+			return nil, err
+		}
+		audience.Total += a.Total
+		audience.Eligible += a.Eligible
+		audience.ExcludedDisabled += a.ExcludedDisabled
+		audience.ExcludedNotCataloged += a.ExcludedNotCataloged
+	}
+
+	audience.ExcludedUnknown = len(r.List) - audience.Total
+	audience.Total = len(r.List)
+	return audience, nil
+}
+
+// CountFanOut counts the recipients the broadcast would reach on any of mediums
+// and samples the first sampleSize of them, without listing the rest. For a
+// list-based broadcast the sample fills from the earliest chunks.
+func (r BroadcastRecipients) CountFanOut(ctx context.Context, preferenceRepo repository.PreferenceRepository, mediums []enum.Medium, sampleSize int) (int, []string, error) {
+	if r.List == nil {
+		return preferenceRepo.CountBroadcastFanOut(ctx, r.ProjectID, r.Target, mediums, r.Segment, sampleSize)
+	}
+
+	total := 0
+	sample := []string{}
+	for chunk := range slices.Chunk(r.List, recipientListChunk) {
+		n, ids, err := preferenceRepo.CountBroadcastFanOutAmong(ctx, r.ProjectID, r.Target, mediums, chunk, sampleSize-len(sample))
+		if err != nil {
+			return 0, nil, err
+		}
+		total += n
+		sample = append(sample, ids...)
+	}
+	return total, sample, nil
+}
+
+// UnionRecipientIDs merges two recipient lists, preserving the order of the
+// first and dropping duplicates. Order matters only for determinism of batch
+// slicing.
+func UnionRecipientIDs(a, b []string) []string {
+	seen := make(map[string]struct{}, len(a)+len(b))
+	out := make([]string, 0, len(a)+len(b))
+
+	for _, list := range [][]string{a, b} {
+		for _, id := range list {
+			if _, dup := seen[id]; dup {
+				continue
+			}
+			seen[id] = struct{}{}
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
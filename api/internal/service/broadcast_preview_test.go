@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/tantra/service"
+)
+
+// --- Fakes. Everything a dry run reads; nothing here can write, which is the
+// point — a preview that reached for a writer would panic on the nil embed. So
+// would one that listed the audience instead of counting it. ---
+
+type previewPrefRepo struct {
+	repository.PreferenceRepository
+	eligible map[enum.Medium][]string
+	audience entity.BroadcastAudience
+}
+
+// CountBroadcastFanOut is the union of eligible over mediums, sampled in
+// external id order the way the query samples.
+func (f *previewPrefRepo) CountBroadcastFanOut(ctx context.Context, projectID int, target dto.Target, mediums []enum.Medium, segment *entity.AudienceSegment, sampleSize int) (int, []string, error) {
+	var union []string
+	for _, m := range mediums {
+		union = UnionRecipientIDs(union, f.eligible[m])
+	}
+	slices.Sort(union)
+	return len(union), union[:min(len(union), sampleSize)], nil
+}
+
+func (f *previewPrefRepo) CountBroadcastAudience(ctx context.Context, projectID int, target dto.Target, medium enum.Medium, segment *entity.AudienceSegment) (*entity.BroadcastAudience, error) {
+	a := f.audience
+	return &a, nil
+}
+
+type previewProjectRepo struct {
+	repository.ProjectRepository
+}
+
+func (f *previewProjectRepo) Get(ctx context.Context, projectID int) (*entity.Project, error) {
+	return &entity.Project{ID: projectID}, nil
+}
+
+func (f *previewProjectRepo) List(ctx context.Context, userID int) ([]*entity.Project, error) {
+	return []*entity.Project{{ID: 1}}, nil
+}
+
+type previewSubRepo struct {
+	repository.UserSubscriptionRepository
+}
+
+func (f *previewSubRepo) Get(ctx context.Context, userID int) (*entity.UserSubscription, error) {
+	return entity.NewUserSubscription(userID, entity.PlanFree), nil
+}
+
+type previewUsageRepo struct {
+	repository.UsageAggregateRepository
+	used int64
+}
+
+func (f *previewUsageRepo) Get(ctx context.Context, projectID []int, metric entity.Metric, periodStart, periodEnd time.Time) (int64, error) {
+	return f.used, nil
+}
+
+func previewService(pref *previewPrefRepo, emailSettings *entity.ProjectEmailSettings, used int64) *NotificationService {
+	projectRepo := &previewProjectRepo{}
+	billing := NewBillingService(nil, projectRepo, &previewSubRepo{}, nil, &previewUsageRepo{used: used})
+
+	return NewNotificationService(
+		nil, nil, pref, nil, nil, nil, nil, &fakeEmailSettingsRepo{settings: emailSettings}, projectRepo, nil,
+		nil, nil, billing, nil, nil,
+	)
+}
+
+func previewPayload(withEmail bool) dto.SendNotificationPayload {
+	p := dto.SendNotificationPayload{
+		ProjectID: 1,
+		Target:    someTarget(),
+		Payload:   json.RawMessage(`{"title":"Release 2.0"}`),
+	}
+	if withEmail {
+		p.Email = &dto.EmailContent{Subject: "Release 2.0", Text: "It's out."}
+	}
+	return p
+}
+
+// TestPreviewCountsEmailOnlyRecipients — someone who muted in-app but opted into
+// email is reached, and billed, exactly as prepare_batches would: the preview's
+// recipients are the union of the two audiences, not the in-app one.
+func TestPreviewCountsEmailOnlyRecipients(t *testing.T) {
+	pref := &previewPrefRepo{
+		eligible: map[enum.Medium][]string{
+			enum.MediumInApp: {"r1"},
+			enum.MediumEmail: {"r1", "muted_inapp"},
+		},
+		audience: entity.BroadcastAudience{Total: 3, Eligible: 1, ExcludedDisabled: 2},
+	}
+
+	result, _, errKind, err := previewService(pref, settings(), 0).Preview(context.Background(), 1, previewPayload(true))
+	if err != nil {
+		t.Fatalf("preview: %v (%v)", err, errKind)
+	}
+	if result.Broadcast != nil {
+		t.Fatal("a dry run must not report a broadcast")
+	}
+
+	p := result.Preview
+	if p.Recipients != 2 || !slices.Equal(p.SampleRecipientIDs, []string{"muted_inapp", "r1"}) {
+		t.Errorf("recipients = %d, sample %v; want both, by external id", p.Recipients, p.SampleRecipientIDs)
+	}
+	if p.Audience.Total != 3 || p.Audience.Eligible != 1 || p.Audience.ExcludedDisabled != 2 {
+		t.Errorf("audience = %+v, want the in-app breakdown", p.Audience)
+	}
+	if p.Email == nil || p.Email.Eligible != 2 || p.Email.BlockedReason != "" {
+		t.Errorf("email = %+v, want 2 eligible and not blocked", p.Email)
+	}
+	if p.Usage.Amount != 2 || p.Usage.WouldExceed {
+		t.Errorf("usage = %+v, want 2 and within quota", p.Usage)
+	}
+}
+
+// TestPreviewEmailBlockedByCap — an email audience over the project's cap is
+// blocked, not truncated, so nobody is reached by email alone and the preview
+// says why.
+func TestPreviewEmailBlockedByCap(t *testing.T) {
+	pref := &previewPrefRepo{
+		eligible: map[enum.Medium][]string{
+			enum.MediumInApp: {"r1"},
+			enum.MediumEmail: {"r1", "r2", "r3"},
+		},
+		audience: entity.BroadcastAudience{Total: 3, Eligible: 1, ExcludedDisabled: 2},
+	}
+	capped := settings()
+	capped.MaxBroadcastRecipientsForEmail = 2
+
+	result, _, _, err := previewService(pref, capped, 0).Preview(context.Background(), 1, previewPayload(true))
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+
+	p := result.Preview
+	if p.Email.BlockedReason != entity.EmailBlockedRecipientCapExceeded || p.Email.Cap != 2 {
+		t.Errorf("email = %+v, want blocked by a cap of 2", p.Email)
+	}
+	if p.Recipients != 1 {
+		t.Errorf("recipients = %d, want only the in-app audience", p.Recipients)
+	}
+}
+
+// TestPreviewReportsQuota — the preview says when the send would be refused for
+// quota, which is the one outcome a sender cannot see until after the fact.
+func TestPreviewReportsQuota(t *testing.T) {
+	pref := &previewPrefRepo{
+		eligible: map[enum.Medium][]string{enum.MediumInApp: {"r1", "r2"}},
+		audience: entity.BroadcastAudience{Total: 2, Eligible: 2},
+	}
+
+	plan, _ := entity.GetPlan(entity.PlanFree)
+	limit := *plan.Entitlements[entity.MetricNotifications].Limit
+
+	result, message, _, err := previewService(pref, nil, limit-1).Preview(context.Background(), 1, previewPayload(false))
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if !result.Preview.Usage.WouldExceed {
+		t.Errorf("usage = %+v, want it to exceed the plan", result.Preview.Usage)
+	}
+	if message == "" {
+		t.Error("a dry run must say what it found")
+	}
+}
+
+func TestPreviewRejectsDirectSend(t *testing.T) {
+	payload := previewPayload(false)
+	recipient := "u1"
+	payload.RecipientExtID = &recipient
+
+	_, _, errKind, err := previewService(&previewPrefRepo{}, nil, 0).Preview(context.Background(), 1, payload)
+	if err == nil || errKind != service.ErrBadRequest {
+		t.Errorf("preview of a direct send = %v (%v), want a bad request", err, errKind)
+	}
+}
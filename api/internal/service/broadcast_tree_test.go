@@ -48,7 +48,7 @@ func TestBroadcastDeliveryTree(t *testing.T) {
 
 	broadcastRepo := pg.NewBroadcastRepo(pool)
 	notificationRepo := pg.NewNotificationRepo(pool)
-	svc := NewBroadcastService(broadcastRepo, notificationRepo)
+	svc := NewBroadcastService(pool, broadcastRepo, pg.NewBroadcastBatchRepo(pool), notificationRepo, nil, nil, nil)
 
 	broadcast, err := broadcastRepo.Create(ctx, entity.NewBroadcast(projectID, []byte(`{"t":"hi"}`), "digest", "none", "sent"))
 	if err != nil {
@@ -195,7 +195,7 @@ func TestBroadcastDeliveryTreeRejectsCrossProjectAccess(t *testing.T) {
 	intruder := newProject("tree-intruder")
 
 	broadcastRepo := pg.NewBroadcastRepo(pool)
-	svc := NewBroadcastService(broadcastRepo, pg.NewNotificationRepo(pool))
+	svc := NewBroadcastService(pool, broadcastRepo, pg.NewBroadcastBatchRepo(pool), pg.NewNotificationRepo(pool), nil, nil, nil)
 
 	broadcast, err := broadcastRepo.Create(ctx, entity.NewBroadcast(owner, []byte(`{}`), "digest", "none", "sent"))
 	if err != nil {
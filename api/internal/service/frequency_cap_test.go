@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+)
+
+// fakeCounter counts in memory, one count per key, ignoring windows.
+type fakeCounter struct {
+	counts map[string]int
+	err    error
+}
+
+func (f *fakeCounter) Take(ctx context.Context, key string, limit int, window time.Duration, now time.Time) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	if f.counts == nil {
+		f.counts = map[string]int{}
+	}
+	f.counts[key]++
+	return f.counts[key] <= limit, nil
+}
+
+func TestFanOutEmail_FrequencyCap_Throttles(t *testing.T) {
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: "127.0.0.1:1"})
+	defer client.Close()
+
+	pref := &fakePrefRepo{shouldDeliver: true, cataloged: true,
+		frequencyCap: &entity.FrequencyCap{Limit: 1, Window: enum.CapPerHour}}
+	counter := &fakeCounter{}
+
+	send := func() enum.DeliveryStatus {
+		d := &fakeDeliveryRepo{}
+		s := serviceWith(pref, &fakeEmailSettingsRepo{settings: settings()}, &fakeContactRepo{contact: &entity.RecipientContact{ID: 5, Address: "u@e.com"}}, d)
+		s.recipientRepo = newFakeRecipientRepo()
+		s.asynqClient = client
+		s.frequencyCounter = counter
+
+		_, _ = s.fanOutEmail(context.Background(), newNotification(), &dto.EmailContent{Subject: "s", Text: "t"})
+		if d.created == nil {
+			t.Fatal("no delivery row created")
+		}
+		return d.createdStatus
+	}
+
+	if got := send(); got != enum.DeliveryPending {
+		t.Fatalf("first send = %v, want pending (within the cap)", got)
+	}
+	if got := send(); got != enum.DeliveryThrottled {
+		t.Fatalf("second send = %v, want throttled", got)
+	}
+}
+
+func TestWithinFrequencyCap(t *testing.T) {
+	capped := &fakePrefRepo{frequencyCap: &entity.FrequencyCap{Limit: 1, Window: enum.CapPerMinute}}
+
+	t.Run("mediums are counted apart", func(t *testing.T) {
+		s := &NotificationService{preferenceRepo: capped, frequencyCounter: &fakeCounter{}}
+		n := newNotification()
+
+		if !s.withinFrequencyCap(context.Background(), n, enum.MediumInApp) {
+			t.Fatal("first in-app send must fit")
+		}
+		if !s.withinFrequencyCap(context.Background(), n, enum.MediumEmail) {
+			t.Fatal("an email must not spend the in-app allowance")
+		}
+		if s.withinFrequencyCap(context.Background(), n, enum.MediumInApp) {
+			t.Error("second in-app send must be over the cap")
+		}
+	})
+
+	t.Run("recipients are counted apart", func(t *testing.T) {
+		s := &NotificationService{preferenceRepo: capped, frequencyCounter: &fakeCounter{}}
+		other := newNotification()
+		other.RecipientExtID = "user_2"
+
+		s.withinFrequencyCap(context.Background(), newNotification(), enum.MediumInApp)
+		if !s.withinFrequencyCap(context.Background(), other, enum.MediumInApp) {
+			t.Error("one recipient's sends must not spend another's allowance")
+		}
+	})
+
+	// A counter outage must not silently drop every capped send.
+	t.Run("counter error fails open", func(t *testing.T) {
+		s := &NotificationService{preferenceRepo: capped, frequencyCounter: &fakeCounter{err: errors.New("redis down")}}
+		if !s.withinFrequencyCap(context.Background(), newNotification(), enum.MediumInApp) {
+			t.Error("a send must go out when the cap cannot be counted")
+		}
+	})
+
+	t.Run("uncapped entry", func(t *testing.T) {
+		counter := &fakeCounter{}
+		s := &NotificationService{preferenceRepo: &fakePrefRepo{}, frequencyCounter: counter}
+		for range 3 {
+			if !s.withinFrequencyCap(context.Background(), newNotification(), enum.MediumInApp) {
+				t.Fatal("an uncapped entry must never throttle")
+			}
+		}
+		if len(counter.counts) != 0 {
+			t.Error("an uncapped entry must not be counted")
+		}
+	})
+}
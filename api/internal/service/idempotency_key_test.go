@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/tantra/service"
+)
+
+// memIdempotencyRepo is an in-memory ledger. It ignores the expiry cutoff —
+// that is the SQL's job and is covered where the SQL lives.
+type memIdempotencyRepo struct {
+	rows     map[string]*entity.IdempotencyKey
+	released []int
+	nextID   int
+
+	failCompletes int // Complete fails this many times before it works
+}
+
+func newMemIdempotencyRepo() *memIdempotencyRepo {
+	return &memIdempotencyRepo{rows: map[string]*entity.IdempotencyKey{}}
+}
+
+func (m *memIdempotencyRepo) Claim(ctx context.Context, projectID int, key, requestHash string, expiredBefore time.Time) (*entity.IdempotencyKey, bool, error) {
+	if existing, ok := m.rows[key]; ok {
+		return existing, false, nil
+	}
+	m.nextID++
+	row := &entity.IdempotencyKey{ID: m.nextID, ProjectID: projectID, Key: key, RequestHash: requestHash, CreatedAt: time.Now()}
+	m.rows[key] = row
+	return row, true, nil
+}
+
+func (m *memIdempotencyRepo) Complete(ctx context.Context, id int, response json.RawMessage) error {
+	if m.failCompletes > 0 {
+		m.failCompletes--
+		return errors.New("connection reset")
+	}
+	for _, row := range m.rows {
+		if row.ID == id {
+			row.Response = response
+		}
+	}
+	return nil
+}
+
+func (m *memIdempotencyRepo) Release(ctx context.Context, id int) error {
+	m.released = append(m.released, id)
+	for key, row := range m.rows {
+		if row.ID == id {
+			delete(m.rows, key)
+		}
+	}
+	return nil
+}
+
+func (m *memIdempotencyRepo) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func idempotentSend(key string) dto.SendNotificationPayload {
+	recipient := "User-1"
+	return dto.SendNotificationPayload{
+		ProjectID:      1,
+		IdempotencyKey: key,
+		RecipientExtID: &recipient,
+		Target:         someTarget(),
+		Payload:        json.RawMessage(`{"title": "Invoice paid"}`),
+	}
+}
+
+// seedCompleted records a finished send for key, as the first request would have.
+func seedCompleted(t *testing.T, repo *memIdempotencyRepo, payload dto.SendNotificationPayload, result *dto.SendNotificationResult) {
+	t.Helper()
+	if err := payload.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	fingerprint, err := payload.Fingerprint()
+	if err != nil {
+		t.Fatalf("fingerprint: %v", err)
+	}
+	row, _, _ := repo.Claim(context.Background(), payload.ProjectID, payload.IdempotencyKey, fingerprint, time.Time{})
+	response, _ := json.Marshal(result)
+	_ = repo.Complete(context.Background(), row.ID, response)
+}
+
+// TestIdempotentRepeatReplaysOriginalResult is the feature: the retry gets the
+// first notification back and nothing is sent. The service here has no
+// notification repo at all, so reaching the send path would panic.
+func TestIdempotentRepeatReplaysOriginalResult(t *testing.T) {
+	repo := newMemIdempotencyRepo()
+	svc := NewNotificationService(nil, nil, nil, nil, nil, nil, nil, nil, nil, repo, nil, nil, nil, nil, nil)
+
+	original := &dto.SendNotificationResult{Notification: &dto.Notification{ID: 42, RecipientExtID: "user-1"}}
+	seedCompleted(t, repo, idempotentSend("k1"), original)
+
+	// Differs only in recipient casing, which Validate normalizes away.
+	result, message, errKind, err := svc.Send(context.Background(), 1, idempotentSend("k1"))
+	if err != nil {
+		t.Fatalf("replay: %v (kind %v)", err, errKind)
+	}
+	if result.Notification == nil || result.Notification.ID != 42 {
+		t.Fatalf("replay returned %+v, want the original notification 42", result.Notification)
+	}
+	if !result.Replayed {
+		t.Error("a replayed result must be marked Replayed")
+	}
+	if !strings.Contains(message, "user-1") {
+		t.Errorf("replay message should read like the original, got %q", message)
+	}
+}
+
+// TestIdempotencyKeyReusedWithDifferentBodyConflicts — replaying here would
+// report success for a send that never happened.
+func TestIdempotencyKeyReusedWithDifferentBodyConflicts(t *testing.T) {
+	repo := newMemIdempotencyRepo()
+	svc := NewNotificationService(nil, nil, nil, nil, nil, nil, nil, nil, nil, repo, nil, nil, nil, nil, nil)
+
+	seedCompleted(t, repo, idempotentSend("k1"), &dto.SendNotificationResult{Notification: &dto.Notification{ID: 42}})
+
+	different := idempotentSend("k1")
+	different.Payload = json.RawMessage(`{"title": "Invoice overdue"}`)
+
+	_, _, errKind, err := svc.Send(context.Background(), 1, different)
+	if err == nil {
+		t.Fatal("a key reused with a different body must be rejected")
+	}
+	if errKind != service.ErrConflict {
+		t.Fatalf("errKind = %v, want ErrConflict", errKind)
+	}
+}
+
+// TestIdempotencyKeyInFlightConflicts — the concurrent duplicate must not race
+// the request that owns the key.
+func TestIdempotencyKeyInFlightConflicts(t *testing.T) {
+	repo := newMemIdempotencyRepo()
+	svc := NewNotificationService(nil, nil, nil, nil, nil, nil, nil, nil, nil, repo, nil, nil, nil, nil, nil)
+
+	payload := idempotentSend("k1")
+	_ = payload.Validate()
+	fingerprint, _ := payload.Fingerprint()
+	_, _, _ = repo.Claim(context.Background(), 1, "k1", fingerprint, time.Time{})
+
+	_, _, errKind, err := svc.Send(context.Background(), 1, idempotentSend("k1"))
+	if errKind != service.ErrConflict || err == nil || !strings.Contains(err.Error(), "still being processed") {
+		t.Fatalf("in-flight duplicate: got (%v, %v), want an in-progress conflict", errKind, err)
+	}
+}
+
+// TestFailedSendReleasesIdempotencyKey — a request that produced nothing must not
+// leave its key behind, or the caller's corrected retry is refused.
+func TestFailedSendReleasesIdempotencyKey(t *testing.T) {
+	repo := newMemIdempotencyRepo()
+	// Strict targets on, target not cataloged: the send fails at the gate.
+	svc := NewNotificationService(nil, nil, &countingCatalogRepo{}, nil, nil, nil, nil, nil,
+		&flagProjectRepo{strict: true}, repo, nil, nil, nil, nil, nil)
+
+	_, _, errKind, err := svc.Send(context.Background(), 1, idempotentSend("k1"))
+	if err == nil || errKind != service.ErrBadRequest {
+		t.Fatalf("expected the gate to reject the send, got (%v, %v)", errKind, err)
+	}
+
+	if len(repo.released) != 1 {
+		t.Fatalf("released %d claims, want 1", len(repo.released))
+	}
+	if _, still := repo.rows["k1"]; still {
+		t.Error("the failed send's key is still claimed")
+	}
+}
+
+// TestIdempotencyKeyCompleteRetries — a sent request whose response cannot be
+// recorded holds its key unanswered for the whole retention, so one failed
+// write is not the end of it.
+func TestIdempotencyKeyCompleteRetries(t *testing.T) {
+	repo := newMemIdempotencyRepo()
+	repo.failCompletes = idempotencyKeyCompleteAttempts - 1
+	svc := NewNotificationService(nil, nil, nil, nil, nil, nil, nil, nil, nil, repo, nil, nil, nil, nil, nil)
+
+	row, _, _ := repo.Claim(context.Background(), 1, "k1", "hash", time.Time{})
+	if err := svc.completeIdempotencyKey(context.Background(), row.ID, &dto.SendNotificationResult{}); err != nil {
+		t.Fatalf("complete after %d failures: %v", idempotencyKeyCompleteAttempts-1, err)
+	}
+	if !repo.rows["k1"].Completed() {
+		t.Error("the response was not recorded")
+	}
+}
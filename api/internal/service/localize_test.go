@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+)
+
+var ptVariants = entity.ContentVariants{
+	"pt":    {Payload: json.RawMessage(`{"title":"Olá"}`)},
+	"pt-BR": {Email: &entity.VariantEmail{Subject: "Oi", Text: "Oi!"}},
+}
+
+func TestLocalizeDirect(t *testing.T) {
+	n := &entity.Notification{Payload: json.RawMessage(`{"title":"Hello"}`)}
+	email := &dto.EmailContent{Subject: "Hi", Text: "Hi!"}
+
+	localizeDirect(n, &email, ptVariants, "pt-BR")
+
+	// Each block falls back on its own: pt-BR has an email, and its payload
+	// comes from pt.
+	if string(n.Payload) != `{"title":"Olá"}` {
+		t.Errorf("payload = %s, want pt's", n.Payload)
+	}
+	if email.Subject != "Oi" {
+		t.Errorf("email subject = %q, want pt-BR's", email.Subject)
+	}
+}
+
+func TestLocalizeDirectKeepsDefault(t *testing.T) {
+	n := &entity.Notification{Payload: json.RawMessage(`{"title":"Hello"}`)}
+	email := &dto.EmailContent{Subject: "Hi", Text: "Hi!"}
+
+	localizeDirect(n, &email, ptVariants, "fr")
+
+	if string(n.Payload) != `{"title":"Hello"}` || email.Subject != "Hi" {
+		t.Errorf("no fr variant: got payload %s, subject %q, want the default", n.Payload, email.Subject)
+	}
+}
+
+// A variant localizes the mediums the send asked for; it must not give an
+// email-only send an inbox row, or a send without email an email.
+func TestLocalizeDirectAddsNoMedium(t *testing.T) {
+	n := &entity.Notification{Payload: json.RawMessage(`null`)}
+	var email *dto.EmailContent
+
+	localizeDirect(n, &email, ptVariants, "pt-BR")
+
+	if dto.IsJSONContent(n.Payload) {
+		t.Errorf("email-only send gained a payload: %s", n.Payload)
+	}
+	if email != nil {
+		t.Errorf("in-app send gained an email: %+v", email)
+	}
+}
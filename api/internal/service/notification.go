@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/hibiken/asynq"
@@ -17,6 +18,7 @@ import (
 	"github.com/mudgallabs/bodhveda/internal/model/entity"
 	"github.com/mudgallabs/bodhveda/internal/model/enum"
 	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/tantra/apires"
 	"github.com/mudgallabs/tantra/logger"
 	"github.com/mudgallabs/tantra/query"
 	tantraRepo "github.com/mudgallabs/tantra/repository"
@@ -33,6 +35,9 @@ type NotificationService struct {
 	contactRepo        repository.RecipientContactRepository
 	projectEmailRepo   repository.ProjectEmailSettingsRepository
 	projectRepo        repository.ProjectReader
+	idempotencyRepo    repository.IdempotencyKeyRepository
+	frequencyCounter   repository.FrequencyCounterRepository
+	inboxEvents        repository.InboxEventRepository
 
 	billingService   *BillingService
 	recipientService *RecipientService
@@ -46,7 +51,8 @@ func NewNotificationService(
 	broadcastBatchRepo repository.BroadcastBatchRepository,
 	deliveryRepo repository.NotificationDeliveryRepository, contactRepo repository.RecipientContactRepository,
 	projectEmailRepo repository.ProjectEmailSettingsRepository,
-	projectRepo repository.ProjectReader,
+	projectRepo repository.ProjectReader, idempotencyRepo repository.IdempotencyKeyRepository,
+	frequencyCounter repository.FrequencyCounterRepository, inboxEvents repository.InboxEventRepository,
 	billingService *BillingService, recipientService *RecipientService,
 	asynqClient *asynq.Client,
 ) *NotificationService {
@@ -60,6 +66,9 @@ func NewNotificationService(
 		contactRepo:        contactRepo,
 		projectEmailRepo:   projectEmailRepo,
 		projectRepo:        projectRepo,
+		idempotencyRepo:    idempotencyRepo,
+		frequencyCounter:   frequencyCounter,
+		inboxEvents:        inboxEvents,
 
 		billingService:   billingService,
 		recipientService: recipientService,
@@ -68,12 +77,122 @@ func NewNotificationService(
 	}
 }
 
+const (
+	// IdempotencyKeyRetention is how long an Idempotency-Key keeps answering
+	// repeats with the first result. A day comfortably covers any client's retry
+	// policy; a key reused after that is treated as new. The worker prunes rows
+	// past it (see cmd/worker).
+	IdempotencyKeyRetention = 24 * time.Hour
+	// idempotencyKeyCompleteAttempts and idempotencyKeyCompleteBackoff bound the
+	// retry of recording a sent request's response. See sendIdempotent.
+	idempotencyKeyCompleteAttempts = 3
+	idempotencyKeyCompleteBackoff  = 200 * time.Millisecond
+)
+
+// Send validates and dispatches a send. With an Idempotency-Key it goes through
+// sendIdempotent first, which may answer from the ledger without sending.
 func (s *NotificationService) Send(ctx context.Context, userID int, payload dto.SendNotificationPayload) (*dto.SendNotificationResult, string, service.Error, error) {
 	err := payload.Validate()
 	if err != nil {
 		return nil, "", service.ErrInvalidInput, err
 	}
 
+	if payload.IdempotencyKey != "" {
+		return s.sendIdempotent(ctx, userID, payload)
+	}
+
+	return s.send(ctx, userID, payload)
+}
+
+// sendIdempotent wraps send with the Idempotency-Key ledger.
+//
+// The key is CLAIMED before anything is written, so of two concurrent requests
+// with the same key exactly one sends; the other is told the first is still in
+// progress (409) rather than being allowed to race it. A claim whose send fails
+// is released — a failed request produced nothing, so retrying it with the same
+// key must actually retry.
+//
+// A claim that never gets a response fails closed: it answers "in progress"
+// until retention ends, and is never presumed abandoned. Whether its send
+// happened cannot be told from the ledger, and no timeout is safely longer than
+// the longest send (a list broadcast creating 100k recipients is not quick), so
+// the one outcome ruled out is the duplicate. A client stuck on it uses a new
+// key, knowing the first may have gone out.
+//
+// ⚠️ A key seen with a different body is a 409, never a replay. Answering it with
+// the first result would report success for a send that never happened.
+func (s *NotificationService) sendIdempotent(ctx context.Context, userID int, payload dto.SendNotificationPayload) (*dto.SendNotificationResult, string, service.Error, error) {
+	l := logger.FromCtx(ctx)
+
+	fingerprint, err := payload.Fingerprint()
+	if err != nil {
+		return nil, "", service.ErrInternalServerError, fmt.Errorf("fingerprint send payload: %w", err)
+	}
+
+	now := time.Now().UTC()
+	claim, claimed, err := s.idempotencyRepo.Claim(ctx, payload.ProjectID, payload.IdempotencyKey, fingerprint,
+		now.Add(-IdempotencyKeyRetention))
+	if err != nil {
+		return nil, "", service.ErrInternalServerError, fmt.Errorf("claim idempotency key: %w", err)
+	}
+
+	if !claimed {
+		if claim.RequestHash != fingerprint {
+			return nil, "", service.ErrConflict, fmt.Errorf("Idempotency-Key %q was already used with a different request body. Use a new key for a different send.", payload.IdempotencyKey)
+		}
+
+		if !claim.Completed() {
+			return nil, "", service.ErrConflict, fmt.Errorf("A request with Idempotency-Key %q is still being processed. Retry shortly. If this persists, its result was not recorded and it may have been sent; use a new key only if sending it again is acceptable.", payload.IdempotencyKey)
+		}
+
+		var result dto.SendNotificationResult
+		if err := json.Unmarshal(claim.Response, &result); err != nil {
+			return nil, "", service.ErrInternalServerError, fmt.Errorf("unmarshal stored send result: %w", err)
+		}
+		result.Replayed = true
+
+		return &result, sendResultMessage(&result), service.ErrNone, nil
+	}
+
+	result, message, errKind, err := s.send(ctx, userID, payload)
+	if err != nil {
+		if releaseErr := s.idempotencyRepo.Release(ctx, claim.ID); releaseErr != nil {
+			l.Errorw("release idempotency key after failed send", "error", releaseErr, "idempotency_key_id", claim.ID)
+		}
+		return nil, "", errKind, err
+	}
+
+	// The send has happened; failing the request now would invite exactly the
+	// retry this exists to absorb. A response that could not be recorded leaves the
+	// claim empty, so a repeat gets "in progress" until the key expires.
+	if err := s.completeIdempotencyKey(context.WithoutCancel(ctx), claim.ID, result); err != nil {
+		l.Errorw("record idempotency key response", "error", err, "idempotency_key_id", claim.ID)
+	}
+
+	return result, message, service.ErrNone, nil
+}
+
+// completeIdempotencyKey records result on the claim, retrying a few times:
+// every attempt that fails leaves the key unanswerable for the rest of its
+// retention.
+func (s *NotificationService) completeIdempotencyKey(ctx context.Context, claimID int, result *dto.SendNotificationResult) error {
+	response, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshal send result: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = s.idempotencyRepo.Complete(ctx, claimID, response)
+		if err == nil || attempt == idempotencyKeyCompleteAttempts {
+			return err
+		}
+		time.Sleep(time.Duration(attempt) * idempotencyKeyCompleteBackoff)
+	}
+}
+
+// send is the send itself, past validation.
+func (s *NotificationService) send(ctx context.Context, userID int, payload dto.SendNotificationPayload) (*dto.SendNotificationResult, string, service.Error, error) {
+	var err error
 	result := &dto.SendNotificationResult{}
 
 	// When the project has strict targets on, the catalog is a GATEWAY: every
@@ -89,25 +208,285 @@ func (s *NotificationService) Send(ctx context.Context, userID int, payload dto.
 			return nil, "", service.ErrInternalServerError, fmt.Errorf("send direct notification: %w", err)
 		}
 	} else {
+		if payload.IsListBased() {
+			result.CreatedRecipientIDs, result.UnknownRecipientIDs, err = s.resolveRecipientList(ctx, payload)
+			if err != nil {
+				return nil, "", service.ErrInternalServerError, fmt.Errorf("resolve broadcast recipient list: %w", err)
+			}
+		}
+
 		result.Broadcast, err = s.sendBroadcastNotification(ctx, userID, payload)
 		if err != nil {
 			return nil, "", service.ErrInternalServerError, fmt.Errorf("send broadcast notification: %w", err)
 		}
 	}
 
-	var message string
-	if payload.IsDirect() {
+	return result, sendResultMessage(result), service.ErrNone, nil
+}
+
+// Preview is a dry run of a broadcast send: it validates the payload and runs
+// the strict-target gate exactly as Send does, then works out the audience,
+// email outcome and quota use the way prepare_batches would, without writing
+// anything or enqueuing anything.
+//
+// An Idempotency-Key is ignored: there is no send to make safe to retry.
+func (s *NotificationService) Preview(ctx context.Context, userID int, payload dto.SendNotificationPayload) (*dto.SendNotificationResult, string, service.Error, error) {
+	if err := payload.Validate(); err != nil {
+		return nil, "", service.ErrInvalidInput, err
+	}
+
+	if !payload.IsBroadcast() {
+		return nil, "", service.ErrBadRequest, fmt.Errorf("dry_run is supported on broadcasts only. A direct send reaches the one recipient it names.")
+	}
+
+	if svcErr, err := s.gateTarget(ctx, payload.ProjectID, payload.Target, payload.RequestedMediums()); err != nil {
+		return nil, "", svcErr, err
+	}
+
+	recipients := BroadcastRecipients{
+		ProjectID: payload.ProjectID,
+		Target:    *payload.Target,
+		Segment:   payload.Audience,
+		List:      payload.RecipientIDs,
+	}
+
+	preview := &dto.BroadcastPreview{}
+
+	if payload.IsListBased() {
+		unknown, err := s.recipientRepo.Missing(ctx, payload.ProjectID, payload.RecipientIDs)
+		if err != nil {
+			return nil, "", service.ErrInternalServerError, fmt.Errorf("find unknown recipients: %w", err)
+		}
+		preview.UnknownRecipientIDs = unknown
+	}
+
+	// Counts and a sample only: a dry run of a broadcast to the whole project
+	// must not hold the whole project in memory to say how big it is.
+	audience, err := recipients.CountAudience(ctx, s.preferenceRepo)
+	if err != nil {
+		return nil, "", service.ErrInternalServerError, fmt.Errorf("count broadcast audience: %w", err)
+	}
+	preview.Audience = dto.DeliveryTreeAudience{
+		Total:                audience.Total,
+		Eligible:             audience.Eligible,
+		ExcludedDisabled:     audience.ExcludedDisabled,
+		ExcludedNotCataloged: audience.ExcludedNotCataloged,
+		ExcludedSegment:      audience.ExcludedSegment,
+		ExcludedUnknown:      audience.ExcludedUnknown,
+	}
+
+	fanOutMediums := []enum.Medium{enum.MediumInApp}
+
+	if payload.HasEmail() {
+		emailEligible, _, err := recipients.CountFanOut(ctx, s.preferenceRepo, []enum.Medium{enum.MediumEmail}, 0)
+		if err != nil {
+			return nil, "", service.ErrInternalServerError, fmt.Errorf("count email-eligible recipients: %w", err)
+		}
+
+		cap, configured, err := s.broadcastEmailCap(ctx, payload.ProjectID)
+		if err != nil {
+			return nil, "", service.ErrInternalServerError, err
+		}
+
+		preview.Email = &dto.BroadcastPreviewEmail{Eligible: emailEligible, Cap: cap}
+		switch {
+		case !configured:
+			preview.Email.BlockedReason = entity.EmailBlockedProviderNotConfigured
+		case emailEligible > cap:
+			preview.Email.BlockedReason = entity.EmailBlockedRecipientCapExceeded
+		default:
+			fanOutMediums = append(fanOutMediums, enum.MediumEmail)
+		}
+	}
+
+	preview.Recipients, preview.SampleRecipientIDs, err = recipients.CountFanOut(ctx, s.preferenceRepo, fanOutMediums, dto.BroadcastPreviewSampleSize)
+	if err != nil {
+		return nil, "", service.ErrInternalServerError, fmt.Errorf("count broadcast fan-out: %w", err)
+	}
+
+	preview.Usage, err = s.billingService.EstimateUsage(ctx, dto.UsageEvent{
+		UserID:    userID,
+		ProjectID: payload.ProjectID,
+		Metric:    entity.MetricNotifications,
+		Amount:    int64(preview.Recipients),
+	})
+	if err != nil {
+		return nil, "", service.ErrInternalServerError, fmt.Errorf("estimate usage: %w", err)
+	}
+
+	return &dto.SendNotificationResult{Preview: preview}, previewMessage(preview), service.ErrNone, nil
+}
+
+// previewMessage is the human-readable line that accompanies a dry run.
+func previewMessage(p *dto.BroadcastPreview) string {
+	if p.Usage.WouldExceed {
+		return fmt.Sprintf("Dry run: this broadcast would reach %d recipients, which exceeds your plan's quota, so it would not be sent. Nothing was sent.", p.Recipients)
+	}
+	return fmt.Sprintf("Dry run: this broadcast would reach %d recipients. Nothing was sent.", p.Recipients)
+}
+
+// SendBatch is a batch of direct sends in one request: the rows go in as one
+// INSERT and each gets its own notification:delivery task, so from the worker on
+// every item is indistinguishable from a single send.
+//
+// Items are judged one by one. An item that fails validation or the strict
+// target gate is reported in Failed at its index and the rest still send, as
+// RecipientService.BatchCreate does. Only a failure that is not the item's fault
+// (the INSERT, a lookup) fails the request as a whole.
+func (s *NotificationService) SendBatch(ctx context.Context, userID int, payloads []dto.SendNotificationPayload) (*dto.BatchSendNotificationsResult, string, service.Error, error) {
+	l := logger.FromCtx(ctx)
+
+	if len(payloads) == 0 {
+		return nil, "", service.ErrInvalidInput, fmt.Errorf("no notifications provided")
+	}
+
+	if len(payloads) > dto.MaxBatchSendSize {
+		return nil, "", service.ErrInvalidInput, fmt.Errorf("batch size exceeds limit of %d", dto.MaxBatchSendSize)
+	}
+
+	result := &dto.BatchSendNotificationsResult{
+		Sent:   []dto.BatchSendNotificationSent{},
+		Failed: []dto.BatchSendNotificationFailed{},
+	}
+
+	// A batch typically repeats a handful of targets many times over. The gate's
+	// answer depends only on the target and the mediums, so ask once per pair.
+	type gateVerdict struct {
+		errKind service.Error
+		err     error
+	}
+	verdicts := map[string]gateVerdict{}
+
+	var (
+		notifications []*entity.Notification
+		accepted      []int
+	)
+
+	for i := range payloads {
+		p := &payloads[i]
+
+		if err := p.ValidateBatchItem(); err != nil {
+			result.Failed = append(result.Failed, dto.BatchSendNotificationFailed{
+				Errors:         err.(service.InputValidationErrors),
+				RecipientExtID: p.RecipientExtID,
+				BatchIndex:     i,
+			})
+			continue
+		}
+
+		mediums := p.RequestedMediums()
+		key := fmt.Sprint(mediums)
+		if p.Target != nil {
+			key = fmt.Sprintf("%s/%s/%s|%s", p.Target.Channel, p.Target.Topic, p.Target.Event, key)
+		}
+
+		verdict, ok := verdicts[key]
+		if !ok {
+			verdict.errKind, verdict.err = s.gateTarget(ctx, p.ProjectID, p.Target, mediums)
+			verdicts[key] = verdict
+		}
+
+		if verdict.err != nil {
+			if verdict.errKind != service.ErrBadRequest {
+				return nil, "", verdict.errKind, verdict.err
+			}
+
+			var errs service.InputValidationErrors
+			errs.Add(apires.NewApiError("Target not cataloged", verdict.err.Error(), "target", p.Target))
+			result.Failed = append(result.Failed, dto.BatchSendNotificationFailed{
+				Errors:         errs,
+				RecipientExtID: p.RecipientExtID,
+				BatchIndex:     i,
+			})
+			continue
+		}
+
+		notifications = append(notifications, newDirectNotification(*p))
+		accepted = append(accepted, i)
+	}
+
+	if len(notifications) > 0 {
+		if err := s.repo.BatchCreate(ctx, notifications); err != nil {
+			return nil, "", service.ErrInternalServerError, fmt.Errorf("batch create notifications: %w", err)
+		}
+	}
+
+	for j, notification := range notifications {
+		i := accepted[j]
+
+		// The rows are committed, so a failed enqueue cannot fail the request: the
+		// items that did enqueue would be reported as not sent, and a retry would
+		// send them twice. The row is closed out as `failed` instead, so it is not
+		// left looking like a pending send, and the item is reported at its index.
+		if err := s.enqueueDirectDelivery(userID, notification, payloads[i].Email, payloads[i].ContentVariants()); err != nil {
+			l.Errorw("enqueue batch send item", "error", err, "notification_id", notification.ID)
+
+			now := time.Now().UTC()
+			notification.Status = enum.NotificationStatusFailed
+			notification.CompletedAt = &now
+			notification.UpdatedAt = now
+			if updateErr := s.repo.Update(ctx, notification); updateErr != nil {
+				l.Errorw("mark unqueued batch send item failed", "error", updateErr, "notification_id", notification.ID)
+			}
+
+			var errs service.InputValidationErrors
+			errs.Add(apires.NewApiError("Could not queue notification", "The notification was recorded but could not be queued for delivery. Send it again.", "", nil))
+			result.Failed = append(result.Failed, dto.BatchSendNotificationFailed{
+				Errors:         errs,
+				RecipientExtID: payloads[i].RecipientExtID,
+				BatchIndex:     i,
+			})
+			continue
+		}
+
+		result.Sent = append(result.Sent, dto.BatchSendNotificationSent{
+			Notification: dto.FromNotification(notification),
+			BatchIndex:   i,
+		})
+	}
+
+	// Failed is built in two passes (rejected items, then unqueued ones); put it
+	// back in request order so it reads like the request.
+	slices.SortFunc(result.Failed, func(a, b dto.BatchSendNotificationFailed) int {
+		return a.BatchIndex - b.BatchIndex
+	})
+
+	message := fmt.Sprintf("%d of %d notifications queued for delivery.", len(result.Sent), len(payloads))
+
+	return result, message, service.ErrNone, nil
+}
+
+// sendResultMessage is the human-readable line that accompanies a send result.
+// Derived from the result rather than the request so a replayed Idempotency-Key
+// reads exactly like the original response.
+func sendResultMessage(result *dto.SendNotificationResult) string {
+	if n := result.Notification; n != nil {
+		if n.Status == enum.NotificationStatusScheduled && n.SendAt != nil {
+			return fmt.Sprintf("Direct notification scheduled for delivery to recipient %s at %s.", n.RecipientExtID, n.SendAt.Format(time.RFC3339))
+		}
+
 		// The notification row always exists at this point; preference gating,
 		// billing, and email fan-out are resolved asynchronously by the worker.
 		// Read the outcome back via GET /notifications/{id}.
-		message = fmt.Sprintf("Direct notification queued for delivery to recipient %s.", result.Notification.RecipientExtID)
-	} else if payload.IsBroadcast() {
-		if result.Broadcast != nil {
-			message = "Broadcast notification sent successfully. It will be delivered to all elligible recipients."
+		return fmt.Sprintf("Direct notification queued for delivery to recipient %s.", n.RecipientExtID)
+	}
+
+	if b := result.Broadcast; b != nil && b.Status == enum.BroadcastStatusScheduled && b.SendAt != nil {
+		return fmt.Sprintf("Broadcast notification scheduled for %s.", b.SendAt.Format(time.RFC3339))
+	}
+
+	if b := result.Broadcast; b != nil && b.RecipientListSize != nil {
+		if n := len(result.UnknownRecipientIDs); n > 0 {
+			return fmt.Sprintf("Broadcast notification sent to a list of %d recipients. %d of them do not exist and will be skipped.", *b.RecipientListSize, n)
 		}
+		return fmt.Sprintf("Broadcast notification sent to a list of %d recipients. It will be delivered to those eligible.", *b.RecipientListSize)
 	}
 
-	return result, message, service.ErrNone, nil
+	if result.Broadcast != nil {
+		return "Broadcast notification sent successfully. It will be delivered to all elligible recipients."
+	}
+
+	return ""
 }
 
 // gateTarget enforces strict targets: when the project has the setting ON, a
@@ -214,6 +593,22 @@ func (s *NotificationService) gateTarget(ctx context.Context, projectID int, tar
 // so throughput is bounded by one INSERT + one enqueue rather than the old chain
 // of recipient upsert + several email-gate lookups on the hot path.
 func (s *NotificationService) sendDirectNotification(ctx context.Context, userID int, payload dto.SendNotificationPayload) (*dto.Notification, []*dto.NotificationDelivery, error) {
+	notification, err := s.repo.Create(ctx, newDirectNotification(payload))
+	if err != nil {
+		return nil, nil, fmt.Errorf("create notification: %w", err)
+	}
+
+	if err := s.enqueueDirectDelivery(userID, notification, payload.Email, payload.ContentVariants()); err != nil {
+		return nil, nil, err
+	}
+
+	// Deliveries resolve asynchronously in the worker now — nothing to return here.
+	return dto.FromNotification(notification), nil, nil
+}
+
+// newDirectNotification builds the row a validated direct send inserts. Shared
+// by the single and the batch send, which must agree on it exactly.
+func newDirectNotification(payload dto.SendNotificationPayload) *entity.Notification {
 	var channel, topic, event string
 	if payload.Target != nil {
 		channel = payload.Target.Channel
@@ -253,46 +648,62 @@ func (s *NotificationService) sendDirectNotification(ctx context.Context, userID
 	//
 	// The row still exists (suppressed, not missing): the email delivery, the
 	// analytics target join, and GET /notifications/{id} all hang off it.
-	if !inApp {
+	//
+	// A scheduled send is the exception: it is `scheduled` whatever it carries,
+	// because nothing about it has happened yet and it can still be cancelled.
+	// The worker applies the rule above when it fires (see releaseScheduled).
+	notification.ExpiresAt = payload.ExpiresAt
+	notification.CollapseKey = payload.CollapseKey
+	notification.Priority = payload.PriorityOr(enum.PriorityNormal)
+
+	if payload.IsScheduled() {
+		notification.Status = enum.NotificationStatusScheduled
+		notification.SendAt = payload.SendAt
+	} else if !inApp {
 		now := time.Now().UTC()
 		notification.Status = enum.NotificationStatusNotRequested
 		notification.CompletedAt = &now
 	}
 
-	notification, err := s.repo.Create(ctx, notification)
-	if err != nil {
-		return nil, nil, fmt.Errorf("create notification: %w", err)
-	}
+	return notification
+}
 
-	// One job does the rest: recipient upsert, in-app inbox write (gating +
-	// billing), and email fan-out. The email block rides along so the worker can
-	// resolve it — email outcomes are async now and are read back via
-	// GET /notifications/{id}, not returned inline.
+// enqueueDirectDelivery hands an inserted direct notification to the worker.
+//
+// One job does the rest: recipient upsert, in-app inbox write (gating +
+// billing), and email fan-out. The email block rides along so the worker can
+// resolve it — email outcomes are async now and are read back via
+// GET /notifications/{id}, not returned inline. The localized variants ride
+// along too, since the recipient's locale is only known once the worker has
+// upserted them.
+func (s *NotificationService) enqueueDirectDelivery(userID int, notification *entity.Notification, email *dto.EmailContent, variants entity.ContentVariants) error {
 	taskPayload, err := json.Marshal(dto.NotificationDeliveryTaskPayload{
 		UserID:       userID,
 		Notification: notification,
-		Email:        payload.Email,
+		Email:        email,
+		Variants:     variants,
 	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("marshal notification delivery task payload: %w", err)
+		return fmt.Errorf("marshal notification delivery task payload: %w", err)
 	}
 
+	queue := asynq.Queue(task.Queue(notification.Priority))
 	task := asynq.NewTask(task.TaskTypeNotificationDelivery, taskPayload)
 
-	_, err = s.asynqClient.Enqueue(task, asynq.MaxRetry(5))
+	_, err = s.asynqClient.Enqueue(task, enqueueOptions(notification.SendAt, asynq.MaxRetry(5), queue)...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("enqueue notification delivery task: %w", err)
+		return fmt.Errorf("enqueue notification delivery task: %w", err)
 	}
 
-	// Deliveries resolve asynchronously in the worker now — nothing to return here.
-	return dto.FromNotification(notification), nil, nil
+	return nil
 }
 
 // DeliverDirectNotification is the worker-path half of a direct send, run by the
 // notification:delivery job. It carries all the work moved off the request path:
 //
 //  1. upsert the recipient (so it exists for the inbox / recipient list),
-//  2. gate the in-app inbox write on preferences + billing and set the status —
+//  2. gate the in-app inbox write on preferences, the frequency cap and billing,
+//     and set the status —
 //     SKIPPED entirely for an email-only send (one carrying no `payload`), which
 //     has no inbox write to gate and whose status is already terminal,
 //  3. fan out email (best-effort — a failure here never fails the job).
@@ -303,9 +714,21 @@ func (s *NotificationService) sendDirectNotification(ctx context.Context, userID
 func (s *NotificationService) DeliverDirectNotification(ctx context.Context, payload dto.NotificationDeliveryTaskPayload) error {
 	notification := payload.Notification
 
+	// 0. A scheduled send arrives here at its send_at, carrying the snapshot taken
+	//    at request time. The row, not the snapshot, says whether it still goes.
+	if notification.Status == enum.NotificationStatusScheduled {
+		proceed, err := s.releaseScheduled(ctx, notification)
+		if err != nil {
+			return err
+		}
+		if !proceed {
+			return nil
+		}
+	}
+
 	// 1. Ensure the recipient exists. Moved off the request path — a send to a
 	//    not-yet-created recipient still auto-creates it, just in the worker.
-	_, _, err := s.recipientService.CreateIfNotExists(ctx, dto.CreateRecipientPayload{
+	recipient, _, err := s.recipientService.CreateIfNotExists(ctx, dto.CreateRecipientPayload{
 		ProjectID:  notification.ProjectID,
 		ExternalID: notification.RecipientExtID,
 	})
@@ -313,6 +736,14 @@ func (s *NotificationService) DeliverDirectNotification(ctx context.Context, pay
 		return fmt.Errorf("create recipient: %w", err)
 	}
 
+	// 1'. Pick the recipient's content variant, now that their locale is known.
+	//     Each block falls back on its own, so a variant carrying only an email
+	//     still leaves the default payload in place.
+	email := payload.Email
+	if len(payload.Variants) > 0 && recipient != nil && recipient.Locale != nil {
+		localizeDirect(notification, &email, payload.Variants, *recipient.Locale)
+	}
+
 	// Was in-app requested? MUST go through dto.IsJSONContent, not a len() check:
 	// a nil payload marshals into this task as `null` and unmarshals back to the
 	// 4-byte slice `null`, so a naive check would write the inbox row an
@@ -335,6 +766,11 @@ func (s *NotificationService) DeliverDirectNotification(ctx context.Context, pay
 
 		if !shouldDeliver {
 			notification.Status = enum.NotificationStatusMuted
+		} else if !s.withinFrequencyCap(ctx, notification, enum.MediumInApp) {
+			// Over the catalog entry's cap. Checked after the mute, so a send the
+			// recipient never wanted does not spend their allowance, and before
+			// billing, so a send that is never delivered is never metered.
+			notification.Status = enum.NotificationStatusThrottled
 		} else {
 			event := dto.UsageEvent{
 				UserID:    payload.UserID,
@@ -360,9 +796,26 @@ func (s *NotificationService) DeliverDirectNotification(ctx context.Context, pay
 		notification.CompletedAt = &now
 		notification.UpdatedAt = now
 
-		if err := s.repo.Update(ctx, notification); err != nil {
+		// A delivered send under a collapse_key replaces the recipient's unread
+		// ones under the same key; the repo serializes that per key, so two of
+		// these running at once cannot both stay in the feed.
+		var collapsed []int
+		if notification.Status == enum.NotificationStatusDelivered && notification.CollapseKey != nil {
+			collapsed, err = s.repo.UpdateCollapsing(ctx, notification)
+		} else {
+			err = s.repo.Update(ctx, notification)
+		}
+		if err != nil {
 			return fmt.Errorf("update notification: %w", err)
 		}
+
+		// Only now is it in the feed. A collapsed send that lost to a newer one
+		// is not, and PublishInboxCreated leaves it out. The ones it replaced
+		// leave the feed with it, or a stream would show both.
+		s.PublishInboxCreated(ctx, []*entity.Notification{notification})
+		if len(collapsed) > 0 {
+			s.publishInbox(ctx, notification.ProjectID, dto.NewInboxDeletedEvent(notification.RecipientExtID, collapsed))
+		}
 	} else {
 		// 2'. Email-only send: no inbox write, no in-app preference to consult (the
 		//     in_app preference is irrelevant to a send that never asked for it),
@@ -398,7 +851,7 @@ func (s *NotificationService) DeliverDirectNotification(ctx context.Context, pay
 	//    primary contact + configured provider gate the actual send. Independent
 	//    of the in-app outcome above, and a failure here NEVER fails the job (old
 	//    doc #19) — the outcome is recorded on a notification_delivery row.
-	if payload.Email != nil {
+	if email != nil {
 		// An email-only send that blew the quota is rejected here rather than sent,
 		// and the rejection is recorded on the delivery row (the notification's
 		// status stays `not_requested` — it describes the in-app medium, which was
@@ -418,7 +871,7 @@ func (s *NotificationService) DeliverDirectNotification(ctx context.Context, pay
 			return nil
 		}
 
-		if _, ferr := s.fanOutEmail(ctx, notification, payload.Email); ferr != nil {
+		if _, ferr := s.fanOutEmail(ctx, notification, email); ferr != nil {
 			logger.Get().Errorf("email fan-out for notification %d: %v", notification.ID, ferr)
 		}
 	}
@@ -426,12 +879,167 @@ func (s *NotificationService) DeliverDirectNotification(ctx context.Context, pay
 	return nil
 }
 
+// localizeDirect swaps a direct send's default content for the variant matching
+// locale. The in-app payload is only swapped on a send that asked for in-app: a
+// variant cannot turn an email-only send into an inbox write.
+func localizeDirect(notification *entity.Notification, email **dto.EmailContent, variants entity.ContentVariants, locale string) {
+	if dto.IsJSONContent(notification.Payload) {
+		if p := variants.PayloadFor(locale); p != nil {
+			notification.Payload = p
+		}
+	}
+
+	if *email != nil {
+		if e := variants.EmailFor(locale); e != nil {
+			*email = &dto.EmailContent{Subject: e.Subject, HTML: e.HTML, Text: e.Text}
+		}
+	}
+}
+
+// releaseScheduled moves a due scheduled notification into the state an
+// immediate send is created in, and reports whether delivery should go ahead.
+//
+// The row is moved with a conditional UPDATE that races CancelScheduled under
+// the same predicate, so a cancel that lands first stops the send here and a
+// cancel that lands second gets a 409. The snapshot is updated in place so the
+// rest of DeliverDirectNotification sees what an immediate send would have.
+func (s *NotificationService) releaseScheduled(ctx context.Context, notification *entity.Notification) (bool, error) {
+	next := enum.NotificationStatusEnqueued
+	var completedAt *time.Time
+
+	// Same rule sendDirectNotification applies at INSERT for an immediate send.
+	if !dto.IsJSONContent(notification.Payload) {
+		now := time.Now().UTC()
+		next = enum.NotificationStatusNotRequested
+		completedAt = &now
+	}
+
+	status, err := s.repo.ReleaseScheduled(ctx, notification.ProjectID, notification.ID, next, completedAt)
+	if err != nil {
+		if errors.Is(err, tantraRepo.ErrNotFound) {
+			// Deleted while it waited (recipient or project cleanup). Nothing to send.
+			logger.Get().Infof("scheduled notification %d no longer exists; skipping", notification.ID)
+			return false, nil
+		}
+		return false, fmt.Errorf("release scheduled notification: %w", err)
+	}
+
+	if status == enum.NotificationStatusCancelled || status == enum.NotificationStatusRecalled {
+		logger.Get().Infof("scheduled notification %d was %s; skipping", notification.ID, status)
+		return false, nil
+	}
+
+	notification.Status = status
+	notification.CompletedAt = completedAt
+
+	return true, nil
+}
+
+// enqueueOptions adds ProcessAt for a scheduled send, so the task stays parked
+// in Asynq until sendAt.
+func enqueueOptions(sendAt *time.Time, opts ...asynq.Option) []asynq.Option {
+	if sendAt != nil && sendAt.After(time.Now()) {
+		opts = append(opts, asynq.ProcessAt(*sendAt))
+	}
+	return opts
+}
+
+// CancelScheduled withdraws a scheduled direct notification before its send_at.
+// It is a 409, not a no-op, once the send has fired: the caller asked for
+// something that can no longer happen, and must not be told it did.
+func (s *NotificationService) CancelScheduled(ctx context.Context, projectID, notificationID int) (*dto.Notification, service.Error, error) {
+	if projectID <= 0 {
+		return nil, service.ErrInvalidInput, fmt.Errorf("projectID required")
+	}
+	if notificationID <= 0 {
+		return nil, service.ErrInvalidInput, fmt.Errorf("notificationID required")
+	}
+
+	notification, err := s.repo.CancelScheduled(ctx, projectID, notificationID)
+	if err != nil {
+		if errors.Is(err, tantraRepo.ErrNotFound) {
+			return nil, service.ErrNotFound, fmt.Errorf("notification not found")
+		}
+		if errors.Is(err, tantraRepo.ErrConflict) {
+			return nil, service.ErrConflict, fmt.Errorf("Only a scheduled notification can be cancelled. This one has already been sent or cancelled.")
+		}
+		return nil, service.ErrInternalServerError, fmt.Errorf("cancel scheduled notification: %w", err)
+	}
+
+	return dto.FromNotification(notification), service.ErrNone, nil
+}
+
+// Recall pulls a sent notification back: it leaves the recipient's feed, read or
+// not, and an email still waiting to go out never does. Unlike DeleteForRecipient
+// it needs no recipient scope and keeps the row, so the console can show what
+// was recalled. A cancelled notification never went out and cannot be recalled;
+// both that and a second recall are 409s.
+func (s *NotificationService) Recall(ctx context.Context, projectID, notificationID int) (*dto.Notification, service.Error, error) {
+	if projectID <= 0 {
+		return nil, service.ErrInvalidInput, fmt.Errorf("projectID required")
+	}
+	if notificationID <= 0 {
+		return nil, service.ErrInvalidInput, fmt.Errorf("notificationID required")
+	}
+
+	notification, err := s.repo.Recall(ctx, projectID, notificationID)
+	if err != nil {
+		if errors.Is(err, tantraRepo.ErrNotFound) {
+			return nil, service.ErrNotFound, fmt.Errorf("notification not found")
+		}
+		if errors.Is(err, tantraRepo.ErrConflict) {
+			return nil, service.ErrConflict, fmt.Errorf("This notification has already been recalled or was cancelled before it was sent.")
+		}
+		return nil, service.ErrInternalServerError, fmt.Errorf("recall notification: %w", err)
+	}
+
+	s.publishInbox(ctx, projectID, dto.NewInboxDeletedEvent(notification.RecipientExtID, []int{notification.ID}))
+
+	return dto.FromNotification(notification), service.ErrNone, nil
+}
+
+// Patch replaces a delivered notification's payload — the way to keep an
+// aggregated notification ("Alice and 3 others liked your post") current without
+// sending another one. The recipient's feed returns the bumped version, so a
+// client knows to re-render it.
+func (s *NotificationService) Patch(ctx context.Context, payload dto.PatchNotificationPayload) (*dto.Notification, service.Error, error) {
+	if err := payload.Validate(); err != nil {
+		return nil, service.ErrInvalidInput, err
+	}
+
+	notification, err := s.repo.Patch(ctx, &payload)
+	if err != nil {
+		if errors.Is(err, tantraRepo.ErrNotFound) {
+			return nil, service.ErrNotFound, fmt.Errorf("notification not found")
+		}
+		if errors.Is(err, tantraRepo.ErrConflict) {
+			return nil, service.ErrConflict, fmt.Errorf("Only a delivered notification can be edited.")
+		}
+		if errors.Is(err, enum.ErrVersionMismatch) {
+			return nil, service.ErrConflict, fmt.Errorf("The notification has been edited since version %d. Read it again and retry.", *payload.Version)
+		}
+		return nil, service.ErrInternalServerError, fmt.Errorf("patch notification: %w", err)
+	}
+
+	result := dto.FromNotification(notification)
+	s.publishInbox(ctx, notification.ProjectID, &dto.InboxEvent{
+		Type:           dto.InboxEventUpdated,
+		RecipientExtID: notification.RecipientExtID,
+		Notification:   result,
+		IDs:            []int{notification.ID},
+	})
+
+	return result, service.ErrNone, nil
+}
+
 // fanOutEmail resolves whether email may fire for a direct send and records the
 // outcome as a notification_delivery row. When everything passes it creates a
-// `pending` row and enqueues the email:delivery task; otherwise it records a
-// terminal skip outcome (muted / no_contact / failed) so the outcome is visible
-// rather than silently dropped. The returned error is for logging only — it must
-// never reject the send.
+// `pending` row and enqueues the email:delivery task — or, inside the
+// recipient's quiet hours, a `deferred` row whose task waits for them to end.
+// Otherwise it records a terminal skip outcome (muted / no_contact / throttled /
+// failed) so
+// the outcome is visible rather than silently dropped. The returned error is for
+// logging only — it must never reject the send.
 func (s *NotificationService) fanOutEmail(ctx context.Context, notification *entity.Notification, email *dto.EmailContent) (*entity.NotificationDelivery, error) {
 	projectID := notification.ProjectID
 	recipientExtID := notification.RecipientExtID
@@ -453,6 +1061,12 @@ func (s *NotificationService) fanOutEmail(ctx context.Context, notification *ent
 		return created, nil
 	}
 
+	// 0. Expired already — the job ran late, or a scheduled send fired too close
+	//    to its expiry. Nothing else is worth resolving: the answer is no.
+	if notification.Expired(time.Now()) {
+		return record(newRow(enum.DeliveryExpired, ""))
+	}
+
 	// 1. Catalog + per-medium preference gate. For a non-in_app medium this
 	//    defaults to NOT deliver unless the target is cataloged (a project-level
 	//    row exists) or the recipient explicitly enabled it.
@@ -507,6 +1121,12 @@ func (s *NotificationService) fanOutEmail(ctx context.Context, notification *ent
 		return record(newRow(enum.DeliveryFailed, "contact_lookup_error"))
 	}
 
+	// 3b. Frequency cap. Last of the gates, so only an email that would really
+	//     have gone out counts against the recipient's allowance.
+	if !s.withinFrequencyCap(ctx, notification, enum.MediumEmail) {
+		return record(newRow(enum.DeliveryThrottled, ""))
+	}
+
 	// 4. Everything passed — record a pending row and enqueue the send.
 	provider := string(settings.Provider)
 	pending := newRow(enum.DeliveryPending, "")
@@ -514,6 +1134,38 @@ func (s *NotificationService) fanOutEmail(ctx context.Context, notification *ent
 	pending.AddressSnapshot = &contact.Address
 	pending.Provider = &provider
 
+	catalog := s.catalogEmailOptions(ctx, projectID, target)
+
+	// A digested catalog entry parks the email in the recipient's open digest
+	// instead; the worker's flush sends it. No unsubscribe header travels with
+	// it — a digest can mix targets, so no single one-click link is honest.
+	if window := catalog.Digest; window != "" {
+		text := email.ResolvedText()
+		created, err := s.deliveryRepo.CreateDigested(ctx, pending, window, &entity.EmailDigestItem{
+			Subject:   email.Subject,
+			HTML:      email.HTML,
+			Text:      &text,
+			ExpiresAt: notification.ExpiresAt,
+		})
+		if err != nil {
+			if errors.Is(err, tantraRepo.ErrConflict) {
+				return nil, fmt.Errorf("create digested email delivery row: %w", err)
+			}
+			return record(newRow(enum.DeliveryFailed, "digest_error"))
+		}
+		return created, nil
+	}
+
+	// Inside the recipient's quiet hours the row is `deferred` and the task is
+	// parked until they end. Mandatory entries (password resets) never wait.
+	var deferUntil *time.Time
+	if !mandatory && !catalog.BypassQuietHours {
+		if until, quiet := s.quietUntil(ctx, projectID, recipientExtID); quiet {
+			pending.Status = enum.DeliveryDeferred
+			deferUntil = &until
+		}
+	}
+
 	created, err := record(pending)
 	if err != nil {
 		return nil, err
@@ -532,6 +1184,7 @@ func (s *NotificationService) fanOutEmail(ctx context.Context, notification *ent
 		Subject:        email.Subject,
 		HTML:           email.HTML,
 		Text:           email.ResolvedText(),
+		ExpiresAt:      notification.ExpiresAt,
 		UnsubscribeURL: unsubscribeURL,
 	})
 	if err != nil {
@@ -540,8 +1193,14 @@ func (s *NotificationService) fanOutEmail(ctx context.Context, notification *ent
 		return created, fmt.Errorf("marshal email delivery task payload: %w", err)
 	}
 
+	// The email rides the same lane as the send it belongs to.
+	opts := []asynq.Option{asynq.MaxRetry(5), asynq.Queue(task.Queue(notification.Priority))}
+	if deferUntil != nil {
+		opts = append(opts, asynq.ProcessAt(*deferUntil))
+	}
+
 	emailTask := asynq.NewTask(task.TaskTypeEmailDelivery, taskPayload)
-	if _, err := s.asynqClient.Enqueue(emailTask, asynq.MaxRetry(5)); err != nil {
+	if _, err := s.asynqClient.Enqueue(emailTask, opts...); err != nil {
 		s.markDeliveryFailed(ctx, created.ID, "enqueue_error")
 		created.Status = enum.DeliveryFailed
 		return created, fmt.Errorf("enqueue email delivery task: %w", err)
@@ -550,6 +1209,75 @@ func (s *NotificationService) fanOutEmail(ctx context.Context, notification *ent
 	return created, nil
 }
 
+// withinFrequencyCap counts a direct send against the frequency cap of its
+// (target, medium) catalog entry and reports whether it fits. Untargeted sends
+// and uncapped entries always fit. So does any send when the cap cannot be read
+// or counted: the cap protects recipients from a noisy integration, and a Redis
+// blip silently dropping every capped notification is the worse failure.
+// A service built without a counter caps nothing.
+func (s *NotificationService) withinFrequencyCap(ctx context.Context, notification *entity.Notification, medium enum.Medium) bool {
+	target := dto.TargetFromNotification(notification)
+	if s.frequencyCounter == nil || target.Channel == "" {
+		return true
+	}
+
+	frequencyCap, err := s.preferenceRepo.LookupCatalogFrequencyCap(ctx, notification.ProjectID, target, medium)
+	if err != nil {
+		logger.Get().Warnf("lookup frequency cap for project %d target %s/%s/%s: %v",
+			notification.ProjectID, target.Channel, target.Topic, target.Event, err)
+		return true
+	}
+	if frequencyCap == nil {
+		return true
+	}
+
+	// %q keeps the parts apart: recipient ids and topics are caller-chosen and
+	// may themselves contain the separator.
+	key := fmt.Sprintf("%d:%q:%q:%q:%q:%s", notification.ProjectID, notification.RecipientExtID,
+		target.Channel, target.Topic, target.Event, medium)
+
+	ok, err := s.frequencyCounter.Take(ctx, key, frequencyCap.Limit, frequencyCap.Window.Duration(), time.Now())
+	if err != nil {
+		logger.Get().Warnf("count frequency cap for notification %d: %v", notification.ID, err)
+		return true
+	}
+
+	return ok
+}
+
+// catalogEmailOptions reports how a target's email catalog entry wants its
+// email sent. An untargeted send has no catalog entry to ask and gets the zero
+// value, as does a lookup error: a late digest is worse than an early email.
+func (s *NotificationService) catalogEmailOptions(ctx context.Context, projectID int, target dto.Target) entity.CatalogEmailOptions {
+	if target.Channel == "" {
+		return entity.CatalogEmailOptions{}
+	}
+
+	opts, err := s.preferenceRepo.LookupCatalogEmail(ctx, projectID, target)
+	if err != nil {
+		logger.Get().Warnf("lookup catalog email options for project %d target %s/%s/%s: %v",
+			projectID, target.Channel, target.Topic, target.Event, err)
+		return entity.CatalogEmailOptions{}
+	}
+
+	return opts
+}
+
+// quietUntil reports whether the recipient is inside their quiet hours now and
+// when those end. A recipient that cannot be read is treated as having none —
+// the email goes out rather than waiting on a lookup that failed.
+func (s *NotificationService) quietUntil(ctx context.Context, projectID int, recipientExtID string) (time.Time, bool) {
+	recipient, err := s.recipientRepo.Get(ctx, projectID, recipientExtID)
+	if err != nil {
+		if !errors.Is(err, tantraRepo.ErrNotFound) {
+			logger.Get().Warnf("get recipient %s of project %d for quiet hours: %v", recipientExtID, projectID, err)
+		}
+		return time.Time{}, false
+	}
+
+	return recipient.QuietUntil(time.Now())
+}
+
 // markDeliveryFailed flips a pending delivery row to failed when enqueue fails
 // after the row was created (best-effort; logs on error).
 func (s *NotificationService) markDeliveryFailed(ctx context.Context, deliveryID int64, reason string) {
@@ -596,6 +1324,25 @@ func (s *NotificationService) buildUnsubscribeURL(projectID int, recipientExtID
 	return email.UnsubscribeURL(env.APIURL, token)
 }
 
+// resolveRecipientList deals with the ids of a list-based broadcast that have no
+// recipient: creates them when the send asked for that, and otherwise reports
+// them. Either way the ids stay on the list.
+func (s *NotificationService) resolveRecipientList(ctx context.Context, payload dto.SendNotificationPayload) (created, unknown []string, err error) {
+	if payload.CreateMissingRecipients {
+		created, err = s.recipientRepo.CreateMissing(ctx, payload.ProjectID, payload.RecipientIDs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("create missing recipients: %w", err)
+		}
+		return created, nil, nil
+	}
+
+	unknown, err = s.recipientRepo.Missing(ctx, payload.ProjectID, payload.RecipientIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find unknown recipients: %w", err)
+	}
+	return nil, unknown, nil
+}
+
 func (s *NotificationService) sendBroadcastNotification(ctx context.Context, userID int, payload dto.SendNotificationPayload) (*dto.Broadcast, error) {
 	broadcast := entity.NewBroadcast(
 		payload.ProjectID,
@@ -609,7 +1356,30 @@ func (s *NotificationService) sendBroadcastNotification(ctx context.Context, use
 		broadcast = broadcast.WithEmail(payload.Email.Subject, payload.Email.HTML, payload.Email.ResolvedText())
 	}
 
-	broadcast, err := s.broadcastRepo.Create(ctx, broadcast)
+	// The audience is deliberately NOT resolved now for a scheduled broadcast:
+	// prepare_batches runs at send_at and sees the recipients and preferences as
+	// they are then, which is what "send this on Monday" means.
+	if payload.IsScheduled() {
+		broadcast.Status = enum.BroadcastStatusScheduled
+		broadcast.SendAt = payload.SendAt
+	}
+
+	// Copied onto each notification at fan-out; see BroadcastDeliveryProcessor.
+	broadcast.ExpiresAt = payload.ExpiresAt
+	broadcast.Priority = payload.PriorityOr(enum.PriorityBulk)
+	// Resolved per recipient at fan-out, against their locale at that time.
+	broadcast.Variants = payload.ContentVariants()
+	// Stored, not resolved: like preferences, it is evaluated at fan-out.
+	broadcast.Segment = payload.Audience
+
+	var err error
+	if payload.IsListBased() {
+		// The list is stored, not resolved: preferences are checked at fan-out,
+		// like every broadcast's.
+		broadcast, err = s.broadcastRepo.CreateWithRecipients(ctx, broadcast, payload.RecipientIDs)
+	} else {
+		broadcast, err = s.broadcastRepo.Create(ctx, broadcast)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("create broadcast: %w", err)
 	}
@@ -619,9 +1389,10 @@ func (s *NotificationService) sendBroadcastNotification(ctx context.Context, use
 		return nil, fmt.Errorf("marshal prepare broadcast batches task payload: %w", err)
 	}
 
+	queue := asynq.Queue(task.Queue(broadcast.Priority))
 	task := asynq.NewTask(task.TaskTypePrepareBroadcastBatches, taskPayload)
 
-	_, err = s.asynqClient.Enqueue(task, asynq.MaxRetry(5))
+	_, err = s.asynqClient.Enqueue(task, enqueueOptions(broadcast.SendAt, asynq.MaxRetry(5), queue)...)
 	if err != nil {
 		return nil, fmt.Errorf("enqueue prepare broadcast batches task: %w", err)
 	}
@@ -676,6 +1447,11 @@ func (s *NotificationService) ProjectAnalytics(ctx context.Context, filters *dto
 		inApp.ByStatus.QuotaExceeded += d.QuotaExceeded
 		inApp.ByStatus.Failed += d.Failed
 		inApp.ByStatus.NotRequested += d.NotRequested
+		inApp.ByStatus.Scheduled += d.Scheduled
+		inApp.ByStatus.Cancelled += d.Cancelled
+		inApp.ByStatus.Collapsed += d.Collapsed
+		inApp.ByStatus.Throttled += d.Throttled
+		inApp.ByStatus.Recalled += d.Recalled
 	}
 
 	// Email side: aggregate notification_delivery WHERE medium='email'. The repo
@@ -780,6 +1556,10 @@ func (s *NotificationService) GetDeliveryTree(ctx context.Context, projectID, no
 		},
 	}
 
+	if notification.Status == enum.NotificationStatusScheduled {
+		tree.ScheduledFor = notification.SendAt
+	}
+
 	// The email branch exists only when the send actually attempted email. An
 	// absent branch and a branch with zero in it are different facts, so a
 	// payload-only send renders no email row at all rather than an empty one.
@@ -885,17 +1665,21 @@ func (s *NotificationService) normalizeStoredEvents(d *entity.NotificationDelive
 	return events
 }
 
-func (s *NotificationService) ListForRecipient(ctx context.Context, projectID int, recipientExtID string, cursor *query.Cursor) ([]*dto.Notification, *query.Cursor, service.Error, error) {
+func (s *NotificationService) ListForRecipient(ctx context.Context, projectID int, recipientExtID string, filters *dto.RecipientFeedFilters, cursor *query.Cursor) ([]*dto.Notification, *query.Cursor, service.Error, error) {
 	if recipientExtID == "" {
 		return nil, nil, service.ErrInvalidInput, fmt.Errorf("recipient id required")
 	}
 
+	if err := filters.Validate(); err != nil {
+		return nil, nil, service.ErrInvalidInput, err
+	}
+
 	err := cursor.Validate(100, 10)
 	if err != nil {
 		return nil, nil, service.ErrInvalidInput, err
 	}
 
-	notifs, returnedCursor, err := s.repo.ListForRecipient(ctx, projectID, recipientExtID, cursor)
+	notifs, returnedCursor, err := s.repo.ListForRecipient(ctx, projectID, recipientExtID, filters, cursor)
 	if err != nil {
 		return nil, nil, service.ErrInternalServerError, err
 	}
@@ -903,12 +1687,39 @@ func (s *NotificationService) ListForRecipient(ctx context.Context, projectID in
 	return dto.FromNotifications(notifs), returnedCursor, service.ErrNone, nil
 }
 
+// ListGroupsForRecipient is ListForRecipient with filters.GroupBy set: a page of
+// groups rather than of notifications.
+func (s *NotificationService) ListGroupsForRecipient(ctx context.Context, projectID int, recipientExtID string, filters *dto.RecipientFeedFilters, cursor *query.Cursor) ([]*dto.NotificationGroup, *query.Cursor, service.Error, error) {
+	if recipientExtID == "" {
+		return nil, nil, service.ErrInvalidInput, fmt.Errorf("recipient id required")
+	}
+
+	if err := filters.Validate(); err != nil {
+		return nil, nil, service.ErrInvalidInput, err
+	}
+	if filters.GroupBy == "" {
+		return nil, nil, service.ErrInvalidInput, fmt.Errorf("group_by required")
+	}
+
+	err := cursor.Validate(100, 10)
+	if err != nil {
+		return nil, nil, service.ErrInvalidInput, err
+	}
+
+	groups, returnedCursor, err := s.repo.ListGroupsForRecipient(ctx, projectID, recipientExtID, filters, cursor)
+	if err != nil {
+		return nil, nil, service.ErrInternalServerError, err
+	}
+
+	return dto.FromNotificationGroups(groups), returnedCursor, service.ErrNone, nil
+}
+
 func (s *NotificationService) UnreadCountForRecipient(ctx context.Context, projectID int, recipientExtID string) (int, service.Error, error) {
 	if recipientExtID == "" {
 		return 0, service.ErrInvalidInput, fmt.Errorf("recipient id required")
 	}
 
-	count, err := s.repo.UnreadCountForRecipient(ctx, projectID, recipientExtID)
+	count, err := s.repo.UnreadCountForRecipient(ctx, projectID, recipientExtID, false)
 	if err != nil {
 		return 0, service.ErrInternalServerError, err
 	}
@@ -916,13 +1727,43 @@ func (s *NotificationService) UnreadCountForRecipient(ctx context.Context, proje
 	return count, service.ErrNone, nil
 }
 
+// UnreadCountsForRecipient is the unread count with its per-channel breakdown,
+// for the unread-count endpoint, of the inbox or the archive. The streams keep
+// to UnreadCountForRecipient: they send it after every event, and a badge
+// needs only the inbox total.
+func (s *NotificationService) UnreadCountsForRecipient(ctx context.Context, projectID int, recipientExtID string, archived bool) (*dto.UnreadCounts, service.Error, error) {
+	if recipientExtID == "" {
+		return nil, service.ErrInvalidInput, fmt.Errorf("recipient id required")
+	}
+
+	byChannel, err := s.repo.UnreadCountsByChannelForRecipient(ctx, projectID, recipientExtID, archived)
+	if err != nil {
+		return nil, service.ErrInternalServerError, err
+	}
+
+	counts := &dto.UnreadCounts{ByChannel: byChannel}
+	for _, n := range byChannel {
+		counts.UnreadCount += n
+	}
+
+	return counts, service.ErrNone, nil
+}
+
 func (s *NotificationService) UpdateForRecipient(ctx context.Context, projectID int, recipientExtID string, payload dto.UpdateRecipientNotificationsPayload) (int, service.Error, error) {
+	if err := payload.Validate(); err != nil {
+		return 0, service.ErrInvalidInput, err
+	}
+
 	updated, err := s.repo.UpdateForRecipient(ctx, projectID, recipientExtID, payload)
 	if err != nil {
 		return 0, service.ErrInternalServerError, err
 	}
 
-	return updated, service.ErrNone, nil
+	if len(updated) > 0 {
+		s.publishInbox(ctx, projectID, dto.NewInboxUpdatedEvent(recipientExtID, payload, updated))
+	}
+
+	return len(updated), service.ErrNone, nil
 }
 
 func (s *NotificationService) DeleteForRecipient(ctx context.Context, projectID int, recipientExtID string, notificationIDs []int) (int, service.Error, error) {
@@ -931,6 +1772,10 @@ func (s *NotificationService) DeleteForRecipient(ctx context.Context, projectID
 		return 0, service.ErrInternalServerError, err
 	}
 
+	if updated > 0 {
+		s.publishInbox(ctx, projectID, dto.NewInboxDeletedEvent(recipientExtID, notificationIDs))
+	}
+
 	return updated, service.ErrNone, nil
 }
 
@@ -983,24 +1828,18 @@ func (s *NotificationService) ResolveBroadcastEmailAudience(
 	// audience. Someone who muted in-app but opted into email must still receive
 	// the mail; they get a notification row with in-app status `muted` for the
 	// delivery row to hang off, exactly as a direct send does.
-	var err error
-
-	cap := defaultBroadcastEmailCap
-	settings, err := s.projectEmailRepo.Get(ctx, broadcast.ProjectID)
+	cap, configured, err := s.broadcastEmailCap(ctx, broadcast.ProjectID)
 	if err != nil {
-		if !errors.Is(err, tantraRepo.ErrNotFound) {
-			return nil, fmt.Errorf("get project email settings: %w", err)
-		}
+		return nil, err
+	}
+	if !configured {
 		// No email settings at all: nothing can send. Record it as blocked rather
 		// than letting the fan-out discover it per recipient.
-		if serr := s.broadcastRepo.SetEmailOutcomeTx(ctx, tx, broadcast.ID, len(eligible), "provider_not_configured"); serr != nil {
+		if serr := s.broadcastRepo.SetEmailOutcomeTx(ctx, tx, broadcast.ID, len(eligible), entity.EmailBlockedProviderNotConfigured); serr != nil {
 			return nil, serr
 		}
 		return nil, nil
 	}
-	if settings.MaxBroadcastRecipientsForEmail > 0 {
-		cap = settings.MaxBroadcastRecipientsForEmail
-	}
 
 	if len(eligible) > cap {
 		logger.Get().Warnw("broadcast email blocked by recipient cap",
@@ -1021,6 +1860,24 @@ func (s *NotificationService) ResolveBroadcastEmailAudience(
 	return eligible, nil
 }
 
+// broadcastEmailCap is how many recipients one of the project's broadcasts may
+// email. configured is false when the project has no email settings, in which
+// case it cannot email anyone and the cap is moot.
+func (s *NotificationService) broadcastEmailCap(ctx context.Context, projectID int) (cap int, configured bool, err error) {
+	settings, err := s.projectEmailRepo.Get(ctx, projectID)
+	if err != nil {
+		if errors.Is(err, tantraRepo.ErrNotFound) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("get project email settings: %w", err)
+	}
+
+	if settings.MaxBroadcastRecipientsForEmail > 0 {
+		return settings.MaxBroadcastRecipientsForEmail, true, nil
+	}
+	return defaultBroadcastEmailCap, true, nil
+}
+
 // FanOutBroadcastEmail writes the email delivery rows for one batch and returns
 // the send tasks to enqueue.
 //
@@ -1033,8 +1890,11 @@ func (s *NotificationService) ResolveBroadcastEmailAudience(
 // rows — see agent-docs/delivery-feedback-design.md §3.2). Rows are written only
 // for recipients this broadcast intended to mail: `pending` when there is an
 // address, `no_contact` when there is not, because that one is actionable.
+//
+// locales maps the batch's recipients to their locale and picks each one's email
+// variant. A recipient missing from it gets the broadcast's default email.
 func (s *NotificationService) FanOutBroadcastEmail(
-	ctx context.Context, tx pgx.Tx, broadcast *entity.Broadcast, notifications []*entity.Notification,
+	ctx context.Context, tx pgx.Tx, broadcast *entity.Broadcast, notifications []*entity.Notification, locales map[string]string,
 ) ([]dto.EmailDeliveryTaskPayload, error) {
 	// ⚠️ BlockedReason is the authoritative go/no-go, decided once at prepare time
 	// and read here rather than re-derived. Re-checking the cap per batch would let
@@ -1073,6 +1933,7 @@ func (s *NotificationService) FanOutBroadcastEmail(
 	}
 
 	provider := string(settings.Provider)
+	expired := broadcast.Expired(time.Now())
 
 	deliveries := make([]*entity.NotificationDelivery, 0, len(eligibleExtIDs))
 	sendable := make([]string, 0, len(eligibleExtIDs))
@@ -1103,6 +1964,14 @@ func (s *NotificationService) FanOutBroadcastEmail(
 		d.AddressSnapshot = &contact.Address
 		d.Provider = &provider
 
+		// A batch that runs after the broadcast expired still records who would
+		// have been mailed, so the tree can say why they were not.
+		if expired {
+			d.Status = enum.DeliveryExpired
+			deliveries = append(deliveries, d)
+			continue
+		}
+
 		deliveries = append(deliveries, d)
 		sendable = append(sendable, extID)
 	}
@@ -1132,13 +2001,19 @@ func (s *NotificationService) FanOutBroadcastEmail(
 	for _, extID := range sendable {
 		d := byExtID[extID]
 
+		email := entity.VariantEmail{Subject: broadcast.Email.Subject, HTML: broadcast.Email.HTML, Text: broadcast.Email.Text}
+		if v := broadcast.Variants.EmailFor(locales[extID]); v != nil {
+			email = *v
+		}
+
 		tasks = append(tasks, dto.EmailDeliveryTaskPayload{
 			DeliveryID:     d.ID,
 			ProjectID:      broadcast.ProjectID,
 			To:             *d.AddressSnapshot,
-			Subject:        broadcast.Email.Subject,
-			HTML:           broadcast.Email.HTML,
-			Text:           broadcast.Email.Text,
+			Subject:        email.Subject,
+			HTML:           email.HTML,
+			Text:           email.Text,
+			ExpiresAt:      broadcast.ExpiresAt,
 			UnsubscribeURL: s.buildUnsubscribeURL(broadcast.ProjectID, extID, target, mandatory),
 		})
 	}
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/hibiken/asynq"
 	"github.com/mudgallabs/bodhveda/internal/model/dto"
 	"github.com/mudgallabs/bodhveda/internal/model/entity"
 	"github.com/mudgallabs/bodhveda/internal/model/enum"
@@ -19,6 +21,9 @@ type fakePrefRepo struct {
 	shouldDeliver bool
 	cataloged     bool
 	mandatory     bool
+	digest        enum.DigestWindow
+	bypassQuiet   bool
+	frequencyCap  *entity.FrequencyCap
 }
 
 func (f *fakePrefRepo) ShouldDirectNotificationBeDelivered(ctx context.Context, projectID int, recipientExtID string, target dto.Target, medium enum.Medium) (bool, error) {
@@ -29,6 +34,14 @@ func (f *fakePrefRepo) LookupCatalogEntry(ctx context.Context, projectID int, ta
 	return f.cataloged, f.mandatory, nil
 }
 
+func (f *fakePrefRepo) LookupCatalogEmail(ctx context.Context, projectID int, target dto.Target) (entity.CatalogEmailOptions, error) {
+	return entity.CatalogEmailOptions{Digest: f.digest, BypassQuietHours: f.bypassQuiet}, nil
+}
+
+func (f *fakePrefRepo) LookupCatalogFrequencyCap(ctx context.Context, projectID int, target dto.Target, medium enum.Medium) (*entity.FrequencyCap, error) {
+	return f.frequencyCap, nil
+}
+
 type fakeEmailSettingsRepo struct {
 	repository.ProjectEmailSettingsRepository
 	settings *entity.ProjectEmailSettings
@@ -56,11 +69,29 @@ func (f *fakeContactRepo) GetPrimary(ctx context.Context, projectID int, recipie
 type fakeDeliveryRepo struct {
 	repository.NotificationDeliveryRepository
 	created *entity.NotificationDelivery
+	window  enum.DigestWindow
+	item    *entity.EmailDigestItem
+	// createdStatus is the status the row was written with; created.Status can
+	// move on afterwards (a failed enqueue flips it in place).
+	createdStatus enum.DeliveryStatus
 }
 
 func (f *fakeDeliveryRepo) Create(ctx context.Context, d *entity.NotificationDelivery) (*entity.NotificationDelivery, error) {
 	d.ID = 1
 	f.created = d
+	f.createdStatus = d.Status
+	return d, nil
+}
+
+func (f *fakeDeliveryRepo) UpdateResult(ctx context.Context, id int64, result repository.NotificationDeliveryResult) error {
+	return nil
+}
+
+func (f *fakeDeliveryRepo) CreateDigested(ctx context.Context, d *entity.NotificationDelivery, window enum.DigestWindow, item *entity.EmailDigestItem) (*entity.NotificationDelivery, error) {
+	d.ID = 1
+	f.created = d
+	f.window = window
+	f.item = item
 	return d, nil
 }
 
@@ -138,6 +169,71 @@ func TestFanOutEmail_NoContact(t *testing.T) {
 	}
 }
 
+// A digested entry parks the email in the digest rather than enqueueing it (the
+// nil asynqClient would panic if it tried).
+func TestFanOutEmail_Digested_ParksPending(t *testing.T) {
+	d := &fakeDeliveryRepo{}
+	s := serviceWith(&fakePrefRepo{shouldDeliver: true, cataloged: true, digest: enum.DigestDaily}, &fakeEmailSettingsRepo{settings: settings()}, &fakeContactRepo{contact: &entity.RecipientContact{ID: 5, Address: "u@e.com"}}, d)
+
+	_, err := s.fanOutEmail(context.Background(), newNotification(), &dto.EmailContent{Subject: "s", HTML: "<p>h</p>"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.created == nil || d.created.Status != enum.DeliveryPending {
+		t.Fatalf("status = %v, want pending", statusOf(d))
+	}
+	if d.window != enum.DigestDaily {
+		t.Errorf("window = %q, want daily", d.window)
+	}
+	if d.created.AddressSnapshot == nil || *d.created.AddressSnapshot != "u@e.com" {
+		t.Errorf("address = %v, want the contact's", d.created.AddressSnapshot)
+	}
+	if d.item == nil || d.item.Subject != "s" || d.item.HTML != "<p>h</p>" {
+		t.Errorf("item = %+v, want the rendered email", d.item)
+	}
+}
+
+// quietNow is a recipient whose quiet hours started an hour ago and end in two.
+func quietNow() *entity.Recipient {
+	now := time.Now().UTC()
+	start, end := now.Add(-time.Hour).Format("15:04"), now.Add(2*time.Hour).Format("15:04")
+	return &entity.Recipient{ProjectID: 1, ExternalID: "user_1", QuietHoursStart: &start, QuietHoursEnd: &end}
+}
+
+// The send path itself: quiet hours record `deferred`, unless the entry is
+// mandatory or bypasses them. The client points at nothing, so the enqueue that
+// follows fails — createdStatus is what the row was first written as.
+func TestFanOutEmail_QuietHours(t *testing.T) {
+	client := asynq.NewClient(asynq.RedisClientOpt{Addr: "127.0.0.1:1"})
+	defer client.Close()
+
+	tests := []struct {
+		name string
+		pref *fakePrefRepo
+		want enum.DeliveryStatus
+	}{
+		{"inside quiet hours", &fakePrefRepo{shouldDeliver: true, cataloged: true}, enum.DeliveryDeferred},
+		{"mandatory entry", &fakePrefRepo{shouldDeliver: true, cataloged: true, mandatory: true}, enum.DeliveryPending},
+		{"entry bypasses quiet hours", &fakePrefRepo{shouldDeliver: true, cataloged: true, bypassQuiet: true}, enum.DeliveryPending},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &fakeDeliveryRepo{}
+			s := serviceWith(tc.pref, &fakeEmailSettingsRepo{settings: settings()}, &fakeContactRepo{contact: &entity.RecipientContact{ID: 5, Address: "u@e.com"}}, d)
+			recipients := newFakeRecipientRepo()
+			_, _ = recipients.Create(context.Background(), quietNow())
+			s.recipientRepo = recipients
+			s.asynqClient = client
+
+			_, _ = s.fanOutEmail(context.Background(), newNotification(), &dto.EmailContent{Subject: "s", Text: "t"})
+			if d.created == nil || d.createdStatus != tc.want {
+				t.Fatalf("status = %v, want %v", d.createdStatus, tc.want)
+			}
+		})
+	}
+}
+
 func statusOf(d *fakeDeliveryRepo) any {
 	if d.created == nil {
 		return "<no row created>"
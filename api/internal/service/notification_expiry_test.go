@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+)
+
+// recordingDeliveryRepo keeps the delivery rows it is asked to create.
+type recordingDeliveryRepo struct {
+	repository.NotificationDeliveryRepository
+	created []*entity.NotificationDelivery
+}
+
+func (r *recordingDeliveryRepo) Create(ctx context.Context, d *entity.NotificationDelivery) (*entity.NotificationDelivery, error) {
+	r.created = append(r.created, d)
+	return d, nil
+}
+
+// TestExpiredNotificationSkipsEmailFanOut — a delivery job that runs after
+// expires_at records the email as `expired` and stops. The service has no
+// preference, contact or provider repos, so going any further would panic.
+func TestExpiredNotificationSkipsEmailFanOut(t *testing.T) {
+	deliveries := &recordingDeliveryRepo{}
+	svc := NewNotificationService(nil, nil, nil, nil, nil, deliveries, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	notification := &entity.Notification{ID: 7, ProjectID: 1, RecipientExtID: "user-1", ExpiresAt: &expiredAt}
+
+	d, err := svc.fanOutEmail(context.Background(), notification, &dto.EmailContent{Subject: "Standup", Text: "Now"})
+	if err != nil {
+		t.Fatalf("fan out: %v", err)
+	}
+
+	if len(deliveries.created) != 1 || d.Status != enum.DeliveryExpired {
+		t.Fatalf("recorded %d rows (status %q), want one expired row", len(deliveries.created), d.Status)
+	}
+}
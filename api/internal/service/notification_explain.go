@@ -0,0 +1,561 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	tantraRepo "github.com/mudgallabs/tantra/repository"
+	"github.com/mudgallabs/tantra/service"
+)
+
+// explainRequest is what an explanation is about. notification is set when
+// explaining one that was sent, and answers the questions a bare recipient
+// cannot: whether it has expired, and whether its email is the only medium.
+type explainRequest struct {
+	userID         int
+	projectID      int
+	recipientExtID string
+	target         dto.Target
+	medium         enum.Medium
+	kind           dto.ExplainKind
+	notification   *entity.Notification
+	broadcast      *entity.Broadcast
+}
+
+// explainLog collects the steps, and what the preference step found that the
+// later ones need.
+type explainLog struct {
+	steps     []dto.ExplainStep
+	recipient *entity.Recipient
+}
+
+func (l *explainLog) add(step dto.ExplainStep) {
+	l.steps = append(l.steps, step)
+}
+
+// ExplainRecipient reports what a send of payload's kind, target and medium to
+// the recipient would do right now, check by check.
+//
+// It reads everything the send path reads and writes nothing. In particular it
+// never creates the recipient it names, and never counts against a frequency
+// cap: both would change the answer to the next question.
+func (s *NotificationService) ExplainRecipient(ctx context.Context, userID, projectID int, recipientExtID string, payload dto.ExplainRecipientPayload) (*dto.Explanation, service.Error, error) {
+	if err := payload.Validate(); err != nil {
+		return nil, service.ErrInvalidInput, err
+	}
+
+	return s.explain(ctx, explainRequest{
+		userID:         userID,
+		projectID:      projectID,
+		recipientExtID: recipientExtID,
+		target:         payload.Target,
+		medium:         enum.Medium(payload.Medium),
+		kind:           payload.Kind,
+	})
+}
+
+// ExplainNotification is ExplainRecipient for the recipient, target and kind of
+// a notification that was sent, with the outcome it actually recorded attached.
+func (s *NotificationService) ExplainNotification(ctx context.Context, userID, projectID, notificationID int, payload dto.ExplainNotificationPayload) (*dto.Explanation, service.Error, error) {
+	if err := payload.Validate(); err != nil {
+		return nil, service.ErrInvalidInput, err
+	}
+
+	notification, err := s.repo.Get(ctx, projectID, notificationID)
+	if err != nil {
+		if errors.Is(err, tantraRepo.ErrNotFound) {
+			return nil, service.ErrNotFound, fmt.Errorf("Notification not found")
+		}
+		return nil, service.ErrInternalServerError, fmt.Errorf("get notification: %w", err)
+	}
+
+	medium := enum.Medium(payload.Medium)
+	if medium == "" {
+		medium = enum.MediumInApp
+		if !dto.IsJSONContent(notification.Payload) {
+			medium = enum.MediumEmail
+		}
+	}
+
+	req := explainRequest{
+		userID:         userID,
+		projectID:      projectID,
+		recipientExtID: notification.RecipientExtID,
+		target:         dto.TargetFromNotification(notification),
+		medium:         medium,
+		kind:           dto.ExplainKindDirect,
+		notification:   notification,
+	}
+
+	if notification.BroadcastID != nil {
+		req.kind = dto.ExplainKindBroadcast
+		req.broadcast, err = s.broadcastRepo.GetByID(ctx, *notification.BroadcastID)
+		if err != nil {
+			return nil, service.ErrInternalServerError, fmt.Errorf("get broadcast: %w", err)
+		}
+	}
+
+	explanation, errKind, err := s.explain(ctx, req)
+	if err != nil {
+		return nil, errKind, err
+	}
+
+	explanation.NotificationID = &notification.ID
+	explanation.Recorded, err = s.recordedOutcome(ctx, notification, medium)
+	if err != nil {
+		return nil, service.ErrInternalServerError, err
+	}
+
+	return explanation, service.ErrNone, nil
+}
+
+// explain runs the checks in the order the send path makes them for req's kind
+// and medium. The verdict is the first failing step; the ones after it are
+// still run, so fixing the first problem does not just reveal the next.
+func (s *NotificationService) explain(ctx context.Context, req explainRequest) (*dto.Explanation, service.Error, error) {
+	log := &explainLog{}
+
+	steps := []func(context.Context, explainRequest, *explainLog) error{
+		s.explainRecipient,
+		s.explainStrictTargets,
+		s.explainExpiry,
+		s.explainPreference,
+	}
+
+	switch {
+	case req.medium == enum.MediumInApp && req.kind == dto.ExplainKindDirect:
+		steps = append(steps, s.explainFrequencyCap, s.explainQuota)
+	case req.medium == enum.MediumInApp:
+		steps = append(steps, s.explainQuota)
+	case req.kind == dto.ExplainKindDirect:
+		steps = append(steps,
+			s.explainSuppression, s.explainProvider, s.explainContact,
+			s.explainFrequencyCap, s.explainDigestAndQuietHours, s.explainQuota,
+		)
+	default:
+		steps = append(steps,
+			s.explainSuppression, s.explainBroadcastEmail, s.explainProvider, s.explainContact,
+		)
+	}
+
+	for _, step := range steps {
+		if err := step(ctx, req, log); err != nil {
+			return nil, service.ErrInternalServerError, err
+		}
+	}
+
+	explanation := &dto.Explanation{
+		RecipientID: req.recipientExtID,
+		Target:      req.target,
+		Medium:      string(req.medium),
+		Kind:        req.kind,
+		Deliver:     true,
+		Steps:       log.steps,
+	}
+
+	for _, step := range log.steps {
+		if step.Result == dto.ExplainFail {
+			explanation.Deliver = false
+			explanation.Reason = step.Reason
+			break
+		}
+	}
+
+	return explanation, service.ErrNone, nil
+}
+
+func (s *NotificationService) explainRecipient(ctx context.Context, req explainRequest, log *explainLog) error {
+	recipient, err := s.recipientRepo.Get(ctx, req.projectID, req.recipientExtID)
+	if err != nil && !errors.Is(err, tantraRepo.ErrNotFound) {
+		return fmt.Errorf("get recipient: %w", err)
+	}
+
+	switch {
+	case recipient != nil:
+		log.recipient = recipient
+		log.add(dto.ExplainStep{Step: dto.ExplainStepRecipient, Result: dto.ExplainPass, Detail: "The recipient exists."})
+	case req.kind == dto.ExplainKindBroadcast:
+		log.add(dto.ExplainStep{
+			Step: dto.ExplainStepRecipient, Result: dto.ExplainFail, Reason: "unknown_recipient",
+			Detail: "No recipient has this id. A broadcast only reaches recipients that exist.",
+		})
+	default:
+		log.add(dto.ExplainStep{
+			Step: dto.ExplainStepRecipient, Result: dto.ExplainInfo,
+			Detail: "No recipient has this id yet. A direct send creates it, with no preferences or contacts of its own.",
+		})
+	}
+
+	return nil
+}
+
+// explainStrictTargets runs gateTarget itself rather than restating it, so the
+// answer is the one a send would get.
+func (s *NotificationService) explainStrictTargets(ctx context.Context, req explainRequest, log *explainLog) error {
+	step := dto.ExplainStep{Step: dto.ExplainStepStrictTargets, Result: dto.ExplainPass}
+
+	if req.target.Channel == "" {
+		step.Detail = "The send names no target, so there is nothing to check against the catalog."
+		log.add(step)
+		return nil
+	}
+
+	project, err := s.projectRepo.Get(ctx, req.projectID)
+	if err != nil {
+		return fmt.Errorf("get project: %w", err)
+	}
+	if !project.StrictTargets {
+		step.Detail = "Strict targets are off, so any target may be sent."
+		log.add(step)
+		return nil
+	}
+
+	errKind, err := s.gateTarget(ctx, req.projectID, &req.target, []enum.Medium{req.medium})
+	switch {
+	case errKind == service.ErrBadRequest:
+		step.Result, step.Reason, step.Detail = dto.ExplainFail, "not_in_catalog", err.Error()
+	case err != nil:
+		return err
+	default:
+		step.Detail = fmt.Sprintf("The target is in the catalog for %s, as strict targets require.", req.medium)
+	}
+
+	log.add(step)
+	return nil
+}
+
+// explainExpiry only applies to a notification. A bare recipient has no send
+// to have expired.
+func (s *NotificationService) explainExpiry(ctx context.Context, req explainRequest, log *explainLog) error {
+	if req.notification == nil || req.notification.ExpiresAt == nil || req.medium != enum.MediumEmail {
+		return nil
+	}
+
+	step := dto.ExplainStep{Step: dto.ExplainStepExpiry, Result: dto.ExplainPass}
+	if req.notification.Expired(time.Now()) {
+		step.Result, step.Reason = dto.ExplainFail, string(enum.DeliveryExpired)
+		step.Detail = fmt.Sprintf("The notification expired at %s, and an expired email is not sent.", req.notification.ExpiresAt.UTC().Format(time.RFC3339))
+	} else {
+		step.Detail = fmt.Sprintf("The notification expires at %s.", req.notification.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+
+	log.add(step)
+	return nil
+}
+
+// explainPreference shows every rung of the cascade next to the winner. The
+// winner comes from the same resolver as the preferences read, never from the
+// rungs, so the trace cannot disagree with a send.
+func (s *NotificationService) explainPreference(ctx context.Context, req explainRequest, log *explainLog) error {
+	step := dto.ExplainStep{Step: dto.ExplainStepPreference, Result: dto.ExplainPass}
+
+	if req.target.Channel == "" {
+		step.Detail = "An untargeted send has no preference to consult and always delivers."
+		log.add(step)
+		return nil
+	}
+
+	resolved, err := s.preferenceRepo.ResolveRecipientPreferenceForTargets(
+		ctx, req.projectID, req.recipientExtID, []enum.Medium{req.medium}, []dto.Target{req.target},
+	)
+	if err != nil {
+		return fmt.Errorf("resolve recipient preference: %w", err)
+	}
+	if len(resolved) != 1 {
+		return fmt.Errorf("resolve returned %d cells for one (target, medium)", len(resolved))
+	}
+	cell := resolved[0]
+
+	rungs, err := s.preferenceRepo.TracePreference(ctx, req.projectID, req.recipientExtID, req.target, req.medium)
+	if err != nil {
+		return fmt.Errorf("trace preference: %w", err)
+	}
+
+	// A broadcast asks for a positive opt-in; only the default differs.
+	defaultEnabled := req.medium == enum.MediumInApp && req.kind == dto.ExplainKindDirect
+	enabled := cell.Enabled
+	if cell.Source == entity.PreferenceSourceDefault {
+		enabled = defaultEnabled
+	}
+
+	step.Cascade = dto.ExplainRungsFromTrace(rungs, cell.Source, defaultEnabled)
+	step.Detail = preferenceDetail(cell, enabled, req.medium)
+
+	if !enabled {
+		step.Result = dto.ExplainFail
+		// The broadcast buckets split on whether the recipient said anything at
+		// all, not on which rung won, exactly as CountBroadcastAudience does.
+		ownRow := false
+		for _, r := range rungs {
+			isOwn := r.Source == entity.PreferenceSourceRecipientExact || r.Source == entity.PreferenceSourceRecipientAny
+			ownRow = ownRow || (isOwn && r.Consulted && r.PreferenceID != nil)
+		}
+
+		switch {
+		case req.kind == dto.ExplainKindBroadcast && ownRow:
+			step.Reason = "excluded_disabled"
+		case req.kind == dto.ExplainKindBroadcast:
+			step.Reason = "excluded_not_cataloged"
+		case req.medium == enum.MediumInApp:
+			step.Reason = string(enum.NotificationStatusMuted)
+		case !cell.Cataloged:
+			step.Reason = "not_cataloged"
+		default:
+			step.Reason = "preference_disabled"
+		}
+	}
+
+	log.add(step)
+	return nil
+}
+
+// preferenceDetail says, in a sentence, which rung decided and what it said.
+func preferenceDetail(cell *entity.ResolvedPreference, enabled bool, medium enum.Medium) string {
+	onOff := "off"
+	if enabled {
+		onOff = "on"
+	}
+
+	switch cell.Source {
+	case entity.PreferenceSourceRecipientExact:
+		return fmt.Sprintf("The recipient turned %s on for this target: %s.", medium, onOff)
+	case entity.PreferenceSourceRecipientAny:
+		return fmt.Sprintf("The recipient's rule for every topic of this channel and event decides: %s.", onOff)
+	case entity.PreferenceSourceProjectExact, entity.PreferenceSourceProjectAny:
+		if cell.Mandatory {
+			return fmt.Sprintf("The catalog entry is mandatory, so it decides over the recipient's own preference: %s.", onOff)
+		}
+		return fmt.Sprintf("The recipient has said nothing, so the catalog entry's default decides: %s.", onOff)
+	default:
+		if enabled {
+			return "Nothing is stored for this target, and a direct in-app send delivers by default."
+		}
+		return "Nothing is stored for this target, and this send needs a catalog entry or the recipient's opt-in."
+	}
+}
+
+// explainSuppression reports a spam complaint. A complaint does its work by
+// turning the recipient's email preference for the target off, so this step
+// explains the preference step rather than gating on its own; a recipient who
+// turned it back on since is mailed again.
+func (s *NotificationService) explainSuppression(ctx context.Context, req explainRequest, log *explainLog) error {
+	if req.target.Channel == "" {
+		return nil
+	}
+
+	step := dto.ExplainStep{
+		Step: dto.ExplainStepSuppression, Result: dto.ExplainPass,
+		Detail: "No spam complaint on this target. Bounces are recorded but do not suppress.",
+	}
+
+	complaint, err := s.deliveryRepo.LatestComplaint(ctx, req.projectID, req.recipientExtID, req.target)
+	if err != nil && !errors.Is(err, tantraRepo.ErrNotFound) {
+		return fmt.Errorf("get latest complaint: %w", err)
+	}
+	if complaint != nil {
+		step.Result = dto.ExplainInfo
+		step.ComplainedAt = complaint.ComplainedAt
+		step.Detail = fmt.Sprintf("The recipient marked notification %d as spam, which turned their email preference for this target off.", complaint.NotificationID)
+	}
+
+	log.add(step)
+	return nil
+}
+
+// explainBroadcastEmail reports the go/no-go a broadcast's email half was
+// given at fan-out. Only a notification has one: for a bare recipient the cap
+// depends on the whole audience, which the dry run reports instead.
+func (s *NotificationService) explainBroadcastEmail(ctx context.Context, req explainRequest, log *explainLog) error {
+	if req.broadcast == nil {
+		return nil
+	}
+
+	step := dto.ExplainStep{Step: dto.ExplainStepEmailBlocked, Result: dto.ExplainPass}
+	switch {
+	case req.broadcast.Email == nil:
+		step.Result, step.Reason = dto.ExplainFail, string(enum.NotificationStatusNotRequested)
+		step.Detail = "The broadcast carried no email."
+	case req.broadcast.Email.BlockedReason != "":
+		step.Result, step.Reason = dto.ExplainFail, req.broadcast.Email.BlockedReason
+		step.Detail = "The broadcast's email was blocked for its whole audience when it fanned out."
+	default:
+		step.Detail = "The broadcast's email was cleared to send when it fanned out."
+	}
+
+	log.add(step)
+	return nil
+}
+
+func (s *NotificationService) explainProvider(ctx context.Context, req explainRequest, log *explainLog) error {
+	step := dto.ExplainStep{Step: dto.ExplainStepProvider, Result: dto.ExplainPass}
+
+	settings, err := s.projectEmailRepo.Get(ctx, req.projectID)
+	switch {
+	case errors.Is(err, tantraRepo.ErrNotFound):
+		step.Result, step.Reason = dto.ExplainFail, "provider_not_configured"
+		step.Detail = "The project has no email provider set up."
+	case err != nil:
+		return fmt.Errorf("get project email settings: %w", err)
+	default:
+		step.Detail = fmt.Sprintf("The project sends email through %s.", settings.Provider)
+	}
+
+	log.add(step)
+	return nil
+}
+
+func (s *NotificationService) explainContact(ctx context.Context, req explainRequest, log *explainLog) error {
+	step := dto.ExplainStep{Step: dto.ExplainStepContact, Result: dto.ExplainPass}
+
+	contact, err := s.contactRepo.GetPrimary(ctx, req.projectID, req.recipientExtID, enum.MediumEmail)
+	switch {
+	case errors.Is(err, tantraRepo.ErrNotFound):
+		step.Result, step.Reason = dto.ExplainFail, string(enum.DeliverySkippedNoContact)
+		step.Detail = "The recipient has no primary email contact."
+	case err != nil:
+		return fmt.Errorf("get primary contact: %w", err)
+	default:
+		step.Contact = &dto.ExplainContact{ID: contact.ID, Address: contact.Address, Verified: contact.VerifiedAt != nil}
+		step.Detail = fmt.Sprintf("It would go to the primary contact, %s.", contact.Address)
+	}
+
+	log.add(step)
+	return nil
+}
+
+// explainFrequencyCap reports the cap but does not evaluate it. The only way
+// to ask the counter is to take from it, and an explanation that spent the
+// recipient's allowance would throttle the very send it was explaining.
+func (s *NotificationService) explainFrequencyCap(ctx context.Context, req explainRequest, log *explainLog) error {
+	if req.target.Channel == "" {
+		return nil
+	}
+
+	frequencyCap, err := s.preferenceRepo.LookupCatalogFrequencyCap(ctx, req.projectID, req.target, req.medium)
+	if err != nil {
+		return fmt.Errorf("lookup frequency cap: %w", err)
+	}
+
+	step := dto.ExplainStep{Step: dto.ExplainStepFrequencyCap, Result: dto.ExplainPass, Detail: "The catalog entry has no frequency cap."}
+	if frequencyCap != nil {
+		step.Result = dto.ExplainInfo
+		step.FrequencyCap = &dto.ExplainFrequencyCap{Limit: frequencyCap.Limit, Window: frequencyCap.Window}
+		step.Detail = fmt.Sprintf("Capped at %d per %s. Not evaluated: checking the cap counts against it.", frequencyCap.Limit, frequencyCap.Window)
+	}
+
+	log.add(step)
+	return nil
+}
+
+// explainDigestAndQuietHours are the two steps that decide when an email goes,
+// not whether. A digest wins: a digested email waits for the flush and quiet
+// hours are never consulted.
+func (s *NotificationService) explainDigestAndQuietHours(ctx context.Context, req explainRequest, log *explainLog) error {
+	catalog := s.catalogEmailOptions(ctx, req.projectID, req.target)
+
+	if catalog.Digest != "" {
+		log.add(dto.ExplainStep{
+			Step: dto.ExplainStepDigest, Result: dto.ExplainInfo,
+			Detail: fmt.Sprintf("The catalog entry collects this email into the recipient's %s digest.", catalog.Digest),
+		})
+		return nil
+	}
+
+	step := dto.ExplainStep{Step: dto.ExplainStepQuietHours, Result: dto.ExplainPass, Detail: "Not inside the recipient's quiet hours."}
+
+	mandatory := false
+	if req.target.Channel != "" {
+		var err error
+		if _, mandatory, err = s.preferenceRepo.LookupCatalogEntry(ctx, req.projectID, req.target, enum.MediumEmail); err != nil {
+			return fmt.Errorf("lookup catalog entry: %w", err)
+		}
+	}
+
+	switch {
+	case mandatory || catalog.BypassQuietHours:
+		step.Detail = "The catalog entry goes out during quiet hours."
+	case log.recipient != nil:
+		if until, quiet := log.recipient.QuietUntil(time.Now()); quiet {
+			step.Result = dto.ExplainInfo
+			step.Until = &until
+			step.Detail = fmt.Sprintf("Inside the recipient's quiet hours, so it would wait until %s.", until.UTC().Format(time.RFC3339))
+		}
+	}
+
+	log.add(step)
+	return nil
+}
+
+// explainQuota estimates the one unit a direct send meters. A broadcast is
+// metered once for its whole audience, and a mixed send's email rides on the
+// in-app unit and goes out over quota regardless, so neither gates here.
+func (s *NotificationService) explainQuota(ctx context.Context, req explainRequest, log *explainLog) error {
+	step := dto.ExplainStep{Step: dto.ExplainStepQuota, Result: dto.ExplainPass}
+
+	if req.kind == dto.ExplainKindBroadcast {
+		step.Result = dto.ExplainInfo
+		step.Detail = "A broadcast is metered once, for its whole audience, when it fans out."
+		log.add(step)
+		return nil
+	}
+
+	mixed := req.medium == enum.MediumEmail && req.notification != nil && dto.IsJSONContent(req.notification.Payload)
+	if mixed {
+		step.Result = dto.ExplainInfo
+		step.Detail = "Metered with the in-app half. The email goes out even over quota."
+		log.add(step)
+		return nil
+	}
+
+	usage, err := s.billingService.EstimateUsage(ctx, dto.UsageEvent{
+		UserID:    req.userID,
+		ProjectID: req.projectID,
+		Metric:    entity.MetricNotifications,
+		Amount:    1,
+	})
+	if err != nil {
+		return fmt.Errorf("estimate usage: %w", err)
+	}
+	step.Usage = usage
+	step.Detail = "Within the plan's quota."
+
+	if usage.WouldExceed {
+		step.Result, step.Reason = dto.ExplainFail, string(enum.NotificationStatusQuotaExceeded)
+		step.Detail = "The plan's quota is used up."
+		// Asked about a recipient rather than a notification, an email may be
+		// going out next to an in-app one, which would carry it.
+		if req.medium == enum.MediumEmail && req.notification == nil {
+			step.Result, step.Reason = dto.ExplainInfo, ""
+			step.Detail = "The plan's quota is used up. An email-only send would be refused; one sent with in-app still goes out."
+		}
+	}
+
+	log.add(step)
+	return nil
+}
+
+// recordedOutcome is what the notification stored for medium: its own status
+// for in-app, its delivery row's for email.
+func (s *NotificationService) recordedOutcome(ctx context.Context, notification *entity.Notification, medium enum.Medium) (*dto.ExplainRecorded, error) {
+	if medium == enum.MediumInApp {
+		return &dto.ExplainRecorded{Status: string(notification.Status)}, nil
+	}
+
+	deliveries, err := s.deliveryRepo.ListForNotification(ctx, notification.ProjectID, notification.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries: %w", err)
+	}
+
+	for _, d := range deliveries {
+		if d.Medium == medium {
+			return &dto.ExplainRecorded{Status: string(d.Status), FailureReason: d.FailureReason}, nil
+		}
+	}
+
+	return &dto.ExplainRecorded{}, nil
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	tantraRepo "github.com/mudgallabs/tantra/repository"
+)
+
+// --- Fakes. Explain only reads, so every writer stays a nil embed. ---
+
+type explainPrefRepo struct {
+	fakePrefRepo
+	resolved *entity.ResolvedPreference
+	rungs    []*entity.PreferenceRung
+}
+
+func (f *explainPrefRepo) ResolveRecipientPreferenceForTargets(ctx context.Context, projectID int, recipientExtID string, mediums []enum.Medium, targets []dto.Target) ([]*entity.ResolvedPreference, error) {
+	return []*entity.ResolvedPreference{f.resolved}, nil
+}
+
+func (f *explainPrefRepo) TracePreference(ctx context.Context, projectID int, recipientExtID string, target dto.Target, medium enum.Medium) ([]*entity.PreferenceRung, error) {
+	return f.rungs, nil
+}
+
+type explainRecipientRepo struct {
+	repository.RecipientRepository
+	recipient *entity.Recipient
+}
+
+func (f *explainRecipientRepo) Get(ctx context.Context, projectID int, externalID string) (*entity.Recipient, error) {
+	if f.recipient == nil {
+		return nil, tantraRepo.ErrNotFound
+	}
+	return f.recipient, nil
+}
+
+type explainDeliveryRepo struct {
+	repository.NotificationDeliveryRepository
+}
+
+func (f *explainDeliveryRepo) LatestComplaint(ctx context.Context, projectID int, recipientExtID string, target dto.Target) (*entity.NotificationDelivery, error) {
+	return nil, tantraRepo.ErrNotFound
+}
+
+// rungs builds a trace with a row on the given rungs only.
+func rungs(enabled map[entity.PreferenceSource]bool) []*entity.PreferenceRung {
+	sources := []entity.PreferenceSource{
+		entity.PreferenceSourceRecipientExact, entity.PreferenceSourceRecipientAny,
+		entity.PreferenceSourceProjectExact, entity.PreferenceSourceProjectAny,
+	}
+
+	out := make([]*entity.PreferenceRung, 0, len(sources))
+	for i, source := range sources {
+		rung := &entity.PreferenceRung{Source: source, Consulted: true}
+		if e, ok := enabled[source]; ok {
+			id := i + 1
+			rung.PreferenceID, rung.Enabled = &id, e
+		}
+		out = append(out, rung)
+	}
+	return out
+}
+
+func explainService(pref *explainPrefRepo, recipient *entity.Recipient, contact *entity.RecipientContact, counter repository.FrequencyCounterRepository) *NotificationService {
+	projectRepo := &previewProjectRepo{}
+	billing := NewBillingService(nil, projectRepo, &previewSubRepo{}, nil, &previewUsageRepo{})
+
+	return NewNotificationService(
+		nil, &explainRecipientRepo{recipient: recipient}, pref, nil, nil, &explainDeliveryRepo{},
+		&fakeContactRepo{contact: contact}, &fakeEmailSettingsRepo{settings: settings()}, projectRepo, nil,
+		counter, nil, billing, nil, nil,
+	)
+}
+
+func explainQuery(medium enum.Medium, kind dto.ExplainKind) dto.ExplainRecipientPayload {
+	return dto.ExplainRecipientPayload{
+		CheckRecipientTargetPayload: dto.CheckRecipientTargetPayload{Target: *someTarget(), Medium: string(medium)},
+		Kind:                        kind,
+	}
+}
+
+func findStep(t *testing.T, e *dto.Explanation, name string) dto.ExplainStep {
+	t.Helper()
+	for _, step := range e.Steps {
+		if step.Step == name {
+			return step
+		}
+	}
+	t.Fatalf("no %q step in %+v", name, e.Steps)
+	return dto.ExplainStep{}
+}
+
+// TestExplainEmailWithoutContact — the verdict names the first failing check,
+// and the checks after it still run, so the caller sees everything to fix.
+func TestExplainEmailWithoutContact(t *testing.T) {
+	pref := &explainPrefRepo{
+		fakePrefRepo: fakePrefRepo{cataloged: true},
+		resolved:     &entity.ResolvedPreference{Enabled: true, Cataloged: true, Source: entity.PreferenceSourceProjectExact},
+		rungs:        rungs(map[entity.PreferenceSource]bool{entity.PreferenceSourceProjectExact: true}),
+	}
+
+	e, _, err := explainService(pref, &entity.Recipient{}, nil, nil).ExplainRecipient(
+		context.Background(), 1, 1, "u1", explainQuery(enum.MediumEmail, ""))
+	if err != nil {
+		t.Fatalf("explain: %v", err)
+	}
+
+	if e.Deliver || e.Reason != string(enum.DeliverySkippedNoContact) {
+		t.Errorf("verdict = %v %q, want no_contact", e.Deliver, e.Reason)
+	}
+	if e.Kind != dto.ExplainKindDirect {
+		t.Errorf("kind = %q, want direct by default", e.Kind)
+	}
+
+	preference := findStep(t, e, dto.ExplainStepPreference)
+	if preference.Result != dto.ExplainPass || len(preference.Cascade) != 5 {
+		t.Fatalf("preference = %+v, want a pass with four rungs and the default", preference)
+	}
+	for _, rung := range preference.Cascade {
+		if rung.Decided != (rung.Source == entity.PreferenceSourceProjectExact) {
+			t.Errorf("rung %s decided = %v, want only project_exact", rung.Source, rung.Decided)
+		}
+	}
+
+	findStep(t, e, dto.ExplainStepQuietHours)
+	if quota := findStep(t, e, dto.ExplainStepQuota); quota.Usage == nil {
+		t.Error("quota step should carry the usage estimate")
+	}
+}
+
+// TestExplainBroadcastNeedsOptIn — nothing stored delivers a direct in-app send
+// but excludes the recipient from a broadcast, and the trace's default rung
+// says so.
+func TestExplainBroadcastNeedsOptIn(t *testing.T) {
+	pref := &explainPrefRepo{
+		resolved: &entity.ResolvedPreference{Enabled: true, Source: entity.PreferenceSourceDefault},
+		rungs:    rungs(nil),
+	}
+	svc := explainService(pref, &entity.Recipient{}, nil, nil)
+
+	direct, _, err := svc.ExplainRecipient(context.Background(), 1, 1, "u1", explainQuery(enum.MediumInApp, dto.ExplainKindDirect))
+	if err != nil {
+		t.Fatalf("explain direct: %v", err)
+	}
+	if !direct.Deliver {
+		t.Errorf("direct in-app with nothing stored should deliver, got %q", direct.Reason)
+	}
+
+	broadcast, _, err := svc.ExplainRecipient(context.Background(), 1, 1, "u1", explainQuery(enum.MediumInApp, dto.ExplainKindBroadcast))
+	if err != nil {
+		t.Fatalf("explain broadcast: %v", err)
+	}
+	if broadcast.Deliver || broadcast.Reason != "excluded_not_cataloged" {
+		t.Errorf("broadcast verdict = %v %q, want excluded_not_cataloged", broadcast.Deliver, broadcast.Reason)
+	}
+
+	cascade := findStep(t, broadcast, dto.ExplainStepPreference).Cascade
+	if last := cascade[len(cascade)-1]; !last.Decided || last.Enabled == nil || *last.Enabled {
+		t.Errorf("default rung = %+v, want decided and off", last)
+	}
+}
+
+// TestExplainDoesNotSpendFrequencyCap — the counter can only be asked by taking
+// from it, so an explanation must report the cap without touching it.
+func TestExplainDoesNotSpendFrequencyCap(t *testing.T) {
+	pref := &explainPrefRepo{
+		fakePrefRepo: fakePrefRepo{frequencyCap: &entity.FrequencyCap{Limit: 1, Window: enum.CapPerDay}},
+		resolved:     &entity.ResolvedPreference{Enabled: true, Source: entity.PreferenceSourceDefault},
+		rungs:        rungs(nil),
+	}
+	counter := &fakeCounter{}
+
+	e, _, err := explainService(pref, nil, nil, counter).ExplainRecipient(
+		context.Background(), 1, 1, "u1", explainQuery(enum.MediumInApp, ""))
+	if err != nil {
+		t.Fatalf("explain: %v", err)
+	}
+
+	if len(counter.counts) != 0 {
+		t.Errorf("explain took from the frequency counter: %v", counter.counts)
+	}
+	if step := findStep(t, e, dto.ExplainStepFrequencyCap); step.FrequencyCap == nil || step.FrequencyCap.Limit != 1 {
+		t.Errorf("frequency cap step = %+v, want the cap reported", step)
+	}
+	if step := findStep(t, e, dto.ExplainStepRecipient); step.Result != dto.ExplainInfo {
+		t.Errorf("recipient step = %+v, want info for a recipient a direct send would create", step)
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	tantraRepo "github.com/mudgallabs/tantra/repository"
+	"github.com/mudgallabs/tantra/service"
+)
+
+// patchRepo applies the same predicate the SQL does: only a delivered row can
+// be edited, and a version, when given, must be the current one.
+type patchRepo struct {
+	scheduleRepo
+	missing bool
+	version int
+}
+
+func (r *patchRepo) Patch(ctx context.Context, patch *dto.PatchNotificationPayload) (*entity.Notification, error) {
+	if r.missing {
+		return nil, tantraRepo.ErrNotFound
+	}
+	if r.status != enum.NotificationStatusDelivered {
+		return nil, tantraRepo.ErrConflict
+	}
+	if patch.Version != nil && *patch.Version != r.version {
+		return nil, enum.ErrVersionMismatch
+	}
+	r.version++
+	return &entity.Notification{ID: patch.NotificationID, ProjectID: patch.ProjectID, Status: r.status, Payload: patch.Payload, Version: r.version}, nil
+}
+
+func patchPayload(version *int) dto.PatchNotificationPayload {
+	return dto.PatchNotificationPayload{
+		ProjectID:        1,
+		NotificationID:   7,
+		NotificationEdit: dto.NotificationEdit{Payload: json.RawMessage(`{"title":"Alice and 3 others"}`), Version: version},
+	}
+}
+
+func TestPatchBumpsVersion(t *testing.T) {
+	repo := &patchRepo{scheduleRepo: scheduleRepo{status: enum.NotificationStatusDelivered}, version: 1}
+	svc := NewNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	one := 1
+	got, errKind, err := svc.Patch(context.Background(), patchPayload(&one))
+	if err != nil {
+		t.Fatalf("patch: %v (kind %v)", err, errKind)
+	}
+	if got.Version != 2 {
+		t.Errorf("version = %d, want 2", got.Version)
+	}
+	if string(got.Payload) != `{"title":"Alice and 3 others"}` {
+		t.Errorf("payload = %s, want the new one", got.Payload)
+	}
+}
+
+// Two writers that both read version 1: the second must be told, not silently
+// overwrite the first.
+func TestPatchStaleVersionConflicts(t *testing.T) {
+	repo := &patchRepo{scheduleRepo: scheduleRepo{status: enum.NotificationStatusDelivered}, version: 1}
+	svc := NewNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	one := 1
+	if _, _, err := svc.Patch(context.Background(), patchPayload(&one)); err != nil {
+		t.Fatalf("first patch: %v", err)
+	}
+	_, errKind, err := svc.Patch(context.Background(), patchPayload(&one))
+	if err == nil || errKind != service.ErrConflict {
+		t.Errorf("stale patch: got (%v, %v), want a conflict", errKind, err)
+	}
+
+	// Without a version the edit goes through regardless.
+	if _, _, err := svc.Patch(context.Background(), patchPayload(nil)); err != nil {
+		t.Errorf("unversioned patch: %v", err)
+	}
+}
+
+func TestPatchErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		repo *patchRepo
+		want service.Error
+	}{
+		{"missing", &patchRepo{missing: true}, service.ErrNotFound},
+		{"recalled", &patchRepo{scheduleRepo: scheduleRepo{status: enum.NotificationStatusRecalled}}, service.ErrConflict},
+		{"scheduled", &patchRepo{scheduleRepo: scheduleRepo{status: enum.NotificationStatusScheduled}}, service.ErrConflict},
+	}
+
+	for _, c := range cases {
+		svc := NewNotificationService(c.repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		_, errKind, err := svc.Patch(context.Background(), patchPayload(nil))
+		if err == nil || errKind != c.want {
+			t.Errorf("%s: got (%v, %v), want %v", c.name, errKind, err, c.want)
+		}
+	}
+
+	svc := NewNotificationService(&patchRepo{}, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	bad := patchPayload(nil)
+	bad.Payload = nil
+	if _, errKind, _ := svc.Patch(context.Background(), bad); errKind != service.ErrInvalidInput {
+		t.Errorf("empty payload: got %v, want invalid input", errKind)
+	}
+}
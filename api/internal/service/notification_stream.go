@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/tantra/logger"
+	"github.com/mudgallabs/tantra/query"
+	"github.com/mudgallabs/tantra/service"
+)
+
+// InboxReplayLimit bounds what a reconnecting stream replays. A client that
+// missed more than this was away long enough to reload its feed instead.
+const InboxReplayLimit = 100
+
+// publishInbox fans feed changes out to open streams. Best-effort, like the
+// email fan-out: the row is already written, a stream that misses the event
+// catches up on its next reconnect, and Redis being down must never fail the
+// write that triggered it.
+func (s *NotificationService) publishInbox(ctx context.Context, projectID int, events ...*dto.InboxEvent) {
+	publishInboxEvents(ctx, s.inboxEvents, projectID, events...)
+}
+
+// publishInboxEvents is publishInbox for any service holding the bus.
+func publishInboxEvents(ctx context.Context, bus repository.InboxEventRepository, projectID int, events ...*dto.InboxEvent) {
+	if bus == nil || len(events) == 0 {
+		return
+	}
+
+	if err := bus.Publish(ctx, projectID, events...); err != nil {
+		logger.Get().Errorw("publish inbox events", "error", err, "project_id", projectID, "count", len(events))
+	}
+}
+
+// inboxDeletedEvents is one deleted event per recipient for notifications
+// leaving their feeds together, grouped by project. Order follows the input.
+func inboxDeletedEvents(notifications []*entity.Notification) map[int][]*dto.InboxEvent {
+	type feed struct {
+		projectID      int
+		recipientExtID string
+	}
+
+	events := map[feed]*dto.InboxEvent{}
+	byProject := map[int][]*dto.InboxEvent{}
+	for _, n := range notifications {
+		key := feed{n.ProjectID, n.RecipientExtID}
+		if event, ok := events[key]; ok {
+			event.IDs = append(event.IDs, n.ID)
+			continue
+		}
+		event := dto.NewInboxDeletedEvent(n.RecipientExtID, []int{n.ID})
+		events[key] = event
+		byProject[n.ProjectID] = append(byProject[n.ProjectID], event)
+	}
+
+	return byProject
+}
+
+// PublishInboxCreated announces the notifications that landed in a feed. Only
+// delivered rows are announced — anything else is not in the feed, see
+// recipientFeedVisible — so callers can pass a whole batch as written.
+func (s *NotificationService) PublishInboxCreated(ctx context.Context, notifications []*entity.Notification) {
+	byProject := map[int][]*dto.InboxEvent{}
+	for _, n := range notifications {
+		if n.Status != enum.NotificationStatusDelivered {
+			continue
+		}
+		byProject[n.ProjectID] = append(byProject[n.ProjectID], &dto.InboxEvent{
+			Type:           dto.InboxEventCreated,
+			RecipientExtID: n.RecipientExtID,
+			Notification:   dto.FromNotification(n),
+		})
+	}
+
+	for projectID, events := range byProject {
+		s.publishInbox(ctx, projectID, events...)
+	}
+}
+
+// InboxExpiryPage is how many notifications PublishInboxExpired and
+// PublishInboxWoken read per round trip.
+const InboxExpiryPage = 1000
+
+// PublishInboxExpired announces the notifications whose expires_at fell in
+// (from, to] as deleted. Expiry is read against now() and writes nothing, so
+// without this a stream would go on showing a notification the feed no longer
+// has. Returns how many it announced; an error stops it, and the caller's next
+// window does not revisit this one.
+func (s *NotificationService) PublishInboxExpired(ctx context.Context, from, to time.Time) (int, error) {
+	var total, afterID int
+	for {
+		expired, err := s.repo.ListExpiredBetween(ctx, from, to, afterID, InboxExpiryPage)
+		if err != nil {
+			return total, fmt.Errorf("list expired notifications: %w", err)
+		}
+
+		for projectID, events := range inboxDeletedEvents(expired) {
+			s.publishInbox(ctx, projectID, events...)
+		}
+
+		total += len(expired)
+		if len(expired) < InboxExpiryPage {
+			return total, nil
+		}
+		afterID = expired[len(expired)-1].ID
+	}
+}
+
+// PublishInboxWoken announces the notifications whose snooze ended in
+// (from, to] as created: each is back in its feed, unread and on top, and like
+// expiry that is read against now() with nothing written. A stream follows the
+// event with the fresh unread count, as it does every event. Returns how many it
+// announced; an error stops it, as for PublishInboxExpired.
+func (s *NotificationService) PublishInboxWoken(ctx context.Context, from, to time.Time) (int, error) {
+	var total, afterID int
+	for {
+		woken, err := s.repo.ListWokenBetween(ctx, from, to, afterID, InboxExpiryPage)
+		if err != nil {
+			return total, fmt.Errorf("list woken notifications: %w", err)
+		}
+
+		s.PublishInboxCreated(ctx, woken)
+
+		total += len(woken)
+		if len(woken) < InboxExpiryPage {
+			return total, nil
+		}
+		afterID = woken[len(woken)-1].ID
+	}
+}
+
+// SubscribeForRecipient opens the live half of a recipient's stream. The
+// channel closes when ctx ends.
+func (s *NotificationService) SubscribeForRecipient(ctx context.Context, projectID int, recipientExtID string) (<-chan *dto.InboxEvent, service.Error, error) {
+	if recipientExtID == "" {
+		return nil, service.ErrInvalidInput, fmt.Errorf("recipient id required")
+	}
+	if s.inboxEvents == nil {
+		return nil, service.ErrInternalServerError, fmt.Errorf("inbox stream is not configured")
+	}
+
+	events, err := s.inboxEvents.Subscribe(ctx, projectID, recipientExtID)
+	if err != nil {
+		return nil, service.ErrInternalServerError, err
+	}
+
+	return events, service.ErrNone, nil
+}
+
+// ListenForRecipients opens one listener for any number of the project's
+// recipients, for a connection following several feeds. It stops when ctx
+// ends. Authorizing each recipient before following it is the caller's job.
+func (s *NotificationService) ListenForRecipients(ctx context.Context, projectID int) (repository.InboxListener, service.Error, error) {
+	if s.inboxEvents == nil {
+		return nil, service.ErrInternalServerError, fmt.Errorf("inbox stream is not configured")
+	}
+
+	return s.inboxEvents.Listen(ctx, projectID), service.ErrNone, nil
+}
+
+// ReplayForRecipient returns the feed's notifications newer than afterID,
+// oldest first, for a stream resuming from Last-Event-ID. It reports whether
+// there were more than InboxReplayLimit, in which case only the newest are
+// returned and the client should reload the feed.
+func (s *NotificationService) ReplayForRecipient(ctx context.Context, projectID int, recipientExtID string, afterID int) ([]*dto.Notification, bool, service.Error, error) {
+	if recipientExtID == "" {
+		return nil, false, service.ErrInvalidInput, fmt.Errorf("recipient id required")
+	}
+
+	after := strconv.Itoa(afterID)
+	limit := InboxReplayLimit
+	notifs, returnedCursor, err := s.repo.ListForRecipient(ctx, projectID, recipientExtID, nil, &query.Cursor{After: &after, Limit: &limit})
+	if err != nil {
+		return nil, false, service.ErrInternalServerError, err
+	}
+
+	// The feed reads newest first; a replay is played in the order it happened.
+	slices.Reverse(notifs)
+
+	truncated := returnedCursor != nil && returnedCursor.Before != nil
+	return dto.FromNotifications(notifs), truncated, service.ErrNone, nil
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/tantra/query"
+	"github.com/mudgallabs/tantra/service"
+)
+
+// fakeInboxEvents records what was published, by project.
+type fakeInboxEvents struct {
+	repository.InboxEventRepository
+	published map[int][]*dto.InboxEvent
+}
+
+func (f *fakeInboxEvents) Publish(ctx context.Context, projectID int, events ...*dto.InboxEvent) error {
+	if f.published == nil {
+		f.published = map[int][]*dto.InboxEvent{}
+	}
+	f.published[projectID] = append(f.published[projectID], events...)
+	return nil
+}
+
+// feedRepo answers the recipient-scoped calls a stream triggers.
+type feedRepo struct {
+	repository.NotificationRepository
+	affected int
+	feed     []*entity.Notification // newest first, as the feed reads
+	hasMore  bool
+	after    string
+	expired  []*entity.Notification // in id order; also a broadcast's rows, and the woken
+}
+
+func (r *feedRepo) ListForBroadcast(ctx context.Context, broadcastID int, status enum.NotificationStatus, afterID, limit int) ([]*entity.Notification, error) {
+	return r.ListExpiredBetween(ctx, time.Time{}, time.Time{}, afterID, limit)
+}
+
+func (r *feedRepo) ListExpiredBetween(ctx context.Context, from, to time.Time, afterID, limit int) ([]*entity.Notification, error) {
+	page := []*entity.Notification{}
+	for _, n := range r.expired {
+		if n.ID > afterID && len(page) < limit {
+			page = append(page, n)
+		}
+	}
+	return page, nil
+}
+
+func (r *feedRepo) ListWokenBetween(ctx context.Context, from, to time.Time, afterID, limit int) ([]*entity.Notification, error) {
+	return r.ListExpiredBetween(ctx, from, to, afterID, limit)
+}
+
+func (r *feedRepo) DeleteForRecipient(ctx context.Context, projectID int, recipientExtID string, notificationIDs []int) (int, error) {
+	return r.affected, nil
+}
+
+// UpdateForRecipient changes the first `affected` of the ids named, or that
+// many made-up ones for an update that names none.
+func (r *feedRepo) UpdateForRecipient(ctx context.Context, projectID int, recipientExtID string, payload dto.UpdateRecipientNotificationsPayload) ([]int, error) {
+	if len(payload.IDs) > 0 {
+		return payload.IDs[:min(r.affected, len(payload.IDs))], nil
+	}
+	updated := make([]int, r.affected)
+	for i := range updated {
+		updated[i] = 100 + i
+	}
+	return updated, nil
+}
+
+func (r *feedRepo) ListForRecipient(ctx context.Context, projectID int, recipientExtID string, filters *dto.RecipientFeedFilters, cursor *query.Cursor) ([]*entity.Notification, *query.Cursor, error) {
+	r.after = *cursor.After
+	returned := &query.Cursor{}
+	if r.hasMore {
+		before := "1"
+		returned.Before = &before
+	}
+	return r.feed, returned, nil
+}
+
+func streamService(repo repository.NotificationRepository, events repository.InboxEventRepository) *NotificationService {
+	return NewNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, events, nil, nil, nil)
+}
+
+// TestPublishInboxCreatedOnlyAnnouncesTheFeed — a broadcast batch is passed as
+// written, muted rows and all, and only what the recipient can see goes out.
+func TestPublishInboxCreatedOnlyAnnouncesTheFeed(t *testing.T) {
+	events := &fakeInboxEvents{}
+	svc := streamService(nil, events)
+
+	svc.PublishInboxCreated(context.Background(), []*entity.Notification{
+		{ID: 1, ProjectID: 1, RecipientExtID: "u1", Status: enum.NotificationStatusDelivered},
+		{ID: 2, ProjectID: 1, RecipientExtID: "u2", Status: enum.NotificationStatusMuted},
+		{ID: 3, ProjectID: 2, RecipientExtID: "u3", Status: enum.NotificationStatusDelivered},
+	})
+
+	if got := events.published[1]; len(got) != 1 || got[0].RecipientExtID != "u1" || got[0].Notification.ID != 1 {
+		t.Errorf("project 1 published %+v, want only notification 1 to u1", got)
+	}
+	if got := events.published[2]; len(got) != 1 || got[0].Type != dto.InboxEventCreated {
+		t.Errorf("project 2 published %+v, want one created event", got)
+	}
+}
+
+// TestDeleteForRecipientPublishesOnlyWhatChanged — no event for a delete that
+// matched nothing, and a delete with no ids says it took everything.
+func TestDeleteForRecipientPublishesOnlyWhatChanged(t *testing.T) {
+	events := &fakeInboxEvents{}
+	repo := &feedRepo{}
+	svc := streamService(repo, events)
+
+	if _, _, err := svc.DeleteForRecipient(context.Background(), 1, "u1", []int{9}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if len(events.published) != 0 {
+		t.Fatalf("a delete of nothing published %+v", events.published)
+	}
+
+	repo.affected = 3
+	if _, _, err := svc.DeleteForRecipient(context.Background(), 1, "u1", nil); err != nil {
+		t.Fatalf("delete all: %v", err)
+	}
+	if got := events.published[1]; len(got) != 1 || got[0].Type != dto.InboxEventDeleted || !got[0].All {
+		t.Errorf("published %+v, want one deleted event for all", got)
+	}
+}
+
+// TestUpdateForRecipientCarriesTheState — a stream can apply a mark-read
+// without refetching.
+func TestUpdateForRecipientCarriesTheState(t *testing.T) {
+	events := &fakeInboxEvents{}
+	svc := streamService(&feedRepo{affected: 2}, events)
+
+	read := true
+	payload := dto.UpdateRecipientNotificationsPayload{
+		NotificationIDsPayload: dto.NotificationIDsPayload{IDs: []int{4, 5}},
+		State:                  dto.NotificationStateFilter{Read: &read},
+	}
+	if _, _, err := svc.UpdateForRecipient(context.Background(), 1, "u1", payload); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	got := events.published[1]
+	if len(got) != 1 || got[0].All || len(got[0].IDs) != 2 || got[0].Read == nil || !*got[0].Read || got[0].Opened != nil {
+		t.Errorf("published %+v, want read=true on ids 4 and 5", got)
+	}
+}
+
+// TestReplayForRecipientIsOldestFirst — the feed reads newest first, a replay
+// is played in the order things happened, and overflow is reported.
+func TestReplayForRecipientIsOldestFirst(t *testing.T) {
+	repo := &feedRepo{
+		feed:    []*entity.Notification{{ID: 12}, {ID: 11}},
+		hasMore: true,
+	}
+	svc := streamService(repo, nil)
+
+	replay, truncated, _, err := svc.ReplayForRecipient(context.Background(), 1, "u1", 10)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+
+	if repo.after != "10" {
+		t.Errorf("replayed after %q, want the Last-Event-ID", repo.after)
+	}
+	if len(replay) != 2 || replay[0].ID != 11 || replay[1].ID != 12 {
+		t.Errorf("replay = %v, want 11 then 12", replay)
+	}
+	if !truncated {
+		t.Error("a replay with more than the limit behind it should say so")
+	}
+}
+
+// TestUpdateForRecipientCarriesArchiveAndSnooze — a stream can drop archived and
+// snoozed notifications from the inbox it shows, and a contradictory update
+// reaches neither the database nor the stream.
+func TestUpdateForRecipientCarriesArchiveAndSnooze(t *testing.T) {
+	events := &fakeInboxEvents{}
+	svc := streamService(&feedRepo{affected: 1}, events)
+
+	archived, read := true, true
+	later := time.Now().Add(time.Hour)
+	payload := dto.UpdateRecipientNotificationsPayload{
+		NotificationIDsPayload: dto.NotificationIDsPayload{IDs: []int{4}},
+		Archived:               &archived,
+		SnoozeUntil:            &later,
+	}
+	if _, _, err := svc.UpdateForRecipient(context.Background(), 1, "u1", payload); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	got := events.published[1]
+	if len(got) != 1 || got[0].Archived == nil || !*got[0].Archived || got[0].SnoozedUntil == nil || !got[0].SnoozedUntil.Equal(later) {
+		t.Errorf("published %+v, want archived and snoozed until %v", got, later)
+	}
+
+	payload.State.Read = &read
+	if _, kind, err := svc.UpdateForRecipient(context.Background(), 1, "u1", payload); err == nil || kind != service.ErrInvalidInput {
+		t.Errorf("a snooze marked read = (%v, %v), want invalid input", kind, err)
+	}
+	if len(events.published[1]) != 1 {
+		t.Errorf("a refused update published %+v", events.published[1][1:])
+	}
+}
+
+// TestUpdateByFilterNamesWhatChanged — an update by filter names no ids, so its
+// event lists the ids the update reported rather than claiming the whole feed.
+func TestUpdateByFilterNamesWhatChanged(t *testing.T) {
+	events := &fakeInboxEvents{}
+	svc := streamService(&feedRepo{affected: 2}, events)
+
+	seen, billing := true, "billing"
+	payload := dto.UpdateRecipientNotificationsPayload{
+		State:   dto.NotificationStateFilter{Seen: &seen},
+		Filters: &dto.RecipientFeedFilters{Channel: &billing},
+	}
+	if _, _, err := svc.UpdateForRecipient(context.Background(), 1, "u1", payload); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	got := events.published[1]
+	if len(got) != 1 || got[0].All || len(got[0].IDs) != 2 || got[0].Seen == nil || !*got[0].Seen {
+		t.Errorf("published %+v, want seen=true on the 2 ids changed", got)
+	}
+}
+
+// TestPublishInboxExpiredAnnouncesEachOnce — expiry is paged, and every
+// expired notification reaches its own recipient's stream exactly once, grouped
+// into one deleted event per recipient per page.
+func TestPublishInboxExpiredAnnouncesEachOnce(t *testing.T) {
+	repo := &feedRepo{}
+	for id := 1; id <= InboxExpiryPage+2; id++ {
+		n := &entity.Notification{ID: id, ProjectID: 1, RecipientExtID: "u1"}
+		if id%2 == 0 {
+			n.RecipientExtID = "u2"
+		}
+		if id == InboxExpiryPage+2 {
+			n.ProjectID = 2
+		}
+		repo.expired = append(repo.expired, n)
+	}
+	events := &fakeInboxEvents{}
+	svc := streamService(repo, events)
+
+	announced, err := svc.PublishInboxExpired(context.Background(), time.Now().Add(-time.Minute), time.Now())
+	if err != nil {
+		t.Fatalf("publish expired: %v", err)
+	}
+	if announced != len(repo.expired) {
+		t.Errorf("announced %d, want %d", announced, len(repo.expired))
+	}
+
+	seen := map[int]int{}
+	for projectID, published := range events.published {
+		if projectID == 1 && len(published) != 3 {
+			t.Errorf("project 1 got %d events, want one per recipient per page (3)", len(published))
+		}
+		for _, e := range published {
+			if e.Type != dto.InboxEventDeleted || e.All {
+				t.Fatalf("published %+v, want a deleted event naming ids", e)
+			}
+			for _, id := range e.IDs {
+				n := repo.expired[id-1]
+				if n.ProjectID != projectID || n.RecipientExtID != e.RecipientExtID {
+					t.Errorf("notification %d announced to %d/%s", id, projectID, e.RecipientExtID)
+				}
+				seen[id]++
+			}
+		}
+	}
+	for _, n := range repo.expired {
+		if seen[n.ID] != 1 {
+			t.Errorf("notification %d announced %d times", n.ID, seen[n.ID])
+		}
+	}
+}
+
+// TestPublishInboxWokenAnnouncesEachAsCreated — a snooze ending is a
+// notification landing in the feed again, so each woken one reaches its
+// recipient's stream as created, with the notification, across pages.
+func TestPublishInboxWokenAnnouncesEachAsCreated(t *testing.T) {
+	repo := &feedRepo{}
+	for id := 1; id <= InboxExpiryPage+1; id++ {
+		repo.expired = append(repo.expired, &entity.Notification{
+			ID: id, ProjectID: 1, RecipientExtID: fmt.Sprintf("u%d", id%3), Status: enum.NotificationStatusDelivered,
+		})
+	}
+	events := &fakeInboxEvents{}
+	svc := streamService(repo, events)
+
+	announced, err := svc.PublishInboxWoken(context.Background(), time.Now().Add(-time.Minute), time.Now())
+	if err != nil {
+		t.Fatalf("publish woken: %v", err)
+	}
+	if announced != len(repo.expired) || len(events.published[1]) != len(repo.expired) {
+		t.Fatalf("announced %d in %d events, want %d of each", announced, len(events.published[1]), len(repo.expired))
+	}
+	for i, e := range events.published[1] {
+		n := repo.expired[i]
+		if e.Type != dto.InboxEventCreated || e.Notification == nil || e.Notification.ID != n.ID || e.RecipientExtID != n.RecipientExtID {
+			t.Fatalf("event %d = %+v, want created for notification %d to %s", i, e, n.ID, n.RecipientExtID)
+		}
+	}
+}
+
+// TestPublishBroadcastInboxReachesEveryRecipient — a recall is announced to
+// every recipient of the broadcast across pages, and an edit carries each
+// recipient's own notification with the new payload.
+func TestPublishBroadcastInboxReachesEveryRecipient(t *testing.T) {
+	repo := &feedRepo{}
+	for id := 1; id <= broadcastInboxPage+1; id++ {
+		repo.expired = append(repo.expired, &entity.Notification{ID: id, ProjectID: 1, RecipientExtID: fmt.Sprintf("u%d", id)})
+	}
+
+	recalled := &fakeInboxEvents{}
+	svc := NewBroadcastService(nil, nil, nil, repo, nil, nil, recalled)
+	svc.publishBroadcastInbox(context.Background(), 1, enum.NotificationStatusRecalled, inboxDeletedEvents)
+
+	if got := recalled.published[1]; len(got) != len(repo.expired) {
+		t.Fatalf("recall published %d events, want one per recipient (%d)", len(got), len(repo.expired))
+	}
+	for i, e := range recalled.published[1] {
+		if e.Type != dto.InboxEventDeleted || e.RecipientExtID != repo.expired[i].RecipientExtID || len(e.IDs) != 1 || e.IDs[0] != i+1 {
+			t.Fatalf("recall event %d = %+v", i, e)
+		}
+	}
+
+	patched := &fakeInboxEvents{}
+	svc = NewBroadcastService(nil, nil, nil, repo, nil, nil, patched)
+	svc.publishBroadcastInbox(context.Background(), 1, enum.NotificationStatusDelivered, inboxPatchedEvents)
+
+	last := patched.published[1][len(patched.published[1])-1]
+	if last.Type != dto.InboxEventUpdated || last.Notification == nil || last.Notification.ID != broadcastInboxPage+1 {
+		t.Errorf("last edit event = %+v, want the updated notification on the second page", last)
+	}
+}
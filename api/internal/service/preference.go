@@ -10,6 +10,7 @@ import (
 	"github.com/mudgallabs/bodhveda/internal/model/entity"
 	"github.com/mudgallabs/bodhveda/internal/model/enum"
 	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/tantra/apires"
 	tantraRepo "github.com/mudgallabs/tantra/repository"
 	"github.com/mudgallabs/tantra/service"
 )
@@ -46,6 +47,9 @@ func (s *PreferenceService) CreateProjectPreference(ctx context.Context, payload
 	// ever legal on a catalog row, and the two recipient-level call sites should
 	// have no way to pass it. A CHECK constraint backs this up.
 	pref.Mandatory = payload.Mandatory
+	pref.Digest = payload.DigestPtr()
+	pref.BypassQuietHours = payload.BypassQuietHours
+	setFrequencyCap(pref, payload.FrequencyCap.Entity())
 
 	newPref, err := s.repo.Create(ctx, pref)
 	if err != nil {
@@ -111,6 +115,9 @@ func (s *PreferenceService) UpsertProjectPreferences(ctx context.Context, projec
 			items[i].Enabled,
 		)
 		pref.Mandatory = items[i].Mandatory
+		pref.Digest = items[i].DigestPtr()
+		pref.BypassQuietHours = items[i].BypassQuietHours
+		setFrequencyCap(pref, items[i].FrequencyCap.Entity())
 
 		prefs = append(prefs, pref)
 	}
@@ -151,7 +158,31 @@ func (s *PreferenceService) UpdateProjectPreference(ctx context.Context, project
 		return nil, service.ErrInvalidInput, err
 	}
 
-	pref, err := s.repo.UpdateProjectPreference(ctx, projectID, preferenceID, payload.Name, payload.DescriptionPtr(), payload.Enabled, payload.Mandatory)
+	digestOn := payload.Digest != nil && *payload.Digest != enum.DigestOff
+	bypassOn := payload.BypassQuietHours != nil && *payload.BypassQuietHours
+	if digestOn || bypassOn {
+		// The CHECKs would refuse either on a non-email entry anyway; reading the
+		// row first turns that into a 400 naming the field instead of a 500.
+		existing, err := s.repo.GetProjectPreferenceByID(ctx, projectID, preferenceID)
+		if err != nil {
+			if err == tantraRepo.ErrNotFound {
+				return nil, service.ErrNotFound, fmt.Errorf("Preference not found")
+			}
+			return nil, service.ErrInternalServerError, fmt.Errorf("repo get project preference: %w", err)
+		}
+		if existing.Medium != string(enum.MediumEmail) {
+			var errs service.InputValidationErrors
+			if digestOn {
+				errs.Add(apires.NewApiError("Invalid digest", "Only email catalog entries can be digested", "digest", string(*payload.Digest)))
+			}
+			if bypassOn {
+				errs.Add(apires.NewApiError("Invalid quiet hours bypass", "Only email catalog entries can bypass quiet hours", "bypass_quiet_hours", true))
+			}
+			return nil, service.ErrInvalidInput, errs
+		}
+	}
+
+	pref, err := s.repo.UpdateProjectPreference(ctx, projectID, preferenceID, payload.Name, payload.DescriptionPtr(), payload.Enabled, payload.Mandatory, payload.Digest, payload.BypassQuietHours, payload.FrequencyCap.Entity())
 	if err != nil {
 		if err == tantraRepo.ErrNotFound {
 			return nil, service.ErrNotFound, fmt.Errorf("Preference not found")
@@ -189,7 +220,7 @@ func (s *PreferenceService) ListProjectPreferences(ctx context.Context, projectI
 
 		// Subscriber count is per (target, medium) — count recipients opted in to
 		// this catalog entry's own medium.
-		recipients, err := s.repo.ListEligibleRecipientExtIDsForBroadcast(ctx, projectID, target, enum.Medium(pref.Medium))
+		recipients, err := s.repo.ListEligibleRecipientExtIDsForBroadcast(ctx, projectID, target, enum.Medium(pref.Medium), nil)
 		if err != nil {
 			return nil, service.ErrInternalServerError, fmt.Errorf("repo list eligible recipients: %w", err)
 		}
@@ -468,3 +499,13 @@ func (s *PreferenceService) Delete(ctx context.Context, payload *dto.DeletePrefe
 
 	return service.ErrNone, nil
 }
+
+// setFrequencyCap stores c on a catalog row being written; nil leaves it
+// uncapped.
+func setFrequencyCap(pref *entity.Preference, c *entity.FrequencyCap) {
+	if c == nil {
+		return
+	}
+	pref.FrequencyCapLimit = &c.Limit
+	pref.FrequencyCapWindow = &c.Window
+}
@@ -73,7 +73,7 @@ func (s *ProjectService) Update(ctx context.Context, payload dto.UpdateProjectPa
 		return nil, service.ErrInvalidInput, err
 	}
 
-	project, err := s.repo.Update(ctx, payload.UserID, payload.ProjectID, payload.Name, payload.StrictTargets)
+	project, err := s.repo.Update(ctx, payload.UserID, payload.ProjectID, payload.Name, payload.StrictTargets, payload.RequireRecipientToken)
 	if err != nil {
 		if err == tantraRepo.ErrNotFound {
 			return nil, service.ErrNotFound, nil
@@ -133,8 +133,10 @@ func (s *ProjectService) Delete(ctx context.Context, userID, projectID int) (ser
 		return service.ErrInternalServerError, fmt.Errorf("marshal delete project data payload: %w", err)
 	}
 
+	// Cleanup nobody is waiting on, so it runs in the bulk lane.
+	queue := asynq.Queue(task.QueueBulk)
 	task := asynq.NewTask(task.TaskTypeDeleteProjectData, payload)
-	_, err = s.asynqClient.Enqueue(task, asynq.MaxRetry(3))
+	_, err = s.asynqClient.Enqueue(task, asynq.MaxRetry(3), queue)
 	if err != nil {
 		return service.ErrInternalServerError, fmt.Errorf("enqueue delete project data task: %w", err)
 	}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/mudgallabs/bodhveda/internal/model/dto"
@@ -81,6 +82,12 @@ func (s *ProjectEmailSettingsService) Upsert(ctx context.Context, payload *dto.U
 	settings.Provider = enum.EmailProvider(payload.Provider)
 	settings.FromName = payload.FromName
 	settings.FromAddress = payload.FromAddress
+	if payload.DigestSubject != nil {
+		settings.DigestSubject = blankToNil(*payload.DigestSubject)
+	}
+	if payload.DigestHTML != nil {
+		settings.DigestHTML = blankToNil(*payload.DigestHTML)
+	}
 	settings.UpdatedAt = time.Now().UTC()
 
 	if payload.Secret != "" {
@@ -132,7 +139,18 @@ func (s *ProjectEmailSettingsService) toMaskedDTO(settings *entity.ProjectEmailS
 		SecretMasked:        dto.MaskSecret(plain),
 		WebhookSecretMasked: webhookMasked,
 		WebhookSecretSet:    settings.HasWebhookSecret(),
+		DigestSubject:       settings.DigestSubject,
+		DigestHTML:          settings.DigestHTML,
 		CreatedAt:           settings.CreatedAt,
 		UpdatedAt:           settings.UpdatedAt,
 	}, nil
 }
+
+// blankToNil maps a whitespace-only value to nil, which is how an optional
+// setting is cleared back to its default.
+func blankToNil(v string) *string {
+	if strings.TrimSpace(v) == "" {
+		return nil
+	}
+	return &v
+}
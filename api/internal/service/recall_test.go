@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	tantraRepo "github.com/mudgallabs/tantra/repository"
+	"github.com/mudgallabs/tantra/service"
+)
+
+// recallRepo is scheduleRepo plus Recall, applying the same predicate the SQL
+// does: anything but recalled or cancelled can be recalled.
+type recallRepo struct {
+	scheduleRepo
+	missing bool
+}
+
+func (r *recallRepo) Recall(ctx context.Context, projectID, id int) (*entity.Notification, error) {
+	if r.missing {
+		return nil, tantraRepo.ErrNotFound
+	}
+	if r.status == enum.NotificationStatusRecalled || r.status == enum.NotificationStatusCancelled {
+		return nil, tantraRepo.ErrConflict
+	}
+	r.status = enum.NotificationStatusRecalled
+	return &entity.Notification{ID: id, ProjectID: projectID, Status: r.status}, nil
+}
+
+func TestRecallDeliveredNotification(t *testing.T) {
+	repo := &recallRepo{scheduleRepo: scheduleRepo{status: enum.NotificationStatusDelivered}}
+	svc := NewNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	got, errKind, err := svc.Recall(context.Background(), 1, 7)
+	if err != nil {
+		t.Fatalf("recall: %v (kind %v)", err, errKind)
+	}
+	if got.Status != enum.NotificationStatusRecalled {
+		t.Errorf("status = %q, want recalled", got.Status)
+	}
+}
+
+// A second recall, or recalling a send that was cancelled before it went out,
+// asks for something that cannot happen, and the caller must hear so.
+func TestRecallConflicts(t *testing.T) {
+	for _, status := range []enum.NotificationStatus{enum.NotificationStatusRecalled, enum.NotificationStatusCancelled} {
+		repo := &recallRepo{scheduleRepo: scheduleRepo{status: status}}
+		svc := NewNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+		_, errKind, err := svc.Recall(context.Background(), 1, 7)
+		if err == nil || errKind != service.ErrConflict {
+			t.Errorf("recall of %q: got (%v, %v), want a conflict", status, errKind, err)
+		}
+	}
+}
+
+func TestRecallNotFound(t *testing.T) {
+	repo := &recallRepo{missing: true}
+	svc := NewNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	_, errKind, err := svc.Recall(context.Background(), 1, 7)
+	if err == nil || errKind != service.ErrNotFound {
+		t.Fatalf("recall of a missing notification: got (%v, %v), want not found", errKind, err)
+	}
+}
+
+// TestRecalledScheduledSendDoesNothing — a scheduled send recalled before its
+// send_at is stopped by the row, exactly like a cancelled one.
+func TestRecalledScheduledSendDoesNothing(t *testing.T) {
+	repo := &recallRepo{scheduleRepo: scheduleRepo{status: enum.NotificationStatusScheduled}}
+	svc := NewNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	if _, errKind, err := svc.Recall(context.Background(), 1, 7); err != nil {
+		t.Fatalf("recall: %v (kind %v)", err, errKind)
+	}
+
+	if err := svc.DeliverDirectNotification(context.Background(), scheduledTask(json.RawMessage(`{"title":"hi"}`))); err != nil {
+		t.Fatalf("deliver after recall: %v", err)
+	}
+	if repo.status != enum.NotificationStatusRecalled {
+		t.Errorf("status = %q, want recalled to stick", repo.status)
+	}
+}
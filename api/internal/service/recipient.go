@@ -8,10 +8,12 @@ import (
 	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/mudgallabs/bodhveda/internal/env"
 	"github.com/mudgallabs/bodhveda/internal/job/task"
 	"github.com/mudgallabs/bodhveda/internal/model/dto"
 	"github.com/mudgallabs/bodhveda/internal/model/entity"
 	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	"github.com/mudgallabs/bodhveda/internal/recipienttoken"
 	tantraRepo "github.com/mudgallabs/tantra/repository"
 	"github.com/mudgallabs/tantra/service"
 )
@@ -19,12 +21,14 @@ import (
 type RecipientService struct {
 	repo        repository.RecipientRepository
 	asynqClient *asynq.Client
+	hashKey     []byte
 }
 
 func NewRecipientService(repo repository.RecipientRepository, asynqClient *asynq.Client) *RecipientService {
 	return &RecipientService{
 		repo:        repo,
 		asynqClient: asynqClient,
+		hashKey:     []byte(env.HashKey),
 	}
 }
 
@@ -40,6 +44,7 @@ func (s *RecipientService) Create(ctx context.Context, payload dto.CreateRecipie
 	}
 
 	recipient := entity.NewRecipient(payload.ProjectID, payload.ExternalID, name)
+	payload.ApplySettings(recipient)
 	recipient, err = s.repo.Create(ctx, recipient)
 	if err != nil {
 		if err == tantraRepo.ErrConflict {
@@ -63,6 +68,7 @@ func (s *RecipientService) CreateIfNotExists(ctx context.Context, payload dto.Cr
 	}
 
 	recipient := entity.NewRecipient(payload.ProjectID, payload.ExternalID, name)
+	payload.ApplySettings(recipient)
 	recipient, err = s.repo.Create(ctx, recipient)
 	if err != nil {
 		// If recipient already exists, fetch and return it.
@@ -78,7 +84,11 @@ func (s *RecipientService) CreateIfNotExists(ctx context.Context, payload dto.Cr
 }
 
 func (s *RecipientService) List(ctx context.Context, payload *dto.ListRecipientsPayload) (*dto.ListRecipientsResult, service.Error, error) {
-	recipients, total, err := s.repo.List(ctx, payload.ProjectID, payload.Pagination)
+	if err := payload.Validate(); err != nil {
+		return nil, service.ErrInvalidInput, err
+	}
+
+	recipients, total, err := s.repo.List(ctx, payload)
 	if err != nil {
 		return nil, service.ErrInternalServerError, fmt.Errorf("recipient repo list: %w", err)
 	}
@@ -170,8 +180,10 @@ func (s *RecipientService) Delete(ctx context.Context, projectID int, externalID
 		return service.ErrInternalServerError, fmt.Errorf("marshal delete recipient data payload: %w", err)
 	}
 
+	// Cleanup nobody is waiting on, so it runs in the bulk lane.
+	queue := asynq.Queue(task.QueueBulk)
 	task := asynq.NewTask(task.TaskTypeDeleteRecipientData, payload)
-	_, err = s.asynqClient.Enqueue(task, asynq.MaxRetry(3))
+	_, err = s.asynqClient.Enqueue(task, asynq.MaxRetry(3), queue)
 	if err != nil {
 		return service.ErrInternalServerError, fmt.Errorf("enqueue delete recipient data task: %w", err)
 	}
@@ -208,7 +220,9 @@ func (s *RecipientService) BatchCreate(ctx context.Context, payloads []dto.Creat
 		if p.Name != nil {
 			name = *p.Name
 		}
-		recipients = append(recipients, entity.NewRecipient(p.ProjectID, p.ExternalID, name))
+		recipient := entity.NewRecipient(p.ProjectID, p.ExternalID, name)
+		p.ApplySettings(recipient)
+		recipients = append(recipients, recipient)
 	}
 
 	createdIDs, updatedIDs, err := s.repo.BatchCreate(ctx, recipients)
@@ -236,6 +250,30 @@ func (s *RecipientService) BatchCreate(ctx context.Context, payloads []dto.Creat
 	return result, service.ErrNone, nil
 }
 
+// IssueToken mints a recipient-bound token for one recipient. It is reachable
+// only with a full-scope key — the customer's server — and the token is what
+// that server hands to the recipient's client so the client can prove, on the
+// recipient sub-routes, which recipient it is. See package recipienttoken.
+//
+// No DB write: the token is self-contained, and the route has already made sure
+// the recipient exists (CreateRecipientIfNotExists).
+func (s *RecipientService) IssueToken(ctx context.Context, payload dto.IssueRecipientTokenPayload) (*dto.RecipientToken, service.Error, error) {
+	if err := payload.Validate(); err != nil {
+		return nil, service.ErrInvalidInput, err
+	}
+
+	token, expiresAt, err := recipienttoken.Build(payload.ProjectID, payload.RecipientExtID, payload.TTL(), s.hashKey)
+	if err != nil {
+		return nil, service.ErrInternalServerError, fmt.Errorf("build recipient token: %w", err)
+	}
+
+	return &dto.RecipientToken{
+		Token:          token,
+		RecipientExtID: payload.RecipientExtID,
+		ExpiresAt:      expiresAt,
+	}, service.ErrNone, nil
+}
+
 func (s *RecipientService) CreateRandomRecipients(ctx context.Context, projectID int, count int) error {
 	names := []string{
 		"Alice Johnson", "Bob Smith", "Charlie Brown", "Diana Prince", "Edward Norton",
@@ -14,12 +14,14 @@ import (
 )
 
 // fakeRecipientRepo is a minimal in-memory RecipientRepository covering only
-// the methods exercised by RecipientService.CreateIfNotExists and Get. Other
-// methods panic; add implementations when a new test needs them.
+// the methods exercised by RecipientService.CreateIfNotExists, Get and List.
+// Other methods panic; add implementations when a new test needs them.
 type fakeRecipientRepo struct {
 	// keyed by project_id + "|" + lowercased external_id
 	store map[string]*entity.Recipient
 	next  int
+	// listed is the last filter List was called with.
+	listed *dto.ListRecipientsPayload
 }
 
 func newFakeRecipientRepo() *fakeRecipientRepo {
@@ -50,10 +52,12 @@ func (f *fakeRecipientRepo) Get(_ context.Context, projectID int, externalID str
 	return r, nil
 }
 
-// unused in these tests — panic so we catch accidental coverage gaps.
-func (f *fakeRecipientRepo) List(context.Context, int, query.Pagination) ([]*entity.RecipientListItem, int, error) {
-	panic("not implemented")
+func (f *fakeRecipientRepo) List(_ context.Context, payload *dto.ListRecipientsPayload) ([]*entity.RecipientListItem, int, error) {
+	f.listed = payload
+	return []*entity.RecipientListItem{}, 0, nil
 }
+
+// unused in these tests — panic so we catch accidental coverage gaps.
 func (f *fakeRecipientRepo) GetListItem(context.Context, int, string) (*entity.RecipientListItem, error) {
 	panic("not implemented")
 }
@@ -63,6 +67,15 @@ func (f *fakeRecipientRepo) Exists(context.Context, int, string) (bool, error) {
 func (f *fakeRecipientRepo) TotalCount(context.Context, int) (int, error) {
 	panic("not implemented")
 }
+func (f *fakeRecipientRepo) Locales(context.Context, int, []string) (map[string]string, error) {
+	panic("not implemented")
+}
+func (f *fakeRecipientRepo) Missing(context.Context, int, []string) ([]string, error) {
+	panic("not implemented")
+}
+func (f *fakeRecipientRepo) CreateMissing(context.Context, int, []string) ([]string, error) {
+	panic("not implemented")
+}
 func (f *fakeRecipientRepo) BatchCreate(context.Context, []*entity.Recipient) ([]string, []string, error) {
 	panic("not implemented")
 }
@@ -158,3 +171,40 @@ func TestGet_FindsRecipientRegardlessOfInputCase(t *testing.T) {
 		t.Fatalf("Get returned wrong recipient: %q", got.ExternalID)
 	}
 }
+
+func TestList_NormalizesFilters(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeRecipientRepo()
+	svc := newTestRecipientService(repo)
+
+	payload := &dto.ListRecipientsPayload{
+		ProjectID:  1,
+		Pagination: query.Pagination{Page: 1, Limit: 20},
+		Tags:       []string{" VIP ", ""},
+		Attribute:  []string{"plan:pro", "url:https://x.io"},
+	}
+	if _, _, err := svc.List(ctx, payload); err != nil {
+		t.Fatalf("List: %v", err)
+	}
+
+	if got := repo.listed.Tags; len(got) != 1 || got[0] != "vip" {
+		t.Errorf("tags = %q, want [vip]", got)
+	}
+	want := []dto.RecipientAttributeFilter{{Key: "plan", Value: "pro"}, {Key: "url", Value: "https://x.io"}}
+	if got := repo.listed.Attributes; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("attributes = %+v, want %+v", got, want)
+	}
+}
+
+func TestList_RejectsMalformedAttributeFilter(t *testing.T) {
+	repo := newFakeRecipientRepo()
+	svc := newTestRecipientService(repo)
+
+	payload := &dto.ListRecipientsPayload{ProjectID: 1, Attribute: []string{"plan"}}
+	if _, _, err := svc.List(context.Background(), payload); err == nil {
+		t.Fatal("expected a validation error for an attribute filter without ':'")
+	}
+	if repo.listed != nil {
+		t.Error("List reached the repository with an invalid filter")
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/mudgallabs/bodhveda/internal/model/dto"
+	"github.com/mudgallabs/bodhveda/internal/model/entity"
+	"github.com/mudgallabs/bodhveda/internal/model/enum"
+	"github.com/mudgallabs/bodhveda/internal/model/repository"
+	tantraRepo "github.com/mudgallabs/tantra/repository"
+	"github.com/mudgallabs/tantra/service"
+)
+
+// scheduleRepo holds one notification's live status and applies the same
+// conditional transitions the SQL does.
+type scheduleRepo struct {
+	repository.NotificationRepository
+	status   enum.NotificationStatus
+	released enum.NotificationStatus
+}
+
+func (r *scheduleRepo) ReleaseScheduled(ctx context.Context, projectID, id int, next enum.NotificationStatus, completedAt *time.Time) (enum.NotificationStatus, error) {
+	if r.status == enum.NotificationStatusScheduled {
+		r.status = next
+		r.released = next
+	}
+	return r.status, nil
+}
+
+func (r *scheduleRepo) CancelScheduled(ctx context.Context, projectID, id int) (*entity.Notification, error) {
+	if r.status != enum.NotificationStatusScheduled {
+		return nil, tantraRepo.ErrConflict
+	}
+	r.status = enum.NotificationStatusCancelled
+	return &entity.Notification{ID: id, ProjectID: projectID, Status: r.status}, nil
+}
+
+func scheduledTask(payload json.RawMessage) dto.NotificationDeliveryTaskPayload {
+	sendAt := time.Now().Add(-time.Second)
+	return dto.NotificationDeliveryTaskPayload{
+		Notification: &entity.Notification{
+			ID: 7, ProjectID: 1, RecipientExtID: "user-1", Payload: payload,
+			Status: enum.NotificationStatusScheduled, SendAt: &sendAt,
+		},
+	}
+}
+
+// TestCancelledScheduledSendDoesNothing — the task still fires at send_at after
+// a cancel; the row is what stops it. The service has no recipient service, so
+// going past the check would panic.
+func TestCancelledScheduledSendDoesNothing(t *testing.T) {
+	repo := &scheduleRepo{status: enum.NotificationStatusScheduled}
+	svc := NewNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	if _, errKind, err := svc.CancelScheduled(context.Background(), 1, 7); err != nil {
+		t.Fatalf("cancel: %v (kind %v)", err, errKind)
+	}
+
+	if err := svc.DeliverDirectNotification(context.Background(), scheduledTask(json.RawMessage(`{"title":"hi"}`))); err != nil {
+		t.Fatalf("deliver after cancel: %v", err)
+	}
+	if repo.status != enum.NotificationStatusCancelled {
+		t.Errorf("status = %q, want cancelled to stick", repo.status)
+	}
+}
+
+// TestCancelAfterFireConflicts — once the worker has released it, the send is on
+// its way and the caller must hear so.
+func TestCancelAfterFireConflicts(t *testing.T) {
+	repo := &scheduleRepo{status: enum.NotificationStatusEnqueued}
+	svc := NewNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	_, errKind, err := svc.CancelScheduled(context.Background(), 1, 7)
+	if err == nil || errKind != service.ErrConflict {
+		t.Fatalf("cancel after fire: got (%v, %v), want a conflict", errKind, err)
+	}
+}
+
+// TestReleaseAppliesTheImmediateSendRule — an email-only send is `not_requested`
+// from the moment it runs, exactly as an immediate one is at INSERT; only the
+// in-app one goes to `enqueued` for the inbox write.
+func TestReleaseAppliesTheImmediateSendRule(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		payload json.RawMessage
+		want    enum.NotificationStatus
+	}{
+		{"in-app", json.RawMessage(`{"title":"hi"}`), enum.NotificationStatusEnqueued},
+		{"email-only", json.RawMessage(`null`), enum.NotificationStatusNotRequested},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &scheduleRepo{status: enum.NotificationStatusScheduled}
+			svc := NewNotificationService(repo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+			task := scheduledTask(tc.payload)
+			proceed, err := svc.releaseScheduled(context.Background(), task.Notification)
+			if err != nil || !proceed {
+				t.Fatalf("release: proceed=%v err=%v", proceed, err)
+			}
+			if repo.released != tc.want || task.Notification.Status != tc.want {
+				t.Errorf("released to %q (snapshot %q), want %q", repo.released, task.Notification.Status, tc.want)
+			}
+		})
+	}
+}
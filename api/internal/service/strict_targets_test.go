@@ -42,8 +42,8 @@ func gateService(strict, cataloged bool) (*NotificationService, *flagProjectRepo
 	prefRepo := &countingCatalogRepo{cataloged: cataloged}
 
 	svc := NewNotificationService(
-		nil, nil, prefRepo, nil, nil, nil, nil, nil, projectRepo,
-		nil, nil, nil,
+		nil, nil, prefRepo, nil, nil, nil, nil, nil, projectRepo, nil,
+		nil, nil, nil, nil, nil,
 	)
 
 	return svc, projectRepo, prefRepo
@@ -138,7 +138,7 @@ func TestStrictTargetsGatesEveryRequestedMedium(t *testing.T) {
 	projectRepo := &flagProjectRepo{strict: true}
 	prefRepo := &perMediumCatalogRepo{cataloged: map[enum.Medium]bool{enum.MediumInApp: true}}
 
-	svc := NewNotificationService(nil, nil, prefRepo, nil, nil, nil, nil, nil, projectRepo, nil, nil, nil)
+	svc := NewNotificationService(nil, nil, prefRepo, nil, nil, nil, nil, nil, projectRepo, nil, nil, nil, nil, nil, nil)
 
 	// in_app alone passes.
 	if _, err := svc.gateTarget(context.Background(), 1, someTarget(), []enum.Medium{enum.MediumInApp}); err != nil {
@@ -0,0 +1,60 @@
+import { useEffect, useState } from "react";
+import { Input } from "netra";
+
+interface DebouncedInputProps {
+    value: string;
+    onDebouncedChange: (value: string) => void;
+    placeholder?: string;
+    className?: string;
+}
+
+/**
+ * A text input that reports upward only once typing settles.
+ *
+ * List filters drive a request, and often the URL too, so an un-debounced input
+ * would push a refetch (and a router navigation) per keystroke — and leave the
+ * back button walking through every prefix of what was typed.
+ *
+ * It keeps a local mirror while focused, but re-syncs whenever the prop moves on
+ * its own (Clear, or the back button), so the URL stays the source of truth.
+ */
+export function DebouncedInput({
+    value,
+    onDebouncedChange,
+    placeholder,
+    className,
+}: DebouncedInputProps) {
+    const [local, setLocal] = useState(value);
+
+    useEffect(() => {
+        // Re-sync only on a GENUINELY external change (Clear, back button), not
+        // on the echo of our own debounced write. Since we emit `local.trim()`,
+        // the echo comes back trimmed: syncing on it unconditionally would erase
+        // a trailing space the moment the debounce fired, moving the caret out
+        // from under someone still typing "billing alerts".
+        if (value === local.trim()) return;
+        setLocal(value);
+        // Intentionally keyed on `value` alone: this reacts to the prop moving,
+        // and reading `local` here must not re-run it.
+        // eslint-disable-next-line react-hooks/exhaustive-deps
+    }, [value]);
+
+    useEffect(() => {
+        if (local.trim() === value) return;
+
+        const t = setTimeout(() => onDebouncedChange(local.trim()), 300);
+        return () => clearTimeout(t);
+        // onDebouncedChange is a fresh closure each render; depending on it would
+        // reset the timer every render and never fire.
+        // eslint-disable-next-line react-hooks/exhaustive-deps
+    }, [local, value]);
+
+    return (
+        <Input
+            value={local}
+            onChange={(e) => setLocal(e.target.value)}
+            placeholder={placeholder}
+            className={className}
+        />
+    );
+}
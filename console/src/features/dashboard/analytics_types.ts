@@ -13,6 +13,15 @@ export interface AnalyticsInAppByStatus {
     // requested. `total` counts every notification row, so without this bucket
     // the per-status split would not sum to it.
     not_requested: number;
+    // Scheduled sends not yet due, and ones cancelled before they fired.
+    scheduled: number;
+    cancelled: number;
+    // Delivered, then replaced by a newer send under the same collapse_key.
+    collapsed: number;
+    // Turned away by a frequency cap.
+    throttled: number;
+    // Pulled back by the sender after sending.
+    recalled: number;
 }
 
 export interface AnalyticsInAppDay {
@@ -24,6 +33,11 @@ export interface AnalyticsInAppDay {
     quota_exceeded: number;
     failed: number;
     not_requested: number;
+    scheduled: number;
+    cancelled: number;
+    collapsed: number;
+    throttled: number;
+    recalled: number;
 }
 
 export interface AnalyticsInApp {
@@ -41,6 +55,11 @@ export interface AnalyticsEmailByStatus {
     failed: number;
     no_contact: number;
     muted: number;
+    expired: number;
+    digested: number;
+    deferred: number;
+    throttled: number;
+    recalled: number;
 }
 
 export interface AnalyticsEmailDay {
@@ -21,6 +21,9 @@ export interface ProjectEmailSettings {
     // Used to verify inbound Resend delivery-status webhooks.
     webhook_secret_masked: string;
     webhook_secret_set: boolean;
+    // Digest email wrapper; null falls back to the default.
+    digest_subject: string | null;
+    digest_html: string | null;
     created_at: string;
     updated_at: string;
 }
@@ -35,4 +38,8 @@ export interface UpsertProjectEmailSettingsPayload {
     // Always optional: omit/blank to keep the existing webhook secret, or supply a
     // new one to set/rotate it.
     webhook_secret?: string;
+    // Omit to keep, "" to reset to the default. The HTML must contain
+    // {{digest_items}}; both may use {{digest_count}}.
+    digest_subject?: string;
+    digest_html?: string;
 }
@@ -0,0 +1,87 @@
+import { BroadcastPreview } from "@/features/notification/notification_types";
+
+// BroadcastPreviewSummary renders a dry run of the broadcast being composed:
+// who it would reach, what it would cost, and anything that would stop part of
+// it going out.
+export function BroadcastPreviewSummary({
+    preview,
+}: {
+    preview: BroadcastPreview;
+}) {
+    const { audience, email, usage } = preview;
+    const unknown = preview.unknown_recipient_ids?.length ?? 0;
+
+    const excluded = [
+        [audience.excluded_disabled, "turned this target off"],
+        [audience.excluded_not_cataloged, "not offered this target"],
+        [audience.excluded_segment, "outside the audience"],
+        [audience.excluded_unknown, "not a recipient"],
+    ].filter(([count]) => (count as number) > 0);
+
+    return (
+        <div className="rounded-md border border-border p-4 space-y-2 text-sm">
+            <p>
+                Would reach{" "}
+                <span className="font-semibold">
+                    {preview.recipients.toLocaleString()}
+                </span>{" "}
+                {preview.recipients === 1 ? "recipient" : "recipients"}, of{" "}
+                {audience.total.toLocaleString()} considered.
+            </p>
+
+            {excluded.length > 0 && (
+                <p className="text-text-muted">
+                    Left out:{" "}
+                    {excluded
+                        .map(
+                            ([count, why]) =>
+                                `${(count as number).toLocaleString()} ${why}`
+                        )
+                        .join(", ")}
+                    .
+                </p>
+            )}
+
+            {email && (
+                <p className="text-text-muted">
+                    {email.blocked_reason === "recipient_cap_exceeded"
+                        ? `Email would not send: ${email.eligible.toLocaleString()} recipients are eligible, over the project's cap of ${email.cap.toLocaleString()}.`
+                        : email.blocked_reason === "provider_not_configured"
+                          ? "Email would not send: this project has no email provider set up."
+                          : `${email.eligible.toLocaleString()} would also get it by email.`}
+                </p>
+            )}
+
+            {unknown > 0 && (
+                <p className="text-text-muted">
+                    {unknown} {unknown === 1 ? "id has" : "ids have"} no
+                    recipient.
+                </p>
+            )}
+
+            <p
+                className={
+                    usage.would_exceed
+                        ? "text-text-destructive"
+                        : "text-text-muted"
+                }
+            >
+                {usage.would_exceed
+                    ? "This would exceed your plan's quota, so nothing would be sent."
+                    : usage.limit === null
+                      ? `Uses ${usage.amount.toLocaleString()} notifications.`
+                      : `Uses ${usage.amount.toLocaleString()} of the ${(
+                            usage.limit - usage.used
+                        ).toLocaleString()} notifications left this period.`}
+            </p>
+
+            {preview.sample_recipient_ids.length > 0 && (
+                <p className="text-text-muted font-mono text-xs break-all">
+                    {preview.sample_recipient_ids.join(", ")}
+                    {preview.recipients > preview.sample_recipient_ids.length &&
+                        ", …"}
+                </p>
+            )}
+        </div>
+    );
+}
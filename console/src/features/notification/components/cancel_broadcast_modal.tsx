@@ -0,0 +1,90 @@
+import {
+    Button,
+    Dialog,
+    DialogContent,
+    DialogFooter,
+    DialogHeader,
+    DialogTitle,
+    toast,
+} from "netra";
+import { useState } from "react";
+
+import { apiErrorHandler } from "@/lib/api";
+import { useCancelBroadcast } from "@/features/notification/notification_hooks";
+import { Broadcast } from "@/features/notification/notification_types";
+
+// CancelBroadcastButton stops a broadcast that is scheduled or still fanning
+// out. Only rendered for those two statuses; the API 409s on anything else.
+export function CancelBroadcastButton({
+    projectID,
+    broadcast,
+}: {
+    projectID: string;
+    broadcast: Broadcast;
+}) {
+    const [open, setOpen] = useState(false);
+
+    const { mutate: cancelBroadcast, isPending: isCancelling } =
+        useCancelBroadcast(projectID, {
+            onSuccess: () => {
+                toast.success(`Broadcast #${broadcast.id} cancelled`);
+                setOpen(false);
+            },
+            onError: apiErrorHandler,
+        });
+
+    const scheduled = broadcast.status === "scheduled";
+
+    return (
+        <>
+            <Button variant="secondary" onClick={() => setOpen(true)}>
+                Cancel broadcast
+            </Button>
+
+            <Dialog open={open} onOpenChange={setOpen}>
+                <DialogContent>
+                    <DialogHeader>
+                        <DialogTitle>Cancel Broadcast</DialogTitle>
+
+                        {scheduled ? (
+                            <p>
+                                This broadcast has not gone out yet. Cancelling
+                                it means it never will.
+                            </p>
+                        ) : (
+                            <p>
+                                Recipients this broadcast has already reached
+                                keep their notification. Nobody else gets it,
+                                and the notifications it will not send are
+                                refunded.
+                            </p>
+                        )}
+
+                        <p className="text-text-destructive">
+                            This action cannot be undone.
+                        </p>
+                    </DialogHeader>
+
+                    <DialogFooter>
+                        <Button
+                            variant="secondary"
+                            onClick={() => setOpen(false)}
+                        >
+                            Keep sending
+                        </Button>
+
+                        <Button
+                            variant="destructive"
+                            loading={isCancelling}
+                            onClick={() =>
+                                cancelBroadcast({ broadcastID: broadcast.id })
+                            }
+                        >
+                            Cancel Broadcast
+                        </Button>
+                    </DialogFooter>
+                </DialogContent>
+            </Dialog>
+        </>
+    );
+}
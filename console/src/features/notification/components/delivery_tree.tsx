@@ -55,10 +55,17 @@ const OUTCOME_STYLE: Record<
         text: "text-text-muted",
         hint: "The sender never asked for this medium — an email-only send has no in-app content.",
     },
+    scheduled: {
+        label: "Scheduled",
+        dot: "bg-text-muted",
+        text: "text-text-muted",
+        hint: "Waiting for its send time. Nothing is stuck — it goes out when that time comes.",
+    },
 };
 
 const OUTCOME_ORDER: DeliveryOutcome[] = [
     "succeeded",
+    "scheduled",
     "pending",
     "suppressed",
     "failed",
@@ -297,10 +304,17 @@ function outcomeForStatus(status: string): DeliveryOutcome | null {
             return "pending";
         case "delivered":
         case "sent":
+        case "digested":
+        case "collapsed":
             return "succeeded";
         case "muted":
         case "no_contact":
         case "suppressed":
+        case "expired":
+        // Over the catalog entry's frequency cap — the cap doing its job.
+        case "throttled":
+        // Pulled back by the sender after it went out.
+        case "recalled":
             return "suppressed";
         case "failed":
         case "bounced":
@@ -310,6 +324,12 @@ function outcomeForStatus(status: string): DeliveryOutcome | null {
             return "failed";
         case "not_requested":
             return "not_requested";
+        case "scheduled":
+        // Held for the recipient's quiet hours — waiting on the clock.
+        case "deferred":
+            return "scheduled";
+        case "cancelled":
+            return "suppressed";
         default:
             return null;
     }
@@ -317,7 +337,10 @@ function outcomeForStatus(status: string): DeliveryOutcome | null {
 
 function AudienceBranch({ audience }: { audience: DeliveryTreeAudience }) {
     const excluded =
-        audience.excluded_disabled + audience.excluded_not_cataloged;
+        audience.excluded_segment +
+        audience.excluded_unknown +
+        audience.excluded_disabled +
+        audience.excluded_not_cataloged;
 
     return (
         <Branch>
@@ -349,6 +372,39 @@ function AudienceBranch({ audience }: { audience: DeliveryTreeAudience }) {
                         }
                     />
 
+                    {audience.excluded_segment > 0 && (
+                        <Branch
+                            last={
+                                audience.excluded_unknown === 0 &&
+                                audience.excluded_disabled === 0 &&
+                                audience.excluded_not_cataloged === 0
+                            }
+                        >
+                            <NodeLine
+                                label="Outside audience"
+                                count={audience.excluded_segment}
+                                tone="text-text-muted"
+                                hint="These recipients did not match the broadcast's audience filter, so their preferences were never consulted."
+                            />
+                        </Branch>
+                    )}
+
+                    {audience.excluded_unknown > 0 && (
+                        <Branch
+                            last={
+                                audience.excluded_disabled === 0 &&
+                                audience.excluded_not_cataloged === 0
+                            }
+                        >
+                            <NodeLine
+                                label="Unknown recipient"
+                                count={audience.excluded_unknown}
+                                tone="text-text-muted"
+                                hint="These ids were on the broadcast's recipient list, but no such recipient existed when it fanned out."
+                            />
+                        </Branch>
+                    )}
+
                     {audience.excluded_disabled > 0 && (
                         <Branch last={audience.excluded_not_cataloged === 0}>
                             <NodeLine
@@ -32,19 +32,26 @@ export function NotificationStatusCell({
     const createdAt = new Date(notification.created_at);
     const email = notification.email;
 
+    // A scheduled send has no completed_at either, but it is waiting, not
+    // working: show when it goes out instead of a spinner.
+    const scheduled =
+        notification.status === "scheduled" && notification.send_at;
+
     const inAppLine = (
         <MediumStatusLine
             label="In-app"
             status={notification.status}
             elapsed={
-                notification.completed_at
-                    ? formatDuration(
-                          createdAt,
-                          new Date(notification.completed_at)
-                      )
-                    : null
+                scheduled
+                    ? new Date(notification.send_at!).toLocaleString()
+                    : notification.completed_at
+                      ? formatDuration(
+                            createdAt,
+                            new Date(notification.completed_at)
+                        )
+                      : null
             }
-            pending={!notification.completed_at}
+            pending={!notification.completed_at && !scheduled}
         />
     );
 
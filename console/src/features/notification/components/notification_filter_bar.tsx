@@ -1,6 +1,6 @@
-import { useEffect, useState } from "react";
-import { Button, DatePicker, Input, Select, formatDate } from "netra";
+import { Button, DatePicker, Select, formatDate } from "netra";
 
+import { DebouncedInput } from "@/components/debounced_input";
 import {
     EMAIL_DELIVERY_FILTER_STATUSES,
     EmailFilter,
@@ -162,61 +162,3 @@ function rangeLabel(filters: NotificationFilters): string {
     const to = dates[1] ? formatDate(dates[1]) : from;
     return from === to ? from : `${from} → ${to}`;
 }
-
-interface DebouncedInputProps {
-    value: string;
-    onDebouncedChange: (value: string) => void;
-    placeholder?: string;
-    className?: string;
-}
-
-/**
- * A text input that reports upward only once typing settles.
- *
- * These filters live in the URL, so an un-debounced input would push a router
- * navigation and a refetch per keystroke — and leave the back button walking
- * through every prefix of what was typed.
- *
- * It keeps a local mirror while focused, but re-syncs whenever the prop moves on
- * its own (Clear, or the back button), so the URL stays the source of truth.
- */
-function DebouncedInput({
-    value,
-    onDebouncedChange,
-    placeholder,
-    className,
-}: DebouncedInputProps) {
-    const [local, setLocal] = useState(value);
-
-    useEffect(() => {
-        // Re-sync only on a GENUINELY external change (Clear, back button), not
-        // on the echo of our own debounced write. Since we emit `local.trim()`,
-        // the echo comes back trimmed: syncing on it unconditionally would erase
-        // a trailing space the moment the debounce fired, moving the caret out
-        // from under someone still typing "billing alerts".
-        if (value === local.trim()) return;
-        setLocal(value);
-        // Intentionally keyed on `value` alone: this reacts to the prop moving,
-        // and reading `local` here must not re-run it.
-        // eslint-disable-next-line react-hooks/exhaustive-deps
-    }, [value]);
-
-    useEffect(() => {
-        if (local.trim() === value) return;
-
-        const t = setTimeout(() => onDebouncedChange(local.trim()), 300);
-        return () => clearTimeout(t);
-        // onDebouncedChange is a fresh closure each render; depending on it would
-        // reset the timer every render and never fire.
-        // eslint-disable-next-line react-hooks/exhaustive-deps
-    }, [local, value]);
-
-    return (
-        <Input
-            value={local}
-            onChange={(e) => setLocal(e.target.value)}
-            placeholder={placeholder}
-            className={className}
-        />
-    );
-}
@@ -0,0 +1,60 @@
+import { Button, toast, Tooltip } from "netra";
+
+import { apiErrorHandler } from "@/lib/api";
+import {
+    useFailedBroadcastBatches,
+    useRetryBroadcastBatches,
+} from "@/features/notification/notification_hooks";
+import { Broadcast } from "@/features/notification/notification_types";
+
+// RetryBroadcastBatchesButton appears when a broadcast still sending has failed
+// batches — the ones that, once Asynq gives up on them, keep it from ever
+// completing. Retrying re-enqueues them; delivery completes the broadcast when
+// the last one succeeds.
+export function RetryBroadcastBatchesButton({
+    projectID,
+    broadcast,
+}: {
+    projectID: string;
+    broadcast: Broadcast;
+}) {
+    const { data } = useFailedBroadcastBatches(
+        projectID,
+        broadcast.id,
+        broadcast.status === "enqueued"
+    );
+    const failed = data?.data ?? [];
+
+    const { mutate: retryBatches, isPending: isRetrying } =
+        useRetryBroadcastBatches(projectID, {
+            onSuccess: () => {
+                toast.success(
+                    failed.length === 1
+                        ? "Failed batch re-enqueued"
+                        : `${failed.length} failed batches re-enqueued`
+                );
+            },
+            onError: apiErrorHandler,
+        });
+
+    if (failed.length === 0) return null;
+
+    const recipients = failed.reduce((sum, b) => sum + b.recipients, 0);
+
+    return (
+        <Tooltip
+            content={`${recipients} ${
+                recipients === 1 ? "recipient has" : "recipients have"
+            } not been reached because their batch failed. Retrying is safe: a batch that already delivered is skipped.`}
+        >
+            <Button
+                variant="secondary"
+                loading={isRetrying}
+                onClick={() => retryBatches({ broadcastID: broadcast.id })}
+            >
+                Retry {failed.length} failed{" "}
+                {failed.length === 1 ? "batch" : "batches"}
+            </Button>
+        </Tooltip>
+    );
+}
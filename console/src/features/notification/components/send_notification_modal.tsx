@@ -22,9 +22,18 @@ import {
 } from "netra";
 
 import { useGetProjectIDFromParams } from "@/features/project/project_hooks";
-import { NotificationKind, SendNotificationResult } from "../notification_types";
+import {
+    BroadcastPreview,
+    NotificationKind,
+    SendNotificationPayload,
+    SendNotificationResult,
+} from "../notification_types";
 import { NotificationKindToggle } from "./notification_kind_toggle";
-import { useSendNotification } from "../notification_hooks";
+import { BroadcastPreviewSummary } from "./broadcast_preview_summary";
+import {
+    usePreviewBroadcast,
+    useSendNotification,
+} from "../notification_hooks";
 import { apiErrorHandler, APIRes } from "@/lib/api";
 
 type SendNotificationModalProps = {
@@ -45,6 +54,12 @@ interface State {
     email_subject: string;
     email_html: string;
     email_text: string;
+    // Broadcast only. With list_enabled the broadcast goes to the ids in
+    // recipient_list (pasted, or loaded from a CSV) instead of everyone the
+    // target reaches.
+    list_enabled: boolean;
+    recipient_list: string;
+    create_missing_recipients: boolean;
 }
 
 const INITIAL_STATE: State = {
@@ -57,6 +72,9 @@ const INITIAL_STATE: State = {
     email_subject: "",
     email_html: "",
     email_text: "",
+    list_enabled: false,
+    recipient_list: "",
+    create_missing_recipients: false,
 };
 
 export function SendNotificationModal({
@@ -72,18 +90,40 @@ export function SendNotificationModal({
 
     // Only direct sends can carry an email block (email is direct-only).
     const emailEnabled = !isBroadcast && state.email_enabled;
+    const listEnabled = isBroadcast && state.list_enabled;
+    const recipientIDs = useMemo(
+        () => parseRecipientList(state.recipient_list),
+        [state.recipient_list]
+    );
 
     const { mutate: sendNotification, isPending: isSending } =
         useSendNotification(projectID, {
             onSuccess: (res: APIRes<SendNotificationResult>) => {
-                notifyEmailOutcome(res);
+                if (!notifyListOutcome(res)) {
+                    notifyEmailOutcome(res);
+                }
                 setOpen(false);
                 setState(INITIAL_STATE);
             },
             onError: apiErrorHandler,
         });
 
-    const handleSendNotification = () => {
+    // The last dry run of the broadcast on screen. Dropped on any edit, so what
+    // is shown always describes what Send would send.
+    const [preview, setPreview] = useState<BroadcastPreview | null>(null);
+    useEffect(() => setPreview(null), [state, kind]);
+
+    const { mutate: previewBroadcast, isPending: isPreviewing } =
+        usePreviewBroadcast(projectID, {
+            onSuccess: (res: APIRes<SendNotificationResult>) => {
+                setPreview(res?.data?.preview ?? null);
+            },
+            onError: apiErrorHandler,
+        });
+
+    // buildPayload turns the form into a send, or null (with a toast) when the
+    // payload is not valid JSON.
+    const buildPayload = (): SendNotificationPayload | null => {
         let target = null;
 
         if (isBroadcast || state.channel || state.topic || state.event) {
@@ -104,7 +144,7 @@ export function SendNotificationModal({
                 ? undefined
                 : JSON.parse(state.payload);
 
-            sendNotification({
+            return {
                 recipient_id: state.recipient_id ? state.recipient_id : null,
                 target,
                 payload: parsedPayload,
@@ -117,9 +157,29 @@ export function SendNotificationModal({
                           text: state.email_text || undefined,
                       }
                     : undefined,
-            });
+                recipient_ids: listEnabled ? recipientIDs : undefined,
+                create_missing_recipients:
+                    listEnabled && state.create_missing_recipients
+                        ? true
+                        : undefined,
+            };
         } catch {
             toast.error("Payload must be a valid JSON");
+            return null;
+        }
+    };
+
+    const handleSendNotification = () => {
+        const payload = buildPayload();
+        if (payload) {
+            sendNotification(payload);
+        }
+    };
+
+    const handlePreviewBroadcast = () => {
+        const payload = buildPayload();
+        if (payload) {
+            previewBroadcast(payload);
         }
     };
 
@@ -134,10 +194,13 @@ export function SendNotificationModal({
             if (!state.channel || !state.topic || !state.event) {
                 return true;
             }
+            if (listEnabled && recipientIDs.length === 0) {
+                return true;
+            }
         }
 
         return disable;
-    }, [isBroadcast, state]);
+    }, [isBroadcast, state, listEnabled, recipientIDs]);
 
     const disableSendButton = useMemo(() => {
         if (disablePayloadButton) {
@@ -227,6 +290,7 @@ export function SendNotificationModal({
                                 kind={kind}
                                 setKind={setKind}
                                 isBroadcast={isBroadcast}
+                                recipientIDs={recipientIDs}
                             />
                         </MultiStep.Step>
 
@@ -240,6 +304,13 @@ export function SendNotificationModal({
                         </MultiStep.Step>
                     </MultiStep.Content>
 
+                    {preview && (
+                        <>
+                            <div className="h-4" />
+                            <BroadcastPreviewSummary preview={preview} />
+                        </>
+                    )}
+
                     <div className="h-4" />
 
                     <div className="flex w-full justify-between gap-x-4">
@@ -277,20 +348,36 @@ export function SendNotificationModal({
                                         </Button>
                                     </Tooltip>
                                 ) : (
-                                    <Tooltip
-                                        disabled={!disableSendButton}
-                                        content="Some required fields are missing"
-                                    >
-                                        <Button
-                                            variant="primary"
-                                            onClick={handleSendNotification}
-                                            loading={isSending}
-                                            disabled={disableSendButton}
+                                    <div className="flex-x gap-x-2">
+                                        {isBroadcast && (
+                                            <Button
+                                                variant="secondary"
+                                                onClick={handlePreviewBroadcast}
+                                                loading={isPreviewing}
+                                                disabled={
+                                                    disableSendButton ||
+                                                    isSending
+                                                }
+                                            >
+                                                Preview
+                                            </Button>
+                                        )}
+
+                                        <Tooltip
+                                            disabled={!disableSendButton}
+                                            content="Some required fields are missing"
                                         >
-                                            <IconSend />
-                                            Send Notification
-                                        </Button>
-                                    </Tooltip>
+                                            <Button
+                                                variant="primary"
+                                                onClick={handleSendNotification}
+                                                loading={isSending}
+                                                disabled={disableSendButton}
+                                            >
+                                                <IconSend />
+                                                Send Notification
+                                            </Button>
+                                        </Tooltip>
+                                    </div>
                                 )
                             }
                         </MultiStep.NextStepButton>
@@ -307,12 +394,14 @@ function TargetStep({
     kind,
     setKind,
     isBroadcast,
+    recipientIDs,
 }: {
     state: State;
     setState: React.Dispatch<React.SetStateAction<State>>;
     kind: NotificationKind;
     setKind: React.Dispatch<React.SetStateAction<NotificationKind>>;
     isBroadcast: boolean;
+    recipientIDs: string[];
 }) {
     return (
         <div className="space-y-4">
@@ -384,10 +473,161 @@ function TargetStep({
                     }
                 />
             </WithLabel>
+
+            {isBroadcast && (
+                <RecipientListSection
+                    state={state}
+                    setState={setState}
+                    recipientIDs={recipientIDs}
+                />
+            )}
         </div>
     );
 }
 
+function RecipientListSection({
+    state,
+    setState,
+    recipientIDs,
+}: {
+    state: State;
+    setState: React.Dispatch<React.SetStateAction<State>>;
+    recipientIDs: string[];
+}) {
+    // The file's text lands in the same textarea a pasted list does, so what is
+    // sent is always what is on screen.
+    const loadCSV = (file: File | undefined) => {
+        if (!file) return;
+        file.text()
+            .then((text) =>
+                setState((prev) => ({ ...prev, recipient_list: text }))
+            )
+            .catch(() => toast.error("Could not read the CSV file"));
+    };
+
+    return (
+        <div className="rounded-md border border-border p-4 space-y-4">
+            <div className="flex-x justify-between">
+                <span className="flex-x">
+                    <Label>Send to a list of recipients</Label>
+                    <Tooltip
+                        content={
+                            <>
+                                <p>
+                                    Only these recipients are considered, and
+                                    their preferences still apply.
+                                </p>
+                                <p>Up to 100,000 ids.</p>
+                            </>
+                        }
+                    >
+                        <IconInfo />
+                    </Tooltip>
+                </span>
+
+                <Switch
+                    checked={state.list_enabled}
+                    onCheckedChange={(checked) =>
+                        setState((prev) => ({
+                            ...prev,
+                            list_enabled: checked,
+                        }))
+                    }
+                />
+            </div>
+
+            {state.list_enabled && (
+                <div className="space-y-4">
+                    <WithLabel
+                        Label={
+                            <span className="flex-x">
+                                <Label required>Recipient IDs</Label>
+                                <Tooltip content="One per line. From a CSV only the first column is read, and a header row is skipped.">
+                                    <IconInfo />
+                                </Tooltip>
+                            </span>
+                        }
+                    >
+                        <Textarea
+                            className="w-full! h-32 font-mono"
+                            placeholder={"user_1\nuser_2"}
+                            value={state.recipient_list}
+                            onChange={(e) =>
+                                setState((prev) => ({
+                                    ...prev,
+                                    recipient_list: e.target.value,
+                                }))
+                            }
+                        />
+                    </WithLabel>
+
+                    <div className="flex-x justify-between">
+                        <Input
+                            type="file"
+                            accept=".csv,text/csv,text/plain"
+                            onChange={(e) => {
+                                loadCSV(e.target.files?.[0]);
+                                // Let the same file be picked again after an edit.
+                                e.target.value = "";
+                            }}
+                        />
+                        <span className="text-text-muted text-sm">
+                            {recipientIDs.length}{" "}
+                            {recipientIDs.length === 1
+                                ? "recipient"
+                                : "recipients"}
+                        </span>
+                    </div>
+
+                    <div className="flex-x justify-between">
+                        <span className="flex-x">
+                            <Label>Create unknown recipients</Label>
+                            <Tooltip content="Off: ids with no recipient are skipped and reported back. On: a recipient is created for each, and they receive the broadcast.">
+                                <IconInfo />
+                            </Tooltip>
+                        </span>
+
+                        <Switch
+                            checked={state.create_missing_recipients}
+                            onCheckedChange={(checked) =>
+                                setState((prev) => ({
+                                    ...prev,
+                                    create_missing_recipients: checked,
+                                }))
+                            }
+                        />
+                    </div>
+                </div>
+            )}
+        </div>
+    );
+}
+
+// HEADER_IDS are first-column values read as a CSV header rather than an id.
+const HEADER_IDS = new Set(["id", "recipient_id", "external_id"]);
+
+// parseRecipientList reads a pasted list or a CSV: one id per line, the first
+// column when the line has several, quotes stripped, blanks and a header row
+// skipped. Ids are lowercased and deduped the way the API will, so the count
+// shown is the count sent.
+function parseRecipientList(text: string): string[] {
+    const seen = new Set<string>();
+
+    text.split(/\r?\n/).forEach((line, i) => {
+        const id = line
+            .split(",")[0]
+            .trim()
+            .replace(/^"(.*)"$/, "$1")
+            .trim()
+            .toLowerCase();
+        if (id === "") return;
+        if (i === 0 && HEADER_IDS.has(id)) return;
+        seen.add(id);
+    });
+
+    return [...seen];
+}
+
 function PayloadStep({
     state,
     setState,
@@ -636,3 +876,33 @@ function notifyEmailOutcome(res: APIRes<SendNotificationResult>) {
             toast.success("Notification sent successfully!");
     }
 }
+
+// notifyListOutcome reports what happened to the ids of a list-based broadcast
+// that had no recipient. Returns false when the send was not list-based, so the
+// caller falls through to the usual toast.
+function notifyListOutcome(res: APIRes<SendNotificationResult>): boolean {
+    const data = res?.data;
+    if (!data?.broadcast?.recipient_list_size) {
+        return false;
+    }
+
+    const unknown = data.unknown_recipient_ids?.length ?? 0;
+    const created = data.created_recipient_ids?.length ?? 0;
+
+    if (unknown > 0) {
+        toast.warning(
+            `Broadcast queued. ${unknown} ${
+                unknown === 1 ? "id has" : "ids have"
+            } no recipient and will be skipped.`
+        );
+    } else if (created > 0) {
+        toast.success(
+            `Broadcast queued. Created ${created} new ${
+                created === 1 ? "recipient" : "recipients"
+            }.`
+        );
+    } else {
+        toast.success("Broadcast queued.");
+    }
+    return true;
+}
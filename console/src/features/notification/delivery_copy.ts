@@ -77,8 +77,8 @@ export function deliveryFailureReasonText(reason?: string): OutcomeCopy | null {
 
 /**
  * Explains a delivery status that carries no failure_reason but still isn't a
- * plain success — today only `no_contact`, which fanOutEmail records with no
- * reason because the status already says it.
+ * plain success — `no_contact`, `deferred`, `expired`, `throttled` and `recalled`, which are recorded
+ * with no reason because the status already says it.
  */
 export function deliveryStatusText(status: DeliveryStatus): OutcomeCopy | null {
     if (status === "no_contact") {
@@ -87,6 +87,30 @@ export function deliveryStatusText(status: DeliveryStatus): OutcomeCopy | null {
             long: "The recipient has no primary email contact, so there was nowhere to send. Add an email contact for this recipient.",
         };
     }
+    if (status === "deferred") {
+        return {
+            short: "held for quiet hours",
+            long: "The recipient was inside their quiet hours, so the email will go out when they end.",
+        };
+    }
+    if (status === "expired") {
+        return {
+            short: "expired before it was sent",
+            long: "The notification's expires_at passed before the email went out, so it was not sent.",
+        };
+    }
+    if (status === "throttled") {
+        return {
+            short: "over the frequency cap",
+            long: "The recipient had already received this target's frequency cap of emails for the current window, so this one was not sent.",
+        };
+    }
+    if (status === "recalled") {
+        return {
+            short: "recalled before it was sent",
+            long: "The notification was recalled while this email was still waiting to go out, so it was not sent.",
+        };
+    }
     return null;
 }
 
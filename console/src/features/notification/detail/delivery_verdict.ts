@@ -41,6 +41,7 @@ function dominantOutcome(outcomes: Record<string, number>): DeliveryOutcome {
     const priority: DeliveryOutcome[] = [
         "failed",
         "pending",
+        "scheduled",
         "suppressed",
         "not_requested",
         "succeeded",
@@ -55,10 +56,21 @@ const TONE_FOR_OUTCOME: Record<DeliveryOutcome, VerdictTone> = {
     // its job. Colouring it red would make a healthy send look broken.
     suppressed: "neutral",
     not_requested: "neutral",
+    scheduled: "neutral",
     succeeded: "success",
 };
 
 export function verdictFor(tree: DeliveryTree): Verdict {
+    // Before send_at there is nothing to judge, and a broadcast has no audience
+    // yet — without this it would read "Audience not recorded".
+    if (tree.scheduled_for) {
+        return {
+            tone: "neutral",
+            headline: "Scheduled",
+            detail: `Goes out ${new Date(tree.scheduled_for).toLocaleString()}.`,
+        };
+    }
+
     return tree.kind === "broadcast"
         ? broadcastVerdict(tree)
         : directVerdict(tree);
@@ -71,6 +83,7 @@ function broadcastVerdict(tree: DeliveryTree): Verdict {
     const reached = inApp?.outcomes.succeeded ?? 0;
     const pending = inApp?.pending ?? 0;
     const failed = inApp?.outcomes.failed ?? 0;
+    const recalled = inApp?.recalled ?? 0;
 
     // No audience recorded is its own answer — and NOT the same as reaching
     // nobody, which is why it gets its own branch rather than a zeroed ratio.
@@ -87,18 +100,33 @@ function broadcastVerdict(tree: DeliveryTree): Verdict {
     if (audience.eligible === 0) {
         // The most useful thing this page can say. Name the cause, because the two
         // reasons need opposite fixes.
-        const notCataloged = audience.excluded_not_cataloged > 0;
+        let detail =
+            "Every recipient has this target turned off. Nothing to fix — this is their choice.";
+        if (audience.excluded_not_cataloged > 0) {
+            detail =
+                "This target has no enabled in-app catalog entry, so no one could receive it. Add or enable it in Preferences.";
+        } else if (audience.excluded_segment === total) {
+            detail =
+                "No recipient matched this broadcast's audience filter.";
+        } else if (audience.excluded_unknown === total) {
+            detail =
+                "None of the ids on this broadcast's recipient list belong to a recipient.";
+        }
         return {
             tone: "warning",
             headline: `0 of ${total}`,
             unit: total === 1 ? "recipient reached" : "recipients reached",
-            detail: notCataloged
-                ? "This target has no enabled in-app catalog entry, so no one could receive it. Add or enable it in Preferences."
-                : "Every recipient has this target turned off. Nothing to fix — this is their choice.",
+            detail,
         };
     }
 
     const parts: string[] = [];
+    if (audience.excluded_segment > 0) {
+        parts.push(`${audience.excluded_segment} outside audience`);
+    }
+    if (audience.excluded_unknown > 0) {
+        parts.push(`${audience.excluded_unknown} unknown`);
+    }
     if (audience.excluded_disabled > 0) {
         parts.push(`${audience.excluded_disabled} opted out`);
     }
@@ -107,6 +135,7 @@ function broadcastVerdict(tree: DeliveryTree): Verdict {
     }
     if (pending > 0) parts.push(`${pending} still pending`);
     if (failed > 0) parts.push(`${failed} failed`);
+    if (recalled > 0) parts.push(`${recalled} recalled`);
 
     let tone: VerdictTone = "success";
     if (failed > 0) tone = "error";
@@ -134,7 +163,13 @@ function directVerdict(tree: DeliveryTree): Verdict {
             case "pending":
                 return { text: `${name} pending`, outcome };
             case "suppressed":
-                return { text: `${name} suppressed`, outcome };
+                // Recalled is suppressed too, but "suppressed" would hide that
+                // it was the sender who pulled it back.
+                return m.recalled > 0
+                    ? { text: `${name} recalled`, outcome }
+                    : { text: `${name} suppressed`, outcome };
+            case "scheduled":
+                return { text: `${name} scheduled`, outcome };
             default:
                 return { text: `${name} not requested`, outcome };
         }
@@ -0,0 +1,184 @@
+import { Button, ErrorMessage, Loading, formatDate } from "netra";
+import { useState } from "react";
+
+import { useExplainNotification } from "@/features/notification/notification_hooks";
+import {
+    ExplainResult,
+    ExplainRung,
+    ExplainStep,
+} from "@/features/notification/notification_types";
+
+type Medium = "in_app" | "email";
+
+const MEDIUM_LABEL: Record<Medium, string> = {
+    in_app: "in-app",
+    email: "email",
+};
+
+const RESULT_STYLE: Record<ExplainResult, { glyph: string; text: string }> = {
+    pass: { glyph: "✓", text: "text-success-foreground" },
+    fail: { glyph: "✕", text: "text-error-foreground" },
+    info: { glyph: "•", text: "text-warning-foreground" },
+};
+
+const STEP_LABEL: Record<string, string> = {
+    recipient: "Recipient",
+    strict_targets: "Strict targets",
+    expiry: "Expiry",
+    preference: "Preference",
+    suppression: "Spam complaints",
+    broadcast_email: "Broadcast email",
+    provider: "Email provider",
+    contact: "Contact",
+    frequency_cap: "Frequency cap",
+    digest: "Digest",
+    quiet_hours: "Quiet hours",
+    quota: "Quota",
+};
+
+const RUNG_LABEL: Record<ExplainRung["source"], string> = {
+    recipient_exact: "Recipient, this topic",
+    recipient_any: "Recipient, any topic",
+    project_exact: "Catalog, this topic",
+    project_any: "Catalog, any topic",
+    default: "Default",
+};
+
+// ExplainPanel answers "why did (or didn't) this reach them" on demand. The
+// fan-out above says WHAT happened; this re-runs the checks to say why, and is
+// fetched only when asked because it is a dozen reads the page otherwise skips.
+//
+// ⚠️ The steps reflect the project NOW. When they disagree with what the
+// notification recorded, say so — that disagreement is usually the answer.
+export function ExplainPanel({
+    projectID,
+    notificationID,
+    mediums,
+}: {
+    projectID: string;
+    notificationID: number;
+    mediums: Medium[];
+}) {
+    const [medium, setMedium] = useState<Medium>();
+    const { data, isLoading, isError } = useExplainNotification(
+        projectID,
+        notificationID,
+        medium
+    );
+    const explanation = data?.data;
+
+    const recorded = explanation?.recorded?.status;
+    const recordedDelivered =
+        recorded === "delivered" ||
+        recorded === "sent" ||
+        recorded === "pending" ||
+        recorded === "deferred";
+    const changedSince =
+        explanation && recorded && recordedDelivered !== explanation.deliver;
+
+    return (
+        <div className="space-y-4 text-sm">
+            <div className="flex gap-2">
+                {mediums.map((m) => (
+                    <Button
+                        key={m}
+                        variant={m === medium ? "primary" : "secondary"}
+                        onClick={() => setMedium(m)}
+                    >
+                        Why {MEDIUM_LABEL[m]}?
+                    </Button>
+                ))}
+            </div>
+
+            {isLoading && <Loading />}
+            {isError && (
+                <ErrorMessage errorMsg="Could not explain this notification." />
+            )}
+
+            {explanation && (
+                <>
+                    <p className="text-text-primary">
+                        {explanation.deliver
+                            ? `Sent again now, it would reach them by ${MEDIUM_LABEL[medium!]}.`
+                            : `Sent again now, it would not reach them by ${MEDIUM_LABEL[medium!]}.`}
+                        {changedSince &&
+                            ` It recorded ${recorded} at the time, so something has changed since.`}
+                    </p>
+
+                    <ol className="space-y-3">
+                        {explanation.steps.map((step) => (
+                            <StepRow key={step.step} step={step} />
+                        ))}
+                    </ol>
+                </>
+            )}
+        </div>
+    );
+}
+
+function StepRow({ step }: { step: ExplainStep }) {
+    const style = RESULT_STYLE[step.result];
+
+    return (
+        <li className="flex gap-3">
+            <span aria-hidden className={`w-4 shrink-0 ${style.text}`}>
+                {style.glyph}
+            </span>
+            <div className="min-w-0 space-y-1">
+                <p>
+                    <span className="text-text-primary font-medium">
+                        {STEP_LABEL[step.step] ?? step.step}
+                    </span>
+                    {step.reason && (
+                        <span className="text-text-muted ml-2 font-mono text-xs">
+                            {step.reason}
+                        </span>
+                    )}
+                </p>
+                <p className="text-text-muted">{step.detail}</p>
+                {step.until && (
+                    <p className="text-text-muted text-xs">
+                        Until {formatDate(new Date(step.until), { time: true })}
+                    </p>
+                )}
+                {step.cascade && <Cascade rungs={step.cascade} />}
+            </div>
+        </li>
+    );
+}
+
+// Cascade lays the rungs out in the order they are consulted, so the one that
+// decided reads as where the walk stopped.
+function Cascade({ rungs }: { rungs: ExplainRung[] }) {
+    return (
+        <table className="mt-1 text-xs">
+            <tbody>
+                {rungs.map((rung) => (
+                    <tr
+                        key={rung.source}
+                        className={
+                            rung.decided
+                                ? "text-text-primary font-medium"
+                                : "text-text-muted"
+                        }
+                    >
+                        <td className="py-0.5 pr-4">
+                            {RUNG_LABEL[rung.source]}
+                        </td>
+                        <td className="py-0.5 pr-4">
+                            {!rung.consulted
+                                ? "not consulted"
+                                : rung.enabled === null
+                                  ? "—"
+                                  : rung.enabled
+                                    ? "on"
+                                    : "off"}
+                            {rung.mandatory && " · mandatory"}
+                        </td>
+                        <td className="py-0.5">{rung.decided && "decides"}</td>
+                    </tr>
+                ))}
+            </tbody>
+        </table>
+    );
+}
@@ -17,6 +17,9 @@ import { Link } from "@tanstack/react-router";
 import { ReactNode, useMemo } from "react";
 
 import { DeliveryTreeView } from "@/features/notification/components/delivery_tree";
+import { ExplainPanel } from "@/features/notification/detail/explain_panel";
+import { CancelBroadcastButton } from "@/features/notification/components/cancel_broadcast_modal";
+import { RetryBroadcastBatchesButton } from "@/features/notification/components/retry_broadcast_batches_button";
 import { StatusTag } from "@/components/status_tag";
 import {
     Verdict,
@@ -191,6 +194,7 @@ const NEVER_SENT_STATUSES = new Set<string>([
     "no_contact",
     "suppressed",
     "quota_exceeded",
+    "expired",
 ]);
 
 // EmailEventTimeline renders the provider webhook history for a direct send's
@@ -273,6 +277,8 @@ function DetailShell({
     treeLoading,
     treeError,
     extra,
+    why,
+    action,
     backKind,
 }: {
     title: string;
@@ -284,6 +290,10 @@ function DetailShell({
     treeLoading: boolean;
     treeError: boolean;
     extra?: { label: string; body: ReactNode };
+    /** The on-demand explanation, for a direct send. */
+    why?: ReactNode;
+    /** What can still be done to the send, beside its target. */
+    action?: ReactNode;
     /** Which list tab the breadcrumb returns to. */
     backKind: "direct" | "broadcast";
 }) {
@@ -327,9 +337,12 @@ function DetailShell({
 
             {/* The target identifies WHAT was sent, so it sits with the heading
                 rather than down in the details rail. */}
-            <p className="text-text-muted mt-4">
-                <Mono>{target}</Mono>
-            </p>
+            <div className="mt-4 flex items-center justify-between gap-4">
+                <p className="text-text-muted">
+                    <Mono>{target}</Mono>
+                </p>
+                {action}
+            </div>
 
             {verdict && (
                 <div className="border-border-subtle mt-4 mb-8 border-y py-5">
@@ -355,6 +368,13 @@ function DetailShell({
                         </section>
                     )}
 
+                    {why && (
+                        <section>
+                            <Eyebrow>Why</Eyebrow>
+                            {why}
+                        </section>
+                    )}
+
                     <section>
                         <Eyebrow>Payload</Eyebrow>
                         <PayloadBlock payload={payload} />
@@ -480,6 +500,18 @@ export function DirectNotificationDetail({
                       }
                     : undefined
             }
+            why={
+                <ExplainPanel
+                    projectID={projectID}
+                    notificationID={notification.id}
+                    mediums={[
+                        ...(notification.payload != null
+                            ? (["in_app"] as const)
+                            : []),
+                        ...(emailDelivery ? (["email"] as const) : []),
+                    ]}
+                />
+            }
         />
     );
 }
@@ -539,6 +571,21 @@ export function BroadcastNotificationDetail({
             tree={treeQuery.data?.data}
             treeLoading={treeQuery.isLoading}
             treeError={treeQuery.isError}
+            action={
+                (broadcast.status === "scheduled" ||
+                    broadcast.status === "enqueued") && (
+                    <div className="flex items-center gap-2">
+                        <RetryBroadcastBatchesButton
+                            projectID={projectID}
+                            broadcast={broadcast}
+                        />
+                        <CancelBroadcastButton
+                            projectID={projectID}
+                            broadcast={broadcast}
+                        />
+                    </div>
+                )
+            }
         />
     );
 }
@@ -11,7 +11,9 @@ import { getRecipientsKey } from "@/features/recipient/recipient_hooks";
 import { notificationFiltersToParams } from "@/features/notification/notification_filters";
 import {
     Broadcast,
+    BroadcastBatch,
     DeliveryTree,
+    Explanation,
     ListBroadcastsPayload,
     ListBroadcastsResult,
     ListNotificationDeliveriesResult,
@@ -20,6 +22,7 @@ import {
     Notification,
     NotificationFilters,
     NotificationKindFilter,
+    RetryBroadcastBatchesResult,
     SendNotificationPayload,
     SendNotificationResult,
 } from "@/features/notification/notification_types";
@@ -65,6 +68,28 @@ export function useSendNotification(
     });
 }
 
+// usePreviewBroadcast is a dry run of useSendNotification's broadcast: same
+// payload, nothing sent, so nothing to invalidate.
+export function usePreviewBroadcast(
+    projectID: string,
+    options: AnyUseMutationOptions = {}
+) {
+    return useMutation<
+        APIRes<SendNotificationResult>,
+        unknown,
+        SendNotificationPayload
+    >({
+        mutationFn: (payload) => {
+            return client.post(
+                API_ROUTES.project.notifications.send(projectID),
+                payload,
+                { params: { dry_run: true } }
+            );
+        },
+        ...options,
+    });
+}
+
 export interface UseNotificationsParams {
     kind: NotificationKindFilter;
     page: number;
@@ -153,6 +178,29 @@ export function useNotificationDeliveries(
     });
 }
 
+// useExplainNotification fetches the decision log for one medium of a
+// notification. Only on demand: it re-runs every check the send made, which is
+// a handful of queries the page does not need until someone asks why.
+export function useExplainNotification(
+    projectID: string,
+    notificationID: number,
+    medium: "in_app" | "email" | undefined
+) {
+    return useQuery({
+        queryKey: ["useExplainNotification", projectID, notificationID, medium],
+        queryFn: () =>
+            client.get(
+                API_ROUTES.project.notifications.explain(
+                    projectID,
+                    notificationID
+                ),
+                { params: { medium } }
+            ),
+        select: (res) => res.data as APIRes<Explanation>,
+        enabled: !!projectID && !!notificationID && !!medium,
+    });
+}
+
 export function useBroadcasts(projectID: string, page: number, limit: number) {
     return useQuery({
         queryKey: ["useGetBroadcasts", projectID, page, limit],
@@ -230,3 +278,98 @@ export function useBroadcast(projectID: string, broadcastID: string) {
         enabled: !!projectID && !!broadcastID,
     });
 }
+
+// useCancelBroadcast stops a broadcast that is scheduled or still fanning out.
+// Batches that already ran keep their notifications; the rest never run and are
+// refunded.
+export function useCancelBroadcast(
+    projectID: string,
+    options: AnyUseMutationOptions = {}
+) {
+    const { onSuccess, ...rest } = options;
+    const queryClient = useQueryClient();
+
+    return useMutation<APIRes<Broadcast>, unknown, { broadcastID: number }>({
+        mutationFn: ({ broadcastID }) => {
+            return client.post(
+                API_ROUTES.project.broadcasts.cancel(projectID, broadcastID)
+            );
+        },
+        onSuccess: (...args) => {
+            for (const key of [
+                "useBroadcast",
+                "useBroadcastDeliveryTree",
+                "useGetBroadcasts",
+            ]) {
+                queryClient.invalidateQueries({
+                    predicate: (query) =>
+                        Array.isArray(query.queryKey) &&
+                        query.queryKey[0] === key,
+                });
+            }
+            onSuccess?.(...args);
+        },
+        ...rest,
+    });
+}
+
+// useFailedBroadcastBatches lists the batches keeping a broadcast from
+// completing. Only worth asking while the broadcast is still sending; anything
+// final has none.
+export function useFailedBroadcastBatches(
+    projectID: string,
+    broadcastID: number,
+    enabled = true
+) {
+    return useQuery({
+        queryKey: ["useFailedBroadcastBatches", projectID, broadcastID],
+        queryFn: () =>
+            client.get(
+                API_ROUTES.project.broadcasts.failedBatches(
+                    projectID,
+                    broadcastID
+                )
+            ),
+        select: (res) => res.data as APIRes<BroadcastBatch[]>,
+        enabled: enabled && !!projectID && !!broadcastID,
+    });
+}
+
+// useRetryBroadcastBatches re-enqueues a broadcast's failed batches.
+export function useRetryBroadcastBatches(
+    projectID: string,
+    options: AnyUseMutationOptions = {}
+) {
+    const { onSuccess, ...rest } = options;
+    const queryClient = useQueryClient();
+
+    return useMutation<
+        APIRes<RetryBroadcastBatchesResult>,
+        unknown,
+        { broadcastID: number }
+    >({
+        mutationFn: ({ broadcastID }) => {
+            return client.post(
+                API_ROUTES.project.broadcasts.retryBatches(
+                    projectID,
+                    broadcastID
+                )
+            );
+        },
+        onSuccess: (...args) => {
+            for (const key of [
+                "useBroadcast",
+                "useBroadcastDeliveryTree",
+                "useFailedBroadcastBatches",
+            ]) {
+                queryClient.invalidateQueries({
+                    predicate: (query) =>
+                        Array.isArray(query.queryKey) &&
+                        query.queryKey[0] === key,
+                });
+            }
+            onSuccess?.(...args);
+        },
+        ...rest,
+    });
+}
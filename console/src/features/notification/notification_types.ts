@@ -24,6 +24,20 @@ export const NOTIFICATION_STATUSES = [
     // hidden from the recipient's own feed and unread count, but deliberately
     // VISIBLE in the console, which is the operator's view.
     "not_requested",
+    // A send made with `send_at`, waiting for it. Hidden from the recipient
+    // until it fires; then it moves on like any other send.
+    "scheduled",
+    // A scheduled send withdrawn before it fired.
+    "cancelled",
+    // Delivered, then replaced by a newer send under the same collapse_key.
+    // Hidden from the recipient; the newer notification carries the content.
+    "collapsed",
+    // Turned away by the catalog entry's frequency cap. Never delivered, so
+    // hidden from the recipient like a mute.
+    "throttled",
+    // Pulled back by the sender after it went out. Hidden from the recipient,
+    // read or not.
+    "recalled",
 ] as const;
 
 export type NotificationStatus = (typeof NOTIFICATION_STATUSES)[number];
@@ -32,7 +46,11 @@ export type BroadcastStatus =
     | "enqueued"
     | "completed"
     | "quota_exceeded"
-    | "failed";
+    | "failed"
+    | "scheduled"
+    | "cancelled"
+    // Pulled back after it started going out; its notifications are recalled too.
+    | "recalled";
 
 // Per-(notification, medium) delivery status. Email is the only non-in_app
 // medium written today. `pending → sending → sent` are set by the worker;
@@ -49,7 +67,17 @@ export type DeliveryStatus =
     | "no_contact"
     | "suppressed"
     | "quota_exceeded"
-    | "rejected";
+    | "rejected"
+    // Not sent: the notification's expires_at passed first.
+    | "expired"
+    // Sent as part of a digest email rather than on its own.
+    | "digested"
+    // Held until the recipient's quiet hours end.
+    | "deferred"
+    // Not sent: over the catalog entry's frequency cap.
+    | "throttled"
+    // Not sent: the notification was recalled first.
+    | "recalled";
 
 // The email-medium delivery summary on a listed notification. Carries every
 // BOUNDED delivery column, so the list can explain an outcome inline and the
@@ -105,6 +133,10 @@ export interface NotificationDeliveryDetail {
     complained_at?: string;
     opened_at?: string;
     clicked_at?: string;
+    // Set when the email went out inside a digest. The message id is the
+    // digest's, shared with every other delivery folded into it.
+    digest_id?: number;
+    digest_message_id?: string;
     events: DeliveryEvent[];
     created_at: string;
     updated_at: string;
@@ -126,6 +158,17 @@ export interface Notification {
     state: NotificationState;
     status: NotificationStatus;
     completed_at?: string;
+    // Present when the send was scheduled, before and after it fires.
+    send_at?: string;
+    // Present when the send set one. Past it, the recipient no longer sees it.
+    expires_at?: string;
+    // Present when the send set one. See the `collapsed` status.
+    collapse_key?: string;
+    // The lane the send's work ran in. A broadcast's notifications carry the
+    // broadcast's.
+    priority: Priority;
+    // Bumped by every payload edit (PATCH). Starts at 1.
+    version: number;
     created_at: string;
     updated_at: string;
     // Present only when the send included an email block. Lets the list show
@@ -139,10 +182,67 @@ export interface Broadcast {
     target: Target;
     status: BroadcastStatus;
     completed_at?: string;
+    send_at?: string;
+    expires_at?: string;
+    // `bulk` unless the send named another.
+    priority: Priority;
+    version: number;
+    // Localized content keyed by BCP 47 tag; absent when the send had none.
+    variants?: Record<string, ContentVariant>;
+    // The segment the broadcast was narrowed to; absent when it went to everyone.
+    audience?: AudienceSegment;
+    // Number of ids the broadcast was sent to; absent on a target broadcast,
+    // which reaches everyone its preferences allow.
+    recipient_list_size?: number;
+    created_at: string;
+    updated_at: string;
+}
+
+// One slice of a broadcast's fan-out. Listed only when it failed; the recipient
+// ids stay server-side.
+export interface BroadcastBatch {
+    id: number;
+    recipients: number;
+    status: "enqueued" | "success" | "failed" | "recalled" | "cancelled";
+    // Asynq attempt within the current round; reset by a retry.
+    attempt: number;
+    // Times an operator has retried it.
+    retries: number;
     created_at: string;
     updated_at: string;
 }
 
+export interface RetryBroadcastBatchesResult {
+    broadcast: Broadcast;
+    batches: BroadcastBatch[];
+}
+
+// A broadcast's audience filter. Conditions on one node must all hold; `all`
+// and `any` combine child nodes.
+export interface AudienceSegment {
+    all?: AudienceSegment[];
+    any?: AudienceSegment[];
+    created_after?: string;
+    created_before?: string;
+    has_contact?: string;
+    lacks_contact?: string;
+    read_within_days?: number;
+    external_id_prefix?: string;
+    tag?: string;
+    attribute?: string;
+}
+
+// One locale's content on a broadcast. A block it omits falls back along the
+// locale chain to the broadcast's own.
+export interface ContentVariant {
+    payload?: unknown;
+    email?: { subject: string; html: string; text: string };
+}
+
+// Mirrors enum.Priority in the API: which weighted Asynq queue a send's tasks
+// are routed to.
+export type Priority = "critical" | "normal" | "bulk";
+
 export interface Target {
     channel: string;
     topic: string;
@@ -168,6 +268,11 @@ export interface SendNotificationPayload {
     // only); absent ⇒ no email. Gated by catalog + per-medium preference + a
     // primary email contact.
     email?: EmailContent;
+    // Broadcast only: send to these recipient ids instead of everyone the
+    // target reaches. Still filtered by preferences.
+    recipient_ids?: string[];
+    // Create a bare recipient for each unknown id rather than report it.
+    create_missing_recipients?: boolean;
 }
 
 export interface NotificationDelivery {
@@ -191,10 +296,92 @@ export interface SendNotificationResult {
     // Per-medium delivery outcomes for a direct send (email). A partial-medium
     // failure never rejects the send — the outcome is reported here.
     deliveries?: NotificationDelivery[];
+    // List-based broadcasts: ids with no recipient, and the ones created for
+    // them when create_missing_recipients was set. At most one is present.
+    unknown_recipient_ids?: string[];
+    created_recipient_ids?: string[];
+    // Set instead of broadcast on a dry run (`?dry_run=true`).
+    preview?: BroadcastPreview;
+}
+
+// BroadcastPreview is what a broadcast would do if it were sent now. Nothing is
+// written producing it, so it is a forecast: preferences, recipients and usage
+// can all move before the real send.
+export interface BroadcastPreview {
+    // The in-app breakdown the broadcast would freeze at fan-out.
+    audience: DeliveryTreeAudience;
+    email?: BroadcastPreviewEmail;
+    // In-app audience plus anyone reached by email alone. What it is billed.
+    recipients: number;
+    usage: UsageEstimate;
+    sample_recipient_ids: string[];
+    unknown_recipient_ids?: string[];
+}
+
+export interface BroadcastPreviewEmail {
+    eligible: number;
+    // The project's max_broadcast_recipients_for_email; 0 without email set up.
+    cap: number;
+    blocked_reason?: "recipient_cap_exceeded" | "provider_not_configured";
+}
+
+export interface UsageEstimate {
+    metric: string;
+    amount: number;
+    used: number;
+    // null on a plan with no limit.
+    limit: number | null;
+    would_exceed: boolean;
+}
+
+// Explanation is the API's decision log for one recipient, target and medium:
+// every check a send makes, in order, with what each found. Worked out against
+// the project as it is now; `recorded` is what the notification actually did.
+export interface Explanation {
+    recipient_id: string;
+    target: Target;
+    medium: "in_app" | "email";
+    kind: "direct" | "broadcast";
+    notification_id?: number;
+    deliver: boolean;
+    // The first failing step's reason. Absent when deliver is true.
+    reason?: string;
+    steps: ExplainStep[];
+    recorded?: { status?: string; failure_reason?: string | null };
+}
+
+export type ExplainResult = "pass" | "fail" | "info";
+
+export interface ExplainStep {
+    step: string;
+    result: ExplainResult;
+    reason?: string;
+    detail: string;
+    cascade?: ExplainRung[];
+    contact?: { id: number; address: string; verified: boolean };
+    frequency_cap?: { limit: number; window: string };
+    usage?: UsageEstimate;
+    until?: string;
+    complained_at?: string;
+}
+
+export interface ExplainRung {
+    source:
+        | "recipient_exact"
+        | "recipient_any"
+        | "project_exact"
+        | "project_any"
+        | "default";
+    preference_id: number | null;
+    // null when the rung matched no rule.
+    enabled: boolean | null;
+    mandatory: boolean;
+    consulted: boolean;
+    decided: boolean;
 }
 
 // The delivery statuses an email can actually reach in v1. The API validates
-// against the full notification_delivery CHECK (12 values), but four of those
+// against the full notification_delivery CHECK (16 values), but four of those
 // — sending / suppressed / quota_exceeded / rejected — are reserved and never
 // written, so offering them as filters would imply data that cannot exist.
 // The console offers what can occur; the API keeps accepting what is legal.
@@ -207,6 +394,11 @@ export const EMAIL_DELIVERY_FILTER_STATUSES = [
     "failed",
     "muted",
     "no_contact",
+    "expired",
+    "digested",
+    "deferred",
+    "throttled",
+    "recalled",
 ] as const;
 
 // The email filter folds the medium and delivery-status dimensions into one
@@ -297,7 +489,10 @@ export type DeliveryOutcome =
     | "succeeded"
     | "suppressed"
     | "failed"
-    | "not_requested";
+    | "not_requested"
+    // Waiting for send_at. Not pending: pending that never drains means a
+    // stalled worker, and this is supposed to sit still.
+    | "scheduled";
 
 // DeliveryTreeAudience is the recipient breakdown FROZEN when the broadcast
 // fanned out. Absent on broadcasts sent before the counts existed, and on ones
@@ -311,6 +506,12 @@ export interface DeliveryTreeAudience {
     // disabled one). A config mistake, and the usual reason a broadcast reaches
     // nobody.
     excluded_not_cataloged: number;
+    // Outside the broadcast's `audience` segment. Counted first, so the two
+    // above only describe recipients the segment kept.
+    excluded_segment: number;
+    // On the broadcast's recipient list, but no such recipient existed when it
+    // fanned out. Always 0 on a target broadcast.
+    excluded_unknown: number;
     // ⚠️ false for broadcasts, and the UI must respect it: excluded recipients
     // are filtered out before any row is written, so there is nothing to drill
     // into. On a direct send the same case produces a real `muted` row you CAN
@@ -330,11 +531,18 @@ export interface DeliveryTreeMedium {
     // Still in flight. Non-zero long after the send is the signature of a
     // stalled worker.
     pending: number;
+    // Waiting for send_at — expected to sit still, so counted apart.
+    scheduled: number;
+    // Pulled back by the sender. Already inside outcomes.suppressed; counted
+    // apart so the tree can say how much of a send was recalled.
+    recalled: number;
 }
 
 export interface DeliveryTree {
     kind: "direct" | "broadcast";
     target: Target;
+    // Set while the send is waiting for its send_at.
+    scheduled_for?: string;
     audience?: DeliveryTreeAudience;
     mediums: DeliveryTreeMedium[];
 }
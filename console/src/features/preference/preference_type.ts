@@ -21,6 +21,13 @@ export function mediumLabel(medium: string): string {
     return PREFERENCE_MEDIUM_LABELS[medium as PreferenceMedium] ?? medium;
 }
 
+export type DigestWindow = "hourly" | "daily";
+
+export interface FrequencyCap {
+    limit: number;
+    window: "minute" | "hour" | "day";
+}
+
 export interface ProjectPreference {
     id: number;
     target: Target;
@@ -36,6 +43,21 @@ export interface ProjectPreference {
      * applies, so it remains the project's way to stop sending.
      */
     mandatory: boolean;
+    /**
+     * Email entries only: batch this entry's emails per recipient into one
+     * email per window. null sends each email immediately.
+     */
+    digest: DigestWindow | null;
+    /**
+     * Email entries only: send even inside a recipient's quiet hours. Mandatory
+     * entries always do.
+     */
+    bypass_quiet_hours: boolean;
+    /**
+     * At most `limit` sends of this (target, medium) per recipient per window;
+     * sends over it are recorded as `throttled`. null when uncapped.
+     */
+    frequency_cap: FrequencyCap | null;
     created_at: string;
     updated_at: string;
 
@@ -53,6 +75,12 @@ export interface CreateProjectPreferencePayload {
     event: string | null;
     topic: string | null;
     medium: PreferenceMedium;
+    /** Email entries only; omit to send immediately. */
+    digest?: DigestWindow;
+    /** Email entries only; defaults to false. */
+    bypass_quiet_hours?: boolean;
+    /** Omit for no cap. */
+    frequency_cap?: FrequencyCap;
 }
 
 // Only the mutable fields of a catalog entry. The natural key (channel, topic,
@@ -68,6 +96,12 @@ export interface UpdateProjectPreferencePayload {
      * edit modal now has a control for it, so it always sends a value.
      */
     mandatory?: boolean;
+    /** Omit to keep the current window; "off" stops digesting. */
+    digest?: DigestWindow | "off";
+    /** Omit to keep the current value. */
+    bypass_quiet_hours?: boolean;
+    /** Omit to keep the current cap; `{ window: "off" }` removes it. */
+    frequency_cap?: FrequencyCap | { window: "off" };
 }
 
 export interface RecipientPreference {
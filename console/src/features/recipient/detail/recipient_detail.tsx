@@ -21,7 +21,11 @@ import { useGetRecipient } from "@/features/recipient/recipient_hooks";
 import { RecipientContactsPanel } from "@/features/recipient/detail/recipient_contacts_panel";
 import { RecipientNotificationsPanel } from "@/features/recipient/detail/recipient_notifications_panel";
 import { RecipientPreferencesPanel } from "@/features/recipient/detail/recipient_preferences_panel";
-import { RecipientListItem } from "@/features/recipient/recipient_types";
+import { RecipientTags } from "@/features/recipient/recipient_tags";
+import {
+    RecipientAttributeValue,
+    RecipientListItem,
+} from "@/features/recipient/recipient_types";
 
 export const RECIPIENT_TABS = [
     "notifications",
@@ -147,6 +151,10 @@ function SummaryStrip({ recipient }: { recipient: RecipientListItem }) {
                 )}
             </Fact>
 
+            <Fact label="Tags">
+                <RecipientTags tags={recipient.tags} />
+            </Fact>
+
             <Fact label="Created">
                 {formatDate(new Date(recipient.created_at), { time: true })}
             </Fact>
@@ -167,6 +175,39 @@ function SummaryStrip({ recipient }: { recipient: RecipientListItem }) {
                     </Tooltip>
                 </span>
             </Fact>
+
+            <AttributeList attributes={recipient.attributes} />
+        </div>
+    );
+}
+
+/**
+ * The customer's own attributes, on their own line of the strip. Values render
+ * as the JSON they were sent as, so the string "12" and the number 12 don't
+ * look the same when someone is debugging what their sync wrote.
+ */
+function AttributeList({
+    attributes,
+}: {
+    attributes: Record<string, RecipientAttributeValue>;
+}) {
+    const entries = Object.entries(attributes).sort(([a], [b]) =>
+        a.localeCompare(b)
+    );
+
+    return (
+        <div className="flex-x w-full flex-wrap gap-x-4! gap-y-1!">
+            <span className="text-foreground-muted text-xs">Attributes</span>
+            {entries.length === 0 ? (
+                <span className="text-foreground-muted">—</span>
+            ) : (
+                entries.map(([key, value]) => (
+                    <span key={key} className="select-text! font-mono text-xs">
+                        <span className="text-foreground-muted">{key}</span>{" "}
+                        {JSON.stringify(value)}
+                    </span>
+                ))
+            )}
         </div>
     );
 }
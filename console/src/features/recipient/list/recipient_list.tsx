@@ -37,10 +37,15 @@ import {
 } from "@/features/recipient/recipient_hooks";
 import { CreateRecipientModal } from "@/features/recipient/list/create_recipient_modal";
 import { DEFAULT_RECIPIENT_TAB } from "@/features/recipient/detail/recipient_detail";
-import { RecipientListItem } from "@/features/recipient/recipient_types";
+import {
+    RecipientFilters,
+    RecipientListItem,
+} from "@/features/recipient/recipient_types";
 import { ConfirmDialog } from "@/components/confirm_dialog";
+import { DebouncedInput } from "@/components/debounced_input";
 import { EditRecipientModal } from "@/features/recipient/list/edit_recipient_modal";
 import { RecipientLink } from "@/features/recipient/recipient_link";
+import { RecipientTags } from "@/features/recipient/recipient_tags";
 
 export function RecipientList() {
     useDocumentTitle("Recipients  • Bodhveda");
@@ -53,12 +58,39 @@ export function RecipientList() {
         sorting: [],
     });
 
+    // The filter inputs' text, kept as typed; the request filters derive from it.
+    // Round-tripping through the parsed lists would rewrite "beta,vip" as
+    // "beta, vip" under someone's caret.
+    const [filterText, setFilterText] = useState<RecipientFilterText>({
+        tags: "",
+        attributes: "",
+    });
+
+    const filters = useMemo<RecipientFilters>(
+        () => ({
+            tag: splitList(filterText.tags),
+            attribute: splitList(filterText.attributes),
+        }),
+        [filterText]
+    );
+
     const { data, isFetching, isLoading, isError } = useGetRecipients(
         id,
         tableState.pagination.pageIndex + 1,
-        tableState.pagination.pageSize
+        tableState.pagination.pageSize,
+        filters
     );
 
+    // A new filter is a new result set; staying on page 4 of the old one would
+    // usually show nothing.
+    const handleFilterTextChange = (next: RecipientFilterText) => {
+        setFilterText(next);
+        setTableState((s) => ({
+            ...s,
+            pagination: { ...s.pagination, pageIndex: 0 },
+        }));
+    };
+
     const content = useMemo(() => {
         if (isError) {
             return <ErrorMessage errorMsg="Error loading recipients" />;
@@ -94,7 +126,12 @@ export function RecipientList() {
                 {isFetching && <Loading />}
             </PageHeading>
 
-            <div className="flex justify-end mb-4">
+            <div className="flex items-center justify-between gap-2 mb-4">
+                <RecipientFilterBar
+                    value={filterText}
+                    onChange={handleFilterTextChange}
+                />
+
                 <CreateRecipientModal
                     renderTrigger={() => (
                         <Button>
@@ -110,6 +147,51 @@ export function RecipientList() {
     );
 }
 
+interface RecipientFilterText {
+    tags: string;
+    attributes: string;
+}
+
+/**
+ * Tag and attribute filters. Both take a comma-separated list and a recipient
+ * must match every entry: "beta, vip" is tagged both, "plan:pro" has that
+ * attribute.
+ */
+function RecipientFilterBar({
+    value,
+    onChange,
+}: {
+    value: RecipientFilterText;
+    onChange: (next: RecipientFilterText) => void;
+}) {
+    return (
+        <div className="flex flex-wrap items-center gap-2">
+            <DebouncedInput
+                value={value.tags}
+                onDebouncedChange={(tags) => onChange({ ...value, tags })}
+                placeholder="Tags, e.g. beta, vip"
+                className="h-8! w-48!"
+            />
+            <DebouncedInput
+                value={value.attributes}
+                onDebouncedChange={(attributes) =>
+                    onChange({ ...value, attributes })
+                }
+                placeholder="Attributes, e.g. plan:pro"
+                className="h-8! w-56!"
+            />
+        </div>
+    );
+}
+
+function splitList(value: string): string[] | undefined {
+    const items = value
+        .split(",")
+        .map((s) => s.trim())
+        .filter(Boolean);
+    return items.length > 0 ? items : undefined;
+}
+
 function ActionCell({ recipient }: { recipient: RecipientListItem }) {
     const [dropdownOpen, setDropdownOpen] = useState(false);
     const [editOpen, setEditOpen] = useState(false);
@@ -272,6 +354,11 @@ const columns: ColumnDef<RecipientListItem>[] = [
             </div>
         ),
     },
+    {
+        accessorKey: "tags",
+        header: () => <DataTableColumnHeader title="Tags" />,
+        cell: ({ row }) => <RecipientTags tags={row.original.tags} />,
+    },
     {
         accessorKey: "created_at",
         header: () => <DataTableColumnHeader title="Created" />,
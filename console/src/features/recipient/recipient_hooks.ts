@@ -11,19 +11,24 @@ import {
     CreateRecipientPayload,
     EditRecipientPayload,
     ListRecipientsResult,
+    RecipientFilters,
     RecipientListItem,
 } from "@/features/recipient/recipient_types";
 
 export function useGetRecipients(
     projectID: string,
     page: number,
-    limit: number
+    limit: number,
+    filters: RecipientFilters = {}
 ) {
     return useQuery({
-        queryKey: getRecipientsKey(projectID, page, limit),
+        queryKey: [...getRecipientsKey(projectID, page, limit), filters],
         queryFn: () =>
             client.get(API_ROUTES.project.recipients.list(projectID), {
-                params: { page, limit },
+                params: { page, limit, ...filters },
+                // Repeat array params (`tag=a&tag=b`); axios' default `tag[]=`
+                // is not what the API decodes.
+                paramsSerializer: { indexes: null },
             }),
         select: (res) => res.data as APIRes<ListRecipientsResult>,
         placeholderData: keepPreviousData,
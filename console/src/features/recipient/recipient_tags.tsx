@@ -0,0 +1,18 @@
+import { Tag } from "netra";
+
+/** A recipient's tags as small chips, or a muted dash when there are none. */
+export function RecipientTags({ tags }: { tags: string[] }) {
+    if (tags.length === 0) {
+        return <span className="text-foreground-muted">—</span>;
+    }
+
+    return (
+        <span className="flex flex-wrap gap-1">
+            {tags.map((tag) => (
+                <Tag key={tag} variant="muted" size="small">
+                    {tag}
+                </Tag>
+            ))}
+        </span>
+    );
+}
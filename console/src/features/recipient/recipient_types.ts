@@ -1,16 +1,48 @@
 export interface Recipient {
     id: string;
     name: string;
+    // BCP 47 tag picking a send's content variant; null gets the default.
+    locale: string | null;
+    // IANA zone; null reads as UTC.
+    timezone: string | null;
+    // Daily window ("HH:MM", recipient-local) during which email is deferred.
+    // Both set or both null; may cross midnight.
+    quiet_hours_start: string | null;
+    quiet_hours_end: string | null;
+    // The customer's own fields: scalars or arrays of scalars. Always present.
+    attributes: Record<string, RecipientAttributeValue>;
+    // Lowercase, sorted, no duplicates.
+    tags: string[];
     created_at: string;
 }
 
+export type RecipientAttributeScalar = string | number | boolean;
+export type RecipientAttributeValue =
+    | RecipientAttributeScalar
+    | RecipientAttributeScalar[];
+
 export interface CreateRecipientPayload {
     id: string;
     name: string | null;
+    locale?: string;
+    timezone?: string;
+    quiet_hours_start?: string;
+    quiet_hours_end?: string;
+    attributes?: Record<string, RecipientAttributeValue>;
+    tags?: string[];
 }
 
 export interface EditRecipientPayload {
     name: string | null;
+    // Omit to keep; "" clears. The quiet-hours pair must be sent together.
+    locale?: string;
+    timezone?: string;
+    quiet_hours_start?: string;
+    quiet_hours_end?: string;
+    // Merged by key; null removes a key.
+    attributes?: Record<string, RecipientAttributeValue | null>;
+    // Replaces the set; [] clears it.
+    tags?: string[];
 }
 
 export interface RecipientListItem extends Recipient {
@@ -23,6 +55,13 @@ export interface ListRecipientsPayload {
     limit?: number;
 }
 
+/** List filters; a recipient must match all of them. */
+export interface RecipientFilters {
+    tag?: string[];
+    // "key:value", e.g. "plan:pro".
+    attribute?: string[];
+}
+
 export interface ListRecipientsResult {
     recipients: RecipientListItem[];
     pagination: {
@@ -32,6 +32,14 @@ export const API_ROUTES = {
             // audience counts plus the per-status rollup. Console-only.
             tree: (projectId: string | number, broadcastId: number) =>
                 `/console/projects/${projectId}/broadcasts/${broadcastId}/tree`,
+            // Stops a broadcast that is scheduled or still fanning out.
+            cancel: (projectId: string | number, broadcastId: number) =>
+                `/console/projects/${projectId}/broadcasts/${broadcastId}/cancel`,
+            // The batches keeping a broadcast from completing, and re-enqueuing them.
+            failedBatches: (projectId: string | number, broadcastId: number) =>
+                `/console/projects/${projectId}/broadcasts/${broadcastId}/batches/failed`,
+            retryBatches: (projectId: string | number, broadcastId: number) =>
+                `/console/projects/${projectId}/broadcasts/${broadcastId}/batches/retry`,
         },
 
         // Home-page analytics: time-series + per-target/medium breakdowns over a
@@ -60,6 +68,13 @@ export const API_ROUTES = {
                 notificationId: string | number
             ) =>
                 `/console/projects/${projectId}/notifications/${notificationId}/deliveries`,
+            // Why this notification did or did not reach its recipient, check by
+            // check. Read-only; the frequency cap is reported, never counted.
+            explain: (
+                projectId: string | number,
+                notificationId: string | number
+            ) =>
+                `/console/projects/${projectId}/notifications/${notificationId}/explain`,
         },
 
         recipients: {
@@ -48,10 +48,26 @@ export function statusToString(
             return "Suppressed";
         case "rejected":
             return "Rejected";
+        case "expired":
+            return "Expired";
+        case "digested":
+            return "Digested";
+        case "deferred":
+            return "Deferred";
         // Reads as a statement about the SEND, not about a delivery that went
         // wrong — "Not requested" would be ambiguous next to statuses like Muted.
         case "not_requested":
             return "No in-app";
+        case "scheduled":
+            return "Scheduled";
+        case "cancelled":
+            return "Cancelled";
+        case "collapsed":
+            return "Collapsed";
+        case "throttled":
+            return "Throttled";
+        case "recalled":
+            return "Recalled";
         default:
             return status;
     }
@@ -0,0 +1,32 @@
+-- Recipient-bound tokens, and the per-project switch that makes them mandatory.
+--
+-- The recipient sub-routes (/recipients/{id}/notifications, /preferences,
+-- /contacts) have always been reachable with a recipient-scope API key — the key
+-- a customer ships to the browser — and nothing tied that key to a recipient.
+-- The id in the path was whatever the caller typed, so any visitor holding the
+-- public key could read, mark and delete any other recipient's feed in the same
+-- project (todo.md, "recipient sub-routes are not recipient-scoped").
+--
+-- The fix is a short-lived signed token minted by the customer's server with its
+-- full-scope key, carrying (project, recipient, expiry). The client presents it
+-- alongside the public key; the recipient routes verify it and refuse a path id
+-- that is not the one the token was minted for.
+--
+-- ⚠️ DEFAULT false. Flipping every existing project to "token required" would
+-- break every deployed notification center on the next request, since none of
+-- them mint tokens yet. Projects opt in once their server is minting; a token
+-- that IS presented is verified whichever way this is set, so the rollout order
+-- is: start sending tokens -> confirm -> turn this on. Full-scope keys are never
+-- asked for one — they can already act as anyone.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE project
+    ADD COLUMN IF NOT EXISTS require_recipient_token BOOLEAN NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE project
+    DROP COLUMN IF EXISTS require_recipient_token;
+-- +goose StatementEnd
@@ -0,0 +1,46 @@
+-- Idempotency keys for POST /notifications/send.
+--
+-- Callers retry a send when the request times out, and a timeout says nothing
+-- about whether the first attempt landed. Every retry used to INSERT a fresh
+-- notification (or broadcast) and enqueue a fresh delivery task, so one flaky
+-- network hop became two inbox items and two emails for the same event.
+--
+-- A caller that sends an `Idempotency-Key` header gets at-most-once per key: the
+-- first request claims (project_id, key) here, and a repeat within the retention
+-- window is answered from `response` — the SendNotificationResult the first
+-- request returned — without touching the send path at all.
+--
+-- `request_hash` is a fingerprint of the normalized body. A key reused with a
+-- DIFFERENT body is a caller bug (usually a key derived from something too
+-- coarse), and replaying the first response to it would silently drop the second
+-- send, so that case is a 409 instead.
+--
+-- `response` is NULL while the claiming request is still in flight. A claim left
+-- NULL for long (the API process died between the claim and the INSERT) is
+-- treated as abandoned and may be re-claimed — see IdempotencyKeyRepo.Claim.
+--
+-- Same shape as webhook_event (the inbound-webhook ledger): a unique key, a
+-- created_at index for the retention sweep, cascade on project delete.
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS idempotency_key (
+    id           BIGSERIAL PRIMARY KEY,
+    project_id   INT NOT NULL REFERENCES project(id) ON DELETE CASCADE,
+    key          TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    response     JSONB,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    UNIQUE (project_id, key)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS ix_idempotency_key_created_at ON idempotency_key(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_key;
+-- +goose StatementEnd
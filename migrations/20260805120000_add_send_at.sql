@@ -0,0 +1,37 @@
+-- Scheduled sends: `send_at` on direct notifications and broadcasts.
+--
+-- A send carrying `send_at` is persisted immediately — so the caller gets a real
+-- id back, can read it, and can cancel it — but in status `scheduled` rather
+-- than `enqueued`, and its task is handed to Asynq with ProcessAt instead of
+-- being runnable right away. When the task fires it moves the row out of
+-- `scheduled` and proceeds exactly like an immediate send.
+--
+-- Cancelling (DELETE .../schedule) flips `scheduled` -> `cancelled` with a
+-- conditional UPDATE. The Asynq task is left in place and no-ops when it finds
+-- the row no longer `scheduled`: the row is the source of truth, so there is no
+-- window in which Redis and Postgres can disagree about whether it will send.
+--
+-- No status constraint to widen: neither notification.status nor
+-- broadcast.status carries a CHECK (unlike notification_delivery.status).
+--
+-- ⚠️ NOT indexed. A scheduled row is found by id (cancel, the worker) and
+-- otherwise only read back as a column on rows already selected by the existing
+-- indexes. An index on `notification` is paid on every send.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notification
+    ADD COLUMN IF NOT EXISTS send_at TIMESTAMPTZ;
+
+ALTER TABLE broadcast
+    ADD COLUMN IF NOT EXISTS send_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE broadcast
+    DROP COLUMN IF EXISTS send_at;
+
+ALTER TABLE notification
+    DROP COLUMN IF EXISTS send_at;
+-- +goose StatementEnd
@@ -0,0 +1,64 @@
+-- Notification expiry: an optional `expires_at` on a send.
+--
+-- "Meeting starts in 5 minutes" is worse than nothing an hour later, so a send
+-- may say when it stops being true. Past it, the row drops out of the
+-- recipient's feed and unread count (recipientFeedVisible), an email that has
+-- not gone out yet is not sent (its delivery row becomes `expired`), and the
+-- worker purges the row once a grace period has passed.
+--
+-- `broadcast.expires_at` is carried so the per-recipient rows a broadcast fans
+-- out into inherit it; the broadcast row itself is never purged.
+--
+-- The grace period is why the purge is not immediate: GET /notifications/{id}
+-- and the delivery tree keep answering "what happened to it?" for a while after
+-- the recipient stopped seeing it.
+--
+-- Indexed partially: only rows that carry an expiry are ever swept, and most
+-- rows carry none, so the index stays small and costs nothing on a plain send.
+--
+-- notification_delivery.status has a CHECK, so it is widened for `expired`.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notification
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+ALTER TABLE broadcast
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS ix_notification_expires_at
+    ON notification (expires_at)
+    WHERE expires_at IS NOT NULL;
+
+ALTER TABLE notification_delivery
+    DROP CONSTRAINT IF EXISTS notification_delivery_status_check;
+
+ALTER TABLE notification_delivery
+    ADD CONSTRAINT notification_delivery_status_check CHECK (status IN (
+        'pending','sending','sent','delivered','bounced','complained',
+        'failed','muted','no_contact','suppressed','quota_exceeded','rejected',
+        'expired'
+    ));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE notification_delivery SET status = 'suppressed' WHERE status = 'expired';
+
+ALTER TABLE notification_delivery
+    DROP CONSTRAINT IF EXISTS notification_delivery_status_check;
+
+ALTER TABLE notification_delivery
+    ADD CONSTRAINT notification_delivery_status_check CHECK (status IN (
+        'pending','sending','sent','delivered','bounced','complained',
+        'failed','muted','no_contact','suppressed','quota_exceeded','rejected'
+    ));
+
+DROP INDEX IF EXISTS ix_notification_expires_at;
+
+ALTER TABLE broadcast
+    DROP COLUMN IF EXISTS expires_at;
+
+ALTER TABLE notification
+    DROP COLUMN IF EXISTS expires_at;
+-- +goose StatementEnd
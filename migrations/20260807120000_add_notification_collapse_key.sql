@@ -0,0 +1,41 @@
+-- Collapse keys: an optional `collapse_key` on direct sends.
+--
+-- "Vikram commented on your post" fired ten times is ten rows in the inbox. With
+-- a collapse key, a newly delivered notification REPLACES any unread, delivered
+-- notification the recipient already has under the same key, so the inbox shows
+-- one item, carrying the latest payload, at the top.
+--
+-- The replacement is the NEW row, not the old one rewritten. The feed is ordered
+-- and paginated by id, so the only way to put the latest content at the top
+-- without a second sort key (and a second cursor) is for it to live on the
+-- newest id — which it already does, since the row is written on the request
+-- path before the worker runs. The rows it replaces move to status `collapsed`,
+-- which recipientFeedVisible hides; they stay readable by id, so the sender can
+-- still see each send.
+--
+-- Race-freedom is a per-(project, recipient, key) advisory lock held for the
+-- transaction that does the swap (see NotificationRepo.UpdateCollapsing). A
+-- partial unique index was considered and rejected: two deliveries racing would
+-- see one of them fail with a unique violation and retry, which orders them by
+-- who lost rather than by which send was newer.
+--
+-- The index is partial on the key being set, so sends that never use one (most
+-- of them) pay nothing for it.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notification
+    ADD COLUMN IF NOT EXISTS collapse_key TEXT;
+
+CREATE INDEX IF NOT EXISTS ix_notification_collapse_key
+    ON notification (project_id, recipient_external_id, collapse_key)
+    WHERE collapse_key IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ix_notification_collapse_key;
+
+ALTER TABLE notification
+    DROP COLUMN IF EXISTS collapse_key;
+-- +goose StatementEnd
@@ -0,0 +1,145 @@
+-- Email digests: a catalog entry can ask for its emails to be batched per
+-- recipient instead of sent one by one.
+--
+-- `preference.digest` ('hourly' | 'daily') on a project-level email row turns
+-- digesting on for that (target, email) entry. A send that would otherwise
+-- enqueue an email instead writes its delivery row as `pending` with a
+-- `digest_id` pointing at the recipient's OPEN digest for that window, and keeps
+-- the rendered subject/html/text in `email_digest_item` until the digest goes
+-- out. The first item opens the digest and fixes its `due_at`; later items join
+-- it. The partial unique index is what makes "the open digest" a single row, so
+-- concurrent sends converge on it with INSERT ... ON CONFLICT.
+--
+-- The worker claims due digests (open -> sending), sends one email wrapped in
+-- the project's `digest_html`, and flips every attached delivery to `digested`.
+-- The digest's `provider_message_id` lives on `email_digest`, not on the
+-- deliveries: ux_nd_provider_message allows one delivery per provider message,
+-- and a digest is by definition many. A delivery reaches "its" email through
+-- `digest_id`, and the digest's provider webhooks are applied to all of them.
+--
+-- ⚠️ digest only means anything on a project-level EMAIL row. In-app has
+-- nothing to batch, and a recipient row only toggles enabled. The CHECK enforces
+-- that rather than trusting callers.
+--
+-- notification_delivery.status has a CHECK, so it is widened for `digested`.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE preference
+    ADD COLUMN IF NOT EXISTS digest TEXT
+    CHECK (digest IN ('hourly', 'daily'));
+
+ALTER TABLE preference
+    DROP CONSTRAINT IF EXISTS ck_preference_digest_is_project_email;
+
+ALTER TABLE preference
+    ADD CONSTRAINT ck_preference_digest_is_project_email
+    CHECK (digest IS NULL OR (recipient_external_id IS NULL AND medium = 'email'));
+
+ALTER TABLE project_email_settings
+    ADD COLUMN IF NOT EXISTS digest_subject TEXT,
+    ADD COLUMN IF NOT EXISTS digest_html TEXT;
+
+CREATE TABLE IF NOT EXISTS email_digest (
+        id                      BIGSERIAL PRIMARY KEY,
+        project_id              INT NOT NULL REFERENCES project(id) ON DELETE CASCADE,
+        recipient_external_id   VARCHAR(255) NOT NULL,
+        digest_window           TEXT NOT NULL
+                                CHECK (digest_window IN ('hourly', 'daily')),
+        status                  TEXT NOT NULL
+                                CHECK (status IN ('open', 'sending', 'sent', 'failed', 'expired')),
+
+        contact_id              BIGINT REFERENCES recipient_contact(id) ON DELETE SET NULL,
+        address_snapshot        TEXT,
+
+        provider                TEXT,
+        provider_message_id     TEXT,
+        failure_reason          TEXT,
+        attempt                 INT NOT NULL DEFAULT 0,
+
+        due_at                  TIMESTAMPTZ NOT NULL,
+        sent_at                 TIMESTAMPTZ,
+        created_at              TIMESTAMPTZ NOT NULL DEFAULT now(),
+        updated_at              TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- At most one open digest per (recipient, window).
+CREATE UNIQUE INDEX IF NOT EXISTS ux_email_digest_open
+    ON email_digest (project_id, recipient_external_id, digest_window)
+    WHERE status = 'open';
+
+-- The flush job's scan.
+CREATE INDEX IF NOT EXISTS ix_email_digest_due
+    ON email_digest (due_at)
+    WHERE status = 'open';
+
+-- Provider webhooks for a digest email arrive carrying its message id.
+CREATE INDEX IF NOT EXISTS ix_email_digest_provider_message
+    ON email_digest (provider_message_id)
+    WHERE provider_message_id IS NOT NULL;
+
+ALTER TABLE notification_delivery
+    ADD COLUMN IF NOT EXISTS digest_id BIGINT REFERENCES email_digest(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS ix_nd_digest
+    ON notification_delivery (digest_id)
+    WHERE digest_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS email_digest_item (
+        delivery_id     BIGINT PRIMARY KEY REFERENCES notification_delivery(id) ON DELETE CASCADE,
+        subject         TEXT NOT NULL,
+        html            TEXT NOT NULL,
+        text            TEXT,
+        expires_at      TIMESTAMPTZ,
+        created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE notification_delivery
+    DROP CONSTRAINT IF EXISTS notification_delivery_status_check;
+
+ALTER TABLE notification_delivery
+    ADD CONSTRAINT notification_delivery_status_check CHECK (status IN (
+        'pending','sending','sent','delivered','bounced','complained',
+        'failed','muted','no_contact','suppressed','quota_exceeded','rejected',
+        'expired','digested'
+    ));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE notification_delivery SET status = 'sent' WHERE status = 'digested';
+
+-- Still waiting in an open digest: nothing will ever send them now.
+UPDATE notification_delivery
+SET status = 'failed', failure_reason = 'digest_removed'
+WHERE digest_id IS NOT NULL AND status = 'pending';
+
+ALTER TABLE notification_delivery
+    DROP CONSTRAINT IF EXISTS notification_delivery_status_check;
+
+ALTER TABLE notification_delivery
+    ADD CONSTRAINT notification_delivery_status_check CHECK (status IN (
+        'pending','sending','sent','delivered','bounced','complained',
+        'failed','muted','no_contact','suppressed','quota_exceeded','rejected',
+        'expired'
+    ));
+
+DROP TABLE IF EXISTS email_digest_item;
+
+DROP INDEX IF EXISTS ix_nd_digest;
+
+ALTER TABLE notification_delivery
+    DROP COLUMN IF EXISTS digest_id;
+
+DROP TABLE IF EXISTS email_digest;
+
+ALTER TABLE project_email_settings
+    DROP COLUMN IF EXISTS digest_html,
+    DROP COLUMN IF EXISTS digest_subject;
+
+ALTER TABLE preference
+    DROP CONSTRAINT IF EXISTS ck_preference_digest_is_project_email;
+
+ALTER TABLE preference
+    DROP COLUMN IF EXISTS digest;
+-- +goose StatementEnd
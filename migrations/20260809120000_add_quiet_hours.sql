@@ -0,0 +1,87 @@
+-- Quiet hours: a recipient can carry a timezone and a daily window during which
+-- email is held back rather than sent.
+--
+-- `recipient.timezone` is an IANA name ('Asia/Kolkata'); NULL reads as UTC. The
+-- window is two local wall-clock times, 'HH:MM', and may cross midnight
+-- (22:00 -> 07:00). Both ends or neither: a half-set window has no meaning.
+-- Times are TEXT rather than TIME so the API hands back exactly what it was
+-- given and the Go side never has to round-trip pgtype.Time.
+--
+-- `preference.bypass_quiet_hours` is the per-catalog-entry switch. It is FALSE
+-- by default (quiet hours apply), so a row written without it is the safe case,
+-- and only a project-level EMAIL row may set it — in-app has no delivery to
+-- hold, and a recipient row only toggles enabled. Mandatory entries bypass quiet
+-- hours whatever this says (a password reset that arrives at 7am is a broken
+-- password reset); that rule lives in the send path, not here.
+--
+-- An email that lands inside the window is recorded as `deferred` and its task
+-- is enqueued to run at the window's end, so notification_delivery.status is
+-- widened for it.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE recipient
+    ADD COLUMN IF NOT EXISTS timezone TEXT,
+    ADD COLUMN IF NOT EXISTS quiet_hours_start TEXT
+        CHECK (quiet_hours_start ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$'),
+    ADD COLUMN IF NOT EXISTS quiet_hours_end TEXT
+        CHECK (quiet_hours_end ~ '^([01][0-9]|2[0-3]):[0-5][0-9]$');
+
+ALTER TABLE recipient
+    DROP CONSTRAINT IF EXISTS ck_recipient_quiet_hours_pair;
+
+ALTER TABLE recipient
+    ADD CONSTRAINT ck_recipient_quiet_hours_pair
+    CHECK ((quiet_hours_start IS NULL) = (quiet_hours_end IS NULL));
+
+ALTER TABLE preference
+    ADD COLUMN IF NOT EXISTS bypass_quiet_hours BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE preference
+    DROP CONSTRAINT IF EXISTS ck_preference_quiet_hours_is_project_email;
+
+ALTER TABLE preference
+    ADD CONSTRAINT ck_preference_quiet_hours_is_project_email
+    CHECK (NOT bypass_quiet_hours OR (recipient_external_id IS NULL AND medium = 'email'));
+
+ALTER TABLE notification_delivery
+    DROP CONSTRAINT IF EXISTS notification_delivery_status_check;
+
+ALTER TABLE notification_delivery
+    ADD CONSTRAINT notification_delivery_status_check CHECK (status IN (
+        'pending','sending','sent','delivered','bounced','complained',
+        'failed','muted','no_contact','suppressed','quota_exceeded','rejected',
+        'expired','digested','deferred'
+    ));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Their tasks are still parked in Asynq and will send; they just stop saying why
+-- they have not yet.
+UPDATE notification_delivery SET status = 'pending' WHERE status = 'deferred';
+
+ALTER TABLE notification_delivery
+    DROP CONSTRAINT IF EXISTS notification_delivery_status_check;
+
+ALTER TABLE notification_delivery
+    ADD CONSTRAINT notification_delivery_status_check CHECK (status IN (
+        'pending','sending','sent','delivered','bounced','complained',
+        'failed','muted','no_contact','suppressed','quota_exceeded','rejected',
+        'expired','digested'
+    ));
+
+ALTER TABLE preference
+    DROP CONSTRAINT IF EXISTS ck_preference_quiet_hours_is_project_email;
+
+ALTER TABLE preference
+    DROP COLUMN IF EXISTS bypass_quiet_hours;
+
+ALTER TABLE recipient
+    DROP CONSTRAINT IF EXISTS ck_recipient_quiet_hours_pair;
+
+ALTER TABLE recipient
+    DROP COLUMN IF EXISTS quiet_hours_end,
+    DROP COLUMN IF EXISTS quiet_hours_start,
+    DROP COLUMN IF EXISTS timezone;
+-- +goose StatementEnd
@@ -0,0 +1,77 @@
+-- Frequency caps: a catalog entry can limit how many notifications one recipient
+-- gets for its target, on its medium, per window ("at most 5 per hour").
+--
+-- The cap lives on the project-level catalog row, because that row is already
+-- per (target, medium): capping in-app and email separately is just two rows
+-- with different numbers. A recipient row only toggles enabled, so it may not
+-- carry one.
+--
+-- The counting itself is NOT here. It is a fixed-window counter in Redis keyed
+-- by (project, recipient, target, medium, window start), which expires with the
+-- window — a per-send hot write that Postgres has no business absorbing. This
+-- migration only stores the limit.
+--
+-- A send over the cap is recorded as `throttled`: on the notification row for
+-- in-app, on the notification_delivery row for email. notification.status has no
+-- CHECK; notification_delivery.status does, so it is widened.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE preference
+    ADD COLUMN IF NOT EXISTS frequency_cap_limit INT
+        CHECK (frequency_cap_limit > 0),
+    ADD COLUMN IF NOT EXISTS frequency_cap_window TEXT
+        CHECK (frequency_cap_window IN ('minute', 'hour', 'day'));
+
+ALTER TABLE preference
+    DROP CONSTRAINT IF EXISTS ck_preference_frequency_cap_pair;
+
+ALTER TABLE preference
+    ADD CONSTRAINT ck_preference_frequency_cap_pair
+    CHECK ((frequency_cap_limit IS NULL) = (frequency_cap_window IS NULL));
+
+ALTER TABLE preference
+    DROP CONSTRAINT IF EXISTS ck_preference_frequency_cap_is_project;
+
+ALTER TABLE preference
+    ADD CONSTRAINT ck_preference_frequency_cap_is_project
+    CHECK (frequency_cap_limit IS NULL OR recipient_external_id IS NULL);
+
+ALTER TABLE notification_delivery
+    DROP CONSTRAINT IF EXISTS notification_delivery_status_check;
+
+ALTER TABLE notification_delivery
+    ADD CONSTRAINT notification_delivery_status_check CHECK (status IN (
+        'pending','sending','sent','delivered','bounced','complained',
+        'failed','muted','no_contact','suppressed','quota_exceeded','rejected',
+        'expired','digested','deferred','throttled'
+    ));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- A throttled email was never sent and never will be; `muted` is the nearest
+-- older status that says so without claiming a failure.
+UPDATE notification_delivery SET status = 'muted' WHERE status = 'throttled';
+UPDATE notification SET status = 'muted' WHERE status = 'throttled';
+
+ALTER TABLE notification_delivery
+    DROP CONSTRAINT IF EXISTS notification_delivery_status_check;
+
+ALTER TABLE notification_delivery
+    ADD CONSTRAINT notification_delivery_status_check CHECK (status IN (
+        'pending','sending','sent','delivered','bounced','complained',
+        'failed','muted','no_contact','suppressed','quota_exceeded','rejected',
+        'expired','digested','deferred'
+    ));
+
+ALTER TABLE preference
+    DROP CONSTRAINT IF EXISTS ck_preference_frequency_cap_is_project;
+
+ALTER TABLE preference
+    DROP CONSTRAINT IF EXISTS ck_preference_frequency_cap_pair;
+
+ALTER TABLE preference
+    DROP COLUMN IF EXISTS frequency_cap_window,
+    DROP COLUMN IF EXISTS frequency_cap_limit;
+-- +goose StatementEnd
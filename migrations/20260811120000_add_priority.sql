@@ -0,0 +1,33 @@
+-- Priority lanes: a send can ask to be `critical`, `normal` or `bulk`, and its
+-- background tasks are routed to a separate, weighted Asynq queue for that lane.
+-- Before this everything shared the one `default` queue, so the batches of a
+-- million-recipient broadcast could sit in front of a password-reset email.
+--
+-- The priority is stored, not only carried in the task payload, because work is
+-- enqueued again long after the send was accepted: a scheduled send fires at its
+-- send_at, a broadcast's prepare step enqueues its batches, and each batch
+-- enqueues its emails. Each of those re-reads the row.
+--
+-- Defaults match what the API assumes when a send names no priority: a direct
+-- notification is `normal` and a broadcast is `bulk`. Existing rows take the same
+-- values, which is also the lane their remaining work will run in.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notification
+    ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'normal'
+        CHECK (priority IN ('critical', 'normal', 'bulk'));
+
+ALTER TABLE broadcast
+    ADD COLUMN IF NOT EXISTS priority TEXT NOT NULL DEFAULT 'bulk'
+        CHECK (priority IN ('critical', 'normal', 'bulk'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE broadcast
+    DROP COLUMN IF EXISTS priority;
+
+ALTER TABLE notification
+    DROP COLUMN IF EXISTS priority;
+-- +goose StatementEnd
@@ -0,0 +1,52 @@
+-- Recall: the sender can pull a notification or broadcast back after it went
+-- out (POST /notifications/{id}/recall, POST /broadcasts/{id}/recall).
+--
+-- Deleting was the only tool before, and it is recipient-scoped — one call per
+-- inbox — so a wrong broadcast to 50k recipients was, in practice, permanent.
+-- A recall is a status change rather than a delete: the rows stay, so the
+-- console can still answer "what did we send, and what did we pull back?".
+--
+-- `recalled` lands on four tables:
+--   * notification          — hidden from the recipient's feed, read or not.
+--   * notification_delivery — an email still pending, deferred or retrying is
+--                             never sent; one the provider already accepted
+--                             keeps its status, because it is in an inbox now.
+--   * broadcast             — terminal, like cancelled.
+--   * broadcast_batch       — a batch that had not fanned out yet never will.
+--
+-- Only notification_delivery.status has a CHECK (notification, broadcast and
+-- broadcast_batch dropped or never had theirs), so only it is widened here.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notification_delivery
+    DROP CONSTRAINT IF EXISTS notification_delivery_status_check;
+
+ALTER TABLE notification_delivery
+    ADD CONSTRAINT notification_delivery_status_check CHECK (status IN (
+        'pending','sending','sent','delivered','bounced','complained',
+        'failed','muted','no_contact','suppressed','quota_exceeded','rejected',
+        'expired','digested','deferred','throttled','recalled'
+    ));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- The nearest older statuses that still say "withdrawn, not a failure" are
+-- `cancelled` for the rows that had one, and `muted` for a delivery. A batch has
+-- no such status, and a recalled one never ran, so it goes back to `failed`.
+UPDATE notification_delivery SET status = 'muted' WHERE status = 'recalled';
+UPDATE notification SET status = 'cancelled' WHERE status = 'recalled';
+UPDATE broadcast SET status = 'cancelled' WHERE status = 'recalled';
+UPDATE broadcast_batch SET status = 'failed' WHERE status = 'recalled';
+
+ALTER TABLE notification_delivery
+    DROP CONSTRAINT IF EXISTS notification_delivery_status_check;
+
+ALTER TABLE notification_delivery
+    ADD CONSTRAINT notification_delivery_status_check CHECK (status IN (
+        'pending','sending','sent','delivered','bounced','complained',
+        'failed','muted','no_contact','suppressed','quota_exceeded','rejected',
+        'expired','digested','deferred','throttled'
+    ));
+-- +goose StatementEnd
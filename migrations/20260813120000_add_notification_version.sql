@@ -0,0 +1,35 @@
+-- Editable notifications: PATCH /notifications/{id} replaces a delivered
+-- notification's payload, and PATCH /broadcasts/{id} does the same for every
+-- notification a broadcast wrote. The use case is aggregation — "Alice liked
+-- your post" becoming "Alice and 3 others liked your post" — which until now
+-- meant sending a second notification and leaving the first one stale.
+--
+-- `version` counts payload edits, starting at 1. It does two jobs:
+--   * Optimistic concurrency. A PATCH may carry the version it read; if the row
+--     has moved on since, the edit is refused with a 409 instead of silently
+--     overwriting someone else's.
+--   * A re-render signal. The recipient's feed returns it, so a client holding a
+--     cached notification can tell the content changed without diffing payloads.
+--
+-- broadcast.version is the broadcast's own edit count, checked by a broadcast
+-- PATCH. Each notification keeps its own counter, bumped alongside.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notification
+    ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1
+        CHECK (version > 0);
+
+ALTER TABLE broadcast
+    ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1
+        CHECK (version > 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE broadcast
+    DROP COLUMN IF EXISTS version;
+
+ALTER TABLE notification
+    DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- Localized content: a recipient carries a `locale`, and a send can carry
+-- `variants` of its content keyed by BCP 47 tag. Bodhveda picks the variant per
+-- recipient at delivery, so a caller serving five languages no longer resolves
+-- the locale itself before every send.
+--
+-- `recipient.locale` is a canonical BCP 47 tag ('pt-BR'); NULL means "no
+-- preference" and gets the send's default content. The API canonicalizes it, so
+-- the column holds exactly what variant keys are matched against.
+--
+-- `broadcast.variants` is the send's variants map as JSONB, keyed by the same
+-- canonical tags: {"pt-BR": {"payload": {...}, "email": {...}}, "pt": {...}}.
+-- It is stored on the row, like the email block, because a scheduled broadcast
+-- and a retried batch must be able to rebuild what each recipient gets from the
+-- database alone. NULL for a send without variants, which is still most of them.
+--
+-- Direct sends need no column: the variant is picked before the notification
+-- row is delivered, and the row stores the payload the recipient actually got.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE recipient
+    ADD COLUMN IF NOT EXISTS locale TEXT;
+
+ALTER TABLE broadcast
+    ADD COLUMN IF NOT EXISTS variants JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE broadcast
+    DROP COLUMN IF EXISTS variants;
+
+ALTER TABLE recipient
+    DROP COLUMN IF EXISTS locale;
+-- +goose StatementEnd
@@ -0,0 +1,61 @@
+-- +goose NO TRANSACTION
+
+-- Custom recipient data: a free-form `attributes` map ({"plan": "pro",
+-- "seats": 12}) and a `tags` set ({beta, vip}). Callers set them through the
+-- recipient endpoints; the console shows them on the recipient page and filters
+-- the recipient list by them. Audience targeting will build on the same columns.
+--
+-- `attributes` is a flat JSONB object. The API only admits scalar values and
+-- arrays of scalars, so equality on a key is a containment check
+-- (`attributes @> '{"plan": "pro"}'`), and one GIN index serves all of them.
+-- jsonb_path_ops rather than the default opclass: it is smaller and faster for
+-- @>, and @> is the only operator the filters use. It cannot serve `?` (key
+-- exists); add a second index if a "has key" filter ever ships.
+--
+-- `tags` is TEXT[] rather than a JSONB array so a tag filter reads as the plain
+-- `tags @> ARRAY['vip']`, served by the default GIN array opclass. The API
+-- lowercases, dedupes and sorts tags, so the column holds a set.
+--
+-- Both default to empty rather than NULL. "No attributes" and "no tags" are not
+-- unknowns, and a NOT NULL column keeps `@>` free of NULL handling.
+--
+-- ⚠️ `recipient` gets an insert on the send path (CreateIfNotExists for a new
+-- recipient), so these indexes add write cost there. It is small: a recipient
+-- created implicitly by a send has empty values, which index to a single entry
+-- each. Nor is either column rewritten in a hot loop: they change when the
+-- customer syncs its user data.
+--
+-- CONCURRENTLY (hence NO TRANSACTION) so building them never blocks sends.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE recipient
+    ADD COLUMN IF NOT EXISTS attributes JSONB NOT NULL DEFAULT '{}'
+        CHECK (jsonb_typeof(attributes) = 'object'),
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX CONCURRENTLY IF NOT EXISTS ix_recipient_attributes
+    ON recipient USING GIN (attributes jsonb_path_ops);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX CONCURRENTLY IF NOT EXISTS ix_recipient_tags
+    ON recipient USING GIN (tags);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX CONCURRENTLY IF EXISTS ix_recipient_tags;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP INDEX CONCURRENTLY IF EXISTS ix_recipient_attributes;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE recipient
+    DROP COLUMN IF EXISTS tags,
+    DROP COLUMN IF EXISTS attributes;
+-- +goose StatementEnd
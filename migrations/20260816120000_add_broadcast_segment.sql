@@ -0,0 +1,59 @@
+-- +goose NO TRANSACTION
+
+-- Segmented broadcasts: a broadcast send may carry an `audience` filter that
+-- narrows who it reaches using recipient data Bodhveda already stores (created_at,
+-- primary contacts, recent reads, external id prefix, tags and attributes),
+-- combined with all/any.
+--
+-- `broadcast.segment` is that filter as JSONB, in the API's shape. It is stored
+-- on the row for the same reason `variants` and the email block are: a scheduled
+-- broadcast resolves its audience at send_at, and must be able to rebuild it from
+-- the database alone. NULL for an unsegmented broadcast, which reaches everyone
+-- the preferences allow, exactly as before.
+--
+-- `broadcast.excluded_segment` joins the frozen audience breakdown
+-- (total_recipients, eligible_recipients, excluded_disabled,
+-- excluded_not_cataloged): recipients the segment left out. Nullable like its
+-- siblings, so a broadcast whose audience was recorded before this column existed
+-- reads as "not recorded" rather than a false zero.
+--
+-- Most conditions need no new index. The segment is evaluated inside
+-- prepare_batches' one eligibility scan of the project's recipients: the
+-- recipient columns are on the row being scanned, tags/attributes have their GIN
+-- indexes, and a contact condition is a probe on
+-- ux_recipient_contact_one_primary.
+--
+-- `read_within_days` is the exception. It asks "which recipients of this project
+-- read something since T", and nothing on `notification` answers that short of
+-- reading every row the project has: ix_notification_project_created is keyed on
+-- created_at, and an old notification can be read today. ix_notification_read is
+-- PARTIAL on read_at IS NOT NULL so unread rows (every insert on the send path)
+-- never touch it. The write cost falls on marking a notification read, once per
+-- row. ⚠️ If the read-state work ever moves read_at off this table, move this
+-- index with it.
+--
+-- CONCURRENTLY (hence NO TRANSACTION) so building it never blocks sends.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE broadcast
+    ADD COLUMN IF NOT EXISTS segment JSONB,
+    ADD COLUMN IF NOT EXISTS excluded_segment INT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX CONCURRENTLY IF NOT EXISTS ix_notification_read
+    ON notification (project_id, read_at)
+    WHERE read_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX CONCURRENTLY IF EXISTS ix_notification_read;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE broadcast
+    DROP COLUMN IF EXISTS excluded_segment,
+    DROP COLUMN IF EXISTS segment;
+-- +goose StatementEnd
@@ -0,0 +1,46 @@
+-- List-based broadcasts: a broadcast send may name its recipients outright
+-- (`recipient_ids`, or a CSV uploaded in the console) instead of reaching everyone
+-- the target's preferences allow. The ids are still intersected with those
+-- preferences at fan-out, so a list narrows a broadcast and never widens it.
+--
+-- `broadcast_recipient` holds the list, one row per id. Its own table rather than
+-- a TEXT[] on `broadcast`, because a list can run to 100,000 ids: every read of
+-- the broadcast row (the list page, the delivery tree, the prepare task payload)
+-- would otherwise carry it. No foreign key to `recipient` on purpose — an id that
+-- does not exist yet is legal, it is counted as unknown when the broadcast fans
+-- out, and a recipient created before a scheduled send_at is reached.
+--
+-- `broadcast.recipient_list_size` is the number of ids in that list, NULL for an
+-- ordinary target broadcast. It is what tells prepare_batches which audience to
+-- resolve, so it must never be NULL on a list broadcast — an empty list is 0, and
+-- 0 reaches nobody, not everybody.
+--
+-- `broadcast.excluded_unknown` joins the frozen audience breakdown: ids on the
+-- list with no recipient when the broadcast fanned out. Nullable like its siblings.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE broadcast
+    ADD COLUMN IF NOT EXISTS recipient_list_size INT,
+    ADD COLUMN IF NOT EXISTS excluded_unknown INT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS broadcast_recipient (
+        broadcast_id            INT NOT NULL REFERENCES broadcast(id) ON DELETE CASCADE,
+        recipient_external_id   VARCHAR(255) NOT NULL,
+
+        PRIMARY KEY (broadcast_id, recipient_external_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS broadcast_recipient;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE broadcast
+    DROP COLUMN IF EXISTS excluded_unknown,
+    DROP COLUMN IF EXISTS recipient_list_size;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- Cancelling an in-flight broadcast (POST /broadcasts/{id}/cancel) refunds the
+-- notifications it never wrote.
+--
+-- A broadcast is billed once, up front: prepare_batches consumes usage for its
+-- whole fan-out in the same transaction that writes its batches. Cancelling it
+-- part-way stops the batches that have not run yet, and those recipients were
+-- paid for but will never be reached. The refund is recorded the way usage is —
+-- a `usage_log` row, with a NEGATIVE amount — and taken off the aggregate of the
+-- period the broadcast was charged in. A row per refund keeps `usage_log` the
+-- append-only record of how the aggregate got to where it is.
+--
+-- `ck_usage_amount_pos` forbade that, so it becomes "not zero". Zero stays
+-- illegal: an empty consume or refund is always a caller bug (see the empty
+-- audience note in prepare_batches).
+--
+-- The batches a cancel stops become `cancelled`. broadcast_batch.status has no
+-- CHECK, so that needs nothing here.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE usage_log
+    DROP CONSTRAINT IF EXISTS ck_usage_amount_pos;
+
+ALTER TABLE usage_log
+    ADD CONSTRAINT ck_usage_amount_nonzero CHECK (amount <> 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Refund rows cannot survive the old constraint. Dropping them leaves the
+-- aggregates lower than the log says, which is what they were refunded to.
+DELETE FROM usage_log WHERE amount < 0;
+
+ALTER TABLE usage_log
+    DROP CONSTRAINT IF EXISTS ck_usage_amount_nonzero;
+
+ALTER TABLE usage_log
+    ADD CONSTRAINT ck_usage_amount_pos CHECK (amount > 0);
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- Retrying failed broadcast batches (POST /broadcasts/{id}/batches/retry).
+--
+-- A batch whose delivery task exhausts its Asynq retries is left `failed`, and
+-- since PendingCount counts every batch that has not succeeded, its broadcast
+-- stays `enqueued` for good. Nothing could move it: the batch's task is archived
+-- in Asynq under its stable id `broadcast-batch-{id}`, so enqueuing that id again
+-- is refused as a duplicate.
+--
+-- `retries` counts operator retries of the batch. Each one re-enqueues under
+-- `broadcast-batch-{id}-retry-{retries}`, a fresh id per round that is still
+-- stable within it, so a retry that is itself retried does not double-enqueue.
+-- `attempt` keeps meaning the Asynq attempt of the current round and is reset to
+-- 0 by a retry.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE broadcast_batch
+    ADD COLUMN IF NOT EXISTS retries INT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE broadcast_batch
+    DROP COLUMN IF EXISTS retries;
+-- +goose StatementEnd
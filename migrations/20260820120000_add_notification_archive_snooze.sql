@@ -0,0 +1,33 @@
+-- Archive and snooze: a recipient can clear their inbox without deleting
+-- anything, and put a notification off until later.
+--
+--   * archived_at   — set, the row leaves the inbox for the archived view
+--                     (GET /recipients/{id}/notifications?archived=true). It is
+--                     still the recipient's: it can be unarchived, and its
+--                     unread state is kept.
+--   * snoozed_until — until then, the row is in neither view nor any unread
+--                     count. Snoozing clears read_at, so it comes back unread.
+--                     It is kept after the snooze ends: the feed sorts on
+--                     COALESCE(snoozed_until, created_at), which is what moves
+--                     a woken notification to the top.
+--
+-- Nothing wakes a snooze. Both columns are read against now() at query time,
+-- the way expires_at is, so there is no job to run late or twice.
+--
+-- Not indexed. Both are filtered within one recipient's rows, which the feed
+-- already reads through the recipient predicate, and an index here would tax
+-- every send for a query that does not need it.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notification
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE notification
+    DROP COLUMN IF EXISTS snoozed_until,
+    DROP COLUMN IF EXISTS archived_at;
+-- +goose StatementEnd
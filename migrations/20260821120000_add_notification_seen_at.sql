@@ -0,0 +1,19 @@
+-- Seen: the recipient has had the notification in front of them — it was in
+-- the dropdown when they opened it — without necessarily opening or reading
+-- it. It is what a "new" badge clears, where read is what the unread count
+-- clears.
+--
+-- A third timestamp beside read_at and opened_at, and like them independent:
+-- setting one never sets another.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE notification
+    ADD COLUMN IF NOT EXISTS seen_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE notification
+    DROP COLUMN IF EXISTS seen_at;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- Index the snoozes, so the worker can find the ones that just ended.
+--
+-- A snooze ending writes nothing (see the archive/snooze migration), so an open
+-- inbox stream would never hear of the notification coming back. The worker
+-- announces them instead, once a minute, by reading the rows whose
+-- snoozed_until fell in the last minute across every project — a range this
+-- index serves.
+--
+-- Partial, like ix_notification_expires_at: only snoozed rows carry the column,
+-- so a plain send never touches the index.
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS ix_notification_snoozed_until
+    ON notification (snoozed_until)
+    WHERE snoozed_until IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS ix_notification_snoozed_until;
+-- +goose StatementEnd